@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"go.dot.industries/vx/internal/agent"
+	"go.dot.industries/vx/internal/changes"
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/direnv"
+	"go.dot.industries/vx/internal/resolver"
+	"go.dot.industries/vx/internal/token"
+	"go.dot.industries/vx/internal/vault"
+)
+
+// agentTimeout bounds a request to the agent, which may have to read Vault
+// before it answers.
+const agentTimeout = 30 * time.Second
+
+// agentSource resolves secrets for the agent of the project at rootDir. It
+// loads the config again for every request, so edits to a vx.toml apply
+// without restarting the daemon, and reads Vault only with the token the
+// daemon renews: the agent never logs in. The token is the one stored for
+// the [vault] address just loaded, which may differ from the one the daemon
+// started with.
+type agentSource struct {
+	rootDir string
+}
+
+// load reads the project's root config as loadConfig does.
+func (s agentSource) load() (*config.RootConfig, error) {
+	cfg, err := config.LoadRootConfig(rootConfigPath(s.rootDir))
+	if err != nil {
+		return nil, err
+	}
+	cfg.Vault.Namespace = cmp.Or(namespaceOverride(), cfg.Vault.Namespace)
+	return cfg, nil
+}
+
+// Key hashes the config files of workspace, the environment, and the token,
+// so a cached result is dropped when any of them changes.
+func (s agentSource) Key(workspace, env string) (string, error) {
+	cfg, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(cfg.Environments.Available, env) {
+		return "", fmt.Errorf("environment %q is not in available environments", env)
+	}
+	files, err := direnvWatchFiles(cfg, s.rootDir, workspace)
+	if err != nil {
+		return "", err
+	}
+	tok, err := token.ReadTokenFor(cfg.Vault.Address)
+	if err != nil {
+		return "", err
+	}
+	return direnv.Key(files, s.rootDir, workspace, env, cfg.Vault.Address, cfg.Vault.Namespace, tok)
+}
+
+// Resolve reads the secrets of workspace in env from the [vault] connection.
+// The result is kept for the [cache] ttl (whether or not the on-disk cache
+// is enabled), or the shortest cache_ttl override replacing it, unless
+// secrets were left out.
+func (s agentSource) Resolve(ctx context.Context, workspace, env string) (agent.Resolution, error) {
+	cfg, err := s.load()
+	if err != nil {
+		return agent.Resolution{}, err
+	}
+	merged, err := mergeWorkspaceConfig(cfg, s.rootDir, workspace, env)
+	if err != nil {
+		return agent.Resolution{}, err
+	}
+
+	groups := groupByVault(merged.Secrets)
+	if names := sortedVaultNames(groups); len(names) > 0 {
+		return agent.Resolution{}, fmt.Errorf("secrets mapped to [vaults] connections (%s) are not served by the agent", strings.Join(names, ", "))
+	}
+
+	tok, err := token.ReadTokenFor(cfg.Vault.Address)
+	if err != nil {
+		return agent.Resolution{}, err
+	}
+	client, err := vault.NewClientWithToken(cfg.Vault.Address, cfg.Vault.BasePath, tok, vaultClientOptions(cfg)...)
+	if err != nil {
+		return agent.Resolution{}, fmt.Errorf("creating vault client: %w", err)
+	}
+
+	if t := time.Duration(merged.Resolver.Timeout); t > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t)
+		defer cancel()
+	}
+	// A memo of its own: the shared one would keep values for the life of
+	// the daemon.
+	secrets, err := resolveWith(ctx, client, resolver.NewMemo(), nil, merged, groups[""])
+	if err != nil {
+		return agent.Resolution{}, err
+	}
+
+	ttl := merged.EntryCacheTTL(cmp.Or(time.Duration(cfg.Cache.TTL), defaultSecretCacheTTL))
+	if len(secrets) < len(merged.Secrets) {
+		ttl = 0
+	}
+	return agent.Resolution{Secrets: secrets, TTL: ttl}, nil
+}
+
+// agentHandler returns the handler answering requests for the secrets of
+// the project at rootDir.
+func agentHandler(rootDir string) http.Handler {
+	return agent.NewServer(agentSource{rootDir: rootDir}, rootDir, agent.WithChangedSince(latestChange)).Handler()
+}
+
+// latestChange returns the time of the last secret change the daemon
+// recorded, or the zero time.
+func latestChange() time.Time {
+	t, err := changes.Latest()
+	if err != nil {
+		log.Debug().Err(err).Msg("reading change journal")
+	}
+	return t
+}
+
+// agentSecrets asks the agent of a running daemon for the workspace's
+// secrets. ok is false when no agent serves this project, flags select
+// another Vault or token than the daemon's, or the agent could not resolve
+// them; vx exec then reads Vault itself.
+func agentSecrets(cfg *config.RootConfig, rootDir, workspace string, merged *config.MergedConfig) (map[string]string, bool) {
+	if flagExecRefresh || flagVaultAddr != "" || flagNamespace != "" || flagAuth != "" || selectedAuthMethod(cfg) == "token" {
+		return nil, false
+	}
+	if len(sortedVaultNames(groupByVault(merged.Secrets))) > 0 {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), agentTimeout)
+	defer cancel()
+	secrets, err := agent.NewClient(token.SocketPath()).Secrets(ctx, rootDir, workspace, merged.Environment)
+	if err != nil {
+		log.Debug().Err(err).Msg("not using the agent")
+		return nil, false
+	}
+	log.Debug().Int("secrets", len(secrets)).Msg("using secrets from the agent")
+	return secrets, true
+}
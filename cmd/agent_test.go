@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.dot.industries/vx/internal/token"
+)
+
+// kvServer serves dev/app with the given value to requests carrying tok.
+func kvServer(t *testing.T, tok, value string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != tok {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":["permission denied"]}`)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/dev/app" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":[]}`)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"data":{"key":%q},"metadata":{"version":1}}}`, value)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAgentSource_FollowsAddressChange(t *testing.T) {
+	dir := t.TempDir()
+	origDir := token.DefaultDir
+	token.DefaultDir = func() string { return dir }
+	t.Cleanup(func() { token.DefaultDir = origDir })
+
+	first := kvServer(t, "s.first", "one")
+	second := kvServer(t, "s.second", "two")
+	for addr, tok := range map[string]string{first.URL: "s.first", second.URL: "s.second"} {
+		if err := token.WriteTokenFor(addr, tok); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The daemon started with the first server.
+	token.UseAddress(first.URL)
+	t.Cleanup(func() { token.UseAddress("") })
+
+	rootDir := t.TempDir()
+	writeConfig := func(addr string) {
+		t.Helper()
+		cfg := fmt.Sprintf(`[vault]
+address = %q
+base_path = "secret"
+kv_version = 2
+
+[environments]
+default = "dev"
+available = ["dev"]
+
+[secrets]
+APP_KEY = "${env}/app/key"
+`, addr)
+		if err := os.WriteFile(filepath.Join(rootDir, "vx.toml"), []byte(cfg), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	src := agentSource{rootDir: rootDir}
+	resolve := func() (string, string) {
+		t.Helper()
+		key, err := src.Key("", "dev")
+		if err != nil {
+			t.Fatalf("Key() error = %v", err)
+		}
+		res, err := src.Resolve(context.Background(), "", "dev")
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		return key, res.Secrets["APP_KEY"]
+	}
+
+	writeConfig(first.URL)
+	firstKey, got := resolve()
+	if got != "one" {
+		t.Errorf("APP_KEY = %q from the first server, want %q", got, "one")
+	}
+
+	writeConfig(second.URL)
+	secondKey, got := resolve()
+	if got != "two" {
+		t.Errorf("APP_KEY = %q after the address changed, want %q", got, "two")
+	}
+	if firstKey == secondKey {
+		t.Error("Key() did not change with the address and its token")
+	}
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/vault"
+)
+
+var (
+	flagRotateRole        string
+	flagRotateOutput      string
+	flagRotateWriteFile   string
+	flagRotateDestroyOld  bool
+	flagRotateOldAccessor string
+	flagRotateMetadata    map[string]string
+)
+
+func init() {
+	rotateSecretIDCmd.Flags().StringVar(&flagRotateRole, "role", "", "AppRole role name (required)")
+	rotateSecretIDCmd.Flags().StringVar(&flagRotateOutput, "output", "text", "output format: text, env, json, github")
+	rotateSecretIDCmd.Flags().StringVar(&flagRotateWriteFile, "write-file", "", "also write the new secret-id to this file (mode 0600)")
+	rotateSecretIDCmd.Flags().BoolVar(&flagRotateDestroyOld, "destroy-old", false, "destroy the secret-id given by --secret-id/VX_SECRET_ID after rotating")
+	rotateSecretIDCmd.Flags().StringVar(&flagRotateOldAccessor, "old-accessor", "", "destroy the secret-id with this accessor after rotating")
+	rotateSecretIDCmd.Flags().StringToStringVar(&flagRotateMetadata, "metadata", nil, "metadata to attach to the new secret-id (key=value)")
+	_ = rotateSecretIDCmd.MarkFlagRequired("role")
+
+	approleCmd.AddCommand(rotateSecretIDCmd)
+	rootCmd.AddCommand(approleCmd)
+}
+
+var approleCmd = &cobra.Command{
+	Use:   "approle",
+	Short: "Manage AppRole machine credentials",
+}
+
+var rotateSecretIDCmd = &cobra.Command{
+	Use:   "rotate-secret-id",
+	Short: "Generate a new AppRole secret-id and optionally destroy the old one",
+	Long: `Generates a new secret-id for an AppRole role using the current Vault token,
+which needs write access to auth/approle/role/<role>/secret-id.
+
+When a role ID is available (--role-id or VX_ROLE_ID), the new secret-id is
+verified with a test login before anything is destroyed; if that login fails
+the new secret-id is revoked again and the command exits with an error.
+
+The new secret-id is printed in the chosen --output format:
+
+  text    human-readable secret-id, accessor, and TTL
+  env     VX_SECRET_ID=<secret-id>
+  json    {"secret_id": ..., "secret_id_accessor": ..., "ttl_seconds": ...}
+  github  masks the value and appends secret_id/secret_id_accessor to
+          $GITHUB_OUTPUT for later workflow steps
+
+Old credentials are destroyed last, with --destroy-old (the secret-id from
+--secret-id or VX_SECRET_ID) or --old-accessor.`,
+	Args: cobra.NoArgs,
+	RunE: runRotateSecretID,
+}
+
+func runRotateSecretID(cmd *cobra.Command, args []string) error {
+	switch flagRotateOutput {
+	case "text", "env", "json", "github":
+	default:
+		return fmt.Errorf("unsupported output %q (use text, env, json, or github)", flagRotateOutput)
+	}
+
+	roleID, oldSecretID := appRoleCredentials()
+	if flagRotateDestroyOld && oldSecretID == "" {
+		return fmt.Errorf("--destroy-old requires the current secret-id via --secret-id or VX_SECRET_ID")
+	}
+
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := authenticatedClient(cfg, resolveEnv(cfg))
+	if err != nil {
+		return err
+	}
+
+	newID, err := client.GenerateSecretID(flagRotateRole, flagRotateMetadata)
+	if err != nil {
+		return err
+	}
+	log.Info().Str("role", flagRotateRole).Str("accessor", newID.Accessor).Msg("generated new secret-id")
+
+	if roleID != "" {
+		if err := verifySecretID(cfg, roleID, newID.SecretID); err != nil {
+			if revokeErr := client.DestroySecretIDAccessor(flagRotateRole, newID.Accessor); revokeErr != nil {
+				log.Warn().Err(revokeErr).Str("accessor", newID.Accessor).Msg("failed to revoke unverified secret-id")
+			}
+			return fmt.Errorf("new secret-id failed test login (revoked): %w", err)
+		}
+		log.Info().Msg("verified new secret-id with a test login")
+	} else {
+		log.Warn().Msg("no role ID available (--role-id or VX_ROLE_ID); skipping test login")
+	}
+
+	if err := printSecretID(newID); err != nil {
+		return err
+	}
+
+	if flagRotateWriteFile != "" {
+		if err := os.WriteFile(flagRotateWriteFile, []byte(newID.SecretID+"\n"), 0600); err != nil {
+			return fmt.Errorf("writing %s: %w", flagRotateWriteFile, err)
+		}
+		log.Info().Str("path", flagRotateWriteFile).Msg("wrote new secret-id")
+	}
+
+	if flagRotateDestroyOld {
+		if err := client.DestroySecretID(flagRotateRole, oldSecretID); err != nil {
+			return err
+		}
+		log.Info().Msg("destroyed old secret-id")
+	}
+
+	if flagRotateOldAccessor != "" {
+		if err := client.DestroySecretIDAccessor(flagRotateRole, flagRotateOldAccessor); err != nil {
+			return err
+		}
+		log.Info().Str("accessor", flagRotateOldAccessor).Msg("destroyed old secret-id")
+	}
+
+	return nil
+}
+
+// verifySecretID performs a throwaway AppRole login with the new secret-id on
+// a separate client, so the caller's token is left untouched.
+func verifySecretID(cfg *config.RootConfig, roleID, secretID string) error {
+	addr := cfg.Vault.Address
+	if flagVaultAddr != "" {
+		addr = flagVaultAddr
+	}
+
+	probe, err := vault.NewClient(addr, cfg.Vault.BasePath, vaultClientOptions(cfg)...)
+	if err != nil {
+		return fmt.Errorf("creating vault client: %w", err)
+	}
+
+	if _, err := vault.AppRoleAuth(probe, roleID, secretID); err != nil {
+		return err
+	}
+
+	// The probe token is not needed; revoke it so it doesn't linger.
+	if err := probe.RevokeSelf(); err != nil {
+		log.Debug().Err(err).Msg("failed to revoke test login token")
+	}
+	return nil
+}
+
+// printSecretID writes the new secret-id to stdout (or $GITHUB_OUTPUT) in the
+// format selected by --output.
+func printSecretID(id *vault.SecretID) error {
+	switch flagRotateOutput {
+	case "env":
+		fmt.Printf("VX_SECRET_ID=%s\n", id.SecretID)
+
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]any{
+			"secret_id":          id.SecretID,
+			"secret_id_accessor": id.Accessor,
+			"ttl_seconds":        int64(id.TTL.Seconds()),
+		})
+
+	case "github":
+		// Mask first so the value never appears in the job log.
+		fmt.Printf("::add-mask::%s\n", id.SecretID)
+
+		path := os.Getenv("GITHUB_OUTPUT")
+		if path == "" {
+			return fmt.Errorf("--output github requires GITHUB_OUTPUT to be set")
+		}
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("opening GITHUB_OUTPUT: %w", err)
+		}
+		defer f.Close()
+		if _, err := fmt.Fprintf(f, "secret_id=%s\nsecret_id_accessor=%s\n", id.SecretID, id.Accessor); err != nil {
+			return fmt.Errorf("writing GITHUB_OUTPUT: %w", err)
+		}
+
+	default:
+		fmt.Printf("secret_id:          %s\n", id.SecretID)
+		fmt.Printf("secret_id_accessor: %s\n", id.Accessor)
+		if id.TTL > 0 {
+			fmt.Printf("ttl:                %s\n", id.TTL)
+		} else {
+			fmt.Printf("ttl:                never expires\n")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/bootstrap"
+)
+
+// maxBundleSize bounds how much is read from a bootstrap file or URL.
+const maxBundleSize = 1 << 20
+
+var (
+	flagBootstrapPassphraseEnv string
+	flagBootstrapOutput        string
+	flagBootstrapNote          string
+	flagBootstrapDir           string
+	flagBootstrapForce         bool
+	flagBootstrapNoLogin       bool
+)
+
+func init() {
+	bootstrapCmd.PersistentFlags().StringVar(&flagBootstrapPassphraseEnv, "passphrase-env", "", "read the bundle passphrase from this environment variable instead of prompting")
+	bootstrapCmd.Flags().StringVar(&flagBootstrapDir, "dir", ".", "directory to write vx.toml into")
+	bootstrapCmd.Flags().BoolVar(&flagBootstrapForce, "force", false, "replace an existing vx.toml with different contents")
+	bootstrapCmd.Flags().BoolVar(&flagBootstrapNoLogin, "no-login", false, "only write vx.toml; don't log in")
+
+	bootstrapCreateCmd.Flags().StringVarP(&flagBootstrapOutput, "output", "o", "", "write the link to this file (mode 0600) instead of stdout")
+	bootstrapCreateCmd.Flags().StringVar(&flagBootstrapNote, "note", "", "message shown to whoever applies the bundle")
+
+	bootstrapCmd.AddCommand(bootstrapCreateCmd)
+	rootCmd.AddCommand(bootstrapCmd)
+}
+
+var bootstrapCmd = &cobra.Command{
+	Use:   "bootstrap <link|file|url>",
+	Short: "Set up vx from a team bootstrap bundle",
+	Long: `Applies an encrypted bootstrap bundle created with "vx bootstrap create":
+decrypts it with the shared passphrase, shows where it will connect, writes
+vx.toml, and starts "vx login".
+
+The bundle can be given as a vx://bootstrap/... link, a file containing one,
+or an http(s) URL serving one.
+
+  vx bootstrap vx://bootstrap/AQxk...
+  vx bootstrap https://wiki.example.com/vx-bundle.txt`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBootstrap,
+}
+
+var bootstrapCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Package the root vx.toml into an encrypted bootstrap link",
+	Long: `Encrypts the root vx.toml together with connection hints (Vault address,
+auth method, mount, and role) into a vx://bootstrap/... link. The link is
+protected by a passphrase: share the two through different channels.
+
+The bundle is authenticated, so a link that was modified in transit will not
+open.`,
+	Args: cobra.NoArgs,
+	RunE: runBootstrapCreate,
+}
+
+func runBootstrapCreate(cmd *cobra.Command, args []string) error {
+	_, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(rootConfigPath(rootDir))
+	if err != nil {
+		return fmt.Errorf("reading root config: %w", err)
+	}
+
+	bundle, err := bootstrap.New(data, flagBootstrapNote)
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := readPassphrase(flagBootstrapPassphraseEnv, "Bundle passphrase: ", true)
+	if err != nil {
+		return err
+	}
+
+	link, err := bootstrap.Seal(bundle, passphrase)
+	if err != nil {
+		return err
+	}
+
+	if flagBootstrapOutput == "" {
+		fmt.Println(link)
+		return nil
+	}
+
+	if err := os.WriteFile(flagBootstrapOutput, []byte(link+"\n"), 0600); err != nil {
+		return fmt.Errorf("writing %s: %w", flagBootstrapOutput, err)
+	}
+	log.Info().Str("path", flagBootstrapOutput).Msg("wrote bootstrap bundle")
+	return nil
+}
+
+func runBootstrap(cmd *cobra.Command, args []string) error {
+	link, err := readBundleSource(args[0])
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := readPassphrase(flagBootstrapPassphraseEnv, "Bundle passphrase: ", false)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := bootstrap.Open(link, passphrase)
+	if err != nil {
+		return err
+	}
+
+	printBootstrapHints(bundle)
+
+	path, written, err := bootstrap.Apply(bundle, flagBootstrapDir, flagBootstrapForce)
+	if err != nil {
+		return err
+	}
+	if written {
+		fmt.Printf("\nwrote %s\n", path)
+	} else {
+		fmt.Printf("\n%s is already up to date\n", path)
+	}
+
+	if flagBootstrapNoLogin {
+		return nil
+	}
+
+	switch bundle.Hints.AuthMethod {
+	case "oidc":
+		flagConfigDir = path
+		return runLogin(cmd, nil)
+	case "approle":
+		fmt.Println("\nThis project uses AppRole: set VX_ROLE_ID and VX_SECRET_ID, then run any vx command.")
+	default:
+		fmt.Println("\nRun `vx login` to authenticate.")
+	}
+	return nil
+}
+
+// readBundleSource returns the link given directly, read from a file, or
+// fetched from an http(s) URL.
+func readBundleSource(source string) (string, error) {
+	if strings.HasPrefix(source, bootstrap.LinkPrefix) {
+		return source, nil
+	}
+
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		client := &http.Client{Timeout: 30 * time.Second}
+		resp, err := client.Get(source)
+		if err != nil {
+			return "", fmt.Errorf("fetching bundle: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("fetching bundle: %s", resp.Status)
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxBundleSize))
+		if err != nil {
+			return "", fmt.Errorf("fetching bundle: %w", err)
+		}
+		return string(data), nil
+	}
+
+	f, err := os.Open(source)
+	if err != nil {
+		return "", fmt.Errorf("reading bundle: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxBundleSize))
+	if err != nil {
+		return "", fmt.Errorf("reading bundle: %w", err)
+	}
+	return string(data), nil
+}
+
+// readPassphrase returns a passphrase from the environment variable envVar
+// (set by --passphrase-env) or a terminal prompt. With confirm the prompt
+// asks twice, for passphrases that encrypt something new.
+func readPassphrase(envVar string, prompt string, confirm bool) (string, error) {
+	if envVar != "" {
+		p := os.Getenv(envVar)
+		if p == "" {
+			return "", fmt.Errorf("%s is empty or not set", envVar)
+		}
+		return p, nil
+	}
+
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", fmt.Errorf("no terminal to prompt for the passphrase; use --passphrase-env")
+	}
+
+	p, err := promptSecret(prompt)
+	if err != nil {
+		return "", err
+	}
+	if p == "" {
+		return "", fmt.Errorf("a passphrase is required")
+	}
+
+	if confirm {
+		again, err := promptSecret("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != p {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+	return p, nil
+}
+
+// promptSecret reads a line from the terminal without echoing it.
+func promptSecret(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	b, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("reading from the terminal: %w", err)
+	}
+	return string(b), nil
+}
+
+// printBootstrapHints shows where the bundle will connect before anything is
+// written.
+func printBootstrapHints(b *bootstrap.Bundle) {
+	h := b.Hints
+	fmt.Printf("Vault:       %s\n", h.Address)
+
+	auth := h.AuthMethod
+	if h.AuthMount != "" {
+		auth += " (auth/" + h.AuthMount + ")"
+	}
+	if h.AuthRole != "" {
+		auth += ", role " + h.AuthRole
+	}
+	fmt.Printf("Auth:        %s\n", auth)
+	fmt.Printf("Environment: %s\n", h.Environment)
+	if !b.CreatedAt.IsZero() {
+		fmt.Printf("Created:     %s\n", b.CreatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	if h.Note != "" {
+		fmt.Printf("\n%s\n", h.Note)
+	}
+}
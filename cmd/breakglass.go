@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/breakglass"
+	"go.dot.industries/vx/internal/config"
+)
+
+var flagBreakGlassEnvs []string
+
+func init() {
+	breakGlassSealCmd.Flags().StringSliceVar(&flagBreakGlassEnvs, "environments", nil, "environments to include (default: all available)")
+	breakGlassCmd.AddCommand(breakGlassSealCmd)
+	rootCmd.AddCommand(breakGlassCmd)
+}
+
+var breakGlassCmd = &cobra.Command{
+	Use:   "break-glass",
+	Short: "Manage the encrypted fallback secrets file",
+	Long: `An encrypted vx.secrets.age file, checked into the repository, can stand in
+for Vault during an outage:
+
+  vx exec --break-glass -- ./deploy.sh
+
+It is decrypted with the age identity in $VX_BREAK_GLASS_IDENTITY (or with
+gpg for a .gpg/.asc file), must hold a value for every mapping, and each use
+is appended to ~/.vx/break-glass.log before any value is handed out.
+
+Configure the file and who can decrypt it in the root vx.toml:
+
+  [break_glass]
+  file = "vx.secrets.age"
+  recipients = ["age1...", "age1..."]`,
+}
+
+var breakGlassSealCmd = &cobra.Command{
+	Use:   "seal",
+	Short: "Resolve every mapping from Vault and write the encrypted fallback file",
+	Long: `Resolves the mappings of the root vx.toml and every workspace in each
+environment and writes the values, encrypted to [break_glass] recipients, to
+the fallback file. Re-run it after rotating secrets, and commit the result.`,
+	Args: cobra.NoArgs,
+	RunE: runBreakGlassSeal,
+}
+
+func runBreakGlassSeal(cmd *cobra.Command, args []string) error {
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	envs := flagBreakGlassEnvs
+	if len(envs) == 0 {
+		envs = cfg.Environments.Available
+	}
+
+	values := make(map[string]string)
+	for _, env := range envs {
+		if err := sealEnvironment(cfg, rootDir, env, values); err != nil {
+			return fmt.Errorf("%s: %w", env, err)
+		}
+	}
+
+	path := breakglass.Path(rootDir, cfg.BreakGlass.File)
+	c := breakglass.CipherFor(path, cfg.BreakGlass.Recipients, "")
+	if err := breakglass.Save(path, values, time.Now(), c); err != nil {
+		return err
+	}
+
+	fmt.Printf("Sealed %d value(s) for %d environment(s) in %s\n", len(values), len(envs), path)
+	return nil
+}
+
+// sealEnvironment adds the resolved values of the root config and every
+// workspace in env to values, keyed by Vault path.
+func sealEnvironment(cfg *config.RootConfig, rootDir, env string, values map[string]string) error {
+	client, err := authenticatedClient(cfg, env)
+	if err != nil {
+		return err
+	}
+
+	workspaces := append([]string{""}, cfg.Workspaces...)
+	for _, ws := range workspaces {
+		var wsCfg *config.WorkspaceConfig
+		if ws != "" {
+			wsCfg, err = config.LoadWorkspaceConfig(filepath.Join(rootDir, ws))
+			if err != nil {
+				return err
+			}
+		}
+
+		merged, err := config.Merge(cfg, wsCfg, env)
+		if err != nil {
+			return err
+		}
+
+		secrets, err := resolveSecrets(client, merged)
+		if err != nil {
+			return err
+		}
+		for envVar, v := range secrets {
+			values[breakglass.Key(merged.Secrets[envVar], merged.PathEnv)] = v
+		}
+	}
+	return nil
+}
+
+// breakGlassSecrets serves the mappings from the fallback file instead of
+// Vault. Every mapping must be present, and the use is audited before any
+// value is returned.
+func breakGlassSecrets(cfg *config.RootConfig, rootDir, workspace string, merged *config.MergedConfig) (map[string]string, error) {
+	path := breakglass.Path(rootDir, cfg.BreakGlass.File)
+	c := breakglass.CipherFor(path, nil, os.Getenv(breakglass.IdentityEnv))
+
+	f, err := breakglass.Load(path, c)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, missing := f.Resolve(merged.Secrets, merged.PathEnv)
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("break-glass file has no value for %v; re-seal it with \"vx break-glass seal\"", missing)
+	}
+
+	entry := breakglass.AuditEntry{
+		Time:        time.Now().UTC(),
+		File:        path,
+		Environment: merged.Environment,
+		Workspace:   workspace,
+		Command:     os.Args,
+		Keys:        sortedKeys(secrets),
+	}
+	if u, err := user.Current(); err == nil {
+		entry.User = u.Username
+	}
+	entry.Host, _ = os.Hostname()
+
+	if err := breakglass.Audit(entry); err != nil {
+		return nil, fmt.Errorf("refusing break-glass without an audit record: %w", err)
+	}
+
+	log.Warn().
+		Str("file", path).
+		Str("sealed_at", f.CreatedAt.Format(time.RFC3339)).
+		Int("secrets", len(secrets)).
+		Msg("BREAK-GLASS: using fallback secrets instead of Vault")
+	return secrets, nil
+}
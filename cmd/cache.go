@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/changes"
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/direnv"
+	"go.dot.industries/vx/internal/secretcache"
+	"go.dot.industries/vx/internal/token"
+)
+
+// defaultSecretCacheTTL is how long vx exec reuses cached values when
+// [cache] ttl is not set.
+const defaultSecretCacheTTL = 10 * time.Minute
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the on-disk cache of resolved secrets",
+	Long: `With [cache] enabled in the root vx.toml, vx exec keeps the values it
+resolves in ~/.vx/cache, encrypted with a key derived from your Vault token,
+and reuses them for [cache] ttl (default 10m) instead of reading Vault:
+
+  [cache]
+  enabled = true
+  ttl = "30m"
+
+Editing a vx.toml, switching workspace or environment, logging in again, or
+a change reported by the daemon's event subscription starts a fresh entry.
+vx exec --refresh reads Vault anyway and updates the cache.`,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cached value",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheClear,
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	n, err := secretcache.Clear(secretCacheDir())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("removed %d cached entry(s) from %s\n", n, secretCacheDir())
+	return nil
+}
+
+// secretCacheDir returns where vx exec caches resolved values (~/.vx/cache).
+func secretCacheDir() string {
+	return filepath.Join(token.DefaultDir(), "cache")
+}
+
+// secretCacheTTL returns how long vx exec may reuse cached values for the
+// workspace: zero when [cache] is off, otherwise the shortest lifetime among
+// the mapped secrets, where a cache_ttl override replaces the [cache] ttl.
+func secretCacheTTL(cfg *config.RootConfig, merged *config.MergedConfig) time.Duration {
+	if !cfg.Cache.Enabled {
+		return 0
+	}
+	return merged.EntryCacheTTL(globalCacheTTL(cfg.Cache))
+}
+
+// globalCacheTTL returns how long secrets without a cache_ttl override are
+// cached: zero when [cache] is off, otherwise its ttl.
+func globalCacheTTL(c config.CacheConfig) time.Duration {
+	if !c.Enabled {
+		return 0
+	}
+	return cmp.Or(time.Duration(c.TTL), defaultSecretCacheTTL)
+}
+
+// cacheToken returns the Vault token the cache is encrypted with, read
+// locally without contacting Vault.
+func cacheToken(cfg *config.RootConfig) (string, error) {
+	if selectedAuthMethod(cfg) == "token" {
+		return externalToken(cfg)
+	}
+	return token.ReadToken()
+}
+
+// cachedSecrets returns the workspace's secrets from the on-disk cache when
+// it is enabled and holds a fresh entry, and otherwise calls resolve and
+// caches the result. Vault is not contacted at all on a hit, so commands
+// start even while it is unreachable.
+func cachedSecrets(cfg *config.RootConfig, rootDir, workspace string, merged *config.MergedConfig, resolve func() (map[string]string, error)) (map[string]string, error) {
+	ttl := secretCacheTTL(cfg, merged)
+	if ttl <= 0 {
+		return resolve()
+	}
+
+	files, err := direnvWatchFiles(cfg, rootDir, workspace)
+	if err != nil {
+		return nil, err
+	}
+	addr := cfg.Vault.Address
+	if flagVaultAddr != "" {
+		addr = flagVaultAddr
+	}
+	key, err := direnv.Key(files, rootDir, workspace, merged.Environment, addr)
+	if err != nil {
+		return nil, err
+	}
+	cache := secretcache.New(secretCacheDir(), ttl)
+
+	if !flagExecRefresh {
+		if tok, err := cacheToken(cfg); err == nil {
+			// Values cached before the daemon last saw a secret change are stale.
+			changed, err := changes.Latest()
+			if err != nil {
+				log.Debug().Err(err).Msg("reading change journal")
+			}
+			if values, ok := cache.Load(key, tok, changed); ok {
+				log.Debug().Int("secrets", len(values)).Msg("using cached secrets")
+				return values, nil
+			}
+		}
+	}
+
+	secrets, err := resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	// A resolution that left secrets out (on_error) is not cached, so the
+	// next run tries Vault again rather than reusing the gap for the TTL.
+	if len(secrets) < len(merged.Secrets) {
+		log.Debug().Msg("not caching a partial resolution")
+		return secrets, nil
+	}
+
+	// Read the token again: resolving may have logged in.
+	tok, err := cacheToken(cfg)
+	if err != nil {
+		log.Debug().Err(err).Msg("no token to encrypt the secret cache with")
+		return secrets, nil
+	}
+	if err := cache.Store(key, tok, secrets); err != nil {
+		log.Warn().Err(err).Msg("failed to cache secrets")
+	}
+	return secrets, nil
+}
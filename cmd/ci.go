@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/ci"
+)
+
+var (
+	flagCIGitHub   bool
+	flagCIGitLab   bool
+	flagCICircleCI bool
+	flagCIOutput   string
+)
+
+func init() {
+	ciCmd.Flags().BoolVar(&flagCIGitHub, "github", false, "mask the values and append them to $GITHUB_ENV")
+	ciCmd.Flags().BoolVar(&flagCIGitLab, "gitlab", false, "write a GitLab dotenv report")
+	ciCmd.Flags().BoolVar(&flagCICircleCI, "circleci", false, "append export statements to $BASH_ENV")
+	ciCmd.Flags().StringVarP(&flagCIOutput, "output", "o", "vx.env", "dotenv report to write for --gitlab")
+	ciCmd.MarkFlagsMutuallyExclusive("github", "gitlab", "circleci")
+	rootCmd.AddCommand(ciCmd)
+}
+
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Hand resolved values to the CI provider's later steps",
+	Long: `Resolves the workspace's secrets and defaults and passes them to the
+following steps of a CI job the way the provider expects. Without a provider
+flag, the one running the job is detected.
+
+--github masks every value with ::add-mask:: and appends it to $GITHUB_ENV.
+
+--gitlab writes a dotenv report (default vx.env, mode 0600) for later jobs:
+
+  script:
+    - vx ci --gitlab -e production -w api
+  artifacts:
+    reports:
+      dotenv: vx.env
+    expire_in: 1 hour
+
+GitLab does not mask variables from a dotenv report, accepts at most 20 of
+them by default, and rejects multi-line values.
+
+--circleci appends export statements to $BASH_ENV, which CircleCI sources
+before each later step. CircleCI only masks project and context variables,
+so these values show up in the output of any step that prints them.`,
+	Args: cobra.NoArgs,
+	RunE: runCI,
+}
+
+func runCI(cmd *cobra.Command, args []string) error {
+	provider := ci.Detect(os.Getenv)
+	switch {
+	case flagCIGitHub:
+		provider = ci.GitHub
+	case flagCIGitLab:
+		provider = ci.GitLab
+	case flagCICircleCI:
+		provider = ci.CircleCI
+	}
+	if provider == "" {
+		return fmt.Errorf("no CI provider detected; pass --github, --gitlab, or --circleci")
+	}
+
+	// Check where the values go before reading Vault.
+	path := flagCIOutput
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if envFile := provider.EnvFile(); envFile != "" {
+		path = os.Getenv(envFile)
+		if path == "" {
+			return fmt.Errorf("--%s requires %s to be set", provider, envFile)
+		}
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	env := resolveEnv(cfg)
+
+	workspace, err := detectWorkspace(cfg, rootDir, []string{})
+	if err != nil {
+		return err
+	}
+
+	merged, err := mergeForWorkspace(cfg, rootDir, workspace, env)
+	if err != nil {
+		return err
+	}
+
+	values, err := resolveAll(cfg, merged)
+	if err != nil {
+		return err
+	}
+
+	if provider == ci.GitHub {
+		// Mask first so no value reaches the job log unmasked.
+		for _, mask := range ci.Masks(values) {
+			fmt.Println(mask)
+		}
+	}
+
+	f, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", path, err)
+	}
+	if err := ci.Render(f, provider, values); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	if provider == ci.GitLab && len(values) > ci.GitLabMaxVariables {
+		log.Warn().Int("variables", len(values)).
+			Msgf("GitLab accepts %d dotenv variables by default; the report may be rejected", ci.GitLabMaxVariables)
+	}
+
+	log.Info().Str("provider", string(provider)).Str("path", path).Int("variables", len(values)).Msg("CI variables written")
+	return nil
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/upgrade"
+)
+
+var flagConfigUpgradeWrite bool
+
+func init() {
+	configUpgradeCmd.Flags().BoolVar(&flagConfigUpgradeWrite, "write", false, "rewrite the files (default: dry-run)")
+	configCmd.AddCommand(configUpgradeCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Maintain vx.toml files",
+}
+
+var configUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Migrate vx.toml files to the current format version",
+	Long: `Upgrades the root vx.toml and every workspace vx.toml written for an older
+format version, recorded in their top-level version key, to the version this
+vx understands. Files without a version predate versioning and count as
+version 0.
+
+By default the changes are shown as a diff. With --write each file is
+rewritten in place, keeping comments and layout, and its previous contents
+are saved next to it as vx.toml.v<N>.bak.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigUpgrade,
+}
+
+func runConfigUpgrade(cmd *cobra.Command, args []string) error {
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	wsPaths := make([]string, len(cfg.Workspaces))
+	for i, ws := range cfg.Workspaces {
+		wsPaths[i] = filepath.Join(rootDir, ws)
+	}
+
+	files, err := upgrade.Plan(rootConfigPath(rootDir), wsPaths)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Printf("all vx.toml files are at version %d\n", config.CurrentVersion)
+		return nil
+	}
+
+	if !flagConfigUpgradeWrite {
+		fmt.Println("# Dry run — use --write to apply")
+		fmt.Println()
+	}
+	for _, f := range files {
+		rel, err := filepath.Rel(rootDir, f.Path)
+		if err != nil {
+			rel = f.Path
+		}
+		fmt.Printf("%s: version %d -> %d\n", rel, f.From, config.CurrentVersion)
+		for _, m := range f.Applied {
+			fmt.Printf("  - %s\n", m.Description)
+		}
+		fmt.Print(f.Diff(rel))
+		fmt.Println()
+	}
+
+	if !flagConfigUpgradeWrite {
+		return nil
+	}
+
+	if err := upgrade.Write(files); err != nil {
+		return err
+	}
+	fmt.Printf("upgraded %d file(s); backups saved as *.bak\n", len(files))
+	return nil
+}
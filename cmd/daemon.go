@@ -1,29 +1,92 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
+	"github.com/charmbracelet/x/term"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
+	"go.dot.industries/vx/internal/agent"
+	"go.dot.industries/vx/internal/changes"
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/service"
 	"go.dot.industries/vx/internal/token"
+	"go.dot.industries/vx/internal/vault"
+)
+
+const (
+	// eventsRetryDelay is how long the event subscriber waits before
+	// reconnecting after the stream fails.
+	eventsRetryDelay = 30 * time.Second
+	// daemonStatusTimeout bounds asking the running daemon for its status.
+	daemonStatusTimeout = 2 * time.Second
+)
+
+var (
+	flagDaemonAgent   bool
+	flagDaemonService bool
 )
 
 func init() {
+	daemonStartCmd.Flags().BoolVar(&flagDaemonAgent, "agent", false, "also serve resolved secrets to vx exec and other tools over ~/.vx/daemon.sock")
+	daemonStartCmd.Flags().BoolVar(&flagDaemonService, "service", false, "started by the service manager (set by vx daemon install)")
+	daemonStartCmd.Flags().MarkHidden("service")
+	daemonInstallCmd.Flags().BoolVar(&flagDaemonAgent, "agent", false, "start the daemon with --agent")
 	rootCmd.AddCommand(daemonCmd)
 	daemonCmd.AddCommand(daemonStartCmd)
 	daemonCmd.AddCommand(daemonStopCmd)
 	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonInstallCmd)
+	daemonCmd.AddCommand(daemonUninstallCmd)
 }
 
 var daemonCmd = &cobra.Command{
 	Use:   "daemon",
 	Short: "Manage the token renewal daemon",
-	Long:  `The daemon automatically renews your Vault token before it expires.`,
+	Long: `The daemon automatically renews your Vault token before it expires,
+along with the tokens of the other Vault servers you have logged in to. It
+logs to ~/.vx/daemon.log as JSON lines, rotated at 5 MB with three earlier
+files kept.
+
+"vx daemon status" asks the running daemon over ~/.vx/daemon.sock for the
+token's TTL, the renewals so far, the next check, and the last error. When
+the daemon is not running, it shows the last error from the log instead.
+
+With events = true under [vault], it also subscribes to Vault's event
+stream (Vault 1.16+) and records changes to secrets under base_path in
+~/.vx/changes.jsonl. The TUI reports those changes as they happen, and
+"vx direnv export" stops serving values cached before the latest one.
+
+With --agent, the daemon also serves the secrets of the project it was
+started in over that socket, which is accessible to you only.
+vx exec asks it first and reads Vault itself only when the agent is not
+running or cannot help, so repeated runs start without a Vault round trip.
+Results are kept in memory for the [cache] ttl (default 10m) and dropped
+when a vx.toml is edited, you log in again, or a change is reported. Other
+tools can query it too:
+
+  curl --unix-socket ~/.vx/daemon.sock 'http://vx/v1/secrets?workspace=web&env=dev'
+
+The agent only uses the token the daemon renews; secrets mapped to [vaults]
+connections are always read by vx exec itself.
+
+"vx daemon install" registers the daemon with your login session's service
+manager, so it starts when you log in and is restarted if it fails instead
+of dying with the terminal that started it: a launchd agent on macOS, a
+systemd user unit on Linux, and a Scheduled Task on Windows.`,
 }
 
 var daemonStartCmd = &cobra.Command{
@@ -47,26 +110,101 @@ var daemonStatusCmd = &cobra.Command{
 	RunE:  runDaemonStatus,
 }
 
+var daemonInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Start the daemon at login and restart it when it fails",
+	Long: `Registers the token renewal daemon with the platform's service manager for
+the project found from the current directory:
+
+  macOS    launchd agent ~/Library/LaunchAgents/` + service.LaunchdLabel + `.plist
+  Linux    systemd user unit ~/.config/systemd/user/` + service.SystemdUnit + `
+  Windows  Scheduled Task "` + service.TaskName + `", run when you log on
+
+The service runs this vx binary with the project's vx.toml, and with
+--agent, --namespace, and --token-store when given. It is started right
+away, replacing a daemon started in the background by another command, and
+logs to ~/.vx/daemon.log. Anything it prints outside the log, such as a
+crash, goes to ~/.vx/daemon.out. Install again after moving vx or the
+project.
+
+On Linux, systemd stops user units when your last session ends. Run
+"loginctl enable-linger" to keep the daemon running while logged out.`,
+	Args: cobra.NoArgs,
+	RunE: runDaemonInstall,
+}
+
+var daemonUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stop the daemon and remove it from the service manager",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonUninstall,
+}
+
 func runDaemonStart(cmd *cobra.Command, args []string) error {
-	cfg, _, err := loadConfig()
+	if flagDaemonService {
+		service.Detach()
+	}
+
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	logFile, err := token.OpenLogFile(token.LogPath(), token.MaxLogSize)
 	if err != nil {
 		return err
 	}
+	defer logFile.Close()
+	useDaemonLog(logFile)
 
-	renewer := token.NewTokenRenewer(cfg.Vault.Address)
-	daemon := token.NewDaemon(renewer)
+	renewer := token.NewTokenRenewer(cfg.Vault.Address, renewerOptions(cfg)...)
+	daemon := token.NewDaemon(renewer,
+		token.WithStateChange(logRenewalState),
+		token.WithFailure(logRenewalFailure),
+		token.WithOtherTokens(token.Addresses, func(addr string) *token.TokenRenewer {
+			return token.NewTokenRenewer(addr, renewerOptions(cfg)...)
+		}),
+	)
 
 	if daemon.IsRunning() {
 		return fmt.Errorf("daemon is already running")
 	}
 
+	// The socket answers "vx daemon status", and with --agent serves
+	// secrets too; the daemon runs without it only when it isn't needed
+	// for the agent.
+	ln, err := agent.Listen(token.SocketPath())
+	if err != nil {
+		if flagDaemonAgent {
+			return err
+		}
+		log.Warn().Err(err).Msg("daemon socket unavailable, vx daemon status will show less")
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	if err := daemon.Start(ctx); err != nil {
+		if ln != nil {
+			ln.Close()
+		}
 		return fmt.Errorf("starting daemon: %w", err)
 	}
 
+	socketDone := make(chan struct{})
+	if ln != nil {
+		go func() {
+			serveDaemonSocket(ctx, ln, daemon, rootDir)
+			close(socketDone)
+		}()
+	} else {
+		close(socketDone)
+	}
+
+	if cfg.Vault.Events {
+		go watchVaultEvents(ctx, cfg)
+	}
+
 	log.Info().Msg("daemon started, press Ctrl+C to stop")
 
 	sigCh := make(chan os.Signal, 1)
@@ -77,10 +215,177 @@ func runDaemonStart(cmd *cobra.Command, args []string) error {
 	if err := daemon.Stop(); err != nil {
 		log.Warn().Err(err).Msg("error stopping daemon")
 	}
+	// Closing the listener removes the socket.
+	cancel()
+	<-socketDone
 
 	return nil
 }
 
+// serveDaemonSocket answers status requests on ln, and with --agent requests
+// for the secrets of the project at rootDir, until ctx is done.
+func serveDaemonSocket(ctx context.Context, ln net.Listener, daemon *token.Daemon, rootDir string) {
+	mux := http.NewServeMux()
+	mux.Handle("/v1/status", daemon.StatusHandler())
+	if flagDaemonAgent {
+		mux.Handle("/v1/secrets", agentHandler(rootDir))
+		log.Info().Str("socket", ln.Addr().String()).Str("root", rootDir).Msg("agent serving secrets")
+	}
+
+	if err := agent.Serve(ctx, ln, mux); err != nil {
+		log.Warn().Err(err).Msg("daemon socket closed")
+	}
+}
+
+// useDaemonLog sends the daemon's log to f as JSON lines, which "vx daemon
+// status" reads back. When run in a terminal the log is printed there too;
+// otherwise stderr is already the log file.
+func useDaemonLog(f *token.LogFile) {
+	zerolog.TimeFieldFormat = time.RFC3339
+
+	var w io.Writer = f
+	if term.IsTerminal(os.Stderr.Fd()) {
+		w = zerolog.MultiLevelWriter(f, zerolog.ConsoleWriter{Out: os.Stderr, NoColor: !colorEnabled(os.Stderr)})
+	}
+	log.Logger = log.Output(w)
+}
+
+// logRenewalState logs the renewal loop recovering after failures, which
+// logRenewalFailure logs as they happen.
+func logRenewalState(c token.StateChange) {
+	if c.Healthy {
+		log.Info().Int("failures", c.Failures).Dur("next_check", c.NextCheck).Msg("token renewal recovered")
+	}
+}
+
+// logRenewalFailure logs each failed renewal check, the last of which "vx
+// daemon status" reports.
+func logRenewalFailure(c token.StateChange) {
+	log.Error().Err(c.Err).Int("failures", c.Failures).Dur("next_check", c.NextCheck).Msg("token renewal failed, backing off")
+}
+
+// watchVaultEvents journals KV changes from Vault's event stream until ctx
+// is done, reconnecting after failures. It gives up when the server has no
+// event system.
+func watchVaultEvents(ctx context.Context, cfg *config.RootConfig) {
+	for {
+		err := subscribeVaultEvents(ctx, cfg)
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, vault.ErrEventsUnsupported) {
+			log.Warn().Msg("vault does not support events (requires Vault 1.16+), change notifications disabled")
+			return
+		}
+		if err != nil {
+			log.Warn().Err(err).Dur("retry_in", eventsRetryDelay).Msg("vault event subscription failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventsRetryDelay):
+		}
+	}
+}
+
+// subscribeVaultEvents runs one event subscription with the cached token,
+// which is re-read on every attempt so a fresh login is picked up.
+func subscribeVaultEvents(ctx context.Context, cfg *config.RootConfig) error {
+	tok, err := token.ReadToken()
+	if err != nil {
+		return err
+	}
+
+	client, err := vault.NewClientWithToken(cfg.Vault.Address, cfg.Vault.BasePath, tok, vaultClientOptions(cfg)...)
+	if err != nil {
+		return fmt.Errorf("creating vault client: %w", err)
+	}
+
+	log.Info().Str("base_path", cfg.Vault.BasePath).Msg("subscribing to vault events")
+	return client.WatchKV(ctx, func(c vault.KVChange) {
+		log.Info().Str("path", c.Path).Str("operation", c.Operation).Int("version", c.Version).Msg("secret changed")
+
+		// Journal the time of receipt rather than the event's own, so readers
+		// compare it against their local clock.
+		err := changes.Append(changes.Change{
+			Path:      c.Path,
+			Operation: c.Operation,
+			Version:   c.Version,
+			Time:      time.Now(),
+		})
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to record secret change")
+		}
+	})
+}
+
+func runDaemonInstall(cmd *cobra.Command, args []string) error {
+	_, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	configPath, err := filepath.Abs(rootConfigPath(rootDir))
+	if err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("resolving the vx binary: %w", err)
+	}
+
+	// The service manager starts the daemon from here on; a background
+	// daemon still running would make it exit as a duplicate.
+	if token.NewDaemon(nil).IsRunning() {
+		if err := runDaemonStop(cmd, nil); err != nil {
+			return err
+		}
+	}
+
+	where, err := service.Install(service.Service{
+		Executable: exe,
+		Args:       serviceArgs(configPath),
+		Dir:        filepath.Dir(configPath),
+		OutputPath: token.OutputPath(),
+	})
+	if err != nil {
+		return fmt.Errorf("installing daemon service: %w", err)
+	}
+
+	log.Info().Str("service", where).Str("config", configPath).Msg("daemon installed, it starts when you log in")
+	return nil
+}
+
+// serviceArgs returns the arguments the service manager starts vx with,
+// carrying over the flags and environment that select the project's Vault
+// token.
+func serviceArgs(configPath string) []string {
+	args := []string{"daemon", "start", "--service", "--config", configPath}
+	if flagDaemonAgent {
+		args = append(args, "--agent")
+	}
+	if ns := namespaceOverride(); ns != "" {
+		args = append(args, "--namespace", ns)
+	}
+	if store := cmp.Or(flagTokenStore, os.Getenv(tokenStoreEnv)); store != "" {
+		args = append(args, "--token-store", store)
+	}
+	return args
+}
+
+func runDaemonUninstall(cmd *cobra.Command, args []string) error {
+	where, err := service.Uninstall()
+	if errors.Is(err, service.ErrNotInstalled) {
+		return fmt.Errorf("daemon is not installed (%s not found)", where)
+	}
+	if err != nil {
+		return fmt.Errorf("uninstalling daemon service: %w", err)
+	}
+
+	log.Info().Str("service", where).Msg("daemon uninstalled")
+	return nil
+}
+
 func runDaemonStop(cmd *cobra.Command, args []string) error {
 	pidPath := token.PIDPath()
 
@@ -118,23 +423,63 @@ func runDaemonStatus(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	renewer := token.NewTokenRenewer(cfg.Vault.Address)
-	daemon := token.NewDaemon(renewer)
-
-	status, err := daemon.Status()
-	if err != nil {
-		return fmt.Errorf("checking daemon status: %w", err)
+	// Ask the running daemon; one that doesn't answer on the socket only
+	// leaves its PID file and log behind.
+	ctx, cancel := context.WithTimeout(context.Background(), daemonStatusTimeout)
+	defer cancel()
+	status, err := token.QueryStatus(ctx, token.SocketPath())
+	live := err == nil
+	if !live {
+		log.Debug().Err(err).Msg("daemon socket not answering, reading the PID file")
+		renewer := token.NewTokenRenewer(cfg.Vault.Address, renewerOptions(cfg)...)
+		if status, err = token.NewDaemon(renewer).Status(); err != nil {
+			return fmt.Errorf("checking daemon status: %w", err)
+		}
 	}
 
-	if !status.Running {
+	if status.Running {
+		fmt.Printf("Daemon: running (PID %d)\n", status.PID)
+	} else {
 		fmt.Println("Daemon: not running")
-		return nil
 	}
-
-	fmt.Printf("Daemon: running (PID %d)\n", status.PID)
+	if status.CreationTTL > 0 {
+		fmt.Printf("Token TTL: %s of %s\n", formatDuration(status.TokenTTL), formatDuration(status.CreationTTL))
+	} else if status.TokenTTL > 0 {
+		fmt.Printf("Token TTL: %s\n", formatDuration(status.TokenTTL))
+	}
 	if !status.LastRenewal.IsZero() {
-		fmt.Printf("Last renewal: %s\n", status.LastRenewal.Format("2006-01-02 15:04:05"))
+		fmt.Printf("Last renewal: %s\n", status.LastRenewal.Local().Format("2006-01-02 15:04:05"))
+	}
+	if live {
+		fmt.Printf("Renewals: %d\n", status.Renewals)
 	}
+	if !status.LastCheck.IsZero() {
+		fmt.Printf("Last check: %s\n", status.LastCheck.Local().Format("2006-01-02 15:04:05"))
+	}
+	if !status.NextCheck.IsZero() {
+		fmt.Printf("Next check: %s (in %s)\n", status.NextCheck.Local().Format("2006-01-02 15:04:05"), time.Until(status.NextCheck).Round(time.Second))
+	}
+	if status.TotalFailures > 0 {
+		fmt.Printf("Renewal failures: %d consecutive, %d total\n", status.ConsecutiveFailures, status.TotalFailures)
+		fmt.Printf("Last error: %s (%s)\n", status.LastError, status.LastErrorTime.Local().Format("2006-01-02 15:04:05"))
+	} else if !live {
+		// Without the running daemon's counts, its errors are in the log.
+		entry, ok, err := token.LastLogError(token.LogPath())
+		if err != nil {
+			log.Warn().Err(err).Msg("reading daemon log")
+		} else if ok {
+			fmt.Printf("Last error: %s (%s)\n", logEntryText(entry), entry.Time.Local().Format("2006-01-02 15:04:05"))
+		}
+	}
+	fmt.Printf("Log: %s\n", token.LogPath())
 
 	return nil
 }
+
+// logEntryText returns the message and error of a daemon log entry.
+func logEntryText(e token.LogEntry) string {
+	if e.Err == "" {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/dotenv"
+)
+
+var (
+	flagDiffFrom       string
+	flagDiffTo         string
+	flagDiffShowValues bool
+)
+
+func init() {
+	diffCmd.Flags().StringVar(&flagDiffFrom, "from", "", "environment to compare from (default: the current environment)")
+	diffCmd.Flags().StringVar(&flagDiffTo, "to", "", "environment to compare with (required)")
+	diffCmd.Flags().BoolVar(&flagDiffShowValues, "show-values", false, "print differing values instead of masking them")
+	diffCmd.MarkFlagRequired("to")
+	rootCmd.AddCommand(diffCmd)
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff --from <env> --to <env>",
+	Short: "Compare the resolved values of two environments",
+	Long: `Resolves the workspace's secrets and defaults for two environments and
+lists the variables only one of them provides and those whose values differ.
+Values are masked unless --show-values is given.
+
+Exits non-zero when --to lacks variables --from provides, so a deploy can be
+stopped before production misses a secret the other environments have:
+
+  vx diff --from staging --to production -w api`,
+	Args: cobra.NoArgs,
+	RunE: runDiff,
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	from := flagDiffFrom
+	if from == "" {
+		from = resolveEnv(cfg)
+	}
+	to := flagDiffTo
+	if from == to {
+		return fmt.Errorf("--from and --to are both %q", from)
+	}
+
+	workspace, err := detectWorkspace(cfg, rootDir, []string{})
+	if err != nil {
+		return err
+	}
+
+	fromValues, err := resolveEnvValues(cfg, rootDir, workspace, from)
+	if err != nil {
+		return fmt.Errorf("%s: %w", from, err)
+	}
+	toValues, err := resolveEnvValues(cfg, rootDir, workspace, to)
+	if err != nil {
+		return fmt.Errorf("%s: %w", to, err)
+	}
+
+	// Compare treats its second argument as the reference file: keys
+	// "missing from vx" are those only --from has.
+	c := dotenv.Compare(toValues, fromValues)
+
+	show := dotenv.Mask
+	if flagDiffShowValues {
+		show = func(v string) string { return fmt.Sprintf("%q", v) }
+	}
+
+	for _, k := range c.MissingFromVx {
+		fmt.Printf("- %-35s only in %s\n", k, from)
+	}
+	for _, k := range c.MissingFromFile {
+		fmt.Printf("+ %-35s only in %s\n", k, to)
+	}
+	for _, k := range c.Different {
+		fmt.Printf("~ %-35s %s: %s, %s: %s\n", k, from, show(fromValues[k]), to, show(toValues[k]))
+	}
+
+	fmt.Printf("\n%d identical, %d only in %s, %d only in %s, %d different\n",
+		len(c.Same), len(c.MissingFromVx), from, len(c.MissingFromFile), to, len(c.Different))
+
+	if len(c.MissingFromVx) > 0 {
+		return fmt.Errorf("%s is missing %d variable(s) that %s provides", to, len(c.MissingFromVx), from)
+	}
+	return nil
+}
+
+// resolveEnvValues returns the defaults and resolved secrets of workspace in
+// env, as vx exec would set them.
+func resolveEnvValues(cfg *config.RootConfig, rootDir, workspace, env string) (map[string]string, error) {
+	merged, err := mergeForWorkspace(cfg, rootDir, workspace, env)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := authenticatedClient(cfg, env)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := resolveSecrets(client, merged)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(merged.Defaults)+len(secrets))
+	for k, v := range merged.Defaults {
+		values[k] = v
+	}
+	for k, v := range secrets {
+		values[k] = v
+	}
+	return values, nil
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/changes"
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/direnv"
+	"go.dot.industries/vx/internal/token"
+)
+
+var (
+	flagDirenvCacheTTL time.Duration
+	flagDirenvRefresh  bool
+)
+
+func init() {
+	direnvExportCmd.Flags().DurationVar(&flagDirenvCacheTTL, "cache-ttl", 5*time.Minute, "reuse resolved values for this long (0 disables the cache)")
+	direnvExportCmd.Flags().BoolVar(&flagDirenvRefresh, "refresh", false, "ignore cached values and resolve from Vault")
+
+	direnvCmd.AddCommand(direnvHookCmd)
+	direnvCmd.AddCommand(direnvExportCmd)
+	rootCmd.AddCommand(direnvCmd)
+}
+
+var direnvCmd = &cobra.Command{
+	Use:   "direnv",
+	Short: "Load secrets automatically with direnv",
+	Long: `Integrates vx with direnv, so entering a workspace directory loads its
+secrets and defaults and leaving it unloads them.
+
+  vx direnv hook >> .envrc
+  direnv allow
+
+direnv re-evaluates .envrc when it or a vx.toml it depends on changes.
+Resolved values are cached under ~/.vx/direnv (mode 0600) for --cache-ttl
+so moving between directories doesn't read every path from Vault again.
+When the daemon watches Vault events, a change to any secret discards
+values cached before it.`,
+}
+
+var direnvHookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Print an .envrc snippet that loads values with vx",
+	Long: `Prints the .envrc lines that make direnv call "vx direnv export". The
+--env and --workspace flags, when given, are baked into the snippet;
+otherwise the workspace is detected from the directory holding .envrc.
+
+  vx direnv hook >> .envrc
+  vx direnv hook -e staging >> .envrc`,
+	Args: cobra.NoArgs,
+	RunE: runDirenvHook,
+}
+
+var direnvExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print the resolved environment for direnv to evaluate",
+	Long: `Resolves the workspace's secrets and defaults and prints them as bash
+export statements, along with watch_file calls for the vx.toml files they
+come from. Meant to be evaluated from .envrc (see "vx direnv hook").`,
+	Args: cobra.NoArgs,
+	RunE: runDirenvExport,
+}
+
+func runDirenvHook(cmd *cobra.Command, args []string) error {
+	var flags []string
+	if flagEnv != "" {
+		flags = append(flags, "-e", flagEnv)
+	}
+	if flagWorkspace != "" {
+		flags = append(flags, "-w", flagWorkspace)
+	}
+
+	fmt.Print(direnv.Hook(flags))
+	return nil
+}
+
+func runDirenvExport(cmd *cobra.Command, args []string) error {
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	env := resolveEnv(cfg)
+
+	workspace, err := detectWorkspace(cfg, rootDir, []string{})
+	if err != nil {
+		return err
+	}
+
+	watch, err := direnvWatchFiles(cfg, rootDir, workspace)
+	if err != nil {
+		return err
+	}
+
+	merged, err := mergeForWorkspace(cfg, rootDir, workspace, env)
+	if err != nil {
+		return err
+	}
+
+	cache := direnv.NewCache(filepath.Join(token.DefaultDir(), "direnv"), direnvCacheTTL(merged))
+	key, err := direnv.Key(watch, rootDir, workspace, env, cfg.Vault.Address)
+	if err != nil {
+		return err
+	}
+
+	if !flagDirenvRefresh {
+		// Values cached before the daemon last saw a secret change are stale.
+		changed, err := changes.Latest()
+		if err != nil {
+			log.Debug().Err(err).Msg("reading change journal")
+		}
+		if values, ok := cache.Load(key, changed); ok {
+			log.Debug().Int("values", len(values)).Msg("using cached direnv values")
+			return direnv.Export(os.Stdout, values, watch)
+		}
+	}
+
+	client, err := authenticatedClient(cfg, env)
+	if err != nil {
+		return err
+	}
+
+	secrets, err := resolveSecrets(client, merged)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]string, len(merged.Defaults)+len(secrets))
+	for k, v := range merged.Defaults {
+		values[k] = v
+	}
+	for k, v := range secrets {
+		values[k] = v
+	}
+
+	if err := cache.Store(key, values); err != nil {
+		log.Warn().Err(err).Msg("failed to cache direnv values")
+	}
+
+	return direnv.Export(os.Stdout, values, watch)
+}
+
+// direnvCacheTTL returns how long the loaded values may be cached: the
+// shortest lifetime among the mapped secrets, where a cache_ttl override
+// replaces --cache-ttl.
+func direnvCacheTTL(merged *config.MergedConfig) time.Duration {
+	return merged.EntryCacheTTL(flagDirenvCacheTTL)
+}
+
+// direnvWatchFiles returns the config files the workspace's values come
+// from: the root vx.toml plus the workspace's own file, or every workspace
+// file when none is selected.
+func direnvWatchFiles(cfg *config.RootConfig, rootDir string, workspace string) ([]string, error) {
+	files := []string{rootConfigPath(rootDir)}
+
+	if workspace != "" {
+		wsPath, err := config.ResolveWorkspacePath(rootDir, workspace, cfg.Workspaces)
+		if err != nil {
+			return nil, fmt.Errorf("resolving workspace path: %w", err)
+		}
+		return append(files, wsPath), nil
+	}
+
+	for _, wsRelPath := range cfg.Workspaces {
+		wsPath := filepath.Join(rootDir, wsRelPath)
+		if _, err := os.Stat(wsPath); err == nil {
+			files = append(files, wsPath)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/envrename"
+	"go.dot.industries/vx/internal/shellenv"
+)
+
+var (
+	flagEnvShell           string
+	flagEnvUnset           bool
+	flagEnvRenameWrite     bool
+	flagEnvRenameCopyVault bool
+)
+
+func init() {
+	envCmd.Flags().StringVar(&flagEnvShell, "shell", "", "shell to print statements for: bash, zsh, fish, or powershell (default: fish if $SHELL is fish, powershell on Windows, bash elsewhere)")
+	envCmd.Flags().BoolVar(&flagEnvUnset, "unset", false, "print statements that remove the variables instead of setting them (no Vault access)")
+	envRenameCmd.Flags().BoolVar(&flagEnvRenameWrite, "write", false, "apply the rename (default: dry-run)")
+	envRenameCmd.Flags().BoolVar(&flagEnvRenameCopyVault, "copy-vault", false, "also copy the old environment's Vault secrets to the new environment's path")
+	envCmd.AddCommand(envRenameCmd)
+	rootCmd.AddCommand(envCmd)
+}
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Print shell statements that set the resolved variables, or manage environments",
+	Long: `Without a subcommand, resolves the secrets and defaults vx exec would
+inject and prints statements that set them in the current shell, with each
+value quoted so it reaches the shell unchanged:
+
+  eval "$(vx env)"                              # bash, zsh
+  vx env --shell fish | source                  # fish
+  vx env --shell powershell | Out-String | Invoke-Expression
+
+With --unset, prints statements that remove the same variables again, so
+the values do not outlive the task that needed them. Only the names are
+needed, so Vault is not contacted:
+
+  eval "$(vx env --unset)"
+
+Names are translated by [exports.shell.rename] in vx.toml, as for
+vx list --format=shell.`,
+	Args: cobra.NoArgs,
+	RunE: runEnv,
+}
+
+func runEnv(cmd *cobra.Command, args []string) error {
+	dialect := shellenv.Detect()
+	if flagEnvShell != "" {
+		d, err := shellenv.ParseDialect(flagEnvShell)
+		if err != nil {
+			return err
+		}
+		dialect = d
+	}
+
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	workspace, err := detectWorkspace(cfg, rootDir, []string{})
+	if err != nil {
+		return err
+	}
+
+	merged, err := mergeForWorkspace(cfg, rootDir, workspace, resolveEnv(cfg))
+	if err != nil {
+		return err
+	}
+	renames := cfg.ExportRenames("shell")
+
+	if flagEnvUnset {
+		names := make(map[string]string, len(merged.Defaults)+len(merged.Secrets))
+		for k := range merged.Defaults {
+			names[k] = ""
+		}
+		for k := range merged.Secrets {
+			names[k] = ""
+		}
+		return shellenv.RenderUnset(os.Stdout, dialect, config.RenameKeys(names, renames))
+	}
+
+	all, err := resolveAll(cfg, merged)
+	if err != nil {
+		return err
+	}
+	return shellenv.Render(os.Stdout, dialect, config.RenameKeys(all, renames))
+}
+
+var envRenameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename an environment across vx.toml files and Vault paths",
+	Long: `Renames an environment in environments.available/default, [defaults.<env>]
+tables, [tui] settings, and secret paths that hardcode the old name, in the
+root vx.toml and every workspace vx.toml. Comments and layout are preserved.
+
+With --copy-vault, every secret under the old environment's path segment in
+the KV mount is copied to the new one's: <old>/ to <new>/, or the segments
+[environments.map] gives them. A mapped environment keeps its segment, so
+its entry moves to <new> and nothing is copied. Existing secrets at the destination are never overwritten, and the
+old subtree is left in place for you to remove once nothing depends on it.
+The vx.toml files are only written once every copy succeeded; if one fails,
+run the rename again: secrets already copied with the same values are
+skipped.
+
+By default runs in dry-run mode and prints the plan. Use --write to apply it.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runEnvRename,
+}
+
+func runEnvRename(cmd *cobra.Command, args []string) error {
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	wsPaths := make([]string, len(cfg.Workspaces))
+	for i, ws := range cfg.Workspaces {
+		wsPaths[i] = filepath.Join(rootDir, ws)
+	}
+
+	plan, err := envrename.NewPlan(rootConfigPath(rootDir), wsPaths, args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	var tree envrename.VaultTree
+	if flagEnvRenameCopyVault {
+		client, err := authenticatedClient(cfg, resolveEnv(cfg))
+		if err != nil {
+			return err
+		}
+		if err := plan.PlanVaultCopy(client); err != nil {
+			return err
+		}
+		tree = client
+	}
+
+	if !flagEnvRenameWrite {
+		fmt.Println("# Dry run — use --write to apply")
+		fmt.Println()
+	}
+	printRenamePlan(plan, rootDir)
+
+	if !flagEnvRenameWrite {
+		return nil
+	}
+
+	if err := plan.Apply(tree); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nrenamed %s to %s\n", plan.Old, plan.New)
+	return nil
+}
+
+// printRenamePlan lists the file edits and Vault copies in a plan.
+func printRenamePlan(plan *envrename.Plan, rootDir string) {
+	if len(plan.Changes) == 0 && len(plan.Copies) == 0 {
+		fmt.Println("nothing to change")
+		return
+	}
+
+	for _, c := range plan.Changes {
+		rel, err := filepath.Rel(rootDir, c.File)
+		if err != nil {
+			rel = c.File
+		}
+		fmt.Printf("%s: %s\n", rel, c.Detail)
+	}
+
+	for _, c := range plan.Copies {
+		fmt.Printf("vault: %s -> %s\n", c.From, c.To)
+	}
+	if flagEnvRenameCopyVault && plan.OldSegment == plan.NewSegment {
+		fmt.Printf("vault: %s keeps reading %s/ through [environments.map], nothing to copy\n", plan.New, plan.NewSegment)
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/dotenv"
+)
+
+var flagEnvDiffAgainst string
+
+func init() {
+	envDiffCmd.Flags().StringVar(&flagEnvDiffAgainst, "against", ".env", "dotenv file to compare with")
+	envCmd.AddCommand(envDiffCmd)
+}
+
+var envDiffCmd = &cobra.Command{
+	Use:   "diff --against <file>",
+	Short: "Compare resolved values with a .env file",
+	Long: `Resolves the workspace's secrets and defaults and compares them with a
+dotenv file, listing keys only vx provides, keys only the file has, and keys
+whose values differ. Values are masked.
+
+Exits non-zero while the file still has keys vx does not provide or provides
+with a different value, so it can confirm a dotenv-to-vx migration is
+complete before the old file is deleted:
+
+  vx env diff --against .env -w api`,
+	Args: cobra.NoArgs,
+	RunE: runEnvDiff,
+}
+
+func runEnvDiff(cmd *cobra.Command, args []string) error {
+	file, err := dotenv.ParseFile(flagEnvDiffAgainst)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", flagEnvDiffAgainst, err)
+	}
+
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	env := resolveEnv(cfg)
+
+	workspace, err := detectWorkspace(cfg, rootDir, []string{})
+	if err != nil {
+		return err
+	}
+
+	resolved, err := resolveEnvValues(cfg, rootDir, workspace, env)
+	if err != nil {
+		return err
+	}
+
+	c := dotenv.Compare(resolved, file)
+	printEnvDiff(c, resolved, file)
+
+	if !c.Complete() {
+		return fmt.Errorf("%s has %d key(s) vx does not provide and %d with different values",
+			flagEnvDiffAgainst, len(c.MissingFromVx), len(c.Different))
+	}
+	return nil
+}
+
+// printEnvDiff prints one line per key that is not identical on both sides,
+// followed by a summary.
+func printEnvDiff(c *dotenv.Comparison, resolved, file map[string]string) {
+	for _, k := range c.MissingFromVx {
+		fmt.Printf("- %-35s only in %s\n", k, flagEnvDiffAgainst)
+	}
+	for _, k := range c.MissingFromFile {
+		fmt.Printf("+ %-35s only in vx\n", k)
+	}
+	for _, k := range c.Different {
+		fmt.Printf("~ %-35s vx: %s, file: %s\n", k, dotenv.Mask(resolved[k]), dotenv.Mask(file[k]))
+	}
+
+	fmt.Printf("\n%d identical, %d only in %s, %d only in vx, %d different\n",
+		len(c.Same), len(c.MissingFromVx), flagEnvDiffAgainst, len(c.MissingFromFile), len(c.Different))
+}
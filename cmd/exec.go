@@ -2,45 +2,242 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/charmbracelet/x/term"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
 	"go.dot.industries/vx/internal/config"
 	vxexec "go.dot.industries/vx/internal/exec"
+	"go.dot.industries/vx/internal/jsexport"
 	"go.dot.industries/vx/internal/resolver"
 	"go.dot.industries/vx/internal/token"
 	"go.dot.industries/vx/internal/vault"
 )
 
+var (
+	flagExecStdin         string
+	flagExecExpandArgs    bool
+	flagExecExportRuntime string
+	flagExecBreakGlass    bool
+	flagExecNoInherit     bool
+	flagExecCaptureLog    string
+	flagExecTailLines     int
+	flagExecFiles         []string
+	flagExecWatch         bool
+	flagExecWatchInterval time.Duration
+	flagExecRefresh       bool
+	flagExecSet           []string
+	flagExecAllowMissing  bool
+	flagExecKillTimeout   time.Duration
+	flagExecMaskOutput    bool
+)
+
 func init() {
+	execCmd.Flags().StringVar(&flagExecStdin, "stdin", "", "pipe the value of this secret or default into the command's stdin")
+	execCmd.Flags().BoolVar(&flagExecExpandArgs, "expand-args", false, "replace {{KEY}} placeholders in the command's arguments with resolved values")
+	execCmd.Flags().StringVar(&flagExecExportRuntime, "export-runtime", "", "write non-secret values to this .ts/.mts/.js/.mjs module instead of the environment")
+	execCmd.Flags().BoolVar(&flagExecBreakGlass, "break-glass", false, "read secrets from the encrypted fallback file instead of Vault (audited)")
+	execCmd.Flags().BoolVar(&flagExecNoInherit, "no-inherit-stdio", false, "capture the command's output and print only its last lines if it fails")
+	execCmd.Flags().StringVar(&flagExecCaptureLog, "capture-log", "", "write the captured output to this file (implies --no-inherit-stdio)")
+	execCmd.Flags().IntVar(&flagExecTailLines, "tail-lines", 50, "lines of captured output to print when the command fails")
+	execCmd.Flags().StringSliceVar(&flagExecFiles, "file", nil, "write the value of this secret or default to a file and set KEY_FILE to its path instead (repeatable)")
+	execCmd.Flags().BoolVar(&flagExecWatch, "watch", false, "restart the command when a resolved secret changes")
+	execCmd.Flags().DurationVar(&flagExecWatchInterval, "watch-interval", 30*time.Second, "how often --watch resolves the secrets again")
+	execCmd.Flags().StringArrayVar(&flagExecSet, "set", nil, "use this literal value for KEY in this run, over the resolved one (KEY=VALUE, repeatable)")
+	execCmd.Flags().BoolVar(&flagExecRefresh, "refresh", false, "read secrets from Vault even when the [cache] has them, and update it")
+	execCmd.Flags().BoolVar(&flagExecAllowMissing, "allow-missing", false, "run the command even when some secrets cannot be resolved, warning about each")
+	execCmd.Flags().BoolVar(&flagExecMaskOutput, "mask-output", false, "replace resolved secret values in the command's output with ***")
+	execCmd.Flags().DurationVar(&flagExecKillTimeout, "kill-timeout", 10*time.Second, "how long the command gets to exit after vx forwards it SIGINT or SIGTERM before its process group is killed (0 waits indefinitely)")
 	rootCmd.AddCommand(execCmd)
 }
 
 var execCmd = &cobra.Command{
-	Use:   "exec -- <command> [args...]",
+	Use:   "exec [--export-runtime <file>] -- <command> [args...]",
 	Short: "Run a command with secrets injected as environment variables",
 	Long: `Resolves secrets from Vault and executes the given command with them
 injected as environment variables. Secrets are scoped to the detected or
-specified workspace.`,
+specified workspace.
+
+For tools that only take secrets on stdin or the command line:
+
+  --stdin KEY     pipes the value of KEY into the command's stdin (no
+                  trailing newline), e.g.
+                  vx exec --stdin REGISTRY_TOKEN -- docker login --password-stdin ghcr.io
+  --expand-args   replaces {{KEY}} in the arguments with the value of KEY, e.g.
+                  vx exec --expand-args -- tool --token '{{API_TOKEN}}'
+
+  --file KEY      writes the value of KEY to a file only you can read and
+                  sets KEY_FILE to its path; the file is deleted when the
+                  command exits
+
+  --set K=V       uses the literal value V for K in this run only, on top of
+                  the resolved secrets and defaults, e.g. to try a sandbox
+                  credential without touching Vault or vx.toml:
+                  vx exec --set STRIPE_KEY=sk_test_123 -- npm test
+
+Values consumed this way are not also exported as environment variables.
+Arguments are visible to other local users (ps), so prefer --stdin when the
+tool supports it. Use --file for large values such as certificates or JSON
+documents: the operating system limits how big the environment may be, and
+vx refuses to start the command when a value would not fit.
+
+For frontend builds that need configuration at compile time,
+--export-runtime writes the values to a generated module with typed
+constants (a .js/.mjs target also gets a .d.ts/.d.mts declaration file):
+
+  vx exec --export-runtime src/env.ts -- bun run build
+  vx exec --export-runtime src/env.mjs
+
+Names matching the [export_runtime] deny patterns (by default *SECRET*,
+*TOKEN*, *_KEY, and similar) are never written to the module; they stay in
+the environment of the command. The command is optional with this flag.
+
+When Vault is down, --break-glass reads the secrets from the encrypted
+fallback file instead (see "vx break-glass").
+
+By default a secret that cannot be read stops vx exec before the command
+starts. --allow-missing reads every other secret anyway, warns about each one
+it could not resolve (an unreadable path, a key missing from its path, or a
+failed command) and runs the command without it, or with its default:
+
+  vx exec --allow-missing -- npm run dev
+
+Variables listed in required (in the root or workspace vx.toml) must still
+have a value, or vx exec fails and names the ones that do not.
+
+env_passthrough and env_block in vx.toml limit which variables of vx's own
+environment the command inherits; secrets and defaults are always set.
+
+For noisy steps where only failures matter, such as builds in CI,
+--no-inherit-stdio captures the command's stdout and stderr instead of
+showing them, and prints the last --tail-lines lines if it exits non-zero.
+--capture-log also keeps the full output in a file:
+
+  vx exec --capture-log build.log -- make build
+
+For CI logs, --mask-output replaces every resolved secret value (of four
+characters or more) in the command's stdout and stderr with ***, so tools
+that echo their environment or configuration do not leak them. The command's
+output then goes through a pipe instead of the terminal.
+
+For long-running processes such as dev servers, --watch resolves the secrets
+again every --watch-interval (and as soon as Vault reports a change, on
+Vault 1.16+ with events enabled) and restarts the command when a value
+changed: it gets SIGTERM and 10s to exit before it is killed. A secret
+with a cache lifetime ([cache_ttl], or the [cache] ttl when the cache is
+enabled) is only read again once it has passed or Vault reports a change.
+vx exits when the command exits by itself. --watch cannot be combined with
+--stdin, --file, --export-runtime, --break-glass, or captured output:
+
+  vx exec --watch --watch-interval 1m -- npm run dev
+
+When vx gets SIGINT, SIGTERM, or SIGHUP it passes the signal on and waits
+for the command to exit, killing it after --kill-timeout (default 10s; 0
+waits indefinitely). Outside an interactive terminal, as under CI runners
+and process supervisors, the command runs in a process group of its own:
+signals and the final kill reach everything it started, and processes it
+leaves running when it exits are killed too. On Windows the command runs in
+a Job Object and a console process group of its own, and Ctrl+C reaches it
+as Ctrl+Break.
+
+With [cache] enabled in the root vx.toml, resolved secrets are kept
+encrypted in ~/.vx/cache and reused until they expire, so repeated runs
+start without contacting Vault (see "vx cache"). A daemon started with
+--agent serves resolved secrets the same way from memory (see "vx daemon").
+--refresh reads Vault anyway.`,
 	DisableFlagParsing: false,
-	Args:               cobra.MinimumNArgs(1),
+	Args:               execArgs,
 	RunE:               runExec,
 }
 
+// execSecrets resolves the mapped secrets from Vault, or from the agent of a
+// running daemon, or from the on-disk cache when [cache] is enabled, or from
+// the fallback file with --break-glass.
+func execSecrets(cfg *config.RootConfig, rootDir, workspace string, merged *config.MergedConfig) (map[string]string, error) {
+	if flagExecBreakGlass {
+		return breakGlassSecrets(cfg, rootDir, workspace, merged)
+	}
+	if secrets, ok := agentSecrets(cfg, rootDir, workspace, merged); ok {
+		return secrets, nil
+	}
+
+	return cachedSecrets(cfg, rootDir, workspace, merged, func() (map[string]string, error) {
+		vaultClient, err := authenticatedClient(cfg, merged.Environment)
+		if err != nil {
+			return nil, err
+		}
+		return resolveSecrets(vaultClient, merged)
+	})
+}
+
+// checkRequired fails when a variable listed in required has no value from
+// the secrets, defaults, or --set, so the command is never started without
+// it.
+func checkRequired(merged *config.MergedConfig, secrets, overrides map[string]string) error {
+	values := make(map[string]string, len(merged.Defaults)+len(secrets)+len(overrides))
+	maps.Copy(values, merged.Defaults)
+	maps.Copy(values, secrets)
+	maps.Copy(values, overrides)
+
+	missing := merged.MissingRequired(values)
+	if len(missing) == 0 {
+		return nil
+	}
+	for i, name := range missing {
+		if _, mapped := merged.Secrets[name]; !mapped {
+			missing[i] += " (not mapped)"
+		}
+	}
+	return fmt.Errorf("required secret(s) not resolved, not starting the command: %s", strings.Join(missing, ", "))
+}
+
+// execArgs requires a command unless --export-runtime is set, in which case
+// vx exec can be used just to write the module.
+func execArgs(cmd *cobra.Command, args []string) error {
+	if flagExecExportRuntime != "" {
+		return nil
+	}
+	return cobra.MinimumNArgs(1)(cmd, args)
+}
+
 func runExec(cmd *cobra.Command, args []string) error {
 	cfg, rootDir, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
+	workspace, err := detectWorkspace(cfg, rootDir, args)
+	if err != nil {
+		return err
+	}
+
+	return execInWorkspace(cfg, rootDir, workspace, args)
+}
+
+// execInWorkspace runs args with the secrets and defaults of workspace, as
+// vx exec does once the workspace is known.
+func execInWorkspace(cfg *config.RootConfig, rootDir, workspace string, args []string) error {
 	env := resolveEnv(cfg)
 	log.Debug().Str("env", env).Msg("resolved environment")
 
-	workspace, err := detectWorkspace(cfg, rootDir, args)
+	if flagExecWatch {
+		if err := checkWatchFlags(); err != nil {
+			return err
+		}
+	}
+
+	overrides, err := vxexec.ParseOverrides(flagExecSet)
 	if err != nil {
 		return err
 	}
@@ -50,17 +247,25 @@ func runExec(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	vaultClient, err := authenticatedClient(cfg, env)
+	secrets, err := execSecrets(cfg, rootDir, workspace, merged)
 	if err != nil {
 		return err
 	}
 
-	secrets, err := resolveSecrets(vaultClient, merged)
-	if err != nil {
+	if len(overrides) > 0 {
+		log.Info().Strs("keys", slices.Sorted(maps.Keys(overrides))).Msg("overriding values for this run (--set)")
+	}
+
+	if err := checkRequired(merged, secrets, overrides); err != nil {
 		return err
 	}
 
-	// Overlay defaults under secrets (secrets take precedence).
+	if flagExecWatch {
+		return runWatch(cfg, merged, args, secrets, overrides)
+	}
+
+	// Overlay defaults under secrets (secrets take precedence), and both
+	// under --set.
 	envVars := make(map[string]string, len(merged.Defaults)+len(secrets))
 	for k, v := range merged.Defaults {
 		envVars[k] = v
@@ -68,6 +273,40 @@ func runExec(cmd *cobra.Command, args []string) error {
 	for k, v := range secrets {
 		envVars[k] = v
 	}
+	maps.Copy(envVars, overrides)
+
+	if flagExecExportRuntime != "" {
+		if err := exportRuntime(cfg, envVars); err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			return nil
+		}
+	}
+
+	masked := maskOptions(secrets, envVars)
+	command, runOpts, err := applyExecInputs(args, envVars)
+	if err != nil {
+		return err
+	}
+	runOpts = append(runOpts, masked...)
+
+	var filesDir string
+	if len(flagExecFiles) > 0 {
+		var files map[string]string
+		filesDir, files, err = vxexec.WriteFiles(envVars, flagExecFiles)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(filesDir)
+		for k, v := range files {
+			envVars[k] = v
+		}
+	}
+
+	if err := checkEnvSize(command, envVars); err != nil {
+		return err
+	}
 
 	log.Info().
 		Int("secrets", len(secrets)).
@@ -75,16 +314,158 @@ func runExec(cmd *cobra.Command, args []string) error {
 		Str("workspace", workspace).
 		Msg("injecting environment")
 
+	var capture *vxexec.Capture
+	if flagExecNoInherit || flagExecCaptureLog != "" {
+		capture, err = vxexec.NewCapture(flagExecCaptureLog, flagExecTailLines)
+		if err != nil {
+			return err
+		}
+		defer capture.Close()
+		runOpts = append(runOpts, vxexec.WithOutput(capture, capture))
+	}
+	runOpts = append(runOpts,
+		vxexec.WithKillTimeout(flagExecKillTimeout),
+		vxexec.WithHostEnv(merged.EnvPassthrough, merged.EnvBlock))
+
+	reportProfile()
+
 	ctx := context.Background()
-	if err := vxexec.Run(ctx, args, envVars); err != nil {
+	if err := vxexec.Run(ctx, command, envVars, runOpts...); err != nil {
+		if capture != nil {
+			capture.Close()
+			printCapturedTail(capture, err)
+		}
+		if filesDir != "" {
+			os.RemoveAll(filesDir)
+		}
 		os.Exit(vxexec.ExitCode(err))
 	}
 
 	return nil
 }
 
+// maskOptions returns the option hiding what the command receives for each
+// resolved secret from its output when --mask-output is set. It must be
+// called before applyExecInputs removes the values it consumes, which the
+// command may still print.
+func maskOptions(secrets, envVars map[string]string) []vxexec.Option {
+	if !flagExecMaskOutput {
+		return nil
+	}
+	values := make([]string, 0, len(secrets))
+	for k := range secrets {
+		values = append(values, envVars[k])
+	}
+	return []vxexec.Option{vxexec.WithMask(values)}
+}
+
+// checkEnvSize fails before the command is started when its environment is
+// larger than the operating system accepts, naming the variables to blame,
+// and warns when it is close to the limit.
+func checkEnvSize(command []string, envVars map[string]string) error {
+	report := vxexec.MeasureEnv(command, envVars, vxexec.PlatformLimits())
+	if err := report.Err(); err != nil {
+		return fmt.Errorf("%w; pass large values as files with --file KEY", err)
+	}
+	if report.NearLimit() {
+		keys := make([]string, len(report.Largest))
+		for i, e := range report.Largest {
+			keys[i] = e.Key
+		}
+		log.Warn().
+			Int("bytes", report.Total).
+			Int("limit", report.Limits.Total).
+			Strs("largest", keys).
+			Msg("environment is close to the size limit; consider --file for large values")
+	}
+	return nil
+}
+
+// printCapturedTail shows the end of a failed command's captured output on
+// stderr, followed by where the full output is.
+func printCapturedTail(capture *vxexec.Capture, runErr error) {
+	tail := capture.Tail()
+	if len(tail) == 0 {
+		fmt.Fprintf(os.Stderr, "vx: command failed (%v) without output\n", runErr)
+	} else {
+		fmt.Fprintf(os.Stderr, "vx: command failed (%v); last %d line(s) of output:\n", runErr, len(tail))
+		for _, line := range tail {
+			fmt.Fprintln(os.Stderr, line)
+		}
+	}
+	if flagExecCaptureLog != "" {
+		fmt.Fprintf(os.Stderr, "vx: full output in %s\n", flagExecCaptureLog)
+	}
+}
+
+// applyExecInputs handles --expand-args and --stdin. It returns the command to
+// run and the options for vxexec.Run, and removes every value it consumed from
+// envVars so those secrets are not exported as well.
+func applyExecInputs(args []string, envVars map[string]string) ([]string, []vxexec.Option, error) {
+	command := args
+	var consumed []string
+	var opts []vxexec.Option
+
+	if flagExecExpandArgs {
+		expanded, used, err := vxexec.ExpandArgs(args, envVars)
+		if err != nil {
+			return nil, nil, err
+		}
+		command = expanded
+		consumed = append(consumed, used...)
+	}
+
+	if flagExecStdin != "" {
+		val, ok := envVars[flagExecStdin]
+		if !ok {
+			return nil, nil, fmt.Errorf("--stdin %s: not a mapped secret or default", flagExecStdin)
+		}
+		opts = append(opts, vxexec.WithStdin(strings.NewReader(val)))
+		consumed = append(consumed, flagExecStdin)
+	}
+
+	for _, k := range consumed {
+		delete(envVars, k)
+	}
+	if len(consumed) > 0 {
+		log.Debug().Strs("keys", consumed).Msg("passing values outside the environment")
+	}
+
+	return command, opts, nil
+}
+
+// exportRuntime writes the values allowed by the deny-list to the
+// --export-runtime module, under the names [exports.runtime.rename] gives
+// them, and removes them from envVars, so they are materialized instead of
+// injected. The deny-list applies to the mapped names.
+func exportRuntime(cfg *config.RootConfig, envVars map[string]string) error {
+	deny := jsexport.DefaultDeny
+	if cfg.ExportRuntime.Deny != nil {
+		deny = cfg.ExportRuntime.Deny
+	}
+
+	kept, denied := jsexport.Filter(envVars, deny)
+	if len(denied) > 0 {
+		log.Info().Strs("keys", denied).Msg("withheld from the runtime module by the deny-list")
+	}
+
+	paths, err := jsexport.Write(flagExecExportRuntime, config.RenameKeys(kept, cfg.ExportRenames("runtime")))
+	if err != nil {
+		return fmt.Errorf("exporting runtime module: %w", err)
+	}
+
+	for k := range kept {
+		delete(envVars, k)
+	}
+
+	log.Info().Strs("files", paths).Int("values", len(kept)).Msg("wrote runtime module")
+	return nil
+}
+
 // detectWorkspace determines the workspace using CLI flags, command args, or cwd.
 func detectWorkspace(cfg *config.RootConfig, rootDir string, args []string) (string, error) {
+	defer trackPhase("workspace detection")()
+
 	if flagWorkspace != "" {
 		log.Debug().Str("workspace", flagWorkspace).Msg("using explicit workspace flag")
 		return flagWorkspace, nil
@@ -110,7 +491,25 @@ func detectWorkspace(cfg *config.RootConfig, rootDir string, args []string) (str
 }
 
 // mergeForWorkspace loads the workspace config (if any) and merges it with root.
+// Non-fatal merge warnings are logged.
 func mergeForWorkspace(cfg *config.RootConfig, rootDir string, workspace string, env string) (*config.MergedConfig, error) {
+	merged, err := mergeWorkspaceConfig(cfg, rootDir, workspace, env)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, w := range merged.Warnings {
+		log.Warn().Msg(w)
+	}
+
+	return merged, nil
+}
+
+// mergeWorkspaceConfig performs the merge for mergeForWorkspace without
+// reporting warnings.
+func mergeWorkspaceConfig(cfg *config.RootConfig, rootDir string, workspace string, env string) (*config.MergedConfig, error) {
+	defer trackPhase("merge")()
+
 	if workspace == "" {
 		return mergeAllWorkspaces(cfg, rootDir, env)
 	}
@@ -156,6 +555,14 @@ func mergeAllWorkspaces(cfg *config.RootConfig, rootDir string, env string) (*co
 		for k, v := range wsMerged.Defaults {
 			merged.Defaults[k] = v
 		}
+		for k, v := range wsMerged.CacheTTL {
+			merged.CacheTTL[k] = v
+		}
+		for _, w := range wsMerged.Warnings {
+			if !slices.Contains(merged.Warnings, w) {
+				merged.Warnings = append(merged.Warnings, w)
+			}
+		}
 	}
 
 	return merged, nil
@@ -163,6 +570,14 @@ func mergeAllWorkspaces(cfg *config.RootConfig, rootDir string, env string) (*co
 
 // authenticatedClient creates a Vault client with a valid token.
 func authenticatedClient(cfg *config.RootConfig, env string) (*vault.Client, error) {
+	defer trackPhase("auth")()
+
+	// Externally issued tokens are used as they are, without the renewal
+	// daemon or a browser login when they expire.
+	if selectedAuthMethod(cfg) == "token" {
+		return authenticateNew(cfg, env)
+	}
+
 	addr := cfg.Vault.Address
 	if flagVaultAddr != "" {
 		addr = flagVaultAddr
@@ -171,17 +586,17 @@ func authenticatedClient(cfg *config.RootConfig, env string) (*vault.Client, err
 	tok, err := token.ReadToken()
 	if err != nil {
 		log.Warn().Msg("no cached Vault token — opening browser for authentication...")
-		return authenticateAndStartDaemon(cfg)
+		return authenticateAndStartDaemon(cfg, env)
 	}
 
-	client, err := vault.NewClientWithToken(addr, cfg.Vault.BasePath, tok)
+	client, err := vault.NewClientWithToken(addr, cfg.Vault.BasePath, tok, vaultClientOptions(cfg)...)
 	if err != nil {
 		return nil, fmt.Errorf("creating vault client: %w", err)
 	}
 
 	if !client.IsAuthenticated() {
 		log.Warn().Msg("Vault token expired — opening browser for re-authentication...")
-		return authenticateAndStartDaemon(cfg)
+		return authenticateAndStartDaemon(cfg, env)
 	}
 
 	log.Debug().Msg("using cached vault token")
@@ -190,93 +605,378 @@ func authenticatedClient(cfg *config.RootConfig, env string) (*vault.Client, err
 
 // authenticateAndStartDaemon performs a fresh authentication and then
 // best-effort starts the renewal daemon so the new token stays alive.
-func authenticateAndStartDaemon(cfg *config.RootConfig) (*vault.Client, error) {
-	client, err := authenticateNew(cfg)
+func authenticateAndStartDaemon(cfg *config.RootConfig, env string) (*vault.Client, error) {
+	client, err := authenticateNew(cfg, env)
 	if err != nil {
 		return nil, err
 	}
 
-	if !flagNoDaemon {
+	// The daemon renews ~/.vx/token; external tokens are never written there.
+	if !flagNoDaemon && selectedAuthMethod(cfg) != "token" {
 		startDaemonBackground()
 	}
 
 	return client, nil
 }
 
-// authenticateNew performs a fresh authentication against Vault.
-func authenticateNew(cfg *config.RootConfig) (*vault.Client, error) {
+// selectedAuthMethod returns the auth method to use: "token" when
+// --vault-token is given, then --auth, then the config.
+func selectedAuthMethod(cfg *config.RootConfig) string {
+	switch {
+	case flagVaultToken != "":
+		return "token"
+	case flagAuth != "":
+		return flagAuth
+	default:
+		return cfg.Vault.AuthMethod
+	}
+}
+
+// authenticateNew performs a fresh authentication against Vault. For OIDC the
+// role is chosen for env (see VaultConfig.RoleFor). With the "token" method
+// an externally issued token is used as-is and not cached, since whatever
+// issued it (vault-agent, a CI injector) owns its lifecycle.
+func authenticateNew(cfg *config.RootConfig, env string) (*vault.Client, error) {
 	addr := cfg.Vault.Address
 	if flagVaultAddr != "" {
 		addr = flagVaultAddr
 	}
 
-	authMethod := cfg.Vault.AuthMethod
-	if flagAuth != "" {
-		authMethod = flagAuth
-	}
+	authMethod := selectedAuthMethod(cfg)
 
 	// For OIDC, create the client with any existing stale token. Some Vault
 	// servers require a token (even expired) on auth/oidc/auth_url for policy
 	// evaluation. For other auth methods, start unauthenticated.
-	client, err := newClientForAuth(addr, cfg.Vault.BasePath, authMethod)
+	client, err := newClientForAuth(addr, cfg.Vault.BasePath, authMethod, vaultClientOptions(cfg)...)
 	if err != nil {
 		return nil, fmt.Errorf("creating vault client: %w", err)
 	}
 
+	var login *vault.LoginInfo
 	switch authMethod {
 	case "oidc":
-		if err := vault.OIDCAuth(client, cfg.Vault.AuthRole); err != nil {
+		if err := oidcLogin(client, cfg.Vault, env); err != nil {
 			return nil, fmt.Errorf("OIDC authentication: %w", err)
 		}
 	case "approle":
-		roleID := flagRoleID
-		if roleID == "" {
-			roleID = os.Getenv("VX_ROLE_ID")
-		}
-		secretID := flagSecretID
-		if secretID == "" {
-			secretID = os.Getenv("VX_SECRET_ID")
-		}
+		roleID, secretID := appRoleCredentials()
 		if roleID == "" || secretID == "" {
 			return nil, fmt.Errorf("AppRole auth requires --role-id and --secret-id (or VX_ROLE_ID/VX_SECRET_ID env vars)")
 		}
-		if err := vault.AppRoleAuth(client, roleID, secretID); err != nil {
+		login, err = vault.AppRoleAuth(client, roleID, secretID)
+		if err != nil {
 			return nil, fmt.Errorf("AppRole authentication: %w", err)
 		}
+	case "kubernetes":
+		if err := kubernetesLogin(client, cfg.Vault, env); err != nil {
+			return nil, fmt.Errorf("Kubernetes authentication: %w", err)
+		}
+	case "token":
+		tok, err := externalToken(cfg)
+		prompted := false
+		if errors.Is(err, token.ErrNoExternalToken) && term.IsTerminal(os.Stdin.Fd()) {
+			tok, err = promptSecret("Vault token: ")
+			prompted = true
+		}
+		if err != nil {
+			return nil, fmt.Errorf("token auth: %w", err)
+		}
+		if _, err := checkToken(client, tok); err != nil {
+			return nil, fmt.Errorf("token auth: %w", err)
+		}
+		if prompted {
+			// Asked for once; later commands read it from the sink.
+			if err := token.WriteToken(client.Token()); err != nil {
+				log.Warn().Err(err).Msg("failed to store token")
+			}
+		}
+		log.Debug().Msg("using externally issued vault token")
+		return client, nil
 	default:
 		return nil, fmt.Errorf("unsupported auth method: %s", authMethod)
 	}
 
 	if err := token.WriteToken(client.Token()); err != nil {
 		log.Warn().Err(err).Msg("failed to cache token")
+	} else {
+		removeStaleTokens(cfg)
+		if login != nil {
+			writeLoginMetadata(client.Token(), authMethod, login)
+		}
 	}
 
 	return client, nil
 }
 
+// writeLoginMetadata records what the cached token may do, as reported by
+// its login, for vx status. Failing to write it only costs that display.
+func writeLoginMetadata(tok, authMethod string, login *vault.LoginInfo) {
+	m := token.Metadata{
+		AuthMethod: authMethod,
+		Role:       login.Metadata["role_name"],
+		Accessor:   login.Accessor,
+		Policies:   login.Policies,
+		TTL:        token.Seconds(login.TTL / time.Second),
+		Renewable:  login.Renewable,
+		Orphan:     login.Orphan,
+		IssuedAt:   time.Now(),
+	}
+	if err := token.WriteMetadata(tok, m); err != nil {
+		log.Debug().Err(err).Msg("failed to record token metadata")
+	}
+	log.Info().
+		Str("method", authMethod).
+		Str("role", m.Role).
+		Strs("policies", m.Policies).
+		Str("ttl", login.TTL.String()).
+		Bool("orphan", m.Orphan).
+		Msg("logged in")
+}
+
+// oidcLogin runs the browser OIDC flow against the configured auth mount,
+// using the auth role for env. Instructions the user needs, such as the URL
+// when no browser opens, go to stderr.
+func oidcLogin(client *vault.Client, v config.VaultConfig, env string) error {
+	role := v.RoleFor(env)
+	log.Debug().Str("mount", v.OIDCMount()).Str("role", role).Ints("ports", v.AuthListenPorts).Msg("starting OIDC login")
+	opts := []vault.OIDCOption{
+		vault.WithOIDCMount(v.OIDCMount()),
+		vault.WithCallbackPorts(v.AuthListenPorts...),
+		vault.WithOutput(os.Stderr),
+	}
+	if flagLoginNoBrowser {
+		opts = append(opts, vault.WithoutBrowser())
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err := vault.OIDCAuth(ctx, client, role, opts...)
+	if errors.Is(err, vault.ErrNoCallbackPort) {
+		return fmt.Errorf("%w; free one of them, or list more in auth_listen_ports or pass --callback-port (each port's http://localhost:<port>/oidc/callback must be in the role's allowed_redirect_uris)", err)
+	}
+	return err
+}
+
+// kubernetesLogin logs in with the pod's service account token against the
+// Kubernetes auth mount, using the auth role for env.
+func kubernetesLogin(client *vault.Client, v config.VaultConfig, env string) error {
+	path := v.ServiceAccountTokenFile
+	if path == "" {
+		path = vault.ServiceAccountTokenPath
+	}
+	jwt, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading service account token: %w", err)
+	}
+
+	role := v.RoleFor(env)
+	log.Debug().Str("mount", v.KubernetesMount()).Str("role", role).Msg("starting Kubernetes login")
+	return vault.KubernetesAuth(client, v.KubernetesMount(), role, string(jwt))
+}
+
+// externalToken returns the token for the "token" auth method: --vault-token,
+// then VAULT_TOKEN, then vault.token_file, then the token vx login stored.
+// With none of them, the error wraps token.ErrNoExternalToken.
+func externalToken(cfg *config.RootConfig) (string, error) {
+	if flagVaultToken != "" {
+		return flagVaultToken, nil
+	}
+	tok, err := token.External(cfg.Vault.TokenFile)
+	if errors.Is(err, token.ErrNoExternalToken) {
+		if stored, readErr := token.ReadToken(); readErr == nil {
+			return stored, nil
+		}
+		return "", fmt.Errorf("%w; or run vx login to store one", err)
+	}
+	return tok, err
+}
+
+// checkToken sets tok on client and looks it up in Vault, failing when Vault
+// does not accept it. Tokens that never expire, such as root tokens, pass.
+func checkToken(client *vault.Client, tok string) (*vault.TokenInfo, error) {
+	tok = strings.TrimSpace(tok)
+	if tok == "" {
+		return nil, fmt.Errorf("the Vault token is empty")
+	}
+	client.SetToken(tok)
+	info, err := client.LookupSelf()
+	if err != nil {
+		return nil, fmt.Errorf("the Vault token is invalid or expired: %w", err)
+	}
+	return info, nil
+}
+
+// appRoleCredentials returns the AppRole role ID and secret ID from the
+// --role-id/--secret-id flags, falling back to VX_ROLE_ID/VX_SECRET_ID.
+func appRoleCredentials() (roleID, secretID string) {
+	roleID = flagRoleID
+	if roleID == "" {
+		roleID = os.Getenv("VX_ROLE_ID")
+	}
+	secretID = flagSecretID
+	if secretID == "" {
+		secretID = os.Getenv("VX_SECRET_ID")
+	}
+	return roleID, secretID
+}
+
 // newClientForAuth creates a Vault client appropriate for the given auth
 // method. For OIDC, it preserves any existing stale token from ~/.vx/token
 // because some Vault servers require a token for the auth/oidc/auth_url
 // endpoint. For all other methods, it creates a clean unauthenticated client.
-func newClientForAuth(addr string, basePath string, authMethod string) (*vault.Client, error) {
+func newClientForAuth(addr string, basePath string, authMethod string, opts ...vault.ClientOption) (*vault.Client, error) {
 	if authMethod == "oidc" {
 		if stale, err := token.ReadToken(); err == nil {
-			return vault.NewClientWithToken(addr, basePath, stale)
+			return vault.NewClientWithToken(addr, basePath, stale, opts...)
 		}
 	}
-	return vault.NewClient(addr, basePath)
+	return vault.NewClient(addr, basePath, opts...)
 }
 
+// secretMemo is shared by every resolver in this invocation, so each Vault
+// path is read at most once no matter how many workspaces or environments
+// reference it.
+var secretMemo = resolver.NewMemo()
+
+// secretCache keeps [vault] reads for their cache lifetimes (see
+// config.MergedConfig.SecretCacheTTLs) across the resolutions of one
+// invocation, such as the rounds of vx exec --watch, which reset the memo.
+var secretCache = resolver.NewCache(0)
+
+// defaultPathTimeout bounds each Vault read when [resolver] path_timeout is
+// not set.
+const defaultPathTimeout = 30 * time.Second
+
+// defaultRetryBackoff is the wait before the first retry of a read when
+// [resolver.retry] sets no backoff.
+const defaultRetryBackoff = 200 * time.Millisecond
+
 // resolveSecrets uses the resolver to fetch all secrets from Vault concurrently.
-// The basePath is NOT passed to the resolver because ReadKV already handles it
-// via the Vault client's own basePath (avoiding double-prefixing).
+// Secrets mapped to a named connection from [vaults] are read with that
+// connection's client (see namedVaultClient), the rest with client.
+// Ctrl+C cancels the reads still outstanding.
 func resolveSecrets(client *vault.Client, merged *config.MergedConfig) (map[string]string, error) {
-	r := resolver.New(client, "")
+	defer trackPhase("secret resolution")()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	secrets, err := resolveAllVaults(ctx, client, merged)
+	if err != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("interrupted while resolving secrets: %w", err)
+	}
+	return secrets, err
+}
+
+// resolveAllVaults resolves the secrets of merged from every connection they
+// are mapped to, within the [resolver] timeout.
+func resolveAllVaults(ctx context.Context, client *vault.Client, merged *config.MergedConfig) (map[string]string, error) {
+	if t := time.Duration(merged.Resolver.Timeout); t > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t)
+		defer cancel()
+	}
 
-	secrets, err := r.Resolve(merged.Secrets, merged.Environment)
+	groups := groupByVault(merged.Secrets)
+
+	secrets, err := resolveWith(ctx, client, secretMemo, secretCache, merged, groups[""])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range sortedVaultNames(groups) {
+		named, err := namedVaultClient(merged, name)
+		if err != nil {
+			return nil, fmt.Errorf("vault %s: %w", name, err)
+		}
+		values, err := resolveWith(ctx, named, vaultMemo(name), vaultCache(name), merged, groups[name])
+		if err != nil {
+			return nil, fmt.Errorf("vault %s: %w", name, err)
+		}
+		for k, v := range values {
+			secrets[k] = v
+		}
+	}
+
+	return secrets, nil
+}
+
+// allowedCommands returns the programs cmd:// mappings may run, from the
+// user's ~/.vx/config.toml and never from vx.toml, which anyone who can push
+// to the repository controls. The file is only read when mappings include a
+// command.
+func allowedCommands(mappings map[string]string) ([]string, error) {
+	if !slices.ContainsFunc(slices.Collect(maps.Values(mappings)), resolver.IsCommand) {
+		return nil, nil
+	}
+	user, err := config.LoadUserConfig(userConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	return user.Commands, nil
+}
+
+// userConfigPath returns the path of the user's settings
+// (~/.vx/config.toml).
+func userConfigPath() string {
+	return filepath.Join(token.DefaultDir(), config.UserConfigFile)
+}
+
+// resolveWith resolves mappings, paths without any "vault://<name>/"
+// prefix, with client. The basePath is NOT passed to the resolver because
+// ReadKV already handles it via the Vault client's own basePath (avoiding
+// double-prefixing). Reads are kept in cache, when given, for the secrets'
+// cache lifetimes.
+func resolveWith(ctx context.Context, client *vault.Client, memo *resolver.Memo, cache *resolver.Cache, merged *config.MergedConfig, mappings map[string]string) (map[string]string, error) {
+	pathTimeout := time.Duration(merged.Resolver.PathTimeout)
+	if pathTimeout == 0 {
+		pathTimeout = defaultPathTimeout
+	}
+	retryBackoff := time.Duration(merged.Resolver.Retry.Backoff)
+	if retryBackoff == 0 {
+		retryBackoff = defaultRetryBackoff
+	}
+	commands, err := allowedCommands(mappings)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []resolver.Option{
+		resolver.WithMemo(memo),
+		resolver.WithTimeout(pathTimeout),
+		resolver.WithRetry(merged.Resolver.Retry.MaxAttempts, retryBackoff, vault.IsTransient),
+		resolver.WithCache(cache),
+		resolver.WithCacheTTLs(merged.SecretCacheTTLs(globalCacheTTL(merged.Cache))),
+		resolver.WithCommands(commands),
+		resolver.WithSkipOnError(merged.SkipOnError, func(envVar string, err error) {
+			warnUnavailable(merged, envVar, err, "on_error")
+		}),
+	}
+	if flagExecAllowMissing {
+		opts = append(opts, resolver.WithPartialResults())
+	}
+	r := resolver.New(client, "", opts...)
+
+	secrets, err := r.Resolve(ctx, mappings, merged.PathEnv)
+	var rerr *resolver.Error
+	if err != nil && flagExecAllowMissing && errors.As(err, &rerr) && rerr.Cause == nil {
+		for _, f := range rerr.Report() {
+			warnUnavailable(merged, f.EnvVar, f.Err, "--allow-missing")
+		}
+		return secrets, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("resolving secrets: %w", err)
 	}
 
 	return secrets, nil
 }
+
+// warnUnavailable logs that envVar could not be resolved and whether its
+// default stands in for it. reason names what let the run continue.
+func warnUnavailable(merged *config.MergedConfig, envVar string, err error, reason string) {
+	event := log.Warn().Err(err).Str("var", envVar)
+	if _, ok := merged.Defaults[envVar]; ok {
+		event.Msgf("secret unavailable, using its default (%s)", reason)
+	} else {
+		event.Msgf("secret unavailable, leaving it unset (%s)", reason)
+	}
+}
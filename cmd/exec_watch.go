@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"go.dot.industries/vx/internal/config"
+	vxexec "go.dot.industries/vx/internal/exec"
+	"go.dot.industries/vx/internal/vault"
+)
+
+// watchStopTimeout is how long the command gets to exit after SIGTERM when
+// --watch restarts it, before it is killed.
+const watchStopTimeout = 10 * time.Second
+
+// checkWatchFlags rejects the flags that only make sense for a single run.
+func checkWatchFlags() error {
+	switch {
+	case flagExecBreakGlass:
+		return fmt.Errorf("--watch cannot be combined with --break-glass")
+	case flagExecStdin != "":
+		return fmt.Errorf("--watch cannot be combined with --stdin")
+	case len(flagExecFiles) > 0:
+		return fmt.Errorf("--watch cannot be combined with --file")
+	case flagExecExportRuntime != "":
+		return fmt.Errorf("--watch cannot be combined with --export-runtime")
+	case flagExecNoInherit || flagExecCaptureLog != "":
+		return fmt.Errorf("--watch cannot be combined with --no-inherit-stdio or --capture-log")
+	case flagExecWatchInterval <= 0:
+		return fmt.Errorf("--watch-interval must be positive")
+	}
+	return nil
+}
+
+// runWatch runs args with the defaults and secrets of merged, and the --set
+// overrides over both, and restarts it whenever a resolved secret changes: the command gets SIGTERM and
+// watchStopTimeout to exit, then starts again with the new values. It
+// returns when the command exits by itself, exiting vx with its exit code.
+func runWatch(cfg *config.RootConfig, merged *config.MergedConfig, args []string, secrets, overrides map[string]string) error {
+	client, err := authenticatedClient(cfg, merged.Environment)
+	if err != nil {
+		return err
+	}
+	reportProfile()
+
+	for {
+		envVars := make(map[string]string, len(merged.Defaults)+len(secrets))
+		for k, v := range merged.Defaults {
+			envVars[k] = v
+		}
+		for k, v := range secrets {
+			envVars[k] = v
+		}
+		maps.Copy(envVars, overrides)
+
+		masked := maskOptions(secrets, envVars)
+		command, runOpts, err := applyExecInputs(args, envVars)
+		if err != nil {
+			return err
+		}
+		runOpts = append(runOpts, masked...)
+		if err := checkEnvSize(command, envVars); err != nil {
+			return err
+		}
+		runOpts = append(runOpts,
+			vxexec.WithGracefulStop(watchStopTimeout),
+			vxexec.WithKillTimeout(flagExecKillTimeout),
+			vxexec.WithHostEnv(merged.EnvPassthrough, merged.EnvBlock))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- vxexec.Run(ctx, command, envVars, runOpts...)
+		}()
+		changed := make(chan map[string]string, 1)
+		go watchSecrets(ctx, client, merged, secrets, changed)
+
+		log.Info().
+			Int("secrets", len(secrets)).
+			Dur("interval", flagExecWatchInterval).
+			Msg("running command; restarting it when secrets change")
+
+		select {
+		case err := <-done:
+			cancel()
+			if err != nil {
+				os.Exit(vxexec.ExitCode(err))
+			}
+			return nil
+		case secrets = <-changed:
+			log.Info().Msg("secrets changed; restarting command")
+			cancel()
+			<-done
+		}
+	}
+}
+
+// watchSecrets resolves merged's secrets again every --watch-interval, and
+// right away when Vault reports a change to a secret under the mount, and
+// sends the new values on changed once they differ from current. Failed
+// resolutions are logged and retried at the next tick. It returns when ctx
+// is done or after sending.
+func watchSecrets(ctx context.Context, client *vault.Client, merged *config.MergedConfig, current map[string]string, changed chan<- map[string]string) {
+	events := make(chan struct{}, 1)
+	go func() {
+		err := client.WatchKV(ctx, func(vault.KVChange) {
+			select {
+			case events <- struct{}{}:
+			default:
+			}
+		})
+		switch {
+		case errors.Is(err, vault.ErrEventsUnsupported):
+			log.Debug().Msg("vault does not support events; polling for secret changes")
+		case err != nil:
+			log.Debug().Err(err).Msg("vault event subscription ended; polling for secret changes")
+		}
+	}()
+
+	ticker := time.NewTicker(flagExecWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-events:
+			// A value changed in Vault, so cached reads may be stale.
+			clearSecretCaches()
+		}
+
+		// Every resolution so far is memoized; read Vault afresh, except
+		// for reads still within their cache lifetime.
+		resetSecretMemos()
+		secrets, err := resolveSecrets(client, merged)
+		if err != nil {
+			log.Warn().Err(err).Msg("re-resolving secrets failed; keeping the running command")
+			continue
+		}
+		if !maps.Equal(secrets, current) {
+			changed <- secrets
+			return
+		}
+	}
+}
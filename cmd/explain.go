@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/resolver"
+)
+
+var flagExplainCheck bool
+
+func init() {
+	explainCmd.Flags().BoolVar(&flagExplainCheck, "check", false, "log in and check that the winning secret's key exists in Vault")
+	rootCmd.AddCommand(explainCmd)
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <ENV_VAR>",
+	Short: "Show where a variable's value comes from",
+	Long: `Lists every place the current environment and workspace define a variable:
+[defaults] and [defaults.<env>] in the root and workspace vx.toml files, and
+[secrets] mappings in either. The definition that wins is marked, the
+others are shown as overridden. Secrets win over defaults, and workspace
+files over the root one.
+
+For a secret, the Vault path after ${env} is replaced is shown, split into
+the path that is read and the key taken from it. With --check vx logs in
+and reports whether that key exists; the value is never printed.
+
+  vx explain DATABASE_URL
+  vx explain -e production -w api DATABASE_URL --check`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExplain,
+}
+
+// explainRow is a definition along with the file it is in.
+type explainRow struct {
+	file   string
+	source config.Source
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	env := resolveEnv(cfg)
+
+	workspace, err := detectWorkspace(cfg, rootDir, []string{})
+	if err != nil {
+		return err
+	}
+
+	rows, winner, err := explainDefinitions(cfg, rootDir, workspace, env, name)
+	if err != nil {
+		return err
+	}
+
+	if winner < 0 {
+		return fmt.Errorf("%s is not defined in any [secrets] or [defaults] table", name)
+	}
+
+	if workspace != "" {
+		fmt.Printf("%s in %s, workspace %s\n\n", name, env, workspace)
+	} else {
+		fmt.Printf("%s in %s, all workspaces\n\n", name, env)
+	}
+
+	for i, row := range rows {
+		status := "overridden"
+		if i == winner {
+			status = "used"
+		}
+		fmt.Printf("  %-10s  %-25s  %-22s  %s\n", status, row.file, "["+row.source.Table+"]", row.source.Value)
+	}
+
+	if workspace == "" && len(cfg.Workspaces) > 0 {
+		fmt.Println("\nNo workspace detected, so every workspace is merged and the last one")
+		fmt.Println("listed in vx.toml wins. Pass -w to explain a single workspace.")
+	}
+
+	for _, format := range config.ExportFormats {
+		if to, ok := cfg.ExportRenames(format)[name]; ok {
+			fmt.Printf("\nExported as %s by %s output.\n", to, format)
+		}
+	}
+
+	used := rows[winner].source
+	if !used.IsSecret() {
+		fmt.Printf("\nThe value is a default from %s; Vault is not read.\n", rows[winner].file)
+		return nil
+	}
+
+	if resolver.IsCommand(used.Value) {
+		line := strings.TrimPrefix(resolver.Interpolate(used.Value, cfg.Environments.PathSegment(env)), resolver.CommandScheme)
+		fmt.Printf("\nRuns:        %s\n", line)
+		user, err := config.LoadUserConfig(userConfigPath())
+		if err != nil {
+			return err
+		}
+		if fields := strings.Fields(line); len(fields) == 0 || !slices.Contains(user.Commands, fields[0]) {
+			fmt.Printf("             (not allowed: add the program to commands in %s)\n", userConfigPath())
+		}
+		if flagExplainCheck {
+			fmt.Println("\n--check does not run commands.")
+		}
+		return nil
+	}
+
+	vaultName, mapping := config.SplitVault(used.Value)
+	basePath := cfg.Vault.BasePath
+	if v, ok := cfg.Vaults[vaultName]; ok {
+		basePath = v.BasePath
+	}
+
+	mapping, version := resolver.SplitVersion(mapping)
+	resolved := strings.Trim(path.Clean("/"+resolver.Interpolate(mapping, cfg.Environments.PathSegment(env))), "/")
+	idx := strings.LastIndex(resolved, "/")
+	if idx < 0 {
+		return fmt.Errorf("secret path %q has no key segment (expected <path>/<key>)", resolved)
+	}
+	kvPath, key := resolved[:idx], resolved[idx+1:]
+
+	if vaultName != "" {
+		fmt.Printf("\nCluster:     %s (%s)\n", vaultName, cfg.Vaults[vaultName].Address)
+	} else {
+		fmt.Println()
+	}
+	fmt.Printf("Vault path:  %s/%s\n", strings.Trim(basePath, "/"), resolved)
+	if version != 0 {
+		fmt.Printf("Reads:       %s, key %q, version %d\n", kvPath, key, version)
+	} else {
+		fmt.Printf("Reads:       %s, key %q\n", kvPath, key)
+	}
+
+	if !flagExplainCheck {
+		return nil
+	}
+
+	merged, err := config.Merge(cfg, nil, env)
+	if err != nil {
+		return err
+	}
+	client, _, err := clientForMapping(cfg, merged, used.Value)
+	if err != nil {
+		return err
+	}
+
+	var data map[string]string
+	if version != 0 {
+		data, err = client.ReadKVVersion(context.Background(), kvPath, version)
+	} else {
+		data, err = client.ReadKV(context.Background(), kvPath)
+	}
+	if err != nil {
+		return err
+	}
+	if _, ok := data[key]; !ok {
+		fmt.Printf("Vault:       key %q not found at %s\n", key, kvPath)
+		return fmt.Errorf("secret %s not found at path %s", name, used.Value)
+	}
+	fmt.Println("Vault:       key exists")
+	return nil
+}
+
+// explainDefinitions returns the definitions of name in precedence order
+// and the index of the one that wins, or -1 when there is none. Without a
+// workspace, every workspace is merged like mergeAllWorkspaces does: each
+// one's merge overwrites the previous, so the last workspace whose merge
+// defines name decides.
+func explainDefinitions(cfg *config.RootConfig, rootDir string, workspace string, env string, name string) ([]explainRow, int, error) {
+	rootFile := relativeTo(rootDir, rootConfigPath(rootDir))
+
+	if workspace != "" {
+		wsPath, err := config.ResolveWorkspacePath(rootDir, workspace, cfg.Workspaces)
+		if err != nil {
+			return nil, -1, fmt.Errorf("resolving workspace path: %w", err)
+		}
+		wsCfg, err := config.LoadWorkspaceConfig(wsPath)
+		if err != nil {
+			return nil, -1, fmt.Errorf("loading workspace config: %w", err)
+		}
+
+		e, err := config.Explain(cfg, wsCfg, env, name)
+		if err != nil {
+			return nil, -1, err
+		}
+		rows := explainRows(e, rootFile, relativeTo(rootDir, wsPath), true)
+		return rows, len(rows) - 1, nil
+	}
+
+	e, err := config.Explain(cfg, nil, env, name)
+	if err != nil {
+		return nil, -1, err
+	}
+	rows := explainRows(e, rootFile, "", true)
+	winner := len(rows) - 1
+
+	for _, wsRelPath := range cfg.Workspaces {
+		wsCfg, err := config.LoadWorkspaceConfig(filepath.Join(rootDir, wsRelPath))
+		if err != nil {
+			continue
+		}
+		wsE, err := config.Explain(cfg, wsCfg, env, name)
+		if err != nil {
+			continue
+		}
+
+		wsRows := explainRows(wsE, rootFile, wsRelPath, false)
+		rows = append(rows, wsRows...)
+
+		w, ok := wsE.Winner()
+		if !ok {
+			continue
+		}
+		if w.Workspace {
+			winner = len(rows) - len(wsRows) + lastIndexOf(wsRows, w)
+		} else {
+			winner = lastIndexOf(rows[:len(rows)-len(wsRows)], w)
+		}
+	}
+
+	return rows, winner, nil
+}
+
+// explainRows labels the sources of e with their files. Root sources are
+// left out when includeRoot is false.
+func explainRows(e *config.Explanation, rootFile string, wsFile string, includeRoot bool) []explainRow {
+	var rows []explainRow
+	for _, s := range e.Sources {
+		switch {
+		case s.Workspace:
+			rows = append(rows, explainRow{file: wsFile, source: s})
+		case includeRoot:
+			rows = append(rows, explainRow{file: rootFile, source: s})
+		}
+	}
+	return rows
+}
+
+// lastIndexOf returns the index of the last row holding s.
+func lastIndexOf(rows []explainRow, s config.Source) int {
+	for i := len(rows) - 1; i >= 0; i-- {
+		if rows[i].source == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// relativeTo returns p relative to dir when possible, for shorter output.
+func relativeTo(dir string, p string) string {
+	if rel, err := filepath.Rel(dir, p); err == nil {
+		return rel
+	}
+	return p
+}
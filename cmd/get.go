@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/clipboard"
+	"go.dot.industries/vx/internal/config"
+)
+
+var (
+	flagGetCopy       bool
+	flagGetClearAfter time.Duration
+	flagGetWrap       bool
+	flagGetWrapTTL    time.Duration
+
+	flagClipboardAfter       time.Duration
+	flagClipboardFingerprint string
+)
+
+func init() {
+	getCmd.Flags().BoolVar(&flagGetCopy, "copy", false, "copy the value to the clipboard instead of printing it")
+	getCmd.Flags().DurationVar(&flagGetClearAfter, "clear-after", 0, "with --copy, clear the clipboard after this long (overrides clipboard.clear_after)")
+	getCmd.Flags().BoolVar(&flagGetWrap, "wrap", false, "output a single-use Vault wrapping token for the value instead of the value")
+	getCmd.Flags().DurationVar(&flagGetWrapTTL, "wrap-ttl", 15*time.Minute, "with --wrap, how long the wrapping token stays valid")
+	rootCmd.AddCommand(getCmd)
+
+	clipboardClearCmd.Flags().DurationVar(&flagClipboardAfter, "after", 0, "delay before clearing")
+	clipboardClearCmd.Flags().StringVar(&flagClipboardFingerprint, "fingerprint", "", "fingerprint of the copied value")
+	rootCmd.AddCommand(clipboardClearCmd)
+}
+
+var getCmd = &cobra.Command{
+	Use:   "get <ENV_VAR>",
+	Short: "Print or copy a single resolved secret",
+	Long: `Resolves one mapped secret (or default) for the current environment and
+workspace and prints its value.
+
+With --copy the value is placed on the clipboard instead. If
+clipboard.clear_after is set in vx.toml (or --clear-after is given), a
+background process clears the clipboard after that delay unless something
+else has been copied in the meantime.
+
+With --wrap vx prints a single-use wrapping token instead of the value, so
+the value itself never reaches the terminal, the clipboard, or the chat it
+is shared over. vx still reads and resolves the value locally, then writes
+it back to Vault's cubbyhole behind the token, which expires after
+--wrap-ttl. Send the token to a teammate, who reads the value with
+` + "`vx unwrap <token>`" + `. An unwrap error on their side means someone else
+used the token first.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runGet,
+}
+
+// clipboardClearCmd is spawned in the background by `vx get --copy`.
+var clipboardClearCmd = &cobra.Command{
+	Use:    "clipboard-clear",
+	Short:  "Clear the clipboard if it still holds a copied secret",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE:   runClipboardClear,
+}
+
+func runGet(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	env := resolveEnv(cfg)
+
+	workspace, err := detectWorkspace(cfg, rootDir, []string{})
+	if err != nil {
+		return err
+	}
+
+	merged, err := mergeForWorkspace(cfg, rootDir, workspace, env)
+	if err != nil {
+		return err
+	}
+
+	value, err := resolveOne(cfg, merged, name)
+	if err != nil {
+		return err
+	}
+
+	if flagGetWrap {
+		if value, err = wrapValue(cfg, env, name, value); err != nil {
+			return err
+		}
+	}
+
+	if !flagGetCopy {
+		fmt.Println(value)
+		return nil
+	}
+
+	if err := clipboard.System.WriteAll(value); err != nil {
+		return fmt.Errorf("copying to clipboard: %w", err)
+	}
+
+	clearAfter := time.Duration(cfg.Clipboard.ClearAfter)
+	if cmd.Flags().Changed("clear-after") {
+		clearAfter = flagGetClearAfter
+	}
+
+	if clearAfter <= 0 {
+		log.Info().Str("name", name).Msg("copied to clipboard")
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("resolving vx binary: %w", err)
+	}
+	if err := clipboard.StartClearProcess(exe, clearAfter, clipboard.Fingerprint(value)); err != nil {
+		log.Warn().Err(err).Msg("clipboard will not be cleared automatically")
+		return nil
+	}
+
+	log.Info().
+		Str("name", name).
+		Dur("clears_in", clearAfter).
+		Msg("copied to clipboard")
+	return nil
+}
+
+// resolveOne returns the value of a single mapped secret or default. Only the
+// requested secret is read from Vault.
+func resolveOne(cfg *config.RootConfig, merged *config.MergedConfig, name string) (string, error) {
+	path, ok := merged.Secrets[name]
+	if !ok {
+		if val, ok := merged.Defaults[name]; ok {
+			return val, nil
+		}
+		return "", fmt.Errorf("%s is not mapped in this workspace (see `vx list`)", name)
+	}
+
+	client, err := authenticatedClient(cfg, merged.Environment)
+	if err != nil {
+		return "", err
+	}
+
+	single := *merged
+	single.Secrets = map[string]string{name: path}
+
+	secrets, err := resolveSecrets(client, &single)
+	if err != nil {
+		return "", err
+	}
+
+	val, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found at path %s", name, path)
+	}
+	return val, nil
+}
+
+// wrapValue stores value behind a single-use wrapping token and returns the
+// token. The value has already been resolved locally: it may come from a
+// default, a template, or a command rather than a single KV field, so a
+// wrapped KV read would not carry it.
+func wrapValue(cfg *config.RootConfig, env, name, value string) (string, error) {
+	client, err := authenticatedClient(cfg, env)
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := client.Wrap(map[string]string{name: value}, flagGetWrapTTL)
+	if err != nil {
+		return "", err
+	}
+
+	log.Info().
+		Str("name", name).
+		Time("expires", wrapped.Expires.Local()).
+		Msg("wrapped; share the token, it can be unwrapped once with `vx unwrap`")
+	return wrapped.Token, nil
+}
+
+func runClipboardClear(cmd *cobra.Command, args []string) error {
+	if flagClipboardFingerprint == "" {
+		return fmt.Errorf("--fingerprint is required")
+	}
+
+	time.Sleep(flagClipboardAfter)
+
+	_, err := clipboard.ClearIfUnchanged(clipboard.System, flagClipboardFingerprint)
+	return err
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/dotenv"
+	"go.dot.industries/vx/internal/mappings"
+	"go.dot.industries/vx/internal/resolver"
+)
+
+var (
+	flagImportPath  string
+	flagImportWrite bool
+	flagImportYes   bool
+)
+
+func init() {
+	importCmd.Flags().StringVar(&flagImportPath, "path", "", "Vault path to write the keys under, e.g. '${env}/myservice' (required)")
+	importCmd.Flags().BoolVar(&flagImportWrite, "write", false, "write the values and mappings (default: dry-run)")
+	importCmd.Flags().BoolVarP(&flagImportYes, "yes", "y", false, "write to a protected environment without asking")
+	importCmd.MarkFlagRequired("path")
+	rootCmd.AddCommand(importCmd)
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file.env> --path <vault-path>",
+	Short: "Move a .env file's values into Vault and map them",
+	Long: `Reads a dotenv file, writes every value to one Vault secret for the
+current environment, and adds a mapping for each variable to the workspace's
+vx.toml (the root vx.toml when no workspace is selected). Each variable
+becomes a key named after it, lowercased:
+
+  vx import .env -w api --path '${env}/api' --write
+
+maps DATABASE_URL to "${env}/api/database_url" and writes the value to
+<base_path>/data/dev/api. Keys already in the secret are kept unless the file
+sets them. Mappings are added or repointed, preserving comments and layout.
+A --path starting with "vault://<name>/" writes to that [vaults] connection;
+otherwise the values go to the workspace's vault, if it names one.
+
+By default runs in dry-run mode and prints the plan without any values. Use
+--write to apply it, then delete the .env file.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	values, err := dotenv.ParseFile(args[0])
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("%s has no variables to import", args[0])
+	}
+
+	prefix := strings.Trim(flagImportPath, "/")
+	if prefix == "" {
+		return fmt.Errorf("--path is required")
+	}
+	if resolver.IsCommand(prefix) {
+		return fmt.Errorf("--path must be a Vault path, not a command")
+	}
+
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	env := resolveEnv(cfg)
+
+	workspace, err := detectWorkspace(cfg, rootDir, []string{})
+	if err != nil {
+		return err
+	}
+
+	file, err := importTarget(cfg, rootDir, workspace)
+	if err != nil {
+		return err
+	}
+
+	// The values go to the Vault the mappings will read from: the one --path
+	// names, else the workspace's.
+	vaultName, vaultPrefix := config.SplitVault(prefix)
+	if vaultName == "" && workspace != "" {
+		wsCfg, err := config.LoadWorkspaceConfig(filepath.Join(rootDir, filepath.FromSlash(file)))
+		if err != nil {
+			return fmt.Errorf("loading workspace config: %w", err)
+		}
+		vaultName = wsCfg.Vault
+	}
+	if _, ok := cfg.Vaults[vaultName]; vaultName != "" && !ok {
+		return fmt.Errorf("vault %q is not defined in [vaults]", vaultName)
+	}
+
+	// Keys are lowercased; two variables differing only in case would
+	// overwrite each other.
+	data := make(map[string]string, len(values))
+	owner := make(map[string]string, len(values))
+	for _, name := range sortedKeys(values) {
+		key := strings.ToLower(name)
+		if other, ok := owner[key]; ok {
+			return fmt.Errorf("%s and %s would both be stored as key %q", other, name, key)
+		}
+		owner[key] = name
+		data[key] = values[name]
+	}
+
+	doc := &mappings.Document{
+		Version:  mappings.FormatVersion,
+		Mappings: mappings.UnderPath(file, prefix, sortedKeys(values)),
+	}
+	plan, err := mappings.PlanImport(rootDir, cfg, doc, false)
+	if err != nil {
+		return err
+	}
+
+	kvPath := strings.Trim(path.Clean("/"+resolver.Interpolate(vaultPrefix, cfg.Environments.PathSegment(env))), "/")
+	target := env
+	if vaultName != "" {
+		target = vaultName + ", " + env
+	}
+
+	if !flagImportWrite {
+		fmt.Println("# Dry run — use --write to apply")
+		fmt.Println()
+	}
+	fmt.Printf("Vault (%s): write %d key(s) to %s\n", target, len(data), kvPath)
+	for _, key := range sortedKeys(data) {
+		fmt.Printf("  %s\n", key)
+	}
+	fmt.Println()
+	printMappingsPlan(plan)
+
+	if !flagImportWrite {
+		return nil
+	}
+
+	if cfg.TUI.IsProtected(env) && !flagImportYes {
+		if err := confirmEnvironment(env); err != nil {
+			return err
+		}
+	}
+
+	merged, err := config.Merge(cfg, nil, env)
+	if err != nil {
+		return err
+	}
+	rawPath := vaultPrefix
+	if vaultName != "" {
+		rawPath = config.VaultScheme + vaultName + "/" + vaultPrefix
+	}
+	client, _, err := clientForMapping(cfg, merged, rawPath)
+	if err != nil {
+		return err
+	}
+	if err := client.PatchKV(kvPath, data); err != nil {
+		return err
+	}
+
+	// The values are safe in Vault at this point; a failed vx.toml edit can
+	// be retried without writing them again.
+	if err := plan.Apply(); err != nil {
+		return fmt.Errorf("values written to %s, but updating %s failed: %w", kvPath, file, err)
+	}
+
+	fmt.Printf("\nwrote %d key(s) to %s and applied %d mapping change(s)\n", len(data), kvPath, len(plan.Changes))
+	return nil
+}
+
+// importTarget returns the vx.toml that imported mappings go to, relative to
+// the root in the form mappings.Mapping.File uses.
+func importTarget(cfg *config.RootConfig, rootDir, workspace string) (string, error) {
+	if workspace == "" {
+		return mappings.RootFile, nil
+	}
+
+	wsPath, err := config.ResolveWorkspacePath(rootDir, workspace, cfg.Workspaces)
+	if err != nil {
+		return "", fmt.Errorf("resolving workspace path: %w", err)
+	}
+	rel, err := filepath.Rel(rootDir, wsPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/scaffold"
+)
+
+var (
+	flagInitYes   bool
+	flagInitForce bool
+)
+
+func init() {
+	initCmd.Flags().BoolVarP(&flagInitYes, "yes", "y", false, "don't prompt: use the defaults, --vault-addr, --auth, and the detected workspaces")
+	initCmd.Flags().BoolVar(&flagInitForce, "force", false, "overwrite an existing root vx.toml")
+	rootCmd.AddCommand(initCmd)
+}
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create the root vx.toml of a new project, step by step",
+	Long: `Asks for the Vault address, auth method, KV mount, environments, and
+workspaces, then writes a commented root vx.toml in the current directory (or
+at --config). Workspaces are suggested from package.json "workspaces",
+pnpm-workspace.yaml, Cargo.toml [workspace] members, and go.work "use"
+directives; each one gets an empty vx.toml unless it already has one.
+
+With --yes nothing is asked: the address comes from --vault-addr or
+VAULT_ADDR, the auth method from --auth (oidc otherwise), and every detected
+workspace is added:
+
+  vx init --yes --vault-addr https://vault.example.com`,
+	Args: cobra.NoArgs,
+	RunE: runInit,
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	path := flagConfigDir
+	if path == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("getting working directory: %w", err)
+		}
+		path = filepath.Join(cwd, scaffold.ConfigFile)
+	}
+	if _, err := os.Stat(path); err == nil && !flagInitForce {
+		return fmt.Errorf("%s already exists; use --force to overwrite it", path)
+	}
+	rootDir := filepath.Dir(path)
+
+	detected, err := config.FindWorkspaceCandidates(rootDir)
+	if err != nil {
+		return fmt.Errorf("detecting workspaces: %w", err)
+	}
+
+	opts := scaffold.Options{
+		Address:      cmp.Or(flagVaultAddr, os.Getenv("VAULT_ADDR")),
+		AuthMethod:   cmp.Or(flagAuth, scaffold.AuthMethods[0]),
+		BasePath:     "secret",
+		Environments: []string{"dev", "staging", "production"},
+		Default:      "dev",
+	}
+	for _, d := range detected {
+		opts.Workspaces = append(opts.Workspaces, d.Dir)
+	}
+
+	if !flagInitYes {
+		if !term.IsTerminal(os.Stdin.Fd()) {
+			return fmt.Errorf("no terminal to prompt in; use --yes with --vault-addr")
+		}
+		opts, err = askInitOptions(prompter{bufio.NewReader(os.Stdin)}, opts, detected)
+		if err != nil {
+			return err
+		}
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, scaffold.RootConfig(opts), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s\n", relPath(rootDir, path))
+
+	for _, ws := range opts.Workspaces {
+		p := filepath.Join(rootDir, filepath.FromSlash(scaffold.WorkspacePath(ws)))
+		if _, err := os.Stat(p); err == nil {
+			fmt.Printf("kept %s, which already exists\n", relPath(rootDir, p))
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, scaffold.WorkspaceConfig(), 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("wrote %s\n", relPath(rootDir, p))
+	}
+
+	fmt.Println("\nNext, map secrets under [secrets], then run `vx login` and `vx validate`.")
+	return nil
+}
+
+// askInitOptions prompts for each of the options, offering the current
+// values as defaults. An answer that cannot be used is asked again.
+func askInitOptions(p prompter, opts scaffold.Options, detected []config.WorkspaceCandidate) (scaffold.Options, error) {
+	var err error
+
+	for {
+		if opts.Address, err = p.ask("Vault address", opts.Address); err != nil {
+			return opts, err
+		}
+		if opts.Address != "" {
+			break
+		}
+		fmt.Println("  The address is required, e.g. https://vault.example.com")
+	}
+
+	methods := strings.Join(scaffold.AuthMethods, ", ")
+	for {
+		if opts.AuthMethod, err = p.ask("Auth method ("+methods+")", opts.AuthMethod); err != nil {
+			return opts, err
+		}
+		if slices.Contains(scaffold.AuthMethods, opts.AuthMethod) {
+			break
+		}
+		fmt.Printf("  Pick one of %s\n", methods)
+	}
+
+	for {
+		question := "Vault role to log in with (empty for the auth method's default)"
+		if opts.AuthMethod == "kubernetes" {
+			question = "Vault role to log in with"
+		}
+		if opts.AuthRole, err = p.ask(question, opts.AuthRole); err != nil {
+			return opts, err
+		}
+		if opts.AuthRole != "" || opts.AuthMethod != "kubernetes" {
+			break
+		}
+		fmt.Println("  Kubernetes logins need a role")
+	}
+
+	if opts.BasePath, err = p.ask("KV mount the secret paths are under", opts.BasePath); err != nil {
+		return opts, err
+	}
+
+	answer, err := p.ask("Environments", strings.Join(opts.Environments, ", "))
+	if err != nil {
+		return opts, err
+	}
+	opts.Environments = splitList(answer)
+
+	if !slices.Contains(opts.Environments, opts.Default) && len(opts.Environments) > 0 {
+		opts.Default = opts.Environments[0]
+	}
+	for {
+		if opts.Default, err = p.ask("Default environment", opts.Default); err != nil {
+			return opts, err
+		}
+		if slices.Contains(opts.Environments, opts.Default) {
+			break
+		}
+		fmt.Printf("  Pick one of %s\n", strings.Join(opts.Environments, ", "))
+	}
+
+	opts.Workspaces = nil
+	if len(detected) > 0 {
+		fmt.Printf("Found %d workspace(s):\n", len(detected))
+		for _, d := range detected {
+			fmt.Printf("  %s (from %s)\n", d.Dir, d.Source)
+		}
+		answer, err := p.ask("Add them? [Y/n]", "")
+		if err != nil {
+			return opts, err
+		}
+		if a := strings.ToLower(answer); a != "n" && a != "no" {
+			for _, d := range detected {
+				opts.Workspaces = append(opts.Workspaces, d.Dir)
+			}
+		}
+	}
+
+	answer, err = p.ask("Other workspace directories, comma-separated (empty for none)", "")
+	if err != nil {
+		return opts, err
+	}
+	for _, dir := range splitList(answer) {
+		dir = filepath.ToSlash(filepath.Clean(dir))
+		if !slices.Contains(opts.Workspaces, dir) {
+			opts.Workspaces = append(opts.Workspaces, dir)
+		}
+	}
+
+	return opts, nil
+}
+
+// prompter asks questions on the terminal.
+type prompter struct {
+	in *bufio.Reader
+}
+
+// ask prints question with def in brackets and returns the trimmed answer,
+// or def for an empty one.
+func (p prompter) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Printf("%s [%s]: ", question, def)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+
+	line, err := p.in.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Println()
+		return "", errors.New("cancelled, nothing was written")
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// splitList splits a comma- or space-separated answer into its items.
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// relPath returns path relative to dir for display, or path itself.
+func relPath(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/tomlfmt"
+)
+
+var (
+	flagLintFix   bool
+	flagLintCheck bool
+)
+
+func init() {
+	lintCmd.Flags().BoolVar(&flagLintFix, "fix", false, "rewrite the files in canonical form (default: dry-run)")
+	lintCmd.Flags().BoolVar(&flagLintCheck, "check", false, "exit with an error if any file is not in canonical form, without a diff")
+	lintCmd.MarkFlagsMutuallyExclusive("fix", "check")
+	rootCmd.AddCommand(lintCmd)
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check that vx.toml files are in canonical form",
+	Long: `Formats the root vx.toml and every workspace vx.toml in one canonical
+layout, so config diffs stay small and reviewable:
+
+  - keys are sorted within each table, version first; a key under a comment
+    starts a new group and stays at its head
+  - literal strings that need no escaping are written with double quotes,
+    and keys are quoted only when they must be
+  - trailing comments of neighbouring keys are aligned
+  - comments stay attached to the keys they describe
+
+By default the changes are shown as a diff. With --fix the files are
+rewritten in place. In CI, --check lists the files that are not formatted
+and fails without changing anything:
+
+  vx lint --check`,
+	Args: cobra.NoArgs,
+	RunE: runLint,
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	paths := []string{rootConfigPath(rootDir)}
+	for _, ws := range cfg.Workspaces {
+		paths = append(paths, filepath.Join(rootDir, ws))
+	}
+
+	files, err := tomlfmt.Plan(paths)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Printf("all %d vx.toml file(s) are formatted\n", len(paths))
+		return nil
+	}
+
+	if flagLintCheck {
+		for _, f := range files {
+			fmt.Println(relativeTo(rootDir, f.Path))
+		}
+		return fmt.Errorf("%d vx.toml file(s) are not formatted; run `vx lint --fix`", len(files))
+	}
+
+	if !flagLintFix {
+		fmt.Println("# Dry run — use --fix to apply")
+		fmt.Println()
+	}
+	for _, f := range files {
+		fmt.Print(f.Diff(relativeTo(rootDir, f.Path)))
+		fmt.Println()
+	}
+
+	if !flagLintFix {
+		return nil
+	}
+
+	if err := tomlfmt.Write(files); err != nil {
+		return err
+	}
+	fmt.Printf("formatted %d file(s)\n", len(files))
+	return nil
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"sort"
 
 	"github.com/rs/zerolog/log"
@@ -9,12 +10,17 @@ import (
 
 	"go.dot.industries/vx/internal/config"
 	"go.dot.industries/vx/internal/resolver"
+	"go.dot.industries/vx/internal/shellenv"
 )
 
-var flagFormat string
+var (
+	flagFormat string
+	flagShell  string
+)
 
 func init() {
-	listCmd.Flags().StringVar(&flagFormat, "format", "table", "output format: table, dotenv")
+	listCmd.Flags().StringVar(&flagFormat, "format", "table", "output format: table, dotenv, shell")
+	listCmd.Flags().StringVar(&flagShell, "shell", "", "syntax for --format=shell: posix, fish, or powershell (default: powershell on Windows, posix elsewhere)")
 	rootCmd.AddCommand(listCmd)
 }
 
@@ -27,7 +33,17 @@ The default "table" format shows Vault paths without fetching values.
 Use --format=dotenv to resolve secrets from Vault and output KEY=VALUE pairs
 suitable for piping to a .env file:
 
-  vx list --format=dotenv > .env.docker`,
+  vx list --format=dotenv > .env.docker
+
+Use --format=shell to print statements that set the values in the current
+shell, quoted for it: export lines for sh/bash/zsh, or $env: assignments for
+PowerShell (the default on Windows):
+
+  eval "$(vx list --format=shell)"
+  vx list --format=shell | Out-String | Invoke-Expression
+
+Names can be translated for consumers that expect different ones with
+[exports.dotenv.rename] and [exports.shell.rename] tables in vx.toml.`,
 	Args: cobra.NoArgs,
 	RunE: runList,
 }
@@ -62,8 +78,10 @@ func runList(cmd *cobra.Command, args []string) error {
 		return printTable(merged, env, workspace)
 	case "dotenv":
 		return printDotenv(cfg, merged)
+	case "shell":
+		return printShell(cfg, merged)
 	default:
-		return fmt.Errorf("unsupported format %q (use table or dotenv)", flagFormat)
+		return fmt.Errorf("unsupported format %q (use table, dotenv, or shell)", flagFormat)
 	}
 }
 
@@ -80,7 +98,7 @@ func printTable(merged *config.MergedConfig, env string, workspace string) error
 
 		names := sortedKeys(merged.Secrets)
 		for _, name := range names {
-			path := resolver.Interpolate(merged.Secrets[name], env)
+			path := resolver.Interpolate(merged.Secrets[name], merged.PathEnv)
 			fmt.Printf("  %-35s -> %s\n", name, path)
 		}
 		fmt.Println()
@@ -100,15 +118,52 @@ func printTable(merged *config.MergedConfig, env string, workspace string) error
 
 // printDotenv resolves secrets from Vault and outputs KEY=VALUE lines.
 func printDotenv(cfg *config.RootConfig, merged *config.MergedConfig) error {
-	vaultClient, err := authenticatedClient(cfg, merged.Environment)
+	all, err := resolveAll(cfg, merged)
 	if err != nil {
 		return err
 	}
+	all = config.RenameKeys(all, cfg.ExportRenames("dotenv"))
 
-	secrets, err := resolveSecrets(vaultClient, merged)
+	names := sortedKeys(all)
+	for _, name := range names {
+		fmt.Printf("%s=%s\n", name, all[name])
+	}
+
+	return nil
+}
+
+// printShell resolves secrets from Vault and outputs statements that set
+// them in the shell selected by --shell.
+func printShell(cfg *config.RootConfig, merged *config.MergedConfig) error {
+	dialect := shellenv.Default()
+	if flagShell != "" {
+		d, err := shellenv.ParseDialect(flagShell)
+		if err != nil {
+			return err
+		}
+		dialect = d
+	}
+
+	all, err := resolveAll(cfg, merged)
 	if err != nil {
 		return err
 	}
+	all = config.RenameKeys(all, cfg.ExportRenames("shell"))
+
+	return shellenv.Render(os.Stdout, dialect, all)
+}
+
+// resolveAll resolves secrets from Vault and overlays them on the defaults.
+func resolveAll(cfg *config.RootConfig, merged *config.MergedConfig) (map[string]string, error) {
+	vaultClient, err := authenticatedClient(cfg, merged.Environment)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := resolveSecrets(vaultClient, merged)
+	if err != nil {
+		return nil, err
+	}
 
 	// Merge: defaults first, secrets override.
 	all := make(map[string]string, len(merged.Defaults)+len(secrets))
@@ -119,12 +174,7 @@ func printDotenv(cfg *config.RootConfig, merged *config.MergedConfig) error {
 		all[k] = v
 	}
 
-	names := sortedKeys(all)
-	for _, name := range names {
-		fmt.Printf("%s=%s\n", name, all[name])
-	}
-
-	return nil
+	return all, nil
 }
 
 func sortedKeys(m map[string]string) []string {
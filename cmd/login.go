@@ -1,24 +1,57 @@
 package cmd
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"os"
 
+	"github.com/charmbracelet/x/term"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
+	"go.dot.industries/vx/internal/config"
 	"go.dot.industries/vx/internal/token"
 	"go.dot.industries/vx/internal/vault"
 )
 
+var flagLoginNoBrowser bool
+
 func init() {
+	loginCmd.Flags().BoolVar(&flagLoginNoBrowser, "no-browser", false, "print the login URL (and device code) instead of opening a browser")
 	rootCmd.AddCommand(loginCmd)
 }
 
 var loginCmd = &cobra.Command{
 	Use:   "login",
-	Short: "Authenticate with Vault via OIDC and start the token daemon",
+	Short: "Authenticate with Vault via OIDC, Kubernetes, or a token and start the token daemon",
 	Long: `Opens a browser for OIDC authentication with Vault. On success the
-token is saved to ~/.vx/token and the background renewal daemon is started.`,
+token is saved to ~/.vx/tokens/<host>, one per Vault server, and the
+background renewal daemon is started.
+
+The OIDC mount and role come from auth_mount and auth_role in vx.toml; a
+[vault.auth_roles] entry for the selected environment (--env) overrides the
+role. The callback listens on the first free port of auth_listen_ports
+(default 8250), or on --callback-port.
+
+On machines without a browser (SSH sessions, containers), --no-browser
+prints the login URL to open elsewhere instead. For roles with
+callback_mode = "device", it also prints the code to enter there, and vx
+polls Vault until the login is complete. Other roles redirect the browser
+to the callback port, which then has to be forwarded from the machine
+running the browser (ssh -L 8250:localhost:8250). When no browser can be
+opened, vx falls back to printing the URL.
+
+With auth_method = "kubernetes" (or --auth kubernetes), vx logs in with the
+pod's service account token instead, read from
+/var/run/secrets/kubernetes.io/serviceaccount/token unless
+service_account_token_file says otherwise. auth_mount defaults to
+"kubernetes".
+
+With auth_method = "token" (or --auth token), vx stores a token issued
+elsewhere: from --vault-token, VAULT_TOKEN, or token_file, or else typed at
+a prompt or piped in (vault print token | vx login --auth token). The token
+is looked up in Vault first, and no daemon is started.`,
 	Args: cobra.NoArgs,
 	RunE: runLogin,
 }
@@ -34,20 +67,38 @@ func runLogin(cmd *cobra.Command, args []string) error {
 		addr = flagVaultAddr
 	}
 
-	client, err := newClientForAuth(addr, cfg.Vault.BasePath, "oidc")
+	if selectedAuthMethod(cfg) == "token" {
+		return loginWithToken(cfg, addr)
+	}
+
+	method := "oidc"
+	if selectedAuthMethod(cfg) == "kubernetes" {
+		method = "kubernetes"
+	}
+
+	client, err := newClientForAuth(addr, cfg.Vault.BasePath, method, vaultClientOptions(cfg)...)
 	if err != nil {
 		return fmt.Errorf("creating vault client: %w", err)
 	}
 
-	log.Info().Msg("opening browser for OIDC authentication...")
+	if method == "kubernetes" {
+		if err := kubernetesLogin(client, cfg.Vault, resolveEnv(cfg)); err != nil {
+			return fmt.Errorf("Kubernetes authentication failed: %w", err)
+		}
+	} else {
+		if !flagLoginNoBrowser {
+			log.Info().Msg("opening browser for OIDC authentication...")
+		}
 
-	if err := vault.OIDCAuth(client, cfg.Vault.AuthRole); err != nil {
-		return fmt.Errorf("OIDC authentication failed: %w", err)
+		if err := oidcLogin(client, cfg.Vault, resolveEnv(cfg)); err != nil {
+			return fmt.Errorf("OIDC authentication failed: %w", err)
+		}
 	}
 
 	if err := token.WriteToken(client.Token()); err != nil {
 		return fmt.Errorf("saving token: %w", err)
 	}
+	removeStaleTokens(cfg)
 
 	log.Info().Msg("authenticated successfully")
 
@@ -60,3 +111,57 @@ func runLogin(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// loginWithToken stores a token issued outside vx for the "token" auth
+// method, so later commands need neither VAULT_TOKEN nor a prompt. The token
+// comes from --vault-token, VAULT_TOKEN, or token_file, or else is read from
+// stdin, and must pass a lookup in Vault. No daemon is started: whatever
+// issued the token decides how long it lives.
+func loginWithToken(cfg *config.RootConfig, addr string) error {
+	tok := flagVaultToken
+	if tok == "" {
+		var err error
+		tok, err = token.External(cfg.Vault.TokenFile)
+		if errors.Is(err, token.ErrNoExternalToken) {
+			tok, err = readStdinToken()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	client, err := vault.NewClient(addr, cfg.Vault.BasePath, vaultClientOptions(cfg)...)
+	if err != nil {
+		return fmt.Errorf("creating vault client: %w", err)
+	}
+	info, err := checkToken(client, tok)
+	if err != nil {
+		return fmt.Errorf("token auth: %w", err)
+	}
+
+	if err := token.WriteToken(client.Token()); err != nil {
+		return fmt.Errorf("saving token: %w", err)
+	}
+	removeStaleTokens(cfg)
+
+	ttl := "never expires"
+	if info.TTL > 0 {
+		ttl = info.TTL.String()
+	}
+	log.Info().Strs("policies", info.Policies).Str("ttl", ttl).Msg("token stored")
+	return nil
+}
+
+// readStdinToken prompts for a token on the terminal, or reads the first
+// line of stdin when it is not one, as in `vault print token | vx login`.
+func readStdinToken() (string, error) {
+	if term.IsTerminal(os.Stdin.Fd()) {
+		return promptSecret("Vault token: ")
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("%w; or pipe one to vx login", token.ErrNoExternalToken)
+	}
+	return line, nil
+}
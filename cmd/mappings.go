@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/mappings"
+)
+
+var (
+	flagMappingsJSON  bool
+	flagMappingsWrite bool
+	flagMappingsPrune bool
+)
+
+func init() {
+	mappingsExportCmd.Flags().BoolVar(&flagMappingsJSON, "json", false, "print the mappings as JSON for mappings import")
+	mappingsImportCmd.Flags().BoolVar(&flagMappingsWrite, "write", false, "apply the import (default: dry-run)")
+	mappingsImportCmd.Flags().BoolVar(&flagMappingsPrune, "prune", false, "remove mappings the file does not list from every vx.toml it mentions")
+	mappingsCmd.AddCommand(mappingsExportCmd)
+	mappingsCmd.AddCommand(mappingsImportCmd)
+	rootCmd.AddCommand(mappingsCmd)
+}
+
+var mappingsCmd = &cobra.Command{
+	Use:   "mappings",
+	Short: "Export and import secret mappings in bulk",
+}
+
+var mappingsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print every secret mapping and the vx.toml it lives in",
+	Long: `Prints the [secrets] entries of the root vx.toml and every workspace.
+With --json the output is the document read by "vx mappings import":
+
+  {"version": 1, "mappings": [
+    {"file": "apps/web/vx.toml", "env_var": "API_URL", "path": "${env}/web/api_url"}
+  ]}
+
+File paths are relative to the repository root; the root file is "vx.toml".`,
+	Args: cobra.NoArgs,
+	RunE: runMappingsExport,
+}
+
+var mappingsImportCmd = &cobra.Command{
+	Use:   "import <file.json|->",
+	Short: "Add or update mappings from a JSON document",
+	Long: `Reads a document in the "vx mappings export --json" format and adds or
+updates each mapping in its vx.toml, preserving comments and layout. Every
+file must be the root vx.toml or a configured workspace.
+
+With --prune, mappings the document does not list are removed from each file
+it mentions; other files are left alone.
+
+By default runs in dry-run mode and prints the plan. Use --write to apply it.
+
+  generate-mappings | vx mappings import --prune --write -`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMappingsImport,
+}
+
+func runMappingsExport(cmd *cobra.Command, args []string) error {
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	doc, err := mappings.Export(rootDir, cfg)
+	if err != nil {
+		return err
+	}
+
+	if flagMappingsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(doc)
+	}
+
+	for _, m := range doc.Mappings {
+		fmt.Printf("%-25s %-35s -> %s\n", m.File, m.EnvVar, m.Path)
+	}
+	return nil
+}
+
+func runMappingsImport(cmd *cobra.Command, args []string) error {
+	var in io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("reading mappings: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	doc, err := mappings.Decode(in)
+	if err != nil {
+		return err
+	}
+
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	plan, err := mappings.PlanImport(rootDir, cfg, doc, flagMappingsPrune)
+	if err != nil {
+		return err
+	}
+
+	if !flagMappingsWrite {
+		fmt.Println("# Dry run — use --write to apply")
+		fmt.Println()
+	}
+	printMappingsPlan(plan)
+
+	if !flagMappingsWrite || len(plan.Changes) == 0 {
+		return nil
+	}
+
+	if err := plan.Apply(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\napplied %d change(s)\n", len(plan.Changes))
+	return nil
+}
+
+// printMappingsPlan lists the edits an import will make.
+func printMappingsPlan(plan *mappings.Plan) {
+	if len(plan.Changes) == 0 {
+		fmt.Println("nothing to change")
+		return
+	}
+
+	for _, c := range plan.Changes {
+		switch c.Action {
+		case mappings.ActionAdd:
+			fmt.Printf("%s: + %s = %q\n", c.File, c.EnvVar, c.NewPath)
+		case mappings.ActionUpdate:
+			fmt.Printf("%s: ~ %s: %q -> %q\n", c.File, c.EnvVar, c.OldPath, c.NewPath)
+		case mappings.ActionRemove:
+			fmt.Printf("%s: - %s\n", c.File, c.EnvVar)
+		}
+	}
+}
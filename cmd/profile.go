@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+var flagProfileStartup bool
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&flagProfileStartup, "profile-startup", false, "print how long config discovery, parsing, merging, auth, and secret resolution took")
+}
+
+// processStart approximates when vx started, for the total in the profile.
+var processStart = time.Now()
+
+// profilePhase is the time spent in one phase, summed over its runs.
+type profilePhase struct {
+	name  string
+	total time.Duration
+	runs  int
+}
+
+var (
+	profileMu       sync.Mutex
+	profilePhases   []*profilePhase
+	profileReported bool
+)
+
+// trackPhase starts timing a phase for --profile-startup and returns the
+// function that ends it, meant to be deferred:
+//
+//	defer trackPhase("auth")()
+//
+// Phases can nest (logging in to a named vault happens during secret
+// resolution), so their times may add up to more than the total.
+func trackPhase(name string) func() {
+	if !flagProfileStartup {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		elapsed := time.Since(start)
+
+		profileMu.Lock()
+		defer profileMu.Unlock()
+		for _, p := range profilePhases {
+			if p.name == name {
+				p.total += elapsed
+				p.runs++
+				return
+			}
+		}
+		profilePhases = append(profilePhases, &profilePhase{name: name, total: elapsed, runs: 1})
+	}
+}
+
+// reportProfile prints the phases timed so far to stderr, in the order they
+// first ran, once per invocation. vx exec reports before starting the
+// command, so the command's own run time is not included.
+func reportProfile() {
+	if !flagProfileStartup {
+		return
+	}
+
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	if profileReported {
+		return
+	}
+	profileReported = true
+
+	writeProfile(os.Stderr, profilePhases, time.Since(processStart))
+}
+
+// writeProfile formats phases and the total time as a table.
+func writeProfile(w io.Writer, phases []*profilePhase, total time.Duration) {
+	fmt.Fprintln(w, "vx startup profile:")
+	for _, p := range phases {
+		runs := ""
+		if p.runs > 1 {
+			runs = fmt.Sprintf("  (%d runs)", p.runs)
+		}
+		fmt.Fprintf(w, "  %-20s %10s%s\n", p.name, formatPhase(p.total), runs)
+	}
+	fmt.Fprintf(w, "  %-20s %10s\n", "total", formatPhase(total))
+}
+
+// formatPhase rounds d for display: microseconds below a millisecond,
+// hundredths of a millisecond below a second, milliseconds above.
+func formatPhase(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	case d < time.Second:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Millisecond).String()
+	}
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/exposure"
+)
+
+var (
+	flagReportDiffBase string
+	flagReportOutput   string
+)
+
+func init() {
+	reportCmd.Flags().StringVar(&flagReportDiffBase, "diff-base", "", "git ref to compare the mappings with, e.g. origin/main")
+	reportCmd.Flags().StringVarP(&flagReportOutput, "output", "o", "", "write the report to this file instead of stdout")
+	_ = reportCmd.MarkFlagRequired("diff-base")
+	rootCmd.AddCommand(reportCmd)
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report --diff-base <ref>",
+	Short: "Summarize how secret mappings changed since a git ref, as Markdown",
+	Long: `Compares the merged secret mappings of every workspace in the working tree
+with those at <ref> and prints a Markdown summary: env vars added, removed,
+renamed (same Vault path, new name), and pointed at a different path. Nothing
+is read from Vault.
+
+Post it as a pull request comment from CI so reviewers see which secrets a
+change exposes to which workspace, e.g. on GitHub:
+
+  git fetch origin main
+  vx report --diff-base origin/main -o vx-report.md
+  gh pr comment "$PR" --body-file vx-report.md`,
+	Args: cobra.NoArgs,
+	RunE: runReport,
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	head, err := headMappings(cfg, rootDir)
+	if err != nil {
+		return err
+	}
+	base, err := baseMappings(rootDir, flagReportDiffBase)
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if flagReportOutput != "" {
+		f, err := os.Create(flagReportOutput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	return exposure.WriteMarkdown(out, flagReportDiffBase, exposure.Compare(base, head))
+}
+
+// headMappings collects the mappings of the working tree.
+func headMappings(cfg *config.RootConfig, rootDir string) (exposure.Mappings, error) {
+	workspaces := make(map[string]*config.WorkspaceConfig, len(cfg.Workspaces))
+	for _, p := range cfg.Workspaces {
+		ws, err := config.LoadWorkspaceConfig(filepath.Join(rootDir, p))
+		if err != nil {
+			return nil, err
+		}
+		workspaces[p] = ws
+	}
+	return exposure.Collect(cfg, workspaces)
+}
+
+// baseMappings collects the mappings committed at ref. A root vx.toml that
+// does not exist there yet has no mappings.
+func baseMappings(rootDir, ref string) (exposure.Mappings, error) {
+	if _, err := gitOutput(rootDir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown git ref %q", ref)
+	}
+
+	rel, err := filepath.Rel(rootDir, rootConfigPath(rootDir))
+	if err != nil {
+		return nil, err
+	}
+	data, ok, err := gitFileAt(rootDir, ref, rel)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return exposure.Mappings{}, nil
+	}
+	root, err := config.ParseRootConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s at %s: %w", rel, ref, err)
+	}
+	if root.AutoWorkspaces {
+		root.Workspaces, err = discoverWorkspacesAt(rootDir, ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s at %s: %w", rel, ref, err)
+		}
+	}
+
+	workspaces := make(map[string]*config.WorkspaceConfig, len(root.Workspaces))
+	for _, p := range root.Workspaces {
+		data, ok, err := gitFileAt(rootDir, ref, p)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		ws, err := config.ParseWorkspaceConfig(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s at %s: %w", p, ref, err)
+		}
+		workspaces[p] = ws
+	}
+	return exposure.Collect(root, workspaces)
+}
+
+// discoveryFiles are the files config.DiscoverWorkspaces looks at: the
+// package manager manifests and vx.toml.
+var discoveryFiles = []string{"package.json", "pnpm-workspace.yaml", "Cargo.toml", "go.work", "vx.toml"}
+
+// discoverWorkspacesAt discovers the workspaces of a root config with
+// workspaces = "auto" as committed at ref. The directories of rootDir at ref
+// and the files discovery looks at are recreated in a temporary directory
+// and searched like the working tree. Only the manifests next to the root
+// config are read; elsewhere discovery just checks that they exist.
+func discoverWorkspacesAt(rootDir, ref string) ([]string, error) {
+	out, err := gitOutput(rootDir, "ls-tree", "-r", "-t", "-z", ref, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.MkdirTemp("", "vx-report-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, entry := range strings.Split(string(out), "\x00") {
+		// "<mode> <type> <object>\t<path>", the path relative to rootDir.
+		meta, name, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 3 || name == "./" {
+			continue
+		}
+		dst := filepath.Join(tmp, filepath.FromSlash(name))
+
+		switch {
+		case fields[1] == "tree":
+			if err := os.MkdirAll(dst, 0o700); err != nil {
+				return nil, err
+			}
+		case fields[1] == "blob" && slices.Contains(discoveryFiles, path.Base(name)):
+			var data []byte
+			if !strings.Contains(name, "/") {
+				if data, _, err = gitFileAt(rootDir, ref, name); err != nil {
+					return nil, err
+				}
+			}
+			if err := os.MkdirAll(filepath.Dir(dst), 0o700); err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(dst, data, 0o600); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return config.DiscoverWorkspaces(tmp)
+}
+
+// gitFileAt returns the content of path, relative to dir, at ref, and false
+// if the file does not exist there.
+func gitFileAt(dir, ref, path string) ([]byte, bool, error) {
+	spec := ref + ":./" + filepath.ToSlash(path)
+	if _, err := gitOutput(dir, "cat-file", "-e", spec); err != nil {
+		return nil, false, nil
+	}
+	data, err := gitOutput(dir, "show", spec)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// gitOutput runs git in dir and returns its standard output.
+func gitOutput(dir string, args ...string) ([]byte, error) {
+	c := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
@@ -1,28 +1,40 @@
 package cmd
 
 import (
+	"cmp"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
 
+	"github.com/charmbracelet/x/term"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
 	"go.dot.industries/vx/internal/config"
 	"go.dot.industries/vx/internal/token"
+	"go.dot.industries/vx/internal/vault"
 )
 
 var (
-	flagEnv       string
-	flagWorkspace string
-	flagConfigDir string
-	flagNoDaemon  bool
-	flagVerbose   bool
-	flagAuth      string
-	flagVaultAddr string
-	flagRoleID    string
-	flagSecretID  string
+	flagEnv        string
+	flagWorkspace  string
+	flagConfigDir  string
+	flagNoDaemon   bool
+	flagVerbose    bool
+	flagAuth       string
+	flagVaultAddr  string
+	flagRoleID     string
+	flagSecretID   string
+	flagTrace      bool
+	flagVaultToken string
+	flagTokenStore string
+	flagNamespace  string
+	// flagCallbackPort is the port for the OIDC callback; 0 uses
+	// auth_listen_ports.
+	flagCallbackPort int
 )
 
 var rootCmd = &cobra.Command{
@@ -37,7 +49,9 @@ secret loading, parallel Vault reads, and automatic token renewal.`,
 
 // Execute runs the root command.
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	reportProfile()
+	return err
 }
 
 func init() {
@@ -46,12 +60,17 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&flagConfigDir, "config", "", "path to root vx.toml (auto-detected if omitted)")
 	rootCmd.PersistentFlags().BoolVar(&flagNoDaemon, "no-daemon", false, "skip token daemon; authenticate inline")
 	rootCmd.PersistentFlags().BoolVarP(&flagVerbose, "verbose", "v", false, "enable debug logging")
-	rootCmd.PersistentFlags().StringVar(&flagAuth, "auth", "", "authentication method (oidc, approle); overrides config")
+	rootCmd.PersistentFlags().StringVar(&flagAuth, "auth", "", "authentication method (oidc, approle, kubernetes, token); overrides config")
 	rootCmd.PersistentFlags().StringVar(&flagVaultAddr, "vault-addr", "", "vault address; overrides config")
 	rootCmd.PersistentFlags().StringVar(&flagRoleID, "role-id", "", "AppRole role ID (for --auth approle)")
 	rootCmd.PersistentFlags().StringVar(&flagSecretID, "secret-id", "", "AppRole secret ID (for --auth approle)")
+	rootCmd.PersistentFlags().StringVar(&flagVaultToken, "vault-token", "", "use this Vault token as-is (implies --auth token; never cached or renewed)")
+	rootCmd.PersistentFlags().BoolVar(&flagTrace, "trace-requests", false, "tag Vault requests with a correlation ID and forward TRACEPARENT")
+	rootCmd.PersistentFlags().StringVar(&flagTokenStore, "token-store", "", "where to keep the Vault token (file, keychain); overrides config")
+	rootCmd.PersistentFlags().StringVar(&flagNamespace, "namespace", "", "Vault Enterprise namespace; overrides config")
+	rootCmd.PersistentFlags().IntVar(&flagCallbackPort, "callback-port", 0, "local port for the OIDC login callback; overrides auth_listen_ports")
 
-	cobra.OnInitialize(initLogger)
+	cobra.OnInitialize(initLogger, checkLocalPermissions, initTokenStore)
 }
 
 func initLogger() {
@@ -62,10 +81,16 @@ func initLogger() {
 		level = zerolog.DebugLevel
 	}
 
-	log.Logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).
+	log.Logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr, NoColor: !colorEnabled(os.Stderr)}).
 		With().Timestamp().Logger().Level(level)
 }
 
+// colorEnabled reports whether output to f may use ANSI colors: f is a
+// terminal and NO_COLOR (https://no-color.org) is not set.
+func colorEnabled(f *os.File) bool {
+	return os.Getenv("NO_COLOR") == "" && term.IsTerminal(f.Fd())
+}
+
 // loadConfig finds and parses the root vx.toml and returns the root config,
 // the directory it was found in, and optionally the resolved environment name.
 func loadConfig() (*config.RootConfig, string, error) {
@@ -77,23 +102,56 @@ func loadConfig() (*config.RootConfig, string, error) {
 			return nil, "", fmt.Errorf("getting working directory: %w", err)
 		}
 
+		done := trackPhase("config discovery")
 		found, err := config.FindRootConfig(cwd)
+		done()
 		if err != nil {
 			return nil, "", err
 		}
 		configPath = found
 	}
 
+	done := trackPhase("config parsing")
 	cfg, err := config.LoadRootConfig(configPath)
+	done()
 	if err != nil {
 		return nil, "", err
 	}
 
 	rootDir := filepath.Dir(configPath)
+	useTokenStore(cfg.Vault.TokenStore)
+	if err := token.MigrateLegacyToken(cfg.Vault.Address); err != nil {
+		log.Debug().Err(err).Msg("failed to move ~/.vx/token to the per-server token store")
+	}
+	token.UseAddress(cmp.Or(flagVaultAddr, cfg.Vault.Address))
+	cfg.Vault.Namespace = cmp.Or(namespaceOverride(), cfg.Vault.Namespace)
+	if flagCallbackPort != 0 {
+		if flagCallbackPort < 1 || flagCallbackPort > 65535 {
+			return nil, "", fmt.Errorf("--callback-port must be between 1 and 65535, got %d", flagCallbackPort)
+		}
+		cfg.Vault.AuthListenPorts = []int{flagCallbackPort}
+	}
 
 	return cfg, rootDir, nil
 }
 
+// namespaceEnv selects the Vault namespace of [vault] like --namespace.
+const namespaceEnv = "VX_NAMESPACE"
+
+// namespaceOverride returns the Vault namespace given with --namespace or
+// VX_NAMESPACE, which replaces the one in [vault], or "" if neither is set.
+func namespaceOverride() string {
+	return cmp.Or(flagNamespace, os.Getenv(namespaceEnv))
+}
+
+// rootConfigPath returns the path of the root vx.toml located by loadConfig.
+func rootConfigPath(rootDir string) string {
+	if flagConfigDir != "" {
+		return flagConfigDir
+	}
+	return filepath.Join(rootDir, "vx.toml")
+}
+
 // startDaemonBackground spawns the token renewal daemon as a detached
 // background process. Failures are logged as warnings — daemon start is
 // best-effort and must never block the calling command.
@@ -104,7 +162,16 @@ func startDaemonBackground() {
 		return
 	}
 
-	pid, err := token.StartDaemonProcess(exe)
+	// The daemon renews the token in the namespace this command uses, and
+	// traces its requests if this command does.
+	var args []string
+	if ns := namespaceOverride(); ns != "" {
+		args = append(args, "--namespace", ns)
+	}
+	if flagTrace {
+		args = append(args, "--trace-requests")
+	}
+	pid, err := token.StartDaemonProcess(exe, args...)
 	if err != nil {
 		log.Warn().Err(err).Msg("failed to start token daemon")
 		return
@@ -117,6 +184,68 @@ func startDaemonBackground() {
 	}
 }
 
+// vaultClientOptions returns the options applied to every Vault client the
+// CLI creates.
+func vaultClientOptions(cfg *config.RootConfig) []vault.ClientOption {
+	// Reads are retried by the resolver as [resolver.retry] says, so the
+	// API client must not retry them again underneath.
+	return append([]vault.ClientOption{vault.WithMaxRetries(0)}, connectionOptions(cfg)...)
+}
+
+// connectionOptions returns the options that tag, pace, and scope the
+// requests of a Vault client as cfg says: tracing, the rate limit, the KV
+// version, and the namespace. The TUI applies them as they are, keeping the
+// API client's own retries.
+func connectionOptions(cfg *config.RootConfig) []vault.ClientOption {
+	var opts []vault.ClientOption
+	if headers := traceHeaders(cfg); headers != nil {
+		opts = append(opts, vault.WithHeaders(headers))
+	}
+	if rps := cfg.Resolver.RequestsPerSecond; rps > 0 {
+		opts = append(opts, vault.WithRateLimit(rps, cfg.Resolver.Burst))
+	}
+	if v := cfg.Vault.KVVersion; v != 0 {
+		opts = append(opts, vault.WithKVVersion(v))
+	}
+	if ns := cfg.Vault.Namespace; ns != "" {
+		opts = append(opts, vault.WithNamespace(ns))
+	}
+	return opts
+}
+
+// renewerOptions returns the options applied to every token renewer the CLI
+// creates.
+func renewerOptions(cfg *config.RootConfig) []token.RenewerOption {
+	var opts []token.RenewerOption
+	if headers := traceHeaders(cfg); headers != nil {
+		opts = append(opts, token.WithRequestHeaders(headers))
+	}
+	// Like the Vault clients, fall back to VAULT_NAMESPACE.
+	if ns := cmp.Or(cfg.Vault.Namespace, os.Getenv("VAULT_NAMESPACE")); ns != "" {
+		opts = append(opts, token.WithNamespace(ns))
+	}
+	return opts
+}
+
+var logCorrelationOnce sync.Once
+
+// traceHeaders returns the request tracing headers when tracing is enabled
+// via --trace-requests or vault.trace_requests, or nil otherwise. The
+// correlation ID is logged once per invocation so client-side failures can be
+// matched against Vault audit logs.
+func traceHeaders(cfg *config.RootConfig) http.Header {
+	if !flagTrace && !cfg.Vault.TraceRequests {
+		return nil
+	}
+
+	id := vault.CorrelationID()
+	logCorrelationOnce.Do(func() {
+		log.Info().Str("correlation_id", id).Msg("tracing vault requests")
+	})
+
+	return vault.TraceHeaders(id)
+}
+
 // resolveEnv returns the environment to use, preferring the CLI flag over the
 // config default.
 func resolveEnv(cfg *config.RootConfig) string {
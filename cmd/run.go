@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/config"
+	vxexec "go.dot.industries/vx/internal/exec"
+)
+
+func init() {
+	// Flags after the script name belong to the script.
+	runCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(runCmd)
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run [script] [args...]",
+	Short: "Run a script from [scripts] with secrets injected",
+	Long: `Runs a command line from the [scripts] table of vx.toml like vx exec would,
+with the secrets and defaults of the detected workspace injected:
+
+  [scripts]
+  dev = "bun dev"
+  migrate = "bun run db:migrate"
+
+  vx run dev
+  vx run -e staging migrate -- --dry-run
+
+Scripts run through the shell (sh -c, or cmd /C on Windows) in the current
+directory. Arguments after the script name are passed on to it. A
+workspace's vx.toml can add scripts of its own or replace the root's under
+the same name. Without a script name, lists the available scripts.`,
+	RunE: runRun,
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	workspace, err := detectWorkspace(cfg, rootDir, nil)
+	if err != nil {
+		return err
+	}
+	scripts, err := workspaceScripts(cfg, rootDir, workspace)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		if len(scripts) == 0 {
+			fmt.Printf("No [scripts] defined in %s\n", rootConfigPath(rootDir))
+			return nil
+		}
+		for _, name := range slices.Sorted(maps.Keys(scripts)) {
+			fmt.Printf("%-16s %s\n", name, scripts[name])
+		}
+		return nil
+	}
+
+	name, extra := args[0], args[1:]
+	line, ok := scripts[name]
+	if !ok {
+		if len(scripts) == 0 {
+			return fmt.Errorf("unknown script %q: no [scripts] defined", name)
+		}
+		return fmt.Errorf("unknown script %q (available: %s)", name, strings.Join(slices.Sorted(maps.Keys(scripts)), ", "))
+	}
+	if len(extra) > 0 && extra[0] == "--" {
+		extra = extra[1:]
+	}
+
+	return execInWorkspace(cfg, rootDir, workspace, vxexec.ScriptCommand(line, extra))
+}
+
+// workspaceScripts returns the scripts of the root config and, when one was
+// detected, of the workspace.
+func workspaceScripts(cfg *config.RootConfig, rootDir, workspace string) (map[string]string, error) {
+	if workspace == "" {
+		return config.Scripts(cfg, nil), nil
+	}
+
+	wsPath, err := config.ResolveWorkspacePath(rootDir, workspace, cfg.Workspaces)
+	if err != nil {
+		return nil, fmt.Errorf("resolving workspace path: %w", err)
+	}
+	wsCfg, err := config.LoadWorkspaceConfig(wsPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading workspace config: %w", err)
+	}
+	return config.Scripts(cfg, wsCfg), nil
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/seed"
+)
+
+var (
+	flagSeedDestroy bool
+	flagSeedWrite   bool
+	flagSeedYes     bool
+)
+
+func init() {
+	seedCmd.Flags().BoolVar(&flagSeedDestroy, "destroy", false, "delete the environment's seeded secrets instead of creating them")
+	seedCmd.Flags().BoolVar(&flagSeedWrite, "write", false, "apply the plan (default: dry-run)")
+	seedCmd.Flags().BoolVarP(&flagSeedYes, "yes", "y", false, "write to a protected environment without asking")
+	rootCmd.AddCommand(seedCmd)
+}
+
+var seedCmd = &cobra.Command{
+	Use:   "seed [file]",
+	Short: "Create an environment's secrets from a seed file",
+	Long: `Creates the Vault secrets a project needs for the selected environment from
+a seed file, by default vx.seed.toml next to the root vx.toml. Each table
+names a secret path, which may contain ${env}, and its keys, with a
+placeholder value or a generated one:
+
+  [secrets."${env}/database"]
+  url = "postgres://db.internal/app_${env}"
+  password = { generate = "password", length = 32 }
+
+  [secrets."${env}/api"]
+  signing_key = { generate = "hex", length = 64 }
+  client_id = { generate = "uuid" }
+
+Generators are password (letters and digits), hex, base64 (URL-safe), and
+uuid. ${env} in values is replaced with the environment's name. Secrets and
+keys that already exist are never overwritten, so seeding again only adds
+what is missing and keeps generated values stable.
+
+With --destroy, removes the keys the seed file lists from every secret whose
+path contains ${env}. A secret left with no other keys is deleted with all
+its versions; one with other keys gets a new version without the seeded
+ones. Secrets at other paths are shared by every environment and are left
+alone. --destroy needs -e and refuses protected environments, even with
+--yes, and environments whose paths another environment shares through
+[environments.map].
+
+By default runs in dry-run mode and prints the plan without any values. Use
+--write to apply it.
+
+  vx seed -e preview-123 --write
+  vx seed -e preview-123 --destroy --write`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSeed,
+}
+
+func runSeed(cmd *cobra.Command, args []string) error {
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Join(rootDir, seed.DefaultFile)
+	if len(args) == 1 {
+		file = args[0]
+	}
+	f, err := seed.Load(file)
+	if err != nil {
+		return err
+	}
+
+	env := resolveEnv(cfg)
+	pathEnv := cfg.Environments.PathSegment(env)
+	if flagSeedDestroy {
+		if err := checkDestroyEnv(cfg, env); err != nil {
+			return err
+		}
+	}
+
+	client, err := authenticatedClient(cfg, env)
+	if err != nil {
+		return err
+	}
+
+	var plan *seed.Plan
+	if flagSeedDestroy {
+		plan, err = seed.PlanDestroy(f, client, pathEnv)
+	} else {
+		plan, err = seed.PlanSeed(f, client, env, pathEnv)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !flagSeedWrite {
+		fmt.Println("# Dry run — use --write to apply")
+		fmt.Println()
+	}
+	printSeedPlan(plan)
+
+	if !flagSeedWrite {
+		return nil
+	}
+
+	if cfg.TUI.IsProtected(env) && !flagSeedYes {
+		if err := confirmEnvironment(env); err != nil {
+			return err
+		}
+	}
+
+	if err := plan.Apply(client); err != nil {
+		return err
+	}
+
+	if flagSeedDestroy {
+		log.Info().
+			Str("env", env).
+			Int("destroyed", plan.Count(seed.ActionDestroy)).
+			Int("trimmed", plan.Count(seed.ActionRemoveKeys)).
+			Msg("environment secrets destroyed")
+		return nil
+	}
+	log.Info().
+		Str("env", env).
+		Int("created", plan.Count(seed.ActionCreate)).
+		Int("updated", plan.Count(seed.ActionUpdate)).
+		Msg("environment seeded")
+	return nil
+}
+
+// checkDestroyEnv refuses a --destroy that could hit more than a throwaway
+// environment: one not named with -e, a protected one (even with --yes), or
+// one whose path segment another environment shares through
+// environments.map.
+func checkDestroyEnv(cfg *config.RootConfig, env string) error {
+	if flagEnv == "" {
+		return fmt.Errorf("--destroy needs the environment named with -e")
+	}
+	if cfg.TUI.IsProtected(env) {
+		return fmt.Errorf("refusing to destroy the secrets of protected environment %q", env)
+	}
+	segment := cfg.Environments.PathSegment(env)
+	for _, other := range cfg.Environments.Available {
+		if other != env && cfg.Environments.PathSegment(other) == segment {
+			return fmt.Errorf("refusing to destroy the secrets of %q: environment %q uses the same paths (%s)", env, other, segment)
+		}
+	}
+	return nil
+}
+
+// printSeedPlan lists what seeding or destroying does with each secret,
+// naming keys but never their values.
+func printSeedPlan(plan *seed.Plan) {
+	for _, s := range plan.Steps {
+		switch s.Action {
+		case seed.ActionCreate:
+			fmt.Printf("+ %s (%s)\n", s.Path, strings.Join(s.Keys, ", "))
+		case seed.ActionUpdate:
+			fmt.Printf("~ %s (add %s)\n", s.Path, strings.Join(s.Keys, ", "))
+		case seed.ActionUnchanged:
+			fmt.Printf("= %s\n", s.Path)
+		case seed.ActionDestroy:
+			fmt.Printf("- %s\n", s.Path)
+		case seed.ActionRemoveKeys:
+			fmt.Printf("~ %s (remove %s)\n", s.Path, strings.Join(s.Keys, ", "))
+		case seed.ActionMissing:
+			fmt.Printf("  %s does not exist\n", s.Path)
+		case seed.ActionShared:
+			fmt.Printf("  %s is shared, kept\n", s.Path)
+		}
+	}
+
+	if flagSeedDestroy {
+		fmt.Printf("\n%d to destroy, %d to trim, %d missing, %d shared\n",
+			plan.Count(seed.ActionDestroy), plan.Count(seed.ActionRemoveKeys), plan.Count(seed.ActionMissing), plan.Count(seed.ActionShared))
+		return
+	}
+	fmt.Printf("\n%d to create, %d to update, %d unchanged\n",
+		plan.Count(seed.ActionCreate), plan.Count(seed.ActionUpdate), plan.Count(seed.ActionUnchanged))
+}
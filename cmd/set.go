@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/resolver"
+)
+
+var flagSetYes bool
+
+func init() {
+	setCmd.Flags().BoolVarP(&flagSetYes, "yes", "y", false, "write to a protected environment without asking")
+	rootCmd.AddCommand(setCmd)
+}
+
+var setCmd = &cobra.Command{
+	Use:   "set <ENV_VAR> [value]",
+	Short: "Write a mapped secret's value to Vault",
+	Long: `Writes a value to the Vault key ENV_VAR is mapped to for the current
+environment and workspace. Other keys at the same path are left as they are,
+and the path is created if it does not exist yet.
+
+Without a value argument the value is read from standard input, or prompted
+for without echo on a terminal, so it stays out of your shell history:
+
+  vx set DATABASE_URL
+  pbpaste | vx set -e staging STRIPE_KEY
+
+Writing to a protected environment ([tui] protected_environments, by default
+production) asks you to type its name first, or needs --yes when not on a
+terminal. The write needs the "patch" capability on the path (and "create"
+for a new one).`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runSet,
+}
+
+func runSet(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	env := resolveEnv(cfg)
+
+	workspace, err := detectWorkspace(cfg, rootDir, []string{})
+	if err != nil {
+		return err
+	}
+
+	merged, err := mergeForWorkspace(cfg, rootDir, workspace, env)
+	if err != nil {
+		return err
+	}
+
+	rawPath, ok := merged.Secrets[name]
+	if !ok {
+		if _, ok := merged.Defaults[name]; ok {
+			return fmt.Errorf("%s is a default, not a secret; change it in vx.toml", name)
+		}
+		return fmt.Errorf("%s is not mapped in this workspace (see `vx list`)", name)
+	}
+	if resolver.IsCommand(rawPath) {
+		return fmt.Errorf("%s is read from a command, not Vault", name)
+	}
+
+	_, vaultPath := config.SplitVault(rawPath)
+	if _, version := resolver.SplitVersion(vaultPath); version != 0 {
+		return fmt.Errorf("%s is pinned to version %d; remove the @%d from its mapping to set it", name, version, version)
+	}
+	kvPath, key := resolver.SplitMapping(vaultPath, merged.PathEnv)
+	if kvPath == "" || key == "" {
+		return fmt.Errorf("%s maps to %q, which has no key to write", name, rawPath)
+	}
+
+	if cfg.TUI.IsProtected(env) && !flagSetYes {
+		if err := confirmEnvironment(env); err != nil {
+			return err
+		}
+	}
+
+	value, err := readSetValue(args)
+	if err != nil {
+		return err
+	}
+
+	client, _, err := clientForMapping(cfg, merged, rawPath)
+	if err != nil {
+		return err
+	}
+
+	if err := client.PatchKV(kvPath, map[string]string{key: value}); err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("name", name).
+		Str("path", kvPath).
+		Str("key", key).
+		Str("env", env).
+		Msg("secret written")
+	return nil
+}
+
+// confirmEnvironment asks the user to type env before a write to it.
+func confirmEnvironment(env string) error {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return fmt.Errorf("%s is a protected environment; pass --yes to write to it", env)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s is a protected environment. Type its name to continue: ", env)
+	var answer string
+	fmt.Scanln(&answer)
+	if strings.TrimSpace(answer) != env {
+		return fmt.Errorf("not confirmed; nothing was written")
+	}
+	return nil
+}
+
+// readSetValue returns the value argument, or reads the value from standard
+// input: prompted for without echo on a terminal, otherwise read to the end
+// with one trailing newline removed.
+func readSetValue(args []string) (string, error) {
+	if len(args) == 2 {
+		return args[1], nil
+	}
+
+	if term.IsTerminal(os.Stdin.Fd()) {
+		value, err := promptSecret("Value: ")
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			return "", fmt.Errorf("no value entered")
+		}
+		return value, nil
+	}
+
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("reading value from stdin: %w", err)
+	}
+	value := strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r")
+	return value, nil
+}
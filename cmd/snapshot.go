@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/snapshot"
+)
+
+// maxSnapshotSize bounds the archive vx restore reads into memory.
+const maxSnapshotSize = 256 << 20
+
+var (
+	flagSnapshotOutput        string
+	flagSnapshotPrefix        string
+	flagSnapshotPassphraseEnv string
+
+	flagRestoreTo        string
+	flagRestoreOverwrite bool
+	flagRestoreWrite     bool
+)
+
+func init() {
+	snapshotCmd.Flags().StringVarP(&flagSnapshotOutput, "output", "o", "", "archive to write (default: vx-<prefix>-<time>.vxsnap)")
+	snapshotCmd.Flags().StringVar(&flagSnapshotPrefix, "prefix", "", "KV prefix to export (default: the selected environment)")
+	snapshotCmd.Flags().StringVar(&flagSnapshotPassphraseEnv, "passphrase-env", "", "read the archive passphrase from this environment variable instead of prompting")
+	rootCmd.AddCommand(snapshotCmd)
+
+	restoreCmd.Flags().StringVar(&flagRestoreTo, "to", "", "KV prefix to restore under (default: the prefix the snapshot was taken from)")
+	restoreCmd.Flags().BoolVar(&flagRestoreOverwrite, "overwrite", false, "write a new version of secrets that exist with other values (default: skip them)")
+	restoreCmd.Flags().BoolVar(&flagRestoreWrite, "write", false, "apply the restore (default: dry-run)")
+	restoreCmd.Flags().StringVar(&flagSnapshotPassphraseEnv, "passphrase-env", "", "read the archive passphrase from this environment variable instead of prompting")
+	rootCmd.AddCommand(restoreCmd)
+}
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Export every secret under an environment into an encrypted archive",
+	Long: `Reads every secret under a KV prefix, by default the selected environment's
+("dev/...", or its [environments.map] entry), and writes them to a passphrase-encrypted archive (mode 0600)
+along with a manifest of the paths, key names, and versions captured.
+
+  vx snapshot -e production -o prod.vxsnap
+  vx snapshot --prefix shared --passphrase-env SNAPSHOT_PASSPHRASE
+
+Needs "list" on <base_path>/metadata/<prefix>/* and "read" on the secrets.
+Only the latest version of each secret is exported. Keep the passphrase
+apart from the archive: together they hold every value in plain reach.`,
+	Args: cobra.NoArgs,
+	RunE: runSnapshot,
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Write the secrets of a snapshot back to Vault",
+	Long: `Decrypts an archive made by "vx snapshot" and restores its secrets under
+the prefix they were taken from, or another one with --to. With --vault-addr
+the restore goes to another cluster, for disaster recovery or migrations.
+
+Secrets that are missing are created. Secrets that already hold the same
+values are left alone, and ones with other values are skipped unless
+--overwrite is given, which writes them as a new version. Every write is a
+check-and-set, so a secret changed during the restore is never overwritten.
+
+By default runs in dry-run mode and prints the manifest and the plan. Use
+--write to apply it.
+
+  vx restore prod.vxsnap
+  vx restore prod.vxsnap --to production-dr --vault-addr https://dr.vault:8200 --write`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRestore,
+}
+
+func runSnapshot(cmd *cobra.Command, args []string) error {
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	env := resolveEnv(cfg)
+	prefix := strings.Trim(flagSnapshotPrefix, "/")
+	if prefix == "" {
+		prefix = cfg.Environments.PathSegment(env)
+	}
+
+	output := flagSnapshotOutput
+	if output == "" {
+		output = fmt.Sprintf("vx-%s-%s.vxsnap", strings.ReplaceAll(prefix, "/", "-"), time.Now().UTC().Format("20060102T150405Z"))
+	}
+
+	// Ask before reading Vault, so a mistyped confirmation costs nothing.
+	passphrase, err := readPassphrase(flagSnapshotPassphraseEnv, "Snapshot passphrase: ", true)
+	if err != nil {
+		return err
+	}
+
+	client, err := authenticatedClient(cfg, env)
+	if err != nil {
+		return err
+	}
+
+	addr := cfg.Vault.Address
+	if flagVaultAddr != "" {
+		addr = flagVaultAddr
+	}
+
+	snap, err := snapshot.Take(client, addr, cfg.Vault.BasePath, prefix, time.Now())
+	if err != nil {
+		return fmt.Errorf("reading secrets under %s/: %w", prefix, err)
+	}
+	if len(snap.Secrets) == 0 {
+		return fmt.Errorf("no secrets found under %s/", prefix)
+	}
+
+	archive, err := snapshot.Seal(snap, passphrase)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(output, archive, 0600); err != nil {
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+
+	printManifest(&snap.Manifest)
+	log.Info().Str("path", output).Int("secrets", len(snap.Secrets)).Msg("snapshot written")
+	return nil
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("opening snapshot: %w", err)
+	}
+	archive, err := io.ReadAll(io.LimitReader(f, maxSnapshotSize))
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("reading snapshot: %w", err)
+	}
+
+	passphrase, err := readPassphrase(flagSnapshotPassphraseEnv, "Snapshot passphrase: ", false)
+	if err != nil {
+		return err
+	}
+
+	snap, err := snapshot.Open(archive, passphrase)
+	if err != nil {
+		return err
+	}
+
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	prefix := strings.Trim(flagRestoreTo, "/")
+	if prefix == "" {
+		prefix = snap.Manifest.Prefix
+	}
+
+	client, err := authenticatedClient(cfg, resolveEnv(cfg))
+	if err != nil {
+		return err
+	}
+
+	plan, err := snapshot.PlanRestore(snap, client, prefix, flagRestoreOverwrite)
+	if err != nil {
+		return fmt.Errorf("reading secrets under %s/: %w", prefix, err)
+	}
+
+	if !flagRestoreWrite {
+		fmt.Println("# Dry run — use --write to apply")
+		fmt.Println()
+	}
+	printManifest(&snap.Manifest)
+	fmt.Println()
+	printRestorePlan(plan)
+
+	if !flagRestoreWrite {
+		return nil
+	}
+
+	if err := plan.Apply(client); err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("prefix", prefix).
+		Int("created", plan.Count(snapshot.ActionCreate)).
+		Int("updated", plan.Count(snapshot.ActionUpdate)).
+		Msg("snapshot restored")
+	return nil
+}
+
+// printManifest describes a snapshot without showing any value.
+func printManifest(m *snapshot.Manifest) {
+	fmt.Printf("Snapshot of %s/ on %s (mount %s), taken %s\n",
+		m.Prefix, m.Address, m.BasePath, m.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	for _, e := range m.Entries {
+		fmt.Printf("  %-40s v%-4d %s\n", e.Path, e.Version, strings.Join(e.Keys, ", "))
+	}
+}
+
+// printRestorePlan lists what a restore does with each secret.
+func printRestorePlan(plan *snapshot.Plan) {
+	for _, s := range plan.Steps {
+		switch s.Action {
+		case snapshot.ActionUnchanged:
+			fmt.Printf("= %s\n", s.Path)
+		case snapshot.ActionCreate:
+			fmt.Printf("+ %s\n", s.Path)
+		case snapshot.ActionUpdate:
+			fmt.Printf("~ %s (%s)\n", s.Path, strings.Join(s.Keys, ", "))
+		case snapshot.ActionSkip:
+			fmt.Printf("! %s differs, skipped without --overwrite (%s)\n", s.Path, strings.Join(s.Keys, ", "))
+		}
+	}
+
+	fmt.Printf("\n%d to create, %d to update, %d unchanged, %d skipped\n",
+		plan.Count(snapshot.ActionCreate), plan.Count(snapshot.ActionUpdate),
+		plan.Count(snapshot.ActionUnchanged), plan.Count(snapshot.ActionSkip))
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/stats"
+)
+
+var flagStatsJSON bool
+
+func init() {
+	statsCmd.Flags().BoolVar(&flagStatsJSON, "json", false, "print the report as JSON")
+	rootCmd.AddCommand(statsCmd)
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Summarize the size and shape of the repository's vx config",
+	Long: `Reports, per vx.toml and in total: secret mappings, distinct Vault paths
+they read, how many are scoped to an environment with ${env} versus shared
+by every environment, and defaults. Nothing is read from Vault.
+
+Use --json to record the numbers over time, e.g. from a scheduled CI job:
+
+  vx stats --json > vx-stats.json`,
+	Args: cobra.NoArgs,
+	RunE: runStats,
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	report, err := stats.Collect(rootDir, cfg)
+	if err != nil {
+		return err
+	}
+
+	if flagStatsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(report)
+	}
+
+	printStats(report)
+	return nil
+}
+
+// printStats prints one row per file followed by the totals.
+func printStats(r *stats.Report) {
+	fmt.Printf("Environments: %s\n", strings.Join(r.Environments, ", "))
+	fmt.Printf("Workspaces:   %d\n\n", r.Workspaces)
+
+	fmt.Printf("  %-30s %8s %10s %6s %6s %8s\n", "FILE", "MAPPINGS", "ENV-SCOPED", "SHARED", "PATHS", "DEFAULTS")
+	for _, f := range r.Files {
+		fmt.Printf("  %-30s %8d %10d %6d %6d %8d\n", f.Path, f.Mappings, f.EnvScoped, f.Shared, f.PathGroups, f.Defaults)
+	}
+
+	fmt.Printf("\n%d mapping(s) reading %d distinct Vault path(s), %d default(s)\n", r.Mappings, r.PathGroups, r.Defaults)
+	if r.Mappings > 0 {
+		fmt.Printf("%d env-scoped, %d shared (%.0f%% shared)\n", r.EnvScoped, r.Shared, r.SharedRatio*100)
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -29,6 +30,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	printTokenStatus(cfg)
+	printTokenMetadata()
 	printDaemonStatus(cfg)
 
 	return nil
@@ -46,7 +48,7 @@ func printTokenStatus(cfg *config.RootConfig) {
 		return
 	}
 
-	client, err := vault.NewClientWithToken(addr, cfg.Vault.BasePath, tok)
+	client, err := vault.NewClientWithToken(addr, cfg.Vault.BasePath, tok, vaultClientOptions(cfg)...)
 	if err != nil {
 		fmt.Println("Token:  error (cannot create client)")
 		return
@@ -67,13 +69,52 @@ func printTokenStatus(cfg *config.RootConfig) {
 	fmt.Printf("Token:  valid (%s remaining, expires %s)\n", formatDuration(ttl), expires)
 }
 
+// printTokenMetadata shows how the cached token was issued and what it may
+// do, when its login recorded that (AppRole logins do).
+func printTokenMetadata() {
+	tok, err := token.ReadToken()
+	if err != nil {
+		return
+	}
+	m, ok := token.ReadMetadata(tok)
+	if !ok {
+		return
+	}
+
+	login := m.AuthMethod
+	if m.Role != "" {
+		login += fmt.Sprintf(" (role %s)", m.Role)
+	}
+	fmt.Printf("Login:  %s at %s\n", login, m.IssuedAt.Local().Format("2006-01-02 15:04"))
+
+	policies := "none"
+	if len(m.Policies) > 0 {
+		policies = strings.Join(m.Policies, ", ")
+	}
+	fmt.Printf("        policies: %s\n", policies)
+
+	props := []string{"ttl " + formatDuration(m.TTL.Duration()) + " at login"}
+	if m.TTL == 0 {
+		props[0] = "no ttl"
+	}
+	if m.Renewable {
+		props = append(props, "renewable")
+	} else {
+		props = append(props, "not renewable")
+	}
+	if m.Orphan {
+		props = append(props, "orphan")
+	}
+	fmt.Printf("        %s\n", strings.Join(props, ", "))
+}
+
 func printDaemonStatus(cfg *config.RootConfig) {
 	addr := cfg.Vault.Address
 	if flagVaultAddr != "" {
 		addr = flagVaultAddr
 	}
 
-	renewer := token.NewTokenRenewer(addr)
+	renewer := token.NewTokenRenewer(addr, renewerOptions(cfg)...)
 	daemon := token.NewDaemon(renewer)
 
 	status, err := daemon.Status()
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/suggest"
+)
+
+var (
+	flagSuggestYes      bool
+	flagSuggestPrefixes []string
+)
+
+func init() {
+	suggestCmd.Flags().BoolVarP(&flagSuggestYes, "yes", "y", false, "append all suggestions without prompting")
+	suggestCmd.Flags().StringSliceVar(&flagSuggestPrefixes, "prefix", []string{"${env}", "shared"}, "Vault prefixes to search for matching keys")
+	rootCmd.AddCommand(suggestCmd)
+}
+
+var suggestCmd = &cobra.Command{
+	Use:   "suggest",
+	Short: "Suggest secret mappings for env vars used in a workspace's code",
+	Long: `Scans the workspace's source files for environment variable reads
+(process.env.X, os.Getenv("X"), os.environ["X"], ENV["X"], ...) that are not
+yet mapped, and matches their names against keys that exist in Vault under
+--prefix (default: the current environment and "shared").
+
+Each suggestion is shown with a similarity score and where the variable is
+used. In a terminal you are asked to accept each one ([y]es, [n]o, [a]ll,
+[q]uit); accepted mappings are appended to the workspace's vx.toml. Use --yes
+to accept everything, e.g. in scripts.`,
+	Args: cobra.NoArgs,
+	RunE: runSuggest,
+}
+
+func runSuggest(cmd *cobra.Command, args []string) error {
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	env := resolveEnv(cfg)
+
+	workspace, err := detectWorkspace(cfg, rootDir, []string{})
+	if err != nil {
+		return err
+	}
+
+	targetFile := rootConfigPath(rootDir)
+	if workspace != "" {
+		targetFile, err = config.ResolveWorkspacePath(rootDir, workspace, cfg.Workspaces)
+		if err != nil {
+			return err
+		}
+	}
+	scanDir := filepath.Dir(targetFile)
+
+	merged, err := mergeForWorkspace(cfg, rootDir, workspace, env)
+	if err != nil {
+		return err
+	}
+
+	mapped := make(map[string]bool, len(merged.Secrets)+len(merged.Defaults))
+	for k := range merged.Secrets {
+		mapped[k] = true
+	}
+	for k := range merged.Defaults {
+		mapped[k] = true
+	}
+
+	refs, err := suggest.Scan(scanDir)
+	if err != nil {
+		return err
+	}
+	log.Debug().Str("dir", scanDir).Int("vars", len(refs)).Msg("scanned source files")
+
+	client, err := authenticatedClient(cfg, env)
+	if err != nil {
+		return err
+	}
+
+	candidates, err := suggest.Candidates(context.Background(), client, cfg.Environments.PathSegment(env), flagSuggestPrefixes)
+	if err != nil {
+		return fmt.Errorf("listing Vault keys: %w", err)
+	}
+
+	suggestions := suggest.Match(refs, candidates, mapped)
+	if len(suggestions) == 0 {
+		fmt.Println("No suggestions: every env var read in this workspace is mapped or has no similar Vault key.")
+		return nil
+	}
+
+	accepted, err := chooseSuggestions(suggestions)
+	if err != nil {
+		return err
+	}
+	if len(accepted) == 0 {
+		return nil
+	}
+
+	if err := suggest.AppendMappings(targetFile, accepted); err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(rootDir, targetFile)
+	if err != nil {
+		rel = targetFile
+	}
+	fmt.Printf("added %d mapping(s) to %s\n", len(accepted), rel)
+	return nil
+}
+
+// chooseSuggestions prints the suggestions and returns the accepted ones:
+// all of them with --yes, the ones confirmed at the prompt in a terminal,
+// and none otherwise.
+func chooseSuggestions(suggestions []suggest.Suggestion) ([]suggest.Suggestion, error) {
+	interactive := !flagSuggestYes && term.IsTerminal(os.Stdin.Fd())
+
+	if !interactive {
+		for _, s := range suggestions {
+			printSuggestion(s)
+		}
+		if flagSuggestYes {
+			return suggestions, nil
+		}
+		fmt.Println("\nRe-run with --yes to append these, or in a terminal to pick individually.")
+		return nil, nil
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	var accepted []suggest.Suggestion
+
+	for i, s := range suggestions {
+		printSuggestion(s)
+		fmt.Print("  add? [y/N/a/q] ")
+
+		answer, err := in.ReadString('\n')
+		if err != nil && answer == "" {
+			return accepted, nil
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			accepted = append(accepted, s)
+		case "a", "all":
+			return append(accepted, suggestions[i:]...), nil
+		case "q", "quit":
+			return accepted, nil
+		}
+	}
+
+	return accepted, nil
+}
+
+// printSuggestion prints a suggestion as the TOML line it would add, followed
+// by its score and where the variable is read.
+func printSuggestion(s suggest.Suggestion) {
+	fmt.Printf("%s = %q\n", s.EnvVar, s.VaultPath)
+
+	where := fmt.Sprintf("%s:%d", s.Refs[0].File, s.Refs[0].Line)
+	if more := len(s.Refs) - 1; more > 0 {
+		where += fmt.Sprintf(" (+%d more)", more)
+	}
+	fmt.Printf("  %.0f%% match, used in %s\n", s.Score*100, where)
+}
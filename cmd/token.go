@@ -1,11 +1,18 @@
 package cmd
 
 import (
+	"cmp"
+	"context"
 	"fmt"
+	"maps"
+	"os"
+	"slices"
 	"time"
 
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
+	"go.dot.industries/vx/internal/config"
 	"go.dot.industries/vx/internal/token"
 	"go.dot.industries/vx/internal/vault"
 )
@@ -13,8 +20,13 @@ import (
 func init() {
 	rootCmd.AddCommand(tokenCmd)
 	tokenCmd.AddCommand(tokenStatusCmd)
+
+	tokenCheckCmd.Flags().BoolVar(&flagTokenCheckFix, "fix", false, "remove group and other access from the files reported")
+	tokenCmd.AddCommand(tokenCheckCmd)
 }
 
+var flagTokenCheckFix bool
+
 var tokenCmd = &cobra.Command{
 	Use:   "token",
 	Short: "Manage Vault tokens",
@@ -27,6 +39,133 @@ var tokenStatusCmd = &cobra.Command{
 	RunE:  runTokenStatus,
 }
 
+var tokenCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check that ~/.vx and the files in it are private to you",
+	Long: `Reports every directory and file under ~/.vx that the group or other
+users can access, such as a token file copied in with a loose umask. Every
+vx command warns about them on startup; --fix removes the group and other
+permission bits.`,
+	Args: cobra.NoArgs,
+	RunE: runTokenCheck,
+}
+
+func runTokenCheck(cmd *cobra.Command, args []string) error {
+	issues, err := token.CheckPermissions(flagTokenCheckFix)
+	if err != nil {
+		return err
+	}
+
+	if len(issues) == 0 {
+		fmt.Printf("%s: permissions ok\n", token.DefaultDir())
+		return nil
+	}
+
+	for _, issue := range issues {
+		if issue.Fixed {
+			fmt.Printf("fixed  %04o -> %04o  %s\n", issue.Mode, issue.Want, issue.Path)
+		} else {
+			fmt.Printf("open   %04o (want %04o)  %s\n", issue.Mode, issue.Want, issue.Path)
+		}
+	}
+
+	if !flagTokenCheckFix {
+		return fmt.Errorf("%d path(s) accessible by group or others; run \"vx token check --fix\"", len(issues))
+	}
+	return nil
+}
+
+// checkLocalPermissions warns about files under ~/.vx that the group or
+// other users can access. It runs before every command, so it never fails.
+func checkLocalPermissions() {
+	issues, err := token.CheckPermissions(false)
+	if err != nil {
+		log.Debug().Err(err).Msg("cannot check ~/.vx permissions")
+		return
+	}
+
+	for _, issue := range issues {
+		log.Warn().
+			Str("path", issue.Path).
+			Str("mode", fmt.Sprintf("%04o", issue.Mode)).
+			Msg("accessible by group or others; run \"vx token check --fix\"")
+	}
+}
+
+// tokenStoreEnv selects the token store like --token-store. vx sets it from
+// the flag so the daemon it starts in the background keeps using the same
+// store.
+const tokenStoreEnv = "VX_TOKEN_STORE"
+
+// tokenStore is the token store in use, "" until one is chosen.
+var tokenStore string
+
+// initTokenStore picks the token store from --token-store or
+// VX_TOKEN_STORE for commands that don't load a config.
+func initTokenStore() {
+	if flagTokenStore != "" {
+		os.Setenv(tokenStoreEnv, flagTokenStore)
+	}
+	useTokenStore("")
+}
+
+// useTokenStore selects where tokens are kept: --token-store, then
+// VX_TOKEN_STORE, then token_store from [vault], then the sink file. When
+// the OS keychain cannot be reached it warns and keeps the sink file.
+func useTokenStore(configured string) {
+	choice := cmp.Or(flagTokenStore, os.Getenv(tokenStoreEnv), configured, "file")
+	if choice == tokenStore {
+		return
+	}
+	tokenStore = choice
+
+	switch choice {
+	case "file":
+		token.UseFile()
+	case "keychain":
+		if err := token.UseKeychain(); err != nil {
+			log.Warn().Err(err).Str("path", token.TokenPath()).Msg("keeping the Vault token in a file instead")
+		}
+	default:
+		log.Warn().Str("token_store", choice).Msg("unknown token store (want file or keychain); keeping the Vault token in a file")
+		token.UseFile()
+	}
+}
+
+// expiredTokensTimeout bounds the Vault lookups of removeStaleTokens.
+const expiredTokensTimeout = 5 * time.Second
+
+// removeStaleTokens deletes leftover token files, and the tokens of other
+// servers and of [vaults] connections that Vault reports expired, once a
+// new token has been saved. Failures are only logged.
+func removeStaleTokens(cfg *config.RootConfig) {
+	n, err := token.RemoveStaleTokens()
+	if err != nil {
+		log.Debug().Err(err).Msg("cannot remove stale tokens")
+	} else if n > 0 {
+		log.Debug().Int("removed", n).Msg("removed stale token files")
+	}
+
+	var named []*token.TokenRenewer
+	for _, name := range slices.Sorted(maps.Keys(cfg.Vaults)) {
+		v := cfg.Vaults[name]
+		opts := append(renewerOptions(&config.RootConfig{Vault: v}), token.WithVaultName(name))
+		named = append(named, token.NewTokenRenewer(v.Address, opts...))
+	}
+	newRenewer := func(addr string) *token.TokenRenewer {
+		return token.NewTokenRenewer(addr, renewerOptions(cfg)...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), expiredTokensTimeout)
+	defer cancel()
+	n, err = token.RemoveExpiredTokens(ctx, newRenewer, named)
+	if err != nil {
+		log.Debug().Err(err).Msg("cannot remove expired tokens")
+	} else if n > 0 {
+		log.Debug().Int("removed", n).Msg("removed expired tokens")
+	}
+}
+
 func runTokenStatus(cmd *cobra.Command, args []string) error {
 	cfg, _, err := loadConfig()
 	if err != nil {
@@ -36,11 +175,11 @@ func runTokenStatus(cmd *cobra.Command, args []string) error {
 	tok, err := token.ReadToken()
 	if err != nil {
 		fmt.Println("Token: not found")
-		fmt.Printf("Token path: %s\n", token.TokenPath())
+		fmt.Printf("Token store: %s\n", token.Location())
 		return nil
 	}
 
-	client, err := vault.NewClientWithToken(cfg.Vault.Address, cfg.Vault.BasePath, tok)
+	client, err := vault.NewClientWithToken(cfg.Vault.Address, cfg.Vault.BasePath, tok, vaultClientOptions(cfg)...)
 	if err != nil {
 		return fmt.Errorf("creating vault client: %w", err)
 	}
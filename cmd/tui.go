@@ -3,10 +3,20 @@ package cmd
 import (
 	"github.com/spf13/cobra"
 
+	"go.dot.industries/vx/internal/config"
 	"go.dot.industries/vx/internal/tui"
 )
 
+var (
+	flagTUIPlain  bool
+	flagTUISelect string
+)
+
 func init() {
+	for _, c := range []*cobra.Command{tuiCmd, browseCmd} {
+		c.Flags().BoolVar(&flagTUIPlain, "plain", false, "render without the alternate screen, colors, or borders (for screen readers)")
+		c.Flags().StringVar(&flagTUISelect, "select", "", "select this mapped variable on startup and open its details")
+	}
 	rootCmd.AddCommand(tuiCmd)
 	rootCmd.AddCommand(browseCmd)
 }
@@ -16,7 +26,17 @@ var tuiCmd = &cobra.Command{
 	Short: "Interactive terminal UI for browsing and managing secrets",
 	Long: `Opens an interactive dual-pane terminal dashboard for browsing
 workspaces and secrets, resolving values from Vault on demand, and
-managing secret mappings in vx.toml files.`,
+managing secret mappings in vx.toml files.
+
+-w, -e, and --select open it on a given workspace, environment, and secret
+instead of where the last session left off, for links from scripts, docs,
+and editors:
+
+  vx tui -w api -e staging --select DATABASE_URL
+
+--plain renders the same screens as plain lines for screen readers: no
+alternate screen, colors, or box drawing, with ">" marking the selection.
+Setting NO_COLOR turns off colors in the full view as well.`,
 	RunE: runTUI,
 }
 
@@ -27,6 +47,38 @@ var browseCmd = &cobra.Command{
 	RunE:   runTUI,
 }
 
+// logTraceID logs the correlation ID before the TUI takes over the
+// terminal, when requests are traced, so they can be matched against the
+// Vault audit log. Nothing is logged once the TUI runs: it would draw over
+// the screen.
+func logTraceID() {
+	if cfg, _, err := loadConfig(); err == nil {
+		traceHeaders(cfg)
+	} else if flagTrace {
+		traceHeaders(&config.RootConfig{})
+	}
+	logCorrelationOnce.Do(func() {})
+}
+
 func runTUI(_ *cobra.Command, _ []string) error {
-	return tui.Run(flagConfigDir, flagVaultAddr, flagAuth, flagRoleID, flagSecretID)
+	var opts []tui.Option
+	if flagTUIPlain {
+		opts = append(opts, tui.WithPlain())
+	}
+	if flagWorkspace != "" {
+		opts = append(opts, tui.WithWorkspace(flagWorkspace))
+	}
+	if flagEnv != "" {
+		opts = append(opts, tui.WithEnvironment(flagEnv))
+	}
+	if flagTUISelect != "" {
+		opts = append(opts, tui.WithSelect(flagTUISelect))
+	}
+	if ns := namespaceOverride(); ns != "" {
+		opts = append(opts, tui.WithNamespace(ns))
+	}
+	opts = append(opts, tui.WithClientOptions(connectionOptions))
+	logTraceID()
+	roleID, secretID := appRoleCredentials()
+	return tui.Run(flagConfigDir, flagVaultAddr, flagAuth, roleID, secretID, opts...)
 }
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/vault"
+)
+
+func init() {
+	rootCmd.AddCommand(unwrapCmd)
+}
+
+var unwrapCmd = &cobra.Command{
+	Use:   "unwrap <token>",
+	Short: "Print the secret behind a wrapping token from `vx get --wrap`",
+	Long: `Reads the value a teammate shared with ` + "`vx get --wrap`" + `. The wrapping
+token itself authorizes the read, so no login is needed, and it works only
+once: if unwrapping fails for a token you have not used, treat the secret as
+seen by someone else and rotate it.
+
+The Vault address comes from vx.toml or --vault-addr.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUnwrap,
+}
+
+func runUnwrap(cmd *cobra.Command, args []string) error {
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	addr := cfg.Vault.Address
+	if flagVaultAddr != "" {
+		addr = flagVaultAddr
+	}
+
+	client, err := vault.NewClient(addr, cfg.Vault.BasePath, vaultClientOptions(cfg)...)
+	if err != nil {
+		return fmt.Errorf("creating vault client: %w", err)
+	}
+
+	values, err := client.Unwrap(strings.TrimSpace(args[0]))
+	if err != nil {
+		return err
+	}
+
+	// A single value, as wrapped by vx get --wrap, is printed bare.
+	if len(values) == 1 {
+		for name, value := range values {
+			log.Info().Str("name", name).Msg("unwrapped")
+			fmt.Println(value)
+		}
+		return nil
+	}
+	for _, name := range sortedKeys(values) {
+		fmt.Printf("%s=%s\n", name, values[name])
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/config"
+	vxexec "go.dot.industries/vx/internal/exec"
+)
+
+func init() {
+	rootCmd.AddCommand(upCmd)
+}
+
+var upCmd = &cobra.Command{
+	Use:   "up [service...]",
+	Short: "Start the [services] of vx.toml in dependency order",
+	Long: `Starts the services defined under [services] in the root vx.toml, each
+with the secrets and defaults of its own workspace and environment, after
+the services it depends on. Naming services starts only those and their
+dependencies.
+
+  [services.api]
+  command = "go run ./cmd/api"
+  workspace = "api"
+  depends_on = ["db"]
+
+  [services.db]
+  command = "docker compose up postgres"
+
+Output is prefixed with the service name. When any service exits, or on
+Ctrl+C, the others are stopped in reverse order, and vx exits with the
+exit code of the service that stopped the group.`,
+	RunE: runUp,
+}
+
+func runUp(cmd *cobra.Command, args []string) error {
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if len(cfg.Services) == 0 {
+		return fmt.Errorf("no [services] defined in %s", rootConfigPath(rootDir))
+	}
+
+	order, err := config.ServiceOrder(cfg.Services, args)
+	if err != nil {
+		return err
+	}
+
+	// Resolve everything before starting anything, so a missing secret
+	// doesn't leave half the services running.
+	procs := make([]vxexec.Process, 0, len(order))
+	for _, name := range order {
+		p, err := serviceProcess(cfg, rootDir, name, cfg.Services[name])
+		if err != nil {
+			return fmt.Errorf("service %s: %w", name, err)
+		}
+		procs = append(procs, p)
+	}
+
+	log.Info().Strs("services", order).Msg("starting services")
+
+	err = vxexec.RunGroup(context.Background(), procs,
+		vxexec.WithColor(colorEnabled(os.Stdout)),
+	)
+	if err != nil {
+		os.Exit(vxexec.ExitCode(err))
+	}
+	return nil
+}
+
+// serviceProcess resolves a service's secrets and defaults and describes
+// the process to start.
+func serviceProcess(cfg *config.RootConfig, rootDir, name string, svc config.ServiceConfig) (vxexec.Process, error) {
+	env := svc.Env
+	if env == "" {
+		env = resolveEnv(cfg)
+	}
+
+	merged, dir, err := mergeForService(cfg, rootDir, svc, env)
+	if err != nil {
+		return vxexec.Process{}, err
+	}
+	if svc.Dir != "" {
+		dir = filepath.Join(rootDir, svc.Dir)
+	}
+
+	values := make(map[string]string, len(merged.Defaults)+len(merged.Secrets))
+	for k, v := range merged.Defaults {
+		values[k] = v
+	}
+	if len(merged.Secrets) > 0 {
+		client, err := authenticatedClient(cfg, env)
+		if err != nil {
+			return vxexec.Process{}, err
+		}
+		secrets, err := resolveSecrets(client, merged)
+		if err != nil {
+			return vxexec.Process{}, err
+		}
+		for k, v := range secrets {
+			values[k] = v
+		}
+	}
+
+	return vxexec.Process{
+		Name:    name,
+		Command: vxexec.ShellCommand(svc.Command),
+		Dir:     dir,
+		Env:     values,
+	}, nil
+}
+
+// mergeForService merges the config of the service's workspace, or the
+// root config alone when it has none, and returns it with the directory
+// the service runs in by default.
+func mergeForService(cfg *config.RootConfig, rootDir string, svc config.ServiceConfig, env string) (*config.MergedConfig, string, error) {
+	if svc.Workspace == "" {
+		merged, err := config.Merge(cfg, nil, env)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, w := range merged.Warnings {
+			log.Warn().Msg(w)
+		}
+		return merged, rootDir, nil
+	}
+
+	wsPath, err := config.ResolveWorkspacePath(rootDir, svc.Workspace, cfg.Workspaces)
+	if err != nil {
+		return nil, "", fmt.Errorf("resolving workspace path: %w", err)
+	}
+
+	merged, err := mergeForWorkspace(cfg, rootDir, svc.Workspace, env)
+	if err != nil {
+		return nil, "", err
+	}
+	return merged, filepath.Dir(wsPath), nil
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/resolver"
+	"go.dot.industries/vx/internal/vault"
+)
+
+var (
+	flagMetaMaxVersions int
+	flagMetaDeleteAfter time.Duration
+)
+
+func init() {
+	vaultMetaSetCmd.Flags().IntVar(&flagMetaMaxVersions, "max-versions", 0, "number of versions to keep (0 uses the mount's setting)")
+	vaultMetaSetCmd.Flags().DurationVar(&flagMetaDeleteAfter, "delete-after", 0, "delete versions this long after they are written (0 keeps them)")
+
+	vaultMetaCmd.AddCommand(vaultMetaGetCmd)
+	vaultMetaCmd.AddCommand(vaultMetaSetCmd)
+	vaultCmd.AddCommand(vaultMetaCmd)
+	rootCmd.AddCommand(vaultCmd)
+}
+
+var vaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "Work with secrets in Vault directly",
+}
+
+var vaultMetaCmd = &cobra.Command{
+	Use:   "meta",
+	Short: "Read and change KV v2 secret metadata",
+	Long: `Reads and changes the metadata of a KV v2 secret, including its retention
+settings: how many versions are kept and how long each version lives.
+
+Paths are relative to vault.base_path, like the ones in [secrets], and
+${env} is replaced with the selected environment:
+
+  vx vault meta get '${env}/database'
+  vx vault meta set --max-versions 10 --delete-after 720h '${env}/database'`,
+}
+
+var vaultMetaGetCmd = &cobra.Command{
+	Use:   "get <path>",
+	Short: "Print a secret's metadata and retention settings",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runVaultMetaGet,
+}
+
+var vaultMetaSetCmd = &cobra.Command{
+	Use:   "set <path>",
+	Short: "Change a secret's retention settings",
+	Long: `Changes the retention settings of a secret. Only the flags given are
+changed. Needs "create" or "update" on <base_path>/metadata/<path>.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVaultMetaSet,
+}
+
+// metaClient authenticates and returns the client along with args[0] with
+// ${env} interpolated and surrounding slashes removed.
+func metaClient(args []string) (*vault.Client, string, error) {
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	env := resolveEnv(cfg)
+	kvPath := strings.Trim(resolver.Interpolate(args[0], cfg.Environments.PathSegment(env)), "/")
+	if kvPath == "" {
+		return nil, "", fmt.Errorf("path is required")
+	}
+
+	client, err := authenticatedClient(cfg, env)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, kvPath, nil
+}
+
+func runVaultMetaGet(cmd *cobra.Command, args []string) error {
+	client, kvPath, err := metaClient(args)
+	if err != nil {
+		return err
+	}
+
+	meta, err := client.ReadMetadata(kvPath)
+	if err != nil {
+		return err
+	}
+
+	printMetadata(kvPath, meta)
+	return nil
+}
+
+func runVaultMetaSet(cmd *cobra.Command, args []string) error {
+	var settings vault.MetadataSettings
+	if cmd.Flags().Changed("max-versions") {
+		if flagMetaMaxVersions < 0 {
+			return fmt.Errorf("--max-versions must not be negative")
+		}
+		settings.MaxVersions = &flagMetaMaxVersions
+	}
+	if cmd.Flags().Changed("delete-after") {
+		if flagMetaDeleteAfter < 0 {
+			return fmt.Errorf("--delete-after must not be negative")
+		}
+		settings.DeleteVersionAfter = &flagMetaDeleteAfter
+	}
+	if settings.MaxVersions == nil && settings.DeleteVersionAfter == nil {
+		return fmt.Errorf("nothing to change: pass --max-versions or --delete-after")
+	}
+
+	client, kvPath, err := metaClient(args)
+	if err != nil {
+		return err
+	}
+
+	if err := client.WriteMetadata(kvPath, settings); err != nil {
+		return err
+	}
+	log.Info().Str("path", kvPath).Msg("updated secret metadata")
+
+	meta, err := client.ReadMetadata(kvPath)
+	if err != nil {
+		// The write succeeded; without read access there is just nothing to show.
+		log.Debug().Err(err).Msg("reading metadata back")
+		return nil
+	}
+
+	printMetadata(kvPath, meta)
+	return nil
+}
+
+// printMetadata prints the metadata of the secret at kvPath.
+func printMetadata(kvPath string, meta *vault.KVMetadata) {
+	fmt.Printf("Path:             %s\n", kvPath)
+	fmt.Printf("Current version:  %d\n", meta.CurrentVersion)
+	if !meta.CreatedTime.IsZero() {
+		fmt.Printf("Created:          %s\n", meta.CreatedTime.Local().Format("2006-01-02 15:04:05"))
+	}
+	if !meta.UpdatedTime.IsZero() {
+		fmt.Printf("Updated:          %s\n", meta.UpdatedTime.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	if meta.MaxVersions > 0 {
+		fmt.Printf("Max versions:     %d\n", meta.MaxVersions)
+	} else {
+		fmt.Println("Max versions:     mount default")
+	}
+	if meta.DeleteVersionAfter > 0 {
+		fmt.Printf("Delete after:     %s\n", meta.DeleteVersionAfter)
+	} else {
+		fmt.Println("Delete after:     never")
+	}
+
+	if len(meta.CustomMetadata) > 0 {
+		fmt.Println("Custom metadata:")
+		for _, k := range sortedKeys(meta.CustomMetadata) {
+			fmt.Printf("  %s = %s\n", k, meta.CustomMetadata[k])
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/resolver"
+	"go.dot.industries/vx/internal/token"
+	"go.dot.industries/vx/internal/vault"
+)
+
+var (
+	namedMu      sync.Mutex
+	namedClients = map[string]*vault.Client{}
+	namedMemos   = map[string]*resolver.Memo{}
+	namedCaches  = map[string]*resolver.Cache{}
+)
+
+// groupByVault splits mappings by the connection they read from, keyed by
+// its name from [vaults] ("" for [vault]), with the "vault://<name>/" prefix
+// removed. Command mappings are kept with [vault].
+func groupByVault(mappings map[string]string) map[string]map[string]string {
+	groups := map[string]map[string]string{"": {}}
+	for envVar, rawPath := range mappings {
+		name, path := config.SplitVault(rawPath)
+		if groups[name] == nil {
+			groups[name] = make(map[string]string)
+		}
+		groups[name][envVar] = path
+	}
+	return groups
+}
+
+// sortedVaultNames returns the names of the [vaults] connections in groups,
+// leaving out [vault].
+func sortedVaultNames(groups map[string]map[string]string) []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// vaultMemo returns the memo for a named connection. Memos are keyed by
+// path, so each connection needs its own.
+func vaultMemo(name string) *resolver.Memo {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	m, ok := namedMemos[name]
+	if !ok {
+		m = resolver.NewMemo()
+		namedMemos[name] = m
+	}
+	return m
+}
+
+// vaultCache returns the read cache for a named connection. Like memos,
+// caches are keyed by path, so each connection needs its own.
+func vaultCache(name string) *resolver.Cache {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	c, ok := namedCaches[name]
+	if !ok {
+		c = resolver.NewCache(0)
+		namedCaches[name] = c
+	}
+	return c
+}
+
+// clearSecretCaches drops every cached Vault read of this invocation.
+func clearSecretCaches() {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	secretCache.Clear()
+	for _, c := range namedCaches {
+		c.Clear()
+	}
+}
+
+// resetSecretMemos forgets every Vault read of this invocation, so the next
+// resolution reads the current values.
+func resetSecretMemos() {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	secretMemo.Reset()
+	for _, m := range namedMemos {
+		m.Reset()
+	}
+}
+
+// clientForMapping returns the client and the path within its Vault for a
+// Vault mapping: the named connection's client for a "vault://<name>/"
+// mapping, the [vault] client from authenticatedClient otherwise.
+func clientForMapping(cfg *config.RootConfig, merged *config.MergedConfig, rawPath string) (*vault.Client, string, error) {
+	name, path := config.SplitVault(rawPath)
+	if name == "" {
+		client, err := authenticatedClient(cfg, merged.Environment)
+		return client, path, err
+	}
+
+	client, err := namedVaultClient(merged, name)
+	if err != nil {
+		return nil, "", fmt.Errorf("vault %s: %w", name, err)
+	}
+	return client, path, nil
+}
+
+// namedVaultClient returns an authenticated client for the [vaults]
+// connection name, created once per invocation. A token cached in the
+// connection's sink (~/.vx/token-<name>) is used while it is valid;
+// otherwise vx logs in with the connection's auth_method and caches the new
+// token there. The renewal daemon only renews the [vault] token.
+//
+// The --vault-addr, --auth and --vault-token flags apply to [vault] only.
+// AppRole credentials come from VX_ROLE_ID_<NAME> and VX_SECRET_ID_<NAME>,
+// with the name upper-cased and "-" replaced by "_".
+func namedVaultClient(merged *config.MergedConfig, name string) (*vault.Client, error) {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	if client, ok := namedClients[name]; ok {
+		return client, nil
+	}
+
+	v, ok := merged.Vaults[name]
+	if !ok {
+		return nil, fmt.Errorf("not defined in [vaults]")
+	}
+
+	opts := vaultClientOptions(&config.RootConfig{Vault: v, Resolver: merged.Resolver})
+
+	if v.AuthMethod == "token" {
+		tok, err := token.FromFile(v.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("token auth: %w", err)
+		}
+		client, err := vault.NewClientWithToken(v.Address, v.BasePath, tok, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("creating vault client: %w", err)
+		}
+		namedClients[name] = client
+		return client, nil
+	}
+
+	if tok, err := token.ReadVaultToken(name); err == nil {
+		client, err := vault.NewClientWithToken(v.Address, v.BasePath, tok, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("creating vault client: %w", err)
+		}
+		if client.IsAuthenticated() {
+			log.Debug().Str("vault", name).Msg("using cached vault token")
+			namedClients[name] = client
+			return client, nil
+		}
+	}
+
+	client, err := vault.NewClient(v.Address, v.BasePath, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating vault client: %w", err)
+	}
+
+	env := merged.Environment
+	switch v.AuthMethod {
+	case "oidc":
+		log.Warn().Str("vault", name).Msg("no valid Vault token — opening browser for authentication...")
+		if err := oidcLogin(client, v, env); err != nil {
+			return nil, fmt.Errorf("OIDC authentication: %w", err)
+		}
+	case "approle":
+		suffix := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		roleID, secretID := os.Getenv("VX_ROLE_ID_"+suffix), os.Getenv("VX_SECRET_ID_"+suffix)
+		if roleID == "" || secretID == "" {
+			return nil, fmt.Errorf("AppRole auth requires VX_ROLE_ID_%s and VX_SECRET_ID_%s", suffix, suffix)
+		}
+		if _, err := vault.AppRoleAuth(client, roleID, secretID); err != nil {
+			return nil, fmt.Errorf("AppRole authentication: %w", err)
+		}
+	case "kubernetes":
+		if err := kubernetesLogin(client, v, env); err != nil {
+			return nil, fmt.Errorf("Kubernetes authentication: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported auth method: %s", v.AuthMethod)
+	}
+
+	if err := token.WriteVaultToken(name, client.Token()); err != nil {
+		log.Warn().Err(err).Str("vault", name).Msg("failed to cache token")
+	}
+
+	namedClients[name] = client
+	return client, nil
+}
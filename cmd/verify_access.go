@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/resolver"
+	"go.dot.industries/vx/internal/verify"
+)
+
+var flagVerifyAllowPrefixes []string
+
+func init() {
+	verifyAccessCmd.Flags().StringSliceVar(&flagVerifyAllowPrefixes, "allow-prefix", nil, "Vault path prefix the token may read beyond the required paths (repeatable, ${env} is interpolated)")
+	rootCmd.AddCommand(verifyAccessCmd)
+}
+
+var verifyAccessCmd = &cobra.Command{
+	Use:   "verify-access",
+	Short: "Check that the current credentials read exactly what the workspace needs",
+	Long: `Verifies, without reading any secret values, that the current Vault token
+can read every path mapped for the target workspace and environment, and
+cannot read anything outside the allowed prefixes. Intended for CI, to fail a
+build early when Vault policies drift.
+
+Access is checked with sys/capabilities-self against:
+
+  - every path mapped by the workspace (must be readable)
+  - every path mapped by any workspace in any environment, plus made-up paths
+    at the mount root and under each environment (must NOT be readable unless
+    they fall under an --allow-prefix)
+
+Without --allow-prefix, only the workspace's own mapped paths are allowed.
+Only [vault] is checked; mappings to a named connection in [vaults] are
+skipped.
+
+  vx verify-access -e production -w api --auth approle
+  vx verify-access --allow-prefix '${env}/api' --allow-prefix shared/api`,
+	Args: cobra.NoArgs,
+	RunE: runVerifyAccess,
+}
+
+func runVerifyAccess(cmd *cobra.Command, args []string) error {
+	cfg, rootDir, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	env := resolveEnv(cfg)
+
+	workspace, err := detectWorkspace(cfg, rootDir, []string{})
+	if err != nil {
+		return err
+	}
+
+	merged, err := mergeForWorkspace(cfg, rootDir, workspace, env)
+	if err != nil {
+		return err
+	}
+
+	required := verify.RequiredPaths(groupByVault(merged.Secrets)[""], merged.PathEnv)
+
+	probes, err := accessProbes(cfg, rootDir)
+	if err != nil {
+		return err
+	}
+
+	allowed := make([]string, len(flagVerifyAllowPrefixes))
+	for i, prefix := range flagVerifyAllowPrefixes {
+		allowed[i] = resolver.Interpolate(prefix, merged.PathEnv)
+	}
+
+	client, err := authenticatedClient(cfg, env)
+	if err != nil {
+		return err
+	}
+
+	result, err := verify.Check(client, required, probes, allowed)
+	if err != nil {
+		return err
+	}
+
+	printVerifyResult(result, env, workspace)
+
+	if !result.OK() {
+		return fmt.Errorf("access does not match the %s mappings", env)
+	}
+	return nil
+}
+
+// accessProbes returns every path mapped by any workspace in any available
+// environment, plus synthetic paths that only a wildcard grant can read.
+func accessProbes(cfg *config.RootConfig, rootDir string) ([]string, error) {
+	var probes []string
+
+	for _, env := range cfg.Environments.Available {
+		all, err := mergeWorkspaceConfig(cfg, rootDir, "", env)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s mappings: %w", env, err)
+		}
+		for path := range verify.RequiredPaths(groupByVault(all.Secrets)[""], all.PathEnv) {
+			probes = append(probes, path)
+		}
+	}
+
+	log.Debug().Int("mapped", len(probes)).Msg("collected probe paths")
+	segments := make([]string, len(cfg.Environments.Available))
+	for i, env := range cfg.Environments.Available {
+		segments[i] = cfg.Environments.PathSegment(env)
+	}
+	return append(probes, verify.SyntheticProbes(segments)...), nil
+}
+
+// printVerifyResult prints one line per required path followed by any
+// excess access.
+func printVerifyResult(result *verify.Result, env string, workspace string) {
+	fmt.Printf("Environment: %s\n", env)
+	if workspace != "" {
+		fmt.Printf("Workspace:   %s\n", workspace)
+	}
+	fmt.Println()
+
+	for _, p := range result.Required {
+		status := "ok"
+		if !p.Readable {
+			status = "MISSING"
+		}
+		fmt.Printf("  %-8s %s (%s)\n", status, p.Path, strings.Join(p.EnvVars, ", "))
+	}
+
+	for _, p := range result.Excess {
+		fmt.Printf("  %-8s %s\n", "EXCESS", p)
+	}
+
+	fmt.Printf("\n%d required path(s), %d outside path(s) probed", len(result.Required), result.Probed)
+	if len(result.Excess) > 0 {
+		fmt.Printf(", %d readable outside the allowed prefixes", len(result.Excess))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/token"
+	"go.dot.industries/vx/internal/vault"
+)
+
+func init() {
+	rootCmd.AddCommand(whoamiCmd)
+}
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Show the Vault identity behind the cached token",
+	Long: `Looks up the cached Vault token and prints the authenticated identity:
+display name, entity, policies, token accessor, TTL, and any OIDC claims
+recorded in the token or entity alias metadata.`,
+	Args: cobra.NoArgs,
+	RunE: runWhoami,
+}
+
+func runWhoami(cmd *cobra.Command, args []string) error {
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	addr := cfg.Vault.Address
+	if flagVaultAddr != "" {
+		addr = flagVaultAddr
+	}
+
+	var tok string
+	if selectedAuthMethod(cfg) == "token" {
+		if tok, err = externalToken(cfg); err != nil {
+			return err
+		}
+	} else if tok, err = token.ReadToken(); err != nil {
+		return fmt.Errorf("no cached Vault token; run `vx login` first")
+	}
+
+	client, err := vault.NewClientWithToken(addr, cfg.Vault.BasePath, tok, vaultClientOptions(cfg)...)
+	if err != nil {
+		return fmt.Errorf("creating vault client: %w", err)
+	}
+
+	info, err := client.LookupSelf()
+	if err != nil {
+		return err
+	}
+
+	var entity *vault.EntityInfo
+	if info.EntityID != "" {
+		entity, err = client.LookupEntity(info.EntityID)
+		if err != nil {
+			log.Debug().Err(err).Msg("entity details unavailable")
+		}
+	}
+
+	printIdentity(info, entity)
+
+	return nil
+}
+
+// printIdentity renders token and (optional) entity details.
+func printIdentity(info *vault.TokenInfo, entity *vault.EntityInfo) {
+	printField("Display name", info.DisplayName)
+	printField("Entity ID", info.EntityID)
+	if entity != nil {
+		printField("Entity name", entity.Name)
+	}
+	printField("Auth path", info.AuthPath)
+	printField("Accessor", info.Accessor)
+	printField("Policies", strings.Join(info.Policies, ", "))
+	if len(info.IdentityPolicies) > 0 {
+		printField("Identity policies", strings.Join(info.IdentityPolicies, ", "))
+	}
+
+	switch {
+	case info.TTL <= 0 && info.ExpireTime.IsZero():
+		printField("TTL", "never expires")
+	case info.TTL <= 0:
+		printField("TTL", "expired")
+	default:
+		expires := time.Now().Add(info.TTL).Format("2006-01-02 15:04:05")
+		printField("TTL", fmt.Sprintf("%s (expires %s)", formatDuration(info.TTL), expires))
+	}
+
+	claims := identityClaims(info, entity)
+	if len(claims) > 0 {
+		fmt.Println("Claims:")
+		for _, name := range sortedKeys(claims) {
+			fmt.Printf("  %-20s %s\n", name, claims[name])
+		}
+	}
+}
+
+// identityClaims collects OIDC claims from the token metadata (populated by
+// the role's claim_mappings) and the metadata of any OIDC/JWT entity alias.
+func identityClaims(info *vault.TokenInfo, entity *vault.EntityInfo) map[string]string {
+	claims := make(map[string]string, len(info.Meta))
+	for k, v := range info.Meta {
+		claims[k] = v
+	}
+
+	if entity == nil {
+		return claims
+	}
+
+	for _, alias := range entity.Aliases {
+		if alias.MountType != "oidc" && alias.MountType != "jwt" {
+			continue
+		}
+		for k, v := range alias.Metadata {
+			if _, exists := claims[k]; !exists {
+				claims[k] = v
+			}
+		}
+	}
+
+	return claims
+}
+
+// printField prints a single aligned label/value line, skipping empty values.
+func printField(label string, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Printf("%-19s %s\n", label+":", value)
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"go.dot.industries/vx/internal/workspaces"
+)
+
+func init() {
+	workspacesCmd.AddCommand(workspacesListCmd)
+	workspacesCmd.AddCommand(workspacesAddCmd)
+	workspacesCmd.AddCommand(workspacesRemoveCmd)
+	workspacesCmd.AddCommand(workspacesRenameCmd)
+	rootCmd.AddCommand(workspacesCmd)
+}
+
+var workspacesCmd = &cobra.Command{
+	Use:   "workspaces",
+	Short: "Manage the workspaces of the root vx.toml",
+	Long: `Lists and edits the workspaces array of the root vx.toml. Edits keep the
+file's comments and layout, and check that a workspace's vx.toml exists and
+is not configured twice. A root vx.toml with workspaces = "auto" discovers
+them instead, so only list works there.`,
+}
+
+var workspacesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the configured workspaces",
+	Args:  cobra.NoArgs,
+	RunE:  runWorkspacesList,
+}
+
+var workspacesAddCmd = &cobra.Command{
+	Use:   "add <path>",
+	Short: "Add a workspace",
+	Long: `Adds the workspace at <path>, a directory or its vx.toml, to the root
+vx.toml. The file must exist inside the repository and parse as a workspace
+config. Its directory name becomes the workspace's name for -w, so it must
+not clash with another workspace:
+
+  vx workspaces add services/billing`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWorkspacesAdd,
+}
+
+var workspacesRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a workspace",
+	Long: `Removes the workspace <name> (or the one configured at that path) from the
+root vx.toml. Its vx.toml is left in place. A workspace that a [services]
+entry runs in cannot be removed until the service is changed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWorkspacesRemove,
+}
+
+var workspacesRenameCmd = &cobra.Command{
+	Use:   "rename <name> <new-path>",
+	Short: "Point a workspace at its new location",
+	Long: `Updates the entry of workspace <name> after its directory was moved or
+renamed, keeping its place in the array. <new-path> is checked as for add.
+When the directory name changes, so does the workspace's name, and
+[services] entries that run in it are updated to match:
+
+  git mv services/billing services/invoicing
+  vx workspaces rename billing services/invoicing`,
+	Args: cobra.ExactArgs(2),
+	RunE: runWorkspacesRename,
+}
+
+// openWorkspaces opens the root vx.toml for editing its workspaces.
+func openWorkspaces() (*workspaces.Editor, string, error) {
+	_, rootDir, err := loadConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	ed, err := workspaces.Open(rootConfigPath(rootDir))
+	if err != nil {
+		return nil, "", err
+	}
+	return ed, rootDir, nil
+}
+
+func runWorkspacesList(cmd *cobra.Command, args []string) error {
+	ed, rootDir, err := openWorkspaces()
+	if err != nil {
+		return err
+	}
+
+	entries := ed.List()
+	if ed.Auto() {
+		if entries, err = workspaces.Discovered(rootDir); err != nil {
+			return err
+		}
+		fmt.Println(`workspaces = "auto"; found these from the package manager manifests:`)
+	}
+	if len(entries) == 0 {
+		fmt.Println("no workspaces configured")
+		return nil
+	}
+
+	for _, e := range entries {
+		status := ""
+		if _, err := os.Stat(filepath.Join(rootDir, filepath.FromSlash(e.Path))); err != nil {
+			status = "  (missing)"
+		}
+		fmt.Printf("  %-20s %s%s\n", e.Name, e.Path, status)
+	}
+	return nil
+}
+
+func runWorkspacesAdd(cmd *cobra.Command, args []string) error {
+	ed, _, err := openWorkspaces()
+	if err != nil {
+		return err
+	}
+
+	p, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	entry, err := ed.Add(p)
+	if err != nil {
+		return err
+	}
+	if err := ed.Write(); err != nil {
+		return err
+	}
+
+	fmt.Printf("added workspace %s (%s)\n", entry.Name, entry.Path)
+	return nil
+}
+
+func runWorkspacesRemove(cmd *cobra.Command, args []string) error {
+	ed, _, err := openWorkspaces()
+	if err != nil {
+		return err
+	}
+
+	entry, err := ed.Remove(args[0])
+	if err != nil {
+		return err
+	}
+	if err := ed.Write(); err != nil {
+		return err
+	}
+
+	fmt.Printf("removed workspace %s (%s); its vx.toml was left in place\n", entry.Name, entry.Path)
+	return nil
+}
+
+func runWorkspacesRename(cmd *cobra.Command, args []string) error {
+	ed, _, err := openWorkspaces()
+	if err != nil {
+		return err
+	}
+
+	p, err := filepath.Abs(args[1])
+	if err != nil {
+		return err
+	}
+	old, renamed, services, err := ed.Rename(args[0], p)
+	if err != nil {
+		return err
+	}
+	if err := ed.Write(); err != nil {
+		return err
+	}
+
+	fmt.Printf("workspace %s (%s) is now %s (%s)\n", old.Name, old.Path, renamed.Name, renamed.Path)
+	for _, svc := range services {
+		fmt.Printf("  services.%s.workspace: %q -> %q\n", svc, old.Name, renamed.Name)
+	}
+	return nil
+}
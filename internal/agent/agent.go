@@ -0,0 +1,243 @@
+// Package agent serves resolved secrets to other processes over a Unix
+// socket, so repeated vx exec runs and other local tools reuse one
+// resolution instead of each reading Vault.
+//
+// The protocol is JSON over HTTP/1.1 on the socket:
+//
+//	GET /v1/secrets?workspace=web&env=dev
+//
+// returns {"workspace": "web", "env": "dev", "secrets": {...}, "cached": true}
+// or, on failure, {"error": "..."} with a 4xx or 5xx status. An optional
+// root parameter names the project directory the caller expects; an agent
+// serving another project answers 404. The socket is only accessible to the
+// user running the agent.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	dirPerms    = 0700
+	socketPerms = 0600
+)
+
+// Resolution is the result of resolving the secrets of a workspace.
+type Resolution struct {
+	Secrets map[string]string
+	// TTL is how long the agent may serve the secrets again without
+	// resolving them. Zero serves them once, as for a resolution that left
+	// secrets out.
+	TTL time.Duration
+}
+
+// Source resolves the secrets the agent serves.
+type Source interface {
+	// Key identifies everything a resolution of workspace in env depends
+	// on, such as the contents of the vx.toml files and the Vault token, so
+	// a cached result is only served while it is unchanged. It fails for an
+	// unknown workspace or environment.
+	Key(workspace, env string) (string, error)
+	// Resolve reads the secrets of workspace in env.
+	Resolve(ctx context.Context, workspace, env string) (Resolution, error)
+}
+
+// ServerOption configures a Server.
+type ServerOption func(*Server)
+
+// WithChangedSince makes the server stop serving results resolved before
+// the time fn returns, such as the last change Vault reported.
+func WithChangedSince(fn func() time.Time) ServerOption {
+	return func(s *Server) {
+		s.changedSince = fn
+	}
+}
+
+// Server answers requests for secrets from a Source, keeping each result in
+// memory for its TTL. Concurrent requests for the same result share one
+// resolution.
+type Server struct {
+	source       Source
+	root         string
+	changedSince func() time.Time
+	now          func() time.Time
+
+	group   singleflight.Group
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+// entry is a cached resolution.
+type entry struct {
+	secrets  map[string]string
+	resolved time.Time
+	ttl      time.Duration
+}
+
+// NewServer creates a Server for the project at root resolving from source.
+func NewServer(source Source, root string, opts ...ServerOption) *Server {
+	s := &Server{
+		source:  source,
+		root:    filepath.Clean(root),
+		now:     time.Now,
+		entries: make(map[string]entry),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Listen creates the daemon socket the agent serves on at path, accessible
+// to the current user only. A socket left behind by an agent that is no longer running is
+// replaced; one that still answers is an error.
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("agent: already running on %s", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("agent: removing stale socket: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), dirPerms); err != nil {
+		return nil, fmt.Errorf("agent: %w", err)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("agent: %w", err)
+	}
+	if err := os.Chmod(path, socketPerms); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("agent: %w", err)
+	}
+	return ln, nil
+}
+
+// Serve answers requests on ln until ctx is done, then closes it.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	return Serve(ctx, ln, s.Handler())
+}
+
+// Serve answers requests on ln with h until ctx is done, then closes it,
+// for a socket serving the agent's protocol alongside other endpoints.
+func Serve(ctx context.Context, ln net.Listener, h http.Handler) error {
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("agent: %w", err)
+	}
+	return nil
+}
+
+// Handler returns the HTTP handler of the agent's protocol.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/secrets", s.handleSecrets)
+	return mux
+}
+
+// secretsResponse is the body of a successful GET /v1/secrets.
+type secretsResponse struct {
+	Workspace string            `json:"workspace"`
+	Env       string            `json:"env"`
+	Secrets   map[string]string `json:"secrets"`
+	Cached    bool              `json:"cached"`
+}
+
+// errorResponse is the body of a failed request.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) handleSecrets(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if root := q.Get("root"); root != "" && filepath.Clean(root) != s.root {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: fmt.Sprintf("agent serves %s, not %s", s.root, root)})
+		return
+	}
+	workspace, env := q.Get("workspace"), q.Get("env")
+	if env == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "env is required"})
+		return
+	}
+
+	key, err := s.source.Key(workspace, env)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	secrets, cached, err := s.secrets(r.Context(), key, workspace, env)
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, secretsResponse{Workspace: workspace, Env: env, Secrets: secrets, Cached: cached})
+}
+
+// secrets returns the cached result for key while it is fresh, and
+// otherwise resolves workspace in env and caches the result for its TTL.
+func (s *Server) secrets(ctx context.Context, key, workspace, env string) (map[string]string, bool, error) {
+	s.mu.Lock()
+	e, ok := s.entries[key]
+	s.mu.Unlock()
+	if ok && s.fresh(e) {
+		return e.secrets, true, nil
+	}
+
+	v, err, _ := s.group.Do(key, func() (any, error) {
+		// Shared with concurrent callers, so not cancelled with this one.
+		res, err := s.source.Resolve(context.WithoutCancel(ctx), workspace, env)
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for k, e := range s.entries {
+			if !s.fresh(e) {
+				delete(s.entries, k)
+			}
+		}
+		if res.TTL > 0 {
+			s.entries[key] = entry{secrets: res.Secrets, resolved: s.now(), ttl: res.TTL}
+		}
+		return res.Secrets, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return v.(map[string]string), false, nil
+}
+
+// fresh reports whether e may still be served: it is younger than its TTL
+// and was resolved after the last reported change.
+func (s *Server) fresh(e entry) bool {
+	if s.now().Sub(e.resolved) >= e.ttl {
+		return false
+	}
+	return s.changedSince == nil || e.resolved.After(s.changedSince())
+}
+
+// writeJSON writes v as the JSON body of a response with status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
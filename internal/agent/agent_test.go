@@ -0,0 +1,188 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeSource resolves a counter, so tests can tell a cached result from a
+// new resolution.
+type fakeSource struct {
+	key      string
+	ttl      time.Duration
+	err      error
+	resolves atomic.Int32
+}
+
+func (f *fakeSource) Key(workspace, env string) (string, error) {
+	if workspace == "unknown" {
+		return "", fmt.Errorf("workspace %q not found", workspace)
+	}
+	return f.key + "|" + workspace + "|" + env, nil
+}
+
+func (f *fakeSource) Resolve(ctx context.Context, workspace, env string) (Resolution, error) {
+	if f.err != nil {
+		return Resolution{}, f.err
+	}
+	n := f.resolves.Add(1)
+	return Resolution{
+		Secrets: map[string]string{"DB_URL": fmt.Sprintf("%s-%s-%d", workspace, env, n)},
+		TTL:     f.ttl,
+	}, nil
+}
+
+// serve starts a server for src on a socket in a temporary directory and
+// returns a client for it. The directory is kept short, as socket paths are
+// limited to about a hundred bytes.
+func serve(t *testing.T, src Source, root string, opts ...ServerOption) (*Server, *Client) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "vxagent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "daemon.sock")
+
+	ln, err := Listen(socket)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	srv := NewServer(src, root, opts...)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		srv.Serve(ctx, ln)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return srv, NewClient(socket)
+}
+
+func TestServer_CachesForTTL(t *testing.T) {
+	src := &fakeSource{key: "k", ttl: time.Minute}
+	srv, client := serve(t, src, "/work/app")
+	now := time.Now()
+	srv.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	first, err := client.Secrets(ctx, "/work/app", "web", "dev")
+	if err != nil {
+		t.Fatalf("Secrets() error = %v", err)
+	}
+	if first["DB_URL"] != "web-dev-1" {
+		t.Errorf("DB_URL = %q, want web-dev-1", first["DB_URL"])
+	}
+	again, _ := client.Secrets(ctx, "/work/app", "web", "dev")
+	if again["DB_URL"] != "web-dev-1" {
+		t.Errorf("second request resolved again: DB_URL = %q", again["DB_URL"])
+	}
+	other, _ := client.Secrets(ctx, "/work/app", "web", "staging")
+	if other["DB_URL"] != "web-staging-2" {
+		t.Errorf("another environment was served from the cache: DB_URL = %q", other["DB_URL"])
+	}
+
+	// A changed key, as after editing vx.toml, resolves again.
+	src.key = "edited"
+	if v, _ := client.Secrets(ctx, "/work/app", "web", "dev"); v["DB_URL"] != "web-dev-3" {
+		t.Errorf("after a key change DB_URL = %q, want web-dev-3", v["DB_URL"])
+	}
+
+	now = now.Add(time.Minute)
+	if v, _ := client.Secrets(ctx, "/work/app", "web", "dev"); v["DB_URL"] != "web-dev-4" {
+		t.Errorf("after the TTL DB_URL = %q, want web-dev-4", v["DB_URL"])
+	}
+}
+
+func TestServer_ChangedSince(t *testing.T) {
+	src := &fakeSource{key: "k", ttl: time.Hour}
+	var changed time.Time
+	_, client := serve(t, src, "/work/app", WithChangedSince(func() time.Time { return changed }))
+	ctx := context.Background()
+
+	client.Secrets(ctx, "/work/app", "", "dev")
+	changed = time.Now()
+	if v, _ := client.Secrets(ctx, "/work/app", "", "dev"); v["DB_URL"] != "-dev-2" {
+		t.Errorf("DB_URL = %q, want a resolution after the change", v["DB_URL"])
+	}
+}
+
+func TestServer_ZeroTTLNotCached(t *testing.T) {
+	src := &fakeSource{key: "k"}
+	_, client := serve(t, src, "/work/app")
+	ctx := context.Background()
+
+	client.Secrets(ctx, "/work/app", "web", "dev")
+	client.Secrets(ctx, "/work/app", "web", "dev")
+	if n := src.resolves.Load(); n != 2 {
+		t.Errorf("resolved %d times, want 2", n)
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	src := &fakeSource{key: "k", err: errors.New("permission denied")}
+	_, client := serve(t, src, "/work/app")
+	ctx := context.Background()
+
+	tests := []struct {
+		root, workspace, env string
+		want                 string
+	}{
+		{"/work/other", "web", "dev", "agent serves /work/app"},
+		{"/work/app", "web", "", "env is required"},
+		{"/work/app", "unknown", "dev", `workspace "unknown" not found`},
+		{"/work/app", "web", "dev", "permission denied"},
+	}
+	for _, tt := range tests {
+		_, err := client.Secrets(ctx, tt.root, tt.workspace, tt.env)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Secrets(%q, %q, %q) error = %v, want %q", tt.root, tt.workspace, tt.env, err, tt.want)
+		}
+		if errors.Is(err, ErrUnavailable) {
+			t.Errorf("Secrets(%q, %q, %q) reported the agent unavailable", tt.root, tt.workspace, tt.env)
+		}
+	}
+}
+
+func TestClient_Unavailable(t *testing.T) {
+	client := NewClient(filepath.Join(t.TempDir(), "daemon.sock"))
+	if _, err := client.Secrets(context.Background(), "/work/app", "", "dev"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Secrets() error = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestListen(t *testing.T) {
+	dir, err := os.MkdirTemp("", "vxagent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "daemon.sock")
+
+	// A stale socket file is replaced.
+	if err := os.WriteFile(socket, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := Listen(socket)
+	if err != nil {
+		t.Fatalf("Listen() over a stale socket error = %v", err)
+	}
+	defer ln.Close()
+
+	if info, err := os.Stat(socket); err != nil || info.Mode().Perm() != socketPerms {
+		t.Errorf("socket mode = %v (%v), want %v", info.Mode().Perm(), err, os.FileMode(socketPerms))
+	}
+	if _, err := Listen(socket); err == nil {
+		t.Error("Listen() succeeded while another agent is listening")
+	}
+}
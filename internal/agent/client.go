@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// ErrUnavailable is returned by Client when no agent answers on the socket.
+var ErrUnavailable = errors.New("agent: not running")
+
+// Client requests secrets from an agent over its Unix socket.
+type Client struct {
+	http *http.Client
+}
+
+// NewClient returns a Client for the agent listening on socketPath.
+func NewClient(socketPath string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+	return &Client{http: &http.Client{Transport: transport}}
+}
+
+// Secrets returns the secrets of workspace in env from the agent serving
+// the project at root. It returns an error wrapping ErrUnavailable when no
+// agent is running, and the agent's error when it could not resolve them.
+func (c *Client) Secrets(ctx context.Context, root, workspace, env string) (map[string]string, error) {
+	q := url.Values{"root": {root}, "workspace": {workspace}, "env": {env}}
+	// The host is ignored; every request goes to the socket.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://agent/v1/secrets?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Error == "" {
+			return nil, fmt.Errorf("agent: %s", resp.Status)
+		}
+		return nil, fmt.Errorf("agent: %s", e.Error)
+	}
+
+	var body secretsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("agent: decoding response: %w", err)
+	}
+	return body.Secrets, nil
+}
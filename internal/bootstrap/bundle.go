@@ -0,0 +1,150 @@
+// Package bootstrap packages a project's root vx.toml and Vault connection
+// hints into an encrypted bundle that a new team member can apply with one
+// command. Bundles travel as "vx://bootstrap/..." links, either pasted
+// directly or stored in a file or at a URL.
+package bootstrap
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/passcrypt"
+)
+
+// LinkPrefix starts every encoded bundle.
+const LinkPrefix = "vx://bootstrap/"
+
+const formatVersion = 1
+
+// ErrDecrypt is returned when a bundle cannot be opened, either because the
+// passphrase is wrong or because the bundle was tampered with.
+var ErrDecrypt = errors.New("wrong passphrase or corrupted bundle")
+
+// Bundle is the decrypted content of a bootstrap link.
+type Bundle struct {
+	Version   int       `json:"version"`
+	Config    string    `json:"config"` // root vx.toml contents
+	Hints     Hints     `json:"hints"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Hints describe how to connect and log in, so they can be shown before
+// anything is written.
+type Hints struct {
+	Address     string `json:"address"`
+	AuthMethod  string `json:"auth_method"`
+	AuthMount   string `json:"auth_mount,omitempty"`
+	AuthRole    string `json:"auth_role,omitempty"`
+	Environment string `json:"environment"`
+	Note        string `json:"note,omitempty"`
+}
+
+// New creates a bundle from root vx.toml contents. The config must parse and
+// validate; the connection hints are taken from it.
+func New(rootConfig []byte, note string) (*Bundle, error) {
+	cfg, err := config.ParseRootConfig(rootConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	hints := Hints{
+		Address:     cfg.Vault.Address,
+		AuthMethod:  cfg.Vault.AuthMethod,
+		AuthRole:    cfg.Vault.RoleFor(cfg.Environments.Default),
+		Environment: cfg.Environments.Default,
+		Note:        note,
+	}
+	if cfg.Vault.AuthMethod == "oidc" {
+		hints.AuthMount = cfg.Vault.OIDCMount()
+	}
+
+	return &Bundle{
+		Version:   formatVersion,
+		Config:    string(rootConfig),
+		Hints:     hints,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}, nil
+}
+
+// Seal encrypts the bundle with a key derived from passphrase and returns it
+// as a link. AES-GCM authenticates the content, so a link that was altered
+// fails to open instead of applying a modified config.
+func Seal(b *Bundle, passphrase string) (string, error) {
+	plain, err := json.Marshal(b)
+	if err != nil {
+		return "", fmt.Errorf("encoding bundle: %w", err)
+	}
+
+	sealed, err := passcrypt.Seal([]byte{formatVersion}, plain, passphrase)
+	if err != nil {
+		return "", err
+	}
+	return LinkPrefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open decodes and decrypts a link produced by Seal. Surrounding whitespace
+// is ignored, so the contents of a bundle file can be passed as-is.
+func Open(link string, passphrase string) (*Bundle, error) {
+	link = strings.TrimSpace(link)
+	if !strings.HasPrefix(link, LinkPrefix) {
+		return nil, fmt.Errorf("not a vx bootstrap link (expected %s...)", LinkPrefix)
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(link, LinkPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("decoding bootstrap link: %w", err)
+	}
+	if len(raw) < 1+passcrypt.SaltSize {
+		return nil, ErrDecrypt
+	}
+	if raw[0] != formatVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d (upgrade vx)", raw[0])
+	}
+
+	plain, err := passcrypt.Open(raw, 1, passphrase)
+	if errors.Is(err, passcrypt.ErrDecrypt) {
+		return nil, ErrDecrypt
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var b Bundle
+	if err := json.Unmarshal(plain, &b); err != nil {
+		return nil, fmt.Errorf("decoding bundle: %w", err)
+	}
+	return &b, nil
+}
+
+// Apply writes the bundle's config to vx.toml in dir. It returns the file's
+// path and whether it was written. An existing vx.toml with the same
+// contents is left alone; one with different contents is only replaced when
+// force is set.
+func Apply(b *Bundle, dir string, force bool) (string, bool, error) {
+	path := filepath.Join(dir, "vx.toml")
+
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil && string(existing) == b.Config:
+		return path, false, nil
+	case err == nil && !force:
+		return path, false, fmt.Errorf("%s already exists with different contents (use --force to replace it)", path)
+	case err != nil && !errors.Is(err, os.ErrNotExist):
+		return path, false, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	if err := os.WriteFile(path, []byte(b.Config), 0644); err != nil {
+		return path, false, fmt.Errorf("writing %s: %w", path, err)
+	}
+	return path, true, nil
+}
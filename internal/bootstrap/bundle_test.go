@@ -0,0 +1,132 @@
+package bootstrap
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `[vault]
+address = "https://vault.example.com"
+auth_method = "oidc"
+auth_role = "developer"
+auth_mount = "auth/okta"
+
+[environments]
+default = "dev"
+available = ["dev", "production"]
+`
+
+func TestNew(t *testing.T) {
+	b, err := New([]byte(testConfig), "ask #platform for access")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	want := Hints{
+		Address:     "https://vault.example.com",
+		AuthMethod:  "oidc",
+		AuthMount:   "okta",
+		AuthRole:    "developer",
+		Environment: "dev",
+		Note:        "ask #platform for access",
+	}
+	if b.Hints != want {
+		t.Errorf("Hints = %+v, want %+v", b.Hints, want)
+	}
+	if b.Config != testConfig {
+		t.Error("Config should hold the vx.toml contents verbatim")
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	if _, err := New([]byte("[vault]\naddress = \"x\"\n"), ""); err == nil {
+		t.Error("New() expected validation error")
+	}
+	if _, err := New([]byte("not toml ["), ""); err == nil {
+		t.Error("New() expected parse error")
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	b, err := New([]byte(testConfig), "")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	link, err := Seal(b, "correct horse")
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if !strings.HasPrefix(link, LinkPrefix) {
+		t.Fatalf("link = %q, want %s prefix", link, LinkPrefix)
+	}
+	if strings.Contains(link, "vault.example.com") {
+		t.Error("link should not contain the config in plain text")
+	}
+
+	got, err := Open(link+"\n", "correct horse")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got.Config != b.Config || got.Hints != b.Hints || !got.CreatedAt.Equal(b.CreatedAt) {
+		t.Errorf("Open() = %+v, want %+v", got, b)
+	}
+
+	if _, err := Open(link, "wrong"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Open() with wrong passphrase error = %v, want ErrDecrypt", err)
+	}
+
+	// Flip a character in the ciphertext.
+	tampered := []byte(link)
+	i := len(tampered) - 5
+	if tampered[i] == 'A' {
+		tampered[i] = 'B'
+	} else {
+		tampered[i] = 'A'
+	}
+	if _, err := Open(string(tampered), "correct horse"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Open() on tampered link error = %v, want ErrDecrypt", err)
+	}
+
+	if _, err := Open("https://example.com", "x"); err == nil {
+		t.Error("Open() expected error for non-bootstrap link")
+	}
+}
+
+func TestSeal_RequiresPassphrase(t *testing.T) {
+	if _, err := Seal(&Bundle{}, ""); err == nil {
+		t.Error("Seal() expected error without passphrase")
+	}
+}
+
+func TestApply(t *testing.T) {
+	dir := t.TempDir()
+	b := &Bundle{Config: testConfig}
+
+	path, written, err := Apply(b, dir, false)
+	if err != nil || !written {
+		t.Fatalf("Apply() = %v, %v; want written", written, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != testConfig {
+		t.Errorf("vx.toml = %q", data)
+	}
+
+	// Same contents: nothing to do.
+	if _, written, err := Apply(b, dir, false); err != nil || written {
+		t.Errorf("Apply() again = %v, %v; want unchanged", written, err)
+	}
+
+	// Different contents need --force.
+	if err := os.WriteFile(filepath.Join(dir, "vx.toml"), []byte("# local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Apply(b, dir, false); err == nil {
+		t.Error("Apply() expected error for differing vx.toml")
+	}
+	if _, written, err := Apply(b, dir, true); err != nil || !written {
+		t.Errorf("Apply(force) = %v, %v; want written", written, err)
+	}
+}
@@ -0,0 +1,262 @@
+// Package breakglass reads and writes the encrypted fallback secrets file
+// (vx.secrets.age by default) that can stand in for Vault during an outage.
+//
+// The file is checked into the repository encrypted to the team's age
+// public keys (or gpg keys), holds resolved values keyed by Vault path, and
+// is only ever decrypted on an explicit --break-glass, which is recorded in
+// an audit log.
+package breakglass
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.dot.industries/vx/internal/resolver"
+	"go.dot.industries/vx/internal/token"
+)
+
+// DefaultFile is the fallback file name, relative to the root vx.toml.
+const DefaultFile = "vx.secrets.age"
+
+// IdentityEnv names the environment variable holding the path of the age
+// identity (private key) file used to decrypt.
+const IdentityEnv = "VX_BREAK_GLASS_IDENTITY"
+
+const (
+	formatVersion = 1
+	auditFile     = "break-glass.log"
+	dirPerms      = 0700
+	filePerms     = 0600
+)
+
+// File is the decrypted content of the fallback file.
+type File struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Values maps Vault paths with ${env} filled in, such as
+	// "production/database/url", to their values.
+	Values map[string]string `json:"values"`
+}
+
+// Cipher encrypts and decrypts the fallback file.
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// Age encrypts with the age CLI (https://age-encryption.org).
+type Age struct {
+	// Recipients are the age public keys the file is encrypted to.
+	Recipients []string
+	// Identity is the path of the private key file used to decrypt.
+	Identity string
+}
+
+// Encrypt encrypts plaintext to every recipient, ASCII-armored so the file
+// diffs sensibly in git.
+func (a Age) Encrypt(plaintext []byte) ([]byte, error) {
+	if len(a.Recipients) == 0 {
+		return nil, fmt.Errorf("no age recipients configured")
+	}
+	args := []string{"--encrypt", "--armor"}
+	for _, r := range a.Recipients {
+		args = append(args, "--recipient", r)
+	}
+	return run("age", args, plaintext)
+}
+
+// Decrypt decrypts ciphertext with the identity file.
+func (a Age) Decrypt(ciphertext []byte) ([]byte, error) {
+	if a.Identity == "" {
+		return nil, fmt.Errorf("no age identity: set %s to your private key file", IdentityEnv)
+	}
+	return run("age", []string{"--decrypt", "--identity", a.Identity}, ciphertext)
+}
+
+// GPG encrypts with gpg, using the caller's keyring and agent.
+type GPG struct {
+	// Recipients are the key IDs or emails the file is encrypted to.
+	Recipients []string
+}
+
+// Encrypt encrypts plaintext to every recipient, ASCII-armored.
+func (g GPG) Encrypt(plaintext []byte) ([]byte, error) {
+	if len(g.Recipients) == 0 {
+		return nil, fmt.Errorf("no gpg recipients configured")
+	}
+	args := []string{"--batch", "--yes", "--armor", "--encrypt"}
+	for _, r := range g.Recipients {
+		args = append(args, "--recipient", r)
+	}
+	return run("gpg", args, plaintext)
+}
+
+// Decrypt decrypts ciphertext with a key from the caller's keyring.
+func (g GPG) Decrypt(ciphertext []byte) ([]byte, error) {
+	return run("gpg", []string{"--batch", "--quiet", "--decrypt"}, ciphertext)
+}
+
+// CipherFor picks gpg for ".gpg" and ".asc" files and age otherwise.
+func CipherFor(file string, recipients []string, identity string) Cipher {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".gpg", ".asc":
+		return GPG{Recipients: recipients}
+	default:
+		return Age{Recipients: recipients, Identity: identity}
+	}
+}
+
+// run pipes input through an external command and returns its output.
+var run = func(name string, args []string, input []byte) ([]byte, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("%s is not installed: %w", name, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %s", name, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// Load decrypts and parses the fallback file at path.
+func Load(path string, c Cipher) (*File, error) {
+	ciphertext, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading break-glass file: %w", err)
+	}
+
+	plaintext, err := c.Decrypt(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: %w", filepath.Base(path), err)
+	}
+
+	var f File
+	if err := json.Unmarshal(plaintext, &f); err != nil {
+		return nil, fmt.Errorf("parsing break-glass file: %w", err)
+	}
+	if f.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported break-glass file version %d", f.Version)
+	}
+	return &f, nil
+}
+
+// Save encrypts values and writes them to path, replacing any existing
+// file.
+func Save(path string, values map[string]string, now time.Time, c Cipher) error {
+	plaintext, err := json.Marshal(File{Version: formatVersion, CreatedAt: now.UTC(), Values: values})
+	if err != nil {
+		return fmt.Errorf("encoding break-glass file: %w", err)
+	}
+
+	ciphertext, err := c.Encrypt(plaintext)
+	if err != nil {
+		return fmt.Errorf("encrypting break-glass file: %w", err)
+	}
+
+	if err := os.WriteFile(path, ciphertext, 0644); err != nil {
+		return fmt.Errorf("writing break-glass file: %w", err)
+	}
+	return nil
+}
+
+// Key returns the Values key of a mapping's path template in env.
+func Key(template, env string) string {
+	return strings.Trim(path.Clean("/"+resolver.Interpolate(template, env)), "/")
+}
+
+// Resolve looks up every mapping (env var -> path template) in env. It
+// returns the values found and the sorted env vars the file cannot
+// satisfy.
+func (f *File) Resolve(secrets map[string]string, env string) (map[string]string, []string) {
+	values := make(map[string]string, len(secrets))
+	var missing []string
+	for envVar, template := range secrets {
+		v, ok := f.Values[Key(template, env)]
+		if !ok {
+			missing = append(missing, envVar)
+			continue
+		}
+		values[envVar] = v
+	}
+	sort.Strings(missing)
+	return values, missing
+}
+
+// AuditEntry records one use of the fallback file.
+type AuditEntry struct {
+	Time        time.Time `json:"time"`
+	User        string    `json:"user"`
+	Host        string    `json:"host"`
+	File        string    `json:"file"`
+	Environment string    `json:"environment"`
+	Workspace   string    `json:"workspace,omitempty"`
+	Command     []string  `json:"command"`
+	// Keys lists the env vars served from the file; values are never
+	// logged.
+	Keys []string `json:"keys"`
+}
+
+// AuditPath returns the path to the audit log (~/.vx/break-glass.log).
+var AuditPath = func() string {
+	return filepath.Join(token.DefaultDir(), auditFile)
+}
+
+// Audit appends e to the audit log. Callers must not use the fallback
+// values when it fails.
+func Audit(e AuditEntry) error {
+	return auditTo(AuditPath(), e)
+}
+
+// auditTo appends e as a JSON line to the log at path.
+func auditTo(path string, e AuditEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), dirPerms); err != nil {
+		return fmt.Errorf("creating audit log directory: %w", err)
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding audit entry: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, filePerms)
+	if err != nil {
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	return nil
+}
+
+// Path returns the fallback file configured as file (DefaultFile when
+// empty), resolved against rootDir unless absolute.
+func Path(rootDir, file string) string {
+	if file == "" {
+		file = DefaultFile
+	}
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(rootDir, file)
+}
@@ -0,0 +1,175 @@
+package breakglass
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// xorCipher stands in for age in tests that don't exercise the CLI.
+type xorCipher struct{}
+
+func (xorCipher) Encrypt(p []byte) ([]byte, error) { return xor(p), nil }
+func (xorCipher) Decrypt(c []byte) ([]byte, error) { return xor(c), nil }
+
+func xor(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[i] ^ 0x5A
+	}
+	return out
+}
+
+func TestSaveLoad(t *testing.T) {
+	p := filepath.Join(t.TempDir(), DefaultFile)
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	values := map[string]string{"production/database/url": "postgres://prod"}
+
+	if err := Save(p, values, now, xorCipher{}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	raw, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte("postgres://prod")) {
+		t.Error("saved file contains a plaintext value")
+	}
+
+	f, err := Load(p, xorCipher{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !f.CreatedAt.Equal(now) || !reflect.DeepEqual(f.Values, values) {
+		t.Errorf("Load() = %+v, want values %v created %v", f, values, now)
+	}
+}
+
+func TestLoad_UnsupportedVersion(t *testing.T) {
+	p := filepath.Join(t.TempDir(), DefaultFile)
+	data, _ := json.Marshal(File{Version: 99})
+	if err := os.WriteFile(p, xor(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(p, xorCipher{}); err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Errorf("Load() error = %v, want unsupported version", err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	f := &File{Values: map[string]string{
+		"production/database/url": "postgres://prod",
+		"shared/api/key":          "k",
+	}}
+	secrets := map[string]string{
+		"DATABASE_URL": "${env}/database/url",
+		"API_KEY":      "/shared//api/key",
+		"QUEUE_URL":    "${env}/queue/url",
+		"CACHE_URL":    "${env}/cache/url",
+	}
+
+	values, missing := f.Resolve(secrets, "production")
+
+	want := map[string]string{"DATABASE_URL": "postgres://prod", "API_KEY": "k"}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+	if !reflect.DeepEqual(missing, []string{"CACHE_URL", "QUEUE_URL"}) {
+		t.Errorf("missing = %v, want [CACHE_URL QUEUE_URL]", missing)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "sub", auditFile)
+
+	for _, env := range []string{"production", "staging"} {
+		e := AuditEntry{Environment: env, Command: []string{"vx", "exec"}, Keys: []string{"DATABASE_URL"}}
+		if err := auditTo(p, e); err != nil {
+			t.Fatalf("auditTo() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"environment":"staging"`) {
+		t.Errorf("audit log = %q, want two entries", data)
+	}
+
+	info, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != filePerms {
+		t.Errorf("audit log mode = %o, want %o", perm, filePerms)
+	}
+}
+
+func TestCipherFor(t *testing.T) {
+	if _, ok := CipherFor("vx.secrets.age", nil, "").(Age); !ok {
+		t.Error("CipherFor(.age) should use age")
+	}
+	if _, ok := CipherFor("vx.secrets.gpg", nil, "").(GPG); !ok {
+		t.Error("CipherFor(.gpg) should use gpg")
+	}
+	if _, ok := CipherFor("vx.secrets.ASC", nil, "").(GPG); !ok {
+		t.Error("CipherFor(.ASC) should use gpg")
+	}
+}
+
+func TestPath(t *testing.T) {
+	if got := Path("/repo", ""); got != filepath.Join("/repo", DefaultFile) {
+		t.Errorf("Path() = %q", got)
+	}
+	abs := filepath.Join(t.TempDir(), "x.age")
+	if got := Path("/repo", abs); got != abs {
+		t.Errorf("Path() = %q, want %q", got, abs)
+	}
+}
+
+func TestAge_MissingIdentity(t *testing.T) {
+	if _, err := (Age{}).Decrypt([]byte("x")); err == nil || !strings.Contains(err.Error(), IdentityEnv) {
+		t.Errorf("Decrypt() error = %v, want a hint about %s", err, IdentityEnv)
+	}
+}
+
+// TestAgeRoundTrip runs the real age CLI when it is installed.
+func TestAgeRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("age-keygen"); err != nil {
+		t.Skip("age not installed")
+	}
+
+	dir := t.TempDir()
+	identity := filepath.Join(dir, "key.txt")
+	out, err := exec.Command("age-keygen", "-o", identity).CombinedOutput()
+	if err != nil {
+		t.Fatalf("age-keygen: %v: %s", err, out)
+	}
+	pub, err := exec.Command("age-keygen", "-y", identity).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := Age{Recipients: []string{strings.TrimSpace(string(pub))}, Identity: identity}
+	p := filepath.Join(dir, DefaultFile)
+	if err := Save(p, map[string]string{"dev/app/key": "v"}, time.Now(), c); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	f, err := Load(p, c)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if f.Values["dev/app/key"] != "v" {
+		t.Errorf("Values = %v", f.Values)
+	}
+}
@@ -0,0 +1,138 @@
+// Package changes records Vault secret changes seen by the daemon's event
+// subscriber in a small journal under ~/.vx, so other vx processes — the
+// TUI, direnv exports — can notice them without their own connection to
+// Vault.
+package changes
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.dot.industries/vx/internal/token"
+)
+
+const (
+	journalFile = "changes.jsonl"
+	// maxEntries bounds the journal; readers only care about recent changes.
+	maxEntries = 500
+	dirPerms   = 0700
+	filePerms  = 0600
+)
+
+// Change is one modification of a secret, with Path relative to the KV
+// mount. Time is when the change was recorded.
+type Change struct {
+	Path      string    `json:"path"`
+	Operation string    `json:"operation,omitempty"`
+	Version   int       `json:"version,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// JournalPath returns the path to the change journal (~/.vx/changes.jsonl).
+var JournalPath = func() string {
+	return filepath.Join(token.DefaultDir(), journalFile)
+}
+
+// Append records c in the journal, dropping the oldest entries beyond the
+// journal's limit. A zero Time is set to now.
+func Append(c Change) error {
+	return appendTo(JournalPath(), c)
+}
+
+// Since returns the journaled changes recorded after t, oldest first. A
+// missing journal has no changes.
+func Since(t time.Time) ([]Change, error) {
+	all, err := readFrom(JournalPath())
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Change
+	for _, c := range all {
+		if c.Time.After(t) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// Latest returns the time of the most recent change, or the zero time when
+// nothing has been journaled.
+func Latest() (time.Time, error) {
+	all, err := readFrom(JournalPath())
+	if err != nil || len(all) == 0 {
+		return time.Time{}, err
+	}
+	return all[len(all)-1].Time, nil
+}
+
+// appendTo adds c to the journal at path, rewriting it through a temporary
+// file so readers never see a partial entry.
+func appendTo(path string, c Change) error {
+	if c.Time.IsZero() {
+		c.Time = time.Now()
+	}
+
+	all, err := readFrom(path)
+	if err != nil {
+		return err
+	}
+	all = append(all, c)
+	if len(all) > maxEntries {
+		all = all[len(all)-maxEntries:]
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range all {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("encoding change: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), dirPerms); err != nil {
+		return fmt.Errorf("creating journal directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), filePerms); err != nil {
+		return fmt.Errorf("writing change journal: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("writing change journal: %w", err)
+	}
+	return nil
+}
+
+// readFrom returns every entry in the journal at path. Lines that don't
+// decode are skipped.
+func readFrom(path string) ([]Change, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading change journal: %w", err)
+	}
+	defer f.Close()
+
+	var out []Change
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var c Change
+		if err := json.Unmarshal(sc.Bytes(), &c); err != nil {
+			continue
+		}
+		out = append(out, c)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading change journal: %w", err)
+	}
+	return out, nil
+}
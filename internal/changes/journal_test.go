@@ -0,0 +1,106 @@
+package changes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func withJournal(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sub", "changes.jsonl")
+	orig := JournalPath
+	JournalPath = func() string { return path }
+	t.Cleanup(func() { JournalPath = orig })
+	return path
+}
+
+func TestAppendAndSince(t *testing.T) {
+	path := withJournal(t)
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, p := range []string{"dev/database", "dev/api", "prod/database"} {
+		c := Change{Path: p, Operation: "data-write", Version: i + 1, Time: base.Add(time.Duration(i) * time.Minute)}
+		if err := Append(c); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	got, err := Since(base)
+	if err != nil {
+		t.Fatalf("Since() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Path != "dev/api" || got[1].Path != "prod/database" {
+		t.Errorf("Since() = %+v, want dev/api and prod/database", got)
+	}
+
+	latest, err := Latest()
+	if err != nil {
+		t.Fatalf("Latest() error = %v", err)
+	}
+	if !latest.Equal(base.Add(2 * time.Minute)) {
+		t.Errorf("Latest() = %v, want %v", latest, base.Add(2*time.Minute))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != filePerms {
+		t.Errorf("journal mode = %o, want %o", perm, filePerms)
+	}
+}
+
+func TestAppendTrimsOldEntries(t *testing.T) {
+	withJournal(t)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := range maxEntries + 10 {
+		if err := Append(Change{Path: "dev/app", Version: i, Time: base.Add(time.Duration(i) * time.Second)}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	got, err := Since(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != maxEntries {
+		t.Fatalf("journal holds %d entries, want %d", len(got), maxEntries)
+	}
+	if got[0].Version != 10 {
+		t.Errorf("oldest entry version = %d, want 10", got[0].Version)
+	}
+}
+
+func TestMissingJournal(t *testing.T) {
+	withJournal(t)
+
+	got, err := Since(time.Time{})
+	if err != nil || len(got) != 0 {
+		t.Errorf("Since() = %v, %v; want no changes", got, err)
+	}
+
+	latest, err := Latest()
+	if err != nil || !latest.IsZero() {
+		t.Errorf("Latest() = %v, %v; want zero time", latest, err)
+	}
+}
+
+func TestAppendSetsTime(t *testing.T) {
+	withJournal(t)
+	before := time.Now()
+
+	if err := Append(Change{Path: "dev/app"}); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Before(before) {
+		t.Errorf("Latest() = %v, want a time after %v", latest, before)
+	}
+}
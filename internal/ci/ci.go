@@ -0,0 +1,133 @@
+// Package ci writes resolved values in the formats CI providers read
+// variables from: GitHub Actions' $GITHUB_ENV, a GitLab dotenv report, and
+// CircleCI's $BASH_ENV.
+package ci
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"go.dot.industries/vx/internal/shellenv"
+)
+
+// Provider is a CI service vx can hand variables to.
+type Provider string
+
+const (
+	// GitHub appends to the $GITHUB_ENV file of a GitHub Actions step.
+	GitHub Provider = "github"
+	// GitLab writes a dotenv artifact report (artifacts:reports:dotenv).
+	GitLab Provider = "gitlab"
+	// CircleCI appends export statements to $BASH_ENV.
+	CircleCI Provider = "circleci"
+)
+
+// GitLabMaxVariables is how many variables GitLab accepts from one dotenv
+// report unless an administrator raised the limit.
+const GitLabMaxVariables = 20
+
+// Detect returns the provider running the current job, judged by the
+// variables each one sets, or "" outside CI.
+func Detect(getenv func(string) string) Provider {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		return GitHub
+	case getenv("GITLAB_CI") == "true":
+		return GitLab
+	case getenv("CIRCLECI") == "true":
+		return CircleCI
+	}
+	return ""
+}
+
+// EnvFile returns the variable naming the file p reads variables from, or
+// "" when the file is chosen by the job (GitLab).
+func (p Provider) EnvFile() string {
+	switch p {
+	case GitHub:
+		return "GITHUB_ENV"
+	case CircleCI:
+		return "BASH_ENV"
+	}
+	return ""
+}
+
+// Masks returns GitHub workflow commands that hide every value in the job
+// log. Multi-line values are masked line by line, since GitHub matches each
+// log line on its own. Empty values are skipped.
+func Masks(values map[string]string) []string {
+	var masks []string
+	for _, k := range sortedKeys(values) {
+		for _, line := range strings.Split(values[k], "\n") {
+			if line = strings.TrimRight(line, "\r"); line != "" {
+				masks = append(masks, "::add-mask::"+line)
+			}
+		}
+	}
+	return masks
+}
+
+// Render writes values in the format p reads, in key order.
+//
+// GitHub gets NAME<<DELIMITER blocks with a random delimiter, so a value
+// can never end its own block. GitLab dotenv reports cannot hold multi-line
+// values, which are rejected. CircleCI gets POSIX export statements.
+func Render(w io.Writer, p Provider, values map[string]string) error {
+	switch p {
+	case GitHub:
+		return renderGitHub(w, values)
+	case GitLab:
+		return renderGitLab(w, values)
+	case CircleCI:
+		return shellenv.Render(w, shellenv.POSIX, values)
+	default:
+		return fmt.Errorf("unsupported CI provider %q", p)
+	}
+}
+
+func renderGitHub(w io.Writer, values map[string]string) error {
+	var b strings.Builder
+	for _, k := range sortedKeys(values) {
+		delim, err := delimiter()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "%s<<%s\n%s\n%s\n", k, delim, values[k], delim)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func renderGitLab(w io.Writer, values map[string]string) error {
+	var b strings.Builder
+	for _, k := range sortedKeys(values) {
+		if strings.ContainsAny(values[k], "\r\n") {
+			return fmt.Errorf("%s has a multi-line value, which GitLab dotenv reports do not support", k)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", k, values[k])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// delimiter returns a random heredoc delimiter for $GITHUB_ENV.
+func delimiter() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating delimiter: %w", err)
+	}
+	return "VX_EOF_" + hex.EncodeToString(buf), nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,87 @@
+package ci
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want Provider
+	}{
+		{map[string]string{"GITHUB_ACTIONS": "true"}, GitHub},
+		{map[string]string{"GITLAB_CI": "true", "CI": "true"}, GitLab},
+		{map[string]string{"CIRCLECI": "true"}, CircleCI},
+		{map[string]string{"CI": "true"}, ""},
+		{nil, ""},
+	}
+
+	for _, tt := range tests {
+		if got := Detect(func(k string) string { return tt.env[k] }); got != tt.want {
+			t.Errorf("Detect(%v) = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestMasks(t *testing.T) {
+	got := Masks(map[string]string{
+		"B_KEY":   "-----BEGIN KEY-----\r\nabc\r\n",
+		"A_TOKEN": "s3cret",
+		"EMPTY":   "",
+	})
+	want := []string{
+		"::add-mask::s3cret",
+		"::add-mask::-----BEGIN KEY-----",
+		"::add-mask::abc",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Masks() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderGitHub(t *testing.T) {
+	var b strings.Builder
+	if err := Render(&b, GitHub, map[string]string{"A": "one", "B": "two\nlines"}); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	re := regexp.MustCompile(`^A<<(VX_EOF_[0-9a-f]+)\none\n(VX_EOF_[0-9a-f]+)\nB<<(VX_EOF_[0-9a-f]+)\ntwo\nlines\n(VX_EOF_[0-9a-f]+)\n$`)
+	m := re.FindStringSubmatch(b.String())
+	if m == nil {
+		t.Fatalf("Render() =\n%s", b.String())
+	}
+	if m[1] != m[2] || m[3] != m[4] || m[1] == m[3] {
+		t.Errorf("delimiters = %v, want one random delimiter per block", m[1:])
+	}
+}
+
+func TestRenderGitLab(t *testing.T) {
+	var b strings.Builder
+	if err := Render(&b, GitLab, map[string]string{"B": "x=y", "A": "1"}); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if got, want := b.String(), "A=1\nB=x=y\n"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	if err := Render(&b, GitLab, map[string]string{"KEY": "a\nb"}); err == nil {
+		t.Error("Render() accepted a multi-line value")
+	}
+}
+
+func TestRenderCircleCI(t *testing.T) {
+	var b strings.Builder
+	if err := Render(&b, CircleCI, map[string]string{"A": "it's"}); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if got, want := b.String(), "export A='it'\\''s'\n"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	if err := Render(&b, "jenkins", nil); err == nil {
+		t.Error("Render() accepted an unknown provider")
+	}
+}
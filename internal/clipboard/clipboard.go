@@ -0,0 +1,73 @@
+// Package clipboard copies secret values to the system clipboard and clears
+// them again after a delay, the way password managers do.
+package clipboard
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/atotto/clipboard"
+)
+
+// Board is a clipboard that can be read and written.
+type Board interface {
+	ReadAll() (string, error)
+	WriteAll(text string) error
+}
+
+type systemBoard struct{}
+
+func (systemBoard) ReadAll() (string, error)   { return clipboard.ReadAll() }
+func (systemBoard) WriteAll(text string) error { return clipboard.WriteAll(text) }
+
+// System is the operating system clipboard.
+var System Board = systemBoard{}
+
+// Fingerprint returns a SHA-256 hex digest identifying value. It lets a
+// clearing process recognise the copied secret without holding it.
+func Fingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// ClearIfUnchanged empties b if it still holds the value with fingerprint
+// fp, and reports whether it did. A clipboard that has been overwritten since
+// the copy is left alone.
+func ClearIfUnchanged(b Board, fp string) (bool, error) {
+	current, err := b.ReadAll()
+	if err != nil {
+		return false, fmt.Errorf("reading clipboard: %w", err)
+	}
+	if Fingerprint(current) != fp {
+		return false, nil
+	}
+	if err := b.WriteAll(""); err != nil {
+		return false, fmt.Errorf("clearing clipboard: %w", err)
+	}
+	return true, nil
+}
+
+// StartClearProcess spawns "vx clipboard-clear" as a detached background
+// process that clears the clipboard after the given delay if it still holds
+// the value with fingerprint fp. Only the fingerprint is passed on the
+// command line; the secret itself never leaves this process.
+func StartClearProcess(vxBinary string, after time.Duration, fp string) error {
+	cmd := exec.Command(vxBinary, "clipboard-clear",
+		"--after", after.String(),
+		"--fingerprint", fp,
+	)
+	cmd.SysProcAttr = detachedSysProcAttr()
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start clipboard clear process: %w", err)
+	}
+
+	// Release the child so the parent can exit without waiting.
+	if err := cmd.Process.Release(); err != nil {
+		return fmt.Errorf("release clipboard clear process: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package clipboard
+
+import (
+	"errors"
+	"testing"
+)
+
+type fakeBoard struct {
+	text    string
+	readErr error
+}
+
+func (f *fakeBoard) ReadAll() (string, error) { return f.text, f.readErr }
+
+func (f *fakeBoard) WriteAll(text string) error {
+	f.text = text
+	return nil
+}
+
+func TestFingerprint(t *testing.T) {
+	if Fingerprint("s3cret") != Fingerprint("s3cret") {
+		t.Error("Fingerprint() should be deterministic")
+	}
+	if Fingerprint("s3cret") == Fingerprint("other") {
+		t.Error("Fingerprint() should differ for different values")
+	}
+	if len(Fingerprint("")) != 64 {
+		t.Errorf("Fingerprint() length = %d, want 64", len(Fingerprint("")))
+	}
+}
+
+func TestClearIfUnchanged(t *testing.T) {
+	b := &fakeBoard{text: "s3cret"}
+
+	cleared, err := ClearIfUnchanged(b, Fingerprint("s3cret"))
+	if err != nil {
+		t.Fatalf("ClearIfUnchanged() error = %v", err)
+	}
+	if !cleared || b.text != "" {
+		t.Errorf("cleared = %v, text = %q; want clipboard emptied", cleared, b.text)
+	}
+}
+
+func TestClearIfUnchanged_Overwritten(t *testing.T) {
+	b := &fakeBoard{text: "something the user copied later"}
+
+	cleared, err := ClearIfUnchanged(b, Fingerprint("s3cret"))
+	if err != nil {
+		t.Fatalf("ClearIfUnchanged() error = %v", err)
+	}
+	if cleared || b.text != "something the user copied later" {
+		t.Error("ClearIfUnchanged() must not touch a clipboard that changed")
+	}
+}
+
+func TestClearIfUnchanged_ReadError(t *testing.T) {
+	b := &fakeBoard{readErr: errors.New("no clipboard")}
+
+	if _, err := ClearIfUnchanged(b, Fingerprint("s3cret")); err == nil {
+		t.Error("ClearIfUnchanged() expected error when clipboard is unreadable")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package clipboard
+
+import "syscall"
+
+// detachedSysProcAttr starts the child in a new session so it outlives the
+// parent's terminal.
+func detachedSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
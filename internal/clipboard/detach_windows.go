@@ -0,0 +1,16 @@
+//go:build windows
+
+package clipboard
+
+import "syscall"
+
+const (
+	createNewProcessGroup = 0x00000200
+	createNoWindow        = 0x08000000
+)
+
+// detachedSysProcAttr starts the child in a new process group without a
+// console window so it outlives the parent.
+func detachedSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: createNewProcessGroup | createNoWindow}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"strings"
+
+	"go.dot.industries/vx/internal/vault"
+)
+
+// OIDCMount returns the OIDC auth mount path without the "auth/" prefix or
+// surrounding slashes, so "auth/okta/" and "okta" both yield "okta". It is
+// vault.DefaultOIDCMount when auth_mount is not set.
+func (v VaultConfig) OIDCMount() string {
+	return v.mount(vault.DefaultOIDCMount)
+}
+
+// KubernetesMount returns the Kubernetes auth mount path, normalized like
+// OIDCMount, or vault.DefaultKubernetesMount when auth_mount is not set.
+func (v VaultConfig) KubernetesMount() string {
+	return v.mount(vault.DefaultKubernetesMount)
+}
+
+// mount returns auth_mount without the "auth/" prefix or surrounding
+// slashes, or def when it is not set.
+func (v VaultConfig) mount(def string) string {
+	mount := strings.Trim(v.AuthMount, "/")
+	mount = strings.TrimPrefix(mount, "auth/")
+	if mount == "" {
+		return def
+	}
+	return mount
+}
+
+// RoleFor returns the auth role to log in with for env: the auth_roles entry
+// for env if there is one, otherwise auth_role.
+func (v VaultConfig) RoleFor(env string) string {
+	if role, ok := v.AuthRoles[env]; ok && role != "" {
+		return role
+	}
+	return v.AuthRole
+}
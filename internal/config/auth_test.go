@@ -0,0 +1,50 @@
+package config
+
+import "testing"
+
+func TestVaultConfig_OIDCMount(t *testing.T) {
+	tests := []struct {
+		mount string
+		want  string
+	}{
+		{"", "oidc"},
+		{"okta", "okta"},
+		{"auth/okta", "okta"},
+		{"/auth/azuread/", "azuread"},
+		{"team/oidc", "team/oidc"},
+	}
+
+	for _, tt := range tests {
+		v := VaultConfig{AuthMount: tt.mount}
+		if got := v.OIDCMount(); got != tt.want {
+			t.Errorf("OIDCMount(%q) = %q, want %q", tt.mount, got, tt.want)
+		}
+	}
+}
+
+func TestVaultConfig_KubernetesMount(t *testing.T) {
+	if got := (VaultConfig{}).KubernetesMount(); got != "kubernetes" {
+		t.Errorf("KubernetesMount() = %q, want %q", got, "kubernetes")
+	}
+	if got := (VaultConfig{AuthMount: "auth/k8s-prod/"}).KubernetesMount(); got != "k8s-prod" {
+		t.Errorf("KubernetesMount() = %q, want %q", got, "k8s-prod")
+	}
+}
+
+func TestVaultConfig_RoleFor(t *testing.T) {
+	v := VaultConfig{
+		AuthRole:  "developer",
+		AuthRoles: map[string]string{"production": "prod-reader", "staging": ""},
+	}
+
+	tests := map[string]string{
+		"production": "prod-reader",
+		"staging":    "developer",
+		"dev":        "developer",
+	}
+	for env, want := range tests {
+		if got := v.RoleFor(env); got != want {
+			t.Errorf("RoleFor(%q) = %q, want %q", env, got, want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import "time"
+
+// SecretCacheTTLs returns how long each mapped secret may be cached: its
+// cache_ttl override, or ttl when it has none. An override replaces ttl,
+// whether it is shorter or longer.
+func (m *MergedConfig) SecretCacheTTLs(ttl time.Duration) map[string]time.Duration {
+	ttls := make(map[string]time.Duration, len(m.Secrets))
+	for k := range m.Secrets {
+		if d, ok := m.CacheTTL[k]; ok {
+			ttls[k] = time.Duration(d)
+		} else {
+			ttls[k] = ttl
+		}
+	}
+	return ttls
+}
+
+// EntryCacheTTL returns how long a cache entry holding every mapped secret
+// may be kept: the shortest of their lifetimes (see SecretCacheTTLs), or ttl
+// when nothing is mapped.
+func (m *MergedConfig) EntryCacheTTL(ttl time.Duration) time.Duration {
+	ttls := m.SecretCacheTTLs(ttl)
+	if len(ttls) == 0 {
+		return ttl
+	}
+	entry := time.Duration(-1)
+	for _, d := range ttls {
+		if entry < 0 || d < entry {
+			entry = d
+		}
+	}
+	return entry
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergedConfig_SecretCacheTTLs(t *testing.T) {
+	merged := &MergedConfig{
+		Secrets: map[string]string{
+			"DB_TOKEN":      "${env}/db/token",
+			"FEATURE_FLAGS": "${env}/flags/all",
+			"API_KEY":       "${env}/api/key",
+		},
+		CacheTTL: map[string]Duration{
+			"DB_TOKEN":      0,
+			"FEATURE_FLAGS": Duration(time.Hour),
+			"UNMAPPED":      Duration(time.Second),
+		},
+	}
+
+	got := merged.SecretCacheTTLs(10 * time.Minute)
+	want := map[string]time.Duration{
+		"DB_TOKEN":      0,
+		"FEATURE_FLAGS": time.Hour,
+		"API_KEY":       10 * time.Minute,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SecretCacheTTLs() = %v, want %v", got, want)
+	}
+}
+
+func TestMergedConfig_EntryCacheTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		secrets  map[string]string
+		cacheTTL map[string]Duration
+		want     time.Duration
+	}{
+		{
+			name: "no mapped secrets",
+			want: 10 * time.Minute,
+		},
+		{
+			name:    "no overrides",
+			secrets: map[string]string{"API_KEY": "a/b"},
+			want:    10 * time.Minute,
+		},
+		{
+			name:     "shorter override",
+			secrets:  map[string]string{"API_KEY": "a/b", "DB_TOKEN": "c/d"},
+			cacheTTL: map[string]Duration{"DB_TOKEN": Duration(time.Minute)},
+			want:     time.Minute,
+		},
+		{
+			name:     "longer override alongside the global ttl",
+			secrets:  map[string]string{"API_KEY": "a/b", "FEATURE_FLAGS": "c/d"},
+			cacheTTL: map[string]Duration{"FEATURE_FLAGS": Duration(time.Hour)},
+			want:     10 * time.Minute,
+		},
+		{
+			name:     "every secret overridden longer",
+			secrets:  map[string]string{"FEATURE_FLAGS": "c/d"},
+			cacheTTL: map[string]Duration{"FEATURE_FLAGS": Duration(time.Hour)},
+			want:     time.Hour,
+		},
+		{
+			name:     "zero override",
+			secrets:  map[string]string{"API_KEY": "a/b", "DB_TOKEN": "c/d"},
+			cacheTTL: map[string]Duration{"DB_TOKEN": 0},
+			want:     0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := &MergedConfig{Secrets: tt.secrets, CacheTTL: tt.cacheTTL}
+			if got := merged.EntryCacheTTL(10 * time.Minute); got != tt.want {
+				t.Errorf("EntryCacheTTL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
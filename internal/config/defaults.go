@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	toml "github.com/pelletier/go-toml/v2"
+)
+
+// formatDefault converts a decoded TOML default value into the string that is
+// injected into the environment. Scalar values follow a fixed policy so the
+// same vx.toml always produces the same environment:
+//
+//   - strings are used verbatim
+//   - booleans become "true" or "false"
+//   - integers use base-10 without separators or a leading "+"
+//   - floats use the shortest representation that round-trips ("1.5",
+//     "0.001", "1e+21"); integral floats keep no trailing ".0" ("3.0" -> "3")
+//   - offset date-times use RFC 3339 ("2024-01-02T15:04:05Z")
+//   - local dates, times, and date-times use their TOML spelling
+//
+// Arrays and tables have no canonical env representation; for those the
+// second return value is false and the caller reports the key as dropped.
+func formatDefault(val any) (string, bool) {
+	switch v := val.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case int:
+		return strconv.Itoa(v), true
+	case float64:
+		return formatFloat(v), true
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	case toml.LocalDate:
+		return v.String(), true
+	case toml.LocalTime:
+		return v.String(), true
+	case toml.LocalDateTime:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
+
+// formatFloat renders a float in its shortest round-trippable form. TOML's
+// special values inf and nan map to the spellings used in the TOML spec.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// describeKind returns a short human-readable name for an unsupported default
+// value, used in dropped-value warnings.
+func describeKind(val any) string {
+	switch val.(type) {
+	case []any:
+		return "array"
+	case map[string]any:
+		return "table"
+	default:
+		return fmt.Sprintf("%T", val)
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	toml "github.com/pelletier/go-toml/v2"
+)
+
+func TestFormatDefault(t *testing.T) {
+	tests := []struct {
+		name   string
+		val    any
+		want   string
+		wantOK bool
+	}{
+		{name: "string", val: "hello", want: "hello", wantOK: true},
+		{name: "bool true", val: true, want: "true", wantOK: true},
+		{name: "bool false", val: false, want: "false", wantOK: true},
+		{name: "int", val: int64(-42), want: "-42", wantOK: true},
+		{name: "float", val: 1.5, want: "1.5", wantOK: true},
+		{name: "integral float", val: 3.0, want: "3", wantOK: true},
+		{name: "small float", val: 0.001, want: "0.001", wantOK: true},
+		{name: "large float", val: 1e21, want: "1e+21", wantOK: true},
+		{name: "inf", val: math.Inf(1), want: "inf", wantOK: true},
+		{name: "datetime", val: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC), want: "2024-01-02T15:04:05Z", wantOK: true},
+		{name: "local date", val: toml.LocalDate{Year: 2024, Month: 3, Day: 9}, want: "2024-03-09", wantOK: true},
+		{name: "array", val: []any{"a"}, wantOK: false},
+		{name: "table", val: map[string]any{"k": "v"}, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := formatDefault(tt.val)
+			if ok != tt.wantOK {
+				t.Fatalf("formatDefault(%v) ok = %v, want %v", tt.val, ok, tt.wantOK)
+			}
+			if got != tt.want {
+				t.Errorf("formatDefault(%v) = %q, want %q", tt.val, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRootConfig_TypedDefaults(t *testing.T) {
+	path := t.TempDir() + "/vx.toml"
+	writeTestFile(t, path, `
+[environments]
+default = "dev"
+available = ["dev"]
+
+[defaults]
+ENABLED = true
+PORT = 8080
+TIMEOUT = 2.5
+`)
+
+	cfg, err := LoadRootConfig(path)
+	if err != nil {
+		t.Fatalf("LoadRootConfig() error = %v", err)
+	}
+
+	merged, err := Merge(cfg, nil, "dev")
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	assertMapValue(t, merged.Defaults, "ENABLED", "true")
+	assertMapValue(t, merged.Defaults, "PORT", "8080")
+	assertMapValue(t, merged.Defaults, "TIMEOUT", "2.5")
+}
@@ -0,0 +1,311 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// WorkspacesAuto is the value of workspaces that discovers the workspaces
+// instead of listing them (see DiscoverWorkspaces).
+const WorkspacesAuto = "auto"
+
+// WorkspaceCandidate is a directory a package manager manifest lists as a
+// workspace.
+type WorkspaceCandidate struct {
+	// Dir is relative to the root and slash-separated.
+	Dir string
+	// Source is the file that lists it: "package.json",
+	// "pnpm-workspace.yaml", "Cargo.toml", or "go.work".
+	Source string
+}
+
+// FindWorkspaceCandidates returns the workspace directories listed in
+// rootDir's package.json "workspaces" (npm, Yarn, and Bun),
+// pnpm-workspace.yaml "packages", Cargo.toml [workspace] "members", and
+// go.work "use" directives. Patterns are expanded, and only directories with
+// the package manager's own manifest (package.json or Cargo.toml) count. The
+// root itself is not a workspace. Missing files are not an error.
+func FindWorkspaceCandidates(rootDir string) ([]WorkspaceCandidate, error) {
+	var found []WorkspaceCandidate
+	seen := map[string]bool{}
+	add := func(dirs []string, source string) {
+		for _, d := range dirs {
+			d = path.Clean(d)
+			if d == "." || strings.HasPrefix(d, "../") || seen[d] {
+				continue
+			}
+			seen[d] = true
+			found = append(found, WorkspaceCandidate{Dir: d, Source: source})
+		}
+	}
+
+	sources := []struct {
+		file     string
+		manifest string
+		patterns func(file string) ([]string, error)
+	}{
+		{"package.json", "package.json", packageJSONWorkspaces},
+		{"pnpm-workspace.yaml", "package.json", pnpmWorkspacePackages},
+		{"Cargo.toml", "Cargo.toml", cargoWorkspaceMembers},
+	}
+	for _, s := range sources {
+		patterns, err := s.patterns(filepath.Join(rootDir, s.file))
+		if err != nil {
+			return nil, err
+		}
+		dirs, err := expandPatterns(rootDir, patterns, s.manifest)
+		if err != nil {
+			return nil, err
+		}
+		add(dirs, s.file)
+	}
+
+	dirs, err := goWorkUses(filepath.Join(rootDir, "go.work"))
+	if err != nil {
+		return nil, err
+	}
+	add(dirs, "go.work")
+
+	slices.SortStableFunc(found, func(a, b WorkspaceCandidate) int { return strings.Compare(a.Dir, b.Dir) })
+	return found, nil
+}
+
+// DiscoverWorkspaces returns the workspaces of a root config at rootDir that
+// sets workspaces = "auto": the vx.toml of every directory
+// FindWorkspaceCandidates finds that has one, sorted.
+func DiscoverWorkspaces(rootDir string) ([]string, error) {
+	candidates, err := FindWorkspaceCandidates(rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("discovering workspaces: %w", err)
+	}
+
+	var workspaces []string
+	for _, c := range candidates {
+		p := path.Join(c.Dir, "vx.toml")
+		if _, err := os.Stat(filepath.Join(rootDir, filepath.FromSlash(p))); err == nil {
+			workspaces = append(workspaces, p)
+		}
+	}
+	return workspaces, nil
+}
+
+// packageJSONWorkspaces returns the workspace patterns of a package.json,
+// given as an array or as {"packages": [...]}.
+func packageJSONWorkspaces(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	if len(pkg.Workspaces) == 0 {
+		return nil, nil
+	}
+
+	var patterns []string
+	if err := json.Unmarshal(pkg.Workspaces, &patterns); err == nil {
+		return patterns, nil
+	}
+	var yarn struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(pkg.Workspaces, &yarn); err != nil {
+		return nil, fmt.Errorf("parsing %s: workspaces must be an array or {\"packages\": [...]}", file)
+	}
+	return yarn.Packages, nil
+}
+
+// pnpmWorkspacePackages returns the "packages" patterns of a
+// pnpm-workspace.yaml.
+func pnpmWorkspacePackages(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ws struct {
+		Packages []string `yaml:"packages"`
+	}
+	if err := yaml.Unmarshal(data, &ws); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	return ws.Packages, nil
+}
+
+// cargoWorkspaceMembers returns the [workspace] "members" patterns of a
+// Cargo.toml, with its "exclude" entries negated.
+func cargoWorkspaceMembers(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cargo struct {
+		Workspace struct {
+			Members []string `toml:"members"`
+			Exclude []string `toml:"exclude"`
+		} `toml:"workspace"`
+	}
+	if err := toml.Unmarshal(data, &cargo); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	patterns := cargo.Workspace.Members
+	for _, e := range cargo.Workspace.Exclude {
+		patterns = append(patterns, "!"+e)
+	}
+	return patterns, nil
+}
+
+// expandPatterns returns the directories under rootDir matching patterns
+// that have the file manifest. Negated patterns ("!dir") exclude
+// directories; a trailing "/**" matches directories at any depth.
+func expandPatterns(rootDir string, patterns []string, manifest string) ([]string, error) {
+	var dirs, excluded []string
+	for _, p := range patterns {
+		negate := strings.HasPrefix(p, "!")
+		p = path.Clean(strings.TrimPrefix(strings.TrimPrefix(p, "!"), "./"))
+
+		matches, err := globDirs(rootDir, p)
+		if err != nil {
+			return nil, fmt.Errorf("workspace pattern %q: %w", p, err)
+		}
+		if negate {
+			excluded = append(excluded, matches...)
+		} else {
+			dirs = append(dirs, matches...)
+		}
+	}
+
+	var out []string
+	for _, d := range dirs {
+		if slices.Contains(excluded, d) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(rootDir, filepath.FromSlash(d), manifest)); err == nil {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// globDirs returns the slash-separated directories under rootDir matching
+// pattern.
+func globDirs(rootDir, pattern string) ([]string, error) {
+	if base, ok := strings.CutSuffix(pattern, "/**"); ok {
+		var dirs []string
+		parents, err := globDirs(rootDir, base)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range parents {
+			err := filepath.WalkDir(filepath.Join(rootDir, filepath.FromSlash(parent)), func(p string, d os.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() {
+					return nil
+				}
+				if d.Name() == "node_modules" || strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				rel, err := filepath.Rel(rootDir, p)
+				if err != nil {
+					return err
+				}
+				dirs = append(dirs, filepath.ToSlash(rel))
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return dirs, nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(rootDir, filepath.FromSlash(pattern)))
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, m := range matches {
+		if info, err := os.Stat(m); err != nil || !info.IsDir() {
+			continue
+		}
+		rel, err := filepath.Rel(rootDir, m)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, filepath.ToSlash(rel))
+	}
+	return dirs, nil
+}
+
+// goWorkUses returns the module directories of a go.work's use directives,
+// in both the single-line and the block form.
+func goWorkUses(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	inBlock := false
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case inBlock:
+		case line == "use (":
+			inBlock = true
+			continue
+		case strings.HasPrefix(line, "use "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "use "))
+		default:
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		if unq, err := strconv.Unquote(line); err == nil {
+			line = unq
+		}
+		dirs = append(dirs, filepath.ToSlash(line))
+	}
+	return dirs, sc.Err()
+}
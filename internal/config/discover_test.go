@@ -0,0 +1,147 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFindWorkspaceCandidates(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("package.json", `{"name": "mono", "workspaces": ["apps/*", "packages/**", "!apps/legacy"]}`)
+	write("apps/web/package.json", "{}")
+	write("apps/legacy/package.json", "{}")
+	write("apps/docs/README.md", "no package.json")
+	write("packages/ui/package.json", "{}")
+	write("packages/ui/icons/package.json", "{}")
+	write("packages/ui/node_modules/dep/package.json", "{}")
+	write("go.work", `go 1.25
+
+use . // the root module
+use (
+	./services/api
+	"./services/worker" // quoted
+	./apps/web
+)
+`)
+
+	got, err := FindWorkspaceCandidates(root)
+	if err != nil {
+		t.Fatalf("FindWorkspaceCandidates() error = %v", err)
+	}
+	// packages itself has no package.json, and apps/web is listed by both.
+	want := []WorkspaceCandidate{
+		{"apps/web", "package.json"},
+		{"packages/ui", "package.json"},
+		{"packages/ui/icons", "package.json"},
+		{"services/api", "go.work"},
+		{"services/worker", "go.work"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("FindWorkspaceCandidates() = %v, want %v", got, want)
+	}
+
+	write("package.json", `{"workspaces": {"packages": ["apps/web"]}}`)
+	if err := os.Remove(filepath.Join(root, "go.work")); err != nil {
+		t.Fatal(err)
+	}
+	got, err = FindWorkspaceCandidates(root)
+	if err != nil {
+		t.Fatalf("FindWorkspaceCandidates() error = %v", err)
+	}
+	if want := []WorkspaceCandidate{{"apps/web", "package.json"}}; !slices.Equal(got, want) {
+		t.Errorf("FindWorkspaceCandidates() with Yarn's form = %v, want %v", got, want)
+	}
+
+	got, err = FindWorkspaceCandidates(t.TempDir())
+	if err != nil || len(got) != 0 {
+		t.Errorf("FindWorkspaceCandidates() on an empty directory = %v, %v", got, err)
+	}
+}
+
+func TestFindWorkspaceCandidates_PnpmAndCargo(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, p, content)
+	}
+
+	write("pnpm-workspace.yaml", `packages:
+  - 'apps/*'
+  - "!apps/sandbox"
+catalog:
+  react: ^18
+`)
+	write("apps/web/package.json", "{}")
+	write("apps/sandbox/package.json", "{}")
+	write("Cargo.toml", `[workspace]
+members = ["crates/*"]
+exclude = ["crates/scratch"]
+`)
+	write("crates/core/Cargo.toml", "")
+	write("crates/scratch/Cargo.toml", "")
+	write("crates/notes/README.md", "no Cargo.toml")
+
+	got, err := FindWorkspaceCandidates(root)
+	if err != nil {
+		t.Fatalf("FindWorkspaceCandidates() error = %v", err)
+	}
+	want := []WorkspaceCandidate{
+		{"apps/web", "pnpm-workspace.yaml"},
+		{"crates/core", "Cargo.toml"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("FindWorkspaceCandidates() = %v, want %v", got, want)
+	}
+
+	// A Cargo.toml of a single crate has no [workspace].
+	write("Cargo.toml", "[package]\nname = \"tool\"\n")
+	got, err = FindWorkspaceCandidates(root)
+	if err != nil {
+		t.Fatalf("FindWorkspaceCandidates() error = %v", err)
+	}
+	if want := []WorkspaceCandidate{{"apps/web", "pnpm-workspace.yaml"}}; !slices.Equal(got, want) {
+		t.Errorf("FindWorkspaceCandidates() without a Cargo workspace = %v, want %v", got, want)
+	}
+}
+
+func TestDiscoverWorkspaces(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"apps/web", "apps/admin", "services/api"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTestFile(t, filepath.Join(root, "package.json"), `{"workspaces": ["apps/*"]}`)
+	writeTestFile(t, filepath.Join(root, "apps/web/package.json"), "{}")
+	writeTestFile(t, filepath.Join(root, "apps/admin/package.json"), "{}")
+	writeTestFile(t, filepath.Join(root, "go.work"), "use ./services/api\n")
+
+	// Only the directories with a vx.toml are workspaces.
+	writeTestFile(t, filepath.Join(root, "apps/web/vx.toml"), "")
+	writeTestFile(t, filepath.Join(root, "services/api/vx.toml"), "")
+
+	got, err := DiscoverWorkspaces(root)
+	if err != nil {
+		t.Fatalf("DiscoverWorkspaces() error = %v", err)
+	}
+	if want := []string{"apps/web/vx.toml", "services/api/vx.toml"}; !slices.Equal(got, want) {
+		t.Errorf("DiscoverWorkspaces() = %v, want %v", got, want)
+	}
+}
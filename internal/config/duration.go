@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration that is written in vx.toml as a Go duration
+// string such as "30s", "5m", or "1h30m".
+type Duration time.Duration
+
+// UnmarshalText parses a duration string. Negative durations are rejected.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", text, err)
+	}
+	if parsed < 0 {
+		return fmt.Errorf("invalid duration %q: must not be negative", text)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText formats the duration in Go duration syntax.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
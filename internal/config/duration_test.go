@@ -0,0 +1,59 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDuration_UnmarshalText(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30s", 30 * time.Second, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"0s", 0, false},
+		{"-5s", 0, true},
+		{"30", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		var d Duration
+		err := d.UnmarshalText([]byte(tt.input))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("UnmarshalText(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && time.Duration(d) != tt.want {
+			t.Errorf("UnmarshalText(%q) = %v, want %v", tt.input, time.Duration(d), tt.want)
+		}
+	}
+}
+
+func TestLoadRootConfig_Clipboard(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vx.toml")
+	writeTestFile(t, path, `
+[clipboard]
+clear_after = "45s"
+`)
+
+	cfg, err := LoadRootConfig(path)
+	if err != nil {
+		t.Fatalf("LoadRootConfig() error = %v", err)
+	}
+	if time.Duration(cfg.Clipboard.ClearAfter) != 45*time.Second {
+		t.Errorf("Clipboard.ClearAfter = %v, want 45s", time.Duration(cfg.Clipboard.ClearAfter))
+	}
+
+	writeTestFile(t, path, `
+[clipboard]
+clear_after = "later"
+`)
+	if _, err := LoadRootConfig(path); err == nil {
+		t.Error("LoadRootConfig() expected error for invalid duration")
+	}
+}
@@ -0,0 +1,23 @@
+package config
+
+// PathSegment returns what ${env} is replaced with in secret paths for env:
+// its entry in Map, or the name itself.
+func (e EnvironmentConfig) PathSegment(env string) string {
+	if seg, ok := e.Map[env]; ok {
+		return seg
+	}
+	return env
+}
+
+// DefaultProtectedEnvironments is used when [tui] protected_environments is
+// not set in vx.toml.
+var DefaultProtectedEnvironments = []string{"production"}
+
+// IsProtected reports whether writes and reveals in env need explicit
+// confirmation (see ProtectedEnvironments).
+func (t TUIConfig) IsProtected(env string) bool {
+	if t.ProtectedEnvironments == nil {
+		return contains(DefaultProtectedEnvironments, env)
+	}
+	return contains(t.ProtectedEnvironments, env)
+}
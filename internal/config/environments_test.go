@@ -0,0 +1,73 @@
+package config
+
+import "testing"
+
+func TestPathSegment(t *testing.T) {
+	e := EnvironmentConfig{
+		Available: []string{"dev", "production"},
+		Map:       map[string]string{"production": "prod"},
+	}
+
+	if got := e.PathSegment("production"); got != "prod" {
+		t.Errorf("PathSegment(production) = %q, want prod", got)
+	}
+	if got := e.PathSegment("dev"); got != "dev" {
+		t.Errorf("PathSegment(dev) = %q, want dev", got)
+	}
+}
+
+func TestMerge_PathEnv(t *testing.T) {
+	root := &RootConfig{
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev", "production"},
+			Map:       map[string]string{"production": "prod"},
+		},
+	}
+
+	merged, err := Merge(root, nil, "production")
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if merged.Environment != "production" || merged.PathEnv != "prod" {
+		t.Errorf("Environment = %q, PathEnv = %q; want production, prod", merged.Environment, merged.PathEnv)
+	}
+}
+
+func TestParseRootConfig_EnvironmentMap(t *testing.T) {
+	cfg, err := ParseRootConfig([]byte(`
+[vault]
+address = "https://vault.example.com"
+auth_method = "oidc"
+
+[environments]
+default = "dev"
+available = ["dev", "production"]
+
+[environments.map]
+production = "prod"
+`))
+	if err != nil {
+		t.Fatalf("ParseRootConfig() error = %v", err)
+	}
+	if got := cfg.Environments.PathSegment("production"); got != "prod" {
+		t.Errorf("PathSegment(production) = %q, want prod", got)
+	}
+}
+
+func TestIsProtected(t *testing.T) {
+	if !(TUIConfig{}).IsProtected("production") {
+		t.Error("production is not protected by default")
+	}
+	if (TUIConfig{}).IsProtected("dev") {
+		t.Error("dev is protected by default")
+	}
+
+	custom := TUIConfig{ProtectedEnvironments: []string{"staging"}}
+	if custom.IsProtected("production") || !custom.IsProtected("staging") {
+		t.Error("protected_environments does not replace the default")
+	}
+	if (TUIConfig{ProtectedEnvironments: []string{}}).IsProtected("production") {
+		t.Error("an empty protected_environments still protects production")
+	}
+}
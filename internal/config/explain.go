@@ -0,0 +1,100 @@
+package config
+
+import "fmt"
+
+// Source is one place a variable is defined.
+type Source struct {
+	// Workspace is true for the workspace's vx.toml, false for the root one.
+	Workspace bool
+	// Table is "secrets", "defaults", or "defaults.<env>".
+	Table string
+	// Value is the Vault path as written for secrets, prefixed with the
+	// workspace's vault if it has one, and the formatted value for defaults.
+	Value string
+}
+
+// IsSecret reports whether the source maps a Vault secret.
+func (s Source) IsSecret() bool {
+	return s.Table == "secrets"
+}
+
+// Explanation lists every definition of a variable for one environment.
+type Explanation struct {
+	Name string
+	Env  string
+	// Sources are ordered from lowest to highest precedence: the last one is
+	// what Merge produces.
+	Sources []Source
+}
+
+// Winner returns the source that provides the variable's value, or false
+// when it is not defined anywhere.
+func (e *Explanation) Winner() (Source, bool) {
+	if len(e.Sources) == 0 {
+		return Source{}, false
+	}
+	return e.Sources[len(e.Sources)-1], true
+}
+
+// Explain reports where name is defined for env, following the same
+// precedence as Merge: root defaults, then the root's [defaults.<env>], then
+// the workspace's, and any secret mapping over all defaults, with the
+// workspace's mapping over the root's. workspace may be nil.
+func Explain(root *RootConfig, workspace *WorkspaceConfig, env string, name string) (*Explanation, error) {
+	if root == nil {
+		return nil, fmt.Errorf("root config is required")
+	}
+
+	if env == "" {
+		env = root.Environments.Default
+	}
+
+	if !contains(root.Environments.Available, env) {
+		return nil, fmt.Errorf("environment %q is not in available environments", env)
+	}
+
+	e := &Explanation{Name: name, Env: env}
+	envs := root.Environments.Available
+
+	e.Sources = append(e.Sources, defaultSources(root.Defaults, false, env, envs, name)...)
+	if workspace != nil {
+		e.Sources = append(e.Sources, defaultSources(workspace.Defaults, true, env, envs, name)...)
+	}
+
+	if path, ok := root.Secrets[name]; ok {
+		e.Sources = append(e.Sources, Source{Table: "secrets", Value: path})
+	}
+	if workspace != nil {
+		if path, ok := workspace.Secrets[name]; ok {
+			e.Sources = append(e.Sources, Source{Workspace: true, Table: "secrets", Value: inVault(path, workspace.Vault)})
+		}
+	}
+
+	return e, nil
+}
+
+// defaultSources returns the definitions of name in one file's defaults:
+// the base table, then the table for env. Values resolveDefaults drops are
+// skipped, as they never reach the environment.
+func defaultSources(defaults map[string]any, workspace bool, env string, envs []string, name string) []Source {
+	var sources []Source
+
+	if val, ok := defaults[name]; ok {
+		_, isTable := val.(map[string]any)
+		if !isTable || !contains(envs, name) {
+			if str, ok := formatDefault(val); ok {
+				sources = append(sources, Source{Workspace: workspace, Table: "defaults", Value: str})
+			}
+		}
+	}
+
+	if envMap, ok := defaults[env].(map[string]any); ok {
+		if val, ok := envMap[name]; ok {
+			if str, ok := formatDefault(val); ok {
+				sources = append(sources, Source{Workspace: workspace, Table: "defaults." + env, Value: str})
+			}
+		}
+	}
+
+	return sources
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func explainRoot() *RootConfig {
+	return &RootConfig{
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev", "production"},
+		},
+		Secrets: map[string]string{
+			"DATABASE_URL": "${env}/database/url",
+		},
+		Defaults: map[string]any{
+			"LOG_LEVEL":    "info",
+			"DATABASE_URL": "postgres://localhost/app",
+			"PORT":         int64(3000),
+			"production": map[string]any{
+				"LOG_LEVEL": "warn",
+			},
+		},
+	}
+}
+
+func TestExplain_Precedence(t *testing.T) {
+	ws := &WorkspaceConfig{
+		Secrets: map[string]string{
+			"DATABASE_URL": "${env}/api/database_url",
+		},
+		Defaults: map[string]any{
+			"production": map[string]any{
+				"LOG_LEVEL": "error",
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		env  string
+		want []Source
+	}{
+		{
+			name: "LOG_LEVEL",
+			env:  "production",
+			want: []Source{
+				{Table: "defaults", Value: "info"},
+				{Table: "defaults.production", Value: "warn"},
+				{Workspace: true, Table: "defaults.production", Value: "error"},
+			},
+		},
+		{
+			name: "LOG_LEVEL",
+			env:  "dev",
+			want: []Source{
+				{Table: "defaults", Value: "info"},
+			},
+		},
+		{
+			name: "DATABASE_URL",
+			env:  "dev",
+			want: []Source{
+				{Table: "defaults", Value: "postgres://localhost/app"},
+				{Table: "secrets", Value: "${env}/database/url"},
+				{Workspace: true, Table: "secrets", Value: "${env}/api/database_url"},
+			},
+		},
+		{
+			name: "PORT",
+			env:  "dev",
+			want: []Source{
+				{Table: "defaults", Value: "3000"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"/"+tt.env, func(t *testing.T) {
+			e, err := Explain(explainRoot(), ws, tt.env, tt.name)
+			if err != nil {
+				t.Fatalf("Explain() error = %v", err)
+			}
+			if !reflect.DeepEqual(e.Sources, tt.want) {
+				t.Errorf("Sources = %+v, want %+v", e.Sources, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplain_WinnerMatchesMerge(t *testing.T) {
+	root := explainRoot()
+	ws := &WorkspaceConfig{
+		Defaults: map[string]any{"LOG_LEVEL": "debug"},
+	}
+
+	merged, err := Merge(root, ws, "production")
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	e, err := Explain(root, ws, "production", "LOG_LEVEL")
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+
+	winner, ok := e.Winner()
+	if !ok {
+		t.Fatal("Winner() found nothing")
+	}
+	if winner.Value != merged.Defaults["LOG_LEVEL"] {
+		t.Errorf("winner = %q, Merge gives %q", winner.Value, merged.Defaults["LOG_LEVEL"])
+	}
+}
+
+func TestExplain_Undefined(t *testing.T) {
+	e, err := Explain(explainRoot(), nil, "", "MISSING")
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	if e.Env != "dev" {
+		t.Errorf("Env = %q, want the default environment", e.Env)
+	}
+	if _, ok := e.Winner(); ok {
+		t.Error("Winner() found a source for an undefined variable")
+	}
+}
+
+func TestExplain_SkipsDroppedAndEnvTables(t *testing.T) {
+	root := explainRoot()
+	root.Defaults["HOSTS"] = []any{"a", "b"}
+
+	for _, name := range []string{"HOSTS", "production"} {
+		e, err := Explain(root, nil, "dev", name)
+		if err != nil {
+			t.Fatalf("Explain() error = %v", err)
+		}
+		if len(e.Sources) != 0 {
+			t.Errorf("%s: Sources = %+v, want none", name, e.Sources)
+		}
+	}
+}
+
+func TestExplain_UnknownEnv(t *testing.T) {
+	if _, err := Explain(explainRoot(), nil, "qa", "LOG_LEVEL"); err == nil {
+		t.Error("Explain() accepted an unknown environment")
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ExportFormats are the outputs an [exports.<format>] table can customize:
+// vx list --format=dotenv and --format=shell, and vx exec --export-runtime.
+var ExportFormats = []string{"dotenv", "shell", "runtime"}
+
+// ExportRenames returns the rename rules configured for format, or nil.
+func (c *RootConfig) ExportRenames(format string) map[string]string {
+	return c.Exports[format].Rename
+}
+
+// RenameKeys returns a copy of values with every key in rename replaced by
+// its new name. A renamed value replaces one already under the new name.
+func RenameKeys(values map[string]string, rename map[string]string) map[string]string {
+	out := make(map[string]string, len(values))
+	for k, v := range values {
+		if _, ok := rename[k]; !ok {
+			out[k] = v
+		}
+	}
+	for k, v := range values {
+		if to, ok := rename[k]; ok {
+			out[to] = v
+		}
+	}
+	return out
+}
+
+func validateExports(exports map[string]ExportConfig) error {
+	formats := make([]string, 0, len(exports))
+	for format := range exports {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+
+	for _, format := range formats {
+		if !contains(ExportFormats, format) {
+			return fmt.Errorf("unknown export %q (use %s)", format, strings.Join(ExportFormats, ", "))
+		}
+
+		from := make([]string, 0, len(exports[format].Rename))
+		for k := range exports[format].Rename {
+			from = append(from, k)
+		}
+		sort.Strings(from)
+
+		targets := make(map[string]string, len(from))
+		for _, k := range from {
+			to := exports[format].Rename[k]
+			if to == "" || strings.ContainsAny(to, "= \t\n") {
+				return fmt.Errorf("%s: invalid name %q for %s", format, to, k)
+			}
+			if prev, ok := targets[to]; ok {
+				return fmt.Errorf("%s: %s and %s are both renamed to %s", format, prev, k, to)
+			}
+			targets[to] = k
+		}
+	}
+	return nil
+}
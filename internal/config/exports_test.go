@@ -0,0 +1,103 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenameKeys(t *testing.T) {
+	values := map[string]string{
+		"DATABASE_URL": "postgres://db",
+		"API_KEY":      "k",
+		"DB_URL":       "stale",
+	}
+
+	got := RenameKeys(values, map[string]string{"DATABASE_URL": "DB_URL", "MISSING": "OTHER"})
+
+	want := map[string]string{"DB_URL": "postgres://db", "API_KEY": "k"}
+	if len(got) != len(want) {
+		t.Fatalf("RenameKeys() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("RenameKeys()[%q] = %q, want %q", k, got[k], v)
+		}
+	}
+	if values["DATABASE_URL"] != "postgres://db" {
+		t.Error("RenameKeys() modified its input")
+	}
+}
+
+func TestRenameKeys_Swap(t *testing.T) {
+	got := RenameKeys(map[string]string{"A": "1", "B": "2"}, map[string]string{"A": "B", "B": "A"})
+	if got["A"] != "2" || got["B"] != "1" {
+		t.Errorf("RenameKeys() = %v, want A and B swapped", got)
+	}
+}
+
+func TestValidate_Exports(t *testing.T) {
+	cfg := &RootConfig{
+		Vault: VaultConfig{Address: "https://vault.example.com", AuthMethod: "oidc"},
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		exports map[string]ExportConfig
+		wantErr string
+	}{
+		{"valid", map[string]ExportConfig{"dotenv": {Rename: map[string]string{"DATABASE_URL": "DB_URL"}}}, ""},
+		{"unknown format", map[string]ExportConfig{"yaml": {}}, `unknown export "yaml"`},
+		{"empty name", map[string]ExportConfig{"shell": {Rename: map[string]string{"A": ""}}}, "invalid name"},
+		{"name with equals", map[string]ExportConfig{"shell": {Rename: map[string]string{"A": "B=C"}}}, "invalid name"},
+		{"collision", map[string]ExportConfig{"runtime": {Rename: map[string]string{"A": "C", "B": "C"}}}, "A and B are both renamed to C"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Exports = tt.exports
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseExports(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vx.toml")
+	writeTestFile(t, path, `
+[vault]
+address = "https://vault.example.com"
+auth_method = "oidc"
+
+[environments]
+default = "dev"
+available = ["dev"]
+
+[exports.dotenv.rename]
+DATABASE_URL = "DB_URL"
+`)
+
+	cfg, err := LoadRootConfig(path)
+	if err != nil {
+		t.Fatalf("LoadRootConfig() error = %v", err)
+	}
+	if got := cfg.ExportRenames("dotenv")["DATABASE_URL"]; got != "DB_URL" {
+		t.Errorf(`ExportRenames("dotenv")["DATABASE_URL"] = %q, want "DB_URL"`, got)
+	}
+	if cfg.ExportRenames("shell") != nil {
+		t.Error(`ExportRenames("shell") should be nil when not configured`)
+	}
+}
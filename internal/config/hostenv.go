@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"path"
+)
+
+// mergeHostEnv combines the env_passthrough and env_block patterns of root
+// and workspace, each into a new sorted list without duplicates.
+func mergeHostEnv(root *RootConfig, workspace *WorkspaceConfig) (passthrough, block []string) {
+	if workspace == nil {
+		return unionSorted(root.EnvPassthrough), unionSorted(root.EnvBlock)
+	}
+	return unionSorted(root.EnvPassthrough, workspace.EnvPassthrough),
+		unionSorted(root.EnvBlock, workspace.EnvBlock)
+}
+
+// validateHostEnv checks env_passthrough and env_block for empty or
+// malformed patterns.
+func validateHostEnv(passthrough, block []string) error {
+	for _, list := range []struct {
+		key      string
+		patterns []string
+	}{{"env_passthrough", passthrough}, {"env_block", block}} {
+		for _, p := range list.patterns {
+			if p == "" {
+				return fmt.Errorf("%s: empty pattern", list.key)
+			}
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("%s: invalid pattern %q", list.key, p)
+			}
+		}
+	}
+	return nil
+}
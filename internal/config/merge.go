@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 )
 
 // Merge combines a root config and an optional workspace config for a specific environment
@@ -19,80 +21,137 @@ func Merge(root *RootConfig, workspace *WorkspaceConfig, env string) (*MergedCon
 		return nil, fmt.Errorf("environment %q is not in available environments", env)
 	}
 
-	defaults := resolveDefaults(root.Defaults, env)
-	defaults = mergeWorkspaceDefaults(defaults, workspace, env)
+	defaults, warnings := resolveDefaults(root.Defaults, env, root.Environments.Available)
+	defaults, wsWarnings := mergeWorkspaceDefaults(defaults, workspace, env, root.Environments.Available)
+	warnings = append(warnings, wsWarnings...)
+	interpolateDefaults(defaults, env)
+
+	if workspace != nil && workspace.Vault != "" {
+		if _, ok := root.Vaults[workspace.Vault]; !ok {
+			return nil, fmt.Errorf("workspace vault %q is not defined in [vaults]", workspace.Vault)
+		}
+	}
 
 	secrets := mergeSecrets(root.Secrets, workspace)
+	if err := checkVaultRefs(secrets, root.Vaults); err != nil {
+		return nil, err
+	}
+
+	passthrough, block := mergeHostEnv(root, workspace)
 
 	return &MergedConfig{
-		Vault:       root.Vault,
-		Environment: env,
-		Secrets:     secrets,
-		Defaults:    defaults,
+		Vault:          root.Vault,
+		Vaults:         root.Vaults,
+		Resolver:       root.Resolver,
+		Cache:          root.Cache,
+		Environment:    env,
+		PathEnv:        root.Environments.PathSegment(env),
+		Secrets:        secrets,
+		Defaults:       defaults,
+		CacheTTL:       mergeCacheTTL(root.CacheTTL, workspace),
+		OnError:        mergeOnError(root.OnError, workspace),
+		Required:       mergeRequired(root.Required, workspace),
+		EnvPassthrough: passthrough,
+		EnvBlock:       block,
+		Warnings:       warnings,
 	}, nil
 }
 
 // resolveDefaults extracts base defaults and overlays environment-specific defaults.
-// The input map is never mutated.
-func resolveDefaults(defaults map[string]any, env string) map[string]string {
+// Typed scalars are converted with formatDefault. Tables named after one of
+// envs are environment sections; any other array or table cannot be injected
+// and is reported in the returned warnings. The input map is never mutated.
+func resolveDefaults(defaults map[string]any, env string, envs []string) (map[string]string, []string) {
 	result := make(map[string]string)
+	var warnings []string
 
-	for key, val := range defaults {
-		if str, ok := val.(string); ok {
-			result[key] = str
+	for _, key := range sortedAnyKeys(defaults) {
+		val := defaults[key]
+		if _, isTable := val.(map[string]any); isTable && contains(envs, key) {
+			continue
 		}
+
+		str, ok := formatDefault(val)
+		if !ok {
+			warnings = append(warnings, droppedDefaultWarning("defaults", key, val))
+			continue
+		}
+		result[key] = str
 	}
 
-	envDefaults := extractEnvDefaults(defaults, env)
+	envDefaults, envWarnings := extractEnvDefaults(defaults, env)
 	for key, val := range envDefaults {
 		result[key] = val
 	}
+	warnings = append(warnings, envWarnings...)
 
-	return result
+	return result, warnings
 }
 
 // extractEnvDefaults pulls the environment-specific nested table from a defaults map.
-func extractEnvDefaults(defaults map[string]any, env string) map[string]string {
+func extractEnvDefaults(defaults map[string]any, env string) (map[string]string, []string) {
 	result := make(map[string]string)
 
 	envSection, ok := defaults[env]
 	if !ok {
-		return result
+		return result, nil
 	}
 
 	envMap, ok := envSection.(map[string]any)
 	if !ok {
-		return result
+		return result, nil
 	}
 
-	for key, val := range envMap {
-		if str, ok := val.(string); ok {
-			result[key] = str
+	var warnings []string
+	for _, key := range sortedAnyKeys(envMap) {
+		val := envMap[key]
+		str, ok := formatDefault(val)
+		if !ok {
+			warnings = append(warnings, droppedDefaultWarning("defaults."+env, key, val))
+			continue
 		}
+		result[key] = str
 	}
 
-	return result
+	return result, warnings
 }
 
 // mergeWorkspaceDefaults overlays workspace defaults on top of existing defaults.
 // Neither input is mutated; a new map is returned.
-func mergeWorkspaceDefaults(base map[string]string, workspace *WorkspaceConfig, env string) map[string]string {
+func mergeWorkspaceDefaults(base map[string]string, workspace *WorkspaceConfig, env string, envs []string) (map[string]string, []string) {
 	if workspace == nil {
-		return copyStringMap(base)
+		return copyStringMap(base), nil
 	}
 
 	result := copyStringMap(base)
 
-	wsDefaults := resolveDefaults(workspace.Defaults, env)
+	wsDefaults, warnings := resolveDefaults(workspace.Defaults, env, envs)
 	for key, val := range wsDefaults {
 		result[key] = val
 	}
 
-	return result
+	return result, warnings
+}
+
+// interpolateDefaults replaces the placeholders in default values in place:
+// ${env} becomes the environment name. Unlike secret paths, the name is
+// used as-is, without its [environments.map] entry.
+func interpolateDefaults(defaults map[string]string, env string) {
+	r := strings.NewReplacer("${env}", env)
+	for k, v := range defaults {
+		defaults[k] = r.Replace(v)
+	}
+}
+
+// droppedDefaultWarning formats the warning emitted for a default value that
+// has no string representation.
+func droppedDefaultWarning(section string, key string, val any) string {
+	return fmt.Sprintf("[%s] %s: %s values are not supported and were dropped", section, key, describeKind(val))
 }
 
 // mergeSecrets combines root and workspace secrets into a new map.
-// Workspace secrets override root secrets with the same key.
+// Workspace secrets override root secrets with the same key, and read from
+// the workspace's vault, if it has one.
 func mergeSecrets(rootSecrets map[string]string, workspace *WorkspaceConfig) map[string]string {
 	result := copyStringMap(rootSecrets)
 
@@ -100,13 +159,48 @@ func mergeSecrets(rootSecrets map[string]string, workspace *WorkspaceConfig) map
 		return result
 	}
 
-	for key, val := range workspace.Secrets {
+	for key, val := range withVault(workspace.Secrets, workspace.Vault) {
 		result[key] = val
 	}
 
 	return result
 }
 
+// mergeCacheTTL combines root and workspace cache_ttl overrides into a new
+// map. Workspace overrides win.
+func mergeCacheTTL(rootTTL map[string]Duration, workspace *WorkspaceConfig) map[string]Duration {
+	result := make(map[string]Duration, len(rootTTL))
+	for k, v := range rootTTL {
+		result[k] = v
+	}
+
+	if workspace == nil {
+		return result
+	}
+
+	for k, v := range workspace.CacheTTL {
+		result[k] = v
+	}
+
+	return result
+}
+
+// mergeOnError combines root and workspace on_error overrides into a new
+// map. Workspace overrides win.
+func mergeOnError(rootOnError map[string]string, workspace *WorkspaceConfig) map[string]string {
+	result := copyStringMap(rootOnError)
+
+	if workspace == nil {
+		return result
+	}
+
+	for k, v := range workspace.OnError {
+		result[k] = v
+	}
+
+	return result
+}
+
 // copyStringMap creates a shallow copy of a string map.
 func copyStringMap(src map[string]string) map[string]string {
 	result := make(map[string]string, len(src))
@@ -115,3 +209,13 @@ func copyStringMap(src map[string]string) map[string]string {
 	}
 	return result
 }
+
+// sortedAnyKeys returns the keys of m in lexical order so warnings are stable.
+func sortedAnyKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestMerge_RootOnly(t *testing.T) {
@@ -253,6 +255,90 @@ func TestMerge_DoesNotMutateInputs(t *testing.T) {
 	}
 }
 
+func TestMerge_TypedDefaults(t *testing.T) {
+	root := &RootConfig{
+		Vault: VaultConfig{
+			Address:    "https://vault.example.com",
+			AuthMethod: "oidc",
+		},
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev", "production"},
+		},
+		Defaults: map[string]any{
+			"DEBUG":   true,
+			"PORT":    int64(3000),
+			"RATIO":   0.25,
+			"WORKERS": 4.0,
+			"production": map[string]any{
+				"DEBUG": false,
+			},
+		},
+	}
+
+	merged, err := Merge(root, nil, "production")
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	assertMapValue(t, merged.Defaults, "DEBUG", "false")
+	assertMapValue(t, merged.Defaults, "PORT", "3000")
+	assertMapValue(t, merged.Defaults, "RATIO", "0.25")
+	assertMapValue(t, merged.Defaults, "WORKERS", "4")
+
+	if len(merged.Warnings) != 0 {
+		t.Errorf("Warnings = %v, want none", merged.Warnings)
+	}
+}
+
+func TestMerge_ComplexDefaultsWarn(t *testing.T) {
+	root := &RootConfig{
+		Vault: VaultConfig{
+			Address:    "https://vault.example.com",
+			AuthMethod: "oidc",
+		},
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev", "staging"},
+		},
+		Defaults: map[string]any{
+			"HOSTS": []any{"a", "b"},
+			"NESTED": map[string]any{
+				"KEY": "value",
+			},
+			"staging": map[string]any{
+				"PORTS": []any{int64(1), int64(2)},
+			},
+		},
+	}
+
+	merged, err := Merge(root, nil, "staging")
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	if _, ok := merged.Defaults["HOSTS"]; ok {
+		t.Error("array default should be dropped")
+	}
+	if _, ok := merged.Defaults["NESTED"]; ok {
+		t.Error("non-environment table default should be dropped")
+	}
+
+	want := []string{
+		"[defaults] HOSTS: array values are not supported and were dropped",
+		"[defaults] NESTED: table values are not supported and were dropped",
+		"[defaults.staging] PORTS: array values are not supported and were dropped",
+	}
+	if len(merged.Warnings) != len(want) {
+		t.Fatalf("Warnings = %v, want %v", merged.Warnings, want)
+	}
+	for i, w := range want {
+		if merged.Warnings[i] != w {
+			t.Errorf("Warnings[%d] = %q, want %q", i, merged.Warnings[i], w)
+		}
+	}
+}
+
 func assertMapValue(t *testing.T, m map[string]string, key string, want string) {
 	t.Helper()
 	got, ok := m[key]
@@ -264,3 +350,134 @@ func assertMapValue(t *testing.T, m map[string]string, key string, want string)
 		t.Errorf("map[%q] = %q, want %q", key, got, want)
 	}
 }
+
+func TestMerge_CacheTTL(t *testing.T) {
+	root := &RootConfig{
+		Environments: EnvironmentConfig{Default: "dev", Available: []string{"dev"}},
+		CacheTTL: map[string]Duration{
+			"DB_TOKEN":   Duration(time.Minute),
+			"STRIPE_KEY": Duration(time.Hour),
+		},
+	}
+	ws := &WorkspaceConfig{
+		CacheTTL: map[string]Duration{"DB_TOKEN": 0},
+	}
+
+	merged, err := Merge(root, ws, "dev")
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	want := map[string]Duration{"DB_TOKEN": 0, "STRIPE_KEY": Duration(time.Hour)}
+	if !reflect.DeepEqual(merged.CacheTTL, want) {
+		t.Errorf("CacheTTL = %v, want %v", merged.CacheTTL, want)
+	}
+	if root.CacheTTL["DB_TOKEN"] != Duration(time.Minute) {
+		t.Error("Merge() mutated the root cache_ttl")
+	}
+}
+
+func TestMerge_InterpolatesDefaults(t *testing.T) {
+	root := &RootConfig{
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev", "production"},
+			Map:       map[string]string{"production": "prod"},
+		},
+		Defaults: map[string]any{
+			"API_BASE_URL": "https://api.${env}.example.com",
+			"production": map[string]any{
+				"CDN_URL": "https://cdn-${env}.example.com",
+			},
+		},
+	}
+	ws := &WorkspaceConfig{
+		Defaults: map[string]any{"QUEUE": "jobs-${env}"},
+	}
+
+	merged, err := Merge(root, ws, "production")
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	assertMapValue(t, merged.Defaults, "API_BASE_URL", "https://api.production.example.com")
+	assertMapValue(t, merged.Defaults, "CDN_URL", "https://cdn-production.example.com")
+	assertMapValue(t, merged.Defaults, "QUEUE", "jobs-production")
+
+	if root.Defaults["API_BASE_URL"] != "https://api.${env}.example.com" {
+		t.Error("Merge() mutated the root defaults")
+	}
+}
+
+func TestMerge_OnError(t *testing.T) {
+	root := &RootConfig{
+		Environments: EnvironmentConfig{Default: "dev", Available: []string{"dev"}},
+		Resolver:     ResolverConfig{OnError: OnErrorWarn},
+		OnError:      map[string]string{"DATABASE_URL": OnErrorFail, "SENTRY_DSN": OnErrorFail},
+		Defaults:     map[string]any{"SENTRY_DSN": ""},
+	}
+	ws := &WorkspaceConfig{
+		OnError: map[string]string{"SENTRY_DSN": OnErrorDefault, "STRIPE_KEY": OnErrorDefault},
+	}
+
+	merged, err := Merge(root, ws, "dev")
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	tests := map[string]bool{
+		"DATABASE_URL": false, // explicit fail
+		"SENTRY_DSN":   true,  // workspace default, and a default exists
+		"STRIPE_KEY":   false, // default policy without a default
+		"REDIS_URL":    true,  // global warn
+	}
+	for envVar, want := range tests {
+		if got := merged.SkipOnError(envVar); got != want {
+			t.Errorf("SkipOnError(%q) = %v, want %v", envVar, got, want)
+		}
+	}
+	if root.OnError["SENTRY_DSN"] != OnErrorFail {
+		t.Error("Merge() mutated the root on_error")
+	}
+}
+
+func TestMerge_Required(t *testing.T) {
+	root := &RootConfig{
+		Environments: EnvironmentConfig{Default: "dev", Available: []string{"dev"}},
+		Required:     []string{"DATABASE_URL"},
+	}
+	ws := &WorkspaceConfig{Required: []string{"STRIPE_SECRET_KEY", "DATABASE_URL"}}
+
+	merged, err := Merge(root, ws, "dev")
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if want := []string{"DATABASE_URL", "STRIPE_SECRET_KEY"}; !reflect.DeepEqual(merged.Required, want) {
+		t.Errorf("Required = %v, want %v", merged.Required, want)
+	}
+
+	missing := merged.MissingRequired(map[string]string{"DATABASE_URL": "", "OTHER": "x"})
+	if want := []string{"STRIPE_SECRET_KEY"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("MissingRequired() = %v, want %v", missing, want)
+	}
+}
+
+func TestMerge_HostEnv(t *testing.T) {
+	root := &RootConfig{
+		Environments:   EnvironmentConfig{Default: "dev", Available: []string{"dev"}},
+		EnvPassthrough: []string{"PATH", "HOME"},
+		EnvBlock:       []string{"AWS_*"},
+	}
+	ws := &WorkspaceConfig{EnvPassthrough: []string{"NODE_*", "PATH"}}
+
+	merged, err := Merge(root, ws, "dev")
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if want := []string{"HOME", "NODE_*", "PATH"}; !reflect.DeepEqual(merged.EnvPassthrough, want) {
+		t.Errorf("EnvPassthrough = %v, want %v", merged.EnvPassthrough, want)
+	}
+	if want := []string{"AWS_*"}; !reflect.DeepEqual(merged.EnvBlock, want) {
+		t.Errorf("EnvBlock = %v, want %v", merged.EnvBlock, want)
+	}
+}
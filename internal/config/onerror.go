@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Policies for [resolver] on_error and the [on_error] overrides, deciding
+// what happens when a secret's Vault path cannot be read.
+const (
+	// OnErrorFail aborts the whole resolution. It is the default.
+	OnErrorFail = "fail"
+	// OnErrorWarn logs the error and leaves the variable unset.
+	OnErrorWarn = "warn"
+	// OnErrorDefault falls back to the variable's default value, with a
+	// warning, and fails when it has none.
+	OnErrorDefault = "default"
+)
+
+var onErrorPolicies = []string{OnErrorFail, OnErrorWarn, OnErrorDefault}
+
+// SkipOnError reports whether envVar may be left out of the resolution when
+// its path cannot be read, instead of failing it: its policy is "warn", or
+// "default" and a default value exists.
+func (m *MergedConfig) SkipOnError(envVar string) bool {
+	policy, ok := m.OnError[envVar]
+	if !ok {
+		policy = m.Resolver.OnError
+	}
+
+	switch policy {
+	case OnErrorWarn:
+		return true
+	case OnErrorDefault:
+		_, ok := m.Defaults[envVar]
+		return ok
+	default:
+		return false
+	}
+}
+
+// validateOnError checks the policies of an [on_error] table.
+func validateOnError(overrides map[string]string) error {
+	names := make([]string, 0, len(overrides))
+	for k := range overrides {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !contains(onErrorPolicies, overrides[name]) {
+			return fmt.Errorf("on_error: %s has unknown policy %q (use %s)", name, overrides[name], strings.Join(onErrorPolicies, ", "))
+		}
+	}
+	return nil
+}
@@ -8,17 +8,77 @@ import (
 	toml "github.com/pelletier/go-toml/v2"
 )
 
-// LoadRootConfig parses a root vx.toml file at the given path.
+// LoadRootConfig parses a root vx.toml file at the given path. With
+// workspaces = "auto", the workspaces are discovered next to it.
 func LoadRootConfig(path string) (*RootConfig, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("reading root config %s: %w", path, err)
 	}
 
-	var cfg RootConfig
-	if err := toml.Unmarshal(data, &cfg); err != nil {
+	cfg, err := decodeRootConfig(data)
+	if err != nil {
 		return nil, fmt.Errorf("parsing root config %s: %w", path, err)
 	}
+	if err := checkVersion(path, cfg.Version); err != nil {
+		return nil, err
+	}
+
+	if cfg.AutoWorkspaces {
+		cfg.Workspaces, err = DiscoverWorkspaces(filepath.Dir(path))
+		if err != nil {
+			return nil, fmt.Errorf("root config %s: %w", path, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// ParseRootConfig parses the contents of a root vx.toml that did not come
+// from disk, such as a bootstrap bundle. With workspaces = "auto" there is
+// no directory to discover them in, so Workspaces is left empty.
+func ParseRootConfig(data []byte) (*RootConfig, error) {
+	cfg, err := decodeRootConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing root config: %w", err)
+	}
+	if err := checkVersion("root config", cfg.Version); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// decodeRootConfig unmarshals a root vx.toml, whose workspaces is either an
+// array of paths or "auto".
+func decodeRootConfig(data []byte) (*RootConfig, error) {
+	var doc struct {
+		RootConfig
+		Workspaces any `toml:"workspaces"`
+	}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	cfg := doc.RootConfig
+	switch ws := doc.Workspaces.(type) {
+	case nil:
+	case string:
+		if ws != WorkspacesAuto {
+			return nil, fmt.Errorf("workspaces must be an array of paths or %q, got %q", WorkspacesAuto, ws)
+		}
+		cfg.AutoWorkspaces = true
+	case []any:
+		for _, v := range ws {
+			p, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("workspaces must be an array of paths, got %v", v)
+			}
+			cfg.Workspaces = append(cfg.Workspaces, p)
+		}
+	default:
+		return nil, fmt.Errorf("workspaces must be an array of paths or %q, got %v", WorkspacesAuto, ws)
+	}
 
 	return &cfg, nil
 }
@@ -34,6 +94,23 @@ func LoadWorkspaceConfig(path string) (*WorkspaceConfig, error) {
 	if err := toml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("parsing workspace config %s: %w", path, err)
 	}
+	if err := checkVersion(path, cfg.Version); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// ParseWorkspaceConfig parses the contents of a workspace vx.toml that did
+// not come from disk, such as a file at another git revision.
+func ParseWorkspaceConfig(data []byte) (*WorkspaceConfig, error) {
+	var cfg WorkspaceConfig
+	if err := toml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing workspace config: %w", err)
+	}
+	if err := checkVersion("workspace config", cfg.Version); err != nil {
+		return nil, err
+	}
 
 	return &cfg, nil
 }
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadRootConfig(t *testing.T) {
@@ -63,6 +67,166 @@ func TestLoadRootConfig_InvalidTOML(t *testing.T) {
 	}
 }
 
+func TestLoadRootConfig_TUI(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vx.toml")
+	writeTestFile(t, path, `
+[vault]
+address = "https://vault.example.com"
+auth_method = "oidc"
+
+[environments]
+default = "dev"
+available = ["dev", "production"]
+
+[tui]
+protected_environments = []
+
+[tui.accents]
+production = "#DC2626"
+`)
+
+	cfg, err := LoadRootConfig(path)
+	if err != nil {
+		t.Fatalf("LoadRootConfig() error = %v", err)
+	}
+
+	if cfg.TUI.Accents["production"] != "#DC2626" {
+		t.Errorf("TUI.Accents[production] = %q, want %q", cfg.TUI.Accents["production"], "#DC2626")
+	}
+	// An explicit empty list must stay distinguishable from an unset one.
+	if cfg.TUI.ProtectedEnvironments == nil || len(cfg.TUI.ProtectedEnvironments) != 0 {
+		t.Errorf("TUI.ProtectedEnvironments = %#v, want empty non-nil slice", cfg.TUI.ProtectedEnvironments)
+	}
+}
+
+func TestLoadRootConfig_AuthMountAndRoles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vx.toml")
+	writeTestFile(t, path, `
+[vault]
+address = "https://vault.example.com"
+auth_method = "oidc"
+auth_role = "developer"
+auth_mount = "auth/okta"
+
+[vault.auth_roles]
+production = "prod-reader"
+
+[environments]
+default = "dev"
+available = ["dev", "production"]
+`)
+
+	cfg, err := LoadRootConfig(path)
+	if err != nil {
+		t.Fatalf("LoadRootConfig() error = %v", err)
+	}
+
+	if got := cfg.Vault.OIDCMount(); got != "okta" {
+		t.Errorf("OIDCMount() = %q, want %q", got, "okta")
+	}
+	if got := cfg.Vault.RoleFor("production"); got != "prod-reader" {
+		t.Errorf("RoleFor(production) = %q, want %q", got, "prod-reader")
+	}
+	if got := cfg.Vault.RoleFor("dev"); got != "developer" {
+		t.Errorf("RoleFor(dev) = %q, want %q", got, "developer")
+	}
+}
+
+func TestParseRootConfig_ResolverTimeouts(t *testing.T) {
+	cfg, err := ParseRootConfig([]byte(`
+[resolver]
+timeout = "1m"
+path_timeout = "10s"
+requests_per_second = 25.5
+burst = 4
+
+[resolver.retry]
+max_attempts = 3
+backoff = "250ms"
+`))
+	if err != nil {
+		t.Fatalf("ParseRootConfig() error = %v", err)
+	}
+
+	if got := time.Duration(cfg.Resolver.Timeout); got != time.Minute {
+		t.Errorf("Timeout = %v, want 1m", got)
+	}
+	if got := time.Duration(cfg.Resolver.PathTimeout); got != 10*time.Second {
+		t.Errorf("PathTimeout = %v, want 10s", got)
+	}
+	if cfg.Resolver.RequestsPerSecond != 25.5 || cfg.Resolver.Burst != 4 {
+		t.Errorf("rate limit = %v/%d, want 25.5/4", cfg.Resolver.RequestsPerSecond, cfg.Resolver.Burst)
+	}
+	if r := cfg.Resolver.Retry; r.MaxAttempts != 3 || time.Duration(r.Backoff) != 250*time.Millisecond {
+		t.Errorf("Retry = %+v, want 3 attempts with a 250ms backoff", r)
+	}
+}
+
+func TestParseWorkspaceConfig(t *testing.T) {
+	cfg, err := ParseWorkspaceConfig([]byte(`
+[secrets]
+DATABASE_URL = "${env}/db/url"
+`))
+	if err != nil {
+		t.Fatalf("ParseWorkspaceConfig() error = %v", err)
+	}
+	if got := cfg.Secrets["DATABASE_URL"]; got != "${env}/db/url" {
+		t.Errorf("Secrets[DATABASE_URL] = %q", got)
+	}
+
+	if _, err := ParseWorkspaceConfig([]byte("[secrets")); err == nil {
+		t.Error("ParseWorkspaceConfig() expected error for invalid TOML")
+	}
+}
+
+func TestParseRootConfig_CacheTTL(t *testing.T) {
+	cfg, err := ParseRootConfig([]byte(`
+[cache_ttl]
+DB_TOKEN = "0"
+APP_CONFIG = "1h"
+`))
+	if err != nil {
+		t.Fatalf("ParseRootConfig() error = %v", err)
+	}
+
+	if got, ok := cfg.CacheTTL["DB_TOKEN"]; !ok || got != 0 {
+		t.Errorf("CacheTTL[DB_TOKEN] = %v, %v; want 0", time.Duration(got), ok)
+	}
+	if got := time.Duration(cfg.CacheTTL["APP_CONFIG"]); got != time.Hour {
+		t.Errorf("CacheTTL[APP_CONFIG] = %v, want 1h", got)
+	}
+}
+
+func TestParseRootConfig(t *testing.T) {
+	cfg, err := ParseRootConfig([]byte("[vault]\naddress = \"https://vault.example.com\"\n"))
+	if err != nil {
+		t.Fatalf("ParseRootConfig() error = %v", err)
+	}
+	if cfg.Vault.Address != "https://vault.example.com" {
+		t.Errorf("Vault.Address = %q", cfg.Vault.Address)
+	}
+
+	if _, err := ParseRootConfig([]byte("[vault")); err == nil {
+		t.Error("ParseRootConfig() expected error for invalid TOML")
+	}
+}
+
+func TestParseRootConfig_Version(t *testing.T) {
+	cfg, err := ParseRootConfig([]byte("version = 1\n"))
+	if err != nil {
+		t.Fatalf("ParseRootConfig() error = %v", err)
+	}
+	if cfg.Version != CurrentVersion {
+		t.Errorf("Version = %d, want %d", cfg.Version, CurrentVersion)
+	}
+
+	if _, err := ParseRootConfig([]byte(fmt.Sprintf("version = %d\n", CurrentVersion+1))); err == nil {
+		t.Error("ParseRootConfig() accepted a newer format version")
+	}
+}
+
 func TestLoadWorkspaceConfig(t *testing.T) {
 	path := filepath.Join("testdata", "workspace", "vx.toml")
 
@@ -134,3 +298,40 @@ func TestFindRootConfig_InCurrentDir(t *testing.T) {
 		t.Errorf("FindRootConfig() = %q, want %q", found, expected)
 	}
 }
+
+func TestLoadRootConfig_AutoWorkspaces(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vx.toml")
+	writeTestFile(t, path, `
+workspaces = "auto"
+
+[vault]
+address = "https://vault.example.com"
+auth_method = "oidc"
+
+[environments]
+default = "dev"
+available = ["dev"]
+`)
+	if err := os.MkdirAll(filepath.Join(dir, "api"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(dir, "go.work"), "use ./api\n")
+	writeTestFile(t, filepath.Join(dir, "api", "vx.toml"), "")
+
+	cfg, err := LoadRootConfig(path)
+	if err != nil {
+		t.Fatalf("LoadRootConfig() error = %v", err)
+	}
+	if !cfg.AutoWorkspaces {
+		t.Error("AutoWorkspaces = false, want true")
+	}
+	if len(cfg.Workspaces) != 1 || cfg.Workspaces[0] != "api/vx.toml" {
+		t.Errorf("Workspaces = %v, want [api/vx.toml]", cfg.Workspaces)
+	}
+
+	writeTestFile(t, path, `workspaces = "all"`)
+	if _, err := LoadRootConfig(path); err == nil || !strings.Contains(err.Error(), `"auto"`) {
+		t.Errorf("LoadRootConfig() error = %v, want one naming \"auto\"", err)
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// mergeRequired combines the required names of root and workspace into a
+// new sorted list without duplicates.
+func mergeRequired(rootRequired []string, workspace *WorkspaceConfig) []string {
+	if workspace == nil {
+		return unionSorted(rootRequired)
+	}
+	return unionSorted(rootRequired, workspace.Required)
+}
+
+// unionSorted returns the strings of lists as a new sorted list without
+// duplicates.
+func unionSorted(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, list := range lists {
+		for _, s := range list {
+			if !seen[s] {
+				seen[s] = true
+				result = append(result, s)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// MissingRequired returns the required names that have no value in values,
+// in order.
+func (m *MergedConfig) MissingRequired(values map[string]string) []string {
+	var missing []string
+	for _, name := range m.Required {
+		if _, ok := values[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// validateRequired checks a required list for empty names.
+func validateRequired(names []string) error {
+	for _, n := range names {
+		if n == "" {
+			return fmt.Errorf("required: empty variable name")
+		}
+	}
+	return nil
+}
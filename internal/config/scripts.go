@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Scripts returns the [scripts] of root with those of workspace on top, so
+// a workspace can add scripts or replace the root's under the same name.
+// workspace may be nil. Neither input is mutated.
+func Scripts(root *RootConfig, workspace *WorkspaceConfig) map[string]string {
+	scripts := copyStringMap(root.Scripts)
+	if workspace != nil {
+		for name, line := range workspace.Scripts {
+			scripts[name] = line
+		}
+	}
+	return scripts
+}
+
+// validateScripts checks that every script of a [scripts] table has a
+// command.
+func validateScripts(scripts map[string]string) error {
+	names := make([]string, 0, len(scripts))
+	for name := range scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if scripts[name] == "" {
+			return fmt.Errorf("scripts: %s has no command", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScripts(t *testing.T) {
+	root := &RootConfig{Scripts: map[string]string{"dev": "bun dev", "lint": "bun lint"}}
+	ws := &WorkspaceConfig{Scripts: map[string]string{"dev": "bun dev --port 3001", "seed": "bun seed"}}
+
+	want := map[string]string{"dev": "bun dev --port 3001", "lint": "bun lint", "seed": "bun seed"}
+	if got := Scripts(root, ws); !reflect.DeepEqual(got, want) {
+		t.Errorf("Scripts() = %v, want %v", got, want)
+	}
+	if got := Scripts(root, nil); !reflect.DeepEqual(got, root.Scripts) {
+		t.Errorf("Scripts() without a workspace = %v, want the root's", got)
+	}
+	if root.Scripts["dev"] != "bun dev" {
+		t.Error("Scripts() mutated the root scripts")
+	}
+
+	if err := ValidateWorkspace(&WorkspaceConfig{Scripts: map[string]string{"dev": ""}}); err == nil {
+		t.Error("ValidateWorkspace() accepted a script without a command")
+	}
+}
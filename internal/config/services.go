@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ServiceOrder returns the names of targets and every service they depend
+// on, each after its dependencies. Services with no ordering between them
+// come in name order. Empty targets means every service.
+func ServiceOrder(services map[string]ServiceConfig, targets []string) ([]string, error) {
+	if len(targets) == 0 {
+		for name := range services {
+			targets = append(targets, name)
+		}
+	}
+	targets = append([]string(nil), targets...)
+	sort.Strings(targets)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(services))
+	var order []string
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		svc, ok := services[name]
+		if !ok {
+			if len(path) > 0 {
+				return fmt.Errorf("service %q depends on unknown service %q", path[len(path)-1], name)
+			}
+			return fmt.Errorf("unknown service %q", name)
+		}
+
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := append(path[indexOf(path, name):], name)
+			return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+
+		deps := append([]string(nil), svc.DependsOn...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range targets {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// validateServices checks each service's fields and that dependencies
+// exist and have no cycles.
+func validateServices(services map[string]ServiceConfig, envs []string) error {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		svc := services[name]
+		if strings.TrimSpace(svc.Command) == "" {
+			return fmt.Errorf("service %q: command is required", name)
+		}
+		if svc.Env != "" && !contains(envs, svc.Env) {
+			return fmt.Errorf("service %q: unknown environment %q", name, svc.Env)
+		}
+	}
+
+	_, err := ServiceOrder(services, nil)
+	return err
+}
+
+func indexOf(items []string, target string) int {
+	for i, item := range items {
+		if item == target {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestServiceOrder(t *testing.T) {
+	services := map[string]ServiceConfig{
+		"web":    {Command: "bun dev", DependsOn: []string{"api"}},
+		"api":    {Command: "go run ./cmd/api", DependsOn: []string{"db", "queue"}},
+		"db":     {Command: "postgres"},
+		"queue":  {Command: "nats-server"},
+		"worker": {Command: "go run ./cmd/worker", DependsOn: []string{"queue"}},
+	}
+
+	tests := []struct {
+		name    string
+		targets []string
+		want    []string
+	}{
+		{"all", nil, []string{"db", "queue", "api", "web", "worker"}},
+		{"subset pulls in dependencies", []string{"worker"}, []string{"queue", "worker"}},
+		{"shared dependency once", []string{"web", "worker"}, []string{"db", "queue", "api", "web", "worker"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ServiceOrder(services, tt.targets)
+			if err != nil {
+				t.Fatalf("ServiceOrder() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ServiceOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceOrder_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		services map[string]ServiceConfig
+		targets  []string
+		want     string
+	}{
+		{
+			name:     "cycle",
+			services: map[string]ServiceConfig{"a": {DependsOn: []string{"b"}}, "b": {DependsOn: []string{"a"}}},
+			want:     "dependency cycle: a -> b -> a",
+		},
+		{
+			name:     "unknown dependency",
+			services: map[string]ServiceConfig{"a": {DependsOn: []string{"db"}}},
+			want:     `service "a" depends on unknown service "db"`,
+		},
+		{
+			name:     "unknown target",
+			services: map[string]ServiceConfig{"a": {}},
+			targets:  []string{"b"},
+			want:     `unknown service "b"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ServiceOrder(tt.services, tt.targets)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ServiceOrder() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_Services(t *testing.T) {
+	cfg := &RootConfig{
+		Vault: VaultConfig{Address: "https://vault.example.com", AuthMethod: "oidc"},
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev"},
+		},
+		Services: map[string]ServiceConfig{"api": {Command: ""}},
+	}
+
+	if err := Validate(cfg); err == nil {
+		t.Fatal("Validate() expected error for missing command")
+	}
+
+	cfg.Services = map[string]ServiceConfig{"api": {Command: "make run", Env: "qa"}}
+	if err := Validate(cfg); err == nil {
+		t.Fatal("Validate() expected error for unknown environment")
+	}
+
+	cfg.Services = map[string]ServiceConfig{"api": {Command: "make run", DependsOn: []string{"api"}}}
+	if err := Validate(cfg); err == nil {
+		t.Fatal("Validate() expected error for a self-dependency")
+	}
+
+	cfg.Services = map[string]ServiceConfig{"api": {Command: "make run", Env: "dev"}}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
@@ -2,11 +2,56 @@ package config
 
 // RootConfig represents the top-level vx.toml configuration file.
 type RootConfig struct {
+	// Version is the format version the file was written for (see
+	// CurrentVersion). Files without one predate versioning and read as 0.
+	Version      int               `toml:"version"`
 	Vault        VaultConfig       `toml:"vault"`
 	Environments EnvironmentConfig `toml:"environments"`
 	Workspaces   []string          `toml:"workspaces"`
 	Secrets      map[string]string `toml:"secrets"`
 	Defaults     map[string]any    `toml:"defaults"`
+	TUI          TUIConfig         `toml:"tui"`
+	Clipboard    ClipboardConfig   `toml:"clipboard"`
+	Resolver     ResolverConfig    `toml:"resolver"`
+	// Cache enables the encrypted on-disk cache of vx exec.
+	Cache CacheConfig `toml:"cache"`
+	// CacheTTL overrides how long resolved values may be cached, keyed by
+	// env var name. "0" means always read fresh.
+	CacheTTL map[string]Duration `toml:"cache_ttl"`
+	// OnError overrides [resolver] on_error for individual secrets, keyed by
+	// env var name.
+	OnError map[string]string `toml:"on_error"`
+	// Required lists the variables vx exec must have a value for before it
+	// starts the command, in every workspace.
+	Required []string `toml:"required"`
+	// EnvPassthrough, when set, limits the host environment vx exec passes
+	// on to the command to the variables matching these name patterns
+	// (path.Match syntax). Resolved secrets and defaults are always set.
+	EnvPassthrough []string `toml:"env_passthrough"`
+	// EnvBlock lists name patterns of host variables vx exec never passes on
+	// to the command.
+	EnvBlock []string `toml:"env_block"`
+	// ExportRuntime controls vx exec --export-runtime.
+	ExportRuntime ExportRuntimeConfig `toml:"export_runtime"`
+	// BreakGlass configures the encrypted fallback secrets file.
+	BreakGlass BreakGlassConfig `toml:"break_glass"`
+	// Services are the commands vx up starts, keyed by name.
+	Services map[string]ServiceConfig `toml:"services"`
+	// Scripts are command lines vx run runs with the secrets injected,
+	// keyed by name.
+	Scripts map[string]string `toml:"scripts"`
+	// Exports customizes the output formats that write values under their
+	// names, keyed by format (see ExportFormats).
+	Exports map[string]ExportConfig `toml:"exports"`
+	// Vaults are further Vault connections, keyed by name, for secrets that
+	// live in other clusters. A mapping reads from one with a "vault://"
+	// prefix (see VaultScheme), and a workspace can default to one.
+	Vaults map[string]VaultConfig `toml:"vaults"`
+
+	// AutoWorkspaces is set when the file has workspaces = "auto" (see
+	// WorkspacesAuto) instead of an array. LoadRootConfig then fills
+	// Workspaces with the ones DiscoverWorkspaces finds.
+	AutoWorkspaces bool `toml:"-"`
 }
 
 // VaultConfig holds Vault server connection settings.
@@ -15,25 +60,208 @@ type VaultConfig struct {
 	AuthMethod string `toml:"auth_method"`
 	AuthRole   string `toml:"auth_role"`
 	BasePath   string `toml:"base_path"`
+	// Namespace is the Vault Enterprise namespace every request is sent to,
+	// e.g. "admin/team-a". Empty leaves VAULT_NAMESPACE, if set, in effect.
+	Namespace string `toml:"namespace"`
+	// KVVersion is the KV engine version of BasePath, 1 or 2. Unset, it is
+	// detected from Vault on first use.
+	KVVersion int `toml:"kv_version"`
+	// AuthMount is where the OIDC or Kubernetes auth method is mounted,
+	// e.g. "okta" or "auth/okta". Defaults to "oidc" or "kubernetes".
+	AuthMount string `toml:"auth_mount"`
+	// AuthRoles overrides AuthRole per environment, keyed by environment
+	// name.
+	AuthRoles map[string]string `toml:"auth_roles"`
+	// AuthListenPorts are the local ports tried in order for the OIDC
+	// callback; the first free one is used. Defaults to 8250. The redirect
+	// URI of each must be allowed by the OIDC role.
+	AuthListenPorts []int `toml:"auth_listen_ports"`
+	// TokenFile is read for a token when AuthMethod is "token" and
+	// VAULT_TOKEN is not set, e.g. a vault-agent sink file.
+	TokenFile string `toml:"token_file"`
+	// ServiceAccountTokenFile is the JWT used when AuthMethod is
+	// "kubernetes". Defaults to the token Kubernetes mounts into every pod.
+	ServiceAccountTokenFile string `toml:"service_account_token_file"`
+	// TraceRequests tags every Vault request with a per-invocation
+	// correlation ID and any W3C traceparent found in the environment.
+	TraceRequests bool `toml:"trace_requests"`
+	// Events makes the daemon subscribe to Vault's event stream (Vault
+	// 1.16+) and record changes to secrets under BasePath.
+	Events bool `toml:"events"`
+	// TokenStore is where the token from a login is kept: "file" (the
+	// default, ~/.vx/token) or "keychain" for the OS keychain.
+	TokenStore string `toml:"token_store"`
 }
 
 // EnvironmentConfig defines available environments and the default selection.
 type EnvironmentConfig struct {
 	Default   string   `toml:"default"`
 	Available []string `toml:"available"`
+	// Map overrides what ${env} becomes in secret paths, keyed by
+	// environment name, e.g. production = "prod" for a Vault tree laid out
+	// under "prod/".
+	Map map[string]string `toml:"map"`
+}
+
+// TUIConfig holds settings for the interactive terminal UI.
+type TUIConfig struct {
+	// Accents maps environment names to a hex color (e.g. "#DC2626") used
+	// for the header badge, focused pane border, and selection highlight.
+	Accents map[string]string `toml:"accents"`
+	// ProtectedEnvironments lists environments in which mapping writes and
+	// secret reveals require typing the environment name to confirm. When
+	// unset it defaults to ["production"]; set it to [] to disable.
+	ProtectedEnvironments []string `toml:"protected_environments"`
+}
+
+// ClipboardConfig controls how copied secret values are handled.
+type ClipboardConfig struct {
+	// ClearAfter empties the clipboard this long after a secret is copied,
+	// unless something else has been copied since. Zero disables clearing.
+	ClearAfter Duration `toml:"clear_after"`
+	// FileDeleteAfter deletes a value the TUI wrote to a temp file this
+	// long after writing it. Zero means 5 minutes.
+	FileDeleteAfter Duration `toml:"file_delete_after"`
+}
+
+// CacheConfig controls the on-disk cache of resolved values that lets
+// repeated vx exec runs start without reading Vault.
+type CacheConfig struct {
+	// Enabled turns the cache on. It is off by default.
+	Enabled bool `toml:"enabled"`
+	// TTL is how long cached values are used. Zero means 10 minutes.
+	TTL Duration `toml:"ttl"`
+}
+
+// ResolverConfig bounds how long secret resolution may take and how fast
+// it may query Vault.
+type ResolverConfig struct {
+	// Timeout caps a whole resolution (every path of a vx exec, list, or
+	// get). Zero means no overall limit.
+	Timeout Duration `toml:"timeout"`
+	// PathTimeout caps each individual Vault read, so one hung request
+	// fails fast instead of blocking the rest. Zero uses the built-in
+	// default.
+	PathTimeout Duration `toml:"path_timeout"`
+	// RequestsPerSecond caps how fast vx sends requests to Vault, so cold
+	// runs across many workspaces stay under Vault's rate limit quotas.
+	// Zero means no limit.
+	RequestsPerSecond float64 `toml:"requests_per_second"`
+	// Burst is how many requests may go out at once before the rate limit
+	// applies. Defaults to 1.
+	Burst int `toml:"burst"`
+	// OnError is what happens when a secret cannot be read: "fail" (the
+	// default), "warn", or "default". [on_error] overrides it per secret.
+	OnError string `toml:"on_error"`
+	// Retry reads a path again after a transient failure.
+	Retry RetryConfig `toml:"retry"`
+}
+
+// RetryConfig controls how often a Vault read that failed with a 5xx or 429
+// response, or could not connect, is tried again.
+type RetryConfig struct {
+	// MaxAttempts is how many times a path is read before its error is
+	// reported, counting the first read. Zero or 1 disables retries; the
+	// Vault API client's own retries are always off.
+	MaxAttempts int `toml:"max_attempts"`
+	// Backoff is the wait before the first retry, doubled for each one
+	// after it. Zero means 200ms.
+	Backoff Duration `toml:"backoff"`
+}
+
+// ExportRuntimeConfig controls which values vx exec --export-runtime may
+// write into a generated JavaScript/TypeScript module.
+type ExportRuntimeConfig struct {
+	// Deny lists name patterns (path.Match syntax, case-insensitive) that are
+	// never written to the module. When unset, a built-in list covering
+	// names like *SECRET*, *TOKEN*, and *_KEY is used; set it to [] to
+	// disable.
+	Deny []string `toml:"deny"`
+}
+
+// ExportConfig customizes one export format.
+type ExportConfig struct {
+	// Rename maps a mapped name to the name this export writes it under,
+	// e.g. DATABASE_URL = "DB_URL" for a consumer that expects DB_URL.
+	Rename map[string]string `toml:"rename"`
+}
+
+// BreakGlassConfig describes the encrypted file that can stand in for Vault
+// with vx exec --break-glass.
+type BreakGlassConfig struct {
+	// File is relative to the root vx.toml. Defaults to "vx.secrets.age";
+	// files ending in ".gpg" or ".asc" are encrypted with gpg instead.
+	File string `toml:"file"`
+	// Recipients are the age public keys (or gpg key IDs) that
+	// "vx break-glass seal" encrypts the file to.
+	Recipients []string `toml:"recipients"`
+}
+
+// ServiceConfig describes one command started by vx up.
+type ServiceConfig struct {
+	// Command is run through the shell (sh -c, or cmd /C on Windows).
+	Command string `toml:"command"`
+	// Workspace scopes the service's secrets; empty means the root
+	// vx.toml's secrets only.
+	Workspace string `toml:"workspace"`
+	// Env overrides the environment selected by --env or the default.
+	Env string `toml:"env"`
+	// Dir is the working directory, relative to the root vx.toml. Defaults
+	// to the workspace's directory, or the root for root-only services.
+	Dir string `toml:"dir"`
+	// DependsOn names services that must be started first.
+	DependsOn []string `toml:"depends_on"`
 }
 
 // WorkspaceConfig represents a workspace-level vx.toml with only secrets and defaults.
 type WorkspaceConfig struct {
-	Secrets  map[string]string `toml:"secrets"`
-	Defaults map[string]any    `toml:"defaults"`
+	Version  int                 `toml:"version"`
+	Secrets  map[string]string   `toml:"secrets"`
+	Defaults map[string]any      `toml:"defaults"`
+	CacheTTL map[string]Duration `toml:"cache_ttl"`
+	OnError  map[string]string   `toml:"on_error"`
+	// Required adds to the variables the root config requires.
+	Required []string `toml:"required"`
+	// EnvPassthrough and EnvBlock add to the root's patterns.
+	EnvPassthrough []string `toml:"env_passthrough"`
+	EnvBlock       []string `toml:"env_block"`
+	// Scripts adds to the root's [scripts], replacing those of the same
+	// name.
+	Scripts map[string]string `toml:"scripts"`
+	// Vault names the [vaults] connection the workspace's mappings read from
+	// unless they name one themselves. Empty means [vault].
+	Vault string `toml:"vault"`
 }
 
 // MergedConfig is the fully resolved configuration after merging root and workspace
 // configs for a specific environment.
 type MergedConfig struct {
 	Vault       VaultConfig
+	Resolver    ResolverConfig
+	Cache       CacheConfig
 	Environment string
-	Secrets     map[string]string
-	Defaults    map[string]string
+	// PathEnv is what ${env} is replaced with in secret paths (see
+	// EnvironmentConfig.Map).
+	PathEnv  string
+	Secrets  map[string]string
+	Defaults map[string]string
+	// CacheTTL holds the cache_ttl overrides of root and workspace, keyed
+	// by env var name.
+	CacheTTL map[string]Duration
+	// OnError holds the on_error overrides of root and workspace, keyed by
+	// env var name.
+	OnError map[string]string
+	// Required lists the variables of root and workspace that must have a
+	// value, sorted.
+	Required []string
+	// EnvPassthrough and EnvBlock hold the host environment patterns of root
+	// and workspace, sorted.
+	EnvPassthrough []string
+	EnvBlock       []string
+	// Warnings lists non-fatal problems found while merging, such as default
+	// values that could not be converted to strings.
+	Warnings []string
+	// Vaults are the named connections of the root config. Secrets of a
+	// workspace with a vault carry its name (see SplitVault).
+	Vaults map[string]VaultConfig
 }
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	toml "github.com/pelletier/go-toml/v2"
+)
+
+// UserConfigFile is the name of the per-user settings file in ~/.vx.
+const UserConfigFile = "config.toml"
+
+// UserConfig holds settings of the user rather than of a repository, read
+// from ~/.vx/config.toml. Anyone who can push to a repository can change
+// its vx.toml, so settings that let vx run programs live here instead.
+type UserConfig struct {
+	// Commands lists the programs cmd:// mappings may run, such as "op" or
+	// "pass". Command mappings are disabled while it is empty.
+	Commands []string `toml:"commands"`
+}
+
+// LoadUserConfig reads the user settings at path. A missing file yields
+// the defaults.
+func LoadUserConfig(path string) (*UserConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &UserConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading user config %s: %w", path, err)
+	}
+
+	var cfg UserConfig
+	if err := toml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing user config %s: %w", path, err)
+	}
+	for _, c := range cfg.Commands {
+		if c == "" || strings.ContainsAny(c, " \t") {
+			return nil, fmt.Errorf("user config %s: invalid command %q (use the program name, without arguments)", path, c)
+		}
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadUserConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, UserConfigFile)
+
+	cfg, err := LoadUserConfig(path)
+	if err != nil || len(cfg.Commands) != 0 {
+		t.Fatalf("LoadUserConfig() without a file = %+v, %v; want the defaults", cfg, err)
+	}
+
+	os.WriteFile(path, []byte(`commands = ["op", "pass"]`), 0o600)
+	cfg, err = LoadUserConfig(path)
+	if err != nil {
+		t.Fatalf("LoadUserConfig() error = %v", err)
+	}
+	if !slices.Equal(cfg.Commands, []string{"op", "pass"}) {
+		t.Errorf("Commands = %v, want [op pass]", cfg.Commands)
+	}
+
+	for _, bad := range []string{`commands = [""]`, `commands = ["op read"]`} {
+		os.WriteFile(path, []byte(bad), 0o600)
+		if _, err := LoadUserConfig(path); err == nil {
+			t.Errorf("LoadUserConfig() accepted %s", bad)
+		}
+	}
+}
@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -17,6 +19,66 @@ func Validate(cfg *RootConfig) error {
 		return fmt.Errorf("environments config: %w", err)
 	}
 
+	if err := validateVaults(cfg); err != nil {
+		return err
+	}
+
+	for env := range cfg.Vault.AuthRoles {
+		if !contains(cfg.Environments.Available, env) {
+			return fmt.Errorf("vault config: auth_roles has unknown environment %q", env)
+		}
+	}
+
+	if err := validateTUI(cfg.TUI); err != nil {
+		return fmt.Errorf("tui config: %w", err)
+	}
+
+	if cfg.Cache.TTL < 0 {
+		return fmt.Errorf("cache config: ttl must not be negative")
+	}
+
+	if cfg.Resolver.RequestsPerSecond < 0 {
+		return fmt.Errorf("resolver config: requests_per_second must not be negative")
+	}
+	if cfg.Resolver.Burst < 0 {
+		return fmt.Errorf("resolver config: burst must not be negative")
+	}
+	if cfg.Resolver.Retry.MaxAttempts < 0 {
+		return fmt.Errorf("resolver config: retry.max_attempts must not be negative")
+	}
+	if cfg.Resolver.Retry.Backoff < 0 {
+		return fmt.Errorf("resolver config: retry.backoff must not be negative")
+	}
+	if p := cfg.Resolver.OnError; p != "" && !contains(onErrorPolicies, p) {
+		return fmt.Errorf("resolver config: unknown on_error policy %q (use %s)", p, strings.Join(onErrorPolicies, ", "))
+	}
+	if err := validateOnError(cfg.OnError); err != nil {
+		return err
+	}
+	if err := validateRequired(cfg.Required); err != nil {
+		return err
+	}
+	if err := validateScripts(cfg.Scripts); err != nil {
+		return err
+	}
+	if err := validateHostEnv(cfg.EnvPassthrough, cfg.EnvBlock); err != nil {
+		return err
+	}
+
+	if err := validateServices(cfg.Services, cfg.Environments.Available); err != nil {
+		return fmt.Errorf("services config: %w", err)
+	}
+
+	if err := validateExports(cfg.Exports); err != nil {
+		return fmt.Errorf("exports config: %w", err)
+	}
+
+	for _, pattern := range cfg.ExportRuntime.Deny {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("export_runtime config: invalid deny pattern %q", pattern)
+		}
+	}
+
 	return nil
 }
 
@@ -35,7 +97,16 @@ func ValidateWorkspace(cfg *WorkspaceConfig) error {
 	if cfg == nil {
 		return fmt.Errorf("workspace config is nil")
 	}
-	return nil
+	if err := validateOnError(cfg.OnError); err != nil {
+		return err
+	}
+	if err := validateRequired(cfg.Required); err != nil {
+		return err
+	}
+	if err := validateScripts(cfg.Scripts); err != nil {
+		return err
+	}
+	return validateHostEnv(cfg.EnvPassthrough, cfg.EnvBlock)
 }
 
 func validateVault(v VaultConfig) error {
@@ -45,6 +116,22 @@ func validateVault(v VaultConfig) error {
 	if v.AuthMethod == "" {
 		return fmt.Errorf("auth_method is required")
 	}
+	if v.AuthMethod == "kubernetes" && v.AuthRole == "" && len(v.AuthRoles) == 0 {
+		return fmt.Errorf("auth_method \"kubernetes\" requires auth_role or auth_roles")
+	}
+	for _, port := range v.AuthListenPorts {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("auth_listen_ports must be between 1 and 65535, got %d", port)
+		}
+	}
+	if v.KVVersion != 0 && v.KVVersion != 1 && v.KVVersion != 2 {
+		return fmt.Errorf("kv_version must be 1 or 2, got %d", v.KVVersion)
+	}
+	switch v.TokenStore {
+	case "", "file", "keychain":
+	default:
+		return fmt.Errorf("token_store must be \"file\" or \"keychain\", got %q", v.TokenStore)
+	}
 	return nil
 }
 
@@ -65,9 +152,50 @@ func validateEnvironments(e EnvironmentConfig) error {
 		)
 	}
 
+	envs := make([]string, 0, len(e.Map))
+	for env := range e.Map {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+
+	for _, env := range envs {
+		if !contains(e.Available, env) {
+			return fmt.Errorf("environments.map: %q is not in available environments", env)
+		}
+		seg := e.Map[env]
+		if strings.Trim(seg, "/") == "" || strings.Contains(seg, "${") {
+			return fmt.Errorf("environments.map: invalid path segment %q for %s", seg, env)
+		}
+	}
+
 	return nil
 }
 
+func validateTUI(t TUIConfig) error {
+	for env, color := range t.Accents {
+		if !isHexColor(color) {
+			return fmt.Errorf("accent for %q must be a hex color like \"#DC2626\", got %q", env, color)
+		}
+	}
+	return nil
+}
+
+// isHexColor reports whether s is a "#RGB" or "#RRGGBB" color string.
+func isHexColor(s string) bool {
+	if len(s) != 4 && len(s) != 7 {
+		return false
+	}
+	if s[0] != '#' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
 func validateWorkspacePaths(workspaces []string, rootDir string) error {
 	for _, ws := range workspaces {
 		absPath := filepath.Join(rootDir, ws)
@@ -3,6 +3,7 @@ package config
 import (
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestValidate_ValidConfig(t *testing.T) {
@@ -106,6 +107,305 @@ func TestValidate_DefaultNotInAvailable(t *testing.T) {
 	}
 }
 
+func TestValidate_TUIAccents(t *testing.T) {
+	tests := []struct {
+		color   string
+		wantErr bool
+	}{
+		{"#DC2626", false},
+		{"#f00", false},
+		{"red", true},
+		{"DC2626", true},
+		{"#DC26", true},
+		{"#GG2626", true},
+	}
+
+	for _, tt := range tests {
+		cfg := &RootConfig{
+			Vault: VaultConfig{
+				Address:    "https://vault.example.com",
+				AuthMethod: "oidc",
+			},
+			Environments: EnvironmentConfig{
+				Default:   "dev",
+				Available: []string{"dev"},
+			},
+			TUI: TUIConfig{
+				Accents: map[string]string{"production": tt.color},
+			},
+		}
+
+		err := Validate(cfg)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Validate() with accent %q error = %v, wantErr %v", tt.color, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate_AuthRolesUnknownEnv(t *testing.T) {
+	cfg := &RootConfig{
+		Vault: VaultConfig{
+			Address:    "https://vault.example.com",
+			AuthMethod: "oidc",
+			AuthRoles:  map[string]string{"prod": "prod-reader"},
+		},
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev", "production"},
+		},
+	}
+
+	if err := Validate(cfg); err == nil {
+		t.Fatal("Validate() expected error for auth_roles entry with unknown environment")
+	}
+
+	cfg.Vault.AuthRoles = map[string]string{"production": "prod-reader"}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
+
+func TestValidate_EnvironmentMap(t *testing.T) {
+	cfg := &RootConfig{
+		Vault: VaultConfig{
+			Address:    "https://vault.example.com",
+			AuthMethod: "oidc",
+		},
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev", "production"},
+			Map:       map[string]string{"production": "prod"},
+		},
+	}
+
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	for _, m := range []map[string]string{
+		{"prod": "prod"},
+		{"production": ""},
+		{"production": "/"},
+		{"production": "${env}"},
+	} {
+		cfg.Environments.Map = m
+		if err := Validate(cfg); err == nil {
+			t.Errorf("Validate() accepted environments.map %v", m)
+		}
+	}
+}
+
+func TestValidate_ExportRuntimeDenyPattern(t *testing.T) {
+	cfg := &RootConfig{
+		Vault: VaultConfig{Address: "https://vault.example.com", AuthMethod: "oidc"},
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev"},
+		},
+		ExportRuntime: ExportRuntimeConfig{Deny: []string{"*SECRET*", "[A-"}},
+	}
+
+	if err := Validate(cfg); err == nil {
+		t.Fatal("Validate() expected error for malformed deny pattern")
+	}
+
+	cfg.ExportRuntime.Deny = []string{"*SECRET*"}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
+
+func TestValidate_ResolverRateLimit(t *testing.T) {
+	cfg := &RootConfig{
+		Vault: VaultConfig{Address: "https://vault.example.com", AuthMethod: "oidc"},
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev"},
+		},
+		Resolver: ResolverConfig{RequestsPerSecond: -1},
+	}
+
+	if err := Validate(cfg); err == nil {
+		t.Fatal("Validate() expected error for negative requests_per_second")
+	}
+
+	cfg.Resolver = ResolverConfig{RequestsPerSecond: 20, Burst: -1}
+	if err := Validate(cfg); err == nil {
+		t.Fatal("Validate() expected error for negative burst")
+	}
+
+	cfg.Resolver.Burst = 5
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	cfg.Resolver.Retry = RetryConfig{MaxAttempts: -1}
+	if err := Validate(cfg); err == nil {
+		t.Fatal("Validate() expected error for negative retry.max_attempts")
+	}
+	cfg.Resolver.Retry = RetryConfig{MaxAttempts: 3, Backoff: Duration(-time.Second)}
+	if err := Validate(cfg); err == nil {
+		t.Fatal("Validate() expected error for negative retry.backoff")
+	}
+}
+
+func TestValidate_CacheTTL(t *testing.T) {
+	cfg := &RootConfig{
+		Vault: VaultConfig{Address: "https://vault.example.com", AuthMethod: "oidc"},
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev"},
+		},
+		Cache: CacheConfig{Enabled: true, TTL: Duration(-time.Minute)},
+	}
+
+	if err := Validate(cfg); err == nil {
+		t.Fatal("Validate() expected error for negative cache ttl")
+	}
+
+	cfg.Cache.TTL = Duration(time.Hour)
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
+
+func TestValidate_KubernetesRequiresRole(t *testing.T) {
+	cfg := &RootConfig{
+		Vault: VaultConfig{Address: "https://vault.example.com", AuthMethod: "kubernetes"},
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev"},
+		},
+	}
+
+	if err := Validate(cfg); err == nil {
+		t.Error("Validate() accepted kubernetes auth without a role")
+	}
+
+	cfg.Vault.AuthRoles = map[string]string{"dev": "api"}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestValidate_KVVersion(t *testing.T) {
+	cfg := &RootConfig{
+		Vault: VaultConfig{Address: "https://vault.example.com", AuthMethod: "token", KVVersion: 3},
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev"},
+		},
+	}
+
+	if err := Validate(cfg); err == nil {
+		t.Error("Validate() accepted kv_version = 3")
+	}
+
+	cfg.Vault.KVVersion = 1
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestValidate_TokenStore(t *testing.T) {
+	cfg := &RootConfig{
+		Vault: VaultConfig{Address: "https://vault.example.com", AuthMethod: "oidc", TokenStore: "keyring"},
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev"},
+		},
+	}
+
+	if err := Validate(cfg); err == nil {
+		t.Error("Validate() accepted token_store = \"keyring\"")
+	}
+
+	cfg.Vault.TokenStore = "keychain"
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestValidate_AuthListenPorts(t *testing.T) {
+	cfg := &RootConfig{
+		Vault: VaultConfig{Address: "https://vault.example.com", AuthMethod: "oidc", AuthListenPorts: []int{8250, 0}},
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev"},
+		},
+	}
+
+	if err := Validate(cfg); err == nil {
+		t.Error("Validate() accepted auth_listen_ports with port 0")
+	}
+
+	cfg.Vault.AuthListenPorts = []int{8250, 8251, 8252}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestValidate_OnError(t *testing.T) {
+	cfg := &RootConfig{
+		Vault: VaultConfig{Address: "https://vault.example.com", AuthMethod: "oidc"},
+		Environments: EnvironmentConfig{
+			Default:   "dev",
+			Available: []string{"dev"},
+		},
+		Resolver: ResolverConfig{OnError: "warn"},
+		OnError:  map[string]string{"SENTRY_DSN": "default", "DATABASE_URL": "fail"},
+	}
+
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	cfg.Resolver.OnError = "ignore"
+	if err := Validate(cfg); err == nil {
+		t.Error("Validate() accepted [resolver] on_error = \"ignore\"")
+	}
+
+	cfg.Resolver.OnError = ""
+	cfg.OnError["SENTRY_DSN"] = "skip"
+	if err := Validate(cfg); err == nil {
+		t.Error("Validate() accepted an unknown [on_error] policy")
+	}
+}
+
+func TestValidate_Required(t *testing.T) {
+	cfg := &RootConfig{
+		Vault:        VaultConfig{Address: "https://vault.example.com", AuthMethod: "oidc"},
+		Environments: EnvironmentConfig{Default: "dev", Available: []string{"dev"}},
+		Required:     []string{"DATABASE_URL", ""},
+	}
+	if err := Validate(cfg); err == nil {
+		t.Error("Validate() accepted an empty required name")
+	}
+	if err := ValidateWorkspace(&WorkspaceConfig{Required: []string{""}}); err == nil {
+		t.Error("ValidateWorkspace() accepted an empty required name")
+	}
+}
+
+func TestValidate_HostEnv(t *testing.T) {
+	cfg := &RootConfig{
+		Vault:          VaultConfig{Address: "https://vault.example.com", AuthMethod: "oidc"},
+		Environments:   EnvironmentConfig{Default: "dev", Available: []string{"dev"}},
+		EnvPassthrough: []string{"PATH", "NODE_*"},
+		EnvBlock:       []string{"AWS_*"},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	cfg.EnvBlock = []string{"AWS_["}
+	if err := Validate(cfg); err == nil {
+		t.Error("Validate() accepted a malformed env_block pattern")
+	}
+	if err := ValidateWorkspace(&WorkspaceConfig{EnvPassthrough: []string{""}}); err == nil {
+		t.Error("ValidateWorkspace() accepted an empty env_passthrough pattern")
+	}
+}
+
 func TestValidateWithRoot_WorkspacePathsExist(t *testing.T) {
 	rootDir := filepath.Join("testdata", "root")
 	cfg := &RootConfig{
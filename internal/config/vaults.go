@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VaultScheme starts a mapping read from one of the named connections in
+// [vaults] instead of [vault], e.g.
+// "vault://data-platform/${env}/warehouse/password".
+const VaultScheme = "vault://"
+
+// commandScheme starts a mapping that runs a command (see resolver.IsCommand).
+// A workspace's vault never applies to those.
+const commandScheme = "cmd://"
+
+// SplitVault returns the named connection a mapping reads from and its path
+// within that connection. A mapping without VaultScheme reads from [vault]:
+// the name is empty and the path is returned unchanged.
+func SplitVault(path string) (string, string) {
+	rest, ok := strings.CutPrefix(path, VaultScheme)
+	if !ok {
+		return "", path
+	}
+	name, p, _ := strings.Cut(rest, "/")
+	return name, p
+}
+
+// withVault returns the workspace's mappings with every plain Vault path
+// prefixed with the connection the workspace reads from (see inVault).
+func withVault(secrets map[string]string, name string) map[string]string {
+	result := make(map[string]string, len(secrets))
+	for k, v := range secrets {
+		result[k] = inVault(v, name)
+	}
+	return result
+}
+
+// inVault prefixes a plain Vault path with the connection name. Mappings
+// that name a connection or run a command, and any mapping when name is
+// empty, are returned as they are.
+func inVault(path string, name string) string {
+	if name == "" || strings.HasPrefix(path, VaultScheme) || strings.HasPrefix(path, commandScheme) {
+		return path
+	}
+	return VaultScheme + name + "/" + path
+}
+
+// checkVaultRefs returns an error for the first mapping that names a
+// connection not defined in vaults.
+func checkVaultRefs(secrets map[string]string, vaults map[string]VaultConfig) error {
+	names := make([]string, 0, len(secrets))
+	for k := range secrets {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		name, _ := SplitVault(secrets[k])
+		if _, ok := vaults[name]; name != "" && !ok {
+			return fmt.Errorf("secret %s reads from vault %q, which is not defined in [vaults]", k, name)
+		}
+	}
+	return nil
+}
+
+// validateVaults checks every named connection and the mappings of the root
+// config that refer to one.
+func validateVaults(cfg *RootConfig) error {
+	names := make([]string, 0, len(cfg.Vaults))
+	for name := range cfg.Vaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, "/ ") {
+			return fmt.Errorf("vaults config: invalid name %q", name)
+		}
+		v := cfg.Vaults[name]
+		if err := validateVault(v); err != nil {
+			return fmt.Errorf("vaults.%s config: %w", name, err)
+		}
+		for env := range v.AuthRoles {
+			if !contains(cfg.Environments.Available, env) {
+				return fmt.Errorf("vaults.%s config: auth_roles has unknown environment %q", name, env)
+			}
+		}
+	}
+
+	return checkVaultRefs(cfg.Secrets, cfg.Vaults)
+}
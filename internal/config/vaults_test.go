@@ -0,0 +1,147 @@
+package config
+
+import "testing"
+
+func TestSplitVault(t *testing.T) {
+	tests := []struct {
+		path     string
+		wantName string
+		wantPath string
+	}{
+		{"${env}/db/password", "", "${env}/db/password"},
+		{"vault://data/${env}/warehouse/password", "data", "${env}/warehouse/password"},
+		{"cmd://op read x", "", "cmd://op read x"},
+	}
+
+	for _, tt := range tests {
+		name, path := SplitVault(tt.path)
+		if name != tt.wantName || path != tt.wantPath {
+			t.Errorf("SplitVault(%q) = %q, %q; want %q, %q", tt.path, name, path, tt.wantName, tt.wantPath)
+		}
+	}
+}
+
+func vaultsRoot() *RootConfig {
+	return &RootConfig{
+		Vault: VaultConfig{Address: "https://core.example.com", AuthMethod: "oidc"},
+		Vaults: map[string]VaultConfig{
+			"data": {Address: "https://data.example.com", AuthMethod: "approle"},
+		},
+		Environments: EnvironmentConfig{Default: "dev", Available: []string{"dev"}},
+		Secrets: map[string]string{
+			"API_KEY":    "${env}/api/key",
+			"WAREHOUSE":  "vault://data/${env}/warehouse/url",
+			"OP_SECRET":  "cmd://op read x",
+			"SHARED_KEY": "shared/key",
+		},
+	}
+}
+
+func TestMerge_WorkspaceVault(t *testing.T) {
+	ws := &WorkspaceConfig{
+		Vault: "data",
+		Secrets: map[string]string{
+			"DB_URL":     "${env}/db/url",
+			"CORE_TOKEN": "vault://data/${env}/token",
+			"LOCAL":      "cmd://cat token",
+		},
+	}
+
+	merged, err := Merge(vaultsRoot(), ws, "dev")
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	want := map[string]string{
+		"API_KEY":    "${env}/api/key",
+		"WAREHOUSE":  "vault://data/${env}/warehouse/url",
+		"OP_SECRET":  "cmd://op read x",
+		"SHARED_KEY": "shared/key",
+		"DB_URL":     "vault://data/${env}/db/url",
+		"CORE_TOKEN": "vault://data/${env}/token",
+		"LOCAL":      "cmd://cat token",
+	}
+	for k, v := range want {
+		if merged.Secrets[k] != v {
+			t.Errorf("Secrets[%s] = %q, want %q", k, merged.Secrets[k], v)
+		}
+	}
+	if _, ok := merged.Vaults["data"]; !ok {
+		t.Error("Vaults missing data")
+	}
+}
+
+func TestMerge_UnknownVault(t *testing.T) {
+	if _, err := Merge(vaultsRoot(), &WorkspaceConfig{Vault: "billing"}, "dev"); err == nil {
+		t.Error("Merge() expected error for unknown workspace vault")
+	}
+
+	ws := &WorkspaceConfig{Secrets: map[string]string{"X": "vault://billing/x"}}
+	if _, err := Merge(vaultsRoot(), ws, "dev"); err == nil {
+		t.Error("Merge() expected error for mapping to unknown vault")
+	}
+}
+
+func TestValidate_Vaults(t *testing.T) {
+	if err := Validate(vaultsRoot()); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	cfg := vaultsRoot()
+	cfg.Vaults["data"] = VaultConfig{AuthMethod: "approle"}
+	if err := Validate(cfg); err == nil {
+		t.Error("Validate() expected error for named vault without address")
+	}
+
+	cfg = vaultsRoot()
+	cfg.Vaults["data/x"] = VaultConfig{Address: "https://x", AuthMethod: "oidc"}
+	if err := Validate(cfg); err == nil {
+		t.Error("Validate() expected error for vault name with a slash")
+	}
+
+	cfg = vaultsRoot()
+	cfg.Secrets["BILLING"] = "vault://billing/key"
+	if err := Validate(cfg); err == nil {
+		t.Error("Validate() expected error for mapping to unknown vault")
+	}
+}
+
+func TestParseRootConfig_Vaults(t *testing.T) {
+	cfg, err := ParseRootConfig([]byte(`
+[vault]
+address = "https://core.example.com"
+auth_method = "oidc"
+
+[vaults.data-platform]
+address = "https://data.example.com"
+auth_method = "approle"
+base_path = "kv"
+`))
+	if err != nil {
+		t.Fatalf("ParseRootConfig() error = %v", err)
+	}
+
+	v, ok := cfg.Vaults["data-platform"]
+	if !ok {
+		t.Fatal("Vaults missing data-platform")
+	}
+	if v.Address != "https://data.example.com" || v.AuthMethod != "approle" || v.BasePath != "kv" {
+		t.Errorf("Vaults[data-platform] = %+v", v)
+	}
+}
+
+func TestExplain_WorkspaceVault(t *testing.T) {
+	ws := &WorkspaceConfig{
+		Vault:   "data",
+		Secrets: map[string]string{"DB_URL": "${env}/db/url"},
+	}
+
+	e, err := Explain(vaultsRoot(), ws, "dev", "DB_URL")
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	w, ok := e.Winner()
+	if !ok || w.Value != "vault://data/${env}/db/url" {
+		t.Errorf("Winner() = %+v, want the mapping in vault data", w)
+	}
+}
@@ -0,0 +1,21 @@
+package config
+
+import "fmt"
+
+// CurrentVersion is the vx.toml format version this build of vx reads and
+// writes. Raise it together with a migration in internal/upgrade whenever
+// the layout changes incompatibly.
+const CurrentVersion = 1
+
+// checkVersion refuses files written for a newer format than this vx
+// understands, since reading them could silently drop settings. Older
+// versions are read as-is; `vx config upgrade` rewrites them.
+func checkVersion(name string, version int) error {
+	if version > CurrentVersion {
+		return fmt.Errorf("%s is format version %d, but this vx only understands up to version %d; upgrade vx", name, version, CurrentVersion)
+	}
+	if version < 0 {
+		return fmt.Errorf("%s: invalid version %d", name, version)
+	}
+	return nil
+}
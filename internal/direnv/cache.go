@@ -0,0 +1,102 @@
+package direnv
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache keeps resolved values on disk for a short time. direnv re-runs the
+// .envrc whenever the directory is re-entered after a change, and without a
+// cache each run would read every path from Vault again.
+//
+// Entries hold plain secret values, so the directory is created 0700 and
+// each file 0600.
+type Cache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// entry is the on-disk form of a cached resolution.
+type entry struct {
+	CreatedAt time.Time         `json:"created_at"`
+	Values    map[string]string `json:"values"`
+}
+
+// NewCache creates a cache in dir whose entries live for ttl. A ttl less
+// than or equal to zero disables the cache.
+func NewCache(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl, now: time.Now}
+}
+
+// Key derives a cache key from parts (such as the root directory, workspace,
+// and environment) and the contents of files, so editing any of them starts
+// a fresh entry.
+func Key(files []string, parts ...string) (string, error) {
+	h := sha256.New()
+	for _, p := range parts {
+		fmt.Fprintf(h, "%d:%s\n", len(p), p)
+	}
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading %s: %w", path, err)
+		}
+		fmt.Fprintf(h, "%d:%s\n", len(path), path)
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+// Load returns the values stored under key if they are younger than the
+// cache's TTL and were stored after notBefore, such as the time of the last
+// known change in Vault. Stale or unreadable entries are removed.
+func (c *Cache) Load(key string, notBefore time.Time) (map[string]string, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil || c.now().Sub(e.CreatedAt) >= c.ttl || !e.CreatedAt.After(notBefore) {
+		_ = os.Remove(path)
+		return nil, false
+	}
+	return e.Values, true
+}
+
+// Store saves values under key. It does nothing when the cache is disabled.
+func (c *Cache) Store(key string, values map[string]string) error {
+	if c.ttl <= 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("creating direnv cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(entry{CreatedAt: c.now(), Values: values})
+	if err != nil {
+		return fmt.Errorf("encoding direnv cache: %w", err)
+	}
+
+	if err := os.WriteFile(c.path(key), data, 0600); err != nil {
+		return fmt.Errorf("writing direnv cache: %w", err)
+	}
+	return nil
+}
+
+// path returns the file holding the entry for key.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
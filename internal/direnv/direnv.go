@@ -0,0 +1,45 @@
+// Package direnv connects vx to direnv: it renders the .envrc snippet that
+// loads a workspace's values and the bash that snippet evaluates, and caches
+// resolved values briefly so direnv reloads don't hit Vault every time.
+package direnv
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"go.dot.industries/vx/internal/shellenv"
+)
+
+// safeChars never need quoting in a bash word.
+const safeChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@%+,"
+
+// Hook returns an .envrc snippet that loads values with "vx direnv export".
+// flags are passed through to the export command, e.g. ["-e", "staging"].
+func Hook(flags []string) string {
+	cmd := "vx direnv export"
+	for _, f := range flags {
+		if f == "" || strings.Trim(f, safeChars) != "" {
+			f = shellenv.Quote(shellenv.POSIX, f)
+		}
+		cmd += " " + f
+	}
+
+	var b strings.Builder
+	b.WriteString("# Load secrets and defaults for this directory from Vault.\n")
+	b.WriteString("# Generated by `vx direnv hook`.\n")
+	fmt.Fprintf(&b, "eval \"$(%s)\"\n", cmd)
+	return b.String()
+}
+
+// Export writes bash that direnv evaluates: a watch_file call for each
+// config file, so editing one reloads the environment, followed by one
+// export per value in key order.
+func Export(w io.Writer, values map[string]string, watch []string) error {
+	for _, path := range watch {
+		if _, err := fmt.Fprintf(w, "watch_file %s\n", shellenv.Quote(shellenv.POSIX, path)); err != nil {
+			return err
+		}
+	}
+	return shellenv.Render(w, shellenv.POSIX, values)
+}
@@ -0,0 +1,120 @@
+package direnv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHook(t *testing.T) {
+	got := Hook([]string{"-e", "staging", "-w", "my app"})
+	if !strings.Contains(got, `eval "$(vx direnv export -e staging -w 'my app')"`) {
+		t.Errorf("Hook() = %q", got)
+	}
+}
+
+func TestExport(t *testing.T) {
+	var b strings.Builder
+	err := Export(&b, map[string]string{
+		"B": "it's",
+		"A": "$HOME `x`",
+	}, []string{"/repo/vx.toml"})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	want := "watch_file '/repo/vx.toml'\n" +
+		"export A='$HOME `x`'\n" +
+		"export B='it'\\''s'\n"
+	if b.String() != want {
+		t.Errorf("Export() = %q, want %q", b.String(), want)
+	}
+}
+
+func TestKey(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "vx.toml")
+	if err := os.WriteFile(cfg, []byte("a = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	k1, err := Key([]string{cfg}, dir, "api", "dev")
+	if err != nil {
+		t.Fatalf("Key() error = %v", err)
+	}
+	k2, _ := Key([]string{cfg}, dir, "api", "staging")
+	if k1 == k2 {
+		t.Error("Key() should differ between environments")
+	}
+
+	if err := os.WriteFile(cfg, []byte("a = 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	k3, _ := Key([]string{cfg}, dir, "api", "dev")
+	if k1 == k3 {
+		t.Error("Key() should change when a config file changes")
+	}
+
+	if _, err := Key([]string{filepath.Join(dir, "missing.toml")}); err == nil {
+		t.Error("Key() expected error for a missing file")
+	}
+}
+
+func TestCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "direnv")
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCache(dir, time.Minute)
+	c.now = func() time.Time { return now }
+
+	if _, ok := c.Load("k", time.Time{}); ok {
+		t.Fatal("Load() hit on an empty cache")
+	}
+
+	if err := c.Store("k", map[string]string{"A": "1"}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	info, err := os.Stat(c.path("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("cache file mode = %v, want 0600", perm)
+	}
+
+	got, ok := c.Load("k", time.Time{})
+	if !ok || got["A"] != "1" {
+		t.Errorf("Load() = %v, %v", got, ok)
+	}
+
+	if _, ok := c.Load("k", now); ok {
+		t.Error("Load() hit on an entry stored before a change")
+	}
+	if err := c.Store("k", map[string]string{"A": "1"}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.Load("k", time.Time{}); ok {
+		t.Error("Load() hit on an expired entry")
+	}
+	if _, err := os.Stat(c.path("k")); !os.IsNotExist(err) {
+		t.Error("expired entry should be removed")
+	}
+}
+
+func TestCache_Disabled(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "direnv")
+	c := NewCache(dir, 0)
+
+	if err := c.Store("k", map[string]string{"A": "1"}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("disabled cache should not create its directory")
+	}
+	if _, ok := c.Load("k", time.Time{}); ok {
+		t.Error("Load() hit on a disabled cache")
+	}
+}
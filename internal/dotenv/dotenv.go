@@ -0,0 +1,167 @@
+// Package dotenv reads .env files and compares them with resolved vx values.
+package dotenv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Parse reads KEY=VALUE lines. Blank lines and # comments are skipped, an
+// optional "export " prefix is accepted, and values may be single-quoted
+// (literal), double-quoted (with \n, \t, \", and \\ escapes), or bare (a
+// trailing " #" comment is dropped). Later assignments override earlier
+// ones, as in a shell.
+func Parse(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: invalid key %q", lineNo, key)
+		}
+
+		val, err := parseValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		values[key] = val
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// ParseFile parses the .env file at path.
+func ParseFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+func parseValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch raw[0] {
+	case '\'':
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quote")
+		}
+		return raw[1 : end+1], nil
+
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(raw); i++ {
+			c := raw[i]
+			switch {
+			case c == '"':
+				return b.String(), nil
+			case c == '\\' && i+1 < len(raw):
+				i++
+				switch raw[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				default:
+					b.WriteByte(raw[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated double quote")
+	}
+
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = raw[:i]
+	}
+	return strings.TrimSpace(raw), nil
+}
+
+// Comparison is the result of comparing resolved vx values with a .env file.
+// Every list is sorted.
+type Comparison struct {
+	MissingFromFile []string // resolved by vx but absent from the file
+	MissingFromVx   []string // in the file but not mapped by vx
+	Different       []string // in both with different values
+	Same            []string
+}
+
+// Complete reports whether every key in the file is provided by vx with the
+// same value, i.e. whether the file can be deleted.
+func (c *Comparison) Complete() bool {
+	return len(c.MissingFromVx) == 0 && len(c.Different) == 0
+}
+
+// Compare compares the values vx resolves with those in a .env file.
+func Compare(resolved, file map[string]string) *Comparison {
+	c := &Comparison{}
+
+	for k, v := range resolved {
+		fv, ok := file[k]
+		switch {
+		case !ok:
+			c.MissingFromFile = append(c.MissingFromFile, k)
+		case fv != v:
+			c.Different = append(c.Different, k)
+		default:
+			c.Same = append(c.Same, k)
+		}
+	}
+	for k := range file {
+		if _, ok := resolved[k]; !ok {
+			c.MissingFromVx = append(c.MissingFromVx, k)
+		}
+	}
+
+	sort.Strings(c.MissingFromFile)
+	sort.Strings(c.MissingFromVx)
+	sort.Strings(c.Different)
+	sort.Strings(c.Same)
+	return c
+}
+
+// Mask hides a value for display, keeping only its first two characters (for
+// values long enough that this reveals little) and its length.
+func Mask(v string) string {
+	n := len([]rune(v))
+	if n == 0 {
+		return "(empty)"
+	}
+	if n < 12 {
+		return fmt.Sprintf("%s (%d chars)", strings.Repeat("*", 6), n)
+	}
+	return fmt.Sprintf("%s%s (%d chars)", string([]rune(v)[:2]), strings.Repeat("*", 6), n)
+}
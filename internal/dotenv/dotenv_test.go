@@ -0,0 +1,86 @@
+package dotenv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	input := `# database
+DATABASE_URL=postgres://localhost:5432/app
+export API_KEY = "sk-\"quoted\"\nline"
+LITERAL='no $expansion # here'
+BARE=value # trailing comment
+EMPTY=
+HASH=abc#def
+DATABASE_URL=postgres://override
+`
+
+	got, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := map[string]string{
+		"DATABASE_URL": "postgres://override",
+		"API_KEY":      "sk-\"quoted\"\nline",
+		"LITERAL":      "no $expansion # here",
+		"BARE":         "value",
+		"EMPTY":        "",
+		"HASH":         "abc#def",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %#v, want %#v", got, want)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, input := range []string{
+		"NOEQUALS",
+		"BAD KEY=1",
+		`OPEN="unterminated`,
+		"OPEN='unterminated",
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Parse(%q) expected error", input)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	resolved := map[string]string{"A": "1", "B": "2", "C": "3"}
+	file := map[string]string{"A": "1", "B": "changed", "D": "4"}
+
+	got := Compare(resolved, file)
+
+	want := &Comparison{
+		MissingFromFile: []string{"C"},
+		MissingFromVx:   []string{"D"},
+		Different:       []string{"B"},
+		Same:            []string{"A"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compare() = %+v, want %+v", got, want)
+	}
+	if got.Complete() {
+		t.Error("Complete() = true, want false")
+	}
+
+	if !Compare(resolved, map[string]string{"A": "1"}).Complete() {
+		t.Error("Complete() = false for a file fully covered by vx")
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := map[string]string{
+		"":                   "(empty)",
+		"short":              "****** (5 chars)",
+		"sk_live_1234567890": "sk****** (18 chars)",
+	}
+	for in, want := range tests {
+		if got := Mask(in); got != want {
+			t.Errorf("Mask(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
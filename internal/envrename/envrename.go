@@ -0,0 +1,341 @@
+// Package envrename renames an environment across a monorepo's vx.toml files
+// and, optionally, copies the environment's Vault subtree to the new prefix.
+package envrename
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/creachadair/tomledit"
+	"github.com/creachadair/tomledit/parser"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/tomlfile"
+	"go.dot.industries/vx/internal/vault"
+)
+
+// VaultTree is the subset of the Vault client needed to copy an environment's
+// secrets to a new prefix.
+type VaultTree interface {
+	ListKeys(kvPath string) ([]vault.VaultEntry, error)
+	ReadKVData(kvPath string) (map[string]interface{}, int, error)
+	CopyKV(src, dst string) error
+}
+
+// Change describes a single edit to a vx.toml file.
+type Change struct {
+	File   string // absolute path of the edited file
+	Detail string // human-readable description, e.g. `environments.default: "staging" -> "stage"`
+}
+
+// Copy describes a single Vault secret to copy, relative to the KV mount.
+type Copy struct {
+	From string
+	To   string
+}
+
+// Plan holds every edit needed to rename environment Old to New. Nothing is
+// written until Apply is called, so a Plan doubles as the dry-run output.
+type Plan struct {
+	Old     string
+	New     string
+	Changes []Change
+	Copies  []Copy
+
+	// OldSegment and NewSegment are the Vault path segments Old reads before
+	// the rename and New reads after it (see [environments.map]). They are
+	// equal when the environment is mapped: its entry moves to the new name
+	// and keeps its segment.
+	OldSegment string
+	NewSegment string
+
+	docs []editedDoc
+}
+
+// editedDoc is a parsed vx.toml with the rename already applied in memory.
+type editedDoc struct {
+	path string
+	doc  *tomledit.Document
+}
+
+// NewPlan reads the root vx.toml at rootPath and the given workspace vx.toml
+// files and computes the edits for renaming oldEnv to newEnv:
+//
+//   - environments.available and environments.default
+//   - tui.protected_environments and the tui.accents and environments.map keys
+//   - [defaults.<old>] tables
+//   - secret paths that hardcode the old name as a path segment
+//
+// Paths using ${env} need no change and are left alone.
+func NewPlan(rootPath string, workspacePaths []string, oldEnv, newEnv string) (*Plan, error) {
+	if err := validateNames(oldEnv, newEnv); err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.LoadRootConfig(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(cfg.Environments.Available, oldEnv) {
+		return nil, fmt.Errorf("environment %q is not in available environments [%s]",
+			oldEnv, strings.Join(cfg.Environments.Available, ", "))
+	}
+	if slices.Contains(cfg.Environments.Available, newEnv) {
+		return nil, fmt.Errorf("environment %q already exists", newEnv)
+	}
+
+	p := &Plan{Old: oldEnv, New: newEnv}
+	p.OldSegment, p.NewSegment = pathSegments(cfg.Environments, oldEnv, newEnv)
+
+	for i, file := range append([]string{rootPath}, workspacePaths...) {
+		doc, err := tomlfile.Read(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var changes []Change
+		if i == 0 {
+			changes = append(changes, p.renameRootSettings(file, doc)...)
+		}
+		changes = append(changes, p.renameDefaultsTables(file, doc)...)
+		changes = append(changes, p.renameSecretPaths(file, doc)...)
+
+		if len(changes) > 0 {
+			p.Changes = append(p.Changes, changes...)
+			p.docs = append(p.docs, editedDoc{path: file, doc: doc})
+		}
+	}
+
+	return p, nil
+}
+
+// PlanVaultCopy walks the Vault subtree under OldSegment and records a copy
+// to NewSegment for every secret found. Nothing is copied when the two are
+// the same: the renamed environment still reads the same secrets.
+func (p *Plan) PlanVaultCopy(v VaultTree) error {
+	if p.OldSegment == p.NewSegment {
+		return nil
+	}
+
+	leaves, err := vault.ListTree(v, p.OldSegment+"/")
+	if err != nil {
+		return fmt.Errorf("listing Vault secrets under %s/: %w", p.OldSegment, err)
+	}
+
+	for _, src := range leaves {
+		dst := p.NewSegment + strings.TrimPrefix(src, p.OldSegment)
+		p.Copies = append(p.Copies, Copy{From: src, To: dst})
+	}
+	return nil
+}
+
+// pathSegments returns the Vault path segment of oldEnv in envs, and that
+// of newEnv once renameRootSettings has moved oldEnv's environments.map
+// entry, if any, to newEnv.
+func pathSegments(envs config.EnvironmentConfig, oldEnv, newEnv string) (string, string) {
+	renamed := envs
+	if seg, ok := envs.Map[oldEnv]; ok {
+		renamed.Map = maps.Clone(envs.Map)
+		delete(renamed.Map, oldEnv)
+		renamed.Map[newEnv] = seg
+	}
+	return envs.PathSegment(oldEnv), renamed.PathSegment(newEnv)
+}
+
+// Apply performs the planned Vault copies, then writes the edited vx.toml
+// files. Copies run first so a Vault failure leaves the config untouched.
+// The old Vault subtree is never deleted. v may be nil when the plan has no
+// copies.
+//
+// After a failure the rename can simply be run again: a secret that an
+// earlier run already copied, so the new path holds the same values, is
+// skipped. A new path holding different values is an error.
+func (p *Plan) Apply(v VaultTree) error {
+	for _, c := range p.Copies {
+		if err := copySecret(v, c); err != nil {
+			return fmt.Errorf("copying %s to %s: %w", c.From, c.To, err)
+		}
+	}
+
+	for _, d := range p.docs {
+		if err := tomlfile.Write(d.path, d.doc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copySecret performs c unless its destination already holds the source's
+// values.
+func copySecret(v VaultTree, c Copy) error {
+	dst, _, err := v.ReadKVData(c.To)
+	if err != nil {
+		return err
+	}
+	if dst == nil {
+		return v.CopyKV(c.From, c.To)
+	}
+
+	src, _, err := v.ReadKVData(c.From)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(src, dst) {
+		return fmt.Errorf("%s already exists with different values", c.To)
+	}
+	return nil
+}
+
+// renameRootSettings updates the environment lists and TUI settings that
+// only appear in the root vx.toml.
+func (p *Plan) renameRootSettings(file string, doc *tomledit.Document) []Change {
+	var changes []Change
+
+	for _, key := range [][]string{
+		{"environments", "available"},
+		{"tui", "protected_environments"},
+	} {
+		entry := doc.First(key...)
+		if entry == nil || !entry.IsMapping() {
+			continue
+		}
+		if p.renameInArray(entry.KeyValue.Value.X) {
+			changes = append(changes, p.change(file, strings.Join(key, ".")))
+		}
+	}
+
+	if entry := doc.First("environments", "default"); entry != nil && entry.IsMapping() {
+		if s, ok := tomlfile.StringValue(entry.KeyValue.Value); ok && s == p.Old {
+			entry.KeyValue.Value = tomlfile.QuotedValue(p.New, entry.KeyValue.Value.Trailer)
+			changes = append(changes, p.change(file, "environments.default"))
+		}
+	}
+
+	for _, key := range [][]string{
+		{"tui", "accents"},
+		{"environments", "map"},
+	} {
+		if entry := doc.First(append(key, p.Old)...); entry != nil && entry.IsMapping() {
+			name := entry.KeyValue.Name
+			name[len(name)-1] = p.New
+			changes = append(changes, p.change(file, strings.Join(key, ".")+" key"))
+		}
+	}
+
+	return changes
+}
+
+// renameDefaultsTables renames [defaults.<old>] headings.
+func (p *Plan) renameDefaultsTables(file string, doc *tomledit.Document) []Change {
+	var changes []Change
+	want := parser.Key{"defaults", p.Old}
+
+	for _, s := range doc.Sections {
+		if s.Heading != nil && s.Heading.Name.Equals(want) {
+			s.Heading.Name = parser.Key{"defaults", p.New}
+			changes = append(changes, Change{
+				File:   file,
+				Detail: fmt.Sprintf("[defaults.%s] -> [defaults.%s]", p.Old, p.New),
+			})
+		}
+	}
+
+	return changes
+}
+
+// renameSecretPaths rewrites [secrets] values that contain the old
+// environment name as a whole path segment.
+func (p *Plan) renameSecretPaths(file string, doc *tomledit.Document) []Change {
+	var changes []Change
+
+	for _, entry := range doc.Find("secrets") {
+		if !entry.IsSection() {
+			continue
+		}
+		for _, item := range entry.Section.Items {
+			kv, ok := item.(*parser.KeyValue)
+			if !ok {
+				continue
+			}
+			old, ok := tomlfile.StringValue(kv.Value)
+			if !ok {
+				continue
+			}
+			renamed := replaceSegment(old, p.Old, p.New)
+			if renamed == old {
+				continue
+			}
+			kv.Value = tomlfile.QuotedValue(renamed, kv.Value.Trailer)
+			changes = append(changes, Change{
+				File:   file,
+				Detail: fmt.Sprintf("secrets.%s: %q -> %q", kv.Name, old, renamed),
+			})
+		}
+	}
+
+	return changes
+}
+
+// renameInArray replaces string elements equal to the old name in place and
+// reports whether any were changed.
+func (p *Plan) renameInArray(d parser.Datum) bool {
+	arr, ok := d.(parser.Array)
+	if !ok {
+		return false
+	}
+
+	changed := false
+	for i, item := range arr {
+		v, ok := item.(parser.Value)
+		if !ok {
+			continue
+		}
+		if s, ok := tomlfile.StringValue(v); ok && s == p.Old {
+			arr[i] = tomlfile.QuotedValue(p.New, v.Trailer)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// change formats a Change for a setting whose value mentions the old name.
+func (p *Plan) change(file, setting string) Change {
+	return Change{
+		File:   file,
+		Detail: fmt.Sprintf("%s: %q -> %q", setting, p.Old, p.New),
+	}
+}
+
+// validateNames rejects names that cannot be used as a Vault path segment.
+// The old name is checked too: its subtree is listed and its segments are
+// replaced in secret paths, which a name with '/' would get wrong.
+func validateNames(oldEnv, newEnv string) error {
+	if oldEnv == "" || newEnv == "" {
+		return fmt.Errorf("environment names must not be empty")
+	}
+	if oldEnv == newEnv {
+		return fmt.Errorf("old and new environment names are the same")
+	}
+	for _, name := range []string{oldEnv, newEnv} {
+		if strings.ContainsAny(name, "/ ") {
+			return fmt.Errorf("environment name %q must not contain '/' or spaces", name)
+		}
+	}
+	return nil
+}
+
+// replaceSegment replaces every "/"-separated segment of vaultPath equal to
+// from with to.
+func replaceSegment(vaultPath, from, to string) string {
+	segments := strings.Split(vaultPath, "/")
+	for i, s := range segments {
+		if s == from {
+			segments[i] = to
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,331 @@
+package envrename
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.dot.industries/vx/internal/vault"
+)
+
+const rootTOML = `workspaces = ["web/vx.toml"]
+
+[vault]
+address = "https://vault.example.com"
+auth_method = "oidc"
+
+# Environments in promotion order
+[environments]
+default = "staging"
+available = ["dev", "staging", "production"] # keep sorted
+
+[secrets]
+DATABASE_URL = "${env}/database/url"
+LEGACY_TOKEN = "staging/legacy/token"
+STAGING_ONLY = "shared/staging-db/url"
+
+[defaults.staging]
+NODE_ENV = "staging"
+
+[tui]
+protected_environments = ["staging", "production"]
+
+[tui.accents]
+staging = "#F59E0B"
+`
+
+const workspaceTOML = `[secrets]
+API_KEY = 'staging/api/key'
+
+[defaults.staging]
+LOG_LEVEL = "debug"
+`
+
+func writeFixture(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	root := filepath.Join(dir, "vx.toml")
+	ws := filepath.Join(dir, "web", "vx.toml")
+
+	if err := os.MkdirAll(filepath.Dir(ws), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(root, []byte(rootTOML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(ws, []byte(workspaceTOML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return root, ws
+}
+
+func TestNewPlan(t *testing.T) {
+	root, ws := writeFixture(t)
+
+	plan, err := NewPlan(root, []string{ws}, "staging", "stage")
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+
+	if len(plan.Changes) != 8 {
+		for _, c := range plan.Changes {
+			t.Logf("%s: %s", filepath.Base(filepath.Dir(c.File)), c.Detail)
+		}
+		t.Fatalf("len(Changes) = %d, want 8", len(plan.Changes))
+	}
+
+	// Planning must not touch the files.
+	data, _ := os.ReadFile(root)
+	if string(data) != rootTOML {
+		t.Error("NewPlan() modified the root vx.toml")
+	}
+}
+
+func TestPlanApply(t *testing.T) {
+	root, ws := writeFixture(t)
+
+	plan, err := NewPlan(root, []string{ws}, "staging", "stage")
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if err := plan.Apply(nil); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(root)
+	got := string(data)
+	for _, want := range []string{
+		`default = "stage"`,
+		`available = ["dev", "stage", "production"]`,
+		`# keep sorted`,
+		`LEGACY_TOKEN = "stage/legacy/token"`,
+		`STAGING_ONLY = "shared/staging-db/url"`,
+		`DATABASE_URL = "${env}/database/url"`,
+		`[defaults.stage]`,
+		`protected_environments = ["stage", "production"]`,
+		`stage = "#F59E0B"`,
+		`# Environments in promotion order`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("root vx.toml missing %q:\n%s", want, got)
+		}
+	}
+
+	data, _ = os.ReadFile(ws)
+	got = string(data)
+	for _, want := range []string{`API_KEY = "stage/api/key"`, `[defaults.stage]`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("workspace vx.toml missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestPlanApply_EnvironmentMap(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "vx.toml")
+	content := `[environments]
+default = "dev"
+available = ["dev", "production"]
+
+[environments.map]
+production = "prod"
+`
+	if err := os.WriteFile(root, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := NewPlan(root, nil, "production", "live")
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if err := plan.Apply(nil); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(root)
+	if !strings.Contains(string(data), `live = "prod"`) {
+		t.Errorf("environments.map key not renamed:\n%s", data)
+	}
+}
+
+func TestNewPlan_Errors(t *testing.T) {
+	root, _ := writeFixture(t)
+
+	tests := []struct {
+		name     string
+		old, new string
+	}{
+		{"unknown old", "qa", "test"},
+		{"new exists", "staging", "dev"},
+		{"same name", "staging", "staging"},
+		{"empty new", "staging", ""},
+		{"slash in new", "staging", "st/age"},
+		{"slash in old", "stag/ing", "stage"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPlan(root, nil, tt.old, tt.new); err == nil {
+				t.Errorf("NewPlan(%q, %q) expected error", tt.old, tt.new)
+			}
+		})
+	}
+}
+
+type fakeTree struct {
+	dirs   map[string][]vault.VaultEntry
+	data   map[string]map[string]interface{}
+	copied []Copy
+}
+
+func (f *fakeTree) ListKeys(p string) ([]vault.VaultEntry, error) {
+	return f.dirs[p], nil
+}
+
+func (f *fakeTree) ReadKVData(p string) (map[string]interface{}, int, error) {
+	return f.data[p], 1, nil
+}
+
+func (f *fakeTree) CopyKV(src, dst string) error {
+	f.copied = append(f.copied, Copy{From: src, To: dst})
+	if f.data == nil {
+		f.data = make(map[string]map[string]interface{})
+	}
+	f.data[dst] = f.data[src]
+	return nil
+}
+
+func TestPlanVaultCopy(t *testing.T) {
+	root, _ := writeFixture(t)
+	tree := &fakeTree{dirs: map[string][]vault.VaultEntry{
+		"staging/":          {{Name: "api", IsDir: false}, {Name: "database/", IsDir: true}},
+		"staging/database/": {{Name: "url", IsDir: false}},
+	}}
+
+	plan, err := NewPlan(root, nil, "staging", "stage")
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if err := plan.PlanVaultCopy(tree); err != nil {
+		t.Fatalf("PlanVaultCopy() error = %v", err)
+	}
+
+	want := []Copy{
+		{From: "staging/api", To: "stage/api"},
+		{From: "staging/database/url", To: "stage/database/url"},
+	}
+	if len(plan.Copies) != len(want) {
+		t.Fatalf("Copies = %v, want %v", plan.Copies, want)
+	}
+	for i := range want {
+		if plan.Copies[i] != want[i] {
+			t.Errorf("Copies[%d] = %v, want %v", i, plan.Copies[i], want[i])
+		}
+	}
+
+	if err := plan.Apply(tree); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if len(tree.copied) != 2 {
+		t.Errorf("copied %d secrets, want 2", len(tree.copied))
+	}
+}
+
+func TestPlanVaultCopy_EnvironmentMap(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "vx.toml")
+	content := `[environments]
+default = "dev"
+available = ["dev", "staging", "production"]
+
+[environments.map]
+staging = "stg"
+dev = "development"
+`
+	if err := os.WriteFile(root, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tree := &fakeTree{dirs: map[string][]vault.VaultEntry{
+		"stg/":         {{Name: "api", IsDir: false}},
+		"staging/":     {{Name: "unrelated", IsDir: false}},
+		"development/": {{Name: "api", IsDir: false}},
+		"dev/":         {{Name: "unrelated", IsDir: false}},
+	}}
+
+	// A mapped environment keeps reading stg/: nothing to copy.
+	plan, err := NewPlan(root, nil, "staging", "stage")
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if err := plan.PlanVaultCopy(tree); err != nil {
+		t.Fatalf("PlanVaultCopy() error = %v", err)
+	}
+	if plan.OldSegment != "stg" || plan.NewSegment != "stg" || len(plan.Copies) != 0 {
+		t.Errorf("segments %q -> %q, copies %v; want stg -> stg and none", plan.OldSegment, plan.NewSegment, plan.Copies)
+	}
+
+	// An unmapped environment copies from its own name.
+	plan, err = NewPlan(root, nil, "production", "live")
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	tree.dirs["production/"] = []vault.VaultEntry{{Name: "api", IsDir: false}}
+	if err := plan.PlanVaultCopy(tree); err != nil {
+		t.Fatalf("PlanVaultCopy() error = %v", err)
+	}
+	if want := (Copy{From: "production/api", To: "live/api"}); len(plan.Copies) != 1 || plan.Copies[0] != want {
+		t.Errorf("Copies = %v, want [%v]", plan.Copies, want)
+	}
+}
+
+func TestPlanApply_Resumes(t *testing.T) {
+	root, _ := writeFixture(t)
+	tree := &fakeTree{
+		dirs: map[string][]vault.VaultEntry{
+			"staging/": {{Name: "api"}, {Name: "db"}},
+		},
+		data: map[string]map[string]interface{}{
+			"staging/api": {"key": "k-1"},
+			"staging/db":  {"url": "pg://staging"},
+			// Copied by an earlier run that failed on staging/db.
+			"stage/api": {"key": "k-1"},
+		},
+	}
+
+	plan, err := NewPlan(root, nil, "staging", "stage")
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if err := plan.PlanVaultCopy(tree); err != nil {
+		t.Fatalf("PlanVaultCopy() error = %v", err)
+	}
+	if err := plan.Apply(tree); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	want := []Copy{{From: "staging/db", To: "stage/db"}}
+	if len(tree.copied) != 1 || tree.copied[0] != want[0] {
+		t.Errorf("copied = %v, want %v", tree.copied, want)
+	}
+
+	// A different secret at the new path is never overwritten.
+	root, _ = writeFixture(t)
+	tree.data["stage/db"] = map[string]interface{}{"url": "pg://other"}
+	plan, err = NewPlan(root, nil, "staging", "stage")
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	if err := plan.PlanVaultCopy(tree); err != nil {
+		t.Fatalf("PlanVaultCopy() error = %v", err)
+	}
+	if err := plan.Apply(tree); err == nil {
+		t.Error("Apply() over different values: error = nil")
+	}
+	data, err := os.ReadFile(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"stage"`) {
+		t.Error("the config was written although a copy failed")
+	}
+}
@@ -0,0 +1,47 @@
+package exec
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// placeholderPattern matches "{{KEY}}", allowing spaces inside the braces.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// ExpandArgs replaces "{{KEY}}" placeholders in args with values[KEY]. It
+// returns the expanded arguments and the sorted, de-duplicated keys that were
+// used. A placeholder naming a key not in values is an error, so a typo never
+// reaches the child as a literal "{{...}}". The input slice is not mutated.
+func ExpandArgs(args []string, values map[string]string) ([]string, []string, error) {
+	out := make([]string, len(args))
+	used := make(map[string]bool)
+	var missing string
+
+	for i, arg := range args {
+		out[i] = placeholderPattern.ReplaceAllStringFunc(arg, func(m string) string {
+			key := placeholderPattern.FindStringSubmatch(m)[1]
+			val, ok := values[key]
+			if !ok {
+				if missing == "" {
+					missing = key
+				}
+				return m
+			}
+			used[key] = true
+			return val
+		})
+	}
+
+	if missing != "" {
+		return nil, nil, fmt.Errorf("{{%s}} does not name a mapped secret or default", missing)
+	}
+
+	keys := make([]string, 0, len(used))
+	for k := range used {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return out, keys, nil
+}
@@ -0,0 +1,37 @@
+package exec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandArgs(t *testing.T) {
+	values := map[string]string{
+		"DB_PASSWORD": "s3cret",
+		"DB_USER":     "admin",
+	}
+	args := []string{"psql", "-U", "{{DB_USER}}", "--password={{ DB_PASSWORD }}", "{{DB_USER}}@{{DB_USER}}", "{not}"}
+
+	got, used, err := ExpandArgs(args, values)
+	if err != nil {
+		t.Fatalf("ExpandArgs() error = %v", err)
+	}
+
+	want := []string{"psql", "-U", "admin", "--password=s3cret", "admin@admin", "{not}"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandArgs() = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(used, []string{"DB_PASSWORD", "DB_USER"}) {
+		t.Errorf("used = %v, want [DB_PASSWORD DB_USER]", used)
+	}
+	if args[2] != "{{DB_USER}}" {
+		t.Error("ExpandArgs() mutated its input")
+	}
+}
+
+func TestExpandArgs_UnknownKey(t *testing.T) {
+	_, _, err := ExpandArgs([]string{"tool", "{{DB_PASWORD}}"}, map[string]string{"DB_PASSWORD": "x"})
+	if err == nil {
+		t.Fatal("ExpandArgs() expected error for unknown key")
+	}
+}
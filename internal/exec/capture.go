@@ -0,0 +1,124 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// maxCapturedLine bounds a single line kept by Capture; the rest of a longer
+// line is dropped from the tail (but still written to the log file).
+const maxCapturedLine = 4096
+
+// Capture collects a child's output instead of showing it: everything is
+// appended to an optional log file, and the last lines are kept in memory so
+// they can be shown if the child fails. It is safe to use as both stdout and
+// stderr.
+type Capture struct {
+	mu      sync.Mutex
+	file    *os.File
+	lines   []string // ring buffer of the last complete lines
+	next    int      // index in lines the next line is written to
+	full    bool     // lines has wrapped around
+	partial []byte   // the current, unterminated line
+}
+
+// NewCapture returns a Capture keeping the last n lines. When logPath is not
+// empty, all output is also written to that file (created or truncated,
+// mode 0600).
+func NewCapture(logPath string, n int) (*Capture, error) {
+	c := &Capture{lines: make([]string, max(n, 1))}
+
+	if logPath != "" {
+		if err := os.MkdirAll(filepath.Dir(logPath), 0700); err != nil {
+			return nil, fmt.Errorf("creating log directory: %w", err)
+		}
+		f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("opening capture log: %w", err)
+		}
+		c.file = f
+	}
+
+	return c, nil
+}
+
+// Write implements io.Writer.
+func (c *Capture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.file != nil {
+		if _, err := c.file.Write(p); err != nil {
+			return 0, fmt.Errorf("writing capture log: %w", err)
+		}
+	}
+
+	rest := p
+	for len(rest) > 0 {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			c.appendPartial(rest)
+			break
+		}
+		c.appendPartial(rest[:i])
+		c.pushLine()
+		rest = rest[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// appendPartial adds b to the current line, up to maxCapturedLine bytes.
+func (c *Capture) appendPartial(b []byte) {
+	if room := maxCapturedLine - len(c.partial); room > 0 {
+		c.partial = append(c.partial, b[:min(len(b), room)]...)
+	}
+}
+
+// pushLine moves the current line into the ring buffer.
+func (c *Capture) pushLine() {
+	c.lines[c.next] = string(bytes.TrimSuffix(c.partial, []byte("\r")))
+	c.partial = c.partial[:0]
+	c.next++
+	if c.next == len(c.lines) {
+		c.next = 0
+		c.full = true
+	}
+}
+
+// Tail returns the last lines written, oldest first, including a final line
+// without a trailing newline.
+func (c *Capture) Tail() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var tail []string
+	if c.full {
+		tail = append(tail, c.lines[c.next:]...)
+	}
+	tail = append(tail, c.lines[:c.next]...)
+
+	if len(c.partial) > 0 {
+		tail = append(tail, string(c.partial))
+		if len(tail) > len(c.lines) {
+			tail = tail[1:]
+		}
+	}
+	return tail
+}
+
+// Close closes the log file, if any.
+func (c *Capture) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
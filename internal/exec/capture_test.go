@@ -0,0 +1,74 @@
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCapture_tail(t *testing.T) {
+	c, err := NewCapture("", 3)
+	if err != nil {
+		t.Fatalf("NewCapture() error = %v", err)
+	}
+
+	c.Write([]byte("one\ntwo\nthr"))
+	c.Write([]byte("ee\r\nfour\nfive"))
+
+	got := c.Tail()
+	want := []string{"three", "four", "five"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Tail() = %q, want %q", got, want)
+	}
+}
+
+func TestCapture_fewerLinesThanLimit(t *testing.T) {
+	c, err := NewCapture("", 10)
+	if err != nil {
+		t.Fatalf("NewCapture() error = %v", err)
+	}
+
+	c.Write([]byte("a\nb\n"))
+
+	if got := c.Tail(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Tail() = %q, want [a b]", got)
+	}
+}
+
+func TestCapture_longLine(t *testing.T) {
+	c, err := NewCapture("", 2)
+	if err != nil {
+		t.Fatalf("NewCapture() error = %v", err)
+	}
+
+	c.Write([]byte(strings.Repeat("x", maxCapturedLine+100) + "\n"))
+
+	if got := c.Tail(); len(got) != 1 || len(got[0]) != maxCapturedLine {
+		t.Errorf("Tail() kept %d bytes of a long line, want %d", len(got[0]), maxCapturedLine)
+	}
+}
+
+func TestCapture_logFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "build.log")
+	c, err := NewCapture(path, 1)
+	if err != nil {
+		t.Fatalf("NewCapture() error = %v", err)
+	}
+
+	c.Write([]byte("first\nsecond\n"))
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "first\nsecond\n" {
+		t.Errorf("log file = %q, want all output", data)
+	}
+	if got := c.Tail(); len(got) != 1 || got[0] != "second" {
+		t.Errorf("Tail() = %q, want [second]", got)
+	}
+}
@@ -0,0 +1,130 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// EnvLimits are the sizes the operating system accepts when starting a
+// process. Zero means no limit.
+type EnvLimits struct {
+	// Total bounds the arguments and environment together, counting each
+	// string with its terminating NUL and the pointer to it.
+	Total int
+	// Entry bounds a single "KEY=value" string.
+	Entry int
+}
+
+// PlatformLimits returns the limits of the current operating system. They are
+// the defaults; a system configured with a larger stack (Linux) accepts more.
+func PlatformLimits() EnvLimits {
+	switch runtime.GOOS {
+	case "windows":
+		// 32767 UTF-16 characters per variable; the block has no fixed cap.
+		return EnvLimits{Entry: 32767}
+	case "darwin":
+		return EnvLimits{Total: 1024 * 1024}
+	case "freebsd", "openbsd", "netbsd":
+		return EnvLimits{Total: 256 * 1024}
+	default:
+		// ARG_MAX is a quarter of the default 8 MiB stack, and execve rejects
+		// any one string longer than MAX_ARG_STRLEN (32 pages).
+		return EnvLimits{Total: 2 * 1024 * 1024, Entry: 128 * 1024}
+	}
+}
+
+// EnvEntry is one environment variable and the bytes it takes up.
+type EnvEntry struct {
+	Key  string
+	Size int
+}
+
+// EnvReport describes how much of the limits a command's arguments and
+// environment use.
+type EnvReport struct {
+	Total  int
+	Limits EnvLimits
+	// Oversized lists the entries longer than Limits.Entry.
+	Oversized []EnvEntry
+	// Largest lists up to five of the largest entries, largest first.
+	Largest []EnvEntry
+}
+
+// MeasureEnv measures command together with the environment Run would pass
+// it: the current environment with env merged over it.
+func MeasureEnv(command []string, env map[string]string, limits EnvLimits) EnvReport {
+	return measure(command, mergeEnv(os.Environ(), env), limits)
+}
+
+func measure(command []string, env []string, limits EnvLimits) EnvReport {
+	r := EnvReport{Limits: limits}
+	const ptr = 8 // each string is also referenced from the argv/envp arrays
+
+	for _, arg := range command {
+		r.Total += len(arg) + 1 + ptr
+	}
+
+	entries := make([]EnvEntry, 0, len(env))
+	for _, kv := range env {
+		key, _ := splitEnvEntry(kv)
+		e := EnvEntry{Key: key, Size: len(kv) + 1}
+		r.Total += e.Size + ptr
+		if limits.Entry > 0 && e.Size > limits.Entry {
+			r.Oversized = append(r.Oversized, e)
+		}
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Size != entries[j].Size {
+			return entries[i].Size > entries[j].Size
+		}
+		return entries[i].Key < entries[j].Key
+	})
+	r.Largest = entries[:min(5, len(entries))]
+	sort.Slice(r.Oversized, func(i, j int) bool { return r.Oversized[i].Key < r.Oversized[j].Key })
+	return r
+}
+
+// Err returns an error naming the offending variables when the command
+// cannot be started with this environment, or nil.
+func (r EnvReport) Err() error {
+	if len(r.Oversized) > 0 {
+		return fmt.Errorf("%s over the %s limit for a single environment variable",
+			formatEntries(r.Oversized), formatSize(r.Limits.Entry))
+	}
+	if r.Limits.Total > 0 && r.Total > r.Limits.Total {
+		return fmt.Errorf("arguments and environment take %s, over the %s limit; largest: %s",
+			formatSize(r.Total), formatSize(r.Limits.Total), formatEntries(r.Largest))
+	}
+	return nil
+}
+
+// NearLimit reports whether the total is within a quarter of its limit, so
+// a little more (a longer PATH, another secret) could push it over.
+func (r EnvReport) NearLimit() bool {
+	return r.Limits.Total > 0 && r.Total > r.Limits.Total*3/4
+}
+
+// formatEntries renders entries as "KEY (12.0 KiB), ...".
+func formatEntries(entries []EnvEntry) string {
+	parts := make([]string, len(entries))
+	for i, e := range entries {
+		parts[i] = fmt.Sprintf("%s (%s)", e.Key, formatSize(e.Size))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatSize(n int) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.1f KiB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
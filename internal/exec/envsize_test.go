@@ -0,0 +1,47 @@
+package exec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMeasure_Oversized(t *testing.T) {
+	env := []string{"SMALL=x", "BLOB=" + strings.Repeat("a", 200), "PATH=/bin"}
+	r := measure([]string{"node"}, env, EnvLimits{Total: 10000, Entry: 100})
+
+	if len(r.Oversized) != 1 || r.Oversized[0].Key != "BLOB" {
+		t.Fatalf("Oversized = %v, want BLOB", r.Oversized)
+	}
+	if r.Largest[0].Key != "BLOB" || r.Largest[0].Size != 206 {
+		t.Errorf("Largest[0] = %v, want BLOB of 206 bytes", r.Largest[0])
+	}
+
+	err := r.Err()
+	if err == nil || !strings.Contains(err.Error(), "BLOB (206 B)") {
+		t.Errorf("Err() = %v, want it to name BLOB", err)
+	}
+}
+
+func TestMeasure_Total(t *testing.T) {
+	var env []string
+	for _, k := range []string{"A", "B", "C", "D", "E", "F"} {
+		env = append(env, k+"="+strings.Repeat("v", 50))
+	}
+
+	r := measure([]string{"sh"}, env, EnvLimits{Total: 200})
+	if len(r.Largest) != 5 {
+		t.Errorf("Largest has %d entries, want 5", len(r.Largest))
+	}
+	err := r.Err()
+	if err == nil || !strings.Contains(err.Error(), "over the 200 B limit") {
+		t.Errorf("Err() = %v, want the total limit exceeded", err)
+	}
+
+	r = measure([]string{"sh"}, env[:1], EnvLimits{Total: 90})
+	if r.Err() != nil || !r.NearLimit() {
+		t.Errorf("Err() = %v, NearLimit() = %v; want nil, true", r.Err(), r.NearLimit())
+	}
+	if r := measure(nil, nil, EnvLimits{}); r.Err() != nil || r.NearLimit() {
+		t.Error("a report without limits is not within them")
+	}
+}
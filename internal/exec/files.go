@@ -0,0 +1,44 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileSuffix is appended to a variable's name to form the variable that
+// holds the path of its file, following the KEY_FILE convention of many
+// container images.
+const FileSuffix = "_FILE"
+
+// WriteFiles writes the value of each of keys to its own file, readable only
+// by the current user, in a new temporary directory. It returns the directory,
+// which the caller removes once the command has exited, and the KEY_FILE
+// variables pointing at the files. The values are removed from env.
+func WriteFiles(env map[string]string, keys []string) (string, map[string]string, error) {
+	for _, k := range keys {
+		if _, ok := env[k]; !ok {
+			return "", nil, fmt.Errorf("--file %s: not a mapped secret or default", k)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "vx-exec-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("creating directory for value files: %w", err)
+	}
+
+	paths := make(map[string]string, len(keys))
+	for _, k := range keys {
+		path := filepath.Join(dir, k)
+		if err := os.WriteFile(path, []byte(env[k]), 0600); err != nil {
+			os.RemoveAll(dir)
+			return "", nil, fmt.Errorf("writing value file for %s: %w", k, err)
+		}
+		paths[k+FileSuffix] = path
+	}
+
+	for _, k := range keys {
+		delete(env, k)
+	}
+	return dir, paths, nil
+}
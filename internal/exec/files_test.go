@@ -0,0 +1,36 @@
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFiles(t *testing.T) {
+	env := map[string]string{"CERT": "-----BEGIN-----", "PORT": "8080"}
+
+	dir, files, err := WriteFiles(env, []string{"CERT"})
+	if err != nil {
+		t.Fatalf("WriteFiles() error = %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := files["CERT_FILE"]
+	if filepath.Dir(path) != dir {
+		t.Fatalf("CERT_FILE = %q, want a file in %s", path, dir)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil || string(b) != "-----BEGIN-----" {
+		t.Errorf("file holds %q (%v), want the value", b, err)
+	}
+	if _, ok := env["CERT"]; ok {
+		t.Error("CERT is still in the environment")
+	}
+	if env["PORT"] != "8080" {
+		t.Error("WriteFiles() removed a value it was not asked to write")
+	}
+
+	if _, _, err := WriteFiles(env, []string{"MISSING"}); err == nil {
+		t.Error("WriteFiles() accepted an unknown key")
+	}
+}
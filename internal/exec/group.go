@@ -0,0 +1,331 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultStopTimeout is how long RunGroup waits for interrupted processes
+// before killing them.
+const defaultStopTimeout = 10 * time.Second
+
+// outputWaitDelay bounds how long RunGroup keeps copying a process's output
+// after it exits.
+const outputWaitDelay = time.Second
+
+// prefixColors are the ANSI colors cycled through for process name
+// prefixes.
+var prefixColors = []string{"36", "33", "32", "35", "34", "96", "93", "92", "95", "94"}
+
+// Process is one command of a group started by RunGroup.
+type Process struct {
+	Name    string
+	Command []string
+	// Dir is the working directory; empty means vx's own.
+	Dir string
+	// Env is merged over the current process environment.
+	Env map[string]string
+}
+
+// groupSettings holds the optional behaviour of RunGroup.
+type groupSettings struct {
+	out         io.Writer
+	color       bool
+	stopTimeout time.Duration
+}
+
+// GroupOption configures RunGroup.
+type GroupOption func(*groupSettings)
+
+// WithGroupOutput sends every process's prefixed stdout and stderr to w
+// instead of os.Stdout.
+func WithGroupOutput(w io.Writer) GroupOption {
+	return func(s *groupSettings) {
+		s.out = w
+	}
+}
+
+// WithColor colors each process's name prefix.
+func WithColor(on bool) GroupOption {
+	return func(s *groupSettings) {
+		s.color = on
+	}
+}
+
+// WithStopTimeout sets how long stopping processes get to exit after the
+// interrupt before they are killed. Zero or negative values are ignored.
+func WithStopTimeout(d time.Duration) GroupOption {
+	return func(s *groupSettings) {
+		if d > 0 {
+			s.stopTimeout = d
+		}
+	}
+}
+
+// ShellCommand returns the argv that runs line through the platform shell:
+// sh -c on Unix and cmd /C on Windows.
+func ShellCommand(line string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C", line}
+	}
+	return []string{"sh", "-c", line}
+}
+
+// ScriptCommand returns the argv that runs line through the platform shell
+// with args after it. On Unix the arguments reach line as "$@", so the shell
+// never interprets them. cmd /C has no such mechanism, so on Windows they
+// are appended to line, double-quoted when they contain spaces or quotes.
+func ScriptCommand(line string, args []string) []string {
+	if len(args) == 0 {
+		return ShellCommand(line)
+	}
+	if runtime.GOOS == "windows" {
+		parts := []string{line}
+		for _, a := range args {
+			if a == "" || strings.ContainsAny(a, " \t\"") {
+				a = `"` + strings.ReplaceAll(a, `"`, `""`) + `"`
+			}
+			parts = append(parts, a)
+		}
+		return ShellCommand(strings.Join(parts, " "))
+	}
+	return append([]string{"sh", "-c", line + ` "$@"`, "sh"}, args...)
+}
+
+// member is a started process of a group.
+type member struct {
+	proc Process
+	cmd  *exec.Cmd
+	tree *processTree
+	out  []*prefixWriter
+	done chan struct{}
+	err  error
+}
+
+// RunGroup starts procs one after another, in the order given, and waits.
+// Each line a process writes is prefixed with its name. The group stops as
+// a whole: when any process exits, when vx is interrupted, or when ctx is
+// done, the remaining processes are interrupted in reverse start order and
+// killed if they are still running after the stop timeout (a second
+// interrupt kills them at once).
+//
+// RunGroup returns the error of the process whose exit stopped the group,
+// wrapped with its name so ExitCode still applies, or nil when the group
+// was stopped by an interrupt or ctx.
+func RunGroup(ctx context.Context, procs []Process, opts ...GroupOption) error {
+	settings := groupSettings{out: os.Stdout, stopTimeout: defaultStopTimeout}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	width := 0
+	for _, p := range procs {
+		width = max(width, len(p.Name))
+	}
+
+	// Interrupts reach children that share vx's process group directly;
+	// those in a group of their own (outside a terminal on Unix, always on
+	// Windows) are interrupted when vx stops the group.
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	var mu sync.Mutex
+	exited := make(chan *member, len(procs))
+	var started []*member
+
+	var result error
+	stopped := false
+	for i, p := range procs {
+		prefix := fmt.Sprintf("%-*s | ", width, p.Name)
+		if settings.color {
+			prefix = "\x1b[" + prefixColors[i%len(prefixColors)] + "m" + prefix + "\x1b[0m"
+		}
+
+		m, err := startMember(p, settings.out, prefix, &mu)
+		if err != nil {
+			result = err
+			stopped = true
+			break
+		}
+		started = append(started, m)
+		go func() {
+			m.err = m.cmd.Wait()
+			for _, w := range m.out {
+				w.Flush()
+			}
+			close(m.done)
+			exited <- m
+		}()
+
+		// Stop starting services once one has already failed or vx was
+		// interrupted.
+		select {
+		case m := <-exited:
+			result = memberExit(m, settings.out, &mu)
+			stopped = true
+		case <-sigCh:
+			stopped = true
+		case <-ctx.Done():
+			stopped = true
+		default:
+		}
+		if stopped {
+			break
+		}
+	}
+
+	if !stopped {
+		select {
+		case m := <-exited:
+			result = memberExit(m, settings.out, &mu)
+		case <-sigCh:
+		case <-ctx.Done():
+		}
+	}
+
+	stopMembers(started, settings.stopTimeout, sigCh)
+	return result
+}
+
+// startMember starts p with its output prefixed and written to out.
+func startMember(p Process, out io.Writer, prefix string, mu *sync.Mutex) (*member, error) {
+	if len(p.Command) == 0 {
+		return nil, fmt.Errorf("%s: command must not be empty", p.Name)
+	}
+
+	stdout := &prefixWriter{w: out, prefix: prefix, mu: mu}
+	stderr := &prefixWriter{w: out, prefix: prefix, mu: mu}
+
+	cmd := exec.Command(p.Command[0], p.Command[1:]...)
+	cmd.Dir = p.Dir
+	cmd.Env = mergeEnv(os.Environ(), p.Env)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// A process may leave children holding its output open; don't let them
+	// keep Wait from returning once it has exited.
+	cmd.WaitDelay = outputWaitDelay
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("%s: starting command %q: %w", p.Name, p.Command[0], err)
+	}
+
+	tree, err := attachProcessTree(cmd.Process)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("%s: %w", p.Name, err)
+	}
+
+	return &member{
+		proc: p,
+		cmd:  cmd,
+		tree: tree,
+		out:  []*prefixWriter{stdout, stderr},
+		done: make(chan struct{}),
+	}, nil
+}
+
+// memberExit reports the exit of the process that stops the group and
+// returns its error.
+func memberExit(m *member, out io.Writer, mu *sync.Mutex) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if m.err != nil {
+		fmt.Fprintf(out, "%s exited: %v, stopping the others\n", m.proc.Name, m.err)
+		return fmt.Errorf("%s: %w", m.proc.Name, m.err)
+	}
+	fmt.Fprintf(out, "%s exited, stopping the others\n", m.proc.Name)
+	return nil
+}
+
+// stopMembers interrupts the running members in reverse start order and
+// waits for them, killing whatever is left after timeout or a further
+// signal.
+func stopMembers(members []*member, timeout time.Duration, sigCh <-chan os.Signal) {
+	for i := len(members) - 1; i >= 0; i-- {
+		select {
+		case <-members[i].done:
+		default:
+			_ = interrupt(members[i].cmd.Process)
+		}
+	}
+
+	deadline := time.After(timeout)
+	for _, m := range members {
+		select {
+		case <-m.done:
+		case <-deadline:
+			killMembers(members)
+			<-m.done
+		case <-sigCh:
+			killMembers(members)
+			<-m.done
+		}
+	}
+
+	for _, m := range members {
+		_ = m.tree.Close()
+	}
+}
+
+// killMembers kills every member still running.
+func killMembers(members []*member) {
+	for _, m := range members {
+		select {
+		case <-m.done:
+		default:
+			_ = m.cmd.Process.Kill()
+		}
+	}
+}
+
+// prefixWriter writes complete lines to w, each starting with prefix.
+// Writers of one group share mu so lines from different processes never
+// interleave.
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+	mu     *sync.Mutex
+	buf    []byte
+}
+
+// Write buffers p and writes out every line it completes.
+func (pw *prefixWriter) Write(p []byte) (int, error) {
+	pw.buf = append(pw.buf, p...)
+	for {
+		i := bytes.IndexByte(pw.buf, '\n')
+		if i < 0 {
+			break
+		}
+		pw.writeLine(pw.buf[:i])
+		pw.buf = pw.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes out a final line that didn't end in a newline.
+func (pw *prefixWriter) Flush() {
+	if len(pw.buf) > 0 {
+		pw.writeLine(pw.buf)
+		pw.buf = nil
+	}
+}
+
+func (pw *prefixWriter) writeLine(line []byte) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	_, _ = io.WriteString(pw.w, pw.prefix+strings.TrimSuffix(string(line), "\r")+"\n")
+}
@@ -0,0 +1,30 @@
+package exec
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	pw := &prefixWriter{w: &out, prefix: "api | ", mu: &sync.Mutex{}}
+
+	pw.Write([]byte("listening"))
+	pw.Write([]byte(" on :8080\r\nready\npartial"))
+	if got, want := out.String(), "api | listening on :8080\napi | ready\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	pw.Flush()
+	if got, want := out.String(), "api | listening on :8080\napi | ready\napi | partial\n"; got != want {
+		t.Errorf("output after Flush = %q, want %q", got, want)
+	}
+}
+
+func TestShellCommand(t *testing.T) {
+	argv := ShellCommand("echo hi")
+	if len(argv) != 3 || argv[2] != "echo hi" {
+		t.Errorf("ShellCommand() = %q", argv)
+	}
+}
@@ -0,0 +1,115 @@
+//go:build !windows
+
+package exec
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe to read while a group writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRunGroup_StopsOthersOnExit(t *testing.T) {
+	var out syncBuffer
+	procs := []Process{
+		{Name: "db", Command: ShellCommand("echo db up; exec sleep 30")},
+		{Name: "api", Command: ShellCommand(`sleep 0.2; echo "$API_TOKEN"; exit 3`), Env: map[string]string{"API_TOKEN": "s3cret"}},
+	}
+
+	start := time.Now()
+	err := RunGroup(context.Background(), procs, WithGroupOutput(&out), WithStopTimeout(5*time.Second))
+
+	if code := ExitCode(err); code != 3 {
+		t.Errorf("ExitCode() = %d, want 3 (err = %v)", code, err)
+	}
+	if err == nil || !strings.HasPrefix(err.Error(), "api:") {
+		t.Errorf("error = %v, want it to name the api process", err)
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("group took %v; db should have been stopped", elapsed)
+	}
+
+	got := out.String()
+	for _, want := range []string{"db  | db up\n", "api | s3cret\n", "api exited"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestRunGroup_KillsAfterStopTimeout(t *testing.T) {
+	var out syncBuffer
+	procs := []Process{
+		{Name: "stubborn", Command: ShellCommand(`trap "" TERM; echo ready; while :; do sleep 0.1; done`)},
+		{Name: "quick", Command: ShellCommand("sleep 0.3")},
+	}
+
+	start := time.Now()
+	if err := RunGroup(context.Background(), procs, WithGroupOutput(&out), WithStopTimeout(300*time.Millisecond)); err != nil {
+		t.Errorf("RunGroup() error = %v, want nil for a clean exit", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("group took %v; stubborn process should have been killed", elapsed)
+	}
+}
+
+func TestRunGroup_ContextCancel(t *testing.T) {
+	var out syncBuffer
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	err := RunGroup(ctx, []Process{{Name: "web", Command: ShellCommand("exec sleep 30")}}, WithGroupOutput(&out))
+	if err != nil {
+		t.Errorf("RunGroup() error = %v, want nil when ctx ends", err)
+	}
+}
+
+func TestRunGroup_StartFailure(t *testing.T) {
+	var out syncBuffer
+	procs := []Process{
+		{Name: "ok", Command: ShellCommand("exec sleep 30")},
+		{Name: "missing", Command: []string{"/nonexistent/binary"}},
+	}
+
+	err := RunGroup(context.Background(), procs, WithGroupOutput(&out), WithStopTimeout(time.Second))
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("RunGroup() error = %v, want a start failure for missing", err)
+	}
+}
+
+func TestScriptCommand(t *testing.T) {
+	if argv := ScriptCommand("bun dev", nil); !slices.Equal(argv, ShellCommand("bun dev")) {
+		t.Errorf("ScriptCommand() without args = %q, want the plain shell command", argv)
+	}
+
+	argv := ScriptCommand(`printf '<%s>'`, []string{"a b", "$HOME", "-w"})
+	out, err := exec.Command(argv[0], argv[1:]...).Output()
+	if err != nil {
+		t.Fatalf("running %q: %v", argv, err)
+	}
+	if got, want := string(out), "<a b><$HOME><-w>"; got != want {
+		t.Errorf("output = %q, want the arguments passed through untouched %q", got, want)
+	}
+}
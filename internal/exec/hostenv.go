@@ -0,0 +1,57 @@
+package exec
+
+import (
+	"path"
+	"runtime"
+	"strings"
+)
+
+// WithHostEnv limits the variables of vx's own environment the child
+// inherits. When passthrough is not empty, only variables whose names match
+// one of its patterns are kept; variables matching a block pattern are
+// always dropped. Patterns use path.Match syntax and, like environment
+// variable names there, are compared case-insensitively on Windows. The
+// variables passed to Run are never filtered.
+func WithHostEnv(passthrough, block []string) Option {
+	return func(s *runSettings) {
+		s.passthrough = passthrough
+		s.block = block
+	}
+}
+
+// filterEnv returns the "KEY=VALUE" entries of environ whose keys
+// passthrough and block allow, in order.
+func filterEnv(environ, passthrough, block []string) []string {
+	if len(passthrough) == 0 && len(block) == 0 {
+		return environ
+	}
+
+	result := make([]string, 0, len(environ))
+	for _, entry := range environ {
+		key, _ := splitEnvEntry(entry)
+		if len(passthrough) > 0 && !matchesAny(passthrough, key) {
+			continue
+		}
+		if matchesAny(block, key) {
+			continue
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// matchesAny reports whether name matches one of patterns.
+func matchesAny(patterns []string, name string) bool {
+	if runtime.GOOS == "windows" {
+		name = strings.ToUpper(name)
+	}
+	for _, p := range patterns {
+		if runtime.GOOS == "windows" {
+			p = strings.ToUpper(p)
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
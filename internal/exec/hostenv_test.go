@@ -0,0 +1,29 @@
+package exec
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFilterEnv(t *testing.T) {
+	environ := []string{"PATH=/usr/bin", "HOME=/home/dev", "NODE_ENV=test", "AWS_SECRET_ACCESS_KEY=x", "GITHUB_TOKEN=y"}
+
+	tests := []struct {
+		name        string
+		passthrough []string
+		block       []string
+		want        []string
+	}{
+		{"no patterns", nil, nil, environ},
+		{"passthrough", []string{"PATH", "HOME", "NODE_*"}, nil, environ[:3]},
+		{"block", nil, []string{"AWS_*", "*_TOKEN"}, environ[:3]},
+		{"block wins", []string{"*"}, []string{"HOME"}, []string{"PATH=/usr/bin", "NODE_ENV=test", "AWS_SECRET_ACCESS_KEY=x", "GITHUB_TOKEN=y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filterEnv(environ, tt.passthrough, tt.block); !slices.Equal(got, tt.want) {
+				t.Errorf("filterEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package exec
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// minMaskedLength is the length below which values are not masked: hiding
+// every "1" or "on" would garble unrelated output without protecting much.
+const minMaskedLength = 4
+
+// maskReplacement is written in place of a masked value.
+var maskReplacement = []byte("***")
+
+// Masker replaces secret values in what is written through it with "***"
+// before passing it on. Values may be split across writes: output that could
+// be the start of a value is held back until the next write shows whether it
+// is, or until Flush. It is safe to use as both stdout and stderr.
+type Masker struct {
+	mu     sync.Mutex
+	w      io.Writer
+	values [][]byte // longest first, so a longer value wins over its prefix
+	first  [256]bool
+	held   []byte
+}
+
+// NewMasker returns a Masker writing to w that hides values. Each line of a
+// multi-line value is also hidden on its own, for tools that print them
+// with a prefix. Values shorter than four bytes are left alone.
+func NewMasker(w io.Writer, values []string) *Masker {
+	m := &Masker{w: w}
+	seen := make(map[string]bool)
+	add := func(v string) {
+		if len(v) < minMaskedLength || seen[v] {
+			return
+		}
+		seen[v] = true
+		m.values = append(m.values, []byte(v))
+		m.first[v[0]] = true
+	}
+	for _, v := range values {
+		add(v)
+		if strings.Contains(v, "\n") {
+			for _, line := range strings.Split(v, "\n") {
+				add(strings.TrimSuffix(line, "\r"))
+			}
+		}
+	}
+	sort.SliceStable(m.values, func(i, j int) bool { return len(m.values[i]) > len(m.values[j]) })
+	return m
+}
+
+// Write implements io.Writer.
+func (m *Masker) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.held = append(m.held, p...)
+	out, rest := m.mask(m.held, false)
+	m.held = append(m.held[:0], rest...)
+	if len(out) > 0 {
+		if _, err := m.w.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes the output held back as the possible start of a value.
+func (m *Masker) Flush() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out, _ := m.mask(m.held, true)
+	m.held = m.held[:0]
+	if len(out) == 0 {
+		return nil
+	}
+	_, err := m.w.Write(out)
+	return err
+}
+
+// mask returns buf with every value replaced, and the tail of buf that may
+// be the start of a value and must wait for more output. When final is set
+// nothing is held back.
+func (m *Masker) mask(buf []byte, final bool) (out, rest []byte) {
+	out = make([]byte, 0, len(buf))
+	for i := 0; i < len(buf); {
+		if !m.first[buf[i]] {
+			out = append(out, buf[i])
+			i++
+			continue
+		}
+
+		matched := 0
+		for _, v := range m.values {
+			if bytes.HasPrefix(buf[i:], v) {
+				matched = len(v)
+				break
+			}
+			if !final && bytes.HasPrefix(v, buf[i:]) {
+				return out, buf[i:]
+			}
+		}
+		if matched > 0 {
+			out = append(out, maskReplacement...)
+			i += matched
+			continue
+		}
+		out = append(out, buf[i])
+		i++
+	}
+	return out, nil
+}
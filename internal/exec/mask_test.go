@@ -0,0 +1,40 @@
+package exec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMasker(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		writes []string
+		want   string
+	}{
+		{"single write", []string{"hunter22"}, []string{"password=hunter22\n"}, "password=***\n"},
+		{"split across writes", []string{"hunter22"}, []string{"password=hun", "ter22 ok\n"}, "password=*** ok\n"},
+		{"held tail flushed", []string{"hunter22"}, []string{"prompt: hun"}, "prompt: hun"},
+		{"longest value wins", []string{"abcd", "abcdef"}, []string{"x abcd", "ef abcd y"}, "x *** *** y"},
+		{"short values left alone", []string{"on", "1"}, []string{"turn it on 1\n"}, "turn it on 1\n"},
+		{"lines of multi-line values", []string{"-----BEGIN KEY-----\nMIIEvQIBADAN\n-----END KEY-----"}, []string{"key: MIIEvQIBADAN\n"}, "key: ***\n"},
+		{"repeated", []string{"s3cr3t"}, []string{"s3cr3ts3cr3t"}, "******"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			m := NewMasker(&out, tt.values)
+			for _, w := range tt.writes {
+				if n, err := m.Write([]byte(w)); err != nil || n != len(w) {
+					t.Fatalf("Write(%q) = %d, %v", w, n, err)
+				}
+			}
+			if err := m.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package exec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// namePattern matches the variable names an override may set.
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseOverrides parses KEY=VALUE pairs, such as those given with vx exec
+// --set, into a map. The value is everything after the first "=" and may be
+// empty. A later pair for the same key wins.
+func ParseOverrides(pairs []string) (map[string]string, error) {
+	out := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		k, v, ok := strings.Cut(p, "=")
+		if !ok {
+			return nil, fmt.Errorf("--set %q: expected KEY=VALUE", p)
+		}
+		if !namePattern.MatchString(k) {
+			return nil, fmt.Errorf("--set %q: %q is not a valid variable name", p, k)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
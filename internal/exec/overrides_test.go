@@ -0,0 +1,23 @@
+package exec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOverrides(t *testing.T) {
+	got, err := ParseOverrides([]string{"STRIPE_KEY=sk_test_1", "URL=postgres://h/db?a=b", "EMPTY=", "STRIPE_KEY=sk_test_2"})
+	if err != nil {
+		t.Fatalf("ParseOverrides() error = %v", err)
+	}
+	want := map[string]string{"STRIPE_KEY": "sk_test_2", "URL": "postgres://h/db?a=b", "EMPTY": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseOverrides() = %v, want %v", got, want)
+	}
+
+	for _, bad := range []string{"STRIPE_KEY", "=value", "1KEY=x", "MY-KEY=x"} {
+		if _, err := ParseOverrides([]string{bad}); err == nil {
+			t.Errorf("ParseOverrides(%q) expected error", bad)
+		}
+	}
+}
@@ -4,33 +4,152 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"syscall"
+	"time"
 )
 
+// runSettings holds the optional behaviour of Run.
+type runSettings struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	// gracePeriod, when set, makes cancelling ctx interrupt the child and
+	// wait this long for it to exit before killing it.
+	gracePeriod time.Duration
+	// killTimeout, when set, kills the child's process group when it is
+	// still running this long after vx forwarded it a signal.
+	killTimeout time.Duration
+	// passthrough and block filter the environment the child inherits from
+	// vx (see WithHostEnv).
+	passthrough []string
+	block       []string
+	// mask lists values hidden from the child's output (see WithMask).
+	mask []string
+}
+
+// Option configures Run.
+type Option func(*runSettings)
+
+// WithStdin feeds r to the child's stdin instead of inheriting the parent's.
+// Nil values are ignored.
+func WithStdin(r io.Reader) Option {
+	return func(s *runSettings) {
+		if r != nil {
+			s.stdin = r
+		}
+	}
+}
+
+// WithOutput sends the child's stdout and stderr to the given writers instead
+// of the parent's. Passing the same writer for both keeps their output in
+// order. Nil values are ignored.
+func WithOutput(stdout, stderr io.Writer) Option {
+	return func(s *runSettings) {
+		if stdout != nil {
+			s.stdout = stdout
+		}
+		if stderr != nil {
+			s.stderr = stderr
+		}
+	}
+}
+
+// WithGracefulStop makes cancelling Run's context interrupt the child
+// (SIGTERM on Unix) and give it d to exit before it is killed, instead of
+// killing it right away. Non-positive values are ignored.
+func WithGracefulStop(d time.Duration) Option {
+	return func(s *runSettings) {
+		if d > 0 {
+			s.gracePeriod = d
+		}
+	}
+}
+
+// WithKillTimeout kills the child, with its whole process group, when it
+// has not exited d after vx forwarded it SIGINT, SIGTERM, or SIGHUP (Ctrl+C
+// on Windows). Non-positive values are ignored, so the child is waited for
+// indefinitely.
+func WithKillTimeout(d time.Duration) Option {
+	return func(s *runSettings) {
+		if d > 0 {
+			s.killTimeout = d
+		}
+	}
+}
+
+// WithMask replaces every occurrence of values in the child's stdout and
+// stderr with "***" (see Masker). The output then goes through a pipe, so
+// the child no longer writes to a terminal directly.
+func WithMask(values []string) Option {
+	return func(s *runSettings) {
+		s.mask = values
+	}
+}
+
 // Run executes a child process with injected environment variables.
-// Provided env vars are merged with the current process environment;
-// provided values override existing ones. Stdin, Stdout, and Stderr are
-// inherited from the parent process. The returned error preserves the
-// child's exit code when available.
-func Run(ctx context.Context, command []string, env map[string]string) error {
+// Provided env vars are merged with the current process environment, or the
+// part of it WithHostEnv allows; provided values override existing ones.
+// Stdin, Stdout, and Stderr are inherited from the parent process unless
+// overridden by options. The returned error preserves the child's exit code
+// when available.
+//
+// On Unix the child leads a process group of its own unless vx is the
+// foreground job of a terminal; on Windows it runs in a Job Object. Either
+// way processes it leaves behind are terminated when it exits.
+func Run(ctx context.Context, command []string, env map[string]string, opts ...Option) error {
 	if len(command) == 0 {
 		return fmt.Errorf("command must not be empty")
 	}
 
-	merged := mergeEnv(os.Environ(), env)
+	settings := runSettings{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	merged := mergeEnv(filterEnv(os.Environ(), settings.passthrough, settings.block), env)
 
 	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
 	cmd.Env = merged
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdin = settings.stdin
+	cmd.Stdout = settings.stdout
+	cmd.Stderr = settings.stderr
+	if len(settings.mask) > 0 {
+		stdout := NewMasker(settings.stdout, settings.mask)
+		stderr := stdout
+		if settings.stderr != settings.stdout {
+			stderr = NewMasker(settings.stderr, settings.mask)
+		}
+		// Deferred calls run after Wait, once all output was copied.
+		defer stdout.Flush()
+		defer stderr.Flush()
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+	}
+	setProcessGroup(cmd)
+	if settings.gracePeriod > 0 {
+		cmd.Cancel = func() error { return interrupt(cmd.Process) }
+		cmd.WaitDelay = settings.gracePeriod
+	}
 
 	if err := cmd.Start(); err != nil {
+		if errors.Is(err, syscall.E2BIG) {
+			return fmt.Errorf("starting command %q: arguments and environment are too large: %w", command[0], err)
+		}
 		return fmt.Errorf("starting command %q: %w", command[0], err)
 	}
 
-	cleanup := ForwardSignals(ctx, cmd.Process)
+	tree, err := attachProcessTree(cmd.Process)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+	defer tree.Close()
+
+	cleanup := forwardSignals(ctx, cmd.Process, settings.killTimeout)
 	defer cleanup()
 
 	return cmd.Wait()
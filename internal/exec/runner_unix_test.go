@@ -0,0 +1,135 @@
+//go:build !windows
+
+package exec
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun_echoCommand(t *testing.T) {
+	ctx := context.Background()
+
+	err := Run(ctx, []string{"echo", "hello"}, nil)
+	if err != nil {
+		t.Fatalf("Run(echo hello) returned unexpected error: %v", err)
+	}
+}
+
+func TestRun_envInjection(t *testing.T) {
+	ctx := context.Background()
+
+	env := map[string]string{
+		"VX_TEST_VAR": "injected_value",
+	}
+
+	// Use env command to print a specific variable; sh -c reads it.
+	err := Run(ctx, []string{"sh", "-c", "test \"$VX_TEST_VAR\" = \"injected_value\""}, env)
+	if err != nil {
+		t.Fatalf("Run() with env injection failed: %v", err)
+	}
+}
+
+func TestRun_exitCodePropagation(t *testing.T) {
+	ctx := context.Background()
+
+	err := Run(ctx, []string{"sh", "-c", "exit 42"}, nil)
+	if err == nil {
+		t.Fatal("Run() expected error for non-zero exit code, got nil")
+	}
+
+	code := ExitCode(err)
+	if code != 42 {
+		t.Errorf("ExitCode() = %d, want 42", code)
+	}
+}
+
+func TestExitCode_exitError(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "exit 7")
+	err := cmd.Run()
+
+	code := ExitCode(err)
+	if code != 7 {
+		t.Errorf("ExitCode() = %d, want 7", code)
+	}
+}
+
+func TestRun_withStdin(t *testing.T) {
+	ctx := context.Background()
+
+	err := Run(ctx, []string{"sh", "-c", `test "$(cat)" = "piped-secret"`}, nil,
+		WithStdin(strings.NewReader("piped-secret")))
+	if err != nil {
+		t.Fatalf("Run() with stdin failed: %v", err)
+	}
+}
+
+func TestRun_withOutput(t *testing.T) {
+	c, err := NewCapture("", 10)
+	if err != nil {
+		t.Fatalf("NewCapture() error = %v", err)
+	}
+
+	err = Run(context.Background(), []string{"sh", "-c", "echo out; echo err >&2; exit 3"}, nil, WithOutput(c, c))
+	if ExitCode(err) != 3 {
+		t.Fatalf("ExitCode() = %d, want 3", ExitCode(err))
+	}
+
+	got := strings.Join(c.Tail(), "\n")
+	if got != "out\nerr" {
+		t.Errorf("captured output = %q, want stdout then stderr", got)
+	}
+}
+
+func TestRun_gracefulStop(t *testing.T) {
+	c, err := NewCapture("", 10)
+	if err != nil {
+		t.Fatalf("NewCapture() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(300*time.Millisecond, cancel)
+
+	script := `trap 'echo stopped; exit 0' TERM; while :; do sleep 0.05; done`
+	Run(ctx, []string{"sh", "-c", script}, nil, WithOutput(c, c), WithGracefulStop(5*time.Second))
+
+	// The shell may also report its sleep being terminated along with it.
+	if tail := c.Tail(); len(tail) == 0 || tail[len(tail)-1] != "stopped" {
+		t.Errorf("output = %q, want the TERM handler to run", strings.Join(tail, "\n"))
+	}
+}
+
+func TestRun_killsLeftoverProcessGroup(t *testing.T) {
+	if foregroundTerminal() {
+		t.Skip("the child shares the terminal's foreground process group")
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// The background sleep holds the pipe open for as long as it lives.
+	err = Run(context.Background(), []string{"sh", "-c", "sleep 30 &"}, nil, WithOutput(w, w))
+	w.Close()
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	eof := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, r)
+		close(eof)
+	}()
+	select {
+	case <-eof:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the command's background process outlived it")
+	}
+}
@@ -0,0 +1,93 @@
+//go:build windows
+
+package exec
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestRun_envInjection(t *testing.T) {
+	env := map[string]string{"VX_TEST_VAR": "injected_value"}
+
+	err := Run(context.Background(), []string{"cmd", "/c", `if not "%VX_TEST_VAR%"=="injected_value" exit 1`}, env)
+	if err != nil {
+		t.Fatalf("Run() with env injection failed: %v", err)
+	}
+}
+
+func TestRun_exitCodePropagation(t *testing.T) {
+	err := Run(context.Background(), []string{"cmd", "/c", "exit 42"}, nil)
+	if code := ExitCode(err); code != 42 {
+		t.Errorf("ExitCode() = %d, want 42 (err = %v)", code, err)
+	}
+}
+
+func TestRun_withStdin(t *testing.T) {
+	err := Run(context.Background(), []string{"findstr", "/x", "piped-secret"}, nil,
+		WithStdin(strings.NewReader("piped-secret\r\n")))
+	if err != nil {
+		t.Fatalf("Run() with stdin failed: %v", err)
+	}
+}
+
+func TestRun_terminatesProcessTree(t *testing.T) {
+	// The child starts a grandchild that outlives it by writing a file after
+	// a delay; the job object must terminate it when the child exits.
+	marker := filepath.Join(t.TempDir(), "leaked")
+	grandchild := `start "" /b powershell -NoProfile -Command "Start-Sleep -Seconds 2; Set-Content '` + marker + `' x"`
+
+	if err := Run(context.Background(), []string{"cmd", "/c", grandchild}, nil); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	time.Sleep(4 * time.Second)
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("grandchild kept running after the child exited")
+	}
+}
+
+func TestRun_gracefulStop(t *testing.T) {
+	// Run again as the child, which sleeps until Go's default Ctrl+Break
+	// handling ends it.
+	if os.Getenv("VX_TEST_SLEEP") == "1" {
+		time.Sleep(30 * time.Second)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(500*time.Millisecond, cancel)
+
+	start := time.Now()
+	Run(ctx, []string{os.Args[0], "-test.run=^TestRun_gracefulStop$"}, map[string]string{"VX_TEST_SLEEP": "1"},
+		WithGracefulStop(30*time.Second))
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Run() returned after %s, want the child to stop on CTRL_BREAK_EVENT", elapsed)
+	}
+}
+
+func TestSetProcessGroup(t *testing.T) {
+	cmd := exec.Command("cmd", "/c", "exit 0")
+	setProcessGroup(cmd)
+	if cmd.SysProcAttr == nil || cmd.SysProcAttr.CreationFlags&syscall.CREATE_NEW_PROCESS_GROUP == 0 {
+		t.Error("the child should start in a console process group of its own")
+	}
+	if cmd.SysProcAttr == nil || cmd.SysProcAttr.CreationFlags&windows.CREATE_SUSPENDED == 0 {
+		t.Error("the child should start suspended until it is in the job")
+	}
+}
+
+func TestExitCode_exitError(t *testing.T) {
+	err := exec.Command("cmd", "/c", "exit 7").Run()
+	if code := ExitCode(err); code != 7 {
+		t.Errorf("ExitCode() = %d, want 7", code)
+	}
+}
@@ -1,10 +1,14 @@
+//go:build !windows
+
 package exec
 
 import (
 	"context"
 	"os"
 	"os/exec"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestForwardSignals_setup(t *testing.T) {
@@ -62,3 +66,39 @@ func TestForwardSignals_signalDelivery(t *testing.T) {
 
 	_ = cmd.Wait()
 }
+
+func TestForwardLoop_killTimeout(t *testing.T) {
+	// Both the shell and its background sleep ignore SIGTERM.
+	cmd := exec.Command("sh", "-c", "trap '' TERM; sleep 30 & wait")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	cmd.Stdout = w
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start process: %v", err)
+	}
+	w.Close()
+
+	sigChan := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go forwardLoop(context.Background(), cmd.Process, 100*time.Millisecond, sigChan, done)
+	sigChan <- syscall.SIGTERM
+
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		// Read until the background sleep closes its end of the pipe.
+		_, _ = r.Read(make([]byte, 1))
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		t.Fatal("the process group was not killed after the kill timeout")
+	}
+}
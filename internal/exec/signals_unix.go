@@ -0,0 +1,75 @@
+//go:build !windows
+
+package exec
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ForwardSignals starts a goroutine that forwards SIGINT, SIGTERM, and
+// SIGHUP to the given child process, or to its whole process group when it
+// leads one. Returns a cleanup function that stops signal forwarding and
+// must be called when the child exits.
+func ForwardSignals(ctx context.Context, process *os.Process) func() {
+	return forwardSignals(ctx, process, 0)
+}
+
+// forwardSignals is ForwardSignals, additionally killing the child's
+// process group when it is still running killTimeout after the first
+// forwarded signal. Zero waits for the child indefinitely.
+func forwardSignals(ctx context.Context, process *os.Process, killTimeout time.Duration) func() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	done := make(chan struct{})
+
+	go forwardLoop(ctx, process, killTimeout, sigChan, done)
+
+	return func() {
+		signal.Stop(sigChan)
+		close(done)
+	}
+}
+
+// interrupt asks p, and its process group when it leads one, to shut down
+// with SIGTERM.
+func interrupt(p *os.Process) error {
+	return signalGroup(p, syscall.SIGTERM)
+}
+
+// signalGroup sends sig to p's process group when p leads one of its own,
+// and to p alone otherwise: a group shared with vx must not be signalled.
+func signalGroup(p *os.Process, sig syscall.Signal) error {
+	if pgid, err := syscall.Getpgid(p.Pid); err == nil && pgid == p.Pid {
+		return syscall.Kill(-pgid, sig)
+	}
+	return p.Signal(sig)
+}
+
+// forwardLoop receives signals from sigChan and sends them to the child
+// process, killing it killTimeout after the first one if it has not exited
+// by then. It exits when done is closed or the context is cancelled.
+func forwardLoop(ctx context.Context, process *os.Process, killTimeout time.Duration, sigChan <-chan os.Signal, done <-chan struct{}) {
+	var deadline <-chan time.Time
+	stopping := false
+	for {
+		select {
+		case sig := <-sigChan:
+			_ = signalGroup(process, sig.(syscall.Signal))
+			if killTimeout > 0 && !stopping {
+				stopping = true
+				deadline = time.After(killTimeout)
+			}
+		case <-deadline:
+			_ = signalGroup(process, syscall.SIGKILL)
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,75 @@
+//go:build windows
+
+package exec
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// ForwardSignals passes Ctrl+C on to the child as CTRL_BREAK_EVENT. The
+// child runs in a console process group of its own (see setProcessGroup),
+// which keeps the console's Ctrl+C from reaching it: Windows can deliver
+// Ctrl+Break, but not Ctrl+C, to a single process group, and the group
+// includes every process the child started, such as the node processes
+// behind npm. Returns a cleanup function that must be called when the child
+// exits.
+func ForwardSignals(ctx context.Context, process *os.Process) func() {
+	return forwardSignals(ctx, process, 0)
+}
+
+// forwardSignals is ForwardSignals, additionally killing the child when it
+// is still running killTimeout after the first Ctrl+C. Zero waits for the
+// child indefinitely.
+func forwardSignals(ctx context.Context, process *os.Process, killTimeout time.Duration) func() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+
+	done := make(chan struct{})
+
+	go forwardLoop(ctx, process, killTimeout, sigChan, done)
+
+	return func() {
+		signal.Stop(sigChan)
+		close(done)
+	}
+}
+
+// interrupt asks p and the rest of its console process group to shut down
+// with CTRL_BREAK_EVENT. When the event cannot be sent, as when vx has no
+// console, p is terminated outright.
+func interrupt(p *os.Process) error {
+	if err := windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(p.Pid)); err != nil {
+		return p.Kill()
+	}
+	return nil
+}
+
+// forwardLoop interrupts the child for every Ctrl+C received on sigChan,
+// killing it killTimeout after the first one if it has not exited by then;
+// the Job Object takes the rest of the tree down with it. It exits when done
+// is closed or the context is cancelled.
+func forwardLoop(ctx context.Context, process *os.Process, killTimeout time.Duration, sigChan <-chan os.Signal, done <-chan struct{}) {
+	var deadline <-chan time.Time
+	stopping := false
+	for {
+		select {
+		case <-sigChan:
+			_ = interrupt(process)
+			if killTimeout > 0 && !stopping {
+				stopping = true
+				deadline = time.After(killTimeout)
+			}
+		case <-deadline:
+			_ = process.Kill()
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
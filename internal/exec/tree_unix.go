@@ -0,0 +1,66 @@
+//go:build !windows
+
+package exec
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// processTree tracks a child and the processes it starts. When vx is the
+// foreground job of a terminal the child stays in vx's process group, so
+// terminal signals already reach the whole tree and job control (Ctrl+Z,
+// reading from the terminal) keeps working. Otherwise, as under CI runners
+// and supervisors, the child leads a process group of its own: signals are
+// forwarded to the whole group, and whatever is left of it is killed when
+// the child exits, so nothing it started outlives vx.
+type processTree struct {
+	// pgid is the child's own process group, or zero when it shares vx's.
+	pgid int
+}
+
+// setProcessGroup makes cmd start in a process group of its own unless vx
+// is the foreground job of a terminal.
+func setProcessGroup(cmd *exec.Cmd) {
+	if foregroundTerminal() {
+		return
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// foregroundTerminal reports whether vx's process group is the foreground
+// job of its controlling terminal.
+func foregroundTerminal() bool {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return false
+	}
+	defer tty.Close()
+
+	pgrp, err := unix.IoctlGetInt(int(tty.Fd()), unix.TIOCGPGRP)
+	return err == nil && pgrp == syscall.Getpgrp()
+}
+
+// attachProcessTree starts tracking p's process tree.
+func attachProcessTree(p *os.Process) (*processTree, error) {
+	if pgid, err := syscall.Getpgid(p.Pid); err == nil && pgid == p.Pid {
+		return &processTree{pgid: pgid}, nil
+	}
+	return &processTree{}, nil
+}
+
+// Close kills every process left in the child's process group, if it has
+// one of its own.
+func (t *processTree) Close() error {
+	if t.pgid == 0 {
+		return nil
+	}
+	if err := syscall.Kill(-t.pgid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+//go:build windows
+
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// processTree holds a Job Object containing the child. Windows has no
+// process groups that die with their parent, so without a job anything the
+// child starts outlives vx; the job is created with
+// JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE, so closing it terminates every process
+// still in the tree.
+type processTree struct {
+	job windows.Handle
+}
+
+// setProcessGroup makes cmd start in a console process group of its own, so
+// vx can send it CTRL_BREAK_EVENT without also interrupting itself. Console
+// Ctrl+C no longer reaches it directly; ForwardSignals passes it on.
+//
+// The child also starts suspended, so it cannot start a process before
+// attachProcessTree has put it in the job; attachProcessTree resumes it.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | windows.CREATE_SUSPENDED}
+}
+
+// attachProcessTree creates a Job Object, assigns p to it, and resumes p,
+// which setProcessGroup started suspended. Every process p starts joins the
+// job automatically.
+func attachProcessTree(p *os.Process) (*processTree, error) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating job object: %w", err)
+	}
+
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{
+		BasicLimitInformation: windows.JOBOBJECT_BASIC_LIMIT_INFORMATION{
+			LimitFlags: windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE,
+		},
+	}
+	if _, err := windows.SetInformationJobObject(
+		job,
+		windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)),
+		uint32(unsafe.Sizeof(info)),
+	); err != nil {
+		_ = windows.CloseHandle(job)
+		return nil, fmt.Errorf("configuring job object: %w", err)
+	}
+
+	proc, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(p.Pid))
+	if err != nil {
+		_ = windows.CloseHandle(job)
+		return nil, fmt.Errorf("opening child process: %w", err)
+	}
+	defer windows.CloseHandle(proc)
+
+	if err := windows.AssignProcessToJobObject(job, proc); err != nil {
+		_ = windows.CloseHandle(job)
+		return nil, fmt.Errorf("assigning child to job object: %w", err)
+	}
+
+	if err := resumeProcess(uint32(p.Pid)); err != nil {
+		_ = windows.CloseHandle(job)
+		return nil, fmt.Errorf("resuming child process: %w", err)
+	}
+
+	return &processTree{job: job}, nil
+}
+
+// resumeProcess resumes the threads of the process pid, which was created
+// suspended and so has only its main thread. os/exec does not keep that
+// thread's handle, so it is found in a snapshot of the system's threads.
+func resumeProcess(pid uint32) error {
+	snap, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPTHREAD, 0)
+	if err != nil {
+		return fmt.Errorf("listing threads: %w", err)
+	}
+	defer windows.CloseHandle(snap)
+
+	resumed := 0
+	entry := windows.ThreadEntry32{Size: uint32(unsafe.Sizeof(windows.ThreadEntry32{}))}
+	for err = windows.Thread32First(snap, &entry); err == nil; err = windows.Thread32Next(snap, &entry) {
+		if entry.OwnerProcessID != pid {
+			continue
+		}
+		thread, err := windows.OpenThread(windows.THREAD_SUSPEND_RESUME, false, entry.ThreadID)
+		if err != nil {
+			return fmt.Errorf("opening thread %d: %w", entry.ThreadID, err)
+		}
+		_, err = windows.ResumeThread(thread)
+		_ = windows.CloseHandle(thread)
+		if err != nil {
+			return fmt.Errorf("resuming thread %d: %w", entry.ThreadID, err)
+		}
+		resumed++
+	}
+	if !errors.Is(err, windows.ERROR_NO_MORE_FILES) {
+		return fmt.Errorf("listing threads: %w", err)
+	}
+	if resumed == 0 {
+		return fmt.Errorf("no thread of process %d found", pid)
+	}
+	return nil
+}
+
+// Close closes the job, terminating any process of the tree still running.
+func (t *processTree) Close() error {
+	return windows.CloseHandle(t.job)
+}
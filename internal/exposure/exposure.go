@@ -0,0 +1,201 @@
+// Package exposure compares the secret mappings of two versions of a
+// repository's vx configuration, such as a pull request's branch and its
+// base, and renders what changed as Markdown for a PR comment: which env
+// vars each workspace gains, loses, or renames, and which Vault paths they
+// read from.
+package exposure
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.dot.industries/vx/internal/config"
+)
+
+// Root names the mappings of a root config without workspaces.
+const Root = "(root)"
+
+// Mappings are the merged secret mappings of each workspace, root mappings
+// included, keyed by workspace name and then env var. Values are Vault path
+// templates as written in [secrets].
+type Mappings map[string]map[string]string
+
+// Collect merges root with each of its workspace configs, keyed by their
+// path in root.Workspaces. Without workspaces the root mappings are keyed
+// by Root.
+func Collect(root *config.RootConfig, workspaces map[string]*config.WorkspaceConfig) (Mappings, error) {
+	m := make(Mappings)
+	if len(root.Workspaces) == 0 {
+		merged, err := config.Merge(root, nil, "")
+		if err != nil {
+			return nil, err
+		}
+		m[Root] = merged.Secrets
+		return m, nil
+	}
+
+	for _, p := range root.Workspaces {
+		merged, err := config.Merge(root, workspaces[p], "")
+		if err != nil {
+			return nil, fmt.Errorf("workspace %s: %w", p, err)
+		}
+		m[workspaceName(p)] = merged.Secrets
+	}
+	return m, nil
+}
+
+// workspaceName returns the name -w selects for a workspace path.
+func workspaceName(p string) string {
+	return filepath.Base(filepath.Dir(p))
+}
+
+// Mapping is one env var and the path template it reads.
+type Mapping struct {
+	EnvVar string
+	Path   string
+}
+
+// Rename is an env var that reads the same path under a new name.
+type Rename struct {
+	From string
+	To   string
+	Path string
+}
+
+// PathChange is an env var that reads from a different path.
+type PathChange struct {
+	EnvVar string
+	From   string
+	To     string
+}
+
+// Changes are the differences in one workspace's mappings.
+type Changes struct {
+	Workspace string
+	// Status is "added" or "removed" for a workspace only one side has,
+	// and empty otherwise.
+	Status  string
+	Added   []Mapping
+	Removed []Mapping
+	Renamed []Rename
+	Changed []PathChange
+}
+
+// Empty reports whether nothing changed.
+func (c Changes) Empty() bool {
+	return c.Status == "" && len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Renamed) == 0 && len(c.Changed) == 0
+}
+
+// Compare returns the changes from base to head of every workspace whose
+// mappings differ, sorted by workspace name. An env var that disappears
+// while another appears reading the same path is reported as a rename.
+func Compare(base, head Mappings) []Changes {
+	names := slices.Sorted(maps.Keys(base))
+	for name := range head {
+		if _, ok := base[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var out []Changes
+	for _, name := range names {
+		b, inBase := base[name]
+		h, inHead := head[name]
+		c := compareWorkspace(b, h)
+		c.Workspace = name
+		switch {
+		case !inBase:
+			c.Status = "added"
+		case !inHead:
+			c.Status = "removed"
+		}
+		if !c.Empty() {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// compareWorkspace diffs the mappings of one workspace.
+func compareWorkspace(base, head map[string]string) Changes {
+	var c Changes
+	for _, k := range slices.Sorted(maps.Keys(head)) {
+		old, ok := base[k]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, Mapping{EnvVar: k, Path: head[k]})
+		case old != head[k]:
+			c.Changed = append(c.Changed, PathChange{EnvVar: k, From: old, To: head[k]})
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(base)) {
+		if _, ok := head[k]; !ok {
+			c.Removed = append(c.Removed, Mapping{EnvVar: k, Path: base[k]})
+		}
+	}
+
+	// Pair removed and added names reading the same path, in name order.
+	for i := 0; i < len(c.Removed); i++ {
+		r := c.Removed[i]
+		j := slices.IndexFunc(c.Added, func(a Mapping) bool { return a.Path == r.Path })
+		if j < 0 {
+			continue
+		}
+		c.Renamed = append(c.Renamed, Rename{From: r.EnvVar, To: c.Added[j].EnvVar, Path: r.Path})
+		c.Added = slices.Delete(c.Added, j, j+1)
+		c.Removed = slices.Delete(c.Removed, i, i+1)
+		i--
+	}
+	return c
+}
+
+// WriteMarkdown writes changes as a Markdown comment, one table per
+// workspace, comparing against the git ref base.
+func WriteMarkdown(w io.Writer, base string, changes []Changes) error {
+	var b strings.Builder
+	b.WriteString("### vx secret mappings\n\n")
+	if len(changes) == 0 {
+		fmt.Fprintf(&b, "No secret mappings changed compared with `%s`.\n", base)
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	fmt.Fprintf(&b, "Compared with `%s`, %d workspace(s) read different secrets.\n", base, len(changes))
+	for _, c := range changes {
+		fmt.Fprintf(&b, "\n#### %s", c.Workspace)
+		if c.Status != "" {
+			fmt.Fprintf(&b, " (%s workspace)", c.Status)
+		}
+		if len(c.Added)+len(c.Removed)+len(c.Renamed)+len(c.Changed) == 0 {
+			b.WriteString("\n\nNo secret mappings.\n")
+			continue
+		}
+		b.WriteString("\n\n| Change | Variable | Vault path |\n| --- | --- | --- |\n")
+		for _, m := range c.Added {
+			fmt.Fprintf(&b, "| added | %s | %s |\n", code(m.EnvVar), code(m.Path))
+		}
+		for _, m := range c.Removed {
+			fmt.Fprintf(&b, "| removed | %s | %s |\n", code(m.EnvVar), code(m.Path))
+		}
+		for _, r := range c.Renamed {
+			fmt.Fprintf(&b, "| renamed | %s → %s | %s |\n", code(r.From), code(r.To), code(r.Path))
+		}
+		for _, p := range c.Changed {
+			fmt.Fprintf(&b, "| path changed | %s | %s → %s |\n", code(p.EnvVar), code(p.From), code(p.To))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// code formats s as inline code in a table cell.
+func code(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return "`" + strings.ReplaceAll(s, "`", "'") + "`"
+}
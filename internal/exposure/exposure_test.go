@@ -0,0 +1,109 @@
+package exposure
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.dot.industries/vx/internal/config"
+)
+
+func TestCollect(t *testing.T) {
+	root := &config.RootConfig{
+		Environments: config.EnvironmentConfig{Default: "dev", Available: []string{"dev"}},
+		Workspaces:   []string{"services/api/vx.toml", "web/vx.toml"},
+		Secrets:      map[string]string{"SENTRY_DSN": "shared/sentry"},
+	}
+	workspaces := map[string]*config.WorkspaceConfig{
+		"services/api/vx.toml": {Secrets: map[string]string{"DATABASE_URL": "${env}/db/url"}},
+	}
+
+	got, err := Collect(root, workspaces)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	want := Mappings{
+		"api": {"SENTRY_DSN": "shared/sentry", "DATABASE_URL": "${env}/db/url"},
+		"web": {"SENTRY_DSN": "shared/sentry"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Collect() = %v, want %v", got, want)
+	}
+
+	root.Workspaces = nil
+	got, err = Collect(root, nil)
+	if err != nil || !reflect.DeepEqual(got, Mappings{Root: {"SENTRY_DSN": "shared/sentry"}}) {
+		t.Errorf("Collect() without workspaces = %v, %v", got, err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := Mappings{
+		"api": {
+			"DB_URL":    "${env}/db/url",
+			"REDIS_URL": "${env}/redis",
+			"OLD_TOKEN": "legacy/token",
+			"SAME":      "shared/same",
+		},
+		"unchanged": {"A": "a"},
+		"gone":      {"B": "b"},
+	}
+	head := Mappings{
+		"api": {
+			"DATABASE_URL": "${env}/db/url",
+			"REDIS_URL":    "${env}/cache/redis",
+			"STRIPE_KEY":   "${env}/stripe",
+			"SAME":         "shared/same",
+		},
+		"unchanged": {"A": "a"},
+		"billing":   {},
+	}
+
+	got := Compare(base, head)
+	want := []Changes{
+		{
+			Workspace: "api",
+			Added:     []Mapping{{"STRIPE_KEY", "${env}/stripe"}},
+			Removed:   []Mapping{{"OLD_TOKEN", "legacy/token"}},
+			Renamed:   []Rename{{"DB_URL", "DATABASE_URL", "${env}/db/url"}},
+			Changed:   []PathChange{{"REDIS_URL", "${env}/redis", "${env}/cache/redis"}},
+		},
+		{Workspace: "billing", Status: "added"},
+		{Workspace: "gone", Status: "removed", Removed: []Mapping{{"B", "b"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compare() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b strings.Builder
+	if err := WriteMarkdown(&b, "main", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "No secret mappings changed compared with `main`.") {
+		t.Errorf("WriteMarkdown() without changes =\n%s", b.String())
+	}
+
+	b.Reset()
+	err := WriteMarkdown(&b, "origin/main", []Changes{{
+		Workspace: "api",
+		Added:     []Mapping{{"STRIPE_KEY", "${env}/stripe"}},
+		Renamed:   []Rename{{"DB_URL", "DATABASE_URL", "${env}/db/url"}},
+		Changed:   []PathChange{{"REDIS_URL", "a|b", "c"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Compared with `origin/main`, 1 workspace(s) read different secrets.",
+		"#### api\n\n| Change | Variable | Vault path |",
+		"| added | `STRIPE_KEY` | `${env}/stripe` |",
+		"| renamed | `DB_URL` → `DATABASE_URL` | `${env}/db/url` |",
+		"| path changed | `REDIS_URL` | `a\\|b` → `c` |",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("WriteMarkdown() lacks %q:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Package jsexport materializes resolved values as a JavaScript or TypeScript
+// module, for build steps (Vite, Next.js, Bun) that need configuration at
+// compile time rather than from the process environment.
+//
+// Everything written this way ends up in build output and often in a browser
+// bundle, so values are passed through a deny-list of name patterns first.
+package jsexport
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultDeny is used when [export_runtime] deny is not set. The patterns
+// match names that usually hold credentials rather than configuration.
+var DefaultDeny = []string{
+	"*SECRET*",
+	"*PASSWORD*",
+	"*PASSWD*",
+	"*TOKEN*",
+	"*PRIVATE*",
+	"*CREDENTIAL*",
+	"*_KEY",
+	"*_KEY_*",
+	"*DATABASE_URL*",
+	"*DSN*",
+}
+
+const header = "// Code generated by vx exec --export-runtime. DO NOT EDIT.\n"
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// Denied reports whether name matches any of the deny patterns. Patterns use
+// path.Match syntax and are compared case-insensitively.
+func Denied(name string, deny []string) bool {
+	upper := strings.ToUpper(name)
+	for _, pattern := range deny {
+		if ok, _ := path.Match(strings.ToUpper(pattern), upper); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter splits values into those that may be exported and the sorted names
+// of those withheld by the deny-list.
+func Filter(values map[string]string, deny []string) (map[string]string, []string) {
+	kept := make(map[string]string, len(values))
+	var denied []string
+
+	for k, v := range values {
+		if Denied(k, deny) {
+			denied = append(denied, k)
+			continue
+		}
+		kept[k] = v
+	}
+
+	sort.Strings(denied)
+	return kept, denied
+}
+
+// Render generates the module for target, keyed by file path. A ".ts" or
+// ".mts" target is a single TypeScript module; a ".js" or ".mjs" target is an
+// ES module plus a matching ".d.ts" or ".d.mts" declaration file.
+func Render(target string, values map[string]string) (map[string][]byte, error) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if !identifier.MatchString(k) {
+			return nil, fmt.Errorf("%q is not a valid JavaScript identifier", k)
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var module, decl strings.Builder
+	module.WriteString(header + "\n")
+	decl.WriteString(header + "\n")
+
+	ext := filepath.Ext(target)
+	typed := ext == ".ts" || ext == ".mts"
+
+	for _, k := range keys {
+		// JSON string syntax is valid JavaScript and escapes U+2028/U+2029.
+		lit, err := json.Marshal(values[k])
+		if err != nil {
+			return nil, fmt.Errorf("encoding %s: %w", k, err)
+		}
+		if typed {
+			fmt.Fprintf(&module, "export const %s: string = %s;\n", k, lit)
+		} else {
+			fmt.Fprintf(&module, "export const %s = %s;\n", k, lit)
+			fmt.Fprintf(&decl, "export declare const %s: string;\n", k)
+		}
+	}
+
+	switch ext {
+	case ".ts", ".mts":
+		return map[string][]byte{target: []byte(module.String())}, nil
+	case ".js", ".mjs":
+		declPath := strings.TrimSuffix(target, ext) + ".d" + strings.Replace(ext, "js", "ts", 1)
+		return map[string][]byte{
+			target:   []byte(module.String()),
+			declPath: []byte(decl.String()),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported module extension %q (use .ts, .mts, .js, or .mjs)", ext)
+	}
+}
+
+// Write renders target and writes the generated files, returning their paths
+// in sorted order.
+func Write(target string, values map[string]string) ([]string, error) {
+	files, err := Render(target, values)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		if err := os.WriteFile(p, files[p], 0644); err != nil {
+			return nil, fmt.Errorf("writing %s: %w", p, err)
+		}
+	}
+	return paths, nil
+}
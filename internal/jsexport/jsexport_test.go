@@ -0,0 +1,101 @@
+package jsexport
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	values := map[string]string{
+		"API_URL":          "https://api.example.com",
+		"STRIPE_KEY":       "sk_live",
+		"SESSION_SECRET":   "s",
+		"github_token":     "ghp",
+		"PUBLIC_KEY_ID":    "kid",
+		"FEATURE_NEW_CART": "true",
+	}
+
+	kept, denied := Filter(values, DefaultDeny)
+
+	wantKept := map[string]string{
+		"API_URL":          "https://api.example.com",
+		"FEATURE_NEW_CART": "true",
+	}
+	if !reflect.DeepEqual(kept, wantKept) {
+		t.Errorf("kept = %v, want %v", kept, wantKept)
+	}
+	wantDenied := []string{"PUBLIC_KEY_ID", "SESSION_SECRET", "STRIPE_KEY", "github_token"}
+	if !reflect.DeepEqual(denied, wantDenied) {
+		t.Errorf("denied = %v, want %v", denied, wantDenied)
+	}
+
+	kept, denied = Filter(values, []string{})
+	if len(kept) != len(values) || len(denied) != 0 {
+		t.Errorf("empty deny-list should keep everything, got %v / %v", kept, denied)
+	}
+}
+
+func TestRender_TypeScript(t *testing.T) {
+	files, err := Render("src/env.ts", map[string]string{
+		"B": `say "hi"`,
+		"A": "1",
+	})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("Render() files = %v, want just src/env.ts", files)
+	}
+
+	want := header + "\n" +
+		"export const A: string = \"1\";\n" +
+		"export const B: string = \"say \\\"hi\\\"\";\n"
+	if got := string(files["src/env.ts"]); got != want {
+		t.Errorf("module =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRender_JavaScriptWithDeclarations(t *testing.T) {
+	files, err := Render("env.mjs", map[string]string{"API_URL": "x"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	if !strings.Contains(string(files["env.mjs"]), `export const API_URL = "x";`) {
+		t.Errorf("module = %q", files["env.mjs"])
+	}
+	if !strings.Contains(string(files["env.d.mts"]), "export declare const API_URL: string;") {
+		t.Errorf("declarations = %q", files["env.d.mts"])
+	}
+}
+
+func TestRender_Errors(t *testing.T) {
+	if _, err := Render("env.json", map[string]string{"A": "1"}); err == nil {
+		t.Error("expected error for unsupported extension")
+	}
+	if _, err := Render("env.ts", map[string]string{"MY-VAR": "1"}); err == nil {
+		t.Error("expected error for a name that is not an identifier")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "env.js")
+
+	paths, err := Write(target, map[string]string{"A": "1"})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	want := []string{strings.TrimSuffix(target, ".js") + ".d.ts", target}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+	for _, p := range paths {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s not written: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// Package mappings reads and writes the complete set of secret mappings in a
+// monorepo as JSON, so generators and IaC pipelines can manage vx.toml files
+// without hand-editing them. Writes go through tomlfile, keeping comments and
+// layout intact.
+package mappings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/tomlfile"
+)
+
+// FormatVersion is written to and expected in every document.
+const FormatVersion = 1
+
+// RootFile names the root vx.toml in a Mapping's File field.
+const RootFile = "vx.toml"
+
+// Document is the JSON form of a repository's mappings.
+type Document struct {
+	Version  int       `json:"version"`
+	Mappings []Mapping `json:"mappings"`
+}
+
+// Mapping is one [secrets] entry and the vx.toml it lives in, relative to the
+// repository root (the root file is "vx.toml").
+type Mapping struct {
+	File   string `json:"file"`
+	EnvVar string `json:"env_var"`
+	Path   string `json:"path"`
+}
+
+// Export collects the [secrets] entries of the root vx.toml and every
+// workspace, sorted by file and then variable name.
+func Export(rootDir string, cfg *config.RootConfig) (*Document, error) {
+	doc := &Document{Version: FormatVersion, Mappings: []Mapping{}}
+
+	for name, p := range cfg.Secrets {
+		doc.Mappings = append(doc.Mappings, Mapping{File: RootFile, EnvVar: name, Path: p})
+	}
+
+	for _, ws := range cleanedWorkspaces(cfg) {
+		wsCfg, err := config.LoadWorkspaceConfig(filepath.Join(rootDir, filepath.FromSlash(ws)))
+		if err != nil {
+			return nil, err
+		}
+		for name, p := range wsCfg.Secrets {
+			doc.Mappings = append(doc.Mappings, Mapping{File: ws, EnvVar: name, Path: p})
+		}
+	}
+
+	sortMappings(doc.Mappings)
+	return doc, nil
+}
+
+// UnderPath returns a mapping in file for each of names, pointing at a key
+// named after it, lowercased, under the Vault path prefix: DATABASE_URL under
+// "${env}/api" maps to "${env}/api/database_url". The mappings are sorted by
+// name.
+func UnderPath(file, prefix string, names []string) []Mapping {
+	prefix = strings.TrimRight(prefix, "/")
+	ms := make([]Mapping, 0, len(names))
+	for _, name := range names {
+		ms = append(ms, Mapping{File: file, EnvVar: name, Path: prefix + "/" + strings.ToLower(name)})
+	}
+	sortMappings(ms)
+	return ms
+}
+
+// Decode reads a Document and checks its version and entries.
+func Decode(r io.Reader) (*Document, error) {
+	var doc Document
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding mappings: %w", err)
+	}
+
+	if doc.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported mappings version %d (expected %d)", doc.Version, FormatVersion)
+	}
+
+	seen := make(map[Mapping]bool)
+	for i, m := range doc.Mappings {
+		if m.File == "" || m.EnvVar == "" || m.Path == "" {
+			return nil, fmt.Errorf("mapping %d: file, env_var, and path are required", i)
+		}
+		key := Mapping{File: cleanFile(m.File), EnvVar: m.EnvVar}
+		if seen[key] {
+			return nil, fmt.Errorf("mapping %d: %s is listed twice for %s", i, m.EnvVar, m.File)
+		}
+		seen[key] = true
+	}
+
+	return &doc, nil
+}
+
+// Action is what an import does to one mapping.
+type Action string
+
+const (
+	ActionAdd    Action = "add"
+	ActionUpdate Action = "update"
+	ActionRemove Action = "remove"
+)
+
+// Change is a single planned edit.
+type Change struct {
+	Action  Action
+	File    string // relative to the repository root, as in Mapping.File
+	EnvVar  string
+	OldPath string // empty for ActionAdd
+	NewPath string // empty for ActionRemove
+}
+
+// Plan holds the edits for an import. Nothing is written until Apply.
+type Plan struct {
+	Changes []Change
+
+	rootDir string
+}
+
+// PlanImport compares doc with the current mappings. Every file in doc must
+// be the root vx.toml or a configured workspace. Mappings missing from doc
+// are kept unless prune is set, in which case they are removed from every
+// file doc mentions — files it does not mention are never touched.
+func PlanImport(rootDir string, cfg *config.RootConfig, doc *Document, prune bool) (*Plan, error) {
+	current, err := Export(rootDir, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[Mapping]string) // File+EnvVar -> path
+	for _, m := range current.Mappings {
+		existing[Mapping{File: m.File, EnvVar: m.EnvVar}] = m.Path
+	}
+
+	plan := &Plan{rootDir: rootDir}
+	wanted := make(map[Mapping]bool)
+	files := make(map[string]bool)
+
+	for _, m := range doc.Mappings {
+		file := cleanFile(m.File)
+		if file != RootFile && !slices.Contains(cleanedWorkspaces(cfg), file) {
+			return nil, fmt.Errorf("%s is not the root vx.toml or a configured workspace", m.File)
+		}
+		files[file] = true
+
+		key := Mapping{File: file, EnvVar: m.EnvVar}
+		wanted[key] = true
+
+		old, ok := existing[key]
+		switch {
+		case !ok:
+			plan.Changes = append(plan.Changes, Change{Action: ActionAdd, File: file, EnvVar: m.EnvVar, NewPath: m.Path})
+		case old != m.Path:
+			plan.Changes = append(plan.Changes, Change{Action: ActionUpdate, File: file, EnvVar: m.EnvVar, OldPath: old, NewPath: m.Path})
+		}
+	}
+
+	if prune {
+		for _, m := range current.Mappings {
+			if files[m.File] && !wanted[Mapping{File: m.File, EnvVar: m.EnvVar}] {
+				plan.Changes = append(plan.Changes, Change{Action: ActionRemove, File: m.File, EnvVar: m.EnvVar, OldPath: m.Path})
+			}
+		}
+	}
+
+	sort.SliceStable(plan.Changes, func(i, j int) bool {
+		a, b := plan.Changes[i], plan.Changes[j]
+		if a.File != b.File {
+			return fileLess(a.File, b.File)
+		}
+		return a.EnvVar < b.EnvVar
+	})
+	return plan, nil
+}
+
+// Apply writes the planned changes, one file at a time.
+func (p *Plan) Apply() error {
+	byFile := make(map[string][]Change)
+	var order []string
+	for _, c := range p.Changes {
+		if _, ok := byFile[c.File]; !ok {
+			order = append(order, c.File)
+		}
+		byFile[c.File] = append(byFile[c.File], c)
+	}
+
+	for _, file := range order {
+		if err := applyFile(filepath.Join(p.rootDir, filepath.FromSlash(file)), byFile[file]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyFile edits the [secrets] section of one vx.toml. Nothing is written
+// when a change cannot be made.
+func applyFile(path string, changes []Change) error {
+	doc, err := tomlfile.Read(path)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		switch c.Action {
+		case ActionAdd:
+			err = tomlfile.AddMapping(doc, c.EnvVar, c.NewPath)
+		case ActionUpdate:
+			err = tomlfile.SetMapping(doc, c.EnvVar, c.EnvVar, c.NewPath)
+		case ActionRemove:
+			err = tomlfile.DeleteMapping(doc, c.EnvVar)
+		}
+		if err != nil {
+			return fmt.Errorf("editing %s: %w", path, err)
+		}
+	}
+
+	return tomlfile.Write(path, doc)
+}
+
+// cleanedWorkspaces returns the configured workspace paths in the form used
+// by Mapping.File.
+func cleanedWorkspaces(cfg *config.RootConfig) []string {
+	out := make([]string, len(cfg.Workspaces))
+	for i, ws := range cfg.Workspaces {
+		out[i] = cleanFile(ws)
+	}
+	return out
+}
+
+// cleanFile returns a file as written in a document or the workspaces array
+// in the form used by Mapping.File, so "./api/vx.toml" and "api/vx.toml"
+// name the same file.
+func cleanFile(file string) string {
+	return filepath.ToSlash(filepath.Clean(file))
+}
+
+func sortMappings(ms []Mapping) {
+	sort.Slice(ms, func(i, j int) bool {
+		if ms[i].File != ms[j].File {
+			return fileLess(ms[i].File, ms[j].File)
+		}
+		return ms[i].EnvVar < ms[j].EnvVar
+	})
+}
+
+// fileLess orders files with the root vx.toml first.
+func fileLess(a, b string) bool {
+	if a == RootFile || b == RootFile {
+		return a == RootFile
+	}
+	return a < b
+}
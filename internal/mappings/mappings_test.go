@@ -0,0 +1,157 @@
+package mappings
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.dot.industries/vx/internal/config"
+)
+
+const testRoot = `workspaces = ["web/vx.toml"]
+
+[vault]
+address = "https://vault.example.com"
+auth_method = "oidc"
+
+[environments]
+default = "dev"
+available = ["dev"]
+
+[secrets]
+# shared across workspaces
+SHARED = "shared/key"
+`
+
+const testWorkspace = `[secrets]
+# the api url
+API_URL = "${env}/web/api_url"
+OLD = "${env}/web/old"
+`
+
+// setupRepo writes a root and one workspace vx.toml and returns the root
+// directory and parsed config.
+func setupRepo(t *testing.T) (string, *config.RootConfig) {
+	t.Helper()
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "vx.toml"), []byte(testRoot), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "web", "vx.toml"), []byte(testWorkspace), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadRootConfig(filepath.Join(dir, "vx.toml"))
+	if err != nil {
+		t.Fatalf("LoadRootConfig() error = %v", err)
+	}
+	return dir, cfg
+}
+
+func TestExport(t *testing.T) {
+	dir, cfg := setupRepo(t)
+
+	doc, err := Export(dir, cfg)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	want := []Mapping{
+		{File: "vx.toml", EnvVar: "SHARED", Path: "shared/key"},
+		{File: "web/vx.toml", EnvVar: "API_URL", Path: "${env}/web/api_url"},
+		{File: "web/vx.toml", EnvVar: "OLD", Path: "${env}/web/old"},
+	}
+	if !reflect.DeepEqual(doc.Mappings, want) {
+		t.Errorf("Mappings = %+v, want %+v", doc.Mappings, want)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	if _, err := Decode(strings.NewReader(`{"version":1,"mappings":[{"file":"vx.toml","env_var":"A","path":"a/b"}]}`)); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	for _, input := range []string{
+		`{"version":2,"mappings":[]}`,
+		`{"version":1,"mappings":[{"file":"vx.toml","env_var":"A"}]}`,
+		`{"version":1,"mappings":[{"file":"vx.toml","env_var":"A","path":"x/y"},{"file":"vx.toml","env_var":"A","path":"x/z"}]}`,
+		`{"version":1,"mappings":[{"file":"api/vx.toml","env_var":"A","path":"x/y"},{"file":"./api//vx.toml","env_var":"A","path":"x/z"}]}`,
+		`{"version":1,"extra":true}`,
+	} {
+		if _, err := Decode(strings.NewReader(input)); err == nil {
+			t.Errorf("Decode(%s) expected error", input)
+		}
+	}
+}
+
+func TestPlanImport_ApplyPreservesComments(t *testing.T) {
+	dir, cfg := setupRepo(t)
+
+	doc := &Document{Version: FormatVersion, Mappings: []Mapping{
+		{File: "web/vx.toml", EnvVar: "API_URL", Path: "${env}/web/url"},
+		{File: "web/vx.toml", EnvVar: "NEW", Path: "${env}/web/new"},
+	}}
+
+	plan, err := PlanImport(dir, cfg, doc, true)
+	if err != nil {
+		t.Fatalf("PlanImport() error = %v", err)
+	}
+
+	want := []Change{
+		{Action: ActionUpdate, File: "web/vx.toml", EnvVar: "API_URL", OldPath: "${env}/web/api_url", NewPath: "${env}/web/url"},
+		{Action: ActionAdd, File: "web/vx.toml", EnvVar: "NEW", NewPath: "${env}/web/new"},
+		{Action: ActionRemove, File: "web/vx.toml", EnvVar: "OLD", OldPath: "${env}/web/old"},
+	}
+	if !reflect.DeepEqual(plan.Changes, want) {
+		t.Fatalf("Changes = %+v, want %+v", plan.Changes, want)
+	}
+
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "web", "vx.toml"))
+	got := string(data)
+	for _, s := range []string{"# the api url", `API_URL = "${env}/web/url"`, `NEW = "${env}/web/new"`} {
+		if !strings.Contains(got, s) {
+			t.Errorf("web/vx.toml missing %q:\n%s", s, got)
+		}
+	}
+	if strings.Contains(got, "OLD") {
+		t.Errorf("web/vx.toml should no longer contain OLD:\n%s", got)
+	}
+
+	// The root file was not mentioned, so pruning left it alone.
+	root, _ := os.ReadFile(filepath.Join(dir, "vx.toml"))
+	if !strings.Contains(string(root), "SHARED") {
+		t.Error("root vx.toml should keep SHARED")
+	}
+}
+
+func TestPlanImport_RejectsUnknownFile(t *testing.T) {
+	dir, cfg := setupRepo(t)
+
+	doc := &Document{Version: FormatVersion, Mappings: []Mapping{
+		{File: "../elsewhere/vx.toml", EnvVar: "A", Path: "a/b"},
+	}}
+	if _, err := PlanImport(dir, cfg, doc, false); err == nil {
+		t.Error("PlanImport() expected error for a file outside the configured workspaces")
+	}
+}
+
+func TestUnderPath(t *testing.T) {
+	got := UnderPath("web/vx.toml", "${env}/web/", []string{"STRIPE_KEY", "API_URL"})
+	want := []Mapping{
+		{File: "web/vx.toml", EnvVar: "API_URL", Path: "${env}/web/api_url"},
+		{File: "web/vx.toml", EnvVar: "STRIPE_KEY", Path: "${env}/web/stripe_key"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnderPath() = %v, want %v", got, want)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	toml "github.com/pelletier/go-toml/v2"
+
+	"go.dot.industries/vx/internal/config"
 )
 
 // ConvertResult holds the output of converting a fnox config to vx format.
@@ -18,6 +20,7 @@ type ConvertResult struct {
 
 // vxRoot represents the root vx.toml structure for TOML serialization.
 type vxRoot struct {
+	Version      int            `toml:"version"`
 	Vault        vxVault        `toml:"vault"`
 	Environments vxEnvironments `toml:"environments"`
 	Workspaces   []string       `toml:"workspaces,omitempty"`
@@ -55,6 +58,7 @@ func Convert(fnox *FnoxConfig, rootDir string) (*ConvertResult, error) {
 	workspaces := convertImports(fnox.Import)
 
 	root := vxRoot{
+		Version: config.CurrentVersion,
 		Vault: vxVault{
 			Address:  address,
 			BasePath: basePath,
@@ -0,0 +1,100 @@
+// Package passcrypt encrypts data with a key derived from a passphrase. It
+// is shared by bootstrap bundles and snapshot archives, which wrap its
+// output in their own framing.
+//
+// A sealed message is the caller's header, a random salt, an AES-GCM nonce
+// and the ciphertext. The header and salt are authenticated along with the
+// content, so changing any byte makes Open fail.
+package passcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+const (
+	// SaltSize is the length of the random salt that follows the header.
+	SaltSize = 16
+	keySize  = 32
+	// kdfIterations follows the OWASP recommendation for PBKDF2-HMAC-SHA256.
+	kdfIterations = 600_000
+)
+
+// ErrDecrypt is returned when a message cannot be opened, either because
+// the passphrase is wrong or because the message was tampered with.
+var ErrDecrypt = errors.New("wrong passphrase or corrupted data")
+
+// Seal encrypts plain with a key derived from passphrase and a fresh salt,
+// and returns header followed by the salt, the nonce and the ciphertext.
+func Seal(header, plain []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("a passphrase is required")
+	}
+
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generating salt: %w", err)
+	}
+
+	aead, err := newAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+
+	ad := append(append([]byte{}, header...), salt...)
+	sealed := aead.Seal(nil, nonce, plain, ad)
+
+	var buf bytes.Buffer
+	buf.Write(ad)
+	buf.Write(nonce)
+	buf.Write(sealed)
+	return buf.Bytes(), nil
+}
+
+// Open decrypts a message produced by Seal whose header is headerLen bytes
+// long. Checking the header itself is up to the caller.
+func Open(sealed []byte, headerLen int, passphrase string) ([]byte, error) {
+	if len(sealed) < headerLen+SaltSize {
+		return nil, ErrDecrypt
+	}
+	ad, rest := sealed[:headerLen+SaltSize], sealed[headerLen+SaltSize:]
+
+	aead, err := newAEAD(passphrase, ad[headerLen:])
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	plain, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], ad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
+
+// newAEAD derives the key from passphrase and salt.
+func newAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, kdfIterations, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
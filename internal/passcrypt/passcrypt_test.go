@@ -0,0 +1,47 @@
+package passcrypt
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	header := []byte("HDR")
+	sealed, err := Seal(header, []byte("secret"), "correct horse")
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if !bytes.HasPrefix(sealed, header) {
+		t.Errorf("sealed message does not start with the header: %q", sealed)
+	}
+
+	plain, err := Open(sealed, len(header), "correct horse")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if string(plain) != "secret" {
+		t.Errorf("Open() = %q, want %q", plain, "secret")
+	}
+
+	if _, err := Open(sealed, len(header), "wrong"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Open() with wrong passphrase error = %v, want ErrDecrypt", err)
+	}
+
+	// The header is authenticated along with the content.
+	tampered := bytes.Clone(sealed)
+	tampered[0] ^= 1
+	if _, err := Open(tampered, len(header), "correct horse"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Open() with altered header error = %v, want ErrDecrypt", err)
+	}
+
+	if _, err := Open(sealed[:len(header)+SaltSize+4], len(header), "correct horse"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Open() of truncated message error = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestSeal_RequiresPassphrase(t *testing.T) {
+	if _, err := Seal(nil, []byte("x"), ""); err == nil {
+		t.Error("Seal() with empty passphrase succeeded")
+	}
+}
@@ -49,15 +49,27 @@ func (c *Cache) Get(path string) (map[string]string, bool) {
 	return copyMap(entry.data), true
 }
 
-// Set stores KV data for the given path. The data is copied to prevent
-// external mutation of cached values.
+// Set stores KV data for the given path with the cache's TTL. The data is
+// copied to prevent external mutation of cached values.
 func (c *Cache) Set(path string, data map[string]string) {
+	c.SetWithTTL(path, data, c.ttl)
+}
+
+// SetWithTTL stores KV data for the given path, expiring after ttl instead
+// of the cache's TTL. A ttl of zero or less removes any entry for path, so
+// the next Get misses.
+func (c *Cache) SetWithTTL(path string, data map[string]string, ttl time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if ttl <= 0 {
+		delete(c.entries, path)
+		return
+	}
+
 	c.entries[path] = cacheEntry{
 		data:      copyMap(data),
-		expiresAt: time.Now().Add(c.ttl),
+		expiresAt: time.Now().Add(ttl),
 	}
 }
 
@@ -74,6 +74,27 @@ func TestCache_Expiry(t *testing.T) {
 	}
 }
 
+func TestCache_SetWithTTL(t *testing.T) {
+	c := NewCache(time.Hour)
+
+	c.Set("dev/token", map[string]string{"value": "old"})
+	c.SetWithTTL("dev/token", map[string]string{"value": "new"}, 0)
+	if _, ok := c.Get("dev/token"); ok {
+		t.Error("expected a zero TTL to drop the entry")
+	}
+
+	c.SetWithTTL("dev/token", map[string]string{"value": "new"}, 10*time.Millisecond)
+	if _, ok := c.Get("dev/token"); !ok {
+		t.Fatal("expected cache hit before the override expires")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := c.Get("dev/token"); ok {
+		t.Error("expected cache miss after the override expires")
+	}
+}
+
 func TestCache_Clear(t *testing.T) {
 	c := NewCache(time.Minute)
 
@@ -0,0 +1,141 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// CommandScheme starts a mapping whose value is the standard output of a
+// local command rather than a Vault key, e.g. "cmd://op read op://dev/db/password".
+const CommandScheme = "cmd://"
+
+// IsCommand reports whether a mapping runs a command instead of reading Vault.
+func IsCommand(path string) bool {
+	return strings.HasPrefix(path, CommandScheme)
+}
+
+// WithCommands allows cmd:// mappings to run the given programs. A program is
+// matched against the first word of the command as written, so "op" allows
+// "cmd://op read ..." but not "cmd:///usr/bin/op read ...". Without this
+// option every command mapping fails to resolve.
+func WithCommands(allowed []string) Option {
+	return func(r *Resolver) {
+		r.commands = allowed
+	}
+}
+
+// runCommand runs argv without a shell and returns its standard output.
+// It is a variable so tests can stub it.
+var runCommand = func(ctx context.Context, argv []string) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, argv[0], argv[1:]...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return out, err
+}
+
+// commandMappings returns the command line of every cmd:// mapping, keyed by
+// env var, with the environment interpolated.
+func commandMappings(secrets map[string]string, env string) map[string]string {
+	commands := make(map[string]string)
+	for envVar, rawPath := range secrets {
+		if IsCommand(rawPath) {
+			commands[envVar] = strings.TrimPrefix(Interpolate(rawPath, env), CommandScheme)
+		}
+	}
+	return commands
+}
+
+// runCommands runs every command mapping concurrently and returns their
+// output keyed by env var. Arguments are split on whitespace and passed to
+// the program directly, never through a shell. Trailing newlines are
+// trimmed from the output, as a shell's $(...) would. With
+// WithPartialResults, a command that cannot run or fails is returned as a
+// Failure instead of ending the resolution.
+func (r *Resolver) runCommands(ctx context.Context, commands map[string]string) (map[string]string, []Failure, error) {
+	var mu sync.Mutex
+	values := make(map[string]string, len(commands))
+	var failures []Failure
+
+	// fail records err for envVar when results may be partial, and
+	// otherwise returns it to end the resolution.
+	fail := func(envVar string, err error) error {
+		if !r.partial {
+			return err
+		}
+		mu.Lock()
+		failures = append(failures, Failure{EnvVar: envVar, Err: err})
+		mu.Unlock()
+		return nil
+	}
+
+	// Every command line is checked before any command starts, so a
+	// disallowed mapping never leaves others running.
+	argvs := make(map[string][]string, len(commands))
+	for _, envVar := range slices.Sorted(maps.Keys(commands)) {
+		argv := strings.Fields(commands[envVar])
+		var invalid error
+		switch {
+		case len(argv) == 0:
+			invalid = fmt.Errorf("%s: empty command", envVar)
+		case len(r.commands) == 0:
+			invalid = fmt.Errorf("%s runs %q, but command mappings are not enabled (allow programs with commands in ~/.vx/config.toml)", envVar, argv[0])
+		case !slices.Contains(r.commands, argv[0]):
+			invalid = fmt.Errorf("%s runs %q, which is not an allowed command (see commands in ~/.vx/config.toml)", envVar, argv[0])
+		}
+		if invalid != nil {
+			if err := fail(envVar, invalid); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		argvs[envVar] = argv
+	}
+
+	g, groupCtx := errgroup.WithContext(ctx)
+	g.SetLimit(r.maxConcurrency)
+
+	for envVar, argv := range argvs {
+		g.Go(func() error {
+			ctx := groupCtx
+			if r.pathTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, r.pathTimeout)
+				defer cancel()
+			}
+
+			out, err := runCommand(ctx, argv)
+			if ctx.Err() == context.DeadlineExceeded {
+				err = fmt.Errorf("run %q for %s: timed out: %w", argv[0], envVar, ctx.Err())
+			} else if err != nil {
+				err = fmt.Errorf("run %q for %s: %w", argv[0], envVar, err)
+			}
+			if err != nil {
+				if err := r.skipped(groupCtx, []string{envVar}, err); err != nil {
+					return fail(envVar, err)
+				}
+				return nil
+			}
+
+			mu.Lock()
+			values[envVar] = strings.TrimRight(string(out), "\r\n")
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+	slices.SortFunc(failures, compareFailures)
+	return values, failures, nil
+}
@@ -0,0 +1,150 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubCommands replaces runCommand for the duration of a test and records
+// every argv it is called with.
+func stubCommands(t *testing.T, fn func(argv []string) ([]byte, error)) *[][]string {
+	t.Helper()
+
+	var mu sync.Mutex
+	var calls [][]string
+
+	orig := runCommand
+	runCommand = func(ctx context.Context, argv []string) ([]byte, error) {
+		mu.Lock()
+		calls = append(calls, argv)
+		mu.Unlock()
+		return fn(argv)
+	}
+	t.Cleanup(func() { runCommand = orig })
+
+	return &calls
+}
+
+func TestResolver_Commands(t *testing.T) {
+	calls := stubCommands(t, func(argv []string) ([]byte, error) {
+		return []byte(argv[len(argv)-1] + "-value\n"), nil
+	})
+
+	vault := newMockVault().withData("secrets/dev/database", map[string]string{"url": "postgres://"})
+	r := New(vault, "secrets", WithCommands([]string{"op", "pass"}))
+
+	got, err := r.Resolve(context.Background(), map[string]string{
+		"DATABASE_URL": "${env}/database/url",
+		"STRIPE_KEY":   "cmd://op read op://${env}/stripe/key",
+		"NPM_TOKEN":    "cmd://pass  show npm",
+	}, "dev")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	want := map[string]string{
+		"DATABASE_URL": "postgres://",
+		"STRIPE_KEY":   "op://dev/stripe/key-value",
+		"NPM_TOKEN":    "npm-value",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() = %v, want %v", got, want)
+	}
+
+	if len(*calls) != 2 {
+		t.Fatalf("ran %d commands, want 2", len(*calls))
+	}
+	if vault.calls.Load() != 1 {
+		t.Errorf("Vault reads = %d, want 1", vault.calls.Load())
+	}
+}
+
+func TestResolver_CommandsNotAllowed(t *testing.T) {
+	calls := stubCommands(t, func(argv []string) ([]byte, error) {
+		return []byte("value"), nil
+	})
+
+	secrets := map[string]string{"TOKEN": "cmd://curl https://example.com"}
+
+	if _, err := New(newMockVault(), "").Resolve(context.Background(), secrets, "dev"); err == nil ||
+		!strings.Contains(err.Error(), "not enabled") {
+		t.Errorf("Resolve() without WithCommands error = %v, want not enabled", err)
+	}
+
+	r := New(newMockVault(), "", WithCommands([]string{"op"}))
+	if _, err := r.Resolve(context.Background(), secrets, "dev"); err == nil ||
+		!strings.Contains(err.Error(), "not an allowed command") {
+		t.Errorf("Resolve() error = %v, want not allowed", err)
+	}
+
+	if len(*calls) != 0 {
+		t.Errorf("ran %v, want no commands", *calls)
+	}
+}
+
+func TestResolver_CommandsNotAllowed_RunsNone(t *testing.T) {
+	calls := stubCommands(t, func(argv []string) ([]byte, error) {
+		return []byte("value"), nil
+	})
+
+	secrets := map[string]string{"CURL_TOKEN": "cmd://curl https://example.com"}
+	for _, name := range []string{"A", "B", "C", "D", "E"} {
+		secrets[name+"_TOKEN"] = "cmd://op read op://" + name
+	}
+
+	r := New(newMockVault(), "", WithCommands([]string{"op"}))
+	_, err := r.Resolve(context.Background(), secrets, "dev")
+	if err == nil || !strings.Contains(err.Error(), "CURL_TOKEN") {
+		t.Errorf("Resolve() error = %v, want CURL_TOKEN not allowed", err)
+	}
+
+	// Give any command started before the disallowed one was seen a chance
+	// to run.
+	time.Sleep(20 * time.Millisecond)
+	if len(*calls) != 0 {
+		t.Errorf("ran %v, want no commands", *calls)
+	}
+}
+
+func TestResolver_CommandFailure(t *testing.T) {
+	stubCommands(t, func(argv []string) ([]byte, error) {
+		return nil, errors.New("exit status 1: item not found")
+	})
+
+	r := New(newMockVault(), "", WithCommands([]string{"op"}))
+	_, err := r.Resolve(context.Background(), map[string]string{"TOKEN": "cmd://op read op://x"}, "dev")
+	if err == nil || !strings.Contains(err.Error(), "TOKEN") || !strings.Contains(err.Error(), "item not found") {
+		t.Errorf("Resolve() error = %v, want the env var and the command's error", err)
+	}
+}
+
+func TestResolver_CommandTimeout(t *testing.T) {
+	orig := runCommand
+	runCommand = func(ctx context.Context, argv []string) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	t.Cleanup(func() { runCommand = orig })
+
+	r := New(newMockVault(), "", WithCommands([]string{"op"}), WithTimeout(10*time.Millisecond))
+	_, err := r.Resolve(context.Background(), map[string]string{"TOKEN": "cmd://op read op://x"}, "dev")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Resolve() error = %v, want timed out", err)
+	}
+}
+
+func TestGroupByPath_SkipsCommands(t *testing.T) {
+	groups := GroupByPath(map[string]string{
+		"DATABASE_URL": "dev/database/url",
+		"TOKEN":        "cmd://pass show dev/token",
+	}, "dev")
+
+	if len(groups) != 1 || len(groups["dev/database"]) != 1 {
+		t.Errorf("GroupByPath() = %v, want only the Vault mapping", groups)
+	}
+}
@@ -1,6 +1,10 @@
 package resolver
 
-import "strings"
+import (
+	"path"
+	"strconv"
+	"strings"
+)
 
 // SecretMapping maps an environment variable name to a key within a Vault
 // KV v2 path. For example, env var DATABASE_URL may map to key "url" under
@@ -8,34 +12,87 @@ import "strings"
 type SecretMapping struct {
 	EnvVar string
 	Key    string
+	// Version pins the mapping to one KV v2 version of its secret (see
+	// SplitVersion). Zero reads the latest version.
+	Version int
 }
 
 // GroupByPath groups secrets by their Vault KV v2 path prefix after
 // interpolating the environment. The path is split at the last "/" separator:
 // the prefix becomes the Vault read path, the suffix becomes the key name
-// within that path's data.
+// within that path's data. Paths are cleaned first, so "dev//database/url"
+// and "/dev/database/url" land in the same "dev/database" group.
+//
+// A mapping pinned to a version, such as "dev/database/url@3", is grouped
+// apart from the latest version of its secret, under "dev/database@3".
+//
+// Command mappings (see IsCommand) are left out, as they read no Vault path.
 //
 // The input map is not mutated.
 func GroupByPath(secrets map[string]string, env string) map[string][]SecretMapping {
 	groups := make(map[string][]SecretMapping, len(secrets))
 
 	for envVar, rawPath := range secrets {
-		resolved := Interpolate(rawPath, env)
+		if IsCommand(rawPath) {
+			continue
+		}
 
-		vaultPath, key := splitPath(resolved)
+		vaultPath, key := SplitMapping(rawPath, env)
 		if vaultPath == "" || key == "" {
 			continue
 		}
 
-		groups[vaultPath] = append(groups[vaultPath], SecretMapping{
-			EnvVar: envVar,
-			Key:    key,
+		_, version := SplitVersion(rawPath)
+		group := pinnedPath(vaultPath, version)
+		groups[group] = append(groups[group], SecretMapping{
+			EnvVar:  envVar,
+			Key:     key,
+			Version: version,
 		})
 	}
 
 	return groups
 }
 
+// SplitMapping returns the Vault path and key a mapping reads in env, the
+// same way GroupByPath splits it. A version pin is not part of the key (see
+// SplitVersion). Both are empty when the mapping has no key after its path.
+func SplitMapping(rawPath, env string) (string, string) {
+	rawPath, _ = SplitVersion(rawPath)
+	return splitPath(cleanPath(Interpolate(rawPath, env)))
+}
+
+// SplitVersion splits a version pin off a mapping: "dev/database/url@3"
+// reads the url key from version 3 of dev/database rather than the latest.
+// The version is zero when the mapping ends in anything but "@" followed by
+// a positive number.
+func SplitVersion(rawPath string) (string, int) {
+	idx := strings.LastIndex(rawPath, "@")
+	if idx < 0 || strings.Contains(rawPath[idx:], "/") {
+		return rawPath, 0
+	}
+	version, err := strconv.Atoi(rawPath[idx+1:])
+	if err != nil || version < 1 || rawPath[idx+1] == '+' {
+		return rawPath, 0
+	}
+	return rawPath[:idx], version
+}
+
+// pinnedPath returns the group GroupByPath puts the mappings reading version
+// of kvPath in.
+func pinnedPath(kvPath string, version int) string {
+	if version == 0 {
+		return kvPath
+	}
+	return kvPath + "@" + strconv.Itoa(version)
+}
+
+// cleanPath removes duplicate, leading, and trailing slashes and resolves
+// "." and ".." segments.
+func cleanPath(p string) string {
+	return strings.Trim(path.Clean("/"+p), "/")
+}
+
 // splitPath splits a resolved path at the last "/" into a Vault path prefix
 // and a key suffix. Returns empty strings if there is no "/" separator.
 func splitPath(path string) (string, string) {
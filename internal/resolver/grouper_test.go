@@ -151,3 +151,89 @@ func TestSplitPath(t *testing.T) {
 		})
 	}
 }
+
+func TestGroupByPath_CleansPaths(t *testing.T) {
+	got := GroupByPath(map[string]string{
+		"A": "${env}/database/url",
+		"B": "dev//database/user",
+		"C": "/dev/database/password",
+	}, "dev")
+
+	if len(got) != 1 {
+		t.Fatalf("GroupByPath() = %v, want a single dev/database group", got)
+	}
+	if n := len(got["dev/database"]); n != 3 {
+		t.Errorf("dev/database has %d mappings, want 3", n)
+	}
+}
+
+func TestSplitMapping(t *testing.T) {
+	tests := []struct {
+		raw      string
+		wantPath string
+		wantKey  string
+	}{
+		{"${env}/database/url", "staging/database", "url"},
+		{"/shared//stripe/key/", "shared/stripe", "key"},
+		{"token", "", ""},
+	}
+
+	for _, tt := range tests {
+		gotPath, gotKey := SplitMapping(tt.raw, "staging")
+		if gotPath != tt.wantPath || gotKey != tt.wantKey {
+			t.Errorf("SplitMapping(%q) = (%q, %q), want (%q, %q)",
+				tt.raw, gotPath, gotKey, tt.wantPath, tt.wantKey)
+		}
+	}
+}
+
+func TestSplitVersion(t *testing.T) {
+	tests := []struct {
+		raw         string
+		wantPath    string
+		wantVersion int
+	}{
+		{"${env}/database/url@3", "${env}/database/url", 3},
+		{"dev/database/url", "dev/database/url", 0},
+		{"dev/users/admin@example.com", "dev/users/admin@example.com", 0},
+		{"dev/team@2/key", "dev/team@2/key", 0},
+		{"dev/database/url@0", "dev/database/url@0", 0},
+		{"dev/database/url@-1", "dev/database/url@-1", 0},
+		{"dev/database/url@+1", "dev/database/url@+1", 0},
+		{"dev/database/url@", "dev/database/url@", 0},
+	}
+
+	for _, tt := range tests {
+		gotPath, gotVersion := SplitVersion(tt.raw)
+		if gotPath != tt.wantPath || gotVersion != tt.wantVersion {
+			t.Errorf("SplitVersion(%q) = (%q, %d), want (%q, %d)",
+				tt.raw, gotPath, gotVersion, tt.wantPath, tt.wantVersion)
+		}
+	}
+}
+
+func TestGroupByPath_PinnedVersions(t *testing.T) {
+	got := GroupByPath(map[string]string{
+		"URL":     "${env}/database/url",
+		"OLD_URL": "${env}/database/url@3",
+		"OLD_PW":  "${env}/database/password@3",
+	}, "dev")
+
+	if len(got) != 2 {
+		t.Fatalf("GroupByPath() = %v, want dev/database and dev/database@3", got)
+	}
+	if want := []SecretMapping{{EnvVar: "URL", Key: "url"}}; !mappingsContainAll(got["dev/database"], want) {
+		t.Errorf("dev/database = %v, want %v", got["dev/database"], want)
+	}
+	want := []SecretMapping{
+		{EnvVar: "OLD_URL", Key: "url", Version: 3},
+		{EnvVar: "OLD_PW", Key: "password", Version: 3},
+	}
+	if !mappingsContainAll(got["dev/database@3"], want) {
+		t.Errorf("dev/database@3 = %v, want %v", got["dev/database@3"], want)
+	}
+
+	if path, key := SplitMapping("${env}/database/url@3", "dev"); path != "dev/database" || key != "url" {
+		t.Errorf("SplitMapping() = (%q, %q), want the key without its version", path, key)
+	}
+}
@@ -0,0 +1,90 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// memoEntry holds the outcome of a single Vault read. once guarantees the read
+// happens at most one time, even when several resolvers ask concurrently.
+type memoEntry struct {
+	once sync.Once
+	data map[string]string
+	err  error
+}
+
+// Memo records every Vault read made through the resolvers that share it, so
+// each distinct full path is fetched exactly once. Unlike Cache, entries never
+// expire and failures are remembered too: a Memo is meant to live for a
+// single CLI invocation, not a long-running process. A read cut short by
+// its caller's context, cancelled or past its deadline, is not remembered:
+// it says nothing about the path.
+type Memo struct {
+	mu      sync.Mutex
+	entries map[string]*memoEntry
+}
+
+// NewMemo creates an empty Memo.
+func NewMemo() *Memo {
+	return &Memo{entries: make(map[string]*memoEntry)}
+}
+
+// Do returns the remembered result for path, calling read to produce it the
+// first time. Concurrent callers for the same path wait for that one read;
+// if it ends with a context error, they read again themselves.
+func (m *Memo) Do(path string, read func() (map[string]string, error)) (map[string]string, error) {
+	m.mu.Lock()
+	entry, ok := m.entries[path]
+	if !ok {
+		entry = &memoEntry{}
+		m.entries[path] = entry
+	}
+	m.mu.Unlock()
+
+	ran := false
+	entry.once.Do(func() {
+		ran = true
+		entry.data, entry.err = read()
+	})
+
+	if errors.Is(entry.err, context.Canceled) || errors.Is(entry.err, context.DeadlineExceeded) {
+		m.forget(path, entry)
+		if !ran {
+			return m.Do(path, read)
+		}
+	}
+	if entry.err != nil {
+		return nil, entry.err
+	}
+
+	// Return a copy so callers can't mutate the remembered data.
+	return copyMap(entry.data), nil
+}
+
+// forget drops entry for path, unless Reset or another read has replaced it.
+func (m *Memo) forget(path string, entry *memoEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.entries[path] == entry {
+		delete(m.entries, path)
+	}
+}
+
+// Len returns the number of distinct paths read so far.
+func (m *Memo) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.entries)
+}
+
+// Reset forgets every read, so the next resolution reads Vault again. Reads
+// in flight finish but are not remembered.
+func (m *Memo) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = make(map[string]*memoEntry)
+}
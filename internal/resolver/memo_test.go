@@ -0,0 +1,147 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMemo_ReadsOncePerPath(t *testing.T) {
+	memo := NewMemo()
+	var calls atomic.Int64
+	read := func() (map[string]string, error) {
+		calls.Add(1)
+		return map[string]string{"key": "value"}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := memo.Do("secret/dev/app", read); err != nil {
+				t.Errorf("Do() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("read called %d times, want 1", got)
+	}
+}
+
+func TestMemo_RemembersErrors(t *testing.T) {
+	memo := NewMemo()
+	calls := 0
+	read := func() (map[string]string, error) {
+		calls++
+		return nil, errors.New("permission denied")
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := memo.Do("secret/prod/app", read); err == nil {
+			t.Fatal("Do() expected error")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("read called %d times, want 1", calls)
+	}
+}
+
+func TestMemo_ForgetsContextErrors(t *testing.T) {
+	for _, cause := range []error{context.Canceled, context.DeadlineExceeded} {
+		memo := NewMemo()
+		calls := 0
+		read := func() (map[string]string, error) {
+			if calls++; calls == 1 {
+				return nil, fmt.Errorf("reading secret/dev/app: %w", cause)
+			}
+			return map[string]string{"key": "value"}, nil
+		}
+
+		if _, err := memo.Do("secret/dev/app", read); !errors.Is(err, cause) {
+			t.Fatalf("first Do() error = %v, want %v", err, cause)
+		}
+		data, err := memo.Do("secret/dev/app", read)
+		if err != nil || data["key"] != "value" {
+			t.Errorf("second Do() = %v, %v; want the value read again", data, err)
+		}
+		if calls != 2 {
+			t.Errorf("%v: read called %d times, want 2", cause, calls)
+		}
+	}
+}
+
+func TestMemo_WaiterRetriesAfterCancel(t *testing.T) {
+	memo := NewMemo()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	cancelled := func() (map[string]string, error) {
+		close(started)
+		<-release
+		return nil, context.Canceled
+	}
+	var calls atomic.Int64
+	read := func() (map[string]string, error) {
+		calls.Add(1)
+		return map[string]string{"key": "value"}, nil
+	}
+
+	go func() {
+		_, _ = memo.Do("p", cancelled)
+	}()
+	<-started
+
+	done := make(chan error)
+	go func() {
+		data, err := memo.Do("p", read)
+		if err == nil && data["key"] != "value" {
+			err = fmt.Errorf("data = %v", data)
+		}
+		done <- err
+	}()
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Errorf("waiting Do() error = %v, want its own read", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("read called %d times, want 1", calls.Load())
+	}
+}
+
+func TestMemo_ReturnsCopies(t *testing.T) {
+	memo := NewMemo()
+	read := func() (map[string]string, error) {
+		return map[string]string{"key": "original"}, nil
+	}
+
+	first, _ := memo.Do("p", read)
+	first["key"] = "mutated"
+
+	second, _ := memo.Do("p", read)
+	if second["key"] != "original" {
+		t.Errorf("key = %q, want %q", second["key"], "original")
+	}
+}
+
+func TestMemo_Reset(t *testing.T) {
+	memo := NewMemo()
+	calls := 0
+	read := func() (map[string]string, error) {
+		calls++
+		return map[string]string{"key": "v"}, nil
+	}
+
+	memo.Do("p", read)
+	memo.Reset()
+	memo.Do("p", read)
+
+	if calls != 2 {
+		t.Errorf("read called %d times, want 2 after Reset", calls)
+	}
+}
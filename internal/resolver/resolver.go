@@ -1,17 +1,34 @@
 package resolver
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 )
 
 const defaultMaxConcurrency = 10
 
+// maxRetryBackoff caps the wait between two attempts at a read.
+const maxRetryBackoff = 10 * time.Second
+
 // VaultReader abstracts reading key-value pairs from a Vault KV v2 path.
+// Implementations must give up when ctx is done.
 type VaultReader interface {
-	ReadKV(path string) (map[string]string, error)
+	ReadKV(ctx context.Context, path string) (map[string]string, error)
+}
+
+// VersionReader is a VaultReader that can also read earlier versions of a
+// KV v2 path, as needed by mappings pinned to a version (see SplitVersion).
+type VersionReader interface {
+	ReadKVVersion(ctx context.Context, path string, version int) (map[string]string, error)
 }
 
 // Option configures a Resolver.
@@ -27,6 +44,34 @@ func WithMaxConcurrency(n int) Option {
 	}
 }
 
+// WithTimeout bounds each Vault read: a path that takes longer than d fails
+// with a timeout instead of holding up the whole resolution. The deadline of
+// the context passed to Resolve still applies on top. Values less than or
+// equal to zero are ignored.
+func WithTimeout(d time.Duration) Option {
+	return func(r *Resolver) {
+		if d > 0 {
+			r.pathTimeout = d
+		}
+	}
+}
+
+// WithRetry makes up to attempts reads of a path whose read fails with an
+// error retryable reports as transient, such as a 5xx response. The wait
+// before the nth retry is backoff doubled n-1 times, at most 10 seconds,
+// with up to half of it taken off at random so that concurrent reads do not
+// retry in lockstep. Values of attempts less than 2, or a nil retryable,
+// disable retries.
+func WithRetry(attempts int, backoff time.Duration, retryable func(error) bool) Option {
+	return func(r *Resolver) {
+		if attempts > 1 && retryable != nil {
+			r.retryAttempts = attempts
+			r.retryBackoff = backoff
+			r.retryable = retryable
+		}
+	}
+}
+
 // WithCache attaches an in-memory cache to the resolver. Nil values are
 // ignored.
 func WithCache(c *Cache) Option {
@@ -37,6 +82,50 @@ func WithCache(c *Cache) Option {
 	}
 }
 
+// WithCacheTTLs overrides the cache TTL for individual secrets, keyed by env
+// var name. A path shared by several secrets is cached for the shortest of
+// their TTLs, and a TTL of zero makes every read of that path go to Vault.
+// Secrets without an override use the cache's own TTL.
+func WithCacheTTLs(ttls map[string]time.Duration) Option {
+	return func(r *Resolver) {
+		r.cacheTTLs = ttls
+	}
+}
+
+// WithMemo shares a process-level Memo with the resolver, so paths already
+// read by any resolver using the same Memo are not fetched again. Nil values
+// are ignored.
+func WithMemo(m *Memo) Option {
+	return func(r *Resolver) {
+		if m != nil {
+			r.memo = m
+		}
+	}
+}
+
+// WithSkipOnError lets mappings whose path cannot be read be left out of the
+// result instead of failing the resolution. skip is asked once per failing
+// mapping; if it returns false for any mapping of a path, the error aborts
+// the resolution as usual. Otherwise every mapping of the path is reported
+// to onSkip, which may be nil.
+func WithSkipOnError(skip func(envVar string) bool, onSkip func(envVar string, err error)) Option {
+	return func(r *Resolver) {
+		r.skip = skip
+		r.onSkip = onSkip
+	}
+}
+
+// WithPartialResults makes Resolve read every path and run every command even
+// when some fail, instead of stopping at the first failure. It then returns
+// all the values it could resolve along with an *Error whose Report lists
+// each variable left unset and why, including those whose path was read but
+// lacks their key. Only the end of the caller's context stops it early.
+func WithPartialResults() Option {
+	return func(r *Resolver) {
+		r.partial = true
+	}
+}
+
 // Resolver resolves environment variable names to secret values by reading
 // from Vault KV v2 paths. It groups secrets by path prefix and fetches
 // each group concurrently.
@@ -44,7 +133,17 @@ type Resolver struct {
 	vaultClient    VaultReader
 	basePath       string
 	maxConcurrency int
+	pathTimeout    time.Duration
+	retryAttempts  int
+	retryBackoff   time.Duration
+	retryable      func(error) bool
 	cache          *Cache
+	cacheTTLs      map[string]time.Duration
+	memo           *Memo
+	commands       []string
+	skip           func(envVar string) bool
+	onSkip         func(envVar string, err error)
+	partial        bool
 }
 
 // New creates a Resolver with the given VaultReader and base path.
@@ -63,91 +162,434 @@ func New(client VaultReader, basePath string, opts ...Option) *Resolver {
 	return r
 }
 
+// PathError is a Vault path that could not be read.
+type PathError struct {
+	// Path is the path as mapped, without the base path.
+	Path string
+	// EnvVars are the variables mapped to the path, sorted.
+	EnvVars []string
+	Err     error
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("read vault path %q: %v", e.Path, e.Err)
+}
+
+func (e *PathError) Unwrap() error { return e.Err }
+
+// ErrKeyNotFound is the Failure of a variable whose path was read but holds
+// no value for its key.
+var ErrKeyNotFound = errors.New("key not found")
+
+// Failure is a variable a resolution left unset.
+type Failure struct {
+	EnvVar string
+	// Path is the Vault path the variable is mapped to, as mapped, or empty
+	// for a command mapping.
+	Path string
+	Err  error
+}
+
+// compareFailures orders failures by variable name.
+func compareFailures(a, b Failure) int {
+	return strings.Compare(a.EnvVar, b.EnvVar)
+}
+
+// Error is returned, wrapped, by Resolve when some paths could not be read.
+type Error struct {
+	// Failed are the paths whose read failed, the one that stopped the
+	// resolution first.
+	Failed []*PathError
+	// Cancelled are the paths left unread because the resolution stopped,
+	// sorted.
+	Cancelled []string
+	// Cause is why the caller's context ended, when it did; nil when the
+	// resolution stopped on a failed read.
+	Cause error
+	// Unresolved are the variables whose key was missing from their path or
+	// whose command failed, sorted by name. Only set with
+	// WithPartialResults.
+	Unresolved []Failure
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	if len(e.Failed) > 0 {
+		b.WriteString(e.Failed[0].Error())
+		if n := len(e.Failed) - 1; n > 0 {
+			fmt.Fprintf(&b, " (and %d more path(s) failed)", n)
+		}
+		if n := len(e.Cancelled); n > 0 && e.Cause == nil {
+			fmt.Fprintf(&b, " (%d path(s) left unread)", n)
+		}
+	}
+	if len(e.Unresolved) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("; ")
+		}
+		fmt.Fprintf(&b, "%s: %v", e.Unresolved[0].EnvVar, e.Unresolved[0].Err)
+		if n := len(e.Unresolved) - 1; n > 0 {
+			fmt.Fprintf(&b, " (and %d more variable(s) unset)", n)
+		}
+	}
+	if e.Cause != nil {
+		if b.Len() > 0 {
+			b.WriteString("; ")
+		}
+		fmt.Fprintf(&b, "cancelled with %d path(s) unread: %v", len(e.Cancelled), e.Cause)
+	}
+	return b.String()
+}
+
+// Unwrap returns the path errors and the cause, so errors.Is matches any of
+// them.
+func (e *Error) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed)+len(e.Unresolved)+1)
+	for _, pe := range e.Failed {
+		errs = append(errs, pe)
+	}
+	for _, f := range e.Unresolved {
+		errs = append(errs, f.Err)
+	}
+	if e.Cause != nil {
+		errs = append(errs, e.Cause)
+	}
+	return errs
+}
+
+// Report returns a Failure for every variable left unset because its path
+// failed, its key was missing, or its command failed, sorted by name.
+// Variables of paths left unread are not included.
+func (e *Error) Report() []Failure {
+	report := slices.Clone(e.Unresolved)
+	for _, pe := range e.Failed {
+		for _, v := range pe.EnvVars {
+			report = append(report, Failure{EnvVar: v, Path: pe.Path, Err: pe})
+		}
+	}
+	slices.SortFunc(report, compareFailures)
+	return report
+}
+
 // Resolve maps environment variable names to their secret values by reading
 // from Vault. The secrets map keys are env var names and values are Vault
 // path templates (e.g. "${env}/database/url"). The env parameter is
 // interpolated into each path template.
 //
+// Mappings starting with CommandScheme take their value from a local command
+// instead, provided the program was allowed with WithCommands.
+//
+// Reads stop when ctx is done, and the first failing path cancels the ones
+// still outstanding. Either way Resolve returns the values it did read
+// along with an error wrapping an *Error, which lists each path that failed
+// and each that was left unread; commands are not run then. See
+// WithPartialResults to carry on past failures instead.
+//
 // The input map is not mutated.
-func (r *Resolver) Resolve(secrets map[string]string, env string) (map[string]string, error) {
+func (r *Resolver) Resolve(ctx context.Context, secrets map[string]string, env string) (map[string]string, error) {
 	if len(secrets) == 0 {
 		return map[string]string{}, nil
 	}
 
 	groups := GroupByPath(secrets, env)
 
-	results, err := r.fetchAll(groups)
+	results, err := r.fetchAll(ctx, groups)
+	resolved := r.mapResults(groups, results)
+	var rerr *Error
 	if err != nil {
-		return nil, fmt.Errorf("resolve secrets: %w", err)
+		if !errors.As(err, &rerr) || !r.partial || rerr.Cause != nil {
+			return resolved, fmt.Errorf("resolve secrets: %w", err)
+		}
 	}
 
-	return r.mapResults(groups, results), nil
+	var unresolved []Failure
+	if r.partial {
+		unresolved = missingKeys(groups, results)
+	}
+
+	if commands := commandMappings(secrets, env); len(commands) > 0 {
+		values, failed, err := r.runCommands(ctx, commands)
+		if err != nil {
+			return resolved, fmt.Errorf("resolve secrets: %w", err)
+		}
+		maps.Copy(resolved, values)
+		unresolved = append(unresolved, failed...)
+	}
+
+	if r.partial && ctx.Err() != nil {
+		return resolved, fmt.Errorf("resolve secrets: %w", context.Cause(ctx))
+	}
+	if len(unresolved) > 0 {
+		if rerr == nil {
+			rerr = &Error{}
+		}
+		slices.SortFunc(unresolved, compareFailures)
+		rerr.Unresolved = unresolved
+	}
+	if rerr != nil {
+		return resolved, fmt.Errorf("resolve secrets: %w", rerr)
+	}
+
+	return resolved, nil
+}
+
+// missingKeys returns a Failure for every mapping whose path was read but
+// holds no value for its key.
+func missingKeys(groups map[string][]SecretMapping, results map[string]map[string]string) []Failure {
+	var missing []Failure
+	for path, mappings := range groups {
+		data, ok := results[path]
+		if !ok {
+			continue
+		}
+		for _, m := range mappings {
+			if _, ok := data[m.Key]; !ok {
+				missing = append(missing, Failure{
+					EnvVar: m.EnvVar,
+					Path:   path,
+					Err:    fmt.Errorf("%w: %q in %q", ErrKeyNotFound, m.Key, path),
+				})
+			}
+		}
+	}
+	return missing
 }
 
 // fetchAll reads all Vault paths concurrently with bounded concurrency.
-// Returns a map of vault-path to its KV data.
-func (r *Resolver) fetchAll(groups map[string][]SecretMapping) (map[string]map[string]string, error) {
-	var mu sync.Mutex
-	results := make(map[string]map[string]string, len(groups))
+// Returns a map of vault-path to its KV data, holding the paths read even
+// when others failed, and an *Error for those that were not.
+func (r *Resolver) fetchAll(ctx context.Context, groups map[string][]SecretMapping) (map[string]map[string]string, error) {
+	f := &fetch{
+		parent:  ctx,
+		results: make(map[string]map[string]string, len(groups)),
+	}
 
-	g := new(errgroup.Group)
+	g, gctx := errgroup.WithContext(ctx)
 	g.SetLimit(r.maxConcurrency)
 
-	for path := range groups {
-		g.Go(r.fetchPath(path, &mu, results))
+	for path, mappings := range groups {
+		g.Go(r.fetchPath(gctx, f, path, mappings))
+	}
+
+	// fetchPath records every error in f; the one it returns only cancels
+	// the other reads.
+	_ = g.Wait()
+
+	if len(f.failed) == 0 && len(f.cancelled) == 0 {
+		return f.results, nil
 	}
+	slices.Sort(f.cancelled)
+	rerr := &Error{Failed: f.failed, Cancelled: f.cancelled}
+	if ctx.Err() != nil {
+		rerr.Cause = context.Cause(ctx)
+	}
+	return f.results, rerr
+}
 
-	if err := g.Wait(); err != nil {
-		return nil, err
+// fetch collects the outcome of the reads of one fetchAll.
+type fetch struct {
+	// parent is the caller's context, to tell its end from a cancellation
+	// caused by a failed read.
+	parent context.Context
+
+	mu        sync.Mutex
+	results   map[string]map[string]string
+	failed    []*PathError
+	cancelled []string
+}
+
+// skipped returns err unless every one of envVars may be skipped, in which
+// case each is reported to the skip handler and nil is returned. Errors
+// after the resolution itself was cancelled are never skipped.
+func (r *Resolver) skipped(ctx context.Context, envVars []string, err error) error {
+	if r.skip == nil || ctx.Err() != nil {
+		return err
 	}
+	for _, v := range envVars {
+		if !r.skip(v) {
+			return err
+		}
+	}
+	if r.onSkip != nil {
+		for _, v := range envVars {
+			r.onSkip(v, err)
+		}
+	}
+	return nil
+}
 
-	return results, nil
+// pathTTL returns the cache TTL for a path read for mappings: the shortest
+// override among them, or -1 when none has one.
+func (r *Resolver) pathTTL(mappings []SecretMapping) time.Duration {
+	ttl := time.Duration(-1)
+	for _, m := range mappings {
+		if d, ok := r.cacheTTLs[m.EnvVar]; ok && (ttl < 0 || d < ttl) {
+			ttl = d
+		}
+	}
+	return ttl
 }
 
-// fetchPath returns a function that reads a single Vault path and stores
-// the result. It checks the cache first when available.
-func (r *Resolver) fetchPath(
-	path string,
-	mu *sync.Mutex,
-	results map[string]map[string]string,
-) func() error {
+// fetchPath returns a function that reads a single Vault path and records
+// the result in f. It checks the cache first when available. A failed read
+// is dropped instead of recorded when every mapping of the path may be
+// skipped. A read interrupted by the end of ctx, or not started because of
+// it, counts as cancelled unless it is what ended it.
+func (r *Resolver) fetchPath(ctx context.Context, f *fetch, path string, mappings []SecretMapping) func() error {
 	return func() error {
-		data, err := r.readWithCache(path)
-		if err != nil {
-			return fmt.Errorf("read vault path %q: %w", path, err)
+		if ctx.Err() != nil {
+			f.mu.Lock()
+			f.cancelled = append(f.cancelled, path)
+			f.mu.Unlock()
+			return nil
 		}
 
-		mu.Lock()
-		results[path] = data
-		mu.Unlock()
+		// The mappings of a group all pin the same version, which is part of
+		// the group's name but not of the path to read.
+		version := mappings[0].Version
+		kvPath := strings.TrimSuffix(path, pinnedPath("", version))
+
+		data, err := r.readWithCache(ctx, kvPath, version, r.pathTTL(mappings))
+		if err == nil {
+			f.mu.Lock()
+			f.results[path] = data
+			f.mu.Unlock()
+			return nil
+		}
 
-		return nil
+		envVars := make([]string, len(mappings))
+		for i, m := range mappings {
+			envVars[i] = m.EnvVar
+		}
+		slices.Sort(envVars)
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out: %w", err)
+		}
+		pe := &PathError{Path: path, EnvVars: envVars, Err: err}
+		if r.skipped(ctx, envVars, pe) == nil {
+			return nil
+		}
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		// A failed read is recorded before it cancels ctx, so a read
+		// cancelled along with ctx was interrupted by another's failure.
+		callerDone := f.parent.Err() != nil && errors.Is(err, f.parent.Err())
+		if callerDone || ctx.Err() != nil && errors.Is(err, context.Canceled) {
+			f.cancelled = append(f.cancelled, path)
+			return nil
+		}
+		f.failed = append(f.failed, pe)
+		if r.partial {
+			// Let the other reads finish.
+			return nil
+		}
+		return pe
 	}
 }
 
-// readWithCache reads from cache first (if available), falling back to the
-// Vault client.
-func (r *Resolver) readWithCache(path string) (map[string]string, error) {
+// readWithCache reads version of path from cache first (if available),
+// falling back to the Vault client. A version of zero is the latest. With a
+// Memo attached, the whole lookup runs at most once per full path and
+// version.
+func (r *Resolver) readWithCache(ctx context.Context, path string, version int, ttl time.Duration) (map[string]string, error) {
 	fullPath := r.fullPath(path)
 
-	if r.cache != nil {
-		if data, ok := r.cache.Get(fullPath); ok {
+	if r.memo != nil {
+		return r.memo.Do(pinnedPath(fullPath, version), func() (map[string]string, error) {
+			return r.readThrough(ctx, fullPath, version, ttl)
+		})
+	}
+
+	return r.readThrough(ctx, fullPath, version, ttl)
+}
+
+// readThrough reads version of fullPath from the cache or, on a miss, from
+// Vault (see readRetrying). A ttl of zero bypasses the cache.
+func (r *Resolver) readThrough(ctx context.Context, fullPath string, version int, ttl time.Duration) (map[string]string, error) {
+	cacheKey := pinnedPath(fullPath, version)
+	if r.cache != nil && ttl != 0 {
+		if data, ok := r.cache.Get(cacheKey); ok {
 			return data, nil
 		}
 	}
 
-	data, err := r.vaultClient.ReadKV(fullPath)
+	data, err := r.readRetrying(ctx, fullPath, version)
 	if err != nil {
 		return nil, err
 	}
 
 	if r.cache != nil {
-		r.cache.Set(fullPath, data)
+		if ttl < 0 {
+			r.cache.Set(cacheKey, data)
+		} else {
+			r.cache.SetWithTTL(cacheKey, data, ttl)
+		}
 	}
 
 	return data, nil
 }
 
+// readRetrying reads version of fullPath from Vault, trying again after a
+// transient failure as configured with WithRetry. The wait between attempts
+// ends early when ctx is done, returning the last failure.
+func (r *Resolver) readRetrying(ctx context.Context, fullPath string, version int) (map[string]string, error) {
+	for attempt := 1; ; attempt++ {
+		data, err := r.readOnce(ctx, fullPath, version)
+		if err == nil || attempt >= r.retryAttempts || ctx.Err() != nil || !r.retryable(err) {
+			return data, err
+		}
+
+		timer := time.NewTimer(r.retryDelay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDelay returns the wait after the given failed attempt: the backoff
+// doubled for each earlier attempt, capped, less a random part of up to half.
+func (r *Resolver) retryDelay(attempt int) time.Duration {
+	d := r.retryBackoff
+	for i := 1; i < attempt && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, maxRetryBackoff)
+	if half := d / 2; half > 0 {
+		d -= rand.N(half)
+	}
+	return d
+}
+
+// readOnce makes a single read of version of fullPath within the per-path
+// timeout. Rate limiting is up to the Vault client (see vault.WithRateLimit).
+func (r *Resolver) readOnce(ctx context.Context, fullPath string, version int) (map[string]string, error) {
+	if r.pathTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.pathTimeout)
+		defer cancel()
+	}
+
+	return r.read(ctx, fullPath, version)
+}
+
+// read reads version of fullPath from the Vault client, or its latest
+// version when version is zero.
+func (r *Resolver) read(ctx context.Context, fullPath string, version int) (map[string]string, error) {
+	if version == 0 {
+		return r.vaultClient.ReadKV(ctx, fullPath)
+	}
+	vr, ok := r.vaultClient.(VersionReader)
+	if !ok {
+		return nil, fmt.Errorf("reading version %d of %q: the Vault client cannot read earlier versions", version, fullPath)
+	}
+	return vr.ReadKVVersion(ctx, fullPath, version)
+}
+
 // fullPath joins the base path with the given relative path.
 func (r *Resolver) fullPath(path string) string {
 	if r.basePath == "" {
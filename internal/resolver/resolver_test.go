@@ -1,7 +1,12 @@
 package resolver
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -31,7 +36,7 @@ func (m *mockVaultReader) withError(path string, err error) *mockVaultReader {
 	return m
 }
 
-func (m *mockVaultReader) ReadKV(path string) (map[string]string, error) {
+func (m *mockVaultReader) ReadKV(ctx context.Context, path string) (map[string]string, error) {
 	m.calls.Add(1)
 
 	if err, ok := m.errPaths[path]; ok {
@@ -70,7 +75,7 @@ func TestResolver_Resolve(t *testing.T) {
 		"STRIPE_SECRET_KEY":   "${env}/stripe/secret_key",
 	}
 
-	got, err := r.Resolve(secrets, "dev")
+	got, err := r.Resolve(context.Background(), secrets, "dev")
 	if err != nil {
 		t.Fatalf("Resolve() error = %v", err)
 	}
@@ -92,7 +97,7 @@ func TestResolver_ResolveEmptySecrets(t *testing.T) {
 	vault := newMockVault()
 	r := New(vault, "secrets")
 
-	got, err := r.Resolve(map[string]string{}, "dev")
+	got, err := r.Resolve(context.Background(), map[string]string{}, "dev")
 	if err != nil {
 		t.Fatalf("Resolve() error = %v", err)
 	}
@@ -118,7 +123,7 @@ func TestResolver_ResolveSharedPaths(t *testing.T) {
 		"OPENAI_API_KEY": "shared/openai/api_key",
 	}
 
-	got, err := r.Resolve(secrets, "dev")
+	got, err := r.Resolve(context.Background(), secrets, "dev")
 	if err != nil {
 		t.Fatalf("Resolve() error = %v", err)
 	}
@@ -140,12 +145,55 @@ func TestResolver_ErrorHandling(t *testing.T) {
 		"STRIPE_SECRET_KEY": "${env}/stripe/secret_key",
 	}
 
-	_, err := r.Resolve(secrets, "dev")
+	_, err := r.Resolve(context.Background(), secrets, "dev")
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
 }
 
+func TestResolver_WithSkipOnError(t *testing.T) {
+	vault := newMockVault().
+		withData("secrets/dev/database", map[string]string{"url": "pg://localhost"}).
+		withError("secrets/dev/stripe", fmt.Errorf("permission denied")).
+		withError("secrets/dev/sentry", fmt.Errorf("permission denied"))
+
+	optional := map[string]bool{"STRIPE_SECRET_KEY": true, "STRIPE_WEBHOOK": true}
+	var skipped []string
+	r := New(vault, "secrets", WithSkipOnError(
+		func(envVar string) bool { return optional[envVar] },
+		func(envVar string, err error) { skipped = append(skipped, envVar) },
+	))
+
+	got, err := r.Resolve(context.Background(), map[string]string{
+		"DATABASE_URL":      "${env}/database/url",
+		"STRIPE_SECRET_KEY": "${env}/stripe/secret_key",
+		"STRIPE_WEBHOOK":    "${env}/stripe/webhook",
+	}, "dev")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if len(got) != 1 || got["DATABASE_URL"] != "pg://localhost" {
+		t.Errorf("Resolve() = %v, want only DATABASE_URL", got)
+	}
+	if len(skipped) != 2 {
+		t.Errorf("skipped = %v, want both stripe mappings", skipped)
+	}
+
+	// One mapping of the failing path must not be skipped, so the path fails.
+	_, err = r.Resolve(context.Background(), map[string]string{
+		"STRIPE_SECRET_KEY": "${env}/stripe/secret_key",
+		"STRIPE_REQUIRED":   "${env}/stripe/required",
+	}, "dev")
+	if err == nil {
+		t.Error("Resolve() skipped a path with a required mapping")
+	}
+
+	_, err = r.Resolve(context.Background(), map[string]string{"SENTRY_DSN": "${env}/sentry/dsn"}, "dev")
+	if err == nil {
+		t.Error("Resolve() skipped a mapping without a skip policy")
+	}
+}
+
 func TestResolver_EmptyBasePath(t *testing.T) {
 	vault := newMockVault().
 		withData("dev/database", map[string]string{"url": "pg://localhost"})
@@ -156,7 +204,7 @@ func TestResolver_EmptyBasePath(t *testing.T) {
 		"DATABASE_URL": "${env}/database/url",
 	}
 
-	got, err := r.Resolve(secrets, "dev")
+	got, err := r.Resolve(context.Background(), secrets, "dev")
 	if err != nil {
 		t.Fatalf("Resolve() error = %v", err)
 	}
@@ -182,7 +230,7 @@ func TestResolver_ConcurrentExecution(t *testing.T) {
 
 	r := New(vault, "", WithMaxConcurrency(5))
 
-	got, err := r.Resolve(secrets, "dev")
+	got, err := r.Resolve(context.Background(), secrets, "dev")
 	if err != nil {
 		t.Fatalf("Resolve() error = %v", err)
 	}
@@ -214,7 +262,7 @@ func TestResolver_WithCache(t *testing.T) {
 	}
 
 	// First call should hit Vault.
-	got1, err := r.Resolve(secrets, "dev")
+	got1, err := r.Resolve(context.Background(), secrets, "dev")
 	if err != nil {
 		t.Fatalf("first Resolve() error = %v", err)
 	}
@@ -226,7 +274,7 @@ func TestResolver_WithCache(t *testing.T) {
 	firstCalls := vault.calls.Load()
 
 	// Second call should hit cache, not Vault.
-	got2, err := r.Resolve(secrets, "dev")
+	got2, err := r.Resolve(context.Background(), secrets, "dev")
 	if err != nil {
 		t.Fatalf("second Resolve() error = %v", err)
 	}
@@ -240,6 +288,81 @@ func TestResolver_WithCache(t *testing.T) {
 	}
 }
 
+func TestResolver_WithCacheTTLs(t *testing.T) {
+	vault := newMockVault().
+		withData("secrets/dev/database", map[string]string{
+			"url":   "pg://cached",
+			"token": "t-1",
+		}).
+		withData("secrets/dev/config", map[string]string{
+			"region": "eu",
+		})
+
+	r := New(vault, "secrets",
+		WithCache(NewCache(time.Minute)),
+		WithCacheTTLs(map[string]time.Duration{
+			"DATABASE_TOKEN": 0,
+			"REGION":         time.Hour,
+		}),
+	)
+
+	secrets := map[string]string{
+		"DATABASE_URL":   "${env}/database/url",
+		"DATABASE_TOKEN": "${env}/database/token",
+		"REGION":         "${env}/config/region",
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.Resolve(context.Background(), secrets, "dev"); err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+	}
+
+	// dev/database holds an always-fresh secret, so both resolutions read
+	// it; dev/config is served from the cache the second time.
+	if got := vault.calls.Load(); got != 3 {
+		t.Errorf("Vault calls = %d, want 3", got)
+	}
+}
+
+func TestResolver_CacheOutlivesMemo(t *testing.T) {
+	vault := newMockVault().
+		withData("secrets/dev/database", map[string]string{"token": "t-1"}).
+		withData("secrets/dev/config", map[string]string{"region": "eu"}).
+		withData("secrets/dev/api", map[string]string{"key": "k-1"})
+
+	memo := NewMemo()
+	cache := NewCache(0)
+	secrets := map[string]string{
+		"DATABASE_TOKEN": "${env}/database/token",
+		"REGION":         "${env}/config/region",
+		"API_KEY":        "${env}/api/key",
+	}
+
+	// Each round resolves with a fresh resolver and a reset memo, as vx exec
+	// --watch does, so only the cache carries reads from one to the next.
+	for i := 0; i < 2; i++ {
+		memo.Reset()
+		r := New(vault, "secrets",
+			WithMemo(memo),
+			WithCache(cache),
+			WithCacheTTLs(map[string]time.Duration{
+				"DATABASE_TOKEN": 0,
+				"REGION":         time.Hour,
+				"API_KEY":        time.Minute,
+			}),
+		)
+		if _, err := r.Resolve(context.Background(), secrets, "dev"); err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+	}
+
+	// Only dev/database, never cached, is read again.
+	if got := vault.calls.Load(); got != 4 {
+		t.Errorf("Vault calls = %d, want 4", got)
+	}
+}
+
 func TestResolver_MissingKeyInVaultData(t *testing.T) {
 	vault := newMockVault().
 		withData("secrets/dev/database", map[string]string{
@@ -254,7 +377,7 @@ func TestResolver_MissingKeyInVaultData(t *testing.T) {
 		"DATABASE_AUTH_TOKEN": "${env}/database/auth_token",
 	}
 
-	got, err := r.Resolve(secrets, "dev")
+	got, err := r.Resolve(context.Background(), secrets, "dev")
 	if err != nil {
 		t.Fatalf("Resolve() error = %v", err)
 	}
@@ -286,3 +409,423 @@ func TestWithCache_IgnoresNil(t *testing.T) {
 		t.Error("expected nil cache when WithCache(nil)")
 	}
 }
+
+func TestResolver_WithMemo(t *testing.T) {
+	vault := newMockVault().
+		withData("secrets/shared/openai", map[string]string{"api_key": "sk-1"}).
+		withData("secrets/dev/database", map[string]string{"url": "pg://dev"}).
+		withData("secrets/prod/database", map[string]string{"url": "pg://prod"})
+
+	memo := NewMemo()
+	secrets := map[string]string{
+		"OPENAI_API_KEY": "shared/openai/api_key",
+		"DATABASE_URL":   "${env}/database/url",
+	}
+
+	// Two resolvers and two environments share the memo; shared/openai is
+	// only read once.
+	for _, env := range []string{"dev", "prod", "dev"} {
+		r := New(vault, "secrets", WithMemo(memo))
+		if _, err := r.Resolve(context.Background(), secrets, env); err != nil {
+			t.Fatalf("Resolve(%s) error = %v", env, err)
+		}
+	}
+
+	if got := vault.calls.Load(); got != 3 {
+		t.Errorf("Vault calls = %d, want 3", got)
+	}
+	if memo.Len() != 3 {
+		t.Errorf("memo.Len() = %d, want 3", memo.Len())
+	}
+}
+
+func TestWithMemo_IgnoresNil(t *testing.T) {
+	r := New(newMockVault(), "", WithMemo(nil))
+	if r.memo != nil {
+		t.Error("expected nil memo when WithMemo(nil)")
+	}
+}
+
+// hangingReader blocks on the paths in hang until ctx is done and serves
+// everything else immediately.
+type hangingReader struct {
+	hang map[string]bool
+}
+
+func (h *hangingReader) ReadKV(ctx context.Context, path string) (map[string]string, error) {
+	if h.hang[path] {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return map[string]string{"key": "value"}, nil
+}
+
+func TestResolver_WithTimeout(t *testing.T) {
+	reader := &hangingReader{hang: map[string]bool{"dev/slow": true}}
+	r := New(reader, "", WithTimeout(20*time.Millisecond))
+
+	start := time.Now()
+	_, err := r.Resolve(context.Background(), map[string]string{
+		"FAST": "dev/fast/key",
+		"SLOW": "dev/slow/key",
+	}, "dev")
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Resolve() error = %v, want deadline exceeded", err)
+	}
+	if !strings.Contains(err.Error(), `"dev/slow"`) || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("error %q should name the path that timed out", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Resolve() took %v, want it bounded by the path timeout", elapsed)
+	}
+}
+
+func TestResolver_ContextCancelled(t *testing.T) {
+	reader := &hangingReader{hang: map[string]bool{"dev/slow": true}}
+	r := New(reader, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := r.Resolve(ctx, map[string]string{"SLOW": "dev/slow/key"}, "dev"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Resolve() error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestResolver_PartialResults(t *testing.T) {
+	vault := newMockVault().
+		withData("secrets/dev/database", map[string]string{"url": "pg://localhost"}).
+		withError("secrets/dev/stripe", fmt.Errorf("permission denied"))
+
+	r := New(vault, "secrets", WithMaxConcurrency(1))
+
+	got, err := r.Resolve(context.Background(), map[string]string{
+		"DATABASE_URL":      "${env}/database/url",
+		"STRIPE_SECRET_KEY": "${env}/stripe/secret_key",
+		"STRIPE_WEBHOOK":    "${env}/stripe/webhook",
+	}, "dev")
+
+	var rerr *Error
+	if !errors.As(err, &rerr) {
+		t.Fatalf("Resolve() error = %v, want an *Error", err)
+	}
+	if len(rerr.Failed) != 1 {
+		t.Fatalf("Failed = %v, want one path", rerr.Failed)
+	}
+	pe := rerr.Failed[0]
+	if pe.Path != "dev/stripe" || strings.Join(pe.EnvVars, ",") != "STRIPE_SECRET_KEY,STRIPE_WEBHOOK" {
+		t.Errorf("Failed[0] = %q %v, want dev/stripe with both stripe variables", pe.Path, pe.EnvVars)
+	}
+	if rerr.Cause != nil {
+		t.Errorf("Cause = %v, want nil", rerr.Cause)
+	}
+	if !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("error %q should say why the path failed", err)
+	}
+	// With one read at a time, the database path is read either before the
+	// failure or not at all.
+	if v, ok := got["DATABASE_URL"]; ok && v != "pg://localhost" {
+		t.Errorf("DATABASE_URL = %q, want pg://localhost", v)
+	}
+	if _, ok := got["DATABASE_URL"]; !ok && !slices.Contains(rerr.Cancelled, "dev/database") {
+		t.Errorf("DATABASE_URL is neither resolved nor cancelled: %v", rerr)
+	}
+}
+
+func TestResolver_FailureCancelsOutstandingReads(t *testing.T) {
+	reader := &failingHangingReader{
+		hangingReader: hangingReader{hang: map[string]bool{"dev/slow": true, "dev/slower": true}},
+		fail:          "dev/broken",
+	}
+	r := New(reader, "")
+
+	start := time.Now()
+	got, err := r.Resolve(context.Background(), map[string]string{
+		"FAST":   "dev/fast/key",
+		"SLOW":   "dev/slow/key",
+		"SLOWER": "dev/slower/key",
+		"BROKEN": "dev/broken/key",
+	}, "dev")
+
+	var rerr *Error
+	if !errors.As(err, &rerr) {
+		t.Fatalf("Resolve() error = %v, want an *Error", err)
+	}
+	if len(rerr.Failed) != 1 || rerr.Failed[0].Path != "dev/broken" {
+		t.Errorf("Failed = %v, want only dev/broken", rerr.Failed)
+	}
+	if want := []string{"dev/slow", "dev/slower"}; !slices.Equal(rerr.Cancelled, want) {
+		t.Errorf("Cancelled = %v, want %v", rerr.Cancelled, want)
+	}
+	if !strings.Contains(err.Error(), "2 path(s) left unread") {
+		t.Errorf("error %q should count the unread paths", err)
+	}
+	if got["FAST"] != "value" {
+		t.Errorf("FAST = %q, want the value read before the failure", got["FAST"])
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Resolve() took %v, want the hanging reads cancelled", elapsed)
+	}
+}
+
+func TestResolver_CallerCancelled(t *testing.T) {
+	reader := &hangingReader{hang: map[string]bool{"dev/slow": true, "dev/slower": true}}
+	r := New(reader, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	got, err := r.Resolve(ctx, map[string]string{
+		"FAST":   "dev/fast/key",
+		"SLOW":   "dev/slow/key",
+		"SLOWER": "dev/slower/key",
+	}, "dev")
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Resolve() error = %v, want context.Canceled", err)
+	}
+	var rerr *Error
+	if !errors.As(err, &rerr) {
+		t.Fatalf("Resolve() error = %v, want an *Error", err)
+	}
+	if len(rerr.Failed) != 0 {
+		t.Errorf("Failed = %v, want none: the caller cancelled", rerr.Failed)
+	}
+	if want := []string{"dev/slow", "dev/slower"}; !slices.Equal(rerr.Cancelled, want) {
+		t.Errorf("Cancelled = %v, want %v", rerr.Cancelled, want)
+	}
+	if !strings.Contains(err.Error(), "2 path(s) unread") {
+		t.Errorf("error %q should count the unread paths", err)
+	}
+	if got["FAST"] != "value" {
+		t.Errorf("FAST = %q, want the value read before the cancellation", got["FAST"])
+	}
+}
+
+// failingHangingReader is a hangingReader whose fail path returns an error
+// once the other paths are in flight.
+type failingHangingReader struct {
+	hangingReader
+	fail string
+}
+
+func (f *failingHangingReader) ReadKV(ctx context.Context, path string) (map[string]string, error) {
+	if path == f.fail {
+		time.Sleep(20 * time.Millisecond)
+		return nil, fmt.Errorf("permission denied")
+	}
+	return f.hangingReader.ReadKV(ctx, path)
+}
+
+func TestWithTimeout_IgnoresNonPositive(t *testing.T) {
+	r := New(newMockVault(), "", WithTimeout(0), WithTimeout(-time.Second))
+	if r.pathTimeout != 0 {
+		t.Errorf("pathTimeout = %v, want 0", r.pathTimeout)
+	}
+}
+
+// versionedReader is a mockVaultReader that also serves earlier versions.
+type versionedReader struct {
+	*mockVaultReader
+	versions map[string]map[string]string // keyed by "path@version"
+}
+
+func (v *versionedReader) ReadKVVersion(ctx context.Context, path string, version int) (map[string]string, error) {
+	v.calls.Add(1)
+	data, ok := v.versions[fmt.Sprintf("%s@%d", path, version)]
+	if !ok {
+		return nil, fmt.Errorf("version %d of %s not found", version, path)
+	}
+	return data, nil
+}
+
+func TestResolver_PinnedVersion(t *testing.T) {
+	vault := &versionedReader{
+		mockVaultReader: newMockVault().withData("secrets/dev/database", map[string]string{"url": "pg://new"}),
+		versions: map[string]map[string]string{
+			"secrets/dev/database@3": {"url": "pg://old"},
+		},
+	}
+	secrets := map[string]string{
+		"DATABASE_URL":     "${env}/database/url",
+		"OLD_DATABASE_URL": "${env}/database/url@3",
+	}
+
+	cache := NewCache(time.Minute)
+	memo := NewMemo()
+	for range 2 {
+		r := New(vault, "secrets", WithCache(cache), WithMemo(memo))
+		got, err := r.Resolve(context.Background(), secrets, "dev")
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if got["DATABASE_URL"] != "pg://new" || got["OLD_DATABASE_URL"] != "pg://old" {
+			t.Errorf("Resolve() = %v, want the latest and version 3 apart", got)
+		}
+	}
+	if n := vault.calls.Load(); n != 2 {
+		t.Errorf("Vault calls = %d, want one per version", n)
+	}
+}
+
+func TestResolver_PinnedVersionUnsupported(t *testing.T) {
+	vault := newMockVault().withData("dev/database", map[string]string{"url": "pg://new"})
+
+	_, err := New(vault, "").Resolve(context.Background(), map[string]string{"URL": "dev/database/url@2"}, "dev")
+	if err == nil || !strings.Contains(err.Error(), "version 2") {
+		t.Errorf("Resolve() error = %v, want one about reading version 2", err)
+	}
+}
+
+// flakyReader fails the first failures reads of every path with err.
+type flakyReader struct {
+	failures int64
+	err      error
+	calls    atomic.Int64
+}
+
+func (f *flakyReader) ReadKV(ctx context.Context, path string) (map[string]string, error) {
+	if f.calls.Add(1) <= f.failures {
+		return nil, f.err
+	}
+	return map[string]string{"key": "value"}, nil
+}
+
+var errTransient = errors.New("503 service unavailable")
+
+func isTransient(err error) bool { return errors.Is(err, errTransient) }
+
+func TestResolver_WithRetry(t *testing.T) {
+	reader := &flakyReader{failures: 2, err: errTransient}
+	r := New(reader, "", WithRetry(3, time.Millisecond, isTransient))
+
+	got, err := r.Resolve(context.Background(), map[string]string{"KEY": "dev/api/key"}, "dev")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if got["KEY"] != "value" {
+		t.Errorf("KEY = %q, want value", got["KEY"])
+	}
+	if n := reader.calls.Load(); n != 3 {
+		t.Errorf("reads = %d, want 3", n)
+	}
+
+	// Attempts run out.
+	reader = &flakyReader{failures: 5, err: errTransient}
+	r = New(reader, "", WithRetry(3, time.Millisecond, isTransient))
+	if _, err := r.Resolve(context.Background(), map[string]string{"KEY": "dev/api/key"}, "dev"); !errors.Is(err, errTransient) {
+		t.Errorf("Resolve() error = %v, want the last transient failure", err)
+	}
+	if n := reader.calls.Load(); n != 3 {
+		t.Errorf("reads = %d, want 3", n)
+	}
+
+	// Other errors fail at once.
+	reader = &flakyReader{failures: 1, err: errors.New("permission denied")}
+	r = New(reader, "", WithRetry(3, time.Millisecond, isTransient))
+	if _, err := r.Resolve(context.Background(), map[string]string{"KEY": "dev/api/key"}, "dev"); err == nil {
+		t.Error("Resolve() retried an error that is not transient")
+	}
+	if n := reader.calls.Load(); n != 1 {
+		t.Errorf("reads = %d, want 1", n)
+	}
+}
+
+func TestResolver_WithRetry_ContextCancelled(t *testing.T) {
+	reader := &flakyReader{failures: 10, err: errTransient}
+	r := New(reader, "", WithRetry(10, time.Hour, isTransient))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := r.Resolve(ctx, map[string]string{"KEY": "dev/api/key"}, "dev"); err == nil {
+		t.Fatal("Resolve() expected an error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Resolve() took %v, want the backoff cut short by the context", elapsed)
+	}
+	if n := reader.calls.Load(); n != 1 {
+		t.Errorf("reads = %d, want 1", n)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	r := New(newMockVault(), "", WithRetry(10, 100*time.Millisecond, isTransient))
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 9: maxRetryBackoff} {
+		for range 20 {
+			if d := r.retryDelay(attempt); d <= want/2 || d > want {
+				t.Errorf("retryDelay(%d) = %v, want within (%v, %v]", attempt, d, want/2, want)
+			}
+		}
+	}
+}
+
+func TestWithRetry_IgnoresInvalid(t *testing.T) {
+	if r := New(newMockVault(), "", WithRetry(1, time.Second, isTransient)); r.retryAttempts != 0 {
+		t.Errorf("retryAttempts = %d, want retries disabled for one attempt", r.retryAttempts)
+	}
+	if r := New(newMockVault(), "", WithRetry(3, time.Second, nil)); r.retryAttempts != 0 {
+		t.Errorf("retryAttempts = %d, want retries disabled without a classifier", r.retryAttempts)
+	}
+}
+
+func TestResolver_WithPartialResults(t *testing.T) {
+	stubCommands(t, func(argv []string) ([]byte, error) {
+		if argv[len(argv)-1] == "broken" {
+			return nil, errors.New("exit status 1")
+		}
+		return []byte("from-op\n"), nil
+	})
+
+	vault := newMockVault().
+		withData("dev/database", map[string]string{"url": "pg://dev"}).
+		withError("dev/stripe", errors.New("permission denied"))
+	r := New(vault, "", WithPartialResults(), WithCommands([]string{"op"}))
+
+	got, err := r.Resolve(context.Background(), map[string]string{
+		"DATABASE_URL":  "${env}/database/url",
+		"DATABASE_USER": "${env}/database/user",
+		"STRIPE_KEY":    "${env}/stripe/key",
+		"STRIPE_SECRET": "${env}/stripe/secret",
+		"OP_TOKEN":      "cmd://op read token",
+		"OP_BROKEN":     "cmd://op read broken",
+		"NOT_ALLOWED":   "cmd://pass show x",
+	}, "dev")
+
+	want := map[string]string{"DATABASE_URL": "pg://dev", "OP_TOKEN": "from-op"}
+	if !maps.Equal(got, want) {
+		t.Errorf("Resolve() = %v, want %v", got, want)
+	}
+
+	var rerr *Error
+	if !errors.As(err, &rerr) {
+		t.Fatalf("Resolve() error = %v, want an *Error", err)
+	}
+	var vars []string
+	for _, f := range rerr.Report() {
+		vars = append(vars, f.EnvVar)
+	}
+	if want := []string{"DATABASE_USER", "NOT_ALLOWED", "OP_BROKEN", "STRIPE_KEY", "STRIPE_SECRET"}; !slices.Equal(vars, want) {
+		t.Errorf("Report() covers %v, want %v", vars, want)
+	}
+	if !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("error %v should report the missing user key", err)
+	}
+	if rerr.Report()[3].Path != "dev/stripe" {
+		t.Errorf("STRIPE_KEY failure = %+v, want its path", rerr.Report()[3])
+	}
+}
+
+func TestResolver_WithPartialResults_NoFailures(t *testing.T) {
+	vault := newMockVault().withData("dev/database", map[string]string{"url": "pg://dev"})
+
+	got, err := New(vault, "", WithPartialResults()).Resolve(context.Background(), map[string]string{"URL": "dev/database/url"}, "dev")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if got["URL"] != "pg://dev" {
+		t.Errorf("URL = %q, want pg://dev", got["URL"])
+	}
+}
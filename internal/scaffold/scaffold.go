@@ -0,0 +1,157 @@
+// Package scaffold writes the vx.toml files of a new project: a commented
+// root config built from a few answers, and an empty vx.toml for each of its
+// workspaces.
+package scaffold
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.dot.industries/vx/internal/config"
+)
+
+// ConfigFile is the name of the root and workspace config files.
+const ConfigFile = "vx.toml"
+
+// AuthMethods are the auth methods a root config can use, the default first.
+var AuthMethods = []string{"oidc", "approle", "kubernetes", "token"}
+
+// Options are the answers the root config is written from.
+type Options struct {
+	Address    string
+	AuthMethod string
+	// AuthRole is the Vault role to log in with; optional except for
+	// Kubernetes.
+	AuthRole string
+	BasePath string
+	// Environments are the available environments; Default must be one of
+	// them.
+	Environments []string
+	Default      string
+	// Workspaces are the workspace directories, relative to the root and
+	// slash-separated, e.g. "services/api".
+	Workspaces []string
+}
+
+// config returns the root config o describes.
+func (o Options) config() *config.RootConfig {
+	cfg := &config.RootConfig{
+		Version: config.CurrentVersion,
+		Vault: config.VaultConfig{
+			Address:    o.Address,
+			AuthMethod: o.AuthMethod,
+			AuthRole:   o.AuthRole,
+			BasePath:   o.BasePath,
+		},
+		Environments: config.EnvironmentConfig{
+			Default:   o.Default,
+			Available: o.Environments,
+		},
+	}
+	for _, ws := range o.Workspaces {
+		cfg.Workspaces = append(cfg.Workspaces, WorkspacePath(ws))
+	}
+	return cfg
+}
+
+// Validate checks that o makes a valid root config.
+func (o Options) Validate() error {
+	if !slices.Contains(AuthMethods, o.AuthMethod) {
+		return fmt.Errorf("auth method must be one of %s, got %q", strings.Join(AuthMethods, ", "), o.AuthMethod)
+	}
+	if o.BasePath == "" {
+		return fmt.Errorf("a KV mount (base path) is required")
+	}
+	for _, env := range o.Environments {
+		if env == "" || strings.ContainsAny(env, "/ ") {
+			return fmt.Errorf("invalid environment name %q", env)
+		}
+	}
+	names := map[string]string{}
+	for _, ws := range o.Workspaces {
+		if ws == "." || ws == "" || strings.HasPrefix(ws, "../") || path.IsAbs(ws) {
+			return fmt.Errorf("workspace %q must be a directory inside the repository", ws)
+		}
+		// -w selects a workspace by its directory name.
+		name := path.Base(ws)
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("workspaces %q and %q would both be named %q", other, ws, name)
+		}
+		names[name] = ws
+	}
+	return config.Validate(o.config())
+}
+
+// WorkspacePath returns the entry of the workspaces array for the workspace
+// directory dir.
+func WorkspacePath(dir string) string {
+	return path.Join(dir, ConfigFile)
+}
+
+// RootConfig renders the root vx.toml for o, with comments explaining each
+// setting and commented-out examples of the tables left empty.
+func RootConfig(o Options) []byte {
+	var b strings.Builder
+
+	b.WriteString("# vx configuration. Secrets are read from Vault and injected as environment\n")
+	b.WriteString("# variables by `vx exec -- <command>`; `vx validate` checks this file.\n")
+	fmt.Fprintf(&b, "version = %d\n\n", config.CurrentVersion)
+
+	b.WriteString("# Each workspace adds its own [secrets] and [defaults] in its vx.toml and is\n")
+	b.WriteString("# selected with -w <directory name>. `vx workspaces add <dir>` adds more.\n")
+	paths := make([]string, len(o.Workspaces))
+	for i, ws := range o.Workspaces {
+		paths[i] = WorkspacePath(ws)
+	}
+	fmt.Fprintf(&b, "workspaces = %s\n", quoteList(paths))
+
+	b.WriteString("\n[vault]\n")
+	fmt.Fprintf(&b, "address = %s\n", strconv.Quote(o.Address))
+	b.WriteString("\n# How `vx login` authenticates (oidc, approle, kubernetes, or token), and as\n")
+	b.WriteString("# which Vault role, e.g. auth_role = \"developer\".\n")
+	fmt.Fprintf(&b, "auth_method = %s\n", strconv.Quote(o.AuthMethod))
+	if o.AuthRole != "" {
+		fmt.Fprintf(&b, "auth_role = %s\n", strconv.Quote(o.AuthRole))
+	}
+	b.WriteString("\n# The KV mount secret paths are relative to.\n")
+	fmt.Fprintf(&b, "base_path = %s\n", strconv.Quote(o.BasePath))
+
+	b.WriteString("\n[environments]\n")
+	b.WriteString("# Selected with -e; ${env} in a secret path becomes the environment's name.\n")
+	fmt.Fprintf(&b, "default = %s\n", strconv.Quote(o.Default))
+	fmt.Fprintf(&b, "available = %s\n", quoteList(o.Environments))
+
+	b.WriteString("\n# Secrets every workspace gets: ENV_VAR = \"<path under base_path>/<key>\".\n")
+	b.WriteString("[secrets]\n")
+	b.WriteString("# DATABASE_URL = \"${env}/database/url\"\n")
+
+	b.WriteString("\n# Plain values every workspace gets; [defaults.<env>] overrides them in one\n")
+	b.WriteString("# environment.\n")
+	b.WriteString("[defaults]\n")
+	b.WriteString("# LOG_LEVEL = \"info\"\n")
+
+	return []byte(b.String())
+}
+
+// quoteList renders items as a TOML array of strings on one line.
+func quoteList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		quoted[i] = strconv.Quote(s)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// WorkspaceConfig renders the vx.toml of a new workspace.
+func WorkspaceConfig() []byte {
+	return []byte(`# Secrets and values for this workspace only, on top of the root vx.toml.
+[secrets]
+# API_KEY = "${env}/api/key"
+
+[defaults]
+# PORT = "3000"
+`)
+}
@@ -0,0 +1,106 @@
+package scaffold
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/tomlfmt"
+)
+
+func testOptions() Options {
+	return Options{
+		Address:      "https://vault.example.com",
+		AuthMethod:   "oidc",
+		BasePath:     "secret",
+		Environments: []string{"dev", "staging", "production"},
+		Default:      "dev",
+		Workspaces:   []string{"apps/web", "services/api"},
+	}
+}
+
+func TestRootConfig(t *testing.T) {
+	o := testOptions()
+	if err := o.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	data := RootConfig(o)
+	cfg, err := config.ParseRootConfig(data)
+	if err != nil {
+		t.Fatalf("ParseRootConfig() error = %v\n%s", err, data)
+	}
+	if err := config.Validate(cfg); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	if cfg.Version != config.CurrentVersion || cfg.Vault.Address != o.Address || cfg.Vault.AuthMethod != "oidc" ||
+		cfg.Vault.AuthRole != "" || cfg.Vault.BasePath != "secret" {
+		t.Errorf("config = %+v", cfg)
+	}
+	if cfg.Environments.Default != "dev" || !slices.Equal(cfg.Environments.Available, o.Environments) {
+		t.Errorf("environments = %+v", cfg.Environments)
+	}
+	if want := []string{"apps/web/vx.toml", "services/api/vx.toml"}; !slices.Equal(cfg.Workspaces, want) {
+		t.Errorf("workspaces = %v, want %v", cfg.Workspaces, want)
+	}
+	if len(cfg.Secrets) != 0 || len(cfg.Defaults) != 0 {
+		t.Errorf("examples should be commented out, got secrets %v and defaults %v", cfg.Secrets, cfg.Defaults)
+	}
+	assertFormatted(t, data)
+
+	o.AuthMethod, o.AuthRole, o.Workspaces = "kubernetes", "ci", nil
+	cfg, err = config.ParseRootConfig(RootConfig(o))
+	if err != nil {
+		t.Fatalf("ParseRootConfig() error = %v", err)
+	}
+	if cfg.Vault.AuthRole != "ci" || len(cfg.Workspaces) != 0 {
+		t.Errorf("config = %+v", cfg)
+	}
+	assertFormatted(t, RootConfig(o))
+
+	if _, err := config.ParseWorkspaceConfig(WorkspaceConfig()); err != nil {
+		t.Errorf("ParseWorkspaceConfig() error = %v", err)
+	}
+	assertFormatted(t, WorkspaceConfig())
+}
+
+// assertFormatted checks that vx lint would leave data as it is.
+func assertFormatted(t *testing.T, data []byte) {
+	t.Helper()
+	formatted, err := tomlfmt.Format(data)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if string(formatted) != string(data) {
+		t.Errorf("output is not formatted as vx lint would:\n%s\nwant:\n%s", data, formatted)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Options)
+		want   string
+	}{
+		{"auth method", func(o *Options) { o.AuthMethod = "ldap" }, "auth method must be one of"},
+		{"kubernetes role", func(o *Options) { o.AuthMethod = "kubernetes" }, "requires auth_role"},
+		{"address", func(o *Options) { o.Address = "" }, "address is required"},
+		{"base path", func(o *Options) { o.BasePath = "" }, "base path"},
+		{"default", func(o *Options) { o.Default = "prod" }, "not in available environments"},
+		{"environment", func(o *Options) { o.Environments = []string{"dev", "my env"} }, "invalid environment name"},
+		{"outside", func(o *Options) { o.Workspaces = []string{"../other"} }, "inside the repository"},
+		{"name clash", func(o *Options) { o.Workspaces = []string{"apps/api", "services/api"} }, "both be named \"api\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := testOptions()
+			tt.modify(&o)
+			err := o.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
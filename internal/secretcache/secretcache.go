@@ -0,0 +1,171 @@
+// Package secretcache keeps resolved secret values on disk between vx exec
+// runs, so repeated commands start without reading Vault again.
+//
+// Entries are encrypted with AES-256-GCM under a key derived from the Vault
+// token that read them. A copied cache file is useless without the token,
+// and logging in again (a new token) leaves old entries unreadable; they are
+// removed the next time they are looked up.
+package secretcache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	fileExt   = ".enc"
+	keyInfo   = "vx secret cache v1"
+	dirPerms  = 0700
+	filePerms = 0600
+)
+
+// Cache is a directory of encrypted entries, each holding the values of one
+// resolution.
+type Cache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// entry is the plaintext of a cache file.
+type entry struct {
+	CreatedAt time.Time         `json:"created_at"`
+	Values    map[string]string `json:"values"`
+}
+
+// New creates a cache in dir whose entries live for ttl. A ttl less than or
+// equal to zero disables the cache.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl, now: time.Now}
+}
+
+// Load returns the values stored under key if they decrypt with token, are
+// younger than the cache's TTL, and were stored after notBefore, such as the
+// time of the last known change in Vault. Stale or unreadable entries are
+// removed.
+func (c *Cache) Load(key, token string, notBefore time.Time) (map[string]string, bool) {
+	if c.ttl <= 0 || token == "" {
+		return nil, false
+	}
+
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	plaintext, err := open(data, key, token)
+	var e entry
+	if err == nil {
+		err = json.Unmarshal(plaintext, &e)
+	}
+	if err != nil || c.now().Sub(e.CreatedAt) >= c.ttl || !e.CreatedAt.After(notBefore) {
+		_ = os.Remove(path)
+		return nil, false
+	}
+	return e.Values, true
+}
+
+// Store encrypts values with token and saves them under key. It does nothing
+// when the cache is disabled or there is no token.
+func (c *Cache) Store(key, token string, values map[string]string) error {
+	if c.ttl <= 0 || token == "" {
+		return nil
+	}
+
+	plaintext, err := json.Marshal(entry{CreatedAt: c.now(), Values: values})
+	if err != nil {
+		return fmt.Errorf("encoding secret cache: %w", err)
+	}
+	data, err := seal(plaintext, key, token)
+	if err != nil {
+		return fmt.Errorf("encrypting secret cache: %w", err)
+	}
+
+	if err := os.MkdirAll(c.dir, dirPerms); err != nil {
+		return fmt.Errorf("creating secret cache directory: %w", err)
+	}
+	if err := os.WriteFile(c.path(key), data, filePerms); err != nil {
+		return fmt.Errorf("writing secret cache: %w", err)
+	}
+	return nil
+}
+
+// Clear removes every entry in dir and returns how many there were. A
+// missing directory is not an error.
+func Clear(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading secret cache directory: %w", err)
+	}
+
+	removed := 0
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), fileExt) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			return removed, fmt.Errorf("removing cached secrets: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// path returns the file holding the entry for key.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+fileExt)
+}
+
+// aead returns the cipher for token's entries.
+func aead(token string) (cipher.AEAD, error) {
+	k, err := hkdf.Key(sha256.New, []byte(token), nil, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext for token, binding it to key so an entry cannot be
+// passed off as another. The result is the nonce followed by the ciphertext.
+func seal(plaintext []byte, key, token string) ([]byte, error) {
+	gcm, err := aead(token)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, []byte(key)), nil
+}
+
+// open decrypts data written by seal.
+func open(data []byte, key, token string) ([]byte, error) {
+	gcm, err := aead(token)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("cache entry too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, []byte(key))
+}
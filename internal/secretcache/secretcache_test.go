@@ -0,0 +1,123 @@
+package secretcache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := New(dir, time.Minute)
+	c.now = func() time.Time { return now }
+
+	if _, ok := c.Load("k", "s.token", time.Time{}); ok {
+		t.Fatal("Load() hit on an empty cache")
+	}
+
+	if err := c.Store("k", "s.token", map[string]string{"DB_PASSWORD": "hunter2"}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	data, err := os.ReadFile(c.path("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("hunter2")) || bytes.Contains(data, []byte("DB_PASSWORD")) {
+		t.Error("cache file holds the value in plain text")
+	}
+	info, err := os.Stat(c.path("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("cache file mode = %v, want 0600", perm)
+	}
+
+	got, ok := c.Load("k", "s.token", time.Time{})
+	if !ok || got["DB_PASSWORD"] != "hunter2" {
+		t.Errorf("Load() = %v, %v", got, ok)
+	}
+
+	if _, ok := c.Load("k", "s.token", now); ok {
+		t.Error("Load() hit on an entry stored before a change")
+	}
+	if err := c.Store("k", "s.token", map[string]string{"A": "1"}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.Load("k", "s.token", time.Time{}); ok {
+		t.Error("Load() hit on an expired entry")
+	}
+	if _, err := os.Stat(c.path("k")); !os.IsNotExist(err) {
+		t.Error("expired entry should be removed")
+	}
+}
+
+func TestCache_otherToken(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	c := New(dir, time.Minute)
+
+	if err := c.Store("k", "s.old", map[string]string{"A": "1"}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	if _, ok := c.Load("k", "s.new", time.Time{}); ok {
+		t.Error("Load() hit with another token")
+	}
+	if _, err := os.Stat(c.path("k")); !os.IsNotExist(err) {
+		t.Error("unreadable entry should be removed")
+	}
+
+	// An entry moved to another key does not decrypt either.
+	if err := c.Store("k", "s.old", map[string]string{"A": "1"}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	if err := os.Rename(c.path("k"), c.path("other")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Load("other", "s.old", time.Time{}); ok {
+		t.Error("Load() hit on an entry stored under another key")
+	}
+}
+
+func TestCache_Disabled(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+
+	if err := New(dir, 0).Store("k", "s.token", map[string]string{"A": "1"}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	if err := New(dir, time.Minute).Store("k", "", map[string]string{"A": "1"}); err != nil {
+		t.Fatalf("Store() without a token error = %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("disabled cache should not create its directory")
+	}
+}
+
+func TestClear(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	if n, err := Clear(dir); n != 0 || err != nil {
+		t.Errorf("Clear() on a missing directory = %d, %v", n, err)
+	}
+
+	c := New(dir, time.Minute)
+	for _, k := range []string{"a", "b"} {
+		if err := c.Store(k, "s.token", map[string]string{"A": "1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := Clear(dir)
+	if err != nil || n != 2 {
+		t.Errorf("Clear() = %d, %v; want 2", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Error("Clear() removed a file it did not write")
+	}
+}
@@ -0,0 +1,185 @@
+package seed
+
+import (
+	"fmt"
+	"strings"
+
+	"go.dot.industries/vx/internal/resolver"
+)
+
+// Target is the subset of the Vault client needed to seed an environment.
+type Target interface {
+	ReadKVData(kvPath string) (map[string]interface{}, int, error)
+	WriteKV(kvPath string, data map[string]interface{}, cas int) error
+	DestroyKV(kvPath string) error
+}
+
+// Action is what seeding or destroying does with one secret.
+type Action string
+
+const (
+	// ActionCreate writes a secret that does not exist yet.
+	ActionCreate Action = "create"
+	// ActionUpdate adds the keys a secret is missing, keeping the others.
+	ActionUpdate Action = "update"
+	// ActionUnchanged marks a secret that has every key already.
+	ActionUnchanged Action = "unchanged"
+	// ActionDestroy deletes a secret with all its versions. Only secrets
+	// holding nothing but keys of the seed file are destroyed.
+	ActionDestroy Action = "destroy"
+	// ActionRemoveKeys writes a new version of a secret without the keys of
+	// the seed file, keeping the keys added by other means.
+	ActionRemoveKeys Action = "remove-keys"
+	// ActionMissing marks a secret to destroy that does not exist.
+	ActionMissing Action = "missing"
+	// ActionShared marks a secret left alone by a destroy because its path
+	// does not contain ${env}, so other environments use it too.
+	ActionShared Action = "shared"
+)
+
+// Step is the planned change to one secret.
+type Step struct {
+	// Path is relative to the client's mount.
+	Path   string
+	Action Action
+	// Keys are the keys a create or update writes, or a destroy removes.
+	Keys []string
+
+	data map[string]interface{}
+	cas  int
+}
+
+// Plan holds a step for every secret of a seed file. Nothing is written
+// until Apply is called, so a Plan doubles as the dry-run output.
+type Plan struct {
+	Steps []Step
+}
+
+// PlanSeed compares f with what is stored on dst for the environment env,
+// whose path segment is pathEnv. Only missing secrets and keys are written:
+// values already in Vault are never replaced, so seeding again is safe and
+// does not rotate generated values.
+func PlanSeed(f *File, dst Target, env, pathEnv string) (*Plan, error) {
+	p := &Plan{}
+	for _, s := range f.Secrets {
+		to := resolver.Interpolate(s.Path, pathEnv)
+
+		current, version, err := dst.ReadKVData(to)
+		if err != nil {
+			return nil, err
+		}
+
+		step := Step{Path: to, cas: version, data: make(map[string]interface{}, len(current)+len(s.Keys))}
+		for k, v := range current {
+			step.data[k] = v
+		}
+		for _, k := range s.Keys {
+			if _, ok := current[k.Name]; ok {
+				continue
+			}
+			v, err := k.value(env)
+			if err != nil {
+				return nil, fmt.Errorf("generating %s/%s: %w", to, k.Name, err)
+			}
+			step.data[k.Name] = v
+			step.Keys = append(step.Keys, k.Name)
+		}
+
+		switch {
+		case current == nil:
+			step.Action = ActionCreate
+		case len(step.Keys) > 0:
+			step.Action = ActionUpdate
+		default:
+			step.Action = ActionUnchanged
+		}
+		p.Steps = append(p.Steps, step)
+	}
+	return p, nil
+}
+
+// PlanDestroy plans removing the keys of f from dst for the environment
+// whose path segment is pathEnv. Only keys the seed file lists are removed:
+// a secret holding nothing else is destroyed with all its versions, and one
+// with other keys too gets a new version without the seeded ones. Secrets
+// whose path does not contain ${env} are shared with other environments and
+// are never touched.
+func PlanDestroy(f *File, dst Target, pathEnv string) (*Plan, error) {
+	p := &Plan{}
+	for _, s := range f.Secrets {
+		to := resolver.Interpolate(s.Path, pathEnv)
+		if !strings.Contains(s.Path, "${env}") {
+			p.Steps = append(p.Steps, Step{Path: to, Action: ActionShared})
+			continue
+		}
+
+		current, version, err := dst.ReadKVData(to)
+		if err != nil {
+			return nil, err
+		}
+		step := Step{Path: to, Action: ActionMissing}
+		if current == nil {
+			// Nothing readable, or a latest version someone deleted: its
+			// keys cannot be checked, so it is left alone.
+			p.Steps = append(p.Steps, step)
+			continue
+		}
+
+		step.data = make(map[string]interface{}, len(current))
+		for k, v := range current {
+			step.data[k] = v
+		}
+		for _, k := range s.Keys {
+			if _, ok := step.data[k.Name]; ok {
+				delete(step.data, k.Name)
+				step.Keys = append(step.Keys, k.Name)
+			}
+		}
+
+		switch {
+		case len(step.Keys) == 0:
+			step.Action = ActionUnchanged
+		case len(step.data) == 0:
+			step.Action = ActionDestroy
+		default:
+			step.Action = ActionRemoveKeys
+			step.cas = version
+		}
+		p.Steps = append(p.Steps, step)
+	}
+	return p, nil
+}
+
+// Count returns the number of steps with action a.
+func (p *Plan) Count(a Action) int {
+	n := 0
+	for _, s := range p.Steps {
+		if s.Action == a {
+			n++
+		}
+	}
+	return n
+}
+
+// Apply carries out the plan's creates, updates, and destroys. Writes are
+// a check-and-set against the version seen while planning, so a secret
+// changed in the meantime fails the seed instead of being overwritten.
+func (p *Plan) Apply(dst Target) error {
+	for _, s := range p.Steps {
+		switch s.Action {
+		case ActionCreate, ActionUpdate:
+			if err := dst.WriteKV(s.Path, s.data, s.cas); err != nil {
+				return fmt.Errorf("seeding %s: %w", s.Path, err)
+			}
+		case ActionRemoveKeys:
+			if err := dst.WriteKV(s.Path, s.data, s.cas); err != nil {
+				return fmt.Errorf("removing seeded keys from %s: %w", s.Path, err)
+			}
+		case ActionDestroy:
+			if err := dst.DestroyKV(s.Path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+// Package seed creates the Vault secrets of an environment from a seed file:
+// the secret paths a project needs, each key with a placeholder value or a
+// generated one. Seeding a new environment, such as a preview environment,
+// gives it real Vault paths to run against, and destroying it removes them.
+package seed
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	toml "github.com/pelletier/go-toml/v2"
+
+	"go.dot.industries/vx/internal/resolver"
+)
+
+// DefaultFile is the seed file vx seed reads next to the root vx.toml.
+const DefaultFile = "vx.seed.toml"
+
+// Generators are the kinds of value a seed can generate.
+var Generators = []string{"password", "hex", "base64", "uuid"}
+
+const (
+	defaultLength = 32
+	maxLength     = 1024
+)
+
+// passwordChars are the characters of generated passwords: letters and
+// digits only, so the value is safe in URLs and shells unquoted.
+const passwordChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// File is a parsed seed file.
+type File struct {
+	Secrets []Secret
+}
+
+// Secret is one secret of a seed file. Path is relative to the KV mount and
+// may contain ${env}.
+type Secret struct {
+	Path string
+	Keys []Key
+}
+
+// Key is one key of a secret: a placeholder Value, in which ${env} is
+// replaced with the environment's name, or a value made by Generate.
+type Key struct {
+	Name     string
+	Value    string
+	Generate string
+	// Length is the number of characters generated; not used for "uuid".
+	Length int
+}
+
+// Load reads the seed file at path.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading seed file: %w", err)
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Parse parses a seed file. Each [secrets."<path>"] table holds the keys of
+// one secret, as strings or as { generate = "<kind>", length = <n> }.
+func Parse(data []byte) (*File, error) {
+	var doc struct {
+		Secrets map[string]map[string]any `toml:"secrets"`
+	}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing seed file: %w", err)
+	}
+	if len(doc.Secrets) == 0 {
+		return nil, fmt.Errorf("no [secrets.\"<path>\"] tables")
+	}
+
+	f := &File{}
+	for _, p := range sortedKeys(doc.Secrets) {
+		clean := strings.Trim(p, "/")
+		if clean == "" || path.Clean(clean) != clean || strings.HasPrefix(clean, "../") {
+			return nil, fmt.Errorf("invalid secret path %q", p)
+		}
+		if len(doc.Secrets[p]) == 0 {
+			return nil, fmt.Errorf("secret %q has no keys", p)
+		}
+
+		s := Secret{Path: clean}
+		for _, name := range sortedKeys(doc.Secrets[p]) {
+			k, err := parseKey(name, doc.Secrets[p][name])
+			if err != nil {
+				return nil, fmt.Errorf("secret %q: %w", p, err)
+			}
+			s.Keys = append(s.Keys, k)
+		}
+		f.Secrets = append(f.Secrets, s)
+	}
+	return f, nil
+}
+
+// parseKey parses the value of one key.
+func parseKey(name string, v any) (Key, error) {
+	switch v := v.(type) {
+	case string:
+		return Key{Name: name, Value: v}, nil
+	case map[string]any:
+		k := Key{Name: name, Length: defaultLength}
+		for field, fv := range v {
+			switch field {
+			case "generate":
+				s, ok := fv.(string)
+				if !ok || !slices.Contains(Generators, s) {
+					return Key{}, fmt.Errorf("key %s: generate must be one of %s", name, strings.Join(Generators, ", "))
+				}
+				k.Generate = s
+			case "length":
+				n, ok := fv.(int64)
+				if !ok || n < 1 || n > maxLength {
+					return Key{}, fmt.Errorf("key %s: length must be between 1 and %d", name, maxLength)
+				}
+				k.Length = int(n)
+			default:
+				return Key{}, fmt.Errorf("key %s: unknown field %q", name, field)
+			}
+		}
+		if k.Generate == "" {
+			return Key{}, fmt.Errorf("key %s: a table needs generate", name)
+		}
+		return k, nil
+	default:
+		return Key{}, fmt.Errorf("key %s: value must be a string or { generate = \"<kind>\" }", name)
+	}
+}
+
+// value returns the value to write for k in env.
+func (k Key) value(env string) (string, error) {
+	if k.Generate == "" {
+		return resolver.Interpolate(k.Value, env), nil
+	}
+	return generate(k.Generate, k.Length)
+}
+
+// generate returns a random value of the given kind and length.
+func generate(kind string, length int) (string, error) {
+	switch kind {
+	case "password":
+		b := make([]byte, length)
+		limit := big.NewInt(int64(len(passwordChars)))
+		for i := range b {
+			n, err := rand.Int(rand.Reader, limit)
+			if err != nil {
+				return "", err
+			}
+			b[i] = passwordChars[n.Int64()]
+		}
+		return string(b), nil
+	case "hex":
+		b := make([]byte, (length+1)/2)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(b)[:length], nil
+	case "base64":
+		b := make([]byte, base64.RawURLEncoding.DecodedLen(length)+1)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		return base64.RawURLEncoding.EncodeToString(b)[:length], nil
+	case "uuid":
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return "", err
+		}
+		b[6] = b[6]&0x0f | 0x40 // version 4
+		b[8] = b[8]&0x3f | 0x80 // RFC 9562 variant
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+	}
+	return "", fmt.Errorf("unknown generator %q", kind)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,239 @@
+package seed
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const seedTOML = `
+[secrets."${env}/database"]
+url = "postgres://localhost/app_${env}"
+password = { generate = "password", length = 20 }
+
+[secrets."${env}/api"]
+token = { generate = "hex" }
+id = { generate = "uuid" }
+
+[secrets."shared/stripe"]
+publishable_key = "pk_test_placeholder"
+`
+
+// fakeVault is a Target holding secrets in memory, with a version each.
+type fakeVault struct {
+	data      map[string]map[string]interface{}
+	versions  map[string]int
+	destroyed []string
+}
+
+func newFakeVault() *fakeVault {
+	return &fakeVault{data: map[string]map[string]interface{}{}, versions: map[string]int{}}
+}
+
+func (f *fakeVault) ReadKVData(kvPath string) (map[string]interface{}, int, error) {
+	return f.data[kvPath], f.versions[kvPath], nil
+}
+
+func (f *fakeVault) WriteKV(kvPath string, data map[string]interface{}, cas int) error {
+	if cas != f.versions[kvPath] {
+		return errCAS
+	}
+	f.data[kvPath] = data
+	f.versions[kvPath]++
+	return nil
+}
+
+func (f *fakeVault) DestroyKV(kvPath string) error {
+	delete(f.data, kvPath)
+	delete(f.versions, kvPath)
+	f.destroyed = append(f.destroyed, kvPath)
+	return nil
+}
+
+type casError struct{}
+
+func (casError) Error() string { return "check-and-set parameter did not match the current version" }
+
+var errCAS = casError{}
+
+func TestParse(t *testing.T) {
+	f, err := Parse([]byte(seedTOML))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	var paths []string
+	for _, s := range f.Secrets {
+		paths = append(paths, s.Path)
+	}
+	if want := []string{"${env}/api", "${env}/database", "shared/stripe"}; !slices.Equal(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+	db := f.Secrets[1].Keys
+	if db[0].Name != "password" || db[0].Generate != "password" || db[0].Length != 20 {
+		t.Errorf("password key = %+v", db[0])
+	}
+	if db[1].Name != "url" || db[1].Value != "postgres://localhost/app_${env}" {
+		t.Errorf("url key = %+v", db[1])
+	}
+
+	for _, bad := range []string{
+		``,
+		`[secrets."../etc"]` + "\nk = \"v\"",
+		`[secrets."dev/db"]`,
+		`[secrets."dev/db"]` + "\nk = 1",
+		`[secrets."dev/db"]` + "\nk = { generate = \"words\" }",
+		`[secrets."dev/db"]` + "\nk = { length = 10 }",
+		`[secrets."dev/db"]` + "\nk = { generate = \"hex\", length = 0 }",
+		`[secrets."dev/db"]` + "\nk = { generate = \"hex\", size = 8 }",
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%q) expected an error", bad)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		kind    string
+		length  int
+		pattern string
+	}{
+		{"password", 24, `^[A-Za-z0-9]{24}$`},
+		{"hex", 7, `^[0-9a-f]{7}$`},
+		{"base64", 43, `^[A-Za-z0-9_-]{43}$`},
+		{"uuid", 0, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+	}
+	for _, tt := range tests {
+		v, err := generate(tt.kind, tt.length)
+		if err != nil {
+			t.Fatalf("generate(%s) error = %v", tt.kind, err)
+		}
+		if !regexp.MustCompile(tt.pattern).MatchString(v) {
+			t.Errorf("generate(%s, %d) = %q, want %s", tt.kind, tt.length, v, tt.pattern)
+		}
+		if again, _ := generate(tt.kind, tt.length); again == v {
+			t.Errorf("generate(%s) returned %q twice", tt.kind, v)
+		}
+	}
+}
+
+func TestPlanSeed(t *testing.T) {
+	f, err := Parse([]byte(seedTOML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := newFakeVault()
+	dst.data["preview-7/api"] = map[string]interface{}{"token": "kept", "extra": "x"}
+	dst.versions["preview-7/api"] = 3
+	dst.data["shared/stripe"] = map[string]interface{}{"publishable_key": "pk_live"}
+	dst.versions["shared/stripe"] = 1
+
+	plan, err := PlanSeed(f, dst, "preview-123", "preview-7")
+	if err != nil {
+		t.Fatalf("PlanSeed() error = %v", err)
+	}
+
+	actions := map[string]Action{}
+	for _, s := range plan.Steps {
+		actions[s.Path] = s.Action
+	}
+	want := map[string]Action{
+		"preview-7/api":      ActionUpdate,
+		"preview-7/database": ActionCreate,
+		"shared/stripe":      ActionUnchanged,
+	}
+	for p, a := range want {
+		if actions[p] != a {
+			t.Errorf("%s: action = %q, want %q", p, actions[p], a)
+		}
+	}
+	if keys := plan.Steps[0].Keys; !slices.Equal(keys, []string{"id"}) {
+		t.Errorf("api keys to write = %v, want only the missing id", keys)
+	}
+
+	if err := plan.Apply(dst); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	api := dst.data["preview-7/api"]
+	if api["token"] != "kept" || api["extra"] != "x" || api["id"] == nil {
+		t.Errorf("api = %v, want the existing keys kept and id added", api)
+	}
+	db := dst.data["preview-7/database"]
+	if db["url"] != "postgres://localhost/app_preview-123" {
+		t.Errorf("url = %v, want ${env} replaced with the environment's name", db["url"])
+	}
+	if pw, _ := db["password"].(string); len(pw) != 20 {
+		t.Errorf("password = %q, want 20 characters", pw)
+	}
+	if dst.data["shared/stripe"]["publishable_key"] != "pk_live" {
+		t.Error("an existing value should never be replaced")
+	}
+
+	// Seeding again changes nothing.
+	again, err := PlanSeed(f, dst, "preview-123", "preview-7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := again.Count(ActionUnchanged); n != len(again.Steps) {
+		t.Errorf("second seed: %d of %d unchanged", n, len(again.Steps))
+	}
+}
+
+func TestPlanSeed_ConcurrentChange(t *testing.T) {
+	f, err := Parse([]byte(seedTOML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := newFakeVault()
+	plan, err := PlanSeed(f, dst, "dev", "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst.data["dev/api"] = map[string]interface{}{"token": "someone else's"}
+	dst.versions["dev/api"] = 1
+
+	if err := plan.Apply(dst); err == nil || !strings.Contains(err.Error(), "dev/api") {
+		t.Errorf("Apply() error = %v, want a check-and-set failure on dev/api", err)
+	}
+	if dst.data["dev/api"]["token"] != "someone else's" {
+		t.Error("a concurrent write should not be overwritten")
+	}
+}
+
+func TestPlanDestroy(t *testing.T) {
+	f, err := Parse([]byte(seedTOML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := newFakeVault()
+	dst.data["preview-1/database"] = map[string]interface{}{"url": "x"}
+	dst.versions["preview-1/database"] = 2
+	dst.data["preview-1/api"] = map[string]interface{}{"token": "t", "webhook_secret": "added by hand"}
+	dst.versions["preview-1/api"] = 3
+	dst.data["shared/stripe"] = map[string]interface{}{"publishable_key": "pk"}
+	dst.versions["shared/stripe"] = 1
+
+	plan, err := PlanDestroy(f, dst, "preview-1")
+	if err != nil {
+		t.Fatalf("PlanDestroy() error = %v", err)
+	}
+	if plan.Count(ActionDestroy) != 1 || plan.Count(ActionRemoveKeys) != 1 || plan.Count(ActionShared) != 1 {
+		t.Errorf("plan = %+v, want one secret each to destroy, trim, and keep as shared", plan.Steps)
+	}
+
+	if err := plan.Apply(dst); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !slices.Equal(dst.destroyed, []string{"preview-1/database"}) {
+		t.Errorf("destroyed %v, want only preview-1/database", dst.destroyed)
+	}
+	if dst.data["shared/stripe"] == nil {
+		t.Error("a shared secret should never be destroyed")
+	}
+	if got := dst.data["preview-1/api"]; len(got) != 1 || got["webhook_secret"] != "added by hand" {
+		t.Errorf("preview-1/api = %v, want only the key the seed file does not list", got)
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// launchdPlist returns the launchd agent for s. launchd starts it at login
+// and again whenever it exits unsuccessfully; "vx daemon stop" exits
+// cleanly and keeps it stopped until the next login.
+func launchdPlist(s Service) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + LaunchdLabel + `</string>
+	<key>ProgramArguments</key>
+	<array>
+`)
+	for _, arg := range append([]string{s.Executable}, s.Args...) {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", escapeXML(arg))
+	}
+	fmt.Fprintf(&b, `	</array>
+	<key>WorkingDirectory</key>
+	<string>%s</string>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ThrottleInterval</key>
+	<integer>30</integer>
+	<key>StandardOutPath</key>
+	<string>%s</string>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+	<key>ProcessType</key>
+	<string>Background</string>
+</dict>
+</plist>
+`, escapeXML(s.Dir), escapeXML(s.OutputPath), escapeXML(s.OutputPath))
+	return b.Bytes()
+}
+
+// systemdUnit returns the systemd user unit for s. It is started with the
+// user's first session and restarted when it fails; a clean exit, as after
+// "vx daemon stop", is left alone.
+func systemdUnit(s Service) []byte {
+	words := make([]string, 0, len(s.Args)+1)
+	for _, arg := range append([]string{s.Executable}, s.Args...) {
+		words = append(words, systemdQuote(arg))
+	}
+
+	return fmt.Appendf(nil, `[Unit]
+Description=vx token renewal daemon
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=simple
+ExecStart=%s
+WorkingDirectory=%s
+StandardOutput=append:%s
+StandardError=append:%s
+Restart=on-failure
+RestartSec=30
+
+[Install]
+WantedBy=default.target
+`, strings.Join(words, " "), systemdEscape(s.Dir), systemdEscape(s.OutputPath), systemdEscape(s.OutputPath))
+}
+
+// systemdEscape escapes the specifier and variable expansion systemd
+// applies to unit settings.
+func systemdEscape(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+// systemdQuote quotes one word of an ExecStart command line when it contains
+// characters systemd would split or unescape.
+func systemdQuote(s string) string {
+	s = systemdEscape(s)
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\;") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// taskXML returns the Scheduled Task for s, run as user when they log on.
+// Task Scheduler restarts it when it fails and never stops it for running
+// too long. The definition is UTF-16 encoded, as schtasks expects.
+func taskXML(s Service, user string) []byte {
+	args := make([]string, len(s.Args))
+	for i, arg := range s.Args {
+		args[i] = windowsQuote(arg)
+	}
+
+	doc := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-16"?>
+<Task version="1.2" xmlns="http://schemas.microsoft.com/windows/2004/02/mit/task">
+  <RegistrationInfo>
+    <Description>vx token renewal daemon</Description>
+  </RegistrationInfo>
+  <Triggers>
+    <LogonTrigger>
+      <Enabled>true</Enabled>
+      <UserId>%[1]s</UserId>
+    </LogonTrigger>
+  </Triggers>
+  <Principals>
+    <Principal id="Author">
+      <UserId>%[1]s</UserId>
+      <LogonType>InteractiveToken</LogonType>
+      <RunLevel>LeastPrivilege</RunLevel>
+    </Principal>
+  </Principals>
+  <Settings>
+    <MultipleInstancesPolicy>IgnoreNew</MultipleInstancesPolicy>
+    <DisallowStartIfOnBatteries>false</DisallowStartIfOnBatteries>
+    <StopIfGoingOnBatteries>false</StopIfGoingOnBatteries>
+    <StartWhenAvailable>true</StartWhenAvailable>
+    <ExecutionTimeLimit>PT0S</ExecutionTimeLimit>
+    <RestartOnFailure>
+      <Interval>PT1M</Interval>
+      <Count>999</Count>
+    </RestartOnFailure>
+  </Settings>
+  <Actions Context="Author">
+    <Exec>
+      <Command>%[2]s</Command>
+      <Arguments>%[3]s</Arguments>
+      <WorkingDirectory>%[4]s</WorkingDirectory>
+    </Exec>
+  </Actions>
+</Task>
+`, escapeXML(user), escapeXML(s.Executable), escapeXML(strings.Join(args, " ")), escapeXML(s.Dir))
+
+	units := utf16.Encode([]rune(doc))
+	out := make([]byte, 2, 2+2*len(units))
+	out[0], out[1] = 0xff, 0xfe // little-endian byte order mark
+	for _, u := range units {
+		out = append(out, byte(u), byte(u>>8))
+	}
+	return out
+}
+
+// windowsQuote quotes a command-line argument the way the Windows C runtime
+// parses it, like syscall.EscapeArg.
+func windowsQuote(s string) string {
+	if s == "" {
+		return `""`
+	}
+	if !strings.ContainsAny(s, " \t\"") {
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	slashes := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			slashes++
+		case '"':
+			b.WriteString(strings.Repeat(`\`, slashes+1))
+			slashes = 0
+		default:
+			slashes = 0
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteString(strings.Repeat(`\`, slashes))
+	b.WriteByte('"')
+	return b.String()
+}
+
+// escapeXML escapes s for use in XML text.
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,7 @@
+//go:build !windows
+
+package service
+
+// Detach is called by the daemon when the service manager starts it. launchd
+// and systemd run it without a terminal, so there is nothing to do.
+func Detach() {}
@@ -0,0 +1,13 @@
+package service
+
+import "golang.org/x/sys/windows"
+
+var procFreeConsole = windows.NewLazySystemDLL("kernel32.dll").NewProc("FreeConsole")
+
+// Detach is called by the daemon when the service manager starts it. Task
+// Scheduler runs it in a console window of its own, which would stop the
+// daemon when closed; Detach closes the window. The daemon writes its own
+// log, so nothing is lost.
+func Detach() {
+	procFreeConsole.Call()
+}
@@ -0,0 +1,93 @@
+// Package service registers the token renewal daemon with the platform's
+// per-user service manager, so it starts at login and is restarted when it
+// fails: a launchd agent on macOS, a systemd user unit on Linux, and a
+// Scheduled Task on Windows.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Names the daemon is registered under.
+const (
+	// LaunchdLabel is the label of the launchd agent.
+	LaunchdLabel = "industries.dot.vx.daemon"
+	// SystemdUnit is the name of the systemd user unit.
+	SystemdUnit = "vx-daemon.service"
+	// TaskName is the name of the Windows Scheduled Task.
+	TaskName = "vx-daemon"
+)
+
+// ErrUnsupported is returned by Install and Uninstall on platforms without
+// a supported service manager.
+var ErrUnsupported = errors.New("installing the daemon as a service is not supported on this platform")
+
+// ErrNotInstalled is returned by Uninstall when no service is registered.
+var ErrNotInstalled = errors.New("the daemon is not installed as a service")
+
+// Service describes the command the service manager runs.
+type Service struct {
+	// Executable is the absolute path of the vx binary.
+	Executable string
+	// Args are passed to Executable.
+	Args []string
+	// Dir is the working directory.
+	Dir string
+	// OutputPath receives the daemon's stdout and stderr, where the service
+	// manager can redirect them: output written before the daemon opens its
+	// own log, and panics. It must not be the log, which the daemon rotates.
+	OutputPath string
+}
+
+// Install registers s and starts it. It replaces an earlier registration,
+// so installing again picks up a new binary or arguments. It returns where
+// the service was registered: a file path, or the task name on Windows.
+func Install(s Service) (string, error) {
+	return install(s)
+}
+
+// Uninstall stops the service and removes its registration, returning
+// where it was registered.
+func Uninstall() (string, error) {
+	return uninstall()
+}
+
+// run runs a service manager command, including its output in the error
+// when it fails.
+var run = func(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, msg)
+		}
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// writeDefinition writes a service definition to path, creating its
+// directory.
+func writeDefinition(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// removeDefinition removes the service definition at path, reporting
+// ErrNotInstalled when there is none.
+func removeDefinition(path string) error {
+	err := os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotInstalled
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// launchdPath returns where the launchd agent is defined.
+func launchdPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", LaunchdLabel+".plist"), nil
+}
+
+// launchdDomain returns the launchd domain of the logged-in user's agents.
+func launchdDomain() string {
+	return fmt.Sprintf("gui/%d", os.Getuid())
+}
+
+func install(s Service) (string, error) {
+	path, err := launchdPath()
+	if err != nil {
+		return "", err
+	}
+	if err := writeDefinition(path, launchdPlist(s)); err != nil {
+		return "", err
+	}
+
+	// Unload an earlier version first; bootstrap refuses a loaded label.
+	_ = run("launchctl", "bootout", launchdDomain()+"/"+LaunchdLabel)
+	if err := run("launchctl", "bootstrap", launchdDomain(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func uninstall() (string, error) {
+	path, err := launchdPath()
+	if err != nil {
+		return "", err
+	}
+	_ = run("launchctl", "bootout", launchdDomain()+"/"+LaunchdLabel)
+	return path, removeDefinition(path)
+}
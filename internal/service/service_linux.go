@@ -0,0 +1,55 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// systemdPath returns where the systemd user unit is defined.
+func systemdPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "systemd", "user", SystemdUnit), nil
+}
+
+func install(s Service) (string, error) {
+	path, err := systemdPath()
+	if err != nil {
+		return "", err
+	}
+	if err := writeDefinition(path, systemdUnit(s)); err != nil {
+		return "", err
+	}
+
+	if err := run("systemctl", "--user", "daemon-reload"); err != nil {
+		return "", err
+	}
+	if err := run("systemctl", "--user", "enable", SystemdUnit); err != nil {
+		return "", err
+	}
+	// Restart rather than start, so a running daemon picks up the new unit.
+	if err := run("systemctl", "--user", "restart", SystemdUnit); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func uninstall() (string, error) {
+	path, err := systemdPath()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return path, ErrNotInstalled
+	}
+
+	if err := run("systemctl", "--user", "disable", "--now", SystemdUnit); err != nil {
+		return "", err
+	}
+	if err := removeDefinition(path); err != nil {
+		return "", err
+	}
+	return path, run("systemctl", "--user", "daemon-reload")
+}
@@ -0,0 +1,7 @@
+//go:build !darwin && !linux && !windows
+
+package service
+
+func install(Service) (string, error) { return "", ErrUnsupported }
+
+func uninstall() (string, error) { return "", ErrUnsupported }
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+var testService = Service{
+	Executable: "/Users/ana/My Tools/vx",
+	Args:       []string{"daemon", "start", "--config", "/src/app & co/vx.toml", "--agent"},
+	Dir:        "/src/app & co",
+	OutputPath: "/Users/ana/.vx/daemon.out",
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	plist := launchdPlist(testService)
+	if err := xml.Unmarshal(plist, new(struct{})); err != nil {
+		t.Fatalf("plist is not well-formed XML: %v\n%s", err, plist)
+	}
+
+	for _, want := range []string{
+		"<string>" + LaunchdLabel + "</string>",
+		"<string>/Users/ana/My Tools/vx</string>\n\t\t<string>daemon</string>",
+		"<string>/src/app &amp; co/vx.toml</string>",
+		"<key>RunAtLoad</key>\n\t<true/>",
+		"<key>SuccessfulExit</key>\n\t\t<false/>",
+		"<key>StandardOutPath</key>\n\t<string>/Users/ana/.vx/daemon.out</string>",
+		"<key>StandardErrorPath</key>\n\t<string>/Users/ana/.vx/daemon.out</string>",
+	} {
+		if !strings.Contains(string(plist), want) {
+			t.Errorf("plist missing %q:\n%s", want, plist)
+		}
+	}
+}
+
+func TestSystemdUnit(t *testing.T) {
+	s := testService
+	s.Args = append(s.Args, "--namespace", "team-$a/100%")
+	unit := string(systemdUnit(s))
+
+	for _, want := range []string{
+		`ExecStart="/Users/ana/My Tools/vx" daemon start --config "/src/app & co/vx.toml" --agent --namespace team-$$a/100%%` + "\n",
+		"WorkingDirectory=/src/app & co\n",
+		"StandardOutput=append:/Users/ana/.vx/daemon.out\n",
+		"StandardError=append:/Users/ana/.vx/daemon.out\n",
+		"Restart=on-failure\n",
+		"WantedBy=default.target\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit missing %q:\n%s", want, unit)
+		}
+	}
+}
+
+func TestSystemdQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":         "plain",
+		"":              `""`,
+		"two words":     `"two words"`,
+		`say "hi"`:      `"say \"hi\""`,
+		`C:\path`:       `"C:\\path"`,
+		"semi;colon":    `"semi;colon"`,
+		"50%":           "50%%",
+		"${HOME}/cache": "$${HOME}/cache",
+	}
+	for in, want := range tests {
+		if got := systemdQuote(in); got != want {
+			t.Errorf("systemdQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestTaskXML(t *testing.T) {
+	s := Service{
+		Executable: `C:\Program Files\vx\vx.exe`,
+		Args:       []string{"daemon", "start", "--config", `C:\src\my app\vx.toml`, "--service"},
+		Dir:        `C:\src\my app`,
+		OutputPath: `C:\Users\ana\.vx\daemon.out`,
+	}
+	data := taskXML(s, `CORP\ana`)
+
+	if len(data) < 2 || data[0] != 0xff || data[1] != 0xfe || len(data)%2 != 0 {
+		t.Fatalf("task definition is not UTF-16LE with a byte order mark")
+	}
+	units := make([]uint16, 0, len(data)/2-1)
+	for i := 2; i < len(data); i += 2 {
+		units = append(units, uint16(data[i])|uint16(data[i+1])<<8)
+	}
+	doc := string(utf16.Decode(units))
+
+	for _, want := range []string{
+		`<UserId>CORP\ana</UserId>`,
+		`<Command>C:\Program Files\vx\vx.exe</Command>`,
+		`<Arguments>daemon start --config &#34;C:\src\my app\vx.toml&#34; --service</Arguments>`,
+		`<WorkingDirectory>C:\src\my app</WorkingDirectory>`,
+		`<ExecutionTimeLimit>PT0S</ExecutionTimeLimit>`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("task missing %q:\n%s", want, doc)
+		}
+	}
+}
+
+func TestWindowsQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":            "plain",
+		"":                 `""`,
+		`C:\my dir\`:       `"C:\my dir\\"`,
+		`say "hi"`:         `"say \"hi\""`,
+		`a\"b c`:           `"a\\\"b c"`,
+		`C:\no\spaces.exe`: `C:\no\spaces.exe`,
+	}
+	for in, want := range tests {
+		if got := windowsQuote(in); got != want {
+			t.Errorf("windowsQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+)
+
+func install(s Service) (string, error) {
+	u, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("looking up the current user: %w", err)
+	}
+
+	// schtasks reads the definition from a file.
+	f, err := os.CreateTemp("", "vx-daemon-*.xml")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(taskXML(s, u.Username))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("writing %s: %w", f.Name(), err)
+	}
+
+	// Stop an earlier version so the new definition takes effect.
+	_ = run("schtasks", "/End", "/TN", TaskName)
+	if err := run("schtasks", "/Create", "/TN", TaskName, "/XML", f.Name(), "/F"); err != nil {
+		return "", err
+	}
+	if err := run("schtasks", "/Run", "/TN", TaskName); err != nil {
+		return "", err
+	}
+	return TaskName, nil
+}
+
+func uninstall() (string, error) {
+	if err := run("schtasks", "/Query", "/TN", TaskName); err != nil {
+		return TaskName, ErrNotInstalled
+	}
+	_ = run("schtasks", "/End", "/TN", TaskName)
+	return TaskName, run("schtasks", "/Delete", "/TN", TaskName, "/F")
+}
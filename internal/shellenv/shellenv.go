@@ -0,0 +1,158 @@
+// Package shellenv renders values as shell statements that set environment
+// variables, quoted so the shell never interprets anything inside a value.
+package shellenv
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Dialect selects the shell syntax to render.
+type Dialect string
+
+const (
+	// POSIX renders export KEY='value' for sh, bash, and zsh.
+	POSIX Dialect = "posix"
+	// PowerShell renders $env:KEY = 'value'.
+	PowerShell Dialect = "powershell"
+	// Fish renders set -gx KEY 'value'.
+	Fish Dialect = "fish"
+)
+
+// validName matches the variable names every dialect can set without
+// quoting. Others are refused rather than written into a statement.
+var validName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Default returns the dialect of the platform's usual shell: PowerShell on
+// Windows, POSIX everywhere else.
+func Default() Dialect {
+	if runtime.GOOS == "windows" {
+		return PowerShell
+	}
+	return POSIX
+}
+
+// Detect returns Fish when $SHELL is fish and Default otherwise.
+func Detect() Dialect {
+	if filepath.Base(os.Getenv("SHELL")) == "fish" {
+		return Fish
+	}
+	return Default()
+}
+
+// ParseDialect returns the dialect named s. The names of POSIX shells (sh,
+// bash, zsh) select POSIX, and pwsh selects PowerShell.
+func ParseDialect(s string) (Dialect, error) {
+	switch d := Dialect(strings.ToLower(s)); d {
+	case POSIX, PowerShell, Fish:
+		return d, nil
+	case "sh", "bash", "zsh":
+		return POSIX, nil
+	case "pwsh":
+		return PowerShell, nil
+	default:
+		return "", fmt.Errorf("unsupported shell %q (use bash, zsh, fish, or powershell)", s)
+	}
+}
+
+// powerShellQuotes are the characters PowerShell accepts as a single quote:
+// the ASCII apostrophe and the typographic variants.
+const powerShellQuotes = "'‘’‚‛"
+
+// Quote returns s as a single-quoted literal for d.
+//
+// POSIX single quotes cannot be escaped inside the literal, so each one
+// closes the quote, adds an escaped quote, and reopens it. PowerShell
+// doubles quote characters instead, including the typographic ones it also
+// treats as quotes. Fish escapes quotes and backslashes with a backslash.
+func Quote(d Dialect, s string) string {
+	switch d {
+	case Fish:
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+	case PowerShell:
+		var b strings.Builder
+		b.WriteByte('\'')
+		for _, r := range s {
+			if strings.ContainsRune(powerShellQuotes, r) {
+				b.WriteRune(r)
+			}
+			b.WriteRune(r)
+		}
+		b.WriteByte('\'')
+		return b.String()
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Render writes one statement per value in key order. Nothing is written
+// when a key is not a valid variable name.
+func Render(w io.Writer, d Dialect, values map[string]string) error {
+	keys, err := sortedNames(values)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, k := range keys {
+		switch d {
+		case PowerShell:
+			fmt.Fprintf(&b, "$env:%s = %s\n", k, Quote(d, values[k]))
+		case Fish:
+			fmt.Fprintf(&b, "set -gx %s %s\n", k, Quote(d, values[k]))
+		default:
+			fmt.Fprintf(&b, "export %s=%s\n", k, Quote(d, values[k]))
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// RenderUnset writes one statement per key of values, in key order, that
+// removes the variable from the environment. Nothing is written when a key
+// is not a valid variable name.
+func RenderUnset(w io.Writer, d Dialect, values map[string]string) error {
+	keys, err := sortedNames(values)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, k := range keys {
+		switch d {
+		case PowerShell:
+			fmt.Fprintf(&b, "Remove-Item Env:%s -ErrorAction SilentlyContinue\n", k)
+		case Fish:
+			fmt.Fprintf(&b, "set -e %s\n", k)
+		default:
+			fmt.Fprintf(&b, "unset %s\n", k)
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// sortedNames returns the keys of values in order, or an error for the
+// first one that is not a valid variable name.
+func sortedNames(values map[string]string) ([]string, error) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		if !validName.MatchString(k) {
+			return nil, fmt.Errorf("%q is not a valid environment variable name", k)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,154 @@
+package shellenv
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		in      string
+		want    string
+	}{
+		{POSIX, "plain", "'plain'"},
+		{POSIX, "it's $HOME", `'it'\''s $HOME'`},
+		{PowerShell, "plain", "'plain'"},
+		{PowerShell, "it's $env:PATH", "'it''s $env:PATH'"},
+		{PowerShell, "‘smart’", "'‘‘smart’’'"},
+		{Fish, "plain", "'plain'"},
+		{Fish, `it's C:\dir`, `'it\'s C:\\dir'`},
+	}
+
+	for _, tt := range tests {
+		if got := Quote(tt.dialect, tt.in); got != tt.want {
+			t.Errorf("Quote(%s, %q) = %q, want %q", tt.dialect, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestQuote_RoundTripsThroughShells(t *testing.T) {
+	value := "a'b\"c $d `e` \\f\ng ‘h’"
+
+	shells := []struct {
+		dialect Dialect
+		name    string
+		args    func(string) []string
+	}{
+		{POSIX, "bash", func(q string) []string { return []string{"-c", "printf %s " + q} }},
+		{PowerShell, "pwsh", func(q string) []string {
+			return []string{"-NoProfile", "-Command", "[Console]::Out.Write(" + q + ")"}
+		}},
+		{Fish, "fish", func(q string) []string { return []string{"-c", "printf %s " + q} }},
+	}
+
+	for _, sh := range shells {
+		t.Run(sh.name, func(t *testing.T) {
+			path, err := exec.LookPath(sh.name)
+			if err != nil {
+				t.Skipf("%s not available", sh.name)
+			}
+
+			out, err := exec.Command(path, sh.args(Quote(sh.dialect, value))...).Output()
+			if err != nil {
+				t.Fatalf("%s error = %v", sh.name, err)
+			}
+			if string(out) != value {
+				t.Errorf("%s printed %q, want %q", sh.name, out, value)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	values := map[string]string{"B": "2", "A": "it's"}
+
+	var posix strings.Builder
+	if err := Render(&posix, POSIX, values); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "export A='it'\\''s'\nexport B='2'\n"; posix.String() != want {
+		t.Errorf("Render(posix) = %q, want %q", posix.String(), want)
+	}
+
+	var ps strings.Builder
+	if err := Render(&ps, PowerShell, values); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "$env:A = 'it''s'\n$env:B = '2'\n"; ps.String() != want {
+		t.Errorf("Render(powershell) = %q, want %q", ps.String(), want)
+	}
+
+	var fish strings.Builder
+	if err := Render(&fish, Fish, values); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "set -gx A 'it\\'s'\nset -gx B '2'\n"; fish.String() != want {
+		t.Errorf("Render(fish) = %q, want %q", fish.String(), want)
+	}
+}
+
+func TestRender_invalidName(t *testing.T) {
+	var b strings.Builder
+	err := Render(&b, POSIX, map[string]string{"OK": "1", "NOT-OK": "2"})
+	if err == nil {
+		t.Fatal("Render() expected error for NOT-OK")
+	}
+	if b.Len() != 0 {
+		t.Errorf("Render() wrote %q despite the error", b.String())
+	}
+}
+
+func TestRenderUnset(t *testing.T) {
+	values := map[string]string{"B": "2", "A": "1"}
+
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{POSIX, "unset A\nunset B\n"},
+		{Fish, "set -e A\nset -e B\n"},
+		{PowerShell, "Remove-Item Env:A -ErrorAction SilentlyContinue\nRemove-Item Env:B -ErrorAction SilentlyContinue\n"},
+	}
+
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := RenderUnset(&b, tt.dialect, values); err != nil {
+			t.Fatalf("RenderUnset(%s) error = %v", tt.dialect, err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("RenderUnset(%s) = %q, want %q", tt.dialect, b.String(), tt.want)
+		}
+	}
+}
+
+func TestParseDialect(t *testing.T) {
+	tests := map[string]Dialect{
+		"PowerShell": PowerShell,
+		"pwsh":       PowerShell,
+		"posix":      POSIX,
+		"bash":       POSIX,
+		"zsh":        POSIX,
+		"fish":       Fish,
+	}
+	for in, want := range tests {
+		if d, err := ParseDialect(in); err != nil || d != want {
+			t.Errorf("ParseDialect(%s) = %q, %v, want %q", in, d, err, want)
+		}
+	}
+	if _, err := ParseDialect("cmd"); err == nil {
+		t.Error("ParseDialect(cmd) expected error")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	t.Setenv("SHELL", "/usr/local/bin/fish")
+	if d := Detect(); d != Fish {
+		t.Errorf("Detect() = %q with fish as $SHELL, want fish", d)
+	}
+	t.Setenv("SHELL", "/bin/zsh")
+	if d := Detect(); d != Default() {
+		t.Errorf("Detect() = %q with zsh as $SHELL, want %q", d, Default())
+	}
+}
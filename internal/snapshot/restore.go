@@ -0,0 +1,137 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Target is the subset of the Vault client needed to restore a snapshot.
+type Target interface {
+	ReadKVData(kvPath string) (map[string]interface{}, int, error)
+	WriteKV(kvPath string, data map[string]interface{}, cas int) error
+}
+
+// Action is what a restore does with one secret.
+type Action string
+
+const (
+	// ActionCreate writes a secret that does not exist at the destination.
+	ActionCreate Action = "create"
+	// ActionUpdate writes a new version over a secret with other values.
+	ActionUpdate Action = "update"
+	// ActionSkip leaves a secret with other values alone.
+	ActionSkip Action = "skip"
+	// ActionUnchanged marks a secret that already holds the snapshot's values.
+	ActionUnchanged Action = "unchanged"
+)
+
+// Step is the planned restore of one secret.
+type Step struct {
+	// Path is the destination, relative to the client's mount.
+	Path   string
+	Action Action
+	// Keys are the key names whose values differ from the destination's.
+	Keys []string
+
+	data map[string]interface{}
+	cas  int
+}
+
+// Plan holds a step for every secret in a snapshot. Nothing is written
+// until Apply is called, so a Plan doubles as the dry-run output.
+type Plan struct {
+	Prefix string
+	Steps  []Step
+}
+
+// PlanRestore compares the snapshot with what is stored under prefix on dst.
+// Secrets that exist with other values are updated when overwrite is set
+// and skipped otherwise.
+func PlanRestore(s *Snapshot, dst Target, prefix string, overwrite bool) (*Plan, error) {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return nil, fmt.Errorf("a prefix is required")
+	}
+
+	p := &Plan{Prefix: prefix}
+	for _, sec := range s.Secrets {
+		to := path.Join(prefix, sec.Path)
+
+		current, version, err := dst.ReadKVData(to)
+		if err != nil {
+			return nil, err
+		}
+
+		step := Step{Path: to, data: sec.Data, cas: version}
+		switch {
+		case current == nil:
+			step.Action = ActionCreate
+			step.Keys = sortedKeys(sec.Data)
+		default:
+			step.Keys = changedKeys(current, sec.Data)
+			switch {
+			case len(step.Keys) == 0:
+				step.Action = ActionUnchanged
+			case overwrite:
+				step.Action = ActionUpdate
+			default:
+				step.Action = ActionSkip
+			}
+		}
+		p.Steps = append(p.Steps, step)
+	}
+
+	return p, nil
+}
+
+// Count returns the number of steps with action a.
+func (p *Plan) Count(a Action) int {
+	n := 0
+	for _, s := range p.Steps {
+		if s.Action == a {
+			n++
+		}
+	}
+	return n
+}
+
+// Apply writes the created and updated secrets. Every write is a
+// check-and-set against the version seen while planning, so a secret
+// changed in the meantime fails the restore instead of being overwritten.
+func (p *Plan) Apply(dst Target) error {
+	for _, s := range p.Steps {
+		if s.Action != ActionCreate && s.Action != ActionUpdate {
+			continue
+		}
+		if err := dst.WriteKV(s.Path, s.data, s.cas); err != nil {
+			return fmt.Errorf("restoring %s: %w", s.Path, err)
+		}
+	}
+	return nil
+}
+
+// changedKeys returns the keys, from either side, whose values differ.
+func changedKeys(current, want map[string]interface{}) []string {
+	var keys []string
+	for _, k := range sortedKeys(want) {
+		if cur, ok := current[k]; !ok || !sameValue(cur, want[k]) {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range sortedKeys(current) {
+		if _, ok := want[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// sameValue compares two decoded JSON values by their encoding, which
+// treats equal numbers alike whether they are json.Number or float64.
+func sameValue(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
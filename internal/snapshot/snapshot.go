@@ -0,0 +1,159 @@
+// Package snapshot exports every secret under a Vault KV prefix into a
+// passphrase-encrypted archive and restores such an archive under the same
+// or another prefix, possibly on another Vault cluster.
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.dot.industries/vx/internal/passcrypt"
+	"go.dot.industries/vx/internal/vault"
+)
+
+// magic starts every archive, so other files are rejected before the
+// passphrase is even tried.
+const magic = "VXSNAP"
+
+const formatVersion = 1
+
+// ErrDecrypt is returned when an archive cannot be opened, either because
+// the passphrase is wrong or because the archive was tampered with.
+var ErrDecrypt = errors.New("wrong passphrase or corrupted snapshot")
+
+// Source is the subset of the Vault client needed to take a snapshot.
+type Source interface {
+	ListKeys(kvPath string) ([]vault.VaultEntry, error)
+	ReadKVData(kvPath string) (map[string]interface{}, int, error)
+}
+
+// Snapshot is the decrypted content of an archive.
+type Snapshot struct {
+	Manifest Manifest `json:"manifest"`
+	Secrets  []Secret `json:"secrets"`
+}
+
+// Manifest describes where a snapshot was taken and what it holds, without
+// any values.
+type Manifest struct {
+	Version   int             `json:"version"`
+	Address   string          `json:"address"`
+	BasePath  string          `json:"base_path"`
+	Prefix    string          `json:"prefix"`
+	CreatedAt time.Time       `json:"created_at"`
+	Entries   []ManifestEntry `json:"entries"`
+}
+
+// ManifestEntry lists one secret's key names and the version captured.
+type ManifestEntry struct {
+	Path    string   `json:"path"`
+	Version int      `json:"version"`
+	Keys    []string `json:"keys"`
+}
+
+// Secret is one secret's data. Path is relative to the snapshot prefix.
+type Secret struct {
+	Path string                 `json:"path"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// Take reads every secret below prefix, relative to the client's mount.
+// Secrets whose latest version is deleted are left out. address and basePath
+// are recorded in the manifest for reference.
+func Take(src Source, address, basePath, prefix string, now time.Time) (*Snapshot, error) {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return nil, fmt.Errorf("a prefix is required")
+	}
+
+	leaves, err := vault.ListTree(src, prefix+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Snapshot{Manifest: Manifest{
+		Version:   formatVersion,
+		Address:   address,
+		BasePath:  basePath,
+		Prefix:    prefix,
+		CreatedAt: now.UTC().Truncate(time.Second),
+	}}
+
+	for _, leaf := range leaves {
+		data, version, err := src.ReadKVData(leaf)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			continue
+		}
+
+		rel := strings.TrimPrefix(leaf, prefix+"/")
+		s.Secrets = append(s.Secrets, Secret{Path: rel, Data: data})
+		s.Manifest.Entries = append(s.Manifest.Entries, ManifestEntry{
+			Path:    rel,
+			Version: version,
+			Keys:    sortedKeys(data),
+		})
+	}
+
+	return s, nil
+}
+
+// Seal encrypts the snapshot with a key derived from passphrase. AES-GCM
+// authenticates the content, so an altered archive fails to open instead of
+// restoring modified values.
+func Seal(s *Snapshot, passphrase string) ([]byte, error) {
+	plain, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("encoding snapshot: %w", err)
+	}
+
+	return passcrypt.Seal(append([]byte(magic), formatVersion), plain, passphrase)
+}
+
+// Open decrypts an archive produced by Seal.
+func Open(archive []byte, passphrase string) (*Snapshot, error) {
+	if !bytes.HasPrefix(archive, []byte(magic)) {
+		return nil, fmt.Errorf("not a vx snapshot")
+	}
+	if len(archive) < len(magic)+1+passcrypt.SaltSize {
+		return nil, ErrDecrypt
+	}
+	if v := archive[len(magic)]; v != formatVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d (upgrade vx)", v)
+	}
+
+	plain, err := passcrypt.Open(archive, len(magic)+1, passphrase)
+	if errors.Is(err, passcrypt.ErrDecrypt) {
+		return nil, ErrDecrypt
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Numbers stay json.Number, as the Vault client returns them, so they
+	// are restored exactly and compare equal to what is already stored.
+	dec := json.NewDecoder(bytes.NewReader(plain))
+	dec.UseNumber()
+
+	var s Snapshot
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("decoding snapshot: %w", err)
+	}
+	return &s, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,211 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.dot.industries/vx/internal/vault"
+)
+
+// fakeVault is an in-memory KV mount.
+type fakeVault struct {
+	secrets  map[string]map[string]interface{}
+	versions map[string]int
+	writes   []string
+}
+
+func newFakeVault(secrets map[string]map[string]interface{}) *fakeVault {
+	f := &fakeVault{secrets: secrets, versions: map[string]int{}}
+	for p := range secrets {
+		f.versions[p] = 1
+	}
+	return f
+}
+
+func (f *fakeVault) ListKeys(kvPath string) ([]vault.VaultEntry, error) {
+	seen := map[string]bool{}
+	var entries []vault.VaultEntry
+	for p := range f.secrets {
+		rest, ok := strings.CutPrefix(p, kvPath)
+		if !ok {
+			continue
+		}
+		name, _, isDir := strings.Cut(rest, "/")
+		if isDir {
+			name += "/"
+		}
+		if !seen[name] {
+			seen[name] = true
+			entries = append(entries, vault.VaultEntry{Name: name, IsDir: isDir})
+		}
+	}
+	return entries, nil
+}
+
+func (f *fakeVault) ReadKVData(kvPath string) (map[string]interface{}, int, error) {
+	return f.secrets[kvPath], f.versions[kvPath], nil
+}
+
+func (f *fakeVault) WriteKV(kvPath string, data map[string]interface{}, cas int) error {
+	if f.versions[kvPath] != cas {
+		return errors.New("check-and-set parameter did not match the current version")
+	}
+	f.secrets[kvPath] = data
+	f.versions[kvPath]++
+	f.writes = append(f.writes, kvPath)
+	return nil
+}
+
+func TestTake(t *testing.T) {
+	src := newFakeVault(map[string]map[string]interface{}{
+		"staging/db":          {"url": "postgres://", "port": json.Number("5432")},
+		"staging/api/keys":    {"token": "abc"},
+		"production/db":       {"url": "postgres://prod"},
+		"staging-old/ignored": {"k": "v"},
+	})
+
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	s, err := Take(src, "https://vault", "secret", "/staging/", now)
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+
+	if s.Manifest.Prefix != "staging" || !s.Manifest.CreatedAt.Equal(now) {
+		t.Errorf("Manifest = %+v", s.Manifest)
+	}
+
+	want := []ManifestEntry{
+		{Path: "api/keys", Version: 1, Keys: []string{"token"}},
+		{Path: "db", Version: 1, Keys: []string{"port", "url"}},
+	}
+	if !reflect.DeepEqual(s.Manifest.Entries, want) {
+		t.Errorf("Entries = %+v, want %+v", s.Manifest.Entries, want)
+	}
+	if len(s.Secrets) != 2 || s.Secrets[1].Data["url"] != "postgres://" {
+		t.Errorf("Secrets = %+v", s.Secrets)
+	}
+
+	if _, err := Take(src, "", "", "/", now); err == nil {
+		t.Error("Take() accepted an empty prefix")
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	s := &Snapshot{
+		Manifest: Manifest{Version: formatVersion, Prefix: "staging"},
+		Secrets: []Secret{
+			{Path: "db", Data: map[string]interface{}{"port": json.Number("5432")}},
+		},
+	}
+
+	archive, err := Seal(s, "correct horse")
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if strings.Contains(string(archive), "5432") {
+		t.Error("archive contains a plaintext value")
+	}
+
+	got, err := Open(archive, "correct horse")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got.Secrets[0].Data["port"] != json.Number("5432") {
+		t.Errorf("port = %#v, want json.Number 5432", got.Secrets[0].Data["port"])
+	}
+
+	if _, err := Open(archive, "wrong"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Open() with wrong passphrase error = %v, want ErrDecrypt", err)
+	}
+
+	archive[len(archive)-1] ^= 1
+	if _, err := Open(archive, "correct horse"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Open() of tampered archive error = %v, want ErrDecrypt", err)
+	}
+
+	if _, err := Open([]byte("hello"), "correct horse"); err == nil {
+		t.Error("Open() accepted a file that is not a snapshot")
+	}
+	if _, err := Seal(s, ""); err == nil {
+		t.Error("Seal() accepted an empty passphrase")
+	}
+}
+
+func TestPlanRestore(t *testing.T) {
+	s := &Snapshot{Secrets: []Secret{
+		{Path: "db", Data: map[string]interface{}{"url": "postgres://", "port": json.Number("5432")}},
+		{Path: "api", Data: map[string]interface{}{"token": "abc"}},
+		{Path: "cache", Data: map[string]interface{}{"url": "redis://"}},
+	}}
+
+	dst := newFakeVault(map[string]map[string]interface{}{
+		"stage/db":    {"url": "postgres://", "port": float64(5432)},
+		"stage/cache": {"url": "redis://old", "extra": "x"},
+	})
+
+	plan, err := PlanRestore(s, dst, "stage", false)
+	if err != nil {
+		t.Fatalf("PlanRestore() error = %v", err)
+	}
+
+	got := map[string]Action{}
+	for _, step := range plan.Steps {
+		got[step.Path] = step.Action
+	}
+	want := map[string]Action{
+		"stage/db":    ActionUnchanged,
+		"stage/api":   ActionCreate,
+		"stage/cache": ActionSkip,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actions = %v, want %v", got, want)
+	}
+	if keys := plan.Steps[2].Keys; !reflect.DeepEqual(keys, []string{"url", "extra"}) {
+		t.Errorf("changed keys = %v, want [url extra]", keys)
+	}
+
+	if err := plan.Apply(dst); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !reflect.DeepEqual(dst.writes, []string{"stage/api"}) {
+		t.Errorf("writes = %v, want only the created secret", dst.writes)
+	}
+
+	plan, err = PlanRestore(s, dst, "stage", true)
+	if err != nil {
+		t.Fatalf("PlanRestore() error = %v", err)
+	}
+	if plan.Count(ActionUpdate) != 1 || plan.Count(ActionUnchanged) != 2 {
+		t.Errorf("overwrite plan = %+v, want one update", plan.Steps)
+	}
+	if err := plan.Apply(dst); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if dst.secrets["stage/cache"]["url"] != "redis://" {
+		t.Errorf("stage/cache = %v, want the snapshot's values", dst.secrets["stage/cache"])
+	}
+}
+
+func TestApply_ConcurrentChange(t *testing.T) {
+	s := &Snapshot{Secrets: []Secret{
+		{Path: "db", Data: map[string]interface{}{"url": "postgres://"}},
+	}}
+	dst := newFakeVault(map[string]map[string]interface{}{})
+
+	plan, err := PlanRestore(s, dst, "stage", false)
+	if err != nil {
+		t.Fatalf("PlanRestore() error = %v", err)
+	}
+
+	// Someone creates the secret between planning and applying.
+	dst.secrets["stage/db"] = map[string]interface{}{"url": "other"}
+	dst.versions["stage/db"] = 1
+
+	if err := plan.Apply(dst); err == nil {
+		t.Error("Apply() overwrote a secret created after planning")
+	}
+}
@@ -0,0 +1,132 @@
+// Package stats summarizes the size and shape of a repository's vx
+// configuration — how many mappings each file holds, how many distinct
+// Vault paths they read, and how much is shared across environments — so
+// platform teams can track secret sprawl over time.
+package stats
+
+import (
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/resolver"
+)
+
+// RootFile names the root vx.toml in a File's Path field.
+const RootFile = "vx.toml"
+
+// Report is the summary of one repository.
+type Report struct {
+	Environments []string `json:"environments"`
+	Workspaces   int      `json:"workspaces"`
+	Files        []File   `json:"files"`
+	// Mappings, EnvScoped, Shared, and Defaults are summed over Files.
+	Mappings  int `json:"mappings"`
+	EnvScoped int `json:"env_scoped"`
+	Shared    int `json:"shared"`
+	Defaults  int `json:"defaults"`
+	// PathGroups counts distinct Vault paths across every file; a path
+	// read by several workspaces is counted once.
+	PathGroups int `json:"path_groups"`
+	// SharedRatio is the fraction of mappings without ${env}, which read
+	// the same value in every environment.
+	SharedRatio float64 `json:"shared_ratio"`
+}
+
+// File is the summary of the root vx.toml or one workspace.
+type File struct {
+	Path       string `json:"path"`
+	Workspace  string `json:"workspace,omitempty"`
+	Mappings   int    `json:"mappings"`
+	EnvScoped  int    `json:"env_scoped"`
+	Shared     int    `json:"shared"`
+	PathGroups int    `json:"path_groups"`
+	Defaults   int    `json:"defaults"`
+}
+
+// Collect builds a Report from the root config and every configured
+// workspace. Paths are counted as written, before ${env} is interpolated.
+func Collect(rootDir string, cfg *config.RootConfig) (*Report, error) {
+	r := &Report{
+		Environments: slices.Clone(cfg.Environments.Available),
+		Workspaces:   len(cfg.Workspaces),
+		Files:        []File{},
+	}
+	allGroups := make(map[string]bool)
+
+	add := func(f File, secrets map[string]string, defaults map[string]any) {
+		groups := pathGroups(secrets)
+		for g := range groups {
+			allGroups[g] = true
+		}
+
+		f.Mappings = len(secrets)
+		f.PathGroups = len(groups)
+		f.Defaults = countDefaults(defaults, cfg.Environments.Available)
+		for _, p := range secrets {
+			if resolver.HasEnvVar(p) {
+				f.EnvScoped++
+			} else {
+				f.Shared++
+			}
+		}
+
+		r.Files = append(r.Files, f)
+		r.Mappings += f.Mappings
+		r.EnvScoped += f.EnvScoped
+		r.Shared += f.Shared
+		r.Defaults += f.Defaults
+	}
+
+	add(File{Path: RootFile}, cfg.Secrets, cfg.Defaults)
+
+	for _, ws := range cfg.Workspaces {
+		rel := filepath.ToSlash(filepath.Clean(ws))
+		wsCfg, err := config.LoadWorkspaceConfig(filepath.Join(rootDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+		add(File{Path: rel, Workspace: path.Base(path.Dir(rel))}, wsCfg.Secrets, wsCfg.Defaults)
+	}
+
+	r.PathGroups = len(allGroups)
+	if r.Mappings > 0 {
+		r.SharedRatio = float64(r.Shared) / float64(r.Mappings)
+	}
+	return r, nil
+}
+
+// pathGroups returns the distinct Vault paths read by secrets, with ${env}
+// left in place. Command mappings read no Vault path.
+func pathGroups(secrets map[string]string) map[string]bool {
+	groups := make(map[string]bool)
+	for _, p := range secrets {
+		if resolver.IsCommand(p) {
+			continue
+		}
+		p = path.Clean(strings.TrimPrefix(p, "/"))
+		if i := strings.LastIndex(p, "/"); i > 0 {
+			groups[p[:i]] = true
+		}
+	}
+	return groups
+}
+
+// countDefaults returns the number of distinct default names, whether set
+// for every environment or only in an environment's table.
+func countDefaults(defaults map[string]any, envs []string) int {
+	names := make(map[string]bool)
+	for k, v := range defaults {
+		table, ok := v.(map[string]any)
+		if !ok || !slices.Contains(envs, k) {
+			names[k] = true
+			continue
+		}
+		for name := range table {
+			names[name] = true
+		}
+	}
+	return len(names)
+}
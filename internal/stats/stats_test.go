@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.dot.industries/vx/internal/config"
+)
+
+const testRoot = `workspaces = ["apps/api/vx.toml", "apps/web/vx.toml"]
+
+[vault]
+address = "https://vault.example.com"
+auth_method = "oidc"
+
+[environments]
+default = "dev"
+available = ["dev", "production"]
+
+[secrets]
+SENTRY_DSN = "shared/sentry/dsn"
+
+[defaults]
+LOG_LEVEL = "info"
+
+[defaults.production]
+LOG_LEVEL = "warn"
+REGION = "eu-west-1"
+`
+
+const testAPI = `[secrets]
+DATABASE_URL = "${env}/api/database/url"
+DATABASE_USER = "${env}/api/database/user"
+STRIPE_KEY = "shared/stripe/key"
+`
+
+const testWeb = `[secrets]
+SENTRY_DSN = "shared/sentry/dsn"
+API_URL = "${env}/web/api_url"
+
+[defaults]
+PORT = 3000
+`
+
+func TestCollect(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"vx.toml":          testRoot,
+		"apps/api/vx.toml": testAPI,
+		"apps/web/vx.toml": testWeb,
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := config.LoadRootConfig(filepath.Join(dir, "vx.toml"))
+	if err != nil {
+		t.Fatalf("LoadRootConfig() error = %v", err)
+	}
+
+	got, err := Collect(dir, cfg)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	want := &Report{
+		Environments: []string{"dev", "production"},
+		Workspaces:   2,
+		Files: []File{
+			{Path: "vx.toml", Mappings: 1, Shared: 1, PathGroups: 1, Defaults: 2},
+			{Path: "apps/api/vx.toml", Workspace: "api", Mappings: 3, EnvScoped: 2, Shared: 1, PathGroups: 2},
+			{Path: "apps/web/vx.toml", Workspace: "web", Mappings: 2, EnvScoped: 1, Shared: 1, PathGroups: 2, Defaults: 1},
+		},
+		Mappings:    6,
+		EnvScoped:   3,
+		Shared:      3,
+		Defaults:    3,
+		PathGroups:  4,
+		SharedRatio: 0.5,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Collect() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestCollect_MissingWorkspace(t *testing.T) {
+	cfg := &config.RootConfig{Workspaces: []string{"missing/vx.toml"}}
+	if _, err := Collect(t.TempDir(), cfg); err == nil {
+		t.Error("Collect() expected error for a missing workspace file")
+	}
+}
@@ -0,0 +1,158 @@
+package suggest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.dot.industries/vx/internal/vault"
+)
+
+// Suggestion is a proposed mapping from an environment variable to a Vault
+// path template.
+type Suggestion struct {
+	EnvVar    string
+	VaultPath string  // e.g. "${env}/database/url"
+	Score     float64 // 0..1; 1 means the path spells the variable name exactly
+	Refs      []Ref
+}
+
+// VaultLister is the subset of the Vault client used to enumerate keys.
+type VaultLister interface {
+	ListKeys(kvPath string) ([]vault.VaultEntry, error)
+	ReadKV(ctx context.Context, kvPath string) (map[string]string, error)
+}
+
+// MinScore is the similarity below which no suggestion is made.
+const MinScore = 0.5
+
+// ignoredVars are runtime or tooling variables that are never secrets.
+var ignoredVars = map[string]bool{
+	"PATH": true, "HOME": true, "USER": true, "PWD": true, "SHELL": true,
+	"TERM": true, "TZ": true, "LANG": true, "CI": true, "DEBUG": true,
+	"NODE_ENV": true, "PORT": true, "HOST": true, "HOSTNAME": true,
+}
+
+// Candidates lists every key below the given prefixes in Vault and returns
+// them as mapping templates. The prefix "${env}" is listed under env and kept
+// as a placeholder in the result, so suggestions work across environments.
+func Candidates(ctx context.Context, v VaultLister, env string, prefixes []string) ([]string, error) {
+	var out []string
+
+	for _, prefix := range prefixes {
+		listPrefix := strings.ReplaceAll(prefix, "${env}", env)
+
+		secrets, err := vault.ListTree(v, strings.TrimSuffix(listPrefix, "/")+"/")
+		if err != nil {
+			return nil, fmt.Errorf("listing %s: %w", listPrefix, err)
+		}
+
+		for _, secret := range secrets {
+			data, err := v.ReadKV(ctx, secret)
+			if err != nil {
+				return nil, err
+			}
+			rel := strings.TrimPrefix(secret, strings.TrimSuffix(listPrefix, "/"))
+			for key := range data {
+				out = append(out, strings.TrimSuffix(prefix, "/")+rel+"/"+key)
+			}
+		}
+	}
+
+	sort.Strings(out)
+	return out, nil
+}
+
+// Match pairs each referenced environment variable with its most similar
+// candidate path. Variables in mapped (already configured) or on the built-in
+// ignore list are skipped, as are matches scoring below MinScore. Results are
+// sorted by variable name.
+func Match(refs map[string][]Ref, candidates []string, mapped map[string]bool) []Suggestion {
+	var out []Suggestion
+
+	for name, locations := range refs {
+		if mapped[name] || ignoredVars[name] {
+			continue
+		}
+
+		best := Suggestion{EnvVar: name, Refs: locations}
+		for _, c := range candidates {
+			score := similarity(name, c)
+			if score > best.Score || (score == best.Score && score > 0 && c < best.VaultPath) {
+				best.Score = score
+				best.VaultPath = c
+			}
+		}
+
+		if best.Score >= MinScore {
+			out = append(out, best)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].EnvVar < out[j].EnvVar })
+	return out
+}
+
+// similarity scores how well a candidate path describes an environment
+// variable name. The path (without its "${env}" or other leading prefix) is
+// turned into name tokens, e.g. "${env}/database/url" -> [DATABASE URL], and
+// compared with the variable's tokens using the Dice coefficient. An exact
+// spelling match scores 1, and a key that alone spells the variable scores
+// at least 0.9.
+func similarity(envVar, candidate string) float64 {
+	nameTokens := tokens(envVar)
+	if len(nameTokens) == 0 {
+		return 0
+	}
+
+	segments := strings.Split(candidate, "/")
+	key := segments[len(segments)-1]
+	if len(segments) > 1 {
+		segments = segments[1:] // drop the environment or shared prefix
+	}
+	pathTokens := tokens(strings.Join(segments, "_"))
+
+	if strings.Join(pathTokens, "_") == strings.Join(nameTokens, "_") {
+		return 1
+	}
+	if strings.Join(tokens(key), "_") == strings.Join(nameTokens, "_") {
+		return 0.9
+	}
+
+	return dice(nameTokens, pathTokens)
+}
+
+// dice returns the Dice coefficient of two token multisets.
+func dice(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	counts := make(map[string]int, len(b))
+	for _, t := range b {
+		counts[t]++
+	}
+
+	shared := 0
+	for _, t := range a {
+		if counts[t] > 0 {
+			counts[t]--
+			shared++
+		}
+	}
+
+	return 2 * float64(shared) / float64(len(a)+len(b))
+}
+
+// tokens splits a name on "_", "-", ".", and "/" and upper-cases the parts.
+func tokens(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '_' || r == '-' || r == '.' || r == '/'
+	})
+	for i, f := range fields {
+		fields[i] = strings.ToUpper(f)
+	}
+	return fields
+}
+
@@ -0,0 +1,134 @@
+package suggest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.dot.industries/vx/internal/vault"
+)
+
+type fakeVault struct {
+	dirs map[string][]vault.VaultEntry
+	data map[string]map[string]string
+}
+
+func (f *fakeVault) ListKeys(p string) ([]vault.VaultEntry, error) { return f.dirs[p], nil }
+
+func (f *fakeVault) ReadKV(ctx context.Context, p string) (map[string]string, error) {
+	return f.data[p], nil
+}
+
+func TestCandidates(t *testing.T) {
+	v := &fakeVault{
+		dirs: map[string][]vault.VaultEntry{
+			"dev/":        {{Name: "database"}, {Name: "stripe/", IsDir: true}},
+			"dev/stripe/": {{Name: "api"}},
+			"shared/":     {{Name: "sentry"}},
+		},
+		data: map[string]map[string]string{
+			"dev/database":   {"url": "x", "password": "y"},
+			"dev/stripe/api": {"key": "z"},
+			"shared/sentry":  {"dsn": "d"},
+		},
+	}
+
+	got, err := Candidates(context.Background(), v, "dev", []string{"${env}", "shared"})
+	if err != nil {
+		t.Fatalf("Candidates() error = %v", err)
+	}
+
+	want := []string{
+		"${env}/database/password",
+		"${env}/database/url",
+		"${env}/stripe/api/key",
+		"shared/sentry/dsn",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Candidates() = %v, want %v", got, want)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	refs := map[string][]Ref{
+		"DATABASE_URL":   {{File: "a.ts", Line: 1}},
+		"STRIPE_API_KEY": {{File: "b.ts", Line: 2}},
+		"SENTRY_DSN":     {{File: "c.ts", Line: 3}},
+		"ALREADY_MAPPED": {{File: "d.ts", Line: 4}},
+		"NODE_ENV":       {{File: "e.ts", Line: 5}},
+		"UNRELATED":      {{File: "f.ts", Line: 6}},
+	}
+	candidates := []string{
+		"${env}/database/password",
+		"${env}/database/url",
+		"${env}/stripe/api/key",
+		"shared/sentry/dsn",
+		"${env}/already/mapped",
+	}
+
+	got := Match(refs, candidates, map[string]bool{"ALREADY_MAPPED": true})
+
+	want := map[string]string{
+		"DATABASE_URL":   "${env}/database/url",
+		"SENTRY_DSN":     "shared/sentry/dsn",
+		"STRIPE_API_KEY": "${env}/stripe/api/key",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Match() = %+v, want %d suggestions", got, len(want))
+	}
+	for _, s := range got {
+		if want[s.EnvVar] != s.VaultPath {
+			t.Errorf("Match() %s -> %s, want %s", s.EnvVar, s.VaultPath, want[s.EnvVar])
+		}
+		if s.Score != 1 {
+			t.Errorf("Match() %s score = %v, want 1", s.EnvVar, s.Score)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		envVar, candidate string
+		min, max          float64
+	}{
+		{"DATABASE_URL", "${env}/database/url", 1, 1},
+		{"DATABASE_URL", "${env}/db/database_url", 0.9, 0.9},
+		{"DB_PASSWORD", "${env}/database/password", 0.5, 0.5},
+		{"REDIS_URL", "${env}/database/url", 0.5, 0.5},
+		{"STRIPE_KEY", "${env}/database/url", 0, 0},
+	}
+
+	for _, tt := range tests {
+		got := similarity(tt.envVar, tt.candidate)
+		if got < tt.min || got > tt.max {
+			t.Errorf("similarity(%q, %q) = %v, want in [%v, %v]", tt.envVar, tt.candidate, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestAppendMappings(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vx.toml")
+	os.WriteFile(path, []byte("# web workspace\n[secrets]\nEXISTING = \"${env}/existing/key\"\n"), 0o644)
+
+	err := AppendMappings(path, []Suggestion{
+		{EnvVar: "DATABASE_URL", VaultPath: "${env}/database/url"},
+		{EnvVar: "EXISTING", VaultPath: "${env}/other/key"},
+	})
+	if err != nil {
+		t.Fatalf("AppendMappings() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	got := string(data)
+	for _, want := range []string{"# web workspace", `DATABASE_URL = "${env}/database/url"`, `EXISTING = "${env}/existing/key"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("vx.toml missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "other/key") {
+		t.Error("AppendMappings() must not replace existing keys")
+	}
+}
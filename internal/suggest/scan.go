@@ -0,0 +1,144 @@
+// Package suggest proposes vx.toml secret mappings for environment variables
+// that a workspace's source code reads, by matching their names against keys
+// that already exist in Vault.
+package suggest
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Ref is a single place in the source tree that reads an environment
+// variable.
+type Ref struct {
+	File string // path relative to the scanned directory
+	Line int
+}
+
+// envPatterns match environment variable reads in common languages. Each
+// pattern captures the variable name in its first group.
+var envPatterns = []*regexp.Regexp{
+	// JavaScript / TypeScript: process.env.FOO, process.env["FOO"], import.meta.env.FOO
+	regexp.MustCompile(`process\.env\.([A-Z_][A-Z0-9_]*)`),
+	regexp.MustCompile(`process\.env\[\s*["'` + "`" + `]([A-Z_][A-Z0-9_]*)["'` + "`" + `]\s*\]`),
+	regexp.MustCompile(`import\.meta\.env\.([A-Z_][A-Z0-9_]*)`),
+	// Deno / Bun
+	regexp.MustCompile(`(?:Deno|Bun)\.env\.get\(\s*["']([A-Z_][A-Z0-9_]*)["']`),
+	// Go
+	regexp.MustCompile(`os\.(?:Getenv|LookupEnv)\(\s*"([A-Z_][A-Z0-9_]*)"`),
+	// Python
+	regexp.MustCompile(`os\.environ\[\s*["']([A-Z_][A-Z0-9_]*)["']\s*\]`),
+	regexp.MustCompile(`os\.(?:environ\.get|getenv)\(\s*["']([A-Z_][A-Z0-9_]*)["']`),
+	// Ruby
+	regexp.MustCompile(`ENV(?:\.fetch\(|\[)\s*["']([A-Z_][A-Z0-9_]*)["']`),
+	// Rust
+	regexp.MustCompile(`env::var(?:_os)?\(\s*"([A-Z_][A-Z0-9_]*)"`),
+	// Java / Kotlin
+	regexp.MustCompile(`System\.getenv\(\s*"([A-Z_][A-Z0-9_]*)"`),
+}
+
+// sourceExts lists the file extensions that are scanned.
+var sourceExts = map[string]bool{
+	".js": true, ".jsx": true, ".mjs": true, ".cjs": true,
+	".ts": true, ".tsx": true, ".mts": true, ".cts": true,
+	".vue": true, ".svelte": true, ".astro": true,
+	".go": true, ".py": true, ".rb": true, ".rs": true,
+	".java": true, ".kt": true,
+}
+
+// skipDirs are never descended into: dependencies, build output, and VCS data.
+var skipDirs = map[string]bool{
+	"node_modules": true, "vendor": true, ".git": true,
+	"dist": true, "build": true, "out": true, "target": true,
+	".next": true, ".nuxt": true, ".svelte-kit": true, ".turbo": true,
+	"__pycache__": true, ".venv": true, "venv": true,
+}
+
+// Scan walks dir and returns every environment variable read by the source
+// files in it, keyed by variable name. References within each variable are
+// ordered by file and line.
+func Scan(dir string) (map[string][]Ref, error) {
+	refs := make(map[string][]Ref)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !sourceExts[filepath.Ext(path)] {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			rel = path
+		}
+		return scanFile(path, rel, refs)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", dir, err)
+	}
+
+	for name := range refs {
+		sort.Slice(refs[name], func(i, j int) bool {
+			a, b := refs[name][i], refs[name][j]
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			return a.Line < b.Line
+		})
+	}
+
+	return refs, nil
+}
+
+// scanFile records the environment variable reads in a single file.
+func scanFile(path, rel string, refs map[string][]Ref) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	line := 0
+	for sc.Scan() {
+		line++
+		text := sc.Text()
+		// Every pattern mentions "env" in some casing; skip other lines cheaply.
+		if !strings.Contains(strings.ToLower(text), "env") {
+			continue
+		}
+
+		seen := make(map[string]bool)
+		for _, re := range envPatterns {
+			for _, m := range re.FindAllStringSubmatch(text, -1) {
+				name := m[1]
+				if seen[name] {
+					continue
+				}
+				seen[name] = true
+				refs[name] = append(refs[name], Ref{File: rel, Line: line})
+			}
+		}
+	}
+
+	// Minified or binary-ish files with huge lines are skipped, not fatal.
+	if err := sc.Err(); err != nil && err != bufio.ErrTooLong {
+		return err
+	}
+	return nil
+}
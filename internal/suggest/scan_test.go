@@ -0,0 +1,55 @@
+package suggest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSource(t *testing.T, dir, rel, content string) {
+	t.Helper()
+	path := filepath.Join(dir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	writeSource(t, dir, "src/db.ts", `const url = process.env.DATABASE_URL;
+const key = process.env["STRIPE_KEY"] ?? import.meta.env.PUBLIC_URL;
+`)
+	writeSource(t, dir, "cmd/main.go", `token := os.Getenv("GITHUB_TOKEN")
+v, ok := os.LookupEnv("DATABASE_URL")
+`)
+	writeSource(t, dir, "app.py", `os.environ["SENTRY_DSN"]
+os.environ.get('REDIS_URL')
+`)
+	writeSource(t, dir, "lib/x.rb", `ENV.fetch("RAILS_KEY")`)
+	writeSource(t, dir, "node_modules/pkg/index.js", `process.env.IGNORED_DEP`)
+	writeSource(t, dir, "README.md", `process.env.NOT_SOURCE`)
+
+	refs, err := Scan(dir)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	for _, name := range []string{"DATABASE_URL", "STRIPE_KEY", "PUBLIC_URL", "GITHUB_TOKEN", "SENTRY_DSN", "REDIS_URL", "RAILS_KEY"} {
+		if len(refs[name]) == 0 {
+			t.Errorf("Scan() missing %s", name)
+		}
+	}
+	for _, name := range []string{"IGNORED_DEP", "NOT_SOURCE"} {
+		if _, ok := refs[name]; ok {
+			t.Errorf("Scan() should not report %s", name)
+		}
+	}
+
+	db := refs["DATABASE_URL"]
+	if len(db) != 2 || db[0].File != filepath.Join("cmd", "main.go") || db[0].Line != 2 {
+		t.Errorf("DATABASE_URL refs = %+v, want cmd/main.go:2 then src/db.ts:1", db)
+	}
+}
@@ -0,0 +1,28 @@
+package suggest
+
+import (
+	"fmt"
+
+	"go.dot.industries/vx/internal/tomlfile"
+)
+
+// AppendMappings adds the accepted suggestions to the [secrets] section of
+// the vx.toml at filePath, creating the section if needed. Existing keys are
+// never replaced. Comments and formatting are preserved.
+func AppendMappings(filePath string, suggestions []Suggestion) error {
+	doc, err := tomlfile.Read(filePath)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range suggestions {
+		if doc.First("secrets", s.EnvVar) != nil {
+			continue
+		}
+		if err := tomlfile.AddMapping(doc, s.EnvVar, s.VaultPath); err != nil {
+			return fmt.Errorf("editing %s: %w", filePath, err)
+		}
+	}
+
+	return tomlfile.Write(filePath, doc)
+}
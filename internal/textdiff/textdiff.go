@@ -0,0 +1,135 @@
+// Package textdiff renders the difference between two versions of a small
+// text file, such as a vx.toml before and after an edit, as a unified diff.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is how many unchanged lines surround each change in Unified.
+const diffContext = 3
+
+// Unified returns a unified diff from before to after, with name as the file
+// name in its header and label describing the new contents, e.g.
+// "upgraded". It is empty when the two are equal.
+func Unified(name, label string, before, after []byte) string {
+	if string(before) == string(after) {
+		return ""
+	}
+	return unifiedDiff(name, label, splitLines(string(before)), splitLines(string(after)))
+}
+
+// op is one line of an edit script: ' ' kept, '-' removed, or '+' added.
+type op struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff renders the edit script between a and b as unified diff hunks.
+// Config files are small, so a quadratic LCS table is fine.
+func unifiedDiff(name, label string, a, b []string) string {
+	ops := editScript(a, b)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s (%s)\n", name, name, label)
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk until diffContext*2 unchanged lines separate it
+		// from the next change.
+		start := max(i-diffContext, 0)
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= diffContext*2 {
+				break
+			}
+		}
+		end = min(end+diffContext, len(ops))
+
+		aLine, bLine := lineNumbers(ops[:start])
+		var aCount, bCount int
+		var body strings.Builder
+		for _, o := range ops[start:end] {
+			if o.kind != '+' {
+				aCount++
+			}
+			if o.kind != '-' {
+				bCount++
+			}
+			body.WriteString(string(o.kind) + o.line + "\n")
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n%s", aLine+1, aCount, bLine+1, bCount, body.String())
+		i = end
+	}
+	return out.String()
+}
+
+// lineNumbers counts the lines of a and b consumed by ops.
+func lineNumbers(ops []op) (a, b int) {
+	for _, o := range ops {
+		if o.kind != '+' {
+			a++
+		}
+		if o.kind != '-' {
+			b++
+		}
+	}
+	return a, b
+}
+
+// editScript returns the shortest sequence of kept, removed, and added lines
+// turning a into b, from their longest common subsequence.
+func editScript(a, b []string) []op {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, op{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, op{'+', b[j]})
+	}
+	return ops
+}
+
+// splitLines splits s into lines without their newlines.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,15 @@
+package textdiff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	got := Unified("vx.toml", "formatted", []byte("a\nb\n"), []byte("a\nc\n"))
+	want := "--- vx.toml\n+++ vx.toml (formatted)\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"
+	if got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := Unified("vx.toml", "formatted", []byte("a\n"), []byte("a\n")); got != "" {
+		t.Errorf("Unified() of equal contents = %q, want empty", got)
+	}
+}
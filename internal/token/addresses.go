@@ -0,0 +1,143 @@
+package token
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const addressesFile = "tokens.json"
+
+// address is the Vault server whose token ReadToken, WriteToken, and
+// RemoveToken use.
+var address string
+
+// UseAddress makes ReadToken, WriteToken, and RemoveToken use the token of
+// the Vault server at addr, kept in a sink of its own (~/.vx/tokens/<host>),
+// so logging in to one server does not replace the token of another. With
+// an empty addr they use ~/.vx/token.
+func UseAddress(addr string) {
+	address = addr
+}
+
+// addressPrefix starts the store names of per-address tokens. Connection
+// names from [vaults] cannot contain a slash, so the two never collide.
+const addressPrefix = tokensDir + "/"
+
+// addressKey returns the store name of the token of the server at addr.
+func addressKey(addr string) string {
+	return addressPrefix + hostName(addr)
+}
+
+// hostName returns the host and port of addr in a form safe for a file
+// name: lowercase, with anything but letters, digits, dots, and dashes
+// replaced by an underscore (vault.example.com_8200).
+func hostName(addr string) string {
+	host := addr
+	if u, err := url.Parse(addr); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '_'
+	}, host)
+}
+
+// AddressesPath returns the path to the list of Vault servers with a token
+// in the store (~/.vx/tokens.json).
+var AddressesPath = func() string {
+	return filepath.Join(DefaultDir(), addressesFile)
+}
+
+// addressesMu serializes updates of the address list within the process.
+var addressesMu sync.Mutex
+
+// Addresses returns the Vault servers a token was written for, which the
+// daemon renews. The list holds no tokens, so it also covers those kept in
+// the OS keychain.
+func Addresses() ([]string, error) {
+	data, err := os.ReadFile(AddressesPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read token addresses: %w", err)
+	}
+
+	var addrs []string
+	if err := json.Unmarshal(data, &addrs); err != nil {
+		return nil, fmt.Errorf("read token addresses: %w", err)
+	}
+	return addrs, nil
+}
+
+// MigrateLegacyToken moves a token from before tokens were kept per server
+// (~/.vx/token) to the sink of addr, the server of the root vx.toml, which
+// is the one it was issued for. It does nothing when there is no such token
+// or addr already has one of its own, so the token moves at most once.
+func MigrateLegacyToken(addr string) error {
+	if addr == "" {
+		return nil
+	}
+	legacy, err := store.Read("")
+	if err != nil {
+		return nil
+	}
+	if _, err := store.Read(addressKey(addr)); err == nil {
+		return nil
+	}
+	if err := WriteTokenFor(addr, legacy); err != nil {
+		return fmt.Errorf("migrate token: %w", err)
+	}
+	return store.Remove("")
+}
+
+// recordAddress adds addr to the address list if it is not there yet.
+func recordAddress(addr string) error {
+	addressesMu.Lock()
+	defer addressesMu.Unlock()
+
+	addr = strings.TrimRight(addr, "/")
+	addrs, err := Addresses()
+	if err != nil || slices.Contains(addrs, addr) {
+		return err
+	}
+
+	return writeAddresses(append(addrs, addr))
+}
+
+// forgetAddress removes addr from the address list.
+func forgetAddress(addr string) error {
+	addressesMu.Lock()
+	defer addressesMu.Unlock()
+
+	addrs, err := Addresses()
+	if err != nil {
+		return err
+	}
+	host := hostName(addr)
+	kept := slices.DeleteFunc(addrs, func(a string) bool { return hostName(a) == host })
+	if len(kept) == len(addrs) {
+		return nil
+	}
+	return writeAddresses(kept)
+}
+
+func writeAddresses(addrs []string) error {
+	data, err := json.Marshal(addrs)
+	if err != nil {
+		return fmt.Errorf("write token addresses: %w", err)
+	}
+	return writeTokenTo(AddressesPath(), string(data))
+}
@@ -0,0 +1,117 @@
+package token
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestHostName(t *testing.T) {
+	tests := map[string]string{
+		"https://vault.example.com":          "vault.example.com",
+		"https://Vault.Example.com:8200/":    "vault.example.com_8200",
+		"http://127.0.0.1:8200":              "127.0.0.1_8200",
+		"https://[::1]:8200":                 "___1__8200",
+		"vault.internal":                     "vault.internal",
+		"https://vault.example.com/../other": "vault.example.com",
+	}
+	for addr, want := range tests {
+		if got := hostName(addr); got != want {
+			t.Errorf("hostName(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
+
+func TestTokenPerAddress(t *testing.T) {
+	dir := t.TempDir()
+	orig := DefaultDir
+	DefaultDir = func() string { return dir }
+	t.Cleanup(func() {
+		DefaultDir = orig
+		UseAddress("")
+	})
+
+	// A token from before tokens were kept per server.
+	if err := WriteToken("s.legacy"); err != nil {
+		t.Fatal(err)
+	}
+
+	// It is never sent to another server.
+	if got, err := ReadTokenFor("https://vault.dev.example.com:8200"); err == nil {
+		t.Errorf("ReadTokenFor(dev) before a login = %q, want no token", got)
+	}
+
+	// It moves to the server of the root vx.toml, once.
+	if err := MigrateLegacyToken("https://vault.prod.example.com"); err != nil {
+		t.Fatalf("MigrateLegacyToken() error = %v", err)
+	}
+	UseAddress("https://vault.prod.example.com")
+	if got, err := ReadToken(); err != nil || got != "s.legacy" {
+		t.Errorf("ReadToken() after migrating = %q, %v; want the legacy token", got, err)
+	}
+	if _, err := ReadTokenFor(""); err == nil {
+		t.Error("~/.vx/token still exists after migrating")
+	}
+	if err := WriteToken("s.prod"); err != nil {
+		t.Fatalf("WriteToken() error = %v", err)
+	}
+
+	UseAddress("https://vault.dev.example.com:8200")
+	if err := WriteToken("s.dev"); err != nil {
+		t.Fatalf("WriteToken() error = %v", err)
+	}
+	if got := Location(); got != filepath.Join(dir, "tokens", "vault.dev.example.com_8200") {
+		t.Errorf("Location() = %q, want the server's sink", got)
+	}
+
+	for addr, want := range map[string]string{
+		"https://vault.prod.example.com":     "s.prod",
+		"https://vault.dev.example.com:8200": "s.dev",
+	} {
+		if got, err := ReadTokenFor(addr); err != nil || got != want {
+			t.Errorf("ReadTokenFor(%q) = %q, %v; want %q", addr, got, err, want)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(dir, "tokens", "vault.prod.example.com")); err != nil || info.Mode().Perm() != filePerms {
+		t.Errorf("prod sink: %v, %v; want a 0600 file", info, err)
+	}
+
+	// Writing again does not list the server twice.
+	if err := WriteTokenFor("https://vault.prod.example.com/", "s.prod2"); err != nil {
+		t.Fatal(err)
+	}
+	addrs, err := Addresses()
+	if err != nil {
+		t.Fatalf("Addresses() error = %v", err)
+	}
+	if want := []string{"https://vault.prod.example.com", "https://vault.dev.example.com:8200"}; !slices.Equal(addrs, want) {
+		t.Errorf("Addresses() = %v, want %v", addrs, want)
+	}
+
+	if err := RemoveToken(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ReadToken(); err == nil {
+		t.Errorf("ReadToken() after RemoveToken() = %q, want no token", got)
+	}
+	if got, _ := ReadTokenFor("https://vault.prod.example.com"); got != "s.prod2" {
+		t.Errorf("prod token = %q after removing the dev token", got)
+	}
+	addrs, _ = Addresses()
+	if want := []string{"https://vault.prod.example.com"}; !slices.Equal(addrs, want) {
+		t.Errorf("Addresses() after RemoveToken() = %v, want %v", addrs, want)
+	}
+}
+
+func TestAccount(t *testing.T) {
+	for name, want := range map[string]string{
+		"":                               "token",
+		"analytics":                      "token-analytics",
+		addressKey("https://vault.corp"): "tokens/vault.corp",
+	} {
+		if got := account(name); got != want {
+			t.Errorf("account(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
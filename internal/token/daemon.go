@@ -3,6 +3,7 @@ package token
 import (
 	"context"
 	"fmt"
+	"math/rand/v2"
 	"os"
 	"strconv"
 	"sync"
@@ -10,28 +11,123 @@ import (
 	"time"
 )
 
+const (
+	// renewJitter spreads checks by up to ±10% of the delay, so daemons
+	// started together don't hit Vault in lockstep.
+	renewJitter = 0.1
+	// maxRenewBackoff caps the delay between checks while renewal keeps
+	// failing.
+	maxRenewBackoff = 15 * time.Minute
+)
+
 // DaemonStatus represents the current state of the background renewal daemon.
 type DaemonStatus struct {
-	Running     bool
-	PID         int
+	Running bool
+	PID     int
+	// TokenTTL is the token's remaining lifetime, estimated from the last
+	// check; CreationTTL is the lifetime it was issued with. Both are zero
+	// until a check succeeds.
 	TokenTTL    time.Duration
+	CreationTTL time.Duration
+	// LastRenewal is when the token was last renewed, and Renewals counts
+	// the renewals since the daemon started. Checks that find the token
+	// fresh enough leave both alone.
 	LastRenewal time.Time
+	Renewals    int
+	LastCheck   time.Time
+	NextCheck   time.Time
+	// ConsecutiveFailures counts the failed checks since the last success;
+	// TotalFailures counts every failed check since the daemon started.
+	ConsecutiveFailures int
+	TotalFailures       int
+	LastError           string
+	LastErrorTime       time.Time
+}
+
+// StateChange describes a transition of the renewal loop between healthy
+// and failing.
+type StateChange struct {
+	// Healthy is false when a check failed after succeeding (or on the
+	// first check), and true when a check succeeded after failures.
+	Healthy bool
+	// Failures is the number of consecutive failures: the count so far when
+	// failing, or the count that just ended when recovering.
+	Failures int
+	// Err is the error that started the failing state; nil on recovery.
+	Err error
+	// NextCheck is the delay before the next check.
+	NextCheck time.Duration
+}
+
+// DaemonOption configures a Daemon.
+type DaemonOption func(*Daemon)
+
+// WithStateChange registers fn to be called from the renewal loop whenever
+// renewal starts failing or recovers, so the caller can log it.
+func WithStateChange(fn func(StateChange)) DaemonOption {
+	return func(d *Daemon) {
+		d.onStateChange = fn
+	}
+}
+
+// WithFailure registers fn to be called from the renewal loop after every
+// failed check, with Failures counting the consecutive failures so far, so
+// the caller can record each error.
+func WithFailure(fn func(StateChange)) DaemonOption {
+	return func(d *Daemon) {
+		d.onFailure = fn
+	}
+}
+
+// WithOtherTokens makes the daemon also renew the tokens of the other Vault
+// servers addrs returns, such as those from Addresses, each with a renewer
+// from newRenewer. They are checked at the daemon's interval, backing off
+// while one keeps failing, and failures go to the WithFailure callback with
+// the server's address in the error.
+func WithOtherTokens(addrs func() ([]string, error), newRenewer func(addr string) *TokenRenewer) DaemonOption {
+	return func(d *Daemon) {
+		d.otherAddrs = addrs
+		d.newRenewer = newRenewer
+	}
 }
 
 // Daemon manages a background token renewal process.
 type Daemon struct {
-	renewer     *TokenRenewer
-	stop        chan struct{}
-	mu          sync.Mutex
-	lastRenewal time.Time
+	renewer       *TokenRenewer
+	stop          chan struct{}
+	onStateChange func(StateChange)
+	onFailure     func(StateChange)
+	otherAddrs    func() ([]string, error)
+	newRenewer    func(addr string) *TokenRenewer
+	// others is only used by the loop renewing the other servers' tokens.
+	others map[string]*otherToken
+
+	mu                  sync.Mutex
+	tokenTTL            time.Duration
+	creationTTL         time.Duration
+	ttlChecked          time.Time
+	lastCheck           time.Time
+	nextCheck           time.Time
+	renewals            int
+	lastRenewal         time.Time
+	consecutiveFailures int
+	totalFailures       int
+	lastError           string
+	lastErrorTime       time.Time
 }
 
 // NewDaemon creates a new Daemon with the given TokenRenewer.
-func NewDaemon(renewer *TokenRenewer) *Daemon {
-	return &Daemon{
+func NewDaemon(renewer *TokenRenewer, opts ...DaemonOption) *Daemon {
+	d := &Daemon{
 		renewer: renewer,
 		stop:    make(chan struct{}),
 	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
 }
 
 // Start begins the daemon renewal loop. It writes a PID file, periodically
@@ -46,6 +142,9 @@ func (d *Daemon) Start(ctx context.Context) error {
 	}
 
 	go d.loop(ctx)
+	if d.otherAddrs != nil {
+		go d.otherLoop(ctx)
+	}
 
 	return nil
 }
@@ -73,8 +172,8 @@ func (d *Daemon) IsRunning() bool {
 	return isProcessAlive(pid)
 }
 
-// Status returns the current daemon status including PID, token TTL, and last
-// renewal time.
+// Status returns the current daemon status including PID, token TTL, last
+// renewal time, and renewal failures.
 func (d *Daemon) Status() (DaemonStatus, error) {
 	pid, err := readPIDFile(PIDPath())
 	if err != nil {
@@ -84,48 +183,179 @@ func (d *Daemon) Status() (DaemonStatus, error) {
 	alive := isProcessAlive(pid)
 
 	d.mu.Lock()
-	lastRenewal := d.lastRenewal
-	d.mu.Unlock()
+	defer d.mu.Unlock()
+
+	var ttl time.Duration
+	if !d.ttlChecked.IsZero() {
+		ttl = max(0, d.tokenTTL-time.Since(d.ttlChecked))
+	}
 
 	return DaemonStatus{
-		Running:     alive,
-		PID:         pid,
-		LastRenewal: lastRenewal,
+		Running:             alive,
+		PID:                 pid,
+		TokenTTL:            ttl,
+		CreationTTL:         d.creationTTL,
+		LastRenewal:         d.lastRenewal,
+		Renewals:            d.renewals,
+		LastCheck:           d.lastCheck,
+		NextCheck:           d.nextCheck,
+		ConsecutiveFailures: d.consecutiveFailures,
+		TotalFailures:       d.totalFailures,
+		LastError:           d.lastError,
+		LastErrorTime:       d.lastErrorTime,
 	}, nil
 }
 
 // loop runs the periodic renewal check until stopped or the context is
-// cancelled.
+// cancelled. Checks are jittered and back off while renewal keeps failing.
 func (d *Daemon) loop(ctx context.Context) {
 	defer removePIDFile(PIDPath())
 
+	// Perform an immediate check on startup.
+	timer := time.NewTimer(d.tryRenew(ctx))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			timer.Reset(d.tryRenew(ctx))
+		}
+	}
+}
+
+// otherToken is the renewal state of another server's token.
+type otherToken struct {
+	renewer  *TokenRenewer
+	failures int
+	due      time.Time
+}
+
+// otherLoop renews the other servers' tokens until stopped or the context
+// is cancelled.
+func (d *Daemon) otherLoop(ctx context.Context) {
 	ticker := time.NewTicker(d.renewer.checkInterval)
 	defer ticker.Stop()
 
-	// Perform an immediate check on startup.
-	d.tryRenew(ctx)
-
 	for {
+		d.renewOthers(ctx, time.Now())
 		select {
 		case <-d.stop:
 			return
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			d.tryRenew(ctx)
 		}
 	}
 }
 
-// tryRenew attempts a single renewal and records the time on success.
-func (d *Daemon) tryRenew(ctx context.Context) {
-	if err := d.renewer.RenewOnce(ctx); err != nil {
+// renewOthers checks each other server's token that is due, skipping the
+// server the daemon's own renewer looks after.
+func (d *Daemon) renewOthers(ctx context.Context, now time.Time) {
+	addrs, err := d.otherAddrs()
+	if err != nil {
+		if d.onFailure != nil {
+			d.onFailure(StateChange{Failures: 1, Err: err, NextCheck: d.renewer.checkInterval})
+		}
 		return
 	}
 
+	if d.others == nil {
+		d.others = make(map[string]*otherToken)
+	}
+	for _, addr := range addrs {
+		if hostName(addr) == hostName(d.renewer.vaultAddr) {
+			continue
+		}
+		o, ok := d.others[addr]
+		if !ok {
+			o = &otherToken{renewer: d.newRenewer(addr)}
+			d.others[addr] = o
+		}
+		if now.Before(o.due) {
+			continue
+		}
+		if _, err := ReadTokenFor(addr); err != nil {
+			// Nothing to renew until the next login to addr.
+			continue
+		}
+
+		if err := o.renewer.RenewOnce(ctx); err != nil {
+			o.failures++
+			// Checked again on the first tick after the backoff.
+			next := nextCheckDelay(d.renewer.checkInterval, o.failures, rand.Float64())
+			o.due = now.Add(next)
+			if d.onFailure != nil {
+				d.onFailure(StateChange{Failures: o.failures, Err: fmt.Errorf("%s: %w", addr, err), NextCheck: next})
+			}
+			continue
+		}
+		o.failures = 0
+		o.due = time.Time{}
+	}
+}
+
+// tryRenew attempts a single renewal, records the outcome, and returns the
+// delay before the next check.
+func (d *Daemon) tryRenew(ctx context.Context) time.Duration {
+	state, err := d.renewer.Check(ctx)
+	now := time.Now()
+
 	d.mu.Lock()
-	d.lastRenewal = time.Now()
+	failures := d.consecutiveFailures
+	d.lastCheck = now
+	if state.CreationTTL > 0 || state.TTL > 0 {
+		d.tokenTTL, d.creationTTL, d.ttlChecked = state.TTL, state.CreationTTL, now
+	}
+	if state.Renewed {
+		d.renewals++
+		d.lastRenewal = now
+	}
+	if err == nil {
+		d.consecutiveFailures = 0
+	} else {
+		d.consecutiveFailures++
+		d.totalFailures++
+		d.lastError = err.Error()
+		d.lastErrorTime = now
+	}
+	next := nextCheckDelay(d.renewer.checkInterval, d.consecutiveFailures, rand.Float64())
+	d.nextCheck = now.Add(next)
 	d.mu.Unlock()
+
+	if err != nil && d.onFailure != nil {
+		d.onFailure(StateChange{Failures: failures + 1, Err: err, NextCheck: next})
+	}
+	if d.onStateChange != nil {
+		switch {
+		case err != nil && failures == 0:
+			d.onStateChange(StateChange{Failures: 1, Err: err, NextCheck: next})
+		case err == nil && failures > 0:
+			d.onStateChange(StateChange{Healthy: true, Failures: failures, NextCheck: next})
+		}
+	}
+
+	return next
+}
+
+// nextCheckDelay returns the delay before the next check: the interval,
+// doubled for each consecutive failure up to maxRenewBackoff (or the
+// interval, if that is longer), then shifted by up to ±renewJitter. r is a
+// random number in [0, 1).
+func nextCheckDelay(interval time.Duration, failures int, r float64) time.Duration {
+	delay := interval
+	for range failures {
+		if delay >= maxRenewBackoff {
+			break
+		}
+		delay *= 2
+	}
+	delay = min(delay, max(maxRenewBackoff, interval))
+
+	return delay + time.Duration((2*r-1)*renewJitter*float64(delay))
 }
 
 // writePIDFile writes the process ID to the given path.
@@ -8,6 +8,8 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -261,6 +263,168 @@ func TestDaemonContextCancellation(t *testing.T) {
 	}
 }
 
+func TestDaemonTryRenew_FailureAndRecovery(t *testing.T) {
+	stub := newStubVaultServer(t, 7200, 86400, true)
+	defer stub.Close()
+
+	var failing atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		stub.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "token")
+	pidPath := filepath.Join(dir, "daemon.pid")
+	writeTokenTo(tokenPath, "s.failure-test")
+	overridePIDPath(t, pidPath)
+	writePIDFile(pidPath, os.Getpid())
+
+	var changes, failures []StateChange
+	renewer := NewTokenRenewer(srv.URL,
+		WithTokenPath(tokenPath),
+		WithCheckInterval(time.Minute),
+	)
+	daemon := NewDaemon(renewer, WithStateChange(func(c StateChange) {
+		changes = append(changes, c)
+	}), WithFailure(func(c StateChange) {
+		failures = append(failures, c)
+	}))
+
+	ctx := context.Background()
+
+	failing.Store(true)
+	first := daemon.tryRenew(ctx)
+	second := daemon.tryRenew(ctx)
+	if second <= first {
+		t.Errorf("delay after 2 failures = %v, want more than after 1 (%v)", second, first)
+	}
+
+	d, err := daemon.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if d.ConsecutiveFailures != 2 || d.TotalFailures != 2 {
+		t.Errorf("failures = %d consecutive, %d total; want 2, 2", d.ConsecutiveFailures, d.TotalFailures)
+	}
+	if d.LastError == "" || d.LastErrorTime.IsZero() {
+		t.Errorf("LastError = %q at %v, want an error and its time", d.LastError, d.LastErrorTime)
+	}
+
+	failing.Store(false)
+	daemon.tryRenew(ctx)
+
+	d, _ = daemon.Status()
+	if d.ConsecutiveFailures != 0 || d.TotalFailures != 2 {
+		t.Errorf("after recovery failures = %d consecutive, %d total; want 0, 2", d.ConsecutiveFailures, d.TotalFailures)
+	}
+	if d.LastRenewal.IsZero() {
+		t.Error("LastRenewal is zero after a successful check")
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("state changes = %+v, want failing then recovered", changes)
+	}
+	if changes[0].Healthy || changes[0].Err == nil {
+		t.Errorf("first change = %+v, want failing with an error", changes[0])
+	}
+	if !changes[1].Healthy || changes[1].Failures != 2 {
+		t.Errorf("second change = %+v, want recovered after 2 failures", changes[1])
+	}
+
+	if len(failures) != 2 || failures[0].Failures != 1 || failures[1].Failures != 2 || failures[1].Err == nil {
+		t.Errorf("failures = %+v, want each failed check with its count and error", failures)
+	}
+}
+
+func TestDaemonRenewOthers(t *testing.T) {
+	dir := t.TempDir()
+	orig := DefaultDir
+	DefaultDir = func() string { return dir }
+	t.Cleanup(func() { DefaultDir = orig })
+
+	primary := newStubVaultServer(t, 7200, 86400, true)
+	defer primary.Close()
+	other := newStubVaultServer(t, 7200, 86400, true)
+	defer other.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	for addr, tok := range map[string]string{
+		primary.URL: "s.primary",
+		other.URL:   "s.other",
+		down.URL:    "s.down",
+	} {
+		if err := WriteTokenFor(addr, tok); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var failures []StateChange
+	renewer := NewTokenRenewer(primary.URL, WithCheckInterval(time.Minute))
+	daemon := NewDaemon(renewer,
+		WithOtherTokens(Addresses, func(addr string) *TokenRenewer {
+			return NewTokenRenewer(addr, WithCheckInterval(time.Minute))
+		}),
+		WithFailure(func(c StateChange) { failures = append(failures, c) }),
+	)
+
+	now := time.Now()
+	daemon.renewOthers(context.Background(), now)
+
+	if got, _ := ReadTokenFor(other.URL); got != "s.renewed" {
+		t.Errorf("other server's token = %q, want it renewed", got)
+	}
+	if got, _ := ReadTokenFor(primary.URL); got != "s.primary" {
+		t.Errorf("primary server's token = %q, want it left to the daemon's own renewer", got)
+	}
+	if len(failures) != 1 || failures[0].Failures != 1 || !strings.Contains(failures[0].Err.Error(), down.URL) {
+		t.Fatalf("failures = %+v, want one naming %s", failures, down.URL)
+	}
+
+	// The failing server is backed off; the others are checked again.
+	daemon.renewOthers(context.Background(), now)
+	if len(failures) != 1 {
+		t.Errorf("failures after an immediate second pass = %d, want the failing server skipped", len(failures))
+	}
+	daemon.renewOthers(context.Background(), now.Add(time.Hour))
+	if len(failures) != 2 || failures[1].Failures != 2 {
+		t.Errorf("failures = %+v, want the failing server checked again once due", failures)
+	}
+}
+
+func TestNextCheckDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		failures int
+		r        float64
+		want     time.Duration
+	}{
+		{"healthy", time.Minute, 0, 0.5, time.Minute},
+		{"one failure", time.Minute, 1, 0.5, 2 * time.Minute},
+		{"three failures", time.Minute, 3, 0.5, 8 * time.Minute},
+		{"capped", time.Minute, 10, 0.5, maxRenewBackoff},
+		{"interval above cap", time.Hour, 2, 0.5, time.Hour},
+		{"jitter low", time.Minute, 0, 0, 54 * time.Second},
+		{"jitter high", time.Minute, 0, 1, 66 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextCheckDelay(tt.interval, tt.failures, tt.r); got != tt.want {
+				t.Errorf("nextCheckDelay(%v, %d, %v) = %v, want %v", tt.interval, tt.failures, tt.r, got, tt.want)
+			}
+		})
+	}
+}
+
 // newStubVaultServer creates a test HTTP server that responds to Vault
 // lookup-self and renew-self endpoints.
 func newStubVaultServer(t *testing.T, ttl int, creationTTL int, renewable bool) *httptest.Server {
@@ -277,6 +441,7 @@ func newStubVaultServer(t *testing.T, ttl int, creationTTL int, renewable bool)
 		case "/v1/auth/token/renew-self":
 			resp := tokenRenewResponse{}
 			resp.Auth.ClientToken = "s.renewed"
+			resp.Auth.LeaseDuration = creationTTL
 			json.NewEncoder(w).Encode(resp)
 		default:
 			w.WriteHeader(http.StatusNotFound)
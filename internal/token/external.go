@@ -0,0 +1,56 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnvVar is the environment variable holding an externally issued token.
+const EnvVar = "VAULT_TOKEN"
+
+// ErrNoExternalToken is returned by External when neither VAULT_TOKEN nor a
+// token file is set.
+var ErrNoExternalToken = errors.New("no token: set " + EnvVar + ", pass --vault-token, or configure vault.token_file")
+
+// External returns a token issued outside vx, for auth_method = "token": the
+// VAULT_TOKEN environment variable, or else the contents of tokenFile (for
+// example a vault-agent sink). A leading "~/" in tokenFile is expanded.
+// Such tokens are owned by whatever issued them, so vx does not cache them
+// unless told to by vx login.
+func External(tokenFile string) (string, error) {
+	if tok := strings.TrimSpace(os.Getenv(EnvVar)); tok != "" {
+		return tok, nil
+	}
+
+	if tokenFile == "" {
+		return "", ErrNoExternalToken
+	}
+
+	return FromFile(tokenFile)
+}
+
+// FromFile reads an externally issued token from tokenFile, expanding a
+// leading "~/". Named Vault connections use it directly: VAULT_TOKEN belongs
+// to [vault].
+func FromFile(tokenFile string) (string, error) {
+	if tokenFile == "" {
+		return "", fmt.Errorf("no token: configure token_file")
+	}
+
+	if rest, ok := strings.CutPrefix(tokenFile, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("expanding %s: %w", tokenFile, err)
+		}
+		tokenFile = filepath.Join(home, rest)
+	}
+
+	tok, err := readTokenFrom(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("token_file %s: %w", tokenFile, err)
+	}
+	return tok, nil
+}
@@ -0,0 +1,55 @@
+package token
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExternal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent-token")
+	if err := os.WriteFile(path, []byte("s.fromfile\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(EnvVar, "")
+	tok, err := External(path)
+	if err != nil || tok != "s.fromfile" {
+		t.Errorf("External(file) = %q, %v; want s.fromfile", tok, err)
+	}
+
+	t.Setenv(EnvVar, "s.fromenv")
+	tok, err = External(path)
+	if err != nil || tok != "s.fromenv" {
+		t.Errorf("External() = %q, %v; want VAULT_TOKEN to take precedence", tok, err)
+	}
+}
+
+func TestExternal_Missing(t *testing.T) {
+	t.Setenv(EnvVar, "")
+
+	if _, err := External(""); !errors.Is(err, ErrNoExternalToken) {
+		t.Errorf("External() error = %v, want %v with no token source", err, ErrNoExternalToken)
+	}
+	if _, err := External(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("External() expected error for a missing token file")
+	}
+}
+
+func TestFromFile_IgnoresEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent-token")
+	if err := os.WriteFile(path, []byte("s.fromfile\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(EnvVar, "s.fromenv")
+	tok, err := FromFile(path)
+	if err != nil || tok != "s.fromfile" {
+		t.Errorf("FromFile() = %q, %v; want s.fromfile", tok, err)
+	}
+
+	if _, err := FromFile(""); err == nil {
+		t.Error("FromFile(\"\") expected error")
+	}
+}
@@ -7,8 +7,8 @@ import (
 	"time"
 )
 
-// StartDaemonProcess spawns "vx daemon start" as a detached background process.
-// It returns the child PID on success. If the daemon is already running it
+// StartDaemonProcess spawns "vx daemon start" with args as a detached
+// background process. It returns the child PID on success. If the daemon is already running it
 // returns 0, nil.
 //
 // Note: there is a small TOCTOU window between the IsRunning check and the
@@ -16,26 +16,26 @@ import (
 // the child's Daemon.Start will detect the duplicate via its own IsRunning
 // check and exit. This is acceptable for a CLI tool; file-locking can be
 // added if contention becomes an issue.
-func StartDaemonProcess(vxBinary string) (int, error) {
+func StartDaemonProcess(vxBinary string, args ...string) (int, error) {
 	d := NewDaemon(nil) // only used for IsRunning check
 	if d.IsRunning() {
 		return 0, nil
 	}
 
-	logPath := LogPath()
+	outPath := OutputPath()
 	if err := os.MkdirAll(DefaultDir(), dirPerms); err != nil {
 		return 0, fmt.Errorf("create vx dir: %w", err)
 	}
 
-	logF, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePerms)
+	outF, err := os.OpenFile(outPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePerms)
 	if err != nil {
-		return 0, fmt.Errorf("open daemon log: %w", err)
+		return 0, fmt.Errorf("open daemon output: %w", err)
 	}
-	defer logF.Close()
+	defer outF.Close()
 
-	cmd := exec.Command(vxBinary, "daemon", "start")
-	cmd.Stdout = logF
-	cmd.Stderr = logF
+	cmd := exec.Command(vxBinary, append([]string{"daemon", "start"}, args...)...)
+	cmd.Stdout = outF
+	cmd.Stderr = outF
 	cmd.SysProcAttr = daemonSysProcAttr()
 
 	if err := cmd.Start(); err != nil {
@@ -52,7 +52,7 @@ func StartDaemonProcess(vxBinary string) (int, error) {
 	// Brief wait then verify the child is still alive.
 	time.Sleep(200 * time.Millisecond)
 	if !isProcessAlive(pid) {
-		return 0, fmt.Errorf("daemon process exited immediately (check %s)", logPath)
+		return 0, fmt.Errorf("daemon process exited immediately (check %s)", outPath)
 	}
 
 	return pid, nil
@@ -27,9 +27,9 @@ func TestStartDaemonProcess_AlreadyRunning(t *testing.T) {
 func TestStartDaemonProcess_BadBinary(t *testing.T) {
 	dir := t.TempDir()
 	pidPath := filepath.Join(dir, "daemon.pid")
-	logPath := filepath.Join(dir, "daemon.log")
+	outPath := filepath.Join(dir, "daemon.out")
 	overridePIDPath(t, pidPath)
-	overrideLogPath(t, logPath)
+	overrideOutputPath(t, outPath)
 	overrideDefaultDir(t, dir)
 
 	_, err := StartDaemonProcess("/nonexistent/vx-binary")
@@ -38,12 +38,12 @@ func TestStartDaemonProcess_BadBinary(t *testing.T) {
 	}
 }
 
-// overrideLogPath temporarily overrides LogPath for tests.
-func overrideLogPath(t *testing.T, path string) {
+// overrideOutputPath temporarily overrides OutputPath for tests.
+func overrideOutputPath(t *testing.T, path string) {
 	t.Helper()
-	orig := LogPath
-	LogPath = func() string { return path }
-	t.Cleanup(func() { LogPath = orig })
+	orig := OutputPath
+	OutputPath = func() string { return path }
+	t.Cleanup(func() { OutputPath = orig })
 }
 
 // overrideDefaultDir temporarily overrides DefaultDir for tests.
@@ -0,0 +1,161 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// tempPrefix starts the name of the temporary file a token is written to
+// before it replaces the token file.
+const tempPrefix = ".token-"
+
+// staleAfter is how old a temporary token file must be before it counts as
+// left behind rather than in the middle of being written.
+const staleAfter = time.Minute
+
+// PermissionIssue is a file or directory under ~/.vx that the group or
+// other users can access.
+type PermissionIssue struct {
+	Path string
+	Mode fs.FileMode
+	// Want is Mode without the group and other bits.
+	Want fs.FileMode
+	// Fixed is set when the mode was changed to Want.
+	Fixed bool
+}
+
+// CheckPermissions reports every directory and regular file under ~/.vx
+// whose mode lets the group or other users in. With fix set, those bits are
+// removed. A missing ~/.vx has no issues. On Windows, where file modes do
+// not describe access, it always reports none.
+func CheckPermissions(fix bool) ([]PermissionIssue, error) {
+	return checkPermissionsIn(DefaultDir(), fix)
+}
+
+func checkPermissionsIn(dir string, fix bool) ([]PermissionIssue, error) {
+	if runtime.GOOS == "windows" {
+		return nil, nil
+	}
+
+	var issues []PermissionIssue
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		// Sockets and symlinks are left alone: the daemon socket is
+		// protected by the directory.
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		mode := info.Mode().Perm()
+		if mode&0077 == 0 {
+			return nil
+		}
+
+		issue := PermissionIssue{Path: path, Mode: mode, Want: mode &^ 0077}
+		if fix {
+			if err := os.Chmod(path, issue.Want); err != nil {
+				return fmt.Errorf("restricting %s: %w", path, err)
+			}
+			issue.Fixed = true
+		}
+		issues = append(issues, issue)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checking permissions: %w", err)
+	}
+
+	return issues, nil
+}
+
+// RemoveStaleTokens deletes the temporary token files that interrupted
+// writes left behind in ~/.vx and ~/.vx/tokens, skipping any written in the
+// last minute. Each holds a token that a later write has replaced, so it is
+// called once a fresh token is in place. It returns the number of files
+// removed.
+func RemoveStaleTokens() (int, error) {
+	n, err := removeStaleTokensIn(filepath.Dir(TokenPath()), time.Now())
+	if err != nil {
+		return n, err
+	}
+	m, err := removeStaleTokensIn(TokensDir(), time.Now())
+	return n + m, err
+}
+
+// RemoveExpiredTokens deletes the stored tokens that Vault rejects as
+// expired or invalid: those of the recorded servers (see Addresses) other
+// than the one set with UseAddress, each checked with the renewer
+// newRenewer returns for it, and those of named, the renewers of the named
+// connections (see WithVaultName). It is called once a fresh token is in
+// place. A removed server's address is forgotten, so the daemon stops
+// renewing it. A token that cannot be checked, for example because its
+// server is unreachable, is kept. It returns the number of tokens removed.
+func RemoveExpiredTokens(ctx context.Context, newRenewer func(addr string) *TokenRenewer, named []*TokenRenewer) (int, error) {
+	addrs, err := Addresses()
+	if err != nil {
+		return 0, fmt.Errorf("remove expired tokens: %w", err)
+	}
+
+	renewers := slices.Clone(named)
+	for _, addr := range addrs {
+		if hostName(addr) != hostName(address) {
+			renewers = append(renewers, newRenewer(addr))
+		}
+	}
+
+	removed := 0
+	for _, r := range renewers {
+		if expired, err := r.Expired(ctx); err != nil || !expired {
+			continue
+		}
+		if err := r.removeToken(); err != nil {
+			return removed, fmt.Errorf("remove expired tokens: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func removeStaleTokensIn(dir string, now time.Time) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("remove stale tokens: %w", err)
+	}
+
+	removed := 0
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !strings.HasPrefix(e.Name(), tempPrefix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || now.Sub(info.ModTime()) < staleAfter {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, fmt.Errorf("remove stale tokens: %w", err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
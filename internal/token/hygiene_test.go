@@ -0,0 +1,204 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes do not describe access on Windows")
+	}
+
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	private := filepath.Join(dir, "token")
+	if err := os.WriteFile(private, []byte("s.ok\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	open := filepath.Join(dir, "state")
+	if err := os.WriteFile(open, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := checkPermissionsIn(dir, false)
+	if err != nil {
+		t.Fatalf("checkPermissionsIn() error = %v", err)
+	}
+	if len(issues) != 2 || issues[0].Path != dir || issues[1].Path != open {
+		t.Fatalf("issues = %+v, want the directory and %s", issues, open)
+	}
+	if issues[1].Want != 0600 || issues[1].Fixed {
+		t.Errorf("issue = %+v, want 0600 and not fixed", issues[1])
+	}
+
+	if _, err := checkPermissionsIn(dir, true); err != nil {
+		t.Fatalf("checkPermissionsIn(fix) error = %v", err)
+	}
+	info, err := os.Stat(open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("permissions after fix = %o, want 600", perm)
+	}
+
+	issues, err = checkPermissionsIn(dir, false)
+	if err != nil || len(issues) != 0 {
+		t.Errorf("after fix: issues = %+v, err = %v", issues, err)
+	}
+}
+
+func TestCheckPermissionsMissingDir(t *testing.T) {
+	issues, err := checkPermissionsIn(filepath.Join(t.TempDir(), "missing"), false)
+	if err != nil || len(issues) != 0 {
+		t.Errorf("checkPermissionsIn() = %v, %v; want no issues", issues, err)
+	}
+}
+
+func TestWriteTokenReplacesLooseFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes do not describe access on Windows")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+	if err := os.WriteFile(path, []byte("s.old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeTokenTo(path, "s.new"); err != nil {
+		t.Fatalf("writeTokenTo() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != filePerms {
+		t.Errorf("permissions = %o, want %o", perm, filePerms)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only the token", len(entries))
+	}
+}
+
+func TestRemoveStaleTokens(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"token", ".token-old", ".token-fresh", "state"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	old := now.Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, ".token-old"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := removeStaleTokensIn(dir, now)
+	if err != nil {
+		t.Fatalf("removeStaleTokensIn() error = %v", err)
+	}
+	if n != 1 {
+		t.Errorf("removed %d files, want 1", n)
+	}
+
+	for name, want := range map[string]bool{"token": true, ".token-old": false, ".token-fresh": true, "state": true} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s exists = %v, want %v", name, exists, want)
+		}
+	}
+
+	if n, err := removeStaleTokensIn(filepath.Join(dir, "missing"), now); n != 0 || err != nil {
+		t.Errorf("missing dir: removed %d, err = %v", n, err)
+	}
+}
+
+func TestRemoveExpiredTokens(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") == "s.expired" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":["permission denied"]}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"ttl":3600,"creation_ttl":7200,"renewable":true}}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	orig := DefaultDir
+	DefaultDir = func() string { return dir }
+	t.Cleanup(func() {
+		DefaultDir = orig
+		UseAddress("")
+	})
+
+	// Both names reach srv but count as different servers.
+	current := srv.URL
+	other := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+	unreachable := "http://127.0.0.1:1"
+	for addr, tok := range map[string]string{current: "s.expired", other: "s.expired", unreachable: "s.expired"} {
+		if err := WriteTokenFor(addr, tok); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, tok := range map[string]string{"ci": "s.expired", "ops": "s.valid"} {
+		if err := WriteVaultToken(name, tok); err != nil {
+			t.Fatal(err)
+		}
+	}
+	UseAddress(current)
+
+	newRenewer := func(addr string) *TokenRenewer { return NewTokenRenewer(addr) }
+	named := []*TokenRenewer{
+		NewTokenRenewer(srv.URL, WithVaultName("ci")),
+		NewTokenRenewer(srv.URL, WithVaultName("ops")),
+	}
+	n, err := RemoveExpiredTokens(context.Background(), newRenewer, named)
+	if err != nil {
+		t.Fatalf("RemoveExpiredTokens() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("removed %d tokens, want 2", n)
+	}
+
+	// The current server's token was just written by a login, and the
+	// unreachable server's could not be checked.
+	for addr, want := range map[string]bool{current: true, other: false, unreachable: true} {
+		if _, err := ReadTokenFor(addr); (err == nil) != want {
+			t.Errorf("token of %s kept = %v, want %v", addr, err == nil, want)
+		}
+	}
+	for name, want := range map[string]bool{"ci": false, "ops": true} {
+		if _, err := ReadVaultToken(name); (err == nil) != want {
+			t.Errorf("token of %s kept = %v, want %v", name, err == nil, want)
+		}
+	}
+
+	addrs, err := Addresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(addrs, other) {
+		t.Errorf("Addresses() = %v, still lists %s", addrs, other)
+	}
+}
@@ -0,0 +1,44 @@
+package token
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// errSecItemNotFound is the status security(1) exits with when there is no
+// matching item.
+const errSecItemNotFound = 44
+
+func keychainProbe() error {
+	_, err := exec.LookPath("security")
+	return err
+}
+
+func keychainGet(account string) (string, error) {
+	out, err := run("security", []string{"find-generic-password", "-s", keychainService, "-a", account, "-w"}, nil)
+	if code, _, ok := toolStatus(err); ok && code == errSecItemNotFound {
+		return "", errNotInKeychain
+	}
+	return string(out), err
+}
+
+// keychainSet adds or updates the item through security's interactive mode,
+// so the token is read from standard input and never shows up in the
+// process list.
+func keychainSet(account, token string) error {
+	if strings.ContainsAny(token, "\"\\\n") {
+		return fmt.Errorf("token contains characters the keychain command cannot quote")
+	}
+	cmd := fmt.Sprintf("add-generic-password -U -s %s -a %s -l \"vx Vault token\" -w \"%s\"\n", keychainService, account, token)
+	_, err := run("security", []string{"-i"}, []byte(cmd))
+	return err
+}
+
+func keychainDelete(account string) error {
+	_, err := run("security", []string{"delete-generic-password", "-s", keychainService, "-a", account}, nil)
+	if code, _, ok := toolStatus(err); ok && code == errSecItemNotFound {
+		return errNotInKeychain
+	}
+	return err
+}
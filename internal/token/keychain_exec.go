@@ -0,0 +1,57 @@
+//go:build !windows
+
+package token
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// run runs a keychain tool with input on its standard input and returns its
+// standard output. It is a variable so tests can stand in for the tool.
+var run = func(name string, args []string, input []byte) ([]byte, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("%s is not installed: %w", name, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, &toolError{name: name, code: exitErr.ExitCode(), msg: strings.TrimSpace(stderr.String())}
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// toolError is a keychain tool that exited with a failure status.
+type toolError struct {
+	name string
+	code int
+	msg  string
+}
+
+func (e *toolError) Error() string {
+	if e.msg != "" {
+		return e.name + ": " + e.msg
+	}
+	return fmt.Sprintf("%s: exit status %d", e.name, e.code)
+}
+
+// toolStatus returns the exit status and error output of a keychain tool
+// that failed with err, and false if the tool did not run to completion.
+func toolStatus(err error) (code int, msg string, ok bool) {
+	var te *toolError
+	if !errors.As(err, &te) {
+		return 0, "", false
+	}
+	return te.code, te.msg, true
+}
@@ -0,0 +1,41 @@
+//go:build !darwin && !windows
+
+package token
+
+import "errors"
+
+// Outside macOS and Windows the keychain is a Secret Service (GNOME
+// Keyring, KWallet, KeePassXC) reached through secret-tool from libsecret.
+
+func keychainProbe() error {
+	// A lookup that finds nothing exits 1 without a message; one that
+	// cannot reach a Secret Service says why.
+	_, err := keychainGet("probe")
+	if errors.Is(err, errNotInKeychain) {
+		return nil
+	}
+	return err
+}
+
+func keychainGet(account string) (string, error) {
+	out, err := run("secret-tool", []string{"lookup", "service", keychainService, "account", account}, nil)
+	if code, msg, ok := toolStatus(err); ok && code == 1 && msg == "" {
+		return "", errNotInKeychain
+	}
+	return string(out), err
+}
+
+// keychainSet stores the item with the token on standard input, so it never
+// shows up in the process list.
+func keychainSet(account, token string) error {
+	_, err := run("secret-tool", []string{"store", "--label=vx Vault token", "service", keychainService, "account", account}, []byte(token))
+	return err
+}
+
+func keychainDelete(account string) error {
+	_, err := run("secret-tool", []string{"clear", "service", keychainService, "account", account}, nil)
+	if code, msg, ok := toolStatus(err); ok && code == 1 && msg == "" {
+		return errNotInKeychain
+	}
+	return err
+}
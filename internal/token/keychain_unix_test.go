@@ -0,0 +1,109 @@
+//go:build !darwin && !windows
+
+package token
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSecretTool stands in for secret-tool with an in-memory keyring.
+func fakeSecretTool(t *testing.T) map[string]string {
+	t.Helper()
+	items := make(map[string]string)
+	orig := run
+	run = func(name string, args []string, input []byte) ([]byte, error) {
+		acct := args[len(args)-1]
+		switch args[0] {
+		case "store":
+			items[acct] = string(input)
+			return nil, nil
+		case "lookup":
+			if tok, ok := items[acct]; ok {
+				return []byte(tok), nil
+			}
+		case "clear":
+			if _, ok := items[acct]; ok {
+				delete(items, acct)
+				return nil, nil
+			}
+		}
+		return nil, &toolError{name: name, code: 1}
+	}
+	t.Cleanup(func() {
+		run = orig
+		UseFile()
+	})
+	return items
+}
+
+func TestUseKeychain(t *testing.T) {
+	dir := t.TempDir()
+	orig := DefaultDir
+	DefaultDir = func() string { return dir }
+	t.Cleanup(func() { DefaultDir = orig })
+
+	items := fakeSecretTool(t)
+	if err := writeTokenTo(TokenPath(), "s.from-file"); err != nil {
+		t.Fatal(err)
+	}
+	if err := UseKeychain(); err != nil {
+		t.Fatalf("UseKeychain() error = %v", err)
+	}
+	if got := Location(); !strings.Contains(got, "keychain") {
+		t.Errorf("Location() = %q", got)
+	}
+
+	if got, err := ReadToken(); err != nil || got != "s.from-file" {
+		t.Errorf("ReadToken() before a login = %q, %v; want the sink file's token", got, err)
+	}
+
+	if err := WriteToken("s.login"); err != nil {
+		t.Fatalf("WriteToken() error = %v", err)
+	}
+	if items["token"] != "s.login" {
+		t.Errorf("keychain items = %v", items)
+	}
+	if _, err := os.Stat(TokenPath()); !os.IsNotExist(err) {
+		t.Errorf("sink file left behind after writing to the keychain: %v", err)
+	}
+	if got, err := ReadToken(); err != nil || got != "s.login" {
+		t.Errorf("ReadToken() = %q, %v", got, err)
+	}
+
+	if err := WriteVaultToken("data", "s.data"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ReadVaultToken("data"); err != nil || got != "s.data" {
+		t.Errorf("ReadVaultToken() = %q, %v", got, err)
+	}
+
+	if err := RemoveToken(); err != nil {
+		t.Fatalf("RemoveToken() error = %v", err)
+	}
+	if err := RemoveToken(); err != nil {
+		t.Errorf("RemoveToken() without a token error = %v", err)
+	}
+	if _, err := ReadToken(); err == nil {
+		t.Error("ReadToken() after RemoveToken() returned no error")
+	}
+	if items["token-data"] != "s.data" {
+		t.Errorf("RemoveToken() touched another connection's token: %v", items)
+	}
+}
+
+func TestUseKeychain_Unavailable(t *testing.T) {
+	fakeSecretTool(t)
+	run = func(name string, args []string, input []byte) ([]byte, error) {
+		return nil, &toolError{name: name, code: 1, msg: "Cannot autolaunch D-Bus without X11 $DISPLAY"}
+	}
+
+	if err := UseKeychain(); err == nil {
+		t.Fatal("UseKeychain() error = nil, want the Secret Service error")
+	}
+	if got := Location(); got != filepath.Join(DefaultDir(), tokenFile) {
+		t.Errorf("Location() = %q, want the sink file", got)
+	}
+}
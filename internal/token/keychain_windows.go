@@ -0,0 +1,114 @@
+package token
+
+import (
+	"errors"
+	"os/user"
+	"syscall"
+	"unsafe"
+)
+
+// On Windows the keychain is the Credential Manager; each token is a generic
+// credential targeted "vx:<account>".
+
+var (
+	advapi32        = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW   = advapi32.NewProc("CredReadW")
+	procCredWriteW  = advapi32.NewProc("CredWriteW")
+	procCredDeleteW = advapi32.NewProc("CredDeleteW")
+	procCredFree    = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+// credential mirrors the Win32 CREDENTIALW structure.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+func keychainProbe() error {
+	return procCredReadW.Find()
+}
+
+func credTarget(account string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(keychainService + ":" + account)
+}
+
+func keychainGet(account string) (string, error) {
+	target, err := credTarget(account)
+	if err != nil {
+		return "", err
+	}
+
+	var c *credential
+	r, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&c)))
+	if r == 0 {
+		if errors.Is(err, errorNotFound) {
+			return "", errNotInKeychain
+		}
+		return "", err
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(c)))
+
+	return string(unsafe.Slice(c.CredentialBlob, c.CredentialBlobSize)), nil
+}
+
+func keychainSet(account, token string) error {
+	if token == "" {
+		return errors.New("token is empty")
+	}
+	target, err := credTarget(account)
+	if err != nil {
+		return err
+	}
+	name := "vx"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	userName, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+
+	blob := []byte(token)
+	c := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		CredentialBlob:     &blob[0],
+		Persist:            credPersistLocalMachine,
+		UserName:           userName,
+	}
+	if r, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&c)), 0); r == 0 {
+		return err
+	}
+	return nil
+}
+
+func keychainDelete(account string) error {
+	target, err := credTarget(account)
+	if err != nil {
+		return err
+	}
+	if r, _, err := procCredDeleteW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0); r == 0 {
+		if errors.Is(err, errorNotFound) {
+			return errNotInKeychain
+		}
+		return err
+	}
+	return nil
+}
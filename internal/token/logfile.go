@@ -0,0 +1,181 @@
+package token
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxLogSize is the size at which the daemon log is rotated.
+	MaxLogSize = 5 << 20
+	// logBackups is the number of rotated logs kept next to the current one,
+	// as daemon.log.1 (the newest) to daemon.log.3.
+	logBackups = 3
+)
+
+// LogFile is an append-only log that rotates itself once it grows past a
+// size limit, keeping a few earlier files. It is safe for concurrent use;
+// each Write is kept whole in one file.
+type LogFile struct {
+	path    string
+	maxSize int64
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// OpenLogFile opens the log at path for appending, creating it and its
+// directory if needed. It is rotated when a write would take it past
+// maxSize bytes.
+func OpenLogFile(path string, maxSize int64) (*LogFile, error) {
+	l := &LogFile{path: path, maxSize: maxSize}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *LogFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), dirPerms); err != nil {
+		return fmt.Errorf("create log dir: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePerms)
+	if err != nil {
+		return fmt.Errorf("open daemon log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("open daemon log: %w", err)
+	}
+	l.f, l.size = f, info.Size()
+	return nil
+}
+
+// Write appends p, rotating the log first if p would not fit.
+func (l *LogFile) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.size > 0 && l.size+int64(len(p)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := l.f.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups along, dropping the oldest, moves the current
+// log to path.1, and starts a new one. When the log cannot be moved, as on
+// Windows while another process has it open, it keeps appending and tries
+// again after another maxSize bytes.
+func (l *LogFile) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return fmt.Errorf("rotate daemon log: %w", err)
+	}
+	for i := logBackups - 1; i > 0; i-- {
+		_ = os.Rename(backupPath(l.path, i), backupPath(l.path, i+1))
+	}
+	moved := os.Rename(l.path, backupPath(l.path, 1)) == nil
+	if err := l.open(); err != nil {
+		return err
+	}
+	if !moved {
+		l.size = 0
+	}
+	return nil
+}
+
+// Close closes the log.
+func (l *LogFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+// backupPath returns the path of the nth rotated log.
+func backupPath(path string, n int) string {
+	return path + "." + strconv.Itoa(n)
+}
+
+// LogEntry is an error recorded in the daemon log.
+type LogEntry struct {
+	Time    time.Time
+	Message string
+	Err     string
+}
+
+// logLine holds the fields read from one structured (JSON) log line.
+type logLine struct {
+	Level   string          `json:"level"`
+	Time    json.RawMessage `json:"time"`
+	Message string          `json:"message"`
+	Error   string          `json:"error"`
+}
+
+// LastLogError returns the last error-level entry of the daemon log at
+// path, looking at the most recent rotated file when the current one has
+// none. Lines that are not structured, such as a crash's output captured by
+// the service manager, are skipped. ok is false when no error was logged.
+func LastLogError(path string) (entry LogEntry, ok bool, err error) {
+	for _, p := range []string{path, backupPath(path, 1)} {
+		entry, ok, err = lastErrorIn(p)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return LogEntry{}, false, err
+		}
+		if ok {
+			return entry, true, nil
+		}
+	}
+	return LogEntry{}, false, nil
+}
+
+func lastErrorIn(path string) (LogEntry, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return LogEntry{}, false, err
+	}
+	defer f.Close()
+
+	var last LogEntry
+	found := false
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		var line logLine
+		if json.Unmarshal(sc.Bytes(), &line) != nil || line.Level != "error" {
+			continue
+		}
+		last = LogEntry{Time: parseLogTime(line.Time), Message: line.Message, Err: line.Error}
+		found = true
+	}
+	if err := sc.Err(); err != nil {
+		return LogEntry{}, false, fmt.Errorf("read daemon log: %w", err)
+	}
+	return last, found, nil
+}
+
+// parseLogTime reads a log line's time, written as RFC 3339 or as Unix
+// seconds. It returns the zero time when there is none.
+func parseLogTime(raw json.RawMessage) time.Time {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		t, _ := time.Parse(time.RFC3339, s)
+		return t
+	}
+	var sec float64
+	if json.Unmarshal(raw, &sec) == nil {
+		return time.Unix(int64(sec), 0)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,94 @@
+package token
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogFile_Rotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "daemon.log")
+	l, err := OpenLogFile(path, 25)
+	if err != nil {
+		t.Fatalf("OpenLogFile() error = %v", err)
+	}
+	defer l.Close()
+
+	// Eleven bytes each, so two fit in a file.
+	for i := 1; i <= 9; i++ {
+		if _, err := fmt.Fprintf(l, "log line %d\n", i); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	want := map[string]string{
+		path:                "log line 9\n",
+		backupPath(path, 1): "log line 7\nlog line 8\n",
+		backupPath(path, 2): "log line 5\nlog line 6\n",
+		backupPath(path, 3): "log line 3\nlog line 4\n",
+		backupPath(path, 4): "",
+	}
+	for p, content := range want {
+		data, err := os.ReadFile(p)
+		if content == "" {
+			if !os.IsNotExist(err) {
+				t.Errorf("%s exists, want at most %d backups", filepath.Base(p), logBackups)
+			}
+			continue
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(p), data, content)
+		}
+	}
+}
+
+func TestLogFile_AppendsToExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	os.WriteFile(path, []byte("0123456789\n"), 0600)
+
+	l, err := OpenLogFile(path, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Write([]byte("abcdefgh\n"))
+	l.Close()
+
+	if data, _ := os.ReadFile(backupPath(path, 1)); string(data) != "0123456789\n" {
+		t.Errorf("rotated log = %q, want the existing content counted against the limit", data)
+	}
+}
+
+func TestLastLogError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.log")
+
+	if _, ok, err := LastLogError(path); ok || err != nil {
+		t.Errorf("LastLogError() with no log = %v, %v; want nothing", ok, err)
+	}
+
+	os.WriteFile(backupPath(path, 1), []byte(`{"level":"error","time":1700000000,"message":"old"}`+"\n"), 0600)
+	os.WriteFile(path, []byte(strings.Join([]string{
+		`{"level":"info","time":"2026-10-16T09:00:00Z","message":"daemon started"}`,
+		`{"level":"error","error":"permission denied","time":"2026-10-16T09:05:00Z","message":"token renewal failed"}`,
+		`panic: something went wrong`,
+		`{"level":"info","time":"2026-10-16T09:10:00Z","message":"token renewal recovered"}`,
+	}, "\n")+"\n"), 0600)
+
+	entry, ok, err := LastLogError(path)
+	if err != nil || !ok {
+		t.Fatalf("LastLogError() = %v, %v", ok, err)
+	}
+	want := LogEntry{Time: time.Date(2026, 10, 16, 9, 5, 0, 0, time.UTC), Message: "token renewal failed", Err: "permission denied"}
+	if !entry.Time.Equal(want.Time) || entry.Message != want.Message || entry.Err != want.Err {
+		t.Errorf("LastLogError() = %+v, want %+v", entry, want)
+	}
+
+	// Falls back to the rotated log.
+	os.WriteFile(path, []byte(`{"level":"info","message":"daemon started"}`+"\n"), 0600)
+	entry, ok, _ = LastLogError(path)
+	if !ok || entry.Message != "old" || entry.Time.Unix() != 1700000000 {
+		t.Errorf("LastLogError() = %+v, %v; want the error from daemon.log.1", entry, ok)
+	}
+}
@@ -0,0 +1,94 @@
+package token
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const metadataFile = "token.json"
+
+// Metadata describes the token in the sink file as reported when it was
+// issued, so commands can show what a machine token may do without asking
+// Vault. It is stored next to the token (~/.vx/token.json).
+type Metadata struct {
+	AuthMethod string `json:"auth_method"`
+	// Role is the role the login used, e.g. the AppRole role name.
+	Role      string    `json:"role,omitempty"`
+	Accessor  string    `json:"accessor,omitempty"`
+	Policies  []string  `json:"policies"`
+	TTL       Seconds   `json:"ttl_seconds"`
+	Renewable bool      `json:"renewable"`
+	Orphan    bool      `json:"orphan"`
+	IssuedAt  time.Time `json:"issued_at"`
+	// TokenHash ties the metadata to one token, so it is ignored once the
+	// sink holds a token from another login.
+	TokenHash string `json:"token_sha256"`
+}
+
+// Seconds is a duration stored as whole seconds.
+type Seconds int64
+
+// Duration returns s as a time.Duration.
+func (s Seconds) Duration() time.Duration {
+	return time.Duration(s) * time.Second
+}
+
+// MetadataPath returns the path to the token metadata file
+// (~/.vx/token.json).
+var MetadataPath = func() string {
+	return filepath.Join(DefaultDir(), metadataFile)
+}
+
+// WriteMetadata stores m as the metadata of tok, with 0600 permissions.
+func WriteMetadata(tok string, m Metadata) error {
+	m.TokenHash = tokenHash(tok)
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("write token metadata: %w", err)
+	}
+
+	path := MetadataPath()
+	if err := os.MkdirAll(filepath.Dir(path), dirPerms); err != nil {
+		return fmt.Errorf("write token metadata: create directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), filePerms); err != nil {
+		return fmt.Errorf("write token metadata: %w", err)
+	}
+	return nil
+}
+
+// ReadMetadata returns the stored metadata of tok, or false when there is
+// none or it belongs to another token.
+func ReadMetadata(tok string) (*Metadata, bool) {
+	data, err := os.ReadFile(MetadataPath())
+	if err != nil {
+		return nil, false
+	}
+
+	var m Metadata
+	if err := json.Unmarshal(data, &m); err != nil || m.TokenHash != tokenHash(tok) {
+		return nil, false
+	}
+	return &m, true
+}
+
+// RemoveMetadata removes the token metadata file. Returns nil if the file
+// does not exist.
+func RemoveMetadata() error {
+	err := os.Remove(MetadataPath())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove token metadata: %w", err)
+	}
+	return nil
+}
+
+// tokenHash returns the hex SHA-256 of tok.
+func tokenHash(tok string) string {
+	sum := sha256.Sum256([]byte(tok))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,60 @@
+package token
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vx", "token.json")
+	orig := MetadataPath
+	MetadataPath = func() string { return path }
+	t.Cleanup(func() { MetadataPath = orig })
+
+	if _, ok := ReadMetadata("s.abc"); ok {
+		t.Fatal("ReadMetadata() found metadata before any was written")
+	}
+
+	issued := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := Metadata{
+		AuthMethod: "approle",
+		Role:       "ci",
+		Policies:   []string{"default", "deploy"},
+		TTL:        3600,
+		Orphan:     true,
+		IssuedAt:   issued,
+	}
+	if err := WriteMetadata("s.abc", m); err != nil {
+		t.Fatalf("WriteMetadata() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != filePerms {
+		t.Errorf("file permissions = %o, want %o", perm, filePerms)
+	}
+
+	got, ok := ReadMetadata("s.abc")
+	if !ok {
+		t.Fatal("ReadMetadata() found nothing")
+	}
+	if got.Role != "ci" || !slices.Equal(got.Policies, m.Policies) || got.TTL.Duration() != time.Hour ||
+		!got.Orphan || !got.IssuedAt.Equal(issued) {
+		t.Errorf("ReadMetadata() = %+v", got)
+	}
+
+	if _, ok := ReadMetadata("s.other"); ok {
+		t.Error("ReadMetadata() returned the metadata of another token")
+	}
+
+	if err := RemoveMetadata(); err != nil {
+		t.Fatalf("RemoveMetadata() error = %v", err)
+	}
+	if err := RemoveMetadata(); err != nil {
+		t.Errorf("RemoveMetadata() on a missing file error = %v", err)
+	}
+}
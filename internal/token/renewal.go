@@ -3,6 +3,7 @@ package token
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -11,12 +12,19 @@ import (
 
 const defaultCheckInterval = 60 * time.Second
 
+// errTokenRejected is returned by lookups that Vault denies, which it does
+// for expired, revoked, and unknown tokens.
+var errTokenRejected = errors.New("token is invalid or expired")
+
 // TokenRenewer handles automatic renewal of Vault tokens before they expire.
 type TokenRenewer struct {
 	vaultAddr     string
 	tokenPath     string
+	vaultName     string
 	checkInterval time.Duration
 	httpClient    *http.Client
+	headers       http.Header
+	namespace     string
 }
 
 // RenewerOption configures a TokenRenewer.
@@ -29,13 +37,38 @@ func WithCheckInterval(d time.Duration) RenewerOption {
 	}
 }
 
-// WithTokenPath overrides the default token sink file path.
+// WithTokenPath makes the renewer read and write the token in the file at
+// path instead of the token store.
 func WithTokenPath(path string) RenewerOption {
 	return func(r *TokenRenewer) {
 		r.tokenPath = path
 	}
 }
 
+// WithVaultName makes the renewer read and write the token of the named
+// Vault connection from [vaults] instead of the server's.
+func WithVaultName(name string) RenewerOption {
+	return func(r *TokenRenewer) {
+		r.vaultName = name
+	}
+}
+
+// WithRequestHeaders adds extra headers (e.g. correlation and trace headers)
+// to every Vault request made by the renewer.
+func WithRequestHeaders(h http.Header) RenewerOption {
+	return func(r *TokenRenewer) {
+		r.headers = h.Clone()
+	}
+}
+
+// WithNamespace sends the renewer's requests to the given Vault Enterprise
+// namespace, the one the token was issued in.
+func WithNamespace(ns string) RenewerOption {
+	return func(r *TokenRenewer) {
+		r.namespace = ns
+	}
+}
+
 // withHTTPClient overrides the HTTP client used for Vault API calls. This is
 // intended for testing only.
 func withHTTPClient(c *http.Client) RenewerOption {
@@ -48,7 +81,6 @@ func withHTTPClient(c *http.Client) RenewerOption {
 func NewTokenRenewer(vaultAddr string, opts ...RenewerOption) *TokenRenewer {
 	r := &TokenRenewer{
 		vaultAddr:     strings.TrimRight(vaultAddr, "/"),
-		tokenPath:     TokenPath(),
 		checkInterval: defaultCheckInterval,
 		httpClient:    &http.Client{Timeout: 30 * time.Second},
 	}
@@ -60,6 +92,43 @@ func NewTokenRenewer(vaultAddr string, opts ...RenewerOption) *TokenRenewer {
 	return r
 }
 
+// readToken reads the token being renewed.
+func (r *TokenRenewer) readToken() (string, error) {
+	switch {
+	case r.tokenPath != "":
+		return readTokenFrom(r.tokenPath)
+	case r.vaultName != "":
+		return ReadVaultToken(r.vaultName)
+	}
+	return ReadTokenFor(r.vaultAddr)
+}
+
+// writeToken replaces the token being renewed.
+func (r *TokenRenewer) writeToken(tok string) error {
+	switch {
+	case r.tokenPath != "":
+		return writeTokenTo(r.tokenPath, tok)
+	case r.vaultName != "":
+		return WriteVaultToken(r.vaultName, tok)
+	}
+	return WriteTokenFor(r.vaultAddr, tok)
+}
+
+// removeToken removes the token being renewed, and forgets a server's
+// address so the daemon stops renewing it.
+func (r *TokenRenewer) removeToken() error {
+	switch {
+	case r.tokenPath != "":
+		return removeTokenAt(r.tokenPath)
+	case r.vaultName != "":
+		return store.Remove(r.vaultName)
+	}
+	if err := store.Remove(addressKey(r.vaultAddr)); err != nil {
+		return err
+	}
+	return forgetAddress(r.vaultAddr)
+}
+
 // tokenLookupResponse represents the relevant fields from Vault's
 // auth/token/lookup-self response.
 type tokenLookupResponse struct {
@@ -75,48 +144,74 @@ type tokenLookupResponse struct {
 // auth/token/renew-self response.
 type tokenRenewResponse struct {
 	Auth struct {
-		ClientToken string `json:"client_token"`
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
 	} `json:"auth"`
 }
 
+// TokenState is what a renewal check learned about the token.
+type TokenState struct {
+	// TTL is the token's remaining lifetime after the check.
+	TTL time.Duration
+	// CreationTTL is the lifetime the token was issued with.
+	CreationTTL time.Duration
+	// Renewed is true when the check renewed the token.
+	Renewed bool
+}
+
 // RenewOnce performs a single renewal check. It reads the current token, looks
 // up its TTL, and renews it if the remaining TTL is below 50% of the max TTL.
 // Returns nil if no renewal was needed.
 func (r *TokenRenewer) RenewOnce(ctx context.Context) error {
-	tok, err := readTokenFrom(r.tokenPath)
+	_, err := r.Check(ctx)
+	return err
+}
+
+// Check performs a single renewal check like RenewOnce, and reports the
+// token's TTLs and whether it was renewed.
+func (r *TokenRenewer) Check(ctx context.Context) (TokenState, error) {
+	tok, err := r.readToken()
 	if err != nil {
-		return fmt.Errorf("renew: %w", err)
+		return TokenState{}, fmt.Errorf("renew: %w", err)
 	}
 
 	lookup, err := r.lookupToken(ctx, tok)
 	if err != nil {
-		return fmt.Errorf("renew: lookup: %w", err)
+		return TokenState{}, fmt.Errorf("renew: lookup: %w", err)
 	}
 
+	state := TokenState{
+		TTL:         time.Duration(lookup.Data.TTL) * time.Second,
+		CreationTTL: time.Duration(lookup.Data.CreationTTL) * time.Second,
+	}
 	if !lookup.Data.Renewable {
-		return nil
+		return state, nil
 	}
 
 	if !needsRenewal(lookup.Data.TTL, lookup.Data.CreationTTL) {
-		return nil
+		return state, nil
 	}
 
-	newToken, err := r.renewToken(ctx, tok)
+	renewed, err := r.renewToken(ctx, tok)
 	if err != nil {
-		return fmt.Errorf("renew: renew-self: %w", err)
+		return state, fmt.Errorf("renew: renew-self: %w", err)
 	}
 
-	if err := writeTokenTo(r.tokenPath, newToken); err != nil {
-		return fmt.Errorf("renew: write: %w", err)
+	if err := r.writeToken(renewed.Auth.ClientToken); err != nil {
+		return state, fmt.Errorf("renew: write: %w", err)
 	}
 
-	return nil
+	state.Renewed = true
+	if renewed.Auth.LeaseDuration > 0 {
+		state.TTL = time.Duration(renewed.Auth.LeaseDuration) * time.Second
+	}
+	return state, nil
 }
 
 // NeedsReauth reports whether the token is missing, empty, or expired and
 // cannot be renewed (requiring a full re-authentication).
 func (r *TokenRenewer) NeedsReauth() bool {
-	tok, err := readTokenFrom(r.tokenPath)
+	tok, err := r.readToken()
 	if err != nil || tok == "" {
 		return true
 	}
@@ -132,6 +227,25 @@ func (r *TokenRenewer) NeedsReauth() bool {
 	return lookup.Data.TTL <= 0 && lookup.Data.ExpireTime != nil
 }
 
+// Expired reports whether Vault rejects the token as expired or invalid, or
+// reports it expired. A missing token is not expired, and a lookup that
+// fails for another reason, such as an unreachable server, is an error.
+func (r *TokenRenewer) Expired(ctx context.Context) (bool, error) {
+	tok, err := r.readToken()
+	if err != nil {
+		return false, nil
+	}
+
+	lookup, err := r.lookupToken(ctx, tok)
+	if errors.Is(err, errTokenRejected) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("lookup: %w", err)
+	}
+	return lookup.Data.TTL <= 0 && lookup.Data.ExpireTime != nil, nil
+}
+
 // needsRenewal returns true when the remaining TTL is below the renewal
 // threshold. When creationTTL is known (> 0) the threshold is 50% of the
 // original lease. When creationTTL is unknown (0) we always renew — Vault
@@ -156,7 +270,7 @@ func (r *TokenRenewer) lookupToken(ctx context.Context, tok string) (*tokenLooku
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 
-	req.Header.Set("X-Vault-Token", tok)
+	r.setHeaders(req, tok)
 
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
@@ -164,6 +278,9 @@ func (r *TokenRenewer) lookupToken(ctx context.Context, tok string) (*tokenLooku
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("unexpected status %d: %w", resp.StatusCode, errTokenRejected)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
 	}
@@ -176,36 +293,50 @@ func (r *TokenRenewer) lookupToken(ctx context.Context, tok string) (*tokenLooku
 	return &result, nil
 }
 
+// setHeaders applies the token, the namespace, and any configured extra
+// headers to req.
+func (r *TokenRenewer) setHeaders(req *http.Request, tok string) {
+	for name, values := range r.headers {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	if r.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", r.namespace)
+	}
+	req.Header.Set("X-Vault-Token", tok)
+}
+
 // renewToken calls Vault's auth/token/renew-self endpoint and returns the new
-// client token.
-func (r *TokenRenewer) renewToken(ctx context.Context, tok string) (string, error) {
+// client token and its lease duration.
+func (r *TokenRenewer) renewToken(ctx context.Context, tok string) (*tokenRenewResponse, error) {
 	url := r.vaultAddr + "/v1/auth/token/renew-self"
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
 	if err != nil {
-		return "", fmt.Errorf("create request: %w", err)
+		return nil, fmt.Errorf("create request: %w", err)
 	}
 
-	req.Header.Set("X-Vault-Token", tok)
+	r.setHeaders(req, tok)
 
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("http request: %w", err)
+		return nil, fmt.Errorf("http request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
 	}
 
 	var result tokenRenewResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("decode response: %w", err)
+		return nil, fmt.Errorf("decode response: %w", err)
 	}
 
 	if result.Auth.ClientToken == "" {
-		return "", fmt.Errorf("empty client token in response")
+		return nil, fmt.Errorf("empty client token in response")
 	}
 
-	return result.Auth.ClientToken, nil
+	return &result, nil
 }
@@ -163,6 +163,38 @@ func TestRenewOnce_VaultTokenHeader(t *testing.T) {
 	renewer.RenewOnce(context.Background())
 }
 
+func TestRenewOnce_ExtraRequestHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Correlation-Id"); got != "corr-1" {
+			t.Errorf("X-Correlation-Id = %q, want %q", got, "corr-1")
+		}
+		if got := r.Header.Get("X-Vault-Token"); got != "s.extra" {
+			t.Errorf("X-Vault-Token = %q, want %q", got, "s.extra")
+		}
+		if got := r.Header.Get("X-Vault-Namespace"); got != "team-a" {
+			t.Errorf("X-Vault-Namespace = %q, want %q", got, "team-a")
+		}
+		resp := tokenLookupResponse{}
+		resp.Data.TTL = 50000
+		resp.Data.CreationTTL = 86400
+		resp.Data.Renewable = true
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "token")
+	writeTokenTo(tokenPath, "s.extra")
+
+	headers := http.Header{}
+	headers.Set("X-Correlation-Id", "corr-1")
+
+	renewer := NewTokenRenewer(srv.URL, WithTokenPath(tokenPath), WithRequestHeaders(headers), WithNamespace("team-a"))
+	if err := renewer.RenewOnce(context.Background()); err != nil {
+		t.Fatalf("RenewOnce() error = %v", err)
+	}
+}
+
 func TestNeedsReauth_MissingToken(t *testing.T) {
 	dir := t.TempDir()
 	tokenPath := filepath.Join(dir, "nonexistent")
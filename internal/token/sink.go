@@ -13,6 +13,8 @@ const (
 	pidFile    = "daemon.pid"
 	socketFile = "daemon.sock"
 	logFile    = "daemon.log"
+	outputFile = "daemon.out"
+	tokensDir  = "tokens"
 	dirPerms   = 0700
 	filePerms  = 0600
 )
@@ -34,6 +36,18 @@ var TokenPath = func() string {
 	return filepath.Join(DefaultDir(), tokenFile)
 }
 
+// VaultTokenPath returns the path to the token sink file of a named Vault
+// connection from [vaults] (~/.vx/token-<name>).
+var VaultTokenPath = func(name string) string {
+	return filepath.Join(DefaultDir(), tokenFile+"-"+name)
+}
+
+// TokensDir returns the directory of the per-address token sinks
+// (~/.vx/tokens).
+var TokensDir = func() string {
+	return filepath.Join(DefaultDir(), tokensDir)
+}
+
 // PIDPath returns the path to the daemon PID file (~/.vx/daemon.pid).
 var PIDPath = func() string {
 	return filepath.Join(DefaultDir(), pidFile)
@@ -49,23 +63,76 @@ var LogPath = func() string {
 	return filepath.Join(DefaultDir(), logFile)
 }
 
-// ReadToken reads the Vault token from the sink file. Returns an error if the
-// file does not exist or the token is empty.
+// OutputPath returns the path that receives the daemon's stdout and stderr
+// (~/.vx/daemon.out). It is separate from the log because the daemon
+// rotates the log itself, which would leave an appending redirect writing
+// to a rotated file.
+var OutputPath = func() string {
+	return filepath.Join(DefaultDir(), outputFile)
+}
+
+// ReadToken reads the Vault token of the server set with UseAddress from
+// the token store (by default the sink file). Returns an error if there is
+// no token or it is empty.
 func ReadToken() (string, error) {
-	return readTokenFrom(TokenPath())
+	return ReadTokenFor(address)
 }
 
-// WriteToken writes the Vault token to the sink file with 0600 permissions.
-// The parent directory (~/.vx) is created with 0700 permissions if it does not
-// exist.
+// WriteToken writes the Vault token of the server set with UseAddress to the
+// token store. The sink file is written with 0600 permissions and its parent
+// directory is created with 0700 permissions if it does not exist.
 func WriteToken(token string) error {
-	return writeTokenTo(TokenPath(), token)
+	return WriteTokenFor(address, token)
+}
+
+// ReadTokenFor reads the token of the Vault server at addr from the token
+// store. It never returns the token of another server: a token from before
+// tokens were kept per server (~/.vx/token) is only read once
+// MigrateLegacyToken has moved it to the server it was issued for. An empty
+// addr reads ~/.vx/token.
+func ReadTokenFor(addr string) (string, error) {
+	if addr == "" {
+		return store.Read("")
+	}
+	return store.Read(addressKey(addr))
+}
+
+// WriteTokenFor writes the token of the Vault server at addr to the token
+// store and records addr, so the daemon renews it. Tokens of other servers
+// are left alone. An empty addr writes ~/.vx/token.
+func WriteTokenFor(addr, token string) error {
+	if addr == "" {
+		return store.Write("", token)
+	}
+	if err := store.Write(addressKey(addr), token); err != nil {
+		return err
+	}
+	return recordAddress(addr)
+}
+
+// ReadVaultToken reads the token of a named Vault connection from the token
+// store.
+func ReadVaultToken(name string) (string, error) {
+	return store.Read(name)
 }
 
-// RemoveToken removes the token sink file. Returns nil if the file does not
-// exist.
+// WriteVaultToken writes the token of a named Vault connection to the token
+// store, like WriteToken.
+func WriteVaultToken(name string, token string) error {
+	return store.Write(name, token)
+}
+
+// RemoveToken removes the Vault token of the server set with UseAddress
+// from the token store, and the server from the address list so the daemon
+// stops renewing it. Returns nil if there is none.
 func RemoveToken() error {
-	return removeTokenAt(TokenPath())
+	if address == "" {
+		return store.Remove("")
+	}
+	if err := store.Remove(addressKey(address)); err != nil {
+		return err
+	}
+	return forgetAddress(address)
 }
 
 // readTokenFrom reads a token from the given path.
@@ -84,7 +151,9 @@ func readTokenFrom(path string) (string, error) {
 }
 
 // writeTokenTo writes a token to the given path, creating the parent directory
-// if necessary.
+// if necessary. The token goes to a temporary file that then replaces path,
+// so readers never see a partial token and a file left with looser
+// permissions by an older version is not reused.
 func writeTokenTo(path string, token string) error {
 	dir := filepath.Dir(path)
 
@@ -92,7 +161,25 @@ func writeTokenTo(path string, token string) error {
 		return fmt.Errorf("write token: create directory: %w", err)
 	}
 
-	if err := os.WriteFile(path, []byte(token+"\n"), filePerms); err != nil {
+	f, err := os.CreateTemp(dir, tempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("write token: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if err := f.Chmod(filePerms); err != nil {
+		f.Close()
+		return fmt.Errorf("write token: %w", err)
+	}
+	if _, err := f.WriteString(token + "\n"); err != nil {
+		f.Close()
+		return fmt.Errorf("write token: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write token: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
 		return fmt.Errorf("write token: %w", err)
 	}
 
@@ -162,3 +162,23 @@ func TestReadTokenTrimsWhitespace(t *testing.T) {
 		t.Errorf("readTokenFrom() = %q, want %q", got, "s.padded")
 	}
 }
+
+func TestVaultToken(t *testing.T) {
+	dir := t.TempDir()
+	overrideDefaultDir(t, dir)
+
+	if err := WriteVaultToken("data", "s.data"); err != nil {
+		t.Fatalf("WriteVaultToken() error = %v", err)
+	}
+
+	got, err := ReadVaultToken("data")
+	if err != nil || got != "s.data" {
+		t.Errorf("ReadVaultToken() = %q, %v; want s.data", got, err)
+	}
+	if got := VaultTokenPath("data"); got != filepath.Join(dir, "token-data") {
+		t.Errorf("VaultTokenPath() = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "token")); !os.IsNotExist(err) {
+		t.Errorf("default token file written: %v", err)
+	}
+}
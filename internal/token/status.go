@@ -0,0 +1,101 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ErrNoDaemon is returned by QueryStatus when no daemon answers on the
+// socket.
+var ErrNoDaemon = errors.New("daemon: not answering")
+
+// statusResponse is the body of GET /v1/status on the daemon socket.
+// Durations are in seconds.
+type statusResponse struct {
+	PID                 int       `json:"pid"`
+	TokenTTL            int64     `json:"token_ttl"`
+	CreationTTL         int64     `json:"creation_ttl"`
+	Renewals            int       `json:"renewals"`
+	LastRenewal         time.Time `json:"last_renewal,omitzero"`
+	LastCheck           time.Time `json:"last_check,omitzero"`
+	NextCheck           time.Time `json:"next_check,omitzero"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	TotalFailures       int       `json:"total_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastErrorTime       time.Time `json:"last_error_time,omitzero"`
+}
+
+// StatusHandler returns an HTTP handler answering GET /v1/status with the
+// daemon's status, for "vx daemon status" to query the running daemon.
+func (d *Daemon) StatusHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/status", func(w http.ResponseWriter, r *http.Request) {
+		s, _ := d.Status()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(statusResponse{
+			PID:                 s.PID,
+			TokenTTL:            int64(s.TokenTTL / time.Second),
+			CreationTTL:         int64(s.CreationTTL / time.Second),
+			Renewals:            s.Renewals,
+			LastRenewal:         s.LastRenewal,
+			LastCheck:           s.LastCheck,
+			NextCheck:           s.NextCheck,
+			ConsecutiveFailures: s.ConsecutiveFailures,
+			TotalFailures:       s.TotalFailures,
+			LastError:           s.LastError,
+			LastErrorTime:       s.LastErrorTime,
+		})
+	})
+	return mux
+}
+
+// QueryStatus asks the daemon listening on socketPath for its status. It
+// returns an error wrapping ErrNoDaemon when nothing answers, as when the
+// daemon is not running or predates the status endpoint.
+func QueryStatus(ctx context.Context, socketPath string) (DaemonStatus, error) {
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}}
+
+	// The host is ignored; the request goes to the socket.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://daemon/v1/status", nil)
+	if err != nil {
+		return DaemonStatus{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return DaemonStatus{}, fmt.Errorf("%w: %w", ErrNoDaemon, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return DaemonStatus{}, fmt.Errorf("%w: %s", ErrNoDaemon, resp.Status)
+	}
+	var body statusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return DaemonStatus{}, fmt.Errorf("daemon: decoding status: %w", err)
+	}
+
+	return DaemonStatus{
+		Running:             true,
+		PID:                 body.PID,
+		TokenTTL:            time.Duration(body.TokenTTL) * time.Second,
+		CreationTTL:         time.Duration(body.CreationTTL) * time.Second,
+		LastRenewal:         body.LastRenewal,
+		Renewals:            body.Renewals,
+		LastCheck:           body.LastCheck,
+		NextCheck:           body.NextCheck,
+		ConsecutiveFailures: body.ConsecutiveFailures,
+		TotalFailures:       body.TotalFailures,
+		LastError:           body.LastError,
+		LastErrorTime:       body.LastErrorTime,
+	}, nil
+}
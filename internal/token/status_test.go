@@ -0,0 +1,68 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueryStatus(t *testing.T) {
+	stub := newStubVaultServer(t, 7200, 86400, true)
+	defer stub.Close()
+
+	dir, err := os.MkdirTemp("", "vxstatus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	tokenPath := filepath.Join(dir, "token")
+	pidPath := filepath.Join(dir, "daemon.pid")
+	socket := filepath.Join(dir, "daemon.sock")
+	writeTokenTo(tokenPath, "s.status-test")
+	overridePIDPath(t, pidPath)
+	writePIDFile(pidPath, os.Getpid())
+
+	renewer := NewTokenRenewer(stub.URL, WithTokenPath(tokenPath), WithCheckInterval(time.Minute))
+	daemon := NewDaemon(renewer)
+	daemon.tryRenew(context.Background())
+
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: daemon.StatusHandler()}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	s, err := QueryStatus(context.Background(), socket)
+	if err != nil {
+		t.Fatalf("QueryStatus() error = %v", err)
+	}
+	if !s.Running || s.PID != os.Getpid() {
+		t.Errorf("Running = %v, PID = %d; want the running daemon's PID %d", s.Running, s.PID, os.Getpid())
+	}
+	if s.Renewals != 1 {
+		t.Errorf("Renewals = %d, want 1", s.Renewals)
+	}
+	if s.CreationTTL != 24*time.Hour || s.TokenTTL <= 23*time.Hour || s.TokenTTL > 24*time.Hour {
+		t.Errorf("TTL = %v of %v, want the renewed lease of 24h", s.TokenTTL, s.CreationTTL)
+	}
+	if until := time.Until(s.NextCheck); until <= 0 || until > 2*time.Minute {
+		t.Errorf("NextCheck in %v, want about a minute", until)
+	}
+	if s.LastCheck.IsZero() || s.TotalFailures != 0 || s.LastError != "" {
+		t.Errorf("status = %+v, want one successful check", s)
+	}
+}
+
+func TestQueryStatus_NoDaemon(t *testing.T) {
+	_, err := QueryStatus(context.Background(), filepath.Join(t.TempDir(), "missing.sock"))
+	if !errors.Is(err, ErrNoDaemon) {
+		t.Errorf("QueryStatus() error = %v, want ErrNoDaemon", err)
+	}
+}
@@ -0,0 +1,134 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// keychainService is the service name vx tokens are filed under in the OS
+// keychain.
+const keychainService = "vx"
+
+// errNotInKeychain is returned by keychainGet when there is no item for an
+// account.
+var errNotInKeychain = errors.New("not in the OS keychain")
+
+// Store keeps the tokens written by logins. name is "" for the [vault]
+// connection without an address, "tokens/<host>" for it with one (see
+// UseAddress), and the connection name for one from [vaults].
+type Store interface {
+	Read(name string) (string, error)
+	Write(name, token string) error
+	Remove(name string) error
+}
+
+// store is where ReadToken, WriteToken, and friends keep tokens.
+var store Store = fileStore{}
+
+// UseFile makes the token functions keep tokens in sink files under ~/.vx,
+// the default.
+func UseFile() {
+	store = fileStore{}
+}
+
+// UseKeychain makes the token functions keep tokens in the OS keychain: the
+// macOS Keychain, the Windows Credential Manager, or a Secret Service such
+// as GNOME Keyring (through secret-tool) elsewhere. When the keychain cannot
+// be reached it returns an error and the sink files stay in use.
+func UseKeychain() error {
+	if err := keychainProbe(); err != nil {
+		return fmt.Errorf("OS keychain unavailable: %w", err)
+	}
+	store = keychainStore{}
+	return nil
+}
+
+// Location describes where the token of the [vault] connection is kept.
+func Location() string {
+	name := ""
+	if address != "" {
+		name = addressKey(address)
+	}
+	if _, ok := store.(keychainStore); ok {
+		return fmt.Sprintf("OS keychain (service %q, account %q)", keychainService, account(name))
+	}
+	return (fileStore{}).path(name)
+}
+
+// fileStore keeps each token in a 0600 sink file: ~/.vx/token,
+// ~/.vx/tokens/<host> for a server's, or ~/.vx/token-<name> for a named
+// connection.
+type fileStore struct{}
+
+func (fileStore) path(name string) string {
+	if name == "" {
+		return TokenPath()
+	}
+	if host, ok := strings.CutPrefix(name, addressPrefix); ok {
+		return filepath.Join(TokensDir(), host)
+	}
+	return VaultTokenPath(name)
+}
+
+func (s fileStore) Read(name string) (string, error) {
+	return readTokenFrom(s.path(name))
+}
+
+func (s fileStore) Write(name, token string) error {
+	return writeTokenTo(s.path(name), token)
+}
+
+func (s fileStore) Remove(name string) error {
+	return removeTokenAt(s.path(name))
+}
+
+// keychainStore keeps each token as a keychain item of service "vx", named
+// like its sink file. A token still in a sink file from before the switch is
+// read from there until the next login moves it into the keychain.
+type keychainStore struct{}
+
+// account returns the keychain account of a connection's token.
+func account(name string) string {
+	if name == "" {
+		return tokenFile
+	}
+	if strings.HasPrefix(name, addressPrefix) {
+		return name
+	}
+	return tokenFile + "-" + name
+}
+
+func (keychainStore) Read(name string) (string, error) {
+	tok, err := keychainGet(account(name))
+	if err == nil {
+		if tok = strings.TrimSpace(tok); tok != "" {
+			return tok, nil
+		}
+		err = errors.New("item is empty")
+	}
+
+	if tok, ferr := (fileStore{}).Read(name); ferr == nil {
+		return tok, nil
+	}
+	if errors.Is(err, errNotInKeychain) {
+		return "", fmt.Errorf("read token: %w", err)
+	}
+	return "", fmt.Errorf("read token: keychain: %w", err)
+}
+
+func (keychainStore) Write(name, token string) error {
+	if err := keychainSet(account(name), token); err != nil {
+		return fmt.Errorf("write token: keychain: %w", err)
+	}
+	// The keychain now holds the token; don't leave a plaintext copy.
+	return (fileStore{}).Remove(name)
+}
+
+func (keychainStore) Remove(name string) error {
+	if err := keychainDelete(account(name)); err != nil && !errors.Is(err, errNotInKeychain) {
+		return fmt.Errorf("remove token: keychain: %w", err)
+	}
+	return (fileStore{}).Remove(name)
+}
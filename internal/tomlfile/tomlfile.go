@@ -0,0 +1,152 @@
+// Package tomlfile reads and writes vx.toml files with tomledit, keeping
+// comments and layout intact, and edits their [secrets] mappings. It is
+// shared by everything that changes a vx.toml in place: the TUI, vx
+// mappings import, vx suggest, vx env rename, and vx workspaces.
+package tomlfile
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/creachadair/tomledit"
+	"github.com/creachadair/tomledit/parser"
+	"github.com/creachadair/tomledit/scanner"
+	"github.com/creachadair/tomledit/transform"
+)
+
+// Read reads and parses the TOML file at path into a document tree.
+func Read(path string) (*tomledit.Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close()
+
+	doc, err := tomledit.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing TOML in %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// Write formats doc back to the existing file at path, keeping the file's
+// permissions.
+func Write(path string, doc *tomledit.Document) error {
+	var buf bytes.Buffer
+	var fmtr tomledit.Formatter
+	if err := fmtr.Format(&buf, doc); err != nil {
+		return fmt.Errorf("formatting TOML: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), info.Mode()); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// Quote returns s as a TOML basic string.
+func Quote(s string) string {
+	return `"` + string(scanner.Escape(s)) + `"`
+}
+
+// QuotedValue builds a basic string value for s, keeping trailer as its
+// trailing line comment.
+func QuotedValue(s, trailer string) parser.Value {
+	return parser.MustValue(Quote(s)).WithComment(trailer)
+}
+
+// StringValue returns the Go string for a single-line basic or literal
+// TOML string value, and false for any other value.
+func StringValue(v parser.Value) (string, bool) {
+	raw := v.X.String()
+	switch {
+	case strings.HasPrefix(raw, `"""`), strings.HasPrefix(raw, "'''"):
+		return "", false
+	case strings.HasPrefix(raw, `"`):
+		s, err := strconv.Unquote(raw)
+		return s, err == nil
+	case strings.HasPrefix(raw, "'") && strings.HasSuffix(raw, "'") && len(raw) >= 2:
+		return raw[1 : len(raw)-1], true
+	}
+	return "", false
+}
+
+// Secrets returns the [secrets] section of doc, or nil.
+func Secrets(doc *tomledit.Document) *tomledit.Section {
+	for _, e := range doc.Find("secrets") {
+		if e.IsSection() {
+			return e.Section
+		}
+	}
+	return nil
+}
+
+// AddMapping adds envVar = "vaultPath" to the [secrets] section of doc,
+// appending the section when there is none. Adding a variable that is
+// already mapped is an error; use SetMapping to change it.
+func AddMapping(doc *tomledit.Document, envVar, vaultPath string) error {
+	section := Secrets(doc)
+	if section == nil {
+		section = &tomledit.Section{Heading: &parser.Heading{Name: parser.Key{"secrets"}}}
+		doc.Sections = append(doc.Sections, section)
+	}
+
+	if !transform.InsertMapping(section, mapping(envVar, vaultPath), false) {
+		return fmt.Errorf("secret %q is already mapped in [secrets]", envVar)
+	}
+	return nil
+}
+
+// SetMapping points the mapping of oldEnvVar in [secrets] at vaultPath,
+// renaming it to newEnvVar when they differ. A changed value keeps its
+// trailing comment. Renaming onto another mapped variable is an error.
+func SetMapping(doc *tomledit.Document, oldEnvVar, newEnvVar, vaultPath string) error {
+	entry := doc.First("secrets", oldEnvVar)
+	if entry == nil || !entry.IsMapping() {
+		return fmt.Errorf("secret %q not found in [secrets]", oldEnvVar)
+	}
+
+	if oldEnvVar == newEnvVar {
+		entry.KeyValue.Value = QuotedValue(vaultPath, entry.KeyValue.Value.Trailer)
+		return nil
+	}
+
+	if doc.First("secrets", newEnvVar) != nil {
+		return fmt.Errorf("secret %q is already mapped in [secrets]", newEnvVar)
+	}
+	section := Secrets(doc)
+	if section == nil {
+		// oldEnvVar is a dotted key outside a [secrets] table.
+		return fmt.Errorf("no [secrets] section to rename %q in", oldEnvVar)
+	}
+	entry.Remove()
+	transform.InsertMapping(section, mapping(newEnvVar, vaultPath), false)
+	return nil
+}
+
+// DeleteMapping removes the mapping of envVar from [secrets].
+func DeleteMapping(doc *tomledit.Document, envVar string) error {
+	entry := doc.First("secrets", envVar)
+	if entry == nil || !entry.IsMapping() {
+		return fmt.Errorf("secret %q not found in [secrets]", envVar)
+	}
+	if !entry.Remove() {
+		return fmt.Errorf("failed to remove secret %q", envVar)
+	}
+	return nil
+}
+
+// mapping returns the key-value envVar = "vaultPath".
+func mapping(envVar, vaultPath string) *parser.KeyValue {
+	return &parser.KeyValue{
+		Name:  parser.Key{envVar},
+		Value: parser.MustValue(Quote(vaultPath)),
+	}
+}
@@ -0,0 +1,125 @@
+package tomlfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/creachadair/tomledit/parser"
+)
+
+func TestMappings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vx.toml")
+	input := `version = 1
+
+[secrets]
+# The primary database.
+DATABASE_URL = "${env}/database/url" # rotated weekly
+API_KEY = "${env}/api/key"
+`
+	if err := os.WriteFile(path, []byte(input), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if err := AddMapping(doc, "REDIS_URL", `${env}/redis/"url"`); err != nil {
+		t.Errorf("AddMapping() error = %v", err)
+	}
+	if err := AddMapping(doc, "API_KEY", "other"); err == nil {
+		t.Error("AddMapping() of a mapped variable: error = nil")
+	}
+	if err := SetMapping(doc, "DATABASE_URL", "DATABASE_URL", "${env}/db/url"); err != nil {
+		t.Errorf("SetMapping() error = %v", err)
+	}
+	if err := SetMapping(doc, "REDIS_URL", "API_KEY", "x"); err == nil {
+		t.Error("SetMapping() onto a mapped variable: error = nil")
+	}
+	if err := DeleteMapping(doc, "API_KEY"); err != nil {
+		t.Errorf("DeleteMapping() error = %v", err)
+	}
+	if err := DeleteMapping(doc, "MISSING"); err == nil {
+		t.Error("DeleteMapping() of an unmapped variable: error = nil")
+	}
+	if err := Write(path, doc); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"# The primary database.",
+		`DATABASE_URL = "${env}/db/url"  # rotated weekly`,
+		`REDIS_URL = "${env}/redis/\"url\""`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("file lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "API_KEY") {
+		t.Errorf("API_KEY was not deleted:\n%s", got)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("mode = %v, %v; want 0640 kept", info.Mode().Perm(), err)
+	}
+}
+
+func TestAddMapping_CreatesSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vx.toml")
+	if err := os.WriteFile(path, []byte("version = 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Secrets(doc) != nil {
+		t.Fatal("Secrets() of a file without [secrets] is not nil")
+	}
+	if err := AddMapping(doc, "A", "x/a"); err != nil {
+		t.Fatalf("AddMapping() error = %v", err)
+	}
+	if Secrets(doc) == nil {
+		t.Error("AddMapping() did not create [secrets]")
+	}
+}
+
+func TestStringValue(t *testing.T) {
+	tests := []struct {
+		raw    string
+		want   string
+		wantOK bool
+	}{
+		{`"a\tb"`, "a\tb", true},
+		{`'C:\dir'`, `C:\dir`, true},
+		{`""`, "", true},
+		{`"""multi"""`, "", false},
+		{`'''multi'''`, "", false},
+		{`42`, "", false},
+		{`["a"]`, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := StringValue(parser.MustValue(tt.raw))
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("StringValue(%s) = %q, %v; want %q, %v", tt.raw, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestQuotedValue(t *testing.T) {
+	v := QuotedValue(`say "hi"`, "# greeting")
+	if got, ok := StringValue(v); !ok || got != `say "hi"` {
+		t.Errorf("StringValue(QuotedValue()) = %q, %v; want the input back", got, ok)
+	}
+	if v.Trailer != "# greeting" {
+		t.Errorf("Trailer = %q, want %q", v.Trailer, "# greeting")
+	}
+}
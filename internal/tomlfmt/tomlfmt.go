@@ -0,0 +1,267 @@
+// Package tomlfmt rewrites vx.toml files in one canonical layout, so that
+// edits by different people and tools produce minimal diffs. Files are
+// edited with tomledit: comments stay attached to the keys they describe.
+package tomlfmt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/creachadair/tomledit"
+	"github.com/creachadair/tomledit/parser"
+	"github.com/creachadair/tomledit/scanner"
+
+	"go.dot.industries/vx/internal/textdiff"
+)
+
+// Format returns data in canonical form:
+//
+//   - keys are sorted within each table, with the top-level version key
+//     first; a comment keeps the keys before and after it apart, so a key
+//     under a comment stays the first of its group
+//   - literal strings ('...') that need no escaping become basic strings
+//     ("..."), and keys are quoted only when they must be
+//   - trailing comments of neighbouring keys are aligned in one column
+//   - spacing follows tomledit's formatter, without a blank line under a
+//     table heading
+//
+// The order of tables is kept, and formatting formatted output changes
+// nothing.
+func Format(data []byte) ([]byte, error) {
+	doc, err := tomledit.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing TOML: %w", err)
+	}
+
+	if doc.Global != nil {
+		sortItems(doc.Global.Items, true)
+		requoteItems(doc.Global.Items)
+	}
+	for _, s := range doc.Sections {
+		sortItems(s.Items, false)
+		requoteItems(s.Items)
+	}
+
+	var buf bytes.Buffer
+	var fmtr tomledit.Formatter
+	if err := fmtr.Format(&buf, doc); err != nil {
+		return nil, fmt.Errorf("formatting TOML: %w", err)
+	}
+	return alignTrailers(trimAfterHeadings(buf.Bytes()), doc), nil
+}
+
+// trimAfterHeadings removes the blank line the formatter puts between a table
+// heading and a commented first key.
+func trimAfterHeadings(formatted []byte) []byte {
+	lines := strings.Split(string(formatted), "\n")
+	out := lines[:0]
+	for i, line := range lines {
+		if line == "" && i > 0 && strings.HasPrefix(lines[i-1], "[") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return []byte(strings.Join(out, "\n"))
+}
+
+// sortItems sorts each run of uncommented key-values in items by key. With
+// global set, the version key goes first.
+func sortItems(items []parser.Item, global bool) {
+	start := 0
+	for i := 0; i <= len(items); i++ {
+		if i < len(items) {
+			if kv, ok := items[i].(*parser.KeyValue); ok && len(kv.Block) == 0 {
+				continue
+			}
+		}
+		slices.SortStableFunc(items[start:i], func(a, b parser.Item) int {
+			return compareKeys(a.(*parser.KeyValue).Name, b.(*parser.KeyValue).Name, global)
+		})
+		start = i + 1
+	}
+}
+
+func compareKeys(a, b parser.Key, global bool) int {
+	version := parser.Key{"version"}
+	switch {
+	case a.Equals(b):
+		return 0
+	case global && a.Equals(version):
+		return -1
+	case global && b.Equals(version):
+		return 1
+	case a.Before(b):
+		return -1
+	default:
+		return 1
+	}
+}
+
+// requoteItems turns literal strings that need no escaping into basic
+// strings, in values, arrays, and inline tables.
+func requoteItems(items []parser.Item) {
+	for _, item := range items {
+		if kv, ok := item.(*parser.KeyValue); ok {
+			kv.Value.X = requote(kv.Value.X)
+		}
+	}
+}
+
+func requote(d parser.Datum) parser.Datum {
+	switch t := d.(type) {
+	case parser.Token:
+		if t.Type != scanner.LString {
+			return t
+		}
+		text := t.String()
+		content := text[1 : len(text)-1]
+		if string(scanner.Escape(content)) != content {
+			return t
+		}
+		v, err := parser.ParseValue(`"` + content + `"`)
+		if err != nil {
+			return t
+		}
+		return v.X
+	case parser.Array:
+		for i, item := range t {
+			if v, ok := item.(parser.Value); ok {
+				v.X = requote(v.X)
+				t[i] = v
+			}
+		}
+		return t
+	case parser.Inline:
+		for _, kv := range t {
+			kv.Value.X = requote(kv.Value.X)
+		}
+		return t
+	}
+	return d
+}
+
+// alignTrailers pads the trailing comments of neighbouring key-value lines in
+// formatted, the output of tomledit's formatter for doc, so that they start
+// in the same column. Lines are neighbours when no blank, comment, or
+// heading line separates them.
+func alignTrailers(formatted []byte, doc *tomledit.Document) []byte {
+	// The formatter writes key-values in document order, each with its
+	// trailer two spaces after the value.
+	var want []string
+	var widths []int
+	collect := func(items []parser.Item) {
+		for _, item := range items {
+			kv, ok := item.(*parser.KeyValue)
+			if !ok || kv.Value.Trailer == "" {
+				continue
+			}
+			head := kv.Name.String() + " = " + kv.Value.String()
+			want = append(want, head+"  "+parser.CleanTrailer(kv.Value.Trailer))
+			widths = append(widths, utf8.RuneCountInString(head))
+		}
+	}
+	if doc.Global != nil {
+		collect(doc.Global.Items)
+	}
+	for _, s := range doc.Sections {
+		collect(s.Items)
+	}
+
+	lines := strings.Split(string(formatted), "\n")
+	width := make([]int, len(lines)) // width before the trailer, or 0
+	next := 0
+	for i, line := range lines {
+		if next < len(want) && line == want[next] {
+			width[i] = widths[next]
+			next++
+		}
+	}
+
+	for start := 0; start < len(lines); {
+		end := start
+		for end < len(lines) && isKeyValueLine(lines[end]) {
+			end++
+		}
+		if end == start {
+			start++
+			continue
+		}
+
+		col, n := 0, 0
+		for i := start; i < end; i++ {
+			if width[i] > 0 {
+				col = max(col, width[i])
+				n++
+			}
+		}
+		if n > 1 {
+			for i := start; i < end; i++ {
+				if w := width[i]; w > 0 {
+					head := string([]rune(lines[i])[:w])
+					rest := strings.TrimLeft(string([]rune(lines[i])[w:]), " ")
+					lines[i] = head + strings.Repeat(" ", col-w+2) + rest
+				}
+			}
+		}
+		start = end
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// isKeyValueLine reports whether line, from formatted output, is neither
+// blank nor a comment or heading.
+func isKeyValueLine(line string) bool {
+	t := strings.TrimSpace(line)
+	return t != "" && !strings.HasPrefix(t, "#") && !strings.HasPrefix(t, "[")
+}
+
+// File is a vx.toml whose canonical form differs from its contents.
+type File struct {
+	Path   string
+	Before []byte
+	After  []byte
+}
+
+// Diff returns a unified diff from f's current to its formatted contents,
+// with name as the file name in its header.
+func (f *File) Diff(name string) string {
+	return textdiff.Unified(name, "formatted", f.Before, f.After)
+}
+
+// Plan formats each of paths in memory and returns those that change.
+func Plan(paths []string) ([]*File, error) {
+	var files []*File
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		out, err := Format(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if !bytes.Equal(data, out) {
+			files = append(files, &File{Path: path, Before: data, After: out})
+		}
+	}
+	return files, nil
+}
+
+// Write writes the formatted contents of each file with the file's existing
+// permissions.
+func Write(files []*File) error {
+	for _, f := range files {
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", f.Path, err)
+		}
+		if err := os.WriteFile(f.Path, f.After, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("writing %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package tomlfmt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	in := `# Project secrets
+
+workspaces = ['api/vx.toml']
+version = 1
+[vault]
+auth_method = 'oidc'   # how we log in
+address = "https://vault.example.com"  # the server
+base_path = 'C:\kv'
+
+[secrets]
+"API_KEY" = '${env}/api/key'
+# the database
+DATABASE_URL = "${env}/db/url"
+DATABASE_PASSWORD = "${env}/db/password"
+
+[defaults]
+LIST = ['a', "b"]
+`
+	want := `# Project secrets
+
+version = 1
+workspaces = ["api/vx.toml"]
+
+[vault]
+address = "https://vault.example.com"  # the server
+auth_method = "oidc"                   # how we log in
+base_path = 'C:\kv'
+
+[secrets]
+API_KEY = "${env}/api/key"
+
+# the database
+DATABASE_URL = "${env}/db/url"
+DATABASE_PASSWORD = "${env}/db/password"
+
+[defaults]
+LIST = ["a", "b"]
+`
+
+	got, err := Format([]byte(in))
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("Format() =\n%s\nwant\n%s", got, want)
+	}
+
+	again, err := Format(got)
+	if err != nil || string(again) != string(got) {
+		t.Errorf("Format() of formatted output changed it:\n%s", again)
+	}
+}
+
+func TestFormat_Invalid(t *testing.T) {
+	if _, err := Format([]byte("[secrets\n")); err == nil {
+		t.Error("Format() expected error for invalid TOML")
+	}
+}
+
+func TestPlanAndWrite(t *testing.T) {
+	dir := t.TempDir()
+	clean := filepath.Join(dir, "clean.toml")
+	messy := filepath.Join(dir, "messy.toml")
+	if err := os.WriteFile(clean, []byte("[secrets]\nA = \"a\"\nB = \"b\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(messy, []byte("[secrets]\nB = 'b'\nA = \"a\"\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := Plan([]string{clean, messy})
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(files) != 1 || files[0].Path != messy {
+		t.Fatalf("Plan() = %v, want only %s", files, messy)
+	}
+	if d := files[0].Diff("messy.toml"); d == "" {
+		t.Error("Diff() is empty")
+	}
+
+	if err := Write(files); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	data, _ := os.ReadFile(messy)
+	if string(data) != "[secrets]\nA = \"a\"\nB = \"b\"\n" {
+		t.Errorf("written = %q", data)
+	}
+	if info, _ := os.Stat(messy); info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, want 0640 kept", info.Mode().Perm())
+	}
+}
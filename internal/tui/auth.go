@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"errors"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/tui/bridge"
+	"go.dot.industries/vx/internal/vault"
+)
+
+// requireAuth opens the login popup, preselecting the configured method.
+// retry is run again once the login succeeds.
+func (m model) requireAuth(retry authRetry) (tea.Model, tea.Cmd) {
+	m.activePopup = popupAuth
+	m.authRetry = retry
+	m.authField = 0
+	m.authError = ""
+	if !m.authBusy {
+		m.authMethod = authOIDC
+		if m.bridge.AuthMethod(m.config) == "approle" {
+			m.authMethod = authAppRole
+		}
+	}
+	return m, nil
+}
+
+// handleAuthKey handles keys within the login popup. Keys are ignored while
+// a login is in flight; Esc closes the popup and leaves it running.
+func (m model) handleAuthKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.authBusy {
+		return m, nil
+	}
+
+	switch msg.Type {
+	case tea.KeyTab:
+		if m.authMethod == authAppRole {
+			m.authField = (m.authField + 1) % 3
+		}
+
+	case tea.KeyEnter:
+		return m.login()
+
+	case tea.KeyBackspace:
+		switch m.authField {
+		case 0:
+			m.toggleAuthMethod()
+		case 1:
+			if r := []rune(m.authRoleID); len(r) > 0 {
+				m.authRoleID = string(r[:len(r)-1])
+			}
+		case 2:
+			if r := []rune(m.authSecretID); len(r) > 0 {
+				m.authSecretID = string(r[:len(r)-1])
+			}
+		}
+
+	case tea.KeyLeft, tea.KeyRight:
+		if m.authField == 0 {
+			m.toggleAuthMethod()
+		}
+
+	case tea.KeyRunes:
+		switch m.authField {
+		case 0:
+			m.toggleAuthMethod()
+		case 1:
+			m.authRoleID += string(msg.Runes)
+		case 2:
+			m.authSecretID += string(msg.Runes)
+		}
+	}
+	return m, nil
+}
+
+// toggleAuthMethod switches the login popup between OIDC and AppRole.
+func (m *model) toggleAuthMethod() {
+	if m.authMethod == authOIDC {
+		m.authMethod = authAppRole
+	} else {
+		m.authMethod = authOIDC
+	}
+	m.authError = ""
+}
+
+// login starts the login chosen in the popup. The secret ID is dropped from
+// the model once handed to the command.
+func (m model) login() (tea.Model, tea.Cmd) {
+	if m.authMethod == authAppRole && (m.authRoleID == "" || m.authSecretID == "") {
+		m.authError = "Role ID and secret ID are required"
+		return m, nil
+	}
+
+	m.authBusy = true
+	m.authError = ""
+	secretID := m.authSecretID
+	m.authSecretID = ""
+	return m, loginCmd(m.bridge, m.config, m.env, m.authMethod, m.authRoleID, secretID)
+}
+
+// handleAuthSucceeded stores the new client and, when the login popup is
+// open, closes it and runs the operation that was waiting on the login.
+func (m model) handleAuthSucceeded(msg authSucceededMsg) (tea.Model, tea.Cmd) {
+	m.vaultClient = msg.client
+	m.authBusy = false
+	if m.activePopup != popupAuth {
+		return m, nil
+	}
+
+	m.activePopup = popupNone
+	retry := m.authRetry
+	m.authRetry = authRetryNone
+	m.statusBar.Message = "Logged in to Vault"
+	m.statusBar.IsError = false
+
+	next, cmd := m.runAuthRetry(retry)
+	return next, tea.Batch(cmd, clearStatusAfter(3*time.Second))
+}
+
+// runAuthRetry runs the operation that needed a login again.
+func (m model) runAuthRetry(retry authRetry) (tea.Model, tea.Cmd) {
+	switch retry {
+	case authRetryDetail:
+		return m.openDetail()
+	case authRetryWriteValue:
+		m.activePopup = popupDetail
+		m.pendingWrites++
+		return m, m.saveValueCmd()
+	case authRetryEffective:
+		return m.handleEffective()
+	}
+	return m, nil
+}
+
+// loginCmd creates a command that logs in with method, returning the new
+// client or the failure for the login popup.
+func loginCmd(b *bridge.Bridge, cfg *config.RootConfig, env string, method int, roleID, secretID string) tea.Cmd {
+	return func() tea.Msg {
+		var client *vault.Client
+		var err error
+		if method == authAppRole {
+			client, err = b.LoginAppRole(cfg, roleID, secretID)
+		} else {
+			client, err = b.LoginOIDC(cfg, env)
+		}
+		if err != nil {
+			return authFailedMsg{err: err, login: true}
+		}
+		return authSucceededMsg{client: client}
+	}
+}
+
+// needsLogin reports whether err is the bridge's lack of a Vault token,
+// which the login popup can resolve.
+func needsLogin(err error) bool {
+	return errors.Is(err, bridge.ErrNotAuthenticated)
+}
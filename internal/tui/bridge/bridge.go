@@ -4,10 +4,16 @@
 package bridge
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-runewidth"
 
 	"go.dot.industries/vx/internal/config"
 	"go.dot.industries/vx/internal/resolver"
@@ -15,6 +21,15 @@ import (
 	"go.dot.industries/vx/internal/vault"
 )
 
+// resolveTimeout bounds a single secret read from the TUI, so a hung request
+// surfaces as an error instead of a spinner that never stops.
+const resolveTimeout = 30 * time.Second
+
+// ErrNotAuthenticated is returned by Authenticate when there is no valid
+// cached token and none can be obtained without the user, who can then log
+// in with LoginOIDC or LoginAppRole.
+var ErrNotAuthenticated = errors.New("no valid Vault token")
+
 // FileTarget represents a vx.toml file that can be written to.
 type FileTarget struct {
 	Label string // display name, e.g. "web" or "[root]"
@@ -29,6 +44,11 @@ type Bridge struct {
 	authMethod string
 	roleID     string
 	secretID   string
+	namespace  string
+	clientOpts func(*config.RootConfig) []vault.ClientOption
+
+	mu    sync.Mutex
+	named map[string]*vault.Client
 }
 
 // New creates a Bridge with the given configuration overrides.
@@ -43,6 +63,18 @@ func New(configPath, vaultAddr, authMethod, roleID, secretID string) *Bridge {
 	}
 }
 
+// SetNamespace overrides the Vault namespace of [vault]. Empty keeps the
+// configured one.
+func (b *Bridge) SetNamespace(ns string) {
+	b.namespace = ns
+}
+
+// SetClientOptions sets the function that returns the options of every Vault
+// client the bridge creates, given the config it is created for.
+func (b *Bridge) SetClientOptions(opts func(*config.RootConfig) []vault.ClientOption) {
+	b.clientOpts = opts
+}
+
 // LoadConfig finds and parses the root vx.toml. Returns the config and its
 // parent directory.
 func (b *Bridge) LoadConfig() (*config.RootConfig, string, error) {
@@ -66,6 +98,13 @@ func (b *Bridge) LoadConfig() (*config.RootConfig, string, error) {
 		return nil, "", err
 	}
 
+	if b.namespace != "" {
+		cfg.Vault.Namespace = b.namespace
+	}
+	// Best effort: without it, vx login asks for a new token.
+	_ = token.MigrateLegacyToken(cfg.Vault.Address)
+	token.UseAddress(b.vaultAddress(cfg))
+
 	rootDir := filepath.Dir(configPath)
 	return cfg, rootDir, nil
 }
@@ -113,13 +152,33 @@ func (b *Bridge) MergeRootOnly(
 }
 
 // Authenticate creates an authenticated Vault client. It first tries the
-// cached token, then falls back to a fresh auth flow.
+// cached token, then falls back to an AppRole login when auth_method is
+// "approle" and credentials were given. With auth_method "token" the
+// externally issued token (VAULT_TOKEN or vault.token_file) is used instead.
+// Flows that need the user, such as OIDC in a browser, are left to the
+// caller: without a token the error wraps ErrNotAuthenticated.
 func (b *Bridge) Authenticate(cfg *config.RootConfig) (*vault.Client, error) {
 	addr := b.vaultAddress(cfg)
 
+	authMethod := b.AuthMethod(cfg)
+	if authMethod == "token" {
+		tok, err := token.External(cfg.Vault.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("token auth: %w", err)
+		}
+		client, err := vault.NewClientWithToken(addr, cfg.Vault.BasePath, tok, b.clientOptions(cfg)...)
+		if err != nil {
+			return nil, fmt.Errorf("creating vault client: %w", err)
+		}
+		if !client.IsAuthenticated() {
+			return nil, fmt.Errorf("token auth: the provided Vault token is invalid or expired")
+		}
+		return client, nil
+	}
+
 	tok, err := token.ReadToken()
 	if err == nil {
-		client, err := vault.NewClientWithToken(addr, cfg.Vault.BasePath, tok)
+		client, err := vault.NewClientWithToken(addr, cfg.Vault.BasePath, tok, b.clientOptions(cfg)...)
 		if err != nil {
 			return nil, fmt.Errorf("creating vault client: %w", err)
 		}
@@ -128,12 +187,148 @@ func (b *Bridge) Authenticate(cfg *config.RootConfig) (*vault.Client, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("no valid Vault token; run `vx login` first")
+	if authMethod == "approle" && b.roleID != "" && b.secretID != "" {
+		return b.LoginAppRole(cfg, b.roleID, b.secretID)
+	}
+
+	return nil, fmt.Errorf("%w; run `vx login` first", ErrNotAuthenticated)
+}
+
+// AuthMethod returns the auth method to log in with: the one given to New,
+// else the configured one.
+func (b *Bridge) AuthMethod(cfg *config.RootConfig) string {
+	if b.authMethod != "" {
+		return b.authMethod
+	}
+	return cfg.Vault.AuthMethod
+}
+
+// LoginOIDC runs the browser OIDC flow with the auth role for env and caches
+// the new token, as vx login does. It blocks until the browser login
+// finishes or times out.
+func (b *Bridge) LoginOIDC(cfg *config.RootConfig, env string) (*vault.Client, error) {
+	addr := b.vaultAddress(cfg)
+
+	// Some Vault servers require a token, even an expired one, on
+	// auth/oidc/auth_url for policy evaluation.
+	var client *vault.Client
+	var err error
+	if stale, readErr := token.ReadToken(); readErr == nil {
+		client, err = vault.NewClientWithToken(addr, cfg.Vault.BasePath, stale, b.clientOptions(cfg)...)
+	} else {
+		client, err = vault.NewClient(addr, cfg.Vault.BasePath, b.clientOptions(cfg)...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating vault client: %w", err)
+	}
+
+	if err := vault.OIDCAuth(context.Background(), client, cfg.Vault.RoleFor(env),
+		vault.WithOIDCMount(cfg.Vault.OIDCMount()), vault.WithCallbackPorts(cfg.Vault.AuthListenPorts...)); err != nil {
+		return nil, fmt.Errorf("OIDC authentication: %w", err)
+	}
+
+	// A token that cannot be cached still serves this session.
+	_ = token.WriteToken(client.Token())
+	return client, nil
+}
+
+// LoginAppRole logs in with AppRole credentials and caches the new token
+// with its login metadata, as vx login does.
+func (b *Bridge) LoginAppRole(cfg *config.RootConfig, roleID, secretID string) (*vault.Client, error) {
+	client, err := vault.NewClient(b.vaultAddress(cfg), cfg.Vault.BasePath, b.clientOptions(cfg)...)
+	if err != nil {
+		return nil, fmt.Errorf("creating vault client: %w", err)
+	}
+
+	login, err := vault.AppRoleAuth(client, roleID, secretID)
+	if err != nil {
+		return nil, fmt.Errorf("AppRole authentication: %w", err)
+	}
+
+	if err := token.WriteToken(client.Token()); err == nil {
+		_ = token.WriteMetadata(client.Token(), token.Metadata{
+			AuthMethod: "approle",
+			Role:       login.Metadata["role_name"],
+			Accessor:   login.Accessor,
+			Policies:   login.Policies,
+			TTL:        token.Seconds(login.TTL / time.Second),
+			Renewable:  login.Renewable,
+			Orphan:     login.Orphan,
+			IssuedAt:   time.Now(),
+		})
+	}
+	return client, nil
+}
+
+// Route returns the client to read the secret behind a mapping with and the
+// path within that client's Vault. A "vault://<name>/" mapping goes to the
+// [vaults] connection name, using the token a vx command cached for it; the
+// TUI does not log in to named connections itself. Any other mapping goes to
+// client, or to a client from Authenticate when client is nil.
+func (b *Bridge) Route(client *vault.Client, cfg *config.RootConfig, vaultPath string) (*vault.Client, string, error) {
+	name, path := config.SplitVault(vaultPath)
+	if name == "" {
+		if client != nil {
+			return client, path, nil
+		}
+		client, err := b.Authenticate(cfg)
+		return client, path, err
+	}
+
+	c, err := b.namedClient(cfg, name)
+	if err != nil {
+		return nil, "", err
+	}
+	return c, path, nil
+}
+
+// namedClient returns the client for the [vaults] connection name, created
+// once from the token a vx command cached for it.
+func (b *Bridge) namedClient(cfg *config.RootConfig, name string) (*vault.Client, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.named[name]; ok {
+		return c, nil
+	}
+
+	v, ok := cfg.Vaults[name]
+	if !ok {
+		return nil, fmt.Errorf("vault %q is not defined in [vaults]", name)
+	}
+
+	var tok string
+	var err error
+	if v.AuthMethod == "token" {
+		tok, err = token.FromFile(v.TokenFile)
+	} else {
+		tok, err = token.ReadVaultToken(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vault %s: no token; run a vx command that reads from it (e.g. `vx list`) to log in", name)
+	}
+
+	c, err := vault.NewClientWithToken(v.Address, v.BasePath, tok, b.clientOptions(&config.RootConfig{Vault: v, Resolver: cfg.Resolver})...)
+	if err != nil {
+		return nil, fmt.Errorf("creating vault client: %w", err)
+	}
+	if !c.IsAuthenticated() {
+		return nil, fmt.Errorf("vault %s: token is invalid or expired; run a vx command that reads from it to log in again", name)
+	}
+
+	if b.named == nil {
+		b.named = make(map[string]*vault.Client)
+	}
+	b.named[name] = c
+	return c, nil
 }
 
 // ResolveSingle fetches a single secret value from Vault. The vaultPath should
-// already be interpolated (no ${env} placeholders).
+// already be interpolated (no ${env} placeholders). A cmd:// mapping runs its
+// command instead, if the user allowed the program in ~/.vx/config.toml.
+// Cancelling ctx abandons the read.
 func (b *Bridge) ResolveSingle(
+	ctx context.Context,
 	client *vault.Client,
 	envVar string,
 	vaultPath string,
@@ -141,10 +336,19 @@ func (b *Bridge) ResolveSingle(
 ) (string, error) {
 	interpolated := resolver.Interpolate(vaultPath, env)
 
-	r := resolver.New(client, "")
+	var commands []string
+	if resolver.IsCommand(interpolated) {
+		user, err := config.LoadUserConfig(filepath.Join(token.DefaultDir(), config.UserConfigFile))
+		if err != nil {
+			return "", err
+		}
+		commands = user.Commands
+	}
+
+	r := resolver.New(client, "", resolver.WithTimeout(resolveTimeout), resolver.WithCommands(commands))
 	secrets := map[string]string{envVar: interpolated}
 
-	result, err := r.Resolve(secrets, "")
+	result, err := r.Resolve(ctx, secrets, "")
 	if err != nil {
 		return "", fmt.Errorf("resolving %s: %w", envVar, err)
 	}
@@ -157,6 +361,83 @@ func (b *Bridge) ResolveSingle(
 	return val, nil
 }
 
+// ResolveVersion reads the key behind a mapping from one earlier version of
+// its secret, for looking at a value from before a rotation. The vaultPath is
+// interpolated for env. Cancelling ctx abandons the read.
+func (b *Bridge) ResolveVersion(ctx context.Context, client *vault.Client, vaultPath, env string, version int) (string, error) {
+	if resolver.IsCommand(vaultPath) {
+		return "", fmt.Errorf("read from a command, not Vault")
+	}
+
+	kvPath, key := resolver.SplitMapping(vaultPath, env)
+	if kvPath == "" || key == "" {
+		return "", fmt.Errorf("path %q has no secret/key separator", resolver.Interpolate(vaultPath, env))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+	data, err := client.ReadKVVersion(ctx, kvPath, version)
+	if err != nil {
+		return "", err
+	}
+
+	val, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("key %q is not in version %d of %s", key, version, kvPath)
+	}
+	return val, nil
+}
+
+// WriteSingle sets the Vault key behind a mapping to value, keeping the other
+// keys of the secret, and creates the secret if it does not exist. The secret
+// is read and written back with check-and-set on the version read, so a
+// concurrent change makes the write fail instead of being overwritten.
+// Mappings pinned to a version cannot be written.
+func (b *Bridge) WriteSingle(client *vault.Client, vaultPath, env, value string) error {
+	if resolver.IsCommand(vaultPath) {
+		return fmt.Errorf("read from a command, not Vault")
+	}
+	if _, version := resolver.SplitVersion(vaultPath); version != 0 {
+		return fmt.Errorf("pinned to version %d; change the mapping to write the latest", version)
+	}
+
+	kvPath, key := resolver.SplitMapping(vaultPath, env)
+	if kvPath == "" || key == "" {
+		return fmt.Errorf("path %q has no secret/key separator", resolver.Interpolate(vaultPath, env))
+	}
+
+	data, version, err := client.ReadKVData(kvPath)
+	if err != nil {
+		return err
+	}
+
+	updated := make(map[string]interface{}, len(data)+1)
+	for k, v := range data {
+		updated[k] = v
+	}
+	updated[key] = value
+
+	return client.WriteKV(kvPath, updated, version)
+}
+
+// ReadMetadata fetches KV v2 metadata for the secret holding a mapping. The
+// vaultPath is interpolated for env and the trailing key segment is dropped,
+// since metadata belongs to the whole secret rather than a single key.
+func (b *Bridge) ReadMetadata(client *vault.Client, vaultPath, env string) (*vault.KVMetadata, error) {
+	if resolver.IsCommand(vaultPath) {
+		return nil, fmt.Errorf("read from a command, not Vault")
+	}
+
+	interpolated := resolver.Interpolate(vaultPath, env)
+
+	idx := strings.LastIndex(interpolated, "/")
+	if idx <= 0 {
+		return nil, fmt.Errorf("path %q has no secret/key separator", interpolated)
+	}
+
+	return client.ReadMetadata(interpolated[:idx])
+}
+
 // ListVaultKeys lists keys and directories at a Vault KV v2 metadata path.
 func (b *Bridge) ListVaultKeys(client *vault.Client, kvPath string) ([]VaultEntry, error) {
 	entries, err := client.ListKeys(kvPath)
@@ -202,6 +483,15 @@ func (b *Bridge) WorkspaceFiles(cfg *config.RootConfig, rootDir string) []FileTa
 	return targets
 }
 
+// clientOptions returns the Vault client options for cfg set with
+// SetClientOptions, or none.
+func (b *Bridge) clientOptions(cfg *config.RootConfig) []vault.ClientOption {
+	if b.clientOpts == nil {
+		return nil
+	}
+	return b.clientOpts(cfg)
+}
+
 // vaultAddress returns the Vault address, preferring the bridge override.
 func (b *Bridge) vaultAddress(cfg *config.RootConfig) string {
 	if b.vaultAddr != "" {
@@ -271,14 +561,24 @@ func InterpolateSecrets(secrets map[string]string, env string) map[string]string
 	return result
 }
 
-// TruncateMiddle truncates a string in the middle if it exceeds maxLen,
-// inserting "..." in the center.
+// TruncateMiddle truncates a string in the middle if it is wider than maxLen
+// terminal cells, inserting "..." in the center. Wide characters are never
+// split.
 func TruncateMiddle(s string, maxLen int) string {
-	if len(s) <= maxLen || maxLen < 4 {
+	width := runewidth.StringWidth(s)
+	if width <= maxLen || maxLen < 4 {
 		return s
 	}
 	half := (maxLen - 3) / 2
-	return s[:half] + "..." + s[len(s)-half:]
+
+	head := runewidth.Truncate(s, half, "")
+	tail := runewidth.TruncateLeft(s, width-half, "")
+	// TruncateLeft pads with a space when the cut lands inside a wide
+	// character; drop it rather than leave a gap after the dots.
+	if !strings.HasSuffix(s, tail) {
+		tail = strings.TrimPrefix(tail, " ")
+	}
+	return head + "..." + tail
 }
 
 // ParseWorkspacePath extracts a workspace directory name from a path like
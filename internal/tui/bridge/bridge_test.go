@@ -0,0 +1,318 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/token"
+	"go.dot.industries/vx/internal/vault"
+)
+
+func TestTruncateMiddle(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		maxLen int
+		want   string
+	}{
+		{"fits", "apps/api/vx.toml", 16, "apps/api/vx.toml"},
+		{"ascii", "packages/payments/vx.toml", 13, "packa....toml"},
+		{"cjk", "サービス/決済/設定.toml", 13, "サー....toml"},
+		{"cjk tail", "apps/支払い設定ファイル", 11, "apps...イル"},
+		{"emoji", "🚀🚀🚀🚀🚀🚀🚀🚀", 9, "🚀...🚀"},
+		{"too narrow", "サービス", 3, "サービス"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TruncateMiddle(tt.in, tt.maxLen)
+			if got != tt.want {
+				t.Errorf("TruncateMiddle(%q, %d) = %q, want %q", tt.in, tt.maxLen, got, tt.want)
+			}
+			if tt.maxLen >= 4 && runewidth.StringWidth(got) > tt.maxLen {
+				t.Errorf("TruncateMiddle(%q, %d) is %d cells wide", tt.in, tt.maxLen, runewidth.StringWidth(got))
+			}
+		})
+	}
+}
+
+func TestWriteSingle(t *testing.T) {
+	var written map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/secret/data/dev/db":
+			w.Write([]byte(`{"data":{"data":{"user":"app","password":"old"},"metadata":{"version":4}}}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/secret/data/dev/db":
+			json.NewDecoder(r.Body).Decode(&written)
+			w.Write([]byte(`{"data":{"version":5}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := vault.NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	b := New("", "", "", "", "")
+	if err := b.WriteSingle(client, "${env}/db/password", "dev", "new"); err != nil {
+		t.Fatalf("WriteSingle() error = %v", err)
+	}
+
+	data, _ := written["data"].(map[string]interface{})
+	if data["password"] != "new" || data["user"] != "app" {
+		t.Errorf("written data = %v, want password replaced and user kept", data)
+	}
+	opts, _ := written["options"].(map[string]interface{})
+	if opts["cas"] != float64(4) {
+		t.Errorf("options = %v, want cas=4", opts)
+	}
+
+	if err := b.WriteSingle(client, "cmd://op read x", "dev", "v"); err == nil {
+		t.Error("WriteSingle() accepted a command mapping")
+	}
+	if err := b.WriteSingle(client, "${env}/db/password@2", "dev", "v"); err == nil {
+		t.Error("WriteSingle() accepted a mapping pinned to a version")
+	}
+}
+
+func TestRoute(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/token/lookup-self" && r.Header.Get("X-Vault-Token") == "s.data" {
+			w.Write([]byte(`{"data":{"ttl":3600}}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	orig := token.VaultTokenPath
+	dir := t.TempDir()
+	token.VaultTokenPath = func(name string) string { return filepath.Join(dir, name) }
+	t.Cleanup(func() { token.VaultTokenPath = orig })
+
+	cfg := &config.RootConfig{
+		Vaults: map[string]config.VaultConfig{
+			"data": {Address: srv.URL, AuthMethod: "oidc", BasePath: "kv"},
+		},
+	}
+	core, err := vault.NewClientWithToken(srv.URL, "secret", "s.core")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	b := New("", "", "", "", "")
+
+	client, path, err := b.Route(core, cfg, "${env}/db/password")
+	if err != nil || client != core || path != "${env}/db/password" {
+		t.Errorf("Route(default) = %p, %q, %v; want the given client", client, path, err)
+	}
+
+	if _, _, err := b.Route(core, cfg, "vault://data/${env}/warehouse/url"); err == nil {
+		t.Error("Route() expected error without a cached token")
+	}
+
+	if err := token.WriteVaultToken("data", "s.data"); err != nil {
+		t.Fatal(err)
+	}
+	client, path, err = b.Route(core, cfg, "vault://data/${env}/warehouse/url")
+	if err != nil {
+		t.Fatalf("Route(named) error = %v", err)
+	}
+	if client == core || client.Token() != "s.data" || path != "${env}/warehouse/url" {
+		t.Errorf("Route(named) = token %q, path %q", client.Token(), path)
+	}
+
+	if _, _, err := b.Route(core, cfg, "vault://billing/x"); err == nil {
+		t.Error("Route() expected error for unknown vault")
+	}
+}
+
+func TestEffectiveEnv(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/secret/data/dev/db" {
+			w.Write([]byte(`{"data":{"data":{"url":"pg://db"}}}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	client, err := vault.NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	rootDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(rootDir, "api"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	ws := `
+[secrets]
+CACHE_URL = "${env}/cache/url"
+TOKEN = "${env}/token/value"
+
+[defaults]
+LOG_LEVEL = "debug"
+CACHE_URL = "redis://localhost"
+
+[on_error]
+CACHE_URL = "default"
+`
+	if err := os.WriteFile(filepath.Join(rootDir, "api", "vx.toml"), []byte(ws), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.RootConfig{
+		Environments: config.EnvironmentConfig{Default: "dev", Available: []string{"dev"}},
+		Workspaces:   []string{"api/vx.toml"},
+		Secrets:      map[string]string{"DB_URL": "${env}/db/url"},
+		Defaults:     map[string]any{"LOG_LEVEL": "info", "DB_URL": "pg://localhost"},
+	}
+
+	b := New("", "", "", "", "")
+	vars, err := b.EffectiveEnv(context.Background(), client, cfg, rootDir, "api", "dev")
+	if err != nil {
+		t.Fatalf("EffectiveEnv() error = %v", err)
+	}
+
+	got := make(map[string]EffectiveVar, len(vars))
+	var names []string
+	for _, v := range vars {
+		got[v.Name] = v
+		names = append(names, v.Name)
+	}
+	if strings.Join(names, ",") != "CACHE_URL,DB_URL,LOG_LEVEL,TOKEN" {
+		t.Errorf("names = %v, want sorted defaults and secrets", names)
+	}
+
+	if v := got["DB_URL"]; v.Value != "pg://db" || !v.Secret || v.Source != "vx.toml [secrets]" ||
+		len(v.Overrides) != 1 || v.Overrides[0] != "vx.toml [defaults]" {
+		t.Errorf("DB_URL = %+v, want the secret over the root default", v)
+	}
+	if v := got["LOG_LEVEL"]; v.Value != "debug" || v.Secret || v.Source != filepath.Join("api", "vx.toml")+" [defaults]" {
+		t.Errorf("LOG_LEVEL = %+v, want the workspace default", v)
+	}
+	if v := got["CACHE_URL"]; v.Value != "redis://localhost" || v.Secret || v.Note == "" || v.Err != nil {
+		t.Errorf("CACHE_URL = %+v, want its default under on_error", v)
+	}
+	if v := got["TOKEN"]; v.Err == nil {
+		t.Errorf("TOKEN = %+v, want the read error", v)
+	}
+}
+
+func TestAuthenticateAppRole(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/auth/approle/login":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["role_id"] != "role" || body["secret_id"] != "secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+				return
+			}
+			w.Write([]byte(`{"auth":{"client_token":"s.approle","lease_duration":3600,"metadata":{"role_name":"ci"}}}`))
+		case r.URL.Path == "/v1/auth/token/lookup-self" && r.Header.Get("X-Vault-Token") == "s.approle":
+			w.Write([]byte(`{"data":{"ttl":3600}}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	orig := token.DefaultDir
+	dir := t.TempDir()
+	token.DefaultDir = func() string { return dir }
+	t.Cleanup(func() { token.DefaultDir = orig })
+
+	cfg := &config.RootConfig{Vault: config.VaultConfig{Address: srv.URL, AuthMethod: "approle", BasePath: "secret"}}
+
+	if _, err := New("", "", "", "", "").Authenticate(cfg); !errors.Is(err, ErrNotAuthenticated) {
+		t.Fatalf("Authenticate() without credentials error = %v, want ErrNotAuthenticated", err)
+	}
+
+	if _, err := New("", "", "", "role", "wrong").LoginAppRole(cfg, "role", "wrong"); err == nil {
+		t.Error("LoginAppRole() accepted a wrong secret ID")
+	}
+
+	client, err := New("", "", "", "role", "secret").Authenticate(cfg)
+	if err != nil {
+		t.Fatalf("Authenticate() with credentials error = %v", err)
+	}
+	if client.Token() != "s.approle" {
+		t.Errorf("token = %q, want the AppRole login's", client.Token())
+	}
+
+	// The token is cached, so the next session needs no credentials.
+	client, err = New("", "", "", "", "").Authenticate(cfg)
+	if err != nil || client.Token() != "s.approle" {
+		t.Errorf("Authenticate() from the cache = %v, %v", client, err)
+	}
+}
+
+func TestSetClientOptions(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("X-Correlation-Test")
+		w.Write([]byte(`{"data":{"ttl":3600}}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("VAULT_TOKEN", "s.external")
+	cfg := &config.RootConfig{Vault: config.VaultConfig{Address: srv.URL, AuthMethod: "token", BasePath: "secret"}}
+
+	b := New("", "", "", "", "")
+	b.SetClientOptions(func(c *config.RootConfig) []vault.ClientOption {
+		if c != cfg {
+			t.Errorf("options asked for another config: %+v", c)
+		}
+		return []vault.ClientOption{vault.WithHeaders(http.Header{"X-Correlation-Test": {"abc"}})}
+	})
+	if _, err := b.Authenticate(cfg); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if got != "abc" {
+		t.Errorf("request header = %q, want the one from the client options", got)
+	}
+}
+
+func TestResolveVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/secret/data/dev/db" && r.URL.Query().Get("version") == "2" {
+			w.Write([]byte(`{"data":{"data":{"password":"before-rotation"},"metadata":{"version":2}}}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	client, err := vault.NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	b := New("", "", "", "", "")
+	val, err := b.ResolveVersion(context.Background(), client, "${env}/db/password", "dev", 2)
+	if err != nil || val != "before-rotation" {
+		t.Errorf("ResolveVersion() = %q, %v; want version 2's value", val, err)
+	}
+	if _, err := b.ResolveVersion(context.Background(), client, "${env}/db/user", "dev", 2); err == nil {
+		t.Error("ResolveVersion() expected an error for a key missing from the version")
+	}
+	if _, err := b.ResolveVersion(context.Background(), client, "${env}/db/password", "dev", 1); err == nil {
+		t.Error("ResolveVersion() expected an error for a missing version")
+	}
+}
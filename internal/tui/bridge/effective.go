@@ -0,0 +1,202 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/resolver"
+	"go.dot.industries/vx/internal/vault"
+)
+
+// EffectiveVar is one variable of the environment vx exec would inject, with
+// the definition it comes from.
+type EffectiveVar struct {
+	Name  string
+	Value string
+	// Secret is true when Value was read from Vault or a command rather
+	// than taken from [defaults].
+	Secret bool
+	// Source is the definition that provides the variable, e.g.
+	// "api/vx.toml [secrets]". Overrides are the definitions it wins over,
+	// highest precedence first.
+	Source    string
+	Overrides []string
+	// Note explains a value other than the mapped one: the default used, or
+	// the variable left unset, because on_error skips the failing secret.
+	Note string
+	// Unset is true when vx exec would not set the variable at all.
+	Unset bool
+	// Err is why the secret could not be read; vx exec would fail on it.
+	Err error
+}
+
+// EffectiveEnv resolves every secret of workspace ("[root]" for the root
+// config alone) in env and returns the complete environment vx exec would
+// inject: the merged defaults with the secrets over them, sorted by name.
+//
+// A secret that cannot be read does not stop the others. If on_error skips
+// it, the variable gets its default or is left unset, as vx exec would do;
+// otherwise the error is recorded on the variable. Mappings of named
+// connections use the tokens Route uses; client may be nil. Cancelling ctx
+// abandons the reads still outstanding.
+func (b *Bridge) EffectiveEnv(
+	ctx context.Context,
+	client *vault.Client,
+	cfg *config.RootConfig,
+	rootDir string,
+	workspace string,
+	env string,
+) ([]EffectiveVar, error) {
+	var wsCfg *config.WorkspaceConfig
+	wsLabel := ""
+	if workspace != "" && workspace != "[root]" {
+		wsPath, err := config.ResolveWorkspacePath(rootDir, workspace, cfg.Workspaces)
+		if err != nil {
+			return nil, fmt.Errorf("resolving workspace path: %w", err)
+		}
+		wsCfg, err = config.LoadWorkspaceConfig(wsPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading workspace config: %w", err)
+		}
+		wsLabel = wsPath
+		if rel, err := filepath.Rel(rootDir, wsPath); err == nil {
+			wsLabel = rel
+		}
+	}
+
+	merged, err := config.Merge(cfg, wsCfg, env)
+	if err != nil {
+		return nil, err
+	}
+
+	values, errs := b.resolveAll(ctx, client, cfg, merged)
+
+	names := make([]string, 0, len(merged.Defaults)+len(merged.Secrets))
+	for name := range merged.Defaults {
+		names = append(names, name)
+	}
+	for name := range merged.Secrets {
+		if _, ok := merged.Defaults[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	vars := make([]EffectiveVar, 0, len(names))
+	for _, name := range names {
+		v := EffectiveVar{Name: name}
+
+		if e, err := config.Explain(cfg, wsCfg, merged.Environment, name); err == nil {
+			for i := len(e.Sources) - 1; i >= 0; i-- {
+				label := sourceLabel(e.Sources[i], wsLabel)
+				if i == len(e.Sources)-1 {
+					v.Source = label
+				} else {
+					v.Overrides = append(v.Overrides, label)
+				}
+			}
+		}
+
+		def, hasDefault := merged.Defaults[name]
+		if _, mapped := merged.Secrets[name]; !mapped {
+			v.Value = def
+			vars = append(vars, v)
+			continue
+		}
+
+		val, ok := values[name]
+		readErr := errs[name]
+		switch {
+		case ok:
+			v.Value, v.Secret = val, true
+		case readErr == nil:
+			v.Unset = true
+			v.Note = "key not found in Vault; left unset"
+		case !merged.SkipOnError(name):
+			v.Err = readErr
+		case hasDefault:
+			v.Value = def
+			v.Note = fmt.Sprintf("secret unavailable, using its default (on_error): %v", readErr)
+		default:
+			v.Unset = true
+			v.Note = fmt.Sprintf("secret unavailable, left unset (on_error): %v", readErr)
+		}
+		vars = append(vars, v)
+	}
+
+	return vars, nil
+}
+
+// resolveAll reads every secret of merged, returning the values read and,
+// separately, why each of the others failed. Each connection and each
+// command is resolved on its own, so one failure leaves the rest readable.
+func (b *Bridge) resolveAll(ctx context.Context, client *vault.Client, cfg *config.RootConfig, merged *config.MergedConfig) (map[string]string, map[string]error) {
+	values := make(map[string]string, len(merged.Secrets))
+	errs := make(map[string]error)
+	var mu sync.Mutex // onSkip runs in the resolver's goroutines
+
+	groups := make(map[string]map[string]string)
+	for envVar, rawPath := range merged.Secrets {
+		if resolver.IsCommand(rawPath) {
+			val, err := b.ResolveSingle(ctx, nil, envVar, rawPath, merged.PathEnv)
+			if err != nil {
+				errs[envVar] = err
+			} else {
+				values[envVar] = val
+			}
+			continue
+		}
+		name, path := config.SplitVault(rawPath)
+		if groups[name] == nil {
+			groups[name] = make(map[string]string)
+		}
+		groups[name][envVar] = path
+	}
+
+	for name, mappings := range groups {
+		c := client
+		var err error
+		switch {
+		case name != "":
+			c, err = b.namedClient(cfg, name)
+		case c == nil:
+			c, err = b.Authenticate(cfg)
+		}
+		if err == nil {
+			r := resolver.New(c, "",
+				resolver.WithTimeout(resolveTimeout),
+				resolver.WithSkipOnError(func(string) bool { return true }, func(envVar string, err error) {
+					mu.Lock()
+					errs[envVar] = err
+					mu.Unlock()
+				}),
+			)
+			var out map[string]string
+			out, err = r.Resolve(ctx, mappings, merged.PathEnv)
+			for k, v := range out {
+				values[k] = v
+			}
+		}
+		if err != nil {
+			for envVar := range mappings {
+				errs[envVar] = err
+			}
+		}
+	}
+
+	return values, errs
+}
+
+// sourceLabel names the file and table of a definition, e.g.
+// "vx.toml [defaults.dev]" or "api/vx.toml [secrets]".
+func sourceLabel(s config.Source, wsLabel string) string {
+	file := "vx.toml"
+	if s.Workspace {
+		file = wsLabel
+	}
+	return file + " [" + s.Table + "]"
+}
@@ -0,0 +1,112 @@
+package bridge
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go.dot.industries/vx/internal/config"
+)
+
+// ChangedFile is a vx.toml file whose changes git has not committed.
+type ChangedFile struct {
+	FileTarget
+	// State is "modified", "added", "deleted", "renamed", or "untracked".
+	State string
+}
+
+// runGit runs git in dir and returns its standard output. It is a variable
+// so tests can stub it.
+var runGit = func(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// UncommittedFiles returns the files of WorkspaceFiles that have staged,
+// unstaged, or untracked changes in git, in the same order. It fails when
+// rootDir is not inside a git work tree or git is not installed.
+func (b *Bridge) UncommittedFiles(cfg *config.RootConfig, rootDir string) ([]ChangedFile, error) {
+	prefix, err := runGit(rootDir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+
+	targets := b.WorkspaceFiles(cfg, rootDir)
+	pathspecs := make([]string, 0, len(targets))
+	byRepoPath := make(map[string]int, len(targets))
+	for i, t := range targets {
+		// Files outside rootDir may be outside the repository, which git
+		// rejects as a pathspec.
+		rel, err := filepath.Rel(rootDir, t.Path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		pathspecs = append(pathspecs, rel)
+		byRepoPath[path.Join(strings.TrimSpace(string(prefix)), filepath.ToSlash(rel))] = i
+	}
+
+	out, err := runGit(rootDir, append([]string{"status", "--porcelain", "-z", "--untracked-files=all", "--"}, pathspecs...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[int]string)
+	for repoPath, state := range parsePorcelain(out) {
+		if i, ok := byRepoPath[repoPath]; ok {
+			states[i] = state
+		}
+	}
+
+	var changed []ChangedFile
+	for i, t := range targets {
+		if state, ok := states[i]; ok {
+			changed = append(changed, ChangedFile{FileTarget: t, State: state})
+		}
+	}
+	return changed, nil
+}
+
+// parsePorcelain reads `git status --porcelain -z` output into the state of
+// each path, relative to the repository root. A renamed file is reported
+// under its new name.
+func parsePorcelain(out []byte) map[string]string {
+	states := make(map[string]string)
+
+	fields := strings.Split(string(out), "\x00")
+	for i := 0; i < len(fields); i++ {
+		entry := fields[i]
+		if len(entry) < 4 {
+			continue
+		}
+		xy, p := entry[:2], entry[3:]
+
+		switch {
+		case xy == "??":
+			states[p] = "untracked"
+		case strings.ContainsAny(xy, "RC"):
+			states[p] = "renamed"
+			// The original path follows as its own field.
+			i++
+		case strings.Contains(xy, "A"):
+			states[p] = "added"
+		case strings.Contains(xy, "D"):
+			states[p] = "deleted"
+		default:
+			states[p] = "modified"
+		}
+	}
+
+	return states
+}
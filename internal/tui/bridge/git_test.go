@@ -0,0 +1,80 @@
+package bridge
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.dot.industries/vx/internal/config"
+)
+
+func TestParsePorcelain(t *testing.T) {
+	out := []byte(" M vx.toml\x00A  api/vx.toml\x00R  web/vx.toml\x00old/vx.toml\x00?? jobs/vx.toml\x00 D gone/vx.toml\x00")
+
+	want := map[string]string{
+		"vx.toml":      "modified",
+		"api/vx.toml":  "added",
+		"web/vx.toml":  "renamed",
+		"jobs/vx.toml": "untracked",
+		"gone/vx.toml": "deleted",
+	}
+	if got := parsePorcelain(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePorcelain() = %v, want %v", got, want)
+	}
+}
+
+func TestUncommittedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	rootDir := filepath.Join(repo, "config")
+	for _, f := range []string{"vx.toml", "api/vx.toml", "web/vx.toml"} {
+		p := filepath.Join(rootDir, f)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("[secrets]\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git := func(args ...string) {
+		t.Helper()
+		if _, err := runGit(repo, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	git("add", "config/vx.toml", "config/api/vx.toml")
+	git("-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "-m", "init")
+
+	cfg := &config.RootConfig{Workspaces: []string{"api/vx.toml", "web/vx.toml"}}
+	b := New("", "", "", "", "")
+
+	changed, err := b.UncommittedFiles(cfg, rootDir)
+	if err != nil {
+		t.Fatalf("UncommittedFiles() error = %v", err)
+	}
+	if len(changed) != 1 || changed[0].Label != "web" || changed[0].State != "untracked" {
+		t.Errorf("UncommittedFiles() = %+v, want web untracked", changed)
+	}
+
+	if err := os.WriteFile(filepath.Join(rootDir, "vx.toml"), []byte("[secrets]\nA = \"x/y\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err = b.UncommittedFiles(cfg, rootDir)
+	if err != nil {
+		t.Fatalf("UncommittedFiles() error = %v", err)
+	}
+	if len(changed) != 2 || changed[0].Label != "[root]" || changed[0].State != "modified" {
+		t.Errorf("UncommittedFiles() = %+v, want [root] modified first", changed)
+	}
+
+	if _, err := b.UncommittedFiles(cfg, t.TempDir()); err == nil {
+		t.Error("UncommittedFiles() outside a repository: expected error")
+	}
+}
@@ -1,155 +1,273 @@
 package bridge
 
 import (
-	"bytes"
+	"cmp"
 	"fmt"
-	"os"
+	"slices"
+	"strings"
 
 	"github.com/creachadair/tomledit"
 	"github.com/creachadair/tomledit/parser"
+	"github.com/creachadair/tomledit/scanner"
 	"github.com/creachadair/tomledit/transform"
+
+	"go.dot.industries/vx/internal/tomlfile"
 )
 
 // AddMapping adds a new KEY = "value" line under the [secrets] section of a
 // vx.toml file. It preserves all existing comments, formatting, and ordering.
-// If the [secrets] section does not exist, it is created.
+// If the [secrets] section does not exist, it is created. Adding a key that
+// is already mapped is an error; use EditMapping to change it.
 func (b *Bridge) AddMapping(filePath, envVar, vaultPath string) error {
-	doc, err := readTOMLDoc(filePath)
+	return editTOMLDoc(filePath, func(doc *tomledit.Document) error {
+		return tomlfile.AddMapping(doc, envVar, vaultPath)
+	})
+}
+
+// EditMapping updates an existing mapping in a vx.toml file. If oldEnvVar
+// differs from newEnvVar, the key is renamed and the value is updated.
+func (b *Bridge) EditMapping(filePath, oldEnvVar, newEnvVar, newPath string) error {
+	return editTOMLDoc(filePath, func(doc *tomledit.Document) error {
+		return tomlfile.SetMapping(doc, oldEnvVar, newEnvVar, newPath)
+	})
+}
+
+// DeleteMapping removes a mapping from the [secrets] section of a vx.toml file.
+func (b *Bridge) DeleteMapping(filePath, envVar string) error {
+	return editTOMLDoc(filePath, func(doc *tomledit.Document) error {
+		return tomlfile.DeleteMapping(doc, envVar)
+	})
+}
+
+// editTOMLDoc applies edit to the vx.toml at filePath and writes it back.
+// Nothing is written when edit fails.
+func editTOMLDoc(filePath string, edit func(doc *tomledit.Document) error) error {
+	doc, err := tomlfile.Read(filePath)
 	if err != nil {
 		return err
 	}
-
-	secretsSection := findSecretsSection(doc)
-	if secretsSection == nil {
-		secretsSection = createSecretsSection(doc)
+	if err := edit(doc); err != nil {
+		return fmt.Errorf("editing %s: %w", filePath, err)
 	}
+	return tomlfile.Write(filePath, doc)
+}
 
-	kv := &parser.KeyValue{
-		Name:  parser.Key{envVar},
-		Value: parser.MustValue(fmt.Sprintf("%q", vaultPath)),
+// DefaultEntry is one value of a [defaults] table in a vx.toml file.
+type DefaultEntry struct {
+	// Env is the environment of a [defaults.<env>] table, or "" for
+	// [defaults] itself.
+	Env   string
+	Key   string
+	Value string // strings unquoted, other values as written
+}
+
+// ReadDefaults returns the values of the [defaults] table of a vx.toml file
+// and of the [defaults.<env>] table of each of envs: [defaults] first, then
+// the environments in the order of envs, each in file order. Environment
+// tables written as inline tables or dotted keys under [defaults] count too.
+func (b *Bridge) ReadDefaults(filePath string, envs []string) ([]DefaultEntry, error) {
+	doc, err := tomlfile.Read(filePath)
+	if err != nil {
+		return nil, err
 	}
 
-	transform.InsertMapping(secretsSection, kv, false)
+	var entries []DefaultEntry
+	doc.Scan(func(key parser.Key, e *tomledit.Entry) bool {
+		if e.IsSection() || len(key) < 2 || key[0] != "defaults" {
+			return true
+		}
+		switch {
+		case len(key) == 2:
+			if _, ok := e.KeyValue.Value.X.(parser.Inline); ok && slices.Contains(envs, key[1]) {
+				return true // an inline environment table; its keys follow
+			}
+			entries = append(entries, DefaultEntry{Key: key[1], Value: defaultText(e.KeyValue.Value)})
+		case len(key) == 3 && slices.Contains(envs, key[1]):
+			entries = append(entries, DefaultEntry{Env: key[1], Key: key[2], Value: defaultText(e.KeyValue.Value)})
+		}
+		return true
+	})
 
-	return writeTOMLDoc(filePath, doc)
+	slices.SortStableFunc(entries, func(a, b DefaultEntry) int {
+		return cmp.Compare(slices.Index(envs, a.Env), slices.Index(envs, b.Env))
+	})
+	return entries, nil
 }
 
-// EditMapping updates an existing mapping in a vx.toml file. If oldEnvVar
-// differs from newEnvVar, the key is renamed and the value is updated.
-func (b *Bridge) EditMapping(filePath, oldEnvVar, newEnvVar, newPath string) error {
-	doc, err := readTOMLDoc(filePath)
+// SetDefault sets key in the [defaults] table of a vx.toml file, or in
+// [defaults.<env>] when env is set, preserving comments and the order of
+// everything else. A new key is added at the end of its table, and a missing
+// table is created next to the other defaults tables. A changed value keeps
+// its trailing comment, and its type when value is valid for it (PORT = 8080
+// stays an integer); anything else is written as a string.
+func (b *Bridge) SetDefault(filePath, env, key, value string) error {
+	doc, err := tomlfile.Read(filePath)
 	if err != nil {
 		return err
 	}
 
-	// Find the existing entry
-	entry := doc.First("secrets", oldEnvVar)
-	if entry == nil {
-		return fmt.Errorf("secret %q not found in [secrets] of %s", oldEnvVar, filePath)
-	}
-
-	if oldEnvVar == newEnvVar {
-		// Same key name — just update the value
-		entry.KeyValue.Value = parser.MustValue(fmt.Sprintf("%q", newPath))
-	} else {
-		// Key name changed — remove old, add new
-		section := findSecretsSection(doc)
-		if section == nil {
-			return fmt.Errorf("no [secrets] section found in %s", filePath)
+	if e := doc.First(defaultKey(env, key)...); e != nil && e.IsMapping() {
+		v, err := defaultValue(e.KeyValue.Value, value)
+		if err != nil {
+			return err
 		}
+		e.KeyValue.Value = v
+		return tomlfile.Write(filePath, doc)
+	}
 
-		entry.Remove()
+	v, err := parser.ParseValue(tomlfile.Quote(value))
+	if err != nil {
+		return fmt.Errorf("default %s: %w", key, err)
+	}
+	kv := &parser.KeyValue{Name: parser.Key{key}, Value: v}
 
-		kv := &parser.KeyValue{
-			Name:  parser.Key{newEnvVar},
-			Value: parser.MustValue(fmt.Sprintf("%q", newPath)),
+	if env != "" {
+		// The environment table may be written inside [defaults]; add the
+		// key there, since a [defaults.<env>] heading would redefine it.
+		if e := doc.First("defaults", env); e != nil && e.IsMapping() {
+			inline, ok := e.KeyValue.Value.X.(parser.Inline)
+			if !ok {
+				return fmt.Errorf("defaults.%s in %s is not a table", env, filePath)
+			}
+			e.KeyValue.Value.X = append(inline, kv)
+			return tomlfile.Write(filePath, doc)
+		}
+		if entry := transform.FindTable(doc, "defaults"); entry != nil && hasDottedTable(entry.Section, env) {
+			kv.Name = parser.Key{env, key}
+			transform.InsertMapping(entry.Section, kv, false)
+			return tomlfile.Write(filePath, doc)
 		}
-		transform.InsertMapping(section, kv, false)
 	}
 
-	return writeTOMLDoc(filePath, doc)
+	name := defaultKey(env, "")
+	var section *tomledit.Section
+	if entry := transform.FindTable(doc, name...); entry != nil {
+		section = entry.Section
+	} else {
+		section = createDefaultsSection(doc, name)
+	}
+	transform.InsertMapping(section, kv, false)
+
+	return tomlfile.Write(filePath, doc)
 }
 
-// DeleteMapping removes a mapping from the [secrets] section of a vx.toml file.
-func (b *Bridge) DeleteMapping(filePath, envVar string) error {
-	doc, err := readTOMLDoc(filePath)
+// DeleteDefault removes key from the [defaults] table of a vx.toml file, or
+// from [defaults.<env>] when env is set. The table itself is kept, with any
+// comments in it.
+func (b *Bridge) DeleteDefault(filePath, env, key string) error {
+	doc, err := tomlfile.Read(filePath)
 	if err != nil {
 		return err
 	}
 
-	entry := doc.First("secrets", envVar)
-	if entry == nil {
-		return fmt.Errorf("secret %q not found in [secrets] of %s", envVar, filePath)
+	name := defaultKey(env, key)
+	entry := doc.First(name...)
+	if entry == nil || !entry.IsMapping() {
+		return fmt.Errorf("default %q not found in [%s] of %s", key, strings.Join(name[:len(name)-1], "."), filePath)
 	}
-
 	if !entry.Remove() {
-		return fmt.Errorf("failed to remove secret %q from %s", envVar, filePath)
+		return fmt.Errorf("failed to remove default %q from %s", key, filePath)
 	}
 
-	return writeTOMLDoc(filePath, doc)
+	return tomlfile.Write(filePath, doc)
 }
 
-// readTOMLDoc reads and parses a TOML file into a document tree.
-func readTOMLDoc(filePath string) (*tomledit.Document, error) {
-	f, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("opening %s: %w", filePath, err)
+// defaultKey returns the full TOML key of a default: defaults.<key>, or
+// defaults.<env>.<key>. An empty key names the table.
+func defaultKey(env, key string) []string {
+	name := []string{"defaults"}
+	if env != "" {
+		name = append(name, env)
 	}
-	defer f.Close()
-
-	doc, err := tomledit.Parse(f)
-	if err != nil {
-		return nil, fmt.Errorf("parsing TOML in %s: %w", filePath, err)
+	if key != "" {
+		name = append(name, key)
 	}
-
-	return doc, nil
+	return name
 }
 
-// writeTOMLDoc writes the TOML document tree back to disk, preserving comments
-// and formatting as much as possible.
-func writeTOMLDoc(filePath string, doc *tomledit.Document) error {
-	var buf bytes.Buffer
-	var fmtr tomledit.Formatter
-	if err := fmtr.Format(&buf, doc); err != nil {
-		return fmt.Errorf("formatting TOML: %w", err)
+// hasDottedTable reports whether section defines keys of table as dotted
+// keys, e.g. staging.LOG_LEVEL = "info" for table "staging".
+func hasDottedTable(section *tomledit.Section, table string) bool {
+	for _, item := range section.Items {
+		if kv, ok := item.(*parser.KeyValue); ok && len(kv.Name) > 1 && kv.Name[0] == table {
+			return true
+		}
 	}
+	return false
+}
 
-	// Preserve original file permissions
-	info, err := os.Stat(filePath)
-	if err != nil {
-		return fmt.Errorf("stat %s: %w", filePath, err)
-	}
+// createDefaultsSection adds a table named name after the last defaults
+// table of the document, or before the first one for [defaults] itself, and
+// at the end when there are none.
+func createDefaultsSection(doc *tomledit.Document, name parser.Key) *tomledit.Section {
+	section := &tomledit.Section{Heading: &parser.Heading{Name: name}}
 
-	if err := os.WriteFile(filePath, buf.Bytes(), info.Mode()); err != nil {
-		return fmt.Errorf("writing %s: %w", filePath, err)
+	at := len(doc.Sections)
+	for i, s := range doc.Sections {
+		if len(s.TableName()) == 0 || s.TableName()[0] != "defaults" {
+			continue
+		}
+		if len(name) == 1 {
+			at = i
+			break
+		}
+		at = i + 1
 	}
 
-	return nil
+	doc.Sections = slices.Insert(doc.Sections, at, section)
+	return section
 }
 
-// findSecretsSection returns the [secrets] section from the document, or nil.
-func findSecretsSection(doc *tomledit.Document) *tomledit.Section {
-	entries := doc.Find("secrets")
-	for _, e := range entries {
-		if e.IsSection() {
-			return e.Section
+// defaultText returns a default value for display: the content of a string,
+// or the value as written for anything else.
+func defaultText(v parser.Value) string {
+	tok, ok := v.X.(parser.Token)
+	if !ok {
+		return v.X.String()
+	}
+
+	s := tok.String()
+	switch tok.Type {
+	case scanner.String:
+		if u, err := scanner.Unescape([]byte(s[1 : len(s)-1])); err == nil {
+			return string(u)
+		}
+	case scanner.MString:
+		if u, err := scanner.Unescape([]byte(strings.TrimPrefix(s[3:len(s)-3], "\n"))); err == nil {
+			return string(u)
 		}
+	case scanner.LString:
+		return s[1 : len(s)-1]
+	case scanner.MLString:
+		return strings.TrimPrefix(s[3:len(s)-3], "\n")
 	}
-	return nil
+	return s
 }
 
-// createSecretsSection adds a new [secrets] section to the document and
-// returns it.
-func createSecretsSection(doc *tomledit.Document) *tomledit.Section {
-	heading := &parser.Heading{
-		Name: parser.Key{"secrets"},
+// defaultValue returns text as the new value of a default that was old. A
+// number, boolean, or date stays one when text is a valid value of that
+// type; everything else becomes a string. Old's trailing comment is kept.
+func defaultValue(old parser.Value, text string) (parser.Value, error) {
+	v, err := parser.ParseValue(tomlfile.Quote(text))
+	if err != nil {
+		return parser.Value{}, err
 	}
 
-	section := &tomledit.Section{
-		Heading: heading,
+	if tok, ok := old.X.(parser.Token); ok && !isStringToken(tok.Type) {
+		if typed, err := parser.ParseValue(text); err == nil {
+			newTok, ok := typed.X.(parser.Token)
+			// Of the bare words only true and false are values.
+			word := ok && newTok.Type == scanner.Word && text != "true" && text != "false"
+			if ok && newTok.Type == tok.Type && !word {
+				v = typed
+			}
+		}
 	}
 
-	doc.Sections = append(doc.Sections, section)
-	return section
+	v.Trailer = old.Trailer
+	return v, nil
 }
 
+func isStringToken(t scanner.Token) bool {
+	return t == scanner.String || t == scanner.MString || t == scanner.LString || t == scanner.MLString
+}
@@ -3,6 +3,7 @@ package bridge
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -72,6 +73,32 @@ address = "https://vault.example.com"
 	}
 }
 
+func TestAddMapping_AlreadyMapped(t *testing.T) {
+	initial := `[secrets]
+DATABASE_URL = "${env}/database/url"
+`
+
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "vx.toml")
+	if err := os.WriteFile(filePath, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := New("", "", "", "", "")
+	err := b.AddMapping(filePath, "DATABASE_URL", "${env}/other/url")
+	if err == nil || !strings.Contains(err.Error(), "already mapped") {
+		t.Fatalf("expected 'already mapped' error, got: %v", err)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != initial {
+		t.Errorf("file changed on a rejected add:\n%s", data)
+	}
+}
+
 func TestAddMapping_PreservesComments(t *testing.T) {
 	initial := `# Root configuration file
 # for the vx secret manager
@@ -254,3 +281,151 @@ DATABASE_URL = "${env}/database/url"
 		t.Fatal("expected error for non-existent key")
 	}
 }
+
+func TestReadDefaults(t *testing.T) {
+	initial := `[defaults]
+LOG_LEVEL = "debug" # verbose locally
+PORT = 8080
+staging.REGION = 'eu-west-1'
+production = { LOG_LEVEL = "warn" }
+
+[defaults.staging]
+LOG_LEVEL = "info"
+
+[defaults.preview]
+LOG_LEVEL = "trace"
+`
+	filePath := filepath.Join(t.TempDir(), "vx.toml")
+	if err := os.WriteFile(filePath, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := New("", "", "", "", "")
+	got, err := b.ReadDefaults(filePath, []string{"dev", "staging", "production"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DefaultEntry{
+		{Key: "LOG_LEVEL", Value: "debug"},
+		{Key: "PORT", Value: "8080"},
+		{Env: "staging", Key: "REGION", Value: "eu-west-1"},
+		{Env: "staging", Key: "LOG_LEVEL", Value: "info"},
+		{Env: "production", Key: "LOG_LEVEL", Value: "warn"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ReadDefaults() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	initial := `# Root config
+[vault]
+address = "https://vault.example.com"
+
+# Local fallbacks
+[defaults]
+LOG_LEVEL = "debug" # verbose locally
+PORT = 8080
+
+[defaults.staging]
+# Staging logs less
+LOG_LEVEL = "info"
+
+[secrets]
+DATABASE_URL = "${env}/database/url"
+`
+	filePath := filepath.Join(t.TempDir(), "vx.toml")
+	if err := os.WriteFile(filePath, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := New("", "", "", "", "")
+	for _, set := range []struct{ env, key, value string }{
+		{"", "LOG_LEVEL", "warn"},
+		{"", "PORT", "9090"},
+		{"staging", "REGION", `eu "west"`},
+		{"production", "LOG_LEVEL", "error"},
+	} {
+		if err := b.SetDefault(filePath, set.env, set.key, set.value); err != nil {
+			t.Fatalf("SetDefault(%q, %q) error = %v", set.env, set.key, err)
+		}
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	for _, want := range []string{
+		"# Root config",
+		"# Local fallbacks",
+		`LOG_LEVEL = "warn"  # verbose locally`,
+		"PORT = 9090",
+		"# Staging logs less",
+		`REGION = "eu \"west\""`,
+		"[defaults.production]\nLOG_LEVEL = \"error\"",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("output missing %q:\n%s", want, content)
+		}
+	}
+	if i, j := strings.Index(content, "[defaults.production]"), strings.Index(content, "[secrets]"); i > j {
+		t.Errorf("[defaults.production] not added next to the other defaults tables:\n%s", content)
+	}
+
+	if err := b.SetDefault(filePath, "", "PORT", "auto"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.DeleteDefault(filePath, "staging", "LOG_LEVEL"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.DeleteDefault(filePath, "staging", "LOG_LEVEL"); err == nil {
+		t.Error("DeleteDefault() of a missing key succeeded")
+	}
+	got, err := b.ReadDefaults(filePath, []string{"staging", "production"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DefaultEntry{
+		{Key: "LOG_LEVEL", Value: "warn"},
+		{Key: "PORT", Value: "auto"},
+		{Env: "staging", Key: "REGION", Value: `eu "west"`},
+		{Env: "production", Key: "LOG_LEVEL", Value: "error"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ReadDefaults() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestSetDefault_EnvTableInDefaults(t *testing.T) {
+	initial := `[defaults]
+staging.LOG_LEVEL = "info"
+production = { LOG_LEVEL = "warn" }
+`
+	filePath := filepath.Join(t.TempDir(), "vx.toml")
+	if err := os.WriteFile(filePath, []byte(initial), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := New("", "", "", "", "")
+	if err := b.SetDefault(filePath, "staging", "REGION", "eu"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetDefault(filePath, "production", "REGION", "us"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if strings.Contains(content, "[defaults.") {
+		t.Errorf("added a table heading that redefines an environment table:\n%s", content)
+	}
+	for _, want := range []string{`staging.REGION = "eu"`, `REGION = "us"`} {
+		if !strings.Contains(content, want) {
+			t.Errorf("output missing %q:\n%s", want, content)
+		}
+	}
+}
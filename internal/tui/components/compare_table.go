@@ -0,0 +1,198 @@
+package components
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	cmpOnly = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#10B981"))
+
+	cmpDiffers = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#F59E0B"))
+
+	cmpMissing = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#4B5563"))
+)
+
+// CompareKind classifies a key in a comparison of two workspaces.
+type CompareKind int
+
+const (
+	CompareSame CompareKind = iota
+	CompareDiffers
+	CompareLeftOnly
+	CompareRightOnly
+)
+
+// CompareRow is one env var and the path template each side maps it to.
+// Left or Right is empty when the key is mapped on one side only.
+type CompareRow struct {
+	EnvVar string
+	Left   string
+	Right  string
+	Kind   CompareKind
+}
+
+// CompareTable holds the state for the side-by-side workspace comparison.
+// Both panes share one cursor, so a key is always on the same line in each.
+type CompareTable struct {
+	LeftName  string
+	RightName string
+	Rows      []CompareRow
+	Cursor    int
+	Offset    int            // scroll offset for viewport
+	Accent    lipgloss.Color // selection color; empty uses the default
+}
+
+// NewCompareTable builds the comparison of two workspaces' merged secrets
+// (env var -> path template), sorted by env var.
+func NewCompareTable(leftName string, left map[string]string, rightName string, right map[string]string) CompareTable {
+	rows := make([]CompareRow, 0, len(left)+len(right))
+	for envVar, l := range left {
+		row := CompareRow{EnvVar: envVar, Left: l, Kind: CompareLeftOnly}
+		if r, ok := right[envVar]; ok {
+			row.Right = r
+			row.Kind = CompareSame
+			if r != l {
+				row.Kind = CompareDiffers
+			}
+		}
+		rows = append(rows, row)
+	}
+	for envVar, r := range right {
+		if _, ok := left[envVar]; !ok {
+			rows = append(rows, CompareRow{EnvVar: envVar, Right: r, Kind: CompareRightOnly})
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].EnvVar < rows[j].EnvVar
+	})
+
+	return CompareTable{LeftName: leftName, RightName: rightName, Rows: rows}
+}
+
+// Count returns the number of rows of the given kind.
+func (ct *CompareTable) Count(kind CompareKind) int {
+	n := 0
+	for _, row := range ct.Rows {
+		if row.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// MoveUp moves the cursor up by one.
+func (ct *CompareTable) MoveUp() {
+	if ct.Cursor > 0 {
+		ct.Cursor--
+	}
+}
+
+// MoveDown moves the cursor down by one.
+func (ct *CompareTable) MoveDown() {
+	if ct.Cursor < len(ct.Rows)-1 {
+		ct.Cursor++
+	}
+}
+
+// View renders one side of the comparison: the right workspace when right
+// is true, the left one otherwise. Keys the side lacks are shown dimmed with
+// "—" so rows stay aligned across the panes.
+func (ct *CompareTable) View(width, height int, right bool) string {
+	var b strings.Builder
+
+	name, only, onlyKind := ct.LeftName, ct.Count(CompareLeftOnly), CompareLeftOnly
+	if right {
+		name, only, onlyKind = ct.RightName, ct.Count(CompareRightOnly), CompareRightOnly
+	}
+
+	titleLeft := stTitle.Render(name)
+	countStr := fmt.Sprintf("%d only here, %d differ", only, ct.Count(CompareDiffers))
+	spacer := width - lipgloss.Width(titleLeft) - lipgloss.Width(countStr) - 2
+	if spacer < 1 {
+		spacer = 1
+	}
+
+	b.WriteString(titleLeft)
+	b.WriteString(lipgloss.NewStyle().Width(spacer).Render(""))
+	b.WriteString(stTitle.Render(countStr))
+	b.WriteString("\n")
+
+	if len(ct.Rows) == 0 {
+		b.WriteString(lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#6B7280")).
+			Italic(true).
+			Render("  No secrets found"))
+		return lipgloss.NewStyle().
+			Width(width).
+			Height(height).
+			Render(b.String())
+	}
+
+	viewportHeight := height - 2 // title + margin
+	if viewportHeight < 1 {
+		viewportHeight = 1
+	}
+
+	if ct.Cursor < ct.Offset {
+		ct.Offset = ct.Cursor
+	}
+	if ct.Cursor >= ct.Offset+viewportHeight {
+		ct.Offset = ct.Cursor - viewportHeight + 1
+	}
+
+	selected := stSelected
+	if ct.Accent != "" {
+		selected = selected.Foreground(ct.Accent)
+	}
+
+	envVarWidth := width * 2 / 5
+	pathWidth := width - envVarWidth - 5 // prefix, marker, and spaces
+
+	for i := ct.Offset; i < len(ct.Rows) && i < ct.Offset+viewportHeight; i++ {
+		row := ct.Rows[i]
+		path := row.Left
+		if right {
+			path = row.Right
+		}
+
+		marker, markerStyle := " ", stPath
+		nameStyle, pathStyle := stNormal, stPath
+		switch {
+		case row.Kind == onlyKind:
+			marker, markerStyle = "+", cmpOnly
+		case row.Kind == CompareDiffers:
+			marker, markerStyle = "~", cmpDiffers
+			pathStyle = cmpDiffers
+		case path == "":
+			path = "—"
+			nameStyle, pathStyle = cmpMissing, cmpMissing
+		}
+
+		prefix := "  "
+		if i == ct.Cursor {
+			prefix = "> "
+			nameStyle = selected
+		}
+
+		line := prefix + markerStyle.Render(marker) + " " +
+			nameStyle.Render(padRight(truncate(row.EnvVar, envVarWidth), envVarWidth)) + " " +
+			pathStyle.Render(truncate(path, pathWidth))
+		b.WriteString(line)
+		if i < ct.Offset+viewportHeight-1 {
+			b.WriteString("\n")
+		}
+	}
+
+	return lipgloss.NewStyle().
+		Width(width).
+		Height(height).
+		Render(b.String())
+}
@@ -0,0 +1,68 @@
+package components
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewCompareTable(t *testing.T) {
+	left := map[string]string{
+		"API_KEY":      "${env}/api/key",
+		"DATABASE_URL": "${env}/database/url",
+		"WEB_ONLY":     "${env}/web/token",
+	}
+	right := map[string]string{
+		"API_KEY":      "${env}/api/key",
+		"DATABASE_URL": "${env}/orders/database/url",
+		"ORDERS_QUEUE": "${env}/orders/queue",
+	}
+
+	ct := NewCompareTable("web", left, "orders", right)
+
+	want := []CompareRow{
+		{EnvVar: "API_KEY", Left: "${env}/api/key", Right: "${env}/api/key", Kind: CompareSame},
+		{EnvVar: "DATABASE_URL", Left: "${env}/database/url", Right: "${env}/orders/database/url", Kind: CompareDiffers},
+		{EnvVar: "ORDERS_QUEUE", Right: "${env}/orders/queue", Kind: CompareRightOnly},
+		{EnvVar: "WEB_ONLY", Left: "${env}/web/token", Kind: CompareLeftOnly},
+	}
+	if len(ct.Rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(ct.Rows), len(want))
+	}
+	for i := range want {
+		if ct.Rows[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, ct.Rows[i], want[i])
+		}
+	}
+
+	if n := ct.Count(CompareDiffers); n != 1 {
+		t.Errorf("Count(CompareDiffers) = %d, want 1", n)
+	}
+}
+
+func TestCompareTable_View(t *testing.T) {
+	ct := NewCompareTable("web", map[string]string{"A": "x/a"}, "api", map[string]string{"B": "x/b"})
+
+	left := ct.View(60, 10, false)
+	if !strings.Contains(left, "web") || !strings.Contains(left, "x/a") || !strings.Contains(left, "—") {
+		t.Errorf("left pane missing workspace, path, or placeholder:\n%s", left)
+	}
+
+	right := ct.View(60, 10, true)
+	if !strings.Contains(right, "api") || !strings.Contains(right, "x/b") {
+		t.Errorf("right pane missing workspace or path:\n%s", right)
+	}
+}
+
+func TestCompareTable_Navigation(t *testing.T) {
+	ct := NewCompareTable("a", map[string]string{"A": "p/a", "B": "p/b"}, "b", nil)
+
+	ct.MoveUp()
+	if ct.Cursor != 0 {
+		t.Errorf("cursor = %d after MoveUp at top, want 0", ct.Cursor)
+	}
+	ct.MoveDown()
+	ct.MoveDown()
+	if ct.Cursor != 1 {
+		t.Errorf("cursor = %d after MoveDown past end, want 1", ct.Cursor)
+	}
+}
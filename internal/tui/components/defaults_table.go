@@ -0,0 +1,173 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DefaultRow is one value of a [defaults] or [defaults.<env>] table.
+type DefaultRow struct {
+	Env   string // "" for [defaults]
+	Key   string
+	Value string
+}
+
+// Table returns the name of the row's table, e.g. "defaults.staging".
+func (r DefaultRow) Table() string {
+	if r.Env == "" {
+		return "defaults"
+	}
+	return "defaults." + r.Env
+}
+
+// DefaultsTable holds the state for the defaults view: the [defaults] and
+// [defaults.<env>] tables of one vx.toml, with the values the file gives in
+// the selected environment marked.
+type DefaultsTable struct {
+	Title  string // the file, e.g. "api/vx.toml"
+	Env    string // the selected environment
+	Rows   []DefaultRow
+	Cursor int
+	Offset int            // scroll offset for viewport
+	Accent lipgloss.Color // selection color; empty uses the default
+}
+
+// InEffect reports whether row is the value the file gives its key in Env:
+// a row of [defaults.<Env>], or a row of [defaults] that table does not
+// override.
+func (dt *DefaultsTable) InEffect(row DefaultRow) bool {
+	if row.Env != "" {
+		return row.Env == dt.Env
+	}
+	for _, r := range dt.Rows {
+		if r.Env != "" && r.Env == dt.Env && r.Key == row.Key {
+			return false
+		}
+	}
+	return true
+}
+
+// Selected returns the row under the cursor, or nil if there are none.
+func (dt *DefaultsTable) Selected() *DefaultRow {
+	if dt.Cursor < 0 || dt.Cursor >= len(dt.Rows) {
+		return nil
+	}
+	return &dt.Rows[dt.Cursor]
+}
+
+// Select moves the cursor to the key of env's table and reports whether it
+// was found.
+func (dt *DefaultsTable) Select(env, key string) bool {
+	for i, r := range dt.Rows {
+		if r.Env == env && r.Key == key {
+			dt.Cursor = i
+			return true
+		}
+	}
+	return false
+}
+
+// MoveUp moves the cursor up by one.
+func (dt *DefaultsTable) MoveUp() {
+	if dt.Cursor > 0 {
+		dt.Cursor--
+	}
+}
+
+// MoveDown moves the cursor down by one.
+func (dt *DefaultsTable) MoveDown() {
+	if dt.Cursor < len(dt.Rows)-1 {
+		dt.Cursor++
+	}
+}
+
+// View renders the values, one per line with their table, and below them
+// whether the selected one is in effect in Env.
+func (dt *DefaultsTable) View(width, height int) string {
+	var b strings.Builder
+
+	titleLeft := stTitle.Render("Defaults: " + dt.Title)
+	countStr := fmt.Sprintf("%d values", len(dt.Rows))
+	spacer := width - lipgloss.Width(titleLeft) - lipgloss.Width(countStr) - 2
+	if spacer < 1 {
+		spacer = 1
+	}
+
+	b.WriteString(titleLeft)
+	b.WriteString(lipgloss.NewStyle().Width(spacer).Render(""))
+	b.WriteString(stTitle.Render(countStr))
+	b.WriteString("\n")
+
+	if len(dt.Rows) == 0 {
+		b.WriteString(lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#6B7280")).
+			Italic(true).
+			Render("  No defaults (press a to add one)"))
+		return lipgloss.NewStyle().
+			Width(width).
+			Height(height).
+			Render(b.String())
+	}
+
+	viewportHeight := height - 4 // title, margin, and the detail line
+	if viewportHeight < 1 {
+		viewportHeight = 1
+	}
+
+	if dt.Cursor < dt.Offset {
+		dt.Offset = dt.Cursor
+	}
+	if dt.Cursor >= dt.Offset+viewportHeight {
+		dt.Offset = dt.Cursor - viewportHeight + 1
+	}
+
+	selected := stSelected
+	if dt.Accent != "" {
+		selected = selected.Foreground(dt.Accent)
+	}
+
+	tableWidth := width / 4
+	keyWidth := width / 3
+	valueWidth := width - tableWidth - keyWidth - 6 // prefix, marker, and spaces
+
+	for i := dt.Offset; i < len(dt.Rows) && i < dt.Offset+viewportHeight; i++ {
+		row := dt.Rows[i]
+
+		marker := " "
+		if dt.InEffect(row) {
+			marker = "*"
+		}
+
+		prefix := "  "
+		keyStyle := stNormal
+		if i == dt.Cursor {
+			prefix = "> "
+			keyStyle = selected
+		}
+
+		line := prefix + selected.Render(marker) + " " +
+			stFreshness.Render(padRight(truncate("["+row.Table()+"]", tableWidth), tableWidth)) + " " +
+			keyStyle.Render(padRight(truncate(row.Key, keyWidth), keyWidth)) + " " +
+			stPath.Render(truncate(row.Value, valueWidth))
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n")
+	if row := dt.Selected(); row != nil {
+		note := fmt.Sprintf("* in effect in %s", dt.Env)
+		switch {
+		case row.Env != "" && row.Env != dt.Env:
+			note = fmt.Sprintf("%s applies in %s only", row.Key, row.Env)
+		case !dt.InEffect(*row):
+			note = fmt.Sprintf("%s is overridden in %s by [defaults.%s]", row.Key, dt.Env, dt.Env)
+		}
+		b.WriteString(stPath.Render(truncate(note, width)))
+	}
+
+	return lipgloss.NewStyle().
+		Width(width).
+		Height(height).
+		Render(b.String())
+}
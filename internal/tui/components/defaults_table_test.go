@@ -0,0 +1,46 @@
+package components
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultsTable(t *testing.T) {
+	dt := DefaultsTable{
+		Title: "api/vx.toml",
+		Env:   "staging",
+		Rows: []DefaultRow{
+			{Key: "LOG_LEVEL", Value: "debug"},
+			{Key: "PORT", Value: "8080"},
+			{Env: "staging", Key: "LOG_LEVEL", Value: "info"},
+			{Env: "production", Key: "LOG_LEVEL", Value: "warn"},
+		},
+	}
+
+	var inEffect []string
+	for _, row := range dt.Rows {
+		if dt.InEffect(row) {
+			inEffect = append(inEffect, row.Table()+"."+row.Key)
+		}
+	}
+	if got := strings.Join(inEffect, " "); got != "defaults.PORT defaults.staging.LOG_LEVEL" {
+		t.Errorf("rows in effect = %s", got)
+	}
+
+	view := dt.View(100, 12)
+	for _, want := range []string{"4 values", "[defaults.staging]", "LOG_LEVEL is overridden in staging by [defaults.staging]"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() missing %q:\n%s", want, view)
+		}
+	}
+
+	if !dt.Select("production", "LOG_LEVEL") || dt.Selected().Value != "warn" {
+		t.Fatalf("Select() did not select the production row: %+v", dt.Selected())
+	}
+	if view := dt.View(100, 12); !strings.Contains(view, "LOG_LEVEL applies in production only") {
+		t.Errorf("View() should note the row's environment:\n%s", view)
+	}
+	if dt.Select("dev", "LOG_LEVEL") {
+		t.Error("Select() found a row of a table that does not exist")
+	}
+}
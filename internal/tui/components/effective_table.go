@@ -0,0 +1,199 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// effMask replaces a secret value until the row is revealed.
+const effMask = "••••••••"
+
+var (
+	effNote = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#F59E0B"))
+
+	effError = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#EF4444"))
+)
+
+// EffectiveRow is one variable of the environment vx exec would inject.
+type EffectiveRow struct {
+	Name  string
+	Value string
+	// Secret values are masked until Revealed; defaults are shown as they
+	// are, since they are written in vx.toml anyway.
+	Secret   bool
+	Revealed bool
+	// Source is the definition providing the value and Overrides the ones
+	// it wins over, highest precedence first.
+	Source    string
+	Overrides []string
+	// Note explains a value other than the mapped one, e.g. a default used
+	// because the secret could not be read.
+	Note  string
+	Unset bool
+	// Err is set when the secret could not be read and vx exec would fail.
+	Err string
+}
+
+// Display returns the value as shown in the table: masked for an unrevealed
+// secret, and a placeholder for a variable without a value.
+func (r EffectiveRow) Display() string {
+	switch {
+	case r.Err != "":
+		return "(error)"
+	case r.Unset:
+		return "(unset)"
+	case r.Secret && !r.Revealed:
+		return effMask
+	}
+	return r.Value
+}
+
+// EffectiveTable holds the state for the effective environment view: every
+// variable a vx exec would see, with the file and table it comes from.
+type EffectiveTable struct {
+	Title  string // e.g. "api in staging"
+	Rows   []EffectiveRow
+	Cursor int
+	Offset int            // scroll offset for viewport
+	Accent lipgloss.Color // selection color; empty uses the default
+}
+
+// Selected returns the row under the cursor, or nil if there are none.
+func (et *EffectiveTable) Selected() *EffectiveRow {
+	if et.Cursor < 0 || et.Cursor >= len(et.Rows) {
+		return nil
+	}
+	return &et.Rows[et.Cursor]
+}
+
+// Count returns the number of secret rows and of rows with an error.
+func (et *EffectiveTable) Count() (secrets, errors int) {
+	for _, row := range et.Rows {
+		if row.Secret {
+			secrets++
+		}
+		if row.Err != "" {
+			errors++
+		}
+	}
+	return secrets, errors
+}
+
+// MoveUp moves the cursor up by one.
+func (et *EffectiveTable) MoveUp() {
+	if et.Cursor > 0 {
+		et.Cursor--
+	}
+}
+
+// MoveDown moves the cursor down by one.
+func (et *EffectiveTable) MoveDown() {
+	if et.Cursor < len(et.Rows)-1 {
+		et.Cursor++
+	}
+}
+
+// View renders the variables, one per line with the value and its source,
+// and below them the overrides and any note or error of the selected one.
+func (et *EffectiveTable) View(width, height int) string {
+	var b strings.Builder
+
+	secrets, errors := et.Count()
+	titleLeft := stTitle.Render("Effective environment: " + et.Title)
+	countStr := fmt.Sprintf("%d vars, %d secrets", len(et.Rows), secrets)
+	if errors > 0 {
+		countStr += fmt.Sprintf(", %d failed", errors)
+	}
+	spacer := width - lipgloss.Width(titleLeft) - lipgloss.Width(countStr) - 2
+	if spacer < 1 {
+		spacer = 1
+	}
+
+	b.WriteString(titleLeft)
+	b.WriteString(lipgloss.NewStyle().Width(spacer).Render(""))
+	b.WriteString(stTitle.Render(countStr))
+	b.WriteString("\n")
+
+	if len(et.Rows) == 0 {
+		b.WriteString(lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#6B7280")).
+			Italic(true).
+			Render("  No variables would be set"))
+		return lipgloss.NewStyle().
+			Width(width).
+			Height(height).
+			Render(b.String())
+	}
+
+	viewportHeight := height - 5 // title, margin, and the detail lines
+	if viewportHeight < 1 {
+		viewportHeight = 1
+	}
+
+	if et.Cursor < et.Offset {
+		et.Offset = et.Cursor
+	}
+	if et.Cursor >= et.Offset+viewportHeight {
+		et.Offset = et.Cursor - viewportHeight + 1
+	}
+
+	selected := stSelected
+	if et.Accent != "" {
+		selected = selected.Foreground(et.Accent)
+	}
+
+	nameWidth := width / 3
+	valueWidth := width / 3
+	sourceWidth := width - nameWidth - valueWidth - 6 // prefix, marker, and spaces
+
+	for i := et.Offset; i < len(et.Rows) && i < et.Offset+viewportHeight; i++ {
+		row := et.Rows[i]
+
+		marker, markerStyle := " ", stPath
+		nameStyle, valueStyle := stNormal, stPath
+		switch {
+		case row.Err != "":
+			marker, markerStyle = "!", effError
+			valueStyle = effError
+		case row.Note != "":
+			marker, markerStyle = "~", effNote
+			valueStyle = effNote
+		}
+
+		prefix := "  "
+		if i == et.Cursor {
+			prefix = "> "
+			nameStyle = selected
+		}
+
+		line := prefix + markerStyle.Render(marker) + " " +
+			nameStyle.Render(padRight(truncate(row.Name, nameWidth), nameWidth)) + " " +
+			valueStyle.Render(padRight(truncate(row.Display(), valueWidth), valueWidth)) + " " +
+			stFreshness.Render(truncate(row.Source, sourceWidth))
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n")
+	if row := et.Selected(); row != nil {
+		overrides := "overrides nothing"
+		if len(row.Overrides) > 0 {
+			overrides = "overrides " + strings.Join(row.Overrides, ", ")
+		}
+		b.WriteString(stPath.Render(truncate(row.Name+" "+overrides, width)) + "\n")
+		switch {
+		case row.Err != "":
+			b.WriteString(effError.Render(truncate("vx exec would fail: "+row.Err, width)))
+		case row.Note != "":
+			b.WriteString(effNote.Render(truncate(row.Note, width)))
+		}
+	}
+
+	return lipgloss.NewStyle().
+		Width(width).
+		Height(height).
+		Render(b.String())
+}
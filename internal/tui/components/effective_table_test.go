@@ -0,0 +1,51 @@
+package components
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEffectiveRowDisplay(t *testing.T) {
+	tests := []struct {
+		row  EffectiveRow
+		want string
+	}{
+		{EffectiveRow{Value: "info"}, "info"},
+		{EffectiveRow{Value: "s3cret", Secret: true}, effMask},
+		{EffectiveRow{Value: "s3cret", Secret: true, Revealed: true}, "s3cret"},
+		{EffectiveRow{Secret: true, Unset: true}, "(unset)"},
+		{EffectiveRow{Secret: true, Err: "permission denied"}, "(error)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.row.Display(); got != tt.want {
+			t.Errorf("Display(%+v) = %q, want %q", tt.row, got, tt.want)
+		}
+	}
+}
+
+func TestEffectiveTableView(t *testing.T) {
+	et := EffectiveTable{
+		Title: "api in dev",
+		Rows: []EffectiveRow{
+			{Name: "DB_URL", Value: "pg://db", Secret: true, Source: "vx.toml [secrets]", Overrides: []string{"vx.toml [defaults]"}},
+			{Name: "TOKEN", Secret: true, Source: "vx.toml [secrets]", Err: "permission denied"},
+		},
+	}
+
+	if secrets, errors := et.Count(); secrets != 2 || errors != 1 {
+		t.Errorf("Count() = %d, %d; want 2, 1", secrets, errors)
+	}
+
+	view := et.View(100, 12)
+	for _, want := range []string{"2 vars, 2 secrets, 1 failed", effMask, "DB_URL overrides vx.toml [defaults]"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() missing %q:\n%s", want, view)
+		}
+	}
+
+	et.MoveDown()
+	if view := et.View(100, 12); !strings.Contains(view, "vx exec would fail: permission denied") {
+		t.Errorf("View() should show the selected row's error:\n%s", view)
+	}
+}
@@ -16,6 +16,9 @@ var (
 
 	footerSep = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#374151"))
+
+	footerPath = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#6B7280"))
 )
 
 type footerBinding struct {
@@ -24,7 +27,9 @@ type footerBinding struct {
 }
 
 // RenderFooter returns the keybinding hints bar for the given focus mode.
-func RenderFooter(width int, filtering bool, popupOpen bool) string {
+// When filePath is set (the selected workspace's vx.toml) it is shown at the
+// right edge, space permitting.
+func RenderFooter(width int, filtering bool, popupOpen bool, filePath string) string {
 	var bindings []footerBinding
 
 	if popupOpen {
@@ -47,6 +52,7 @@ func RenderFooter(width int, filtering bool, popupOpen bool) string {
 			{"r", "edit"},
 			{"d", "del"},
 			{"c", "copy"},
+			{"o", "open"},
 			{"?", "help"},
 			{"q", "quit"},
 		}
@@ -63,5 +69,12 @@ func RenderFooter(width int, filtering bool, popupOpen bool) string {
 		line = line[:width]
 	}
 
+	if filePath != "" && !popupOpen && !filtering {
+		path := footerPath.Render(filePath)
+		if gap := width - lipgloss.Width(line) - lipgloss.Width(path); gap >= 2 {
+			line += strings.Repeat(" ", gap) + path
+		}
+	}
+
 	return lipgloss.NewStyle().Width(width).Render(line)
 }
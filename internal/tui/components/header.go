@@ -17,10 +17,18 @@ var (
 			Padding(0, 1)
 )
 
-// RenderHeader returns the header bar with title and environment badge.
-func RenderHeader(width int, env string) string {
-	title := headerTitle.Render("vx — Secret Browser")
-	badge := headerEnvBadge.Render(fmt.Sprintf("env: %s", env))
+// RenderHeader returns the header bar with title and environment badge. The
+// accent color tints both; an empty accent keeps the default violet.
+func RenderHeader(width int, env string, accent lipgloss.Color) string {
+	titleStyle := headerTitle
+	badgeStyle := headerEnvBadge
+	if accent != "" {
+		titleStyle = titleStyle.Foreground(accent)
+		badgeStyle = badgeStyle.Background(accent)
+	}
+
+	title := titleStyle.Render("vx — Secret Browser")
+	badge := badgeStyle.Render(fmt.Sprintf("env: %s", env))
 
 	spacer := width - lipgloss.Width(title) - lipgloss.Width(badge)
 	if spacer < 1 {
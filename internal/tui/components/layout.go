@@ -52,19 +52,26 @@ func CalculateLayout(termWidth, termHeight int) LayoutDimensions {
 }
 
 // RenderDualPane combines the left and right panes with borders and styling.
+// The focused pane's border uses accent, or the default violet when empty.
 func RenderDualPane(
 	left string,
 	right string,
 	leftFocused bool,
 	dims LayoutDimensions,
+	accent lipgloss.Color,
 ) string {
 	leftBorder := paneBorder
 	rightBorder := paneBorder
 
+	focused := paneBorderFocused
+	if accent != "" {
+		focused = focused.BorderForeground(accent)
+	}
+
 	if leftFocused {
-		leftBorder = paneBorderFocused
+		leftBorder = focused
 	} else {
-		rightBorder = paneBorderFocused
+		rightBorder = focused
 	}
 
 	leftPane := leftBorder.
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 )
 
 var (
@@ -26,6 +27,9 @@ var (
 	stFocusedRow = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#F9FAFB")).
 			Bold(true)
+
+	stFreshness = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#6B7280"))
 )
 
 // SecretRow represents a single secret mapping for display.
@@ -43,6 +47,10 @@ type SecretTable struct {
 	Focused  bool
 	Filter   string
 	Offset   int // scroll offset for viewport
+	Accent   lipgloss.Color // selection color; empty uses the default
+	// Freshness labels rows whose value is cached, e.g. "3m ago", keyed by
+	// env var; it is shown dimmed after the path
+	Freshness map[string]string
 }
 
 // NewSecretTable creates a table from secret mappings.
@@ -124,6 +132,18 @@ func (st *SecretTable) Selected() *SecretRow {
 	return nil
 }
 
+// Select moves the cursor to the row for envVar. It reports whether the row
+// is visible; the cursor is left alone otherwise.
+func (st *SecretTable) Select(envVar string) bool {
+	for i, row := range st.Rows {
+		if row.EnvVar == envVar {
+			st.Cursor = i
+			return true
+		}
+	}
+	return false
+}
+
 // MoveUp moves the cursor up by one.
 func (st *SecretTable) MoveUp() {
 	if st.Cursor > 0 {
@@ -194,6 +214,11 @@ func (st *SecretTable) View(width, height int) string {
 		st.Offset = st.Cursor - viewportHeight + 1
 	}
 
+	selected := stSelected
+	if st.Accent != "" {
+		selected = selected.Foreground(st.Accent)
+	}
+
 	// Column widths: envVar gets ~40% of space, path gets the rest
 	envVarWidth := width * 2 / 5
 	pathWidth := width - envVarWidth - 3 // 3 for prefix + space
@@ -207,8 +232,8 @@ func (st *SecretTable) View(width, height int) string {
 		if i == st.Cursor {
 			prefix = "> "
 			if st.Focused {
-				nameStyle = stSelected
-				pathStyle = stSelected
+				nameStyle = selected
+				pathStyle = selected
 			} else {
 				nameStyle = stFocusedRow
 			}
@@ -216,8 +241,13 @@ func (st *SecretTable) View(width, height int) string {
 
 		envVar := truncate(row.EnvVar, envVarWidth)
 		vaultPath := truncate(row.VaultPath, pathWidth)
+		age := ""
+		if label := st.Freshness[row.EnvVar]; label != "" {
+			vaultPath = truncate(row.VaultPath, pathWidth-runewidth.StringWidth(label)-3)
+			age = " " + stFreshness.Render("· "+label)
+		}
 
-		line := prefix + nameStyle.Render(padRight(envVar, envVarWidth)) + " " + pathStyle.Render(vaultPath)
+		line := prefix + nameStyle.Render(padRight(envVar, envVarWidth)) + " " + pathStyle.Render(vaultPath) + age
 		b.WriteString(line)
 		if i < st.Offset+viewportHeight-1 {
 			b.WriteString("\n")
@@ -230,18 +260,16 @@ func (st *SecretTable) View(width, height int) string {
 		Render(b.String())
 }
 
-// truncate shortens a string to maxLen with ellipsis.
+// truncate shortens a string to maxLen terminal cells with an ellipsis,
+// never splitting a wide character.
 func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen || maxLen < 4 {
+	if runewidth.StringWidth(s) <= maxLen || maxLen < 4 {
 		return s
 	}
-	return s[:maxLen-1] + "…"
+	return runewidth.Truncate(s, maxLen, "…")
 }
 
-// padRight pads a string with spaces to the given width.
+// padRight pads a string with spaces to the given width in terminal cells.
 func padRight(s string, width int) string {
-	if len(s) >= width {
-		return s
-	}
-	return s + strings.Repeat(" ", width-len(s))
+	return runewidth.FillRight(s, width)
 }
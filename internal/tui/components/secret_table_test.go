@@ -1,7 +1,9 @@
 package components
 
 import (
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestNewSecretTable(t *testing.T) {
@@ -145,6 +147,29 @@ func TestSecretTable_Selected(t *testing.T) {
 	}
 }
 
+func TestSecretTable_Select(t *testing.T) {
+	table := NewSecretTable(map[string]string{
+		"A_KEY": "a/key",
+		"B_KEY": "b/key",
+		"C_KEY": "c/key",
+	}, "dev")
+
+	if !table.Select("C_KEY") {
+		t.Fatal("expected C_KEY to be found")
+	}
+	if table.Selected().EnvVar != "C_KEY" {
+		t.Errorf("expected C_KEY selected, got %s", table.Selected().EnvVar)
+	}
+
+	table.ApplyFilter("b_")
+	if table.Select("A_KEY") {
+		t.Error("expected filtered-out A_KEY not to be found")
+	}
+	if table.Selected().EnvVar != "B_KEY" {
+		t.Errorf("expected cursor left on B_KEY, got %s", table.Selected().EnvVar)
+	}
+}
+
 func TestSecretTable_EmptyTable(t *testing.T) {
 	table := NewSecretTable(map[string]string{}, "dev")
 
@@ -177,3 +202,66 @@ func TestSecretTable_FilterResetsCursor(t *testing.T) {
 		t.Errorf("cursor %d exceeds filtered length %d", table.Cursor, table.Len())
 	}
 }
+
+func TestSecretTable_Freshness(t *testing.T) {
+	table := NewSecretTable(map[string]string{
+		"API_KEY":      "${env}/api/key",
+		"DATABASE_URL": "${env}/database/url",
+	}, "dev")
+	table.Freshness = map[string]string{"API_KEY": "3m ago"}
+
+	lines := strings.Split(table.View(80, 10), "\n")
+	if !strings.Contains(lines[1], "dev/api/key · 3m ago") {
+		t.Errorf("API_KEY row = %q, want its age after the path", lines[1])
+	}
+	if strings.Contains(lines[2], "ago") {
+		t.Errorf("DATABASE_URL row = %q, want no age", lines[2])
+	}
+}
+
+func TestTruncate_WideCharacters(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		maxLen int
+		want   string
+	}{
+		{"ascii fits", "DATABASE_URL", 12, "DATABASE_URL"},
+		{"ascii", "DATABASE_URL", 8, "DATABAS…"},
+		{"cjk", "データベース接続", 9, "データベ…"},
+		{"cjk odd cut", "データベース接続", 8, "データ…"},
+		{"emoji", "🔑🔑🔑🔑🔑", 6, "🔑🔑…"},
+		{"too narrow", "データベース", 3, "データベース"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncate(tt.in, tt.maxLen)
+			if got != tt.want {
+				t.Errorf("truncate(%q, %d) = %q, want %q", tt.in, tt.maxLen, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncate(%q, %d) produced invalid UTF-8", tt.in, tt.maxLen)
+			}
+		})
+	}
+}
+
+func TestPadRight_WideCharacters(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"KEY", 6, "KEY   "},
+		{"鍵", 6, "鍵    "},
+		{"🔑KEY", 6, "🔑KEY "},
+		{"データベース", 6, "データベース"},
+	}
+
+	for _, tt := range tests {
+		if got := padRight(tt.in, tt.width); got != tt.want {
+			t.Errorf("padRight(%q, %d) = %q, want %q", tt.in, tt.width, got, tt.want)
+		}
+	}
+}
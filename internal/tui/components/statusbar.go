@@ -21,6 +21,9 @@ var (
 
 	statusSuccess = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#10B981"))
+
+	statusUncommitted = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#F59E0B"))
 )
 
 // StatusBar holds the state for the status bar component.
@@ -30,6 +33,9 @@ type StatusBar struct {
 	SecretCount int
 	Message     string
 	IsError     bool
+	// Uncommitted is the number of vx.toml files with changes git has not
+	// committed.
+	Uncommitted int
 }
 
 // View renders the status bar.
@@ -48,6 +54,10 @@ func (sb *StatusBar) View(width int) string {
 	}
 
 	right := statusCount.Render(fmt.Sprintf("%d secrets", sb.SecretCount))
+	if sb.Uncommitted > 0 {
+		right = statusUncommitted.Render(fmt.Sprintf("%d uncommitted (s)", sb.Uncommitted)) +
+			statusCount.Render("  ") + right
+	}
 
 	spacer := width - lipgloss.Width(left) - lipgloss.Width(right)
 	if spacer < 1 {
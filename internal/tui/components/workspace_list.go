@@ -1,6 +1,7 @@
 package components
 
 import (
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -29,6 +30,7 @@ type WorkspaceList struct {
 	Cursor   int
 	Focused  bool
 	HasRoot  bool // whether to show "[root]" entry
+	Accent   lipgloss.Color // selection color; empty uses the default
 }
 
 // NewWorkspaceList creates a new workspace list from the given names.
@@ -51,6 +53,18 @@ func (wl *WorkspaceList) Selected() string {
 	return ""
 }
 
+// Select moves the cursor to the named workspace. It reports whether the
+// name was found; the cursor is left alone otherwise.
+func (wl *WorkspaceList) Select(name string) bool {
+	for i, item := range wl.allItems() {
+		if item == name {
+			wl.Cursor = i
+			return true
+		}
+	}
+	return false
+}
+
 // MoveUp moves the cursor up by one.
 func (wl *WorkspaceList) MoveUp() {
 	if wl.Cursor > 0 {
@@ -71,6 +85,11 @@ func (wl *WorkspaceList) Len() int {
 	return len(wl.allItems())
 }
 
+// Names returns every selectable item, including "[root]" if shown.
+func (wl *WorkspaceList) Names() []string {
+	return slices.Clone(wl.allItems())
+}
+
 // allItems returns Items plus "[root]" if applicable.
 func (wl *WorkspaceList) allItems() []string {
 	if wl.HasRoot {
@@ -86,6 +105,11 @@ func (wl *WorkspaceList) View(width, height int) string {
 	b.WriteString(wsTitle.Render("Workspaces"))
 	b.WriteString("\n")
 
+	selected := wsSelected
+	if wl.Accent != "" {
+		selected = selected.Foreground(wl.Accent)
+	}
+
 	items := wl.allItems()
 	for i, item := range items {
 		if i >= height-2 { // leave room for title + margin
@@ -97,7 +121,7 @@ func (wl *WorkspaceList) View(width, height int) string {
 		if i == wl.Cursor {
 			prefix = "> "
 			if wl.Focused {
-				style = wsSelected
+				style = selected
 			} else {
 				style = wsFocused
 			}
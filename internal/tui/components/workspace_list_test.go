@@ -101,3 +101,18 @@ func TestWorkspaceList_Empty(t *testing.T) {
 		t.Errorf("expected empty selection, got %q", wl.Selected())
 	}
 }
+
+func TestWorkspaceList_Select(t *testing.T) {
+	wl := NewWorkspaceList([]string{"web", "api"}, true)
+
+	if !wl.Select("[root]") || wl.Selected() != "[root]" {
+		t.Errorf("expected '[root]' selected, got %q", wl.Selected())
+	}
+
+	if wl.Select("gone") {
+		t.Error("Select() should report false for an unknown workspace")
+	}
+	if wl.Selected() != "[root]" {
+		t.Errorf("cursor moved on failed Select, got %q", wl.Selected())
+	}
+}
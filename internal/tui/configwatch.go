@@ -0,0 +1,233 @@
+package tui
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/tui/bridge"
+)
+
+// configRetryInterval is how long a reload that had to wait, because a
+// mapping was being edited or written, waits before the files are checked
+// again.
+const configRetryInterval = 2 * time.Second
+
+// configFileName is the name of the root and workspace config files.
+const configFileName = "vx.toml"
+
+// configWatcher watches the directories of the vx.toml files, so edits made
+// outside the TUI, e.g. in an editor in another terminal, are noticed.
+// Directories are watched rather than files: editors often save by
+// replacing the file, and a workspace's vx.toml may not exist yet.
+type configWatcher struct {
+	w *fsnotify.Watcher
+
+	mu   sync.Mutex
+	dirs map[string]bool
+}
+
+// newConfigWatcher starts a watcher with no directories. It returns nil if
+// the platform cannot watch files; the TUI then only reloads after its own
+// writes.
+func newConfigWatcher() *configWatcher {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil
+	}
+	return &configWatcher{w: w, dirs: make(map[string]bool)}
+}
+
+// watch makes the watched directories those of paths, plus rootDir.
+// Directories that do not exist are skipped; a new one is picked up when
+// it is created in a watched directory.
+func (cw *configWatcher) watch(rootDir string, paths []string) {
+	if cw == nil {
+		return
+	}
+	want := map[string]bool{rootDir: true}
+	for _, p := range paths {
+		want[filepath.Dir(p)] = true
+	}
+
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	for dir := range cw.dirs {
+		if !want[dir] {
+			_ = cw.w.Remove(dir)
+			delete(cw.dirs, dir)
+		}
+	}
+	for dir := range want {
+		cw.add(dir)
+	}
+}
+
+// add watches dir unless it already is. The caller holds mu.
+func (cw *configWatcher) add(dir string) {
+	if dir == "" || cw.dirs[dir] {
+		return
+	}
+	if err := cw.w.Add(dir); err == nil {
+		cw.dirs[dir] = true
+	}
+}
+
+// next blocks until a vx.toml file in a watched directory is written,
+// created, removed, or renamed, and returns its path. Directories created
+// in a watched one are watched too, so a new workspace's vx.toml is seen.
+// ok is false once the watcher is closed.
+func (cw *configWatcher) next() (path string, ok bool) {
+	for {
+		select {
+		case ev, open := <-cw.w.Events:
+			if !open {
+				return "", false
+			}
+			if ev.Has(fsnotify.Create) {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					cw.mu.Lock()
+					cw.add(ev.Name)
+					cw.mu.Unlock()
+					continue
+				}
+			}
+			if filepath.Base(ev.Name) == configFileName && !ev.Has(fsnotify.Chmod) {
+				return ev.Name, true
+			}
+		case _, open := <-cw.w.Errors:
+			if !open {
+				return "", false
+			}
+		}
+	}
+}
+
+// close stops the watcher, ending any pending watchConfigCmd.
+func (cw *configWatcher) close() {
+	if cw != nil {
+		_ = cw.w.Close()
+	}
+}
+
+// fileStamp identifies one version of a file. A missing file has the zero
+// stamp.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// configFiles returns the root and workspace vx.toml files of cfg.
+func configFiles(b *bridge.Bridge, cfg *config.RootConfig, rootDir string) []string {
+	if cfg == nil {
+		return nil
+	}
+	var paths []string
+	for _, t := range b.WorkspaceFiles(cfg, rootDir) {
+		paths = append(paths, t.Path)
+	}
+	return paths
+}
+
+// statFiles returns the current stamp of each of paths.
+func statFiles(paths []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, p := range paths {
+		var s fileStamp
+		if info, err := os.Stat(p); err == nil {
+			s = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+		stamps[p] = s
+	}
+	return stamps
+}
+
+// watchConfigCmd waits for the next change to a vx.toml file. Only one is
+// pending at a time: handleConfigFileChanged starts the next.
+func watchConfigCmd(cw *configWatcher) tea.Cmd {
+	if cw == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		path, ok := cw.next()
+		if !ok {
+			return nil
+		}
+		return configFileChangedMsg{path: path}
+	}
+}
+
+// statConfigCmd stats paths after delay.
+func statConfigCmd(paths []string, delay time.Duration) tea.Cmd {
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		return configFilesStatMsg{stamps: statFiles(paths)}
+	})
+}
+
+// handleConfigFileChanged checks the vx.toml files after the watcher saw
+// one change, including the changed file in case it is a workspace the
+// loaded config does not know yet, and waits for the next change.
+func (m model) handleConfigFileChanged(msg configFileChangedMsg) (tea.Model, tea.Cmd) {
+	paths := configFiles(m.bridge, m.config, m.rootDir)
+	if !slices.Contains(paths, msg.path) {
+		paths = append(paths, msg.path)
+	}
+	return m, tea.Batch(watchConfigCmd(m.configWatcher), statConfigCmd(paths, 0))
+}
+
+// reloadConfigCmd loads the root config again after an edit on disk. Unlike
+// the first load, a failure is reported without replacing what is shown: the
+// file may be saved half-written, or the edit may be a mistake that the next
+// save fixes.
+func reloadConfigCmd(b *bridge.Bridge) tea.Cmd {
+	return func() tea.Msg {
+		cfg, rootDir, err := b.LoadConfig()
+		if err != nil {
+			return configReloadErrorMsg{err: err}
+		}
+		return configLoadedMsg{
+			config:  cfg,
+			rootDir: rootDir,
+			stamps:  statFiles(configFiles(b, cfg, rootDir)),
+		}
+	}
+}
+
+// handleConfigFilesStat reloads the config when a vx.toml file changed since
+// it was loaded, or a new one appeared, keeping the selection. While a
+// mapping is being edited the reload waits, so the form does not change
+// under the user; the files are checked again after configRetryInterval.
+func (m model) handleConfigFilesStat(msg configFilesStatMsg) (tea.Model, tea.Cmd) {
+	if m.config == nil || m.configStamps == nil {
+		return m, nil
+	}
+
+	var changed []string
+	for p, s := range msg.stamps {
+		old, ok := m.configStamps[p]
+		if ok && (!old.modTime.Equal(s.modTime) || old.size != s.size) || !ok && s != (fileStamp{}) {
+			changed = append(changed, m.displayPath(p))
+		}
+	}
+	if len(changed) == 0 {
+		return m, nil
+	}
+	if m.pendingWrites > 0 || m.activePopup == popupMappingForm || m.activePopup == popupConfirm {
+		return m, statConfigCmd(slices.Collect(maps.Keys(msg.stamps)), configRetryInterval)
+	}
+	slices.Sort(changed)
+
+	// Only report this version once, even if loading it fails.
+	m.configStamps = msg.stamps
+	m.statusBar.Message = "Reloaded " + strings.Join(changed, ", ") + " (changed on disk)"
+	m.statusBar.IsError = false
+	return m, tea.Batch(reloadConfigCmd(m.bridge), clearStatusAfter(3*time.Second))
+}
@@ -0,0 +1,263 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go.dot.industries/vx/internal/tui/bridge"
+	"go.dot.industries/vx/internal/tui/components"
+)
+
+// handleDefaults starts reading the [defaults] tables of the selected
+// workspace's vx.toml; the view opens once they are loaded.
+func (m model) handleDefaults() (tea.Model, tea.Cmd) {
+	file := m.selectedWorkspaceFile()
+	if file == "" {
+		return m, nil
+	}
+	return m, loadDefaultsCmd(m.bridge, file, m.environments)
+}
+
+// handleDefaultsLoaded opens or refreshes the defaults view. The selected
+// value stays selected when the view is already open.
+func (m model) handleDefaultsLoaded(msg defaultsLoadedMsg) (tea.Model, tea.Cmd) {
+	// Drop a result for a workspace no longer selected.
+	if msg.file != m.selectedWorkspaceFile() {
+		return m, nil
+	}
+
+	var reselect *components.DefaultRow
+	if m.defaultsView && m.defaultsFile == msg.file {
+		if row := m.defaultsTable.Selected(); row != nil {
+			r := *row
+			reselect = &r
+		}
+	}
+
+	rows := make([]components.DefaultRow, len(msg.entries))
+	for i, e := range msg.entries {
+		rows[i] = components.DefaultRow{Env: e.Env, Key: e.Key, Value: e.Value}
+	}
+	m.defaultsTable = components.DefaultsTable{Title: m.displayPath(msg.file), Env: m.env, Rows: rows}
+	if reselect != nil && !m.defaultsTable.Select(reselect.Env, reselect.Key) {
+		m.defaultsTable.Cursor = min(m.defaultsTable.Cursor, len(rows)-1)
+	}
+	m.defaultsFile = msg.file
+	m.defaultsView = true
+	return m, nil
+}
+
+// handleDefaultsKey handles keys while the defaults view replaces the panes.
+func (m model) handleDefaultsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Quit):
+		return m.requestQuit()
+	case key.Matches(msg, keys.Escape), key.Matches(msg, keys.Defaults):
+		m.defaultsView = false
+		m.defaultsTable = components.DefaultsTable{}
+	case key.Matches(msg, keys.Up):
+		m.defaultsTable.MoveUp()
+	case key.Matches(msg, keys.Down):
+		m.defaultsTable.MoveDown()
+	case key.Matches(msg, keys.Add):
+		m.activePopup = popupDefaultForm
+		m.defaultFormTable = 0
+		if row := m.defaultsTable.Selected(); row != nil {
+			m.defaultFormTable = m.defaultTableIndex(row.Env)
+		}
+		m.defaultFormKey = ""
+		m.defaultFormValue = ""
+		m.defaultFormField = 1
+		m.defaultFormIsEdit = false
+	case key.Matches(msg, keys.Edit), key.Matches(msg, keys.Enter):
+		row := m.defaultsTable.Selected()
+		if row == nil {
+			return m, nil
+		}
+		m.activePopup = popupDefaultForm
+		m.defaultFormTable = m.defaultTableIndex(row.Env)
+		m.defaultFormKey = row.Key
+		m.defaultFormValue = row.Value
+		m.defaultFormField = 2
+		m.defaultFormIsEdit = true
+	case key.Matches(msg, keys.Delete):
+		if m.defaultsTable.Selected() == nil {
+			return m, nil
+		}
+		m.activePopup = popupDefaultConfirm
+		m.confirmCursor = 0
+	case key.Matches(msg, keys.Help):
+		m.activePopup = popupHelp
+	}
+	return m, nil
+}
+
+// defaultTables returns the environments whose defaults table the form can
+// write to, "" standing for [defaults] itself.
+func (m model) defaultTables() []string {
+	return append([]string{""}, m.environments...)
+}
+
+// defaultTableIndex returns the index of env in defaultTables(), or 0.
+func (m model) defaultTableIndex(env string) int {
+	for i, e := range m.defaultTables() {
+		if e == env {
+			return i
+		}
+	}
+	return 0
+}
+
+// defaultFormEnv returns the environment of the table the form writes to.
+func (m model) defaultFormEnv() string {
+	tables := m.defaultTables()
+	if m.defaultFormTable < 0 || m.defaultFormTable >= len(tables) {
+		return ""
+	}
+	return tables[m.defaultFormTable]
+}
+
+// handleDefaultFormKey handles keys within the default form. When editing,
+// only the value can change.
+func (m model) handleDefaultFormKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyTab:
+		if !m.defaultFormIsEdit {
+			m.defaultFormField = (m.defaultFormField + 1) % 3
+		}
+
+	case msg.Type == tea.KeyEnter:
+		return m.saveDefaultForm()
+
+	case msg.Type == tea.KeyBackspace:
+		switch m.defaultFormField {
+		case 0: // table — cycle backwards
+			n := len(m.defaultTables())
+			m.defaultFormTable = (m.defaultFormTable - 1 + n) % n
+		case 1:
+			if r := []rune(m.defaultFormKey); len(r) > 0 {
+				m.defaultFormKey = string(r[:len(r)-1])
+			}
+		case 2:
+			if r := []rune(m.defaultFormValue); len(r) > 0 {
+				m.defaultFormValue = string(r[:len(r)-1])
+			}
+		}
+
+	case msg.Type == tea.KeySpace:
+		if m.defaultFormField == 2 {
+			m.defaultFormValue += " "
+		}
+
+	case msg.Type == tea.KeyRunes:
+		switch m.defaultFormField {
+		case 0: // table — cycle forward
+			m.defaultFormTable = (m.defaultFormTable + 1) % len(m.defaultTables())
+		case 1:
+			m.defaultFormKey += string(msg.Runes)
+		case 2:
+			m.defaultFormValue += string(msg.Runes)
+		}
+	}
+	return m, nil
+}
+
+// defaultProtected reports whether writing to env's defaults table needs
+// the protected-environment confirmation, and for which environment. The
+// [defaults] table applies to every environment, so it asks when the
+// selected one is protected, as mapping changes do.
+func (m model) defaultProtected(env string) (string, bool) {
+	if env == "" {
+		env = m.env
+	}
+	return env, isProtectedEnv(m.config, env)
+}
+
+// saveDefaultForm validates and saves the default form.
+func (m model) saveDefaultForm() (tea.Model, tea.Cmd) {
+	if m.defaultFormKey == "" {
+		m.statusBar.Message = "Key is required"
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(3 * time.Second)
+	}
+
+	if !m.defaultFormIsEdit {
+		for _, row := range m.defaultsTable.Rows {
+			if row.Env == m.defaultFormEnv() && row.Key == m.defaultFormKey {
+				m.statusBar.Message = m.defaultFormKey + " is already set in [" + row.Table() + "]; press r to change it"
+				m.statusBar.IsError = true
+				return m, clearStatusAfter(3 * time.Second)
+			}
+		}
+	}
+
+	if env, ok := m.defaultProtected(m.defaultFormEnv()); ok {
+		return m.requireSafetyConfirmIn(safetySaveDefault, env)
+	}
+
+	m.pendingWrites++
+	return m, saveDefaultCmd(m.bridge, m.defaultsFile, m.defaultFormEnv(), m.defaultFormKey, m.defaultFormValue)
+}
+
+// handleDefaultConfirmKey handles keys within the delete confirmation for
+// the selected default.
+func (m model) handleDefaultConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Up), key.Matches(msg, keys.Down):
+		m.confirmCursor = 1 - m.confirmCursor
+	case msg.Type == tea.KeyEnter:
+		row := m.defaultsTable.Selected()
+		if m.confirmCursor == 0 || row == nil {
+			m.activePopup = popupNone
+			return m, nil
+		}
+		if env, ok := m.defaultProtected(row.Env); ok {
+			return m.requireSafetyConfirmIn(safetyDeleteDefault, env)
+		}
+		m.pendingWrites++
+		return m, deleteDefaultCmd(m.bridge, m.defaultsFile, row.Env, row.Key)
+	}
+	return m, nil
+}
+
+// handleDefaultSaved reloads the config after a default was written or
+// deleted; the defaults view refreshes along with it.
+func (m model) handleDefaultSaved(msg defaultSavedMsg) (tea.Model, tea.Cmd) {
+	var quitNow bool
+	if m, quitNow = m.writeFinished(false); quitNow {
+		return m.quit()
+	}
+	if m.activePopup != popupQuit {
+		m.activePopup = popupNone
+	}
+	m.statusBar.Message = msg.text
+	m.statusBar.IsError = false
+	m.configStamps = nil
+	return m, tea.Batch(
+		loadConfigCmd(m.bridge),
+		clearStatusAfter(3*time.Second),
+	)
+}
+
+// saveDefaultCmd creates a command that sets a default in a vx.toml file.
+func saveDefaultCmd(b *bridge.Bridge, filePath, env, key, value string) tea.Cmd {
+	return func() tea.Msg {
+		if err := b.SetDefault(filePath, env, key, value); err != nil {
+			return defaultSaveErrorMsg{err: err}
+		}
+		return defaultSavedMsg{text: "Default saved"}
+	}
+}
+
+// deleteDefaultCmd creates a command that deletes a default from a vx.toml
+// file.
+func deleteDefaultCmd(b *bridge.Bridge, filePath, env, key string) tea.Cmd {
+	return func() tea.Msg {
+		if err := b.DeleteDefault(filePath, env, key); err != nil {
+			return defaultSaveErrorMsg{err: err}
+		}
+		return defaultSavedMsg{text: "Default deleted"}
+	}
+}
@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorCommand builds the command that opens path in the user's editor:
+// $VISUAL, then $EDITOR, then a platform default. The variable may include
+// arguments, e.g. "code --wait".
+func editorCommand(path string) (*exec.Cmd, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no editor configured; set $EDITOR")
+	}
+
+	return exec.Command(fields[0], append(fields[1:], path)...), nil
+}
+
+// openEditorCmd suspends the TUI, runs the editor on path, and reports back
+// with editorFinishedMsg once it exits.
+func openEditorCmd(path string) tea.Cmd {
+	c, err := editorCommand(path)
+	if err != nil {
+		return func() tea.Msg { return editorFinishedMsg{path: path, err: err} }
+	}
+
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		return editorFinishedMsg{path: path, err: err}
+	})
+}
+
+// selectedWorkspaceFile returns the absolute path of the selected workspace's
+// vx.toml ("[root]" maps to the root file), or "" if nothing is selected.
+func (m model) selectedWorkspaceFile() string {
+	if m.config == nil {
+		return ""
+	}
+
+	selected := m.workspaces.Selected()
+	for _, t := range m.bridge.WorkspaceFiles(m.config, m.rootDir) {
+		if t.Label == selected {
+			return t.Path
+		}
+	}
+	return ""
+}
+
+// displayPath shortens path to be relative to the root directory when it
+// lies inside it.
+func (m model) displayPath(path string) string {
+	if path == "" || m.rootDir == "" {
+		return path
+	}
+	rel, err := filepath.Rel(m.rootDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,76 @@
+package tui
+
+import "time"
+
+// valueCacheTTL is how long a resolved value is reused when reopening its
+// secret, unless cache_ttl sets another lifetime for the variable. It
+// matches the resolver's default cache lifetime.
+const valueCacheTTL = 5 * time.Minute
+
+// cachedValue is a resolved secret value and when it was read.
+type cachedValue struct {
+	value   string
+	fetched time.Time
+}
+
+// valueTTL returns how long envVar's resolved value may be reused: its
+// cache_ttl, or valueCacheTTL. Zero means it is never reused.
+func (m model) valueTTL(envVar string) time.Duration {
+	if ttl, ok := m.cacheTTLs[envVar]; ok {
+		return time.Duration(ttl)
+	}
+	return valueCacheTTL
+}
+
+// cachedValueFor returns the reusable value resolved earlier for envVar from
+// vaultPath, the interpolated path, if it has not outlived its TTL.
+func (m model) cachedValueFor(envVar, vaultPath string, now time.Time) (cachedValue, bool) {
+	v, ok := m.values[vaultPath]
+	if !ok || now.Sub(v.fetched) >= m.valueTTL(envVar) {
+		return cachedValue{}, false
+	}
+	return v, true
+}
+
+// storeValue remembers a value resolved from Vault for envVar, unless its
+// cache_ttl is 0.
+func (m model) storeValue(envVar, vaultPath, value string, now time.Time) {
+	if m.values == nil || m.valueTTL(envVar) <= 0 {
+		return
+	}
+	m.values[vaultPath] = cachedValue{value: value, fetched: now}
+}
+
+// forgetValues drops the cached values read from the KV secret at dir, e.g.
+// after the daemon reports it changed.
+func (m model) forgetValues(dir string) {
+	for vaultPath := range m.values {
+		if secretDir(vaultPath) == dir {
+			delete(m.values, vaultPath)
+		}
+	}
+}
+
+// rowFreshness returns the age of every secret table row with a reusable
+// cached value, keyed by env var, for display next to its path.
+func (m model) rowFreshness(now time.Time) map[string]string {
+	if len(m.values) == 0 {
+		return nil
+	}
+	ages := make(map[string]string)
+	for _, row := range m.secrets.AllRows {
+		if v, ok := m.cachedValueFor(row.EnvVar, row.VaultPath, now); ok {
+			ages[row.EnvVar] = formatAge(now.Sub(v.fetched))
+		}
+	}
+	return ages
+}
+
+// detailFreshness describes where the detail popup's value came from: read
+// live from Vault when it was opened or refreshed, or reused from the cache.
+func (m model) detailFreshness(now time.Time) string {
+	if !m.detailCached {
+		return "live"
+	}
+	return "cached, " + formatAge(now.Sub(m.detailFetched)) + " (R to resolve again)"
+}
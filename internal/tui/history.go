@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/resolver"
+	"go.dot.industries/vx/internal/tui/bridge"
+	"go.dot.industries/vx/internal/vault"
+)
+
+// handleHistory shows the versions of the secret in the detail popup, from
+// the metadata read when it opened, with the cursor on the version shown.
+func (m model) handleHistory() (tea.Model, tea.Cmd) {
+	if m.detailMeta == nil || len(m.detailMeta.Versions) == 0 {
+		m.statusBar.Message = "No version history for " + m.detailEnvVar
+		if m.detailMetaErr != "" {
+			m.statusBar.Message += ": " + m.detailMetaErr
+		}
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(3 * time.Second)
+	}
+
+	m.detailHistory = true
+	m.historyCursor = 0
+	for i, v := range m.detailMeta.Versions {
+		if v.Version == m.shownVersion() {
+			m.historyCursor = i
+		}
+	}
+	return m, nil
+}
+
+// handleHistoryKey handles keys while the detail popup shows the version
+// history. Esc and h go back to the value without closing the popup.
+func (m model) handleHistoryKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Escape), key.Matches(msg, keys.History):
+		m.detailHistory = false
+	case key.Matches(msg, keys.Up):
+		if m.historyCursor > 0 {
+			m.historyCursor--
+		}
+	case key.Matches(msg, keys.Down):
+		if m.historyCursor < len(m.detailMeta.Versions)-1 {
+			m.historyCursor++
+		}
+	case msg.Type == tea.KeyEnter:
+		return m.showVersion(m.detailMeta.Versions[m.historyCursor])
+	}
+	return m, nil
+}
+
+// showVersion reads v of the secret in the detail popup and shows its value.
+// Picking the version the mapping reads, the latest unless it is pinned,
+// resolves the mapping again.
+func (m model) showVersion(v vault.KVVersion) (tea.Model, tea.Cmd) {
+	if v.Deleted(time.Now()) {
+		m.statusBar.Message = fmt.Sprintf("Version %d was deleted; its value cannot be read", v.Version)
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(3 * time.Second)
+	}
+
+	m.detailHistory = false
+	pinned := pinnedVersion(m.detailPath)
+	if v.Version == pinned || pinned == 0 && v.Version == m.detailMeta.CurrentVersion {
+		return m.refreshDetail()
+	}
+
+	rawPath, ok := m.detailRawPath()
+	if !ok {
+		return m, nil
+	}
+	m.detailValue = ""
+	m.detailError = ""
+	m.detailLoading = true
+	m.detailCached = false
+	ctx := newResolveContext(&m.detailCancel)
+	return m, resolveVersionCmd(ctx, m.bridge, m.vaultClient, m.config, m.detailEnvVar, rawPath, m.pathEnv(), v.Version)
+}
+
+// handleVersionResolved shows an earlier version's value in the detail
+// popup. Unlike the latest value it is not kept in the value cache.
+func (m model) handleVersionResolved(msg secretVersionResolvedMsg) (tea.Model, tea.Cmd) {
+	stopResolve(&m.detailCancel)
+	if msg.envVar != m.detailEnvVar {
+		return m, nil
+	}
+	m.detailValue = msg.value
+	m.detailVersion = msg.version
+	m.detailLoading = false
+	return m, nil
+}
+
+// pinnedVersion returns the version a mapping is pinned to, or zero when it
+// reads the latest.
+func pinnedVersion(vaultPath string) int {
+	_, version := resolver.SplitVersion(vaultPath)
+	return version
+}
+
+// shownVersion returns the version of the value in the detail popup.
+func (m model) shownVersion() int {
+	if m.detailVersion != 0 || m.detailMeta == nil {
+		return m.detailVersion
+	}
+	return m.detailMeta.CurrentVersion
+}
+
+// resolveVersionCmd creates a command that reads one version of the key
+// behind a mapping.
+func resolveVersionCmd(ctx context.Context, b *bridge.Bridge, client *vault.Client, cfg *config.RootConfig, envVar, vaultPath, env string, version int) tea.Cmd {
+	return func() tea.Msg {
+		client, vaultPath, err := b.Route(client, cfg, vaultPath)
+		if err != nil {
+			return secretResolveErrorMsg{envVar: envVar, err: err}
+		}
+
+		val, err := b.ResolveVersion(ctx, client, vaultPath, env, version)
+		if ctx.Err() != nil {
+			return nil // abandoned with Esc
+		}
+		if err != nil {
+			return secretResolveErrorMsg{envVar: envVar, err: err}
+		}
+		return secretVersionResolvedMsg{envVar: envVar, version: version, value: val}
+	}
+}
@@ -12,13 +12,25 @@ type keyMap struct {
 	Env        key.Binding
 	Help       key.Binding
 	Copy       key.Binding
+	TempFile   key.Binding
+	Refresh    key.Binding
+	EditValue  key.Binding
+	History    key.Binding
 	Add        key.Binding
 	Edit       key.Binding
 	Delete     key.Binding
+	Duplicate  key.Binding
+	Open       key.Binding
+	Compare    key.Binding
+	Effective  key.Binding
+	Defaults   key.Binding
+	Changes    key.Binding
+	Login      key.Binding
 	Escape     key.Binding
 	Quit       key.Binding
 	ForceQuit  key.Binding
 	Backspace  key.Binding
+	BrowseRoot key.Binding
 }
 
 var keys = keyMap{
@@ -54,6 +66,22 @@ var keys = keyMap{
 		key.WithKeys("c"),
 		key.WithHelp("c", "copy value"),
 	),
+	TempFile: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "write value to temp file"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "resolve again"),
+	),
+	EditValue: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "edit value"),
+	),
+	History: key.NewBinding(
+		key.WithKeys("h"),
+		key.WithHelp("h", "version history"),
+	),
 	Add: key.NewBinding(
 		key.WithKeys("a"),
 		key.WithHelp("a", "add mapping"),
@@ -66,6 +94,34 @@ var keys = keyMap{
 		key.WithKeys("d"),
 		key.WithHelp("d", "delete mapping"),
 	),
+	Duplicate: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "duplicate mapping"),
+	),
+	Open: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open vx.toml in $EDITOR"),
+	),
+	Compare: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "compare workspaces"),
+	),
+	Effective: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "effective environment"),
+	),
+	Defaults: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "defaults"),
+	),
+	Changes: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "uncommitted changes"),
+	),
+	Login: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "log in to Vault"),
+	),
 	Escape: key.NewBinding(
 		key.WithKeys("esc"),
 		key.WithHelp("esc", "close/cancel"),
@@ -79,7 +135,11 @@ var keys = keyMap{
 		key.WithHelp("ctrl+c", "force quit"),
 	),
 	Backspace: key.NewBinding(
-		key.WithKeys("backspace"),
-		key.WithHelp("backspace", "go up"),
+		key.WithKeys("backspace", "u"),
+		key.WithHelp("backspace/u", "go up"),
+	),
+	BrowseRoot: key.NewBinding(
+		key.WithKeys("~", "g"),
+		key.WithHelp("~/g", "base path"),
 	),
 }
@@ -1,7 +1,9 @@
 package tui
 
 import (
+	"go.dot.industries/vx/internal/changes"
 	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/tui/bridge"
 	"go.dot.industries/vx/internal/vault"
 )
 
@@ -11,11 +13,26 @@ import (
 type configLoadedMsg struct {
 	config  *config.RootConfig
 	rootDir string
+	stamps  map[string]fileStamp // of the vx.toml files, taken after loading
 }
 
 // configErrorMsg is sent when config loading fails.
 type configErrorMsg struct{ err error }
 
+// configReloadErrorMsg is sent when reloading the config after an edit on
+// disk fails; the config already loaded stays in use.
+type configReloadErrorMsg struct{ err error }
+
+// configFileChangedMsg is sent when the watcher sees a vx.toml file change.
+type configFileChangedMsg struct {
+	path string
+}
+
+// configFilesStatMsg carries the current stamps of the vx.toml files.
+type configFilesStatMsg struct {
+	stamps map[string]fileStamp
+}
+
 // --- Workspace selection ---
 
 // workspaceSelectedMsg signals that the user selected a workspace.
@@ -25,8 +42,9 @@ type workspaceSelectedMsg struct {
 
 // workspaceDataLoadedMsg carries the merged config for the selected workspace.
 type workspaceDataLoadedMsg struct {
-	secrets map[string]string // env var -> vault path template
-	source  string           // workspace name or "[root]"
+	secrets  map[string]string // env var -> vault path template
+	source   string           // workspace name or "[root]"
+	cacheTTL map[string]config.Duration
 }
 
 // workspaceDataErrorMsg is sent when workspace data loading fails.
@@ -53,6 +71,14 @@ type secretResolvedMsg struct {
 	value  string
 }
 
+// secretVersionResolvedMsg carries the value of an earlier version of the
+// secret shown in the detail popup.
+type secretVersionResolvedMsg struct {
+	envVar  string
+	version int
+	value   string
+}
+
 // secretResolveErrorMsg is sent when secret resolution fails.
 type secretResolveErrorMsg struct {
 	envVar string
@@ -61,16 +87,23 @@ type secretResolveErrorMsg struct {
 
 // --- Authentication ---
 
-// authRequiredMsg signals that Vault auth is needed before an operation.
-type authRequiredMsg struct{}
+// authRequiredMsg signals that Vault auth is needed before an operation;
+// retry names the operation to run again once logged in.
+type authRequiredMsg struct {
+	retry authRetry
+}
 
 // authSucceededMsg is sent after successful Vault authentication.
 type authSucceededMsg struct {
 	client *vault.Client
 }
 
-// authFailedMsg is sent when Vault authentication fails.
-type authFailedMsg struct{ err error }
+// authFailedMsg is sent when Vault authentication fails; login is set when
+// the login popup started it.
+type authFailedMsg struct {
+	err   error
+	login bool
+}
 
 // --- Vault tree browsing (Phase 3) ---
 
@@ -97,6 +130,19 @@ type vaultListErrorMsg struct {
 	err  error
 }
 
+// secretMetadataMsg carries KV metadata for the secret shown in the detail
+// popup.
+type secretMetadataMsg struct {
+	envVar string
+	meta   *vault.KVMetadata
+}
+
+// secretMetadataErrorMsg is sent when KV metadata cannot be read.
+type secretMetadataErrorMsg struct {
+	envVar string
+	err    error
+}
+
 // --- CRUD operations (Phase 3) ---
 
 // saveMappingMsg requests writing a new or updated mapping to a vx.toml file.
@@ -114,6 +160,16 @@ type mappingSavedMsg struct{}
 // mappingSaveErrorMsg is sent when saving a mapping fails.
 type mappingSaveErrorMsg struct{ err error }
 
+// valueSavedMsg signals that a secret value edited in the detail popup was
+// written to Vault.
+type valueSavedMsg struct {
+	envVar string
+	value  string
+}
+
+// valueSaveErrorMsg is sent when writing an edited value fails.
+type valueSaveErrorMsg struct{ err error }
+
 // deleteMappingMsg requests deletion of a mapping from a vx.toml file.
 type deleteMappingMsg struct {
 	filePath string
@@ -126,6 +182,72 @@ type mappingDeletedMsg struct{}
 // mappingDeleteErrorMsg is sent when deleting a mapping fails.
 type mappingDeleteErrorMsg struct{ err error }
 
+// uncommittedFilesMsg carries the vx.toml files with changes git has not
+// committed. It is empty when the config is not in a git work tree.
+type uncommittedFilesMsg struct {
+	files []bridge.ChangedFile
+}
+
+// editorFinishedMsg is sent when the external editor opened with `o` exits.
+type editorFinishedMsg struct {
+	path string
+	err  error
+}
+
+// --- Workspace comparison ---
+
+// compareLoadedMsg carries the merged secrets of two workspaces to compare.
+type compareLoadedMsg struct {
+	left         string
+	right        string
+	leftSecrets  map[string]string
+	rightSecrets map[string]string
+}
+
+// compareErrorMsg is sent when either workspace fails to load.
+type compareErrorMsg struct{ err error }
+
+// --- Effective environment ---
+
+// effectiveLoadedMsg carries the environment vx exec would inject for a
+// workspace.
+type effectiveLoadedMsg struct {
+	workspace string
+	env       string
+	vars      []bridge.EffectiveVar
+}
+
+// effectiveErrorMsg is sent when the workspace's config fails to load.
+type effectiveErrorMsg struct{ err error }
+
+// --- Defaults ---
+
+// defaultsLoadedMsg carries the [defaults] tables of a vx.toml file.
+type defaultsLoadedMsg struct {
+	file    string
+	entries []bridge.DefaultEntry
+}
+
+// defaultsErrorMsg is sent when the file's defaults cannot be read.
+type defaultsErrorMsg struct{ err error }
+
+// defaultSavedMsg signals that a default was written or deleted; text
+// describes which.
+type defaultSavedMsg struct {
+	text string
+}
+
+// defaultSaveErrorMsg is sent when writing or deleting a default fails.
+type defaultSaveErrorMsg struct{ err error }
+
+// --- Vault events ---
+
+// secretsChangedMsg carries the secret changes the daemon journaled since
+// the last poll.
+type secretsChangedMsg struct {
+	entries []changes.Change
+}
+
 // --- UI state ---
 
 // statusMsg shows a temporary status message in the status bar.
@@ -136,3 +258,14 @@ type statusMsg struct {
 
 // clearStatusMsg clears the status message.
 type clearStatusMsg struct{}
+
+// clipboardClearMsg fires when a copied secret's clear-after delay elapses.
+type clipboardClearMsg struct {
+	fingerprint string
+}
+
+// tempFileExpiredMsg fires when a value written to a temp file is due for
+// deletion.
+type tempFileExpiredMsg struct {
+	path string
+}
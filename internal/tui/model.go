@@ -1,11 +1,13 @@
 package tui
 
 import (
+	"context"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"go.dot.industries/vx/internal/clipboard"
 	"go.dot.industries/vx/internal/config"
 	"go.dot.industries/vx/internal/tui/bridge"
 	"go.dot.industries/vx/internal/tui/components"
@@ -31,6 +33,45 @@ const (
 	popupVaultBrowser
 	popupMappingForm
 	popupConfirm
+	popupSafety
+	popupQuit
+	popupComparePicker
+	popupDuplicatePicker
+	popupUncommitted
+	popupDefaultForm
+	popupDefaultConfirm
+	popupAuth
+)
+
+// safetyAction identifies the action waiting on a protected-environment
+// confirmation.
+type safetyAction int
+
+const (
+	safetyReveal safetyAction = iota
+	safetySave
+	safetyDelete
+	safetyWriteValue
+	safetyRevealEffective
+	safetySaveDefault
+	safetyDeleteDefault
+)
+
+// authRetry identifies the operation that failed for want of a Vault token,
+// run again once the user has logged in from the login popup.
+type authRetry int
+
+const (
+	authRetryNone authRetry = iota
+	authRetryDetail
+	authRetryWriteValue
+	authRetryEffective
+)
+
+// Login methods offered by the login popup.
+const (
+	authOIDC = iota
+	authAppRole
 )
 
 // model is the root Bubble Tea model for the vx TUI.
@@ -48,6 +89,7 @@ type model struct {
 	vaultClient *vault.Client
 
 	// UI state
+	plain       bool // linear, uncolored rendering (see plainView)
 	focus       focusPane
 	activePopup popup
 	filtering   bool
@@ -63,11 +105,26 @@ type model struct {
 	envPickerCursor int
 
 	// Detail popup
-	detailEnvVar  string
-	detailPath    string
-	detailValue   string
-	detailLoading bool
-	detailError   string
+	detailEnvVar    string
+	detailPath      string
+	detailValue     string
+	detailLoading   bool
+	detailError     string
+	detailMeta      *vault.KVMetadata
+	detailMetaErr   string
+	detailCached    bool               // value reused from values rather than read live
+	detailFetched   time.Time          // when the value was read from Vault
+	detailEditing   bool               // v pressed: the value is being edited
+	detailEditInput string             // the edited value, written to Vault on enter
+	detailCancel    context.CancelFunc // abandons the read in flight on Esc
+	detailVersion   int                // earlier version shown, picked from the history; 0 is the latest
+	detailHistory   bool               // h pressed: the version history is shown
+	historyCursor   int                // index into detailMeta.Versions
+
+	// Values resolved in the detail popup, keyed by interpolated path, and
+	// the workspace's cache_ttl overrides deciding how long they are reused
+	values    map[string]cachedValue
+	cacheTTLs map[string]config.Duration
 
 	// Vault browser state
 	vaultBrowserPath    string
@@ -89,9 +146,89 @@ type model struct {
 	confirmFile    string
 	confirmCursor  int // 0=cancel, 1=confirm
 
+	// Duplicate picker: the file holding the mapping being copied, and the
+	// cursor over the other files it can be copied to
+	duplicateSource       string
+	duplicatePickerCursor int
+
+	// Workspace comparison: the picker, then a split view that replaces the
+	// panes until closed
+	comparePickerCursor int
+	comparing           bool
+	compare             components.CompareTable
+
+	// Effective environment: every variable vx exec would inject, shown in
+	// place of the panes until closed
+	effective       bool
+	effectiveTable  components.EffectiveTable
+	effectiveCancel context.CancelFunc // set while resolving; Esc abandons it
+
+	// Defaults: the [defaults] tables of the selected workspace's vx.toml,
+	// shown in place of the panes until closed, and the form for adding or
+	// changing one value
+	defaultsView      bool
+	defaultsTable     components.DefaultsTable
+	defaultsFile      string
+	defaultFormTable  int // index into defaultTables()
+	defaultFormKey    string
+	defaultFormValue  string
+	defaultFormField  int // 0=table, 1=key, 2=value
+	defaultFormIsEdit bool
+
+	// vx.toml files with uncommitted git changes, shown in the status bar
+	uncommitted []bridge.ChangedFile
+
+	// Protected environment confirmation state: the action waiting, the
+	// environment whose name must be typed, and what has been typed
+	safetyAction safetyAction
+	safetyEnv    string
+	safetyInput  string
+
+	// Login popup: the method chosen, the AppRole credentials being typed,
+	// and the operation to run again once logged in
+	authMethod   int // authOIDC or authAppRole
+	authField    int // 0=method, 1=role ID, 2=secret ID
+	authRoleID   string
+	authSecretID string
+	authBusy     bool // a login is in flight
+	authError    string
+	authRetry    authRetry
+
+	// Writes (mapping saves and deletes) dispatched but not yet finished, and
+	// the quit prompt shown while they are in flight
+	pendingWrites int
+	quitCursor    int  // 0=wait then quit, 1=cancel
+	quitWhenIdle  bool // user chose to quit once pendingWrites reaches zero
+
 	// Status message timer
 	statusClearTimer *time.Timer
 
+	// Time of the newest journaled Vault change already reported
+	changesSeen time.Time
+
+	// Stamps of the vx.toml files as of the last load, compared with the
+	// files on disk to reload after outside edits. Nil while a reload the
+	// TUI started is in flight, so its own writes are not reported as such.
+	configStamps map[string]fileStamp
+	// configWatcher reports edits to the vx.toml files; nil when files
+	// cannot be watched
+	configWatcher *configWatcher
+
+	// Secret to select again once the workspace data reloads
+	reselect string
+
+	// Clipboard and the fingerprint of a copied secret awaiting clearing
+	clipboard        clipboard.Board
+	clipboardPending string
+
+	// Temp files holding secret values, deleted when their timer fires or
+	// on quit
+	tempFiles []string
+
+	// Selection requested on the command line; the secret is cleared once
+	// the first workspace load has selected it
+	startup startupSelection
+
 	// Error state
 	fatalError string
 }
@@ -99,14 +236,18 @@ type model struct {
 // newModel creates the initial model with the given bridge.
 func newModel(b *bridge.Bridge) model {
 	return model{
-		bridge: b,
-		focus:  focusWorkspaces,
+		bridge:      b,
+		focus:       focusWorkspaces,
+		clipboard:   clipboard.System,
+		changesSeen: time.Now(),
+		values:      make(map[string]cachedValue),
 	}
 }
 
-// Init loads the config on startup.
+// Init loads the config on startup and starts watching for secret changes
+// and edits to the vx.toml files.
 func (m model) Init() tea.Cmd {
-	return loadConfigCmd(m.bridge)
+	return tea.Batch(loadConfigCmd(m.bridge), pollChangesCmd(m.changesSeen), watchConfigCmd(m.configWatcher))
 }
 
 // loadConfigCmd creates a command that loads the root config.
@@ -116,35 +257,112 @@ func loadConfigCmd(b *bridge.Bridge) tea.Cmd {
 		if err != nil {
 			return configErrorMsg{err: err}
 		}
-		return configLoadedMsg{config: cfg, rootDir: rootDir}
+		return configLoadedMsg{
+			config:  cfg,
+			rootDir: rootDir,
+			stamps:  statFiles(configFiles(b, cfg, rootDir)),
+		}
 	}
 }
 
 // loadWorkspaceDataCmd creates a command that loads merged data for a workspace.
 func loadWorkspaceDataCmd(b *bridge.Bridge, cfg *config.RootConfig, rootDir, workspace, env string) tea.Cmd {
 	return func() tea.Msg {
-		var merged *config.MergedConfig
-		var err error
+		merged, err := mergeWorkspace(b, cfg, rootDir, workspace, env)
+		if err != nil {
+			return workspaceDataErrorMsg{err: err}
+		}
 
-		if workspace == "[root]" || workspace == "" {
-			merged, err = b.MergeRootOnly(cfg, env)
-		} else {
-			merged, err = b.MergeForWorkspace(cfg, rootDir, workspace, env)
+		return workspaceDataLoadedMsg{
+			secrets:  merged.Secrets,
+			source:   workspace,
+			cacheTTL: merged.CacheTTL,
 		}
+	}
+}
 
+// loadCompareCmd creates a command that loads merged data for two
+// workspaces to compare.
+func loadCompareCmd(b *bridge.Bridge, cfg *config.RootConfig, rootDir, left, right, env string) tea.Cmd {
+	return func() tea.Msg {
+		l, err := mergeWorkspace(b, cfg, rootDir, left, env)
 		if err != nil {
-			return workspaceDataErrorMsg{err: err}
+			return compareErrorMsg{err: err}
+		}
+		r, err := mergeWorkspace(b, cfg, rootDir, right, env)
+		if err != nil {
+			return compareErrorMsg{err: err}
 		}
 
-		return workspaceDataLoadedMsg{
-			secrets: merged.Secrets,
-			source:  workspace,
+		return compareLoadedMsg{
+			left:         left,
+			right:        right,
+			leftSecrets:  l.Secrets,
+			rightSecrets: r.Secrets,
 		}
 	}
 }
 
+// loadEffectiveCmd creates a command that resolves the complete environment
+// vx exec would inject for a workspace.
+func loadEffectiveCmd(ctx context.Context, b *bridge.Bridge, client *vault.Client, cfg *config.RootConfig, rootDir, workspace, env string) tea.Cmd {
+	return func() tea.Msg {
+		vars, err := b.EffectiveEnv(ctx, client, cfg, rootDir, workspace, env)
+		if ctx.Err() != nil {
+			return nil // abandoned with Esc
+		}
+		if err != nil {
+			return effectiveErrorMsg{err: err}
+		}
+		for _, v := range vars {
+			if needsLogin(v.Err) {
+				return authRequiredMsg{retry: authRetryEffective}
+			}
+		}
+		return effectiveLoadedMsg{workspace: workspace, env: env, vars: vars}
+	}
+}
+
+// loadDefaultsCmd creates a command that reads the [defaults] tables of a
+// vx.toml file.
+func loadDefaultsCmd(b *bridge.Bridge, filePath string, envs []string) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := b.ReadDefaults(filePath, envs)
+		if err != nil {
+			return defaultsErrorMsg{err: err}
+		}
+		return defaultsLoadedMsg{file: filePath, entries: entries}
+	}
+}
+
+// uncommittedFilesCmd creates a command that lists the vx.toml files with
+// uncommitted git changes. Outside a git work tree, or without git, the list
+// is empty.
+func uncommittedFilesCmd(b *bridge.Bridge, cfg *config.RootConfig, rootDir string) tea.Cmd {
+	return func() tea.Msg {
+		files, err := b.UncommittedFiles(cfg, rootDir)
+		if err != nil {
+			return uncommittedFilesMsg{}
+		}
+		return uncommittedFilesMsg{files: files}
+	}
+}
+
+// mergeWorkspace merges the config for a workspace, or the root config alone
+// for "[root]".
+func mergeWorkspace(b *bridge.Bridge, cfg *config.RootConfig, rootDir, workspace, env string) (*config.MergedConfig, error) {
+	if workspace == "[root]" || workspace == "" {
+		return b.MergeRootOnly(cfg, env)
+	}
+	return b.MergeForWorkspace(cfg, rootDir, workspace, env)
+}
+
 // View renders the entire TUI.
 func (m model) View() string {
+	if m.plain {
+		return m.plainView()
+	}
+
 	if m.fatalError != "" {
 		return lipgloss.NewStyle().
 			Foreground(colorError).
@@ -168,27 +386,44 @@ func (m model) View() string {
 
 	dims := components.CalculateLayout(m.width, m.height)
 
+	accent := accentFor(m.config, m.env)
+	m.workspaces.Accent = accent
+	m.secrets.Accent = accent
+
 	// Header
-	header := components.RenderHeader(m.width, m.env)
+	header := components.RenderHeader(m.width, m.env, accent)
 
 	// Dual pane
-	leftContent := m.workspaces.View(dims.LeftWidth-2, dims.ContentHeight-2)
-	rightContent := m.secrets.View(dims.RightWidth-2, dims.ContentHeight-2)
-	panes := components.RenderDualPane(
-		leftContent,
-		rightContent,
-		m.focus == focusWorkspaces,
-		dims,
-	)
+	var panes string
+	if m.defaultsView {
+		panes = m.renderDefaults(dims, accent)
+	} else if m.effective {
+		panes = m.renderEffective(dims, accent)
+	} else if m.comparing {
+		panes = m.renderCompare(dims, accent)
+	} else {
+		leftContent := m.workspaces.View(dims.LeftWidth-2, dims.ContentHeight-2)
+		m.secrets.Freshness = m.rowFreshness(time.Now())
+		rightContent := m.secrets.View(dims.RightWidth-2, dims.ContentHeight-2)
+		panes = components.RenderDualPane(
+			leftContent,
+			rightContent,
+			m.focus == focusWorkspaces,
+			dims,
+			accent,
+		)
+	}
 
 	// Status bar
 	m.statusBar.SecretCount = m.secrets.TotalLen()
 	m.statusBar.Filtering = m.filtering
 	m.statusBar.FilterText = m.filterText
+	m.statusBar.Uncommitted = len(m.uncommitted)
 	statusLine := m.statusBar.View(m.width)
 
 	// Footer
-	footer := components.RenderFooter(m.width, m.filtering, m.activePopup != popupNone)
+	footer := components.RenderFooter(m.width, m.filtering, m.activePopup != popupNone || m.comparing || m.effective || m.defaultsView,
+		m.displayPath(m.selectedWorkspaceFile()))
 
 	// Compose full layout
 	view := lipgloss.JoinVertical(lipgloss.Left,
@@ -206,28 +441,89 @@ func (m model) View() string {
 	return view
 }
 
+// renderCompare renders the workspace comparison as two equal panes in
+// place of the workspace list and secret table.
+func (m model) renderCompare(dims components.LayoutDimensions, accent lipgloss.Color) string {
+	// Leave the same five columns for borders as CalculateLayout.
+	half := (m.width - 5) / 2
+	dims.LeftWidth = half
+	dims.RightWidth = m.width - 5 - half
+
+	m.compare.Accent = accent
+	left := m.compare.View(dims.LeftWidth-2, dims.ContentHeight-2, false)
+	right := m.compare.View(dims.RightWidth-2, dims.ContentHeight-2, true)
+	return components.RenderDualPane(left, right, true, dims, accent)
+}
+
+// renderEffective renders the effective environment as one pane in place of
+// the workspace list and secret table.
+func (m model) renderEffective(dims components.LayoutDimensions, accent lipgloss.Color) string {
+	m.effectiveTable.Accent = accent
+	// As wide as the two panes together, borders included.
+	width := m.width - 3
+	content := m.effectiveTable.View(width-2, dims.ContentHeight-2)
+	return styleBorder.
+		BorderForeground(accent).
+		Width(width).
+		Height(dims.ContentHeight).
+		Render(content)
+}
+
+// renderDefaults renders the defaults tables as one pane in place of the
+// workspace list and secret table.
+func (m model) renderDefaults(dims components.LayoutDimensions, accent lipgloss.Color) string {
+	m.defaultsTable.Accent = accent
+	width := m.width - 3
+	content := m.defaultsTable.View(width-2, dims.ContentHeight-2)
+	return styleBorder.
+		BorderForeground(accent).
+		Width(width).
+		Height(dims.ContentHeight).
+		Render(content)
+}
+
 // overlayPopup renders the active popup centered on the screen.
 func (m model) overlayPopup(base string) string {
-	var popupContent string
+	popupContent := m.popupView()
+	if popupContent == "" {
+		return base
+	}
+	return placeOverlay(m.width, m.height, popupContent, base)
+}
 
+// popupView renders the active popup, or "" if none is open.
+func (m model) popupView() string {
 	switch m.activePopup {
 	case popupHelp:
-		popupContent = m.renderHelpPopup()
+		return m.renderHelpPopup()
 	case popupEnvPicker:
-		popupContent = m.renderEnvPickerPopup()
+		return m.renderEnvPickerPopup()
 	case popupDetail:
-		popupContent = m.renderDetailPopup()
+		return m.renderDetailPopup()
 	case popupVaultBrowser:
-		popupContent = m.renderVaultBrowserPopup()
+		return m.renderVaultBrowserPopup()
 	case popupMappingForm:
-		popupContent = m.renderMappingFormPopup()
+		return m.renderMappingFormPopup()
 	case popupConfirm:
-		popupContent = m.renderConfirmPopup()
-	default:
-		return base
+		return m.renderConfirmPopup()
+	case popupSafety:
+		return m.renderSafetyPopup()
+	case popupQuit:
+		return m.renderQuitPopup()
+	case popupComparePicker:
+		return m.renderComparePickerPopup()
+	case popupDuplicatePicker:
+		return m.renderDuplicatePickerPopup()
+	case popupUncommitted:
+		return m.renderUncommittedPopup()
+	case popupDefaultForm:
+		return m.renderDefaultFormPopup()
+	case popupDefaultConfirm:
+		return m.renderDefaultConfirmPopup()
+	case popupAuth:
+		return m.renderAuthPopup()
 	}
-
-	return placeOverlay(m.width, m.height, popupContent, base)
+	return ""
 }
 
 // placeOverlay centers the overlay string on top of base.
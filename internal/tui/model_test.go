@@ -1,13 +1,22 @@
 package tui
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"go.dot.industries/vx/internal/changes"
 	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/token"
 	"go.dot.industries/vx/internal/tui/bridge"
 	"go.dot.industries/vx/internal/tui/components"
+	"go.dot.industries/vx/internal/vault"
 )
 
 func testConfig() *config.RootConfig {
@@ -116,6 +125,23 @@ func TestWorkspaceDataLoadedMsg(t *testing.T) {
 	}
 }
 
+func TestWorkspaceDataLoadedUsesEnvironmentMap(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.config.Environments.Map = map[string]string{"production": "prod"}
+	m.env = "production"
+
+	updated, _ := m.Update(workspaceDataLoadedMsg{
+		secrets: map[string]string{"DATABASE_URL": "${env}/database/url"},
+		source:  "web",
+	})
+	mdl := updated.(model)
+
+	if got := mdl.secrets.Selected().VaultPath; got != "prod/database/url" {
+		t.Errorf("VaultPath = %q, want prod/database/url", got)
+	}
+}
+
 func TestEnvChangedMsg(t *testing.T) {
 	b := bridge.New("", "", "", "", "")
 	m := newModel(b)
@@ -292,7 +318,1550 @@ func TestSuggestEnvVar(t *testing.T) {
 	}
 }
 
+func TestAccentFor(t *testing.T) {
+	cfg := testConfig()
+	cfg.TUI.Accents = map[string]string{"dev": "#22C55E"}
+
+	if got := accentFor(cfg, "dev"); got != "#22C55E" {
+		t.Errorf("accentFor(dev) = %q, want configured #22C55E", got)
+	}
+	if got := accentFor(cfg, "production"); got != colorError {
+		t.Errorf("accentFor(production) = %q, want default %q", got, colorError)
+	}
+	if got := accentFor(cfg, "qa"); got != colorPrimary {
+		t.Errorf("accentFor(qa) = %q, want %q", got, colorPrimary)
+	}
+}
+
+func TestIsProtectedEnv(t *testing.T) {
+	cfg := testConfig()
+	if !isProtectedEnv(cfg, "production") {
+		t.Error("production should be protected by default")
+	}
+	if isProtectedEnv(cfg, "dev") {
+		t.Error("dev should not be protected by default")
+	}
+
+	cfg.TUI.ProtectedEnvironments = []string{"staging"}
+	if isProtectedEnv(cfg, "production") || !isProtectedEnv(cfg, "staging") {
+		t.Error("configured list should replace the default")
+	}
+
+	cfg.TUI.ProtectedEnvironments = []string{}
+	if isProtectedEnv(cfg, "production") {
+		t.Error("empty list should disable protection")
+	}
+}
+
+func TestProtectedEnvRevealRequiresConfirm(t *testing.T) {
+	b := bridge.New("", "", "", "", "")
+	m := newModel(b)
+	m.config = testConfig()
+	m.env = "production"
+	m.focus = focusSecrets
+	m.secrets = components.NewSecretTable(map[string]string{"API_KEY": "${env}/api/key"}, "production")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl := updated.(model)
+	if mdl.activePopup != popupSafety {
+		t.Fatalf("expected safety popup, got %d", mdl.activePopup)
+	}
+
+	// Wrong name keeps the prompt open.
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("prod")})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if mdl.activePopup != popupSafety {
+		t.Fatalf("wrong name should keep safety popup open, got %d", mdl.activePopup)
+	}
+
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("production")})
+	updated, cmd := updated.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if mdl.activePopup != popupDetail {
+		t.Errorf("expected detail popup after confirm, got %d", mdl.activePopup)
+	}
+	if mdl.detailEnvVar != "API_KEY" || cmd == nil {
+		t.Error("expected API_KEY to be resolving after confirm")
+	}
+}
+
+func TestProtectedEnvDeleteRequiresConfirm(t *testing.T) {
+	b := bridge.New("", "", "", "", "")
+	m := newModel(b)
+	m.config = testConfig()
+	m.env = "production"
+	m.activePopup = popupConfirm
+	m.confirmCursor = 1
+	m.confirmEnvVar = "API_KEY"
+	m.confirmFile = "/tmp/test/vx.toml"
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl := updated.(model)
+	if mdl.activePopup != popupSafety || cmd != nil {
+		t.Fatal("delete in production should wait for typed confirmation")
+	}
+
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(model).activePopup != popupNone {
+		t.Error("esc should cancel the protected action")
+	}
+}
+
+func TestSecretMetadataMsg(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.activePopup = popupDetail
+	m.detailEnvVar = "DATABASE_URL"
+
+	meta := &vault.KVMetadata{CurrentVersion: 3}
+
+	// A response for a previously opened secret is ignored.
+	updated, _ := m.Update(secretMetadataMsg{envVar: "OTHER", meta: meta})
+	if updated.(model).detailMeta != nil {
+		t.Error("stale metadata should be ignored")
+	}
+
+	updated, _ = m.Update(secretMetadataMsg{envVar: "DATABASE_URL", meta: meta})
+	if updated.(model).detailMeta != meta {
+		t.Error("expected metadata to be stored")
+	}
+
+	updated, _ = m.Update(secretMetadataErrorMsg{envVar: "DATABASE_URL", err: errors.New("permission denied")})
+	if updated.(model).detailMetaErr != "permission denied" {
+		t.Errorf("detailMetaErr = %q", updated.(model).detailMetaErr)
+	}
+}
+
+func TestFormatMetadata(t *testing.T) {
+	now := time.Date(2024, 5, 4, 10, 0, 0, 0, time.UTC)
+	meta := &vault.KVMetadata{
+		CurrentVersion: 3,
+		CreatedTime:    now.Add(-90 * 24 * time.Hour),
+		UpdatedTime:    now.Add(-3 * 24 * time.Hour),
+		CustomMetadata: map[string]string{"updated_by": "jane", "owner": "team-a"},
+	}
+
+	got := formatMetadata(meta, now)
+	for _, want := range []string{"v3", "3d ago", "3mo ago", "owner:", "team-a", "jane"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatMetadata() missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "owner") > strings.Index(got, "updated_by") {
+		t.Error("custom metadata should be sorted by key")
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{10 * time.Second, "just now"},
+		{5 * time.Minute, "5m ago"},
+		{3 * time.Hour, "3h ago"},
+		{49 * time.Hour, "2d ago"},
+		{200 * 24 * time.Hour, "6mo ago"},
+	}
+
+	for _, tt := range tests {
+		if got := formatAge(tt.d); got != tt.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+// fakeClipboard is an in-memory clipboard.Board.
+type fakeClipboard struct{ text string }
+
+func (f *fakeClipboard) ReadAll() (string, error) { return f.text, nil }
+
+func (f *fakeClipboard) WriteAll(text string) error {
+	f.text = text
+	return nil
+}
+
+func TestCopyClearsClipboardAfterDelay(t *testing.T) {
+	board := &fakeClipboard{}
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.clipboard = board
+	m.config = testConfig()
+	m.config.Clipboard.ClearAfter = config.Duration(30 * time.Second)
+	m.activePopup = popupDetail
+	m.detailValue = "s3cret"
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	mdl := updated.(model)
+	if board.text != "s3cret" {
+		t.Fatalf("clipboard = %q, want copied value", board.text)
+	}
+	if mdl.clipboardPending == "" || cmd == nil {
+		t.Fatal("expected a pending clipboard clear")
+	}
+
+	updated, _ = mdl.Update(clipboardClearMsg{fingerprint: mdl.clipboardPending})
+	mdl = updated.(model)
+	if board.text != "" {
+		t.Errorf("clipboard = %q, want cleared", board.text)
+	}
+	if mdl.statusBar.Message != "Clipboard cleared" {
+		t.Errorf("status = %q, want %q", mdl.statusBar.Message, "Clipboard cleared")
+	}
+}
+
+func TestClipboardClearSkipsOverwritten(t *testing.T) {
+	board := &fakeClipboard{}
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.clipboard = board
+	m.config = testConfig()
+	m.config.Clipboard.ClearAfter = config.Duration(time.Second)
+	m.activePopup = popupDetail
+	m.detailValue = "s3cret"
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	mdl := updated.(model)
+
+	board.text = "copied elsewhere"
+	mdl.Update(clipboardClearMsg{fingerprint: mdl.clipboardPending})
+	if board.text != "copied elsewhere" {
+		t.Errorf("clipboard = %q, want untouched", board.text)
+	}
+}
+
+func TestQuitClearsPendingClipboard(t *testing.T) {
+	board := &fakeClipboard{}
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.clipboard = board
+	m.config = testConfig()
+	m.config.Clipboard.ClearAfter = config.Duration(time.Minute)
+	m.activePopup = popupDetail
+	m.detailValue = "s3cret"
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	updated.(model).Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if board.text != "" {
+		t.Errorf("clipboard = %q, want cleared on quit", board.text)
+	}
+}
+
+func TestCopyWithoutClearAfter(t *testing.T) {
+	board := &fakeClipboard{}
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.clipboard = board
+	m.config = testConfig()
+	m.activePopup = popupDetail
+	m.detailValue = "s3cret"
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	if updated.(model).clipboardPending != "" {
+		t.Error("no clear should be scheduled when clear_after is unset")
+	}
+	if board.text != "s3cret" {
+		t.Errorf("clipboard = %q, want copied value", board.text)
+	}
+}
+
+func TestWriteTempFile(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	board := &fakeClipboard{}
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.clipboard = board
+	m.config = testConfig()
+	m.activePopup = popupDetail
+	m.detailEnvVar = "KUBECONFIG_DATA"
+	m.detailValue = "apiVersion: v1\n"
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}})
+	mdl := updated.(model)
+	if len(mdl.tempFiles) != 1 || cmd == nil {
+		t.Fatalf("tempFiles = %v, want one file awaiting deletion", mdl.tempFiles)
+	}
+	path := mdl.tempFiles[0]
+	if board.text != path {
+		t.Errorf("clipboard = %q, want %q", board.text, path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(path); string(data) != "apiVersion: v1\n" {
+		t.Errorf("contents = %q, want the value", data)
+	}
+
+	updated, _ = mdl.Update(tempFileExpiredMsg{path: path})
+	if len(updated.(model).tempFiles) != 0 {
+		t.Error("expired file still tracked")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Stat() error = %v, want the file deleted", err)
+	}
+}
+
+func TestQuitRemovesTempFiles(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.clipboard = &fakeClipboard{}
+	m.config = testConfig()
+	m.activePopup = popupDetail
+	m.detailEnvVar = "TLS_KEY"
+	m.detailValue = "-----BEGIN KEY-----"
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}})
+	path := updated.(model).tempFiles[0]
+	updated.(model).Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Stat() error = %v, want the file deleted on quit", err)
+	}
+}
+
 // testWorkspaceList creates a workspace list for testing.
 func testWorkspaceList() components.WorkspaceList {
 	return components.NewWorkspaceList([]string{"web", "api"}, true)
 }
+
+func TestQuitWhileWritePendingPrompts(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.env = "dev"
+	m.pendingWrites = 1
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	mdl := updated.(model)
+	if cmd != nil {
+		t.Fatal("quit should wait for confirmation while a write is pending")
+	}
+	if mdl.activePopup != popupQuit {
+		t.Fatalf("activePopup = %v, want popupQuit", mdl.activePopup)
+	}
+
+	// Choose "wait, then quit".
+	updated, cmd = mdl.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if cmd != nil || !mdl.quitWhenIdle {
+		t.Fatal("expected to wait for the pending write")
+	}
+
+	_, cmd = mdl.Update(mappingSavedMsg{})
+	if cmd == nil {
+		t.Fatal("expected quit once the write finished")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("expected tea.QuitMsg after the pending write finished")
+	}
+}
+
+func TestQuitWaitCancelledByFailedWrite(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.pendingWrites = 1
+	m.activePopup = popupQuit
+	m.quitWhenIdle = true
+
+	updated, _ := m.Update(mappingDeleteErrorMsg{err: errors.New("disk full")})
+	mdl := updated.(model)
+
+	if mdl.quitWhenIdle || mdl.activePopup != popupNone {
+		t.Error("a failed write should cancel the pending quit")
+	}
+	if mdl.pendingWrites != 0 {
+		t.Errorf("pendingWrites = %d, want 0", mdl.pendingWrites)
+	}
+	if !strings.Contains(mdl.statusBar.Message, "disk full") {
+		t.Errorf("status = %q, want the delete error", mdl.statusBar.Message)
+	}
+}
+
+func TestForceQuitTwiceWhileWritePending(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.pendingWrites = 1
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if cmd != nil {
+		t.Fatal("first Ctrl+C should prompt while a write is pending")
+	}
+
+	_, cmd = updated.(model).Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if cmd == nil {
+		t.Fatal("second Ctrl+C should quit immediately")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("expected tea.QuitMsg")
+	}
+}
+
+func TestDeleteConfirmTracksPendingWrite(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.env = "dev"
+	m.activePopup = popupConfirm
+	m.confirmCursor = 1
+	m.confirmFile = "/tmp/vx.toml"
+	m.confirmEnvVar = "API_KEY"
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := updated.(model).pendingWrites; got != 1 {
+		t.Errorf("pendingWrites = %d, want 1", got)
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "code --wait")
+
+	cmd, err := editorCommand("/tmp/vx.toml")
+	if err != nil {
+		t.Fatalf("editorCommand() error = %v", err)
+	}
+	want := []string{"code", "--wait", "/tmp/vx.toml"}
+	if strings.Join(cmd.Args, " ") != strings.Join(want, " ") {
+		t.Errorf("Args = %v, want %v", cmd.Args, want)
+	}
+
+	t.Setenv("VISUAL", "nano")
+	cmd, _ = editorCommand("/tmp/vx.toml")
+	if cmd.Args[0] != "nano" {
+		t.Errorf("VISUAL should take precedence, got %v", cmd.Args)
+	}
+}
+
+func TestSelectedWorkspaceFile(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.rootDir = "/repo"
+	m.workspaces = components.NewWorkspaceList([]string{"web", "api"}, true)
+
+	if got := m.displayPath(m.selectedWorkspaceFile()); got != "web/vx.toml" {
+		t.Errorf("selected file = %q, want web/vx.toml", got)
+	}
+}
+
+func TestOpenRefusedWhileWritePending(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.rootDir = "/repo"
+	m.workspaces = testWorkspaceList()
+	m.pendingWrites = 1
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+	if !updated.(model).statusBar.IsError {
+		t.Error("opening the editor should be refused while a write is pending")
+	}
+}
+
+func TestEditorFinishedReloads(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.rootDir = "/repo"
+
+	updated, cmd := m.Update(editorFinishedMsg{path: "/repo/web/vx.toml"})
+	mdl := updated.(model)
+	if cmd == nil {
+		t.Fatal("expected a reload after the editor exits")
+	}
+	if mdl.statusBar.Message != "Reloaded web/vx.toml" {
+		t.Errorf("status = %q", mdl.statusBar.Message)
+	}
+
+	updated, _ = m.Update(editorFinishedMsg{path: "/repo/web/vx.toml", err: errors.New("exit status 1")})
+	if !updated.(model).statusBar.IsError {
+		t.Error("editor failure should be reported as an error")
+	}
+}
+
+func TestStateRestoredOnConfigLoad(t *testing.T) {
+	dir := t.TempDir()
+	orig := stateDir
+	stateDir = func() string { return dir }
+	t.Cleanup(func() { stateDir = orig })
+
+	rootDir := t.TempDir()
+	if err := saveState(rootDir, savedState{Workspace: "api", Environment: "staging", Filter: "db"}); err != nil {
+		t.Fatalf("saveState() error = %v", err)
+	}
+
+	m := newModel(bridge.New("", "", "", "", ""))
+	updated, _ := m.Update(configLoadedMsg{config: testConfig(), rootDir: rootDir})
+	mdl := updated.(model)
+
+	if mdl.env != "staging" {
+		t.Errorf("env = %q, want staging", mdl.env)
+	}
+	if got := mdl.workspaces.Selected(); got != "api" {
+		t.Errorf("workspace = %q, want api", got)
+	}
+	if mdl.filterText != "db" || mdl.secrets.Filter != "db" {
+		t.Errorf("filter = %q/%q, want db", mdl.filterText, mdl.secrets.Filter)
+	}
+
+	// Quitting saves the current selection.
+	mdl.env = "production"
+	mdl.quit()
+	if got := loadState(rootDir); got.Environment != "production" || got.Workspace != "api" {
+		t.Errorf("saved state = %+v", got)
+	}
+}
+
+func TestStateIgnoresStaleEntries(t *testing.T) {
+	dir := t.TempDir()
+	orig := stateDir
+	stateDir = func() string { return dir }
+	t.Cleanup(func() { stateDir = orig })
+
+	rootDir := t.TempDir()
+	if err := saveState(rootDir, savedState{Workspace: "removed", Environment: "qa"}); err != nil {
+		t.Fatalf("saveState() error = %v", err)
+	}
+
+	m := newModel(bridge.New("", "", "", "", ""))
+	updated, _ := m.Update(configLoadedMsg{config: testConfig(), rootDir: rootDir})
+	mdl := updated.(model)
+
+	if mdl.env != "dev" {
+		t.Errorf("env = %q, want the default dev", mdl.env)
+	}
+	if got := mdl.workspaces.Selected(); got != "web" {
+		t.Errorf("workspace = %q, want the first workspace", got)
+	}
+}
+
+func TestConfigChangedOnDiskReloads(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.rootDir = "/repo"
+	m.env = "staging"
+	m.environments = m.config.Environments.Available
+	m.workspaces = testWorkspaceList()
+	m.workspaces.Select("api")
+	m.secrets.SetSecrets(map[string]string{"A": "a/x", "B": "b/x"}, "staging")
+	m.secrets.Select("B")
+
+	loaded := time.Now()
+	m.configStamps = map[string]fileStamp{
+		"/repo/vx.toml":     {modTime: loaded, size: 10},
+		"/repo/web/vx.toml": {modTime: loaded, size: 10},
+	}
+
+	updated, cmd := m.Update(configFilesStatMsg{stamps: map[string]fileStamp{
+		"/repo/vx.toml":     {modTime: loaded, size: 10},
+		"/repo/web/vx.toml": {modTime: loaded, size: 10},
+	}})
+	if updated.(model).statusBar.Message != "" || cmd != nil {
+		t.Fatalf("unchanged files: status %q, want none and nothing to do", updated.(model).statusBar.Message)
+	}
+
+	edited := map[string]fileStamp{
+		"/repo/vx.toml":     {modTime: loaded, size: 10},
+		"/repo/web/vx.toml": {modTime: loaded.Add(time.Second), size: 12},
+	}
+
+	// Not while a mapping is being edited; the files are checked again.
+	m.activePopup = popupMappingForm
+	updated, cmd = m.Update(configFilesStatMsg{stamps: edited})
+	if updated.(model).statusBar.Message != "" || cmd == nil {
+		t.Errorf("during a mapping edit: status %q, want none and a retry", updated.(model).statusBar.Message)
+	}
+	m.activePopup = popupNone
+
+	// A vx.toml the loaded config does not know, e.g. a new workspace.
+	added := map[string]fileStamp{
+		"/repo/vx.toml":     {modTime: loaded, size: 10},
+		"/repo/web/vx.toml": {modTime: loaded, size: 10},
+		"/repo/cli/vx.toml": {modTime: loaded, size: 4},
+	}
+	updated, _ = m.Update(configFilesStatMsg{stamps: added})
+	if got := updated.(model).statusBar.Message; got != "Reloaded cli/vx.toml (changed on disk)" {
+		t.Errorf("new workspace file: status %q", got)
+	}
+
+	updated, cmd = m.Update(configFilesStatMsg{stamps: edited})
+	mdl := updated.(model)
+	if mdl.statusBar.Message != "Reloaded web/vx.toml (changed on disk)" || cmd == nil {
+		t.Errorf("status = %q, want the reload reported", mdl.statusBar.Message)
+	}
+
+	// The reloaded config keeps the selection.
+	updated, _ = mdl.Update(configLoadedMsg{config: testConfig(), rootDir: "/repo", stamps: edited})
+	mdl = updated.(model)
+	if mdl.env != "staging" || mdl.workspaces.Selected() != "api" {
+		t.Errorf("after reload: env %q, workspace %q; want staging, api", mdl.env, mdl.workspaces.Selected())
+	}
+	updated, _ = mdl.Update(workspaceDataLoadedMsg{secrets: map[string]string{"A": "a/x", "B": "b/x"}, source: "api"})
+	reloaded := updated.(model)
+	if row := reloaded.secrets.Selected(); row == nil || row.EnvVar != "B" {
+		t.Errorf("selected secret after reload = %+v, want B", row)
+	}
+
+	updated, _ = mdl.Update(configReloadErrorMsg{err: errors.New("parsing vx.toml")})
+	if mdl := updated.(model); !mdl.statusBar.IsError || mdl.fatalError != "" || mdl.config == nil {
+		t.Error("a failed reload should be reported and keep the loaded config")
+	}
+}
+
+func TestConfigWatcher(t *testing.T) {
+	cw := newConfigWatcher()
+	if cw == nil {
+		t.Skip("file watching is not available")
+	}
+
+	root := t.TempDir()
+	rootFile := filepath.Join(root, "vx.toml")
+	if err := os.WriteFile(rootFile, []byte("[secrets]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cw.watch(root, []string{rootFile})
+
+	changed := make(chan string, 16)
+	go func() {
+		defer close(changed)
+		for {
+			p, ok := cw.next()
+			if !ok {
+				return
+			}
+			changed <- p
+		}
+	}()
+	defer func() {
+		cw.close()
+		for range changed {
+		}
+	}()
+
+	// waitFor drains changes until path is reported.
+	waitFor := func(path string) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case got := <-changed:
+				if got == path {
+					return
+				}
+				if got != rootFile {
+					t.Fatalf("change reported for %q", got)
+				}
+			case <-timeout:
+				t.Fatalf("no change reported for %q", path)
+			}
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rootFile, []byte("[secrets]\nA = \"a\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(rootFile)
+
+	// A workspace directory created after the watch started is watched too.
+	dir := filepath.Join(root, "web")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		cw.mu.Lock()
+		watched := cw.dirs[dir]
+		cw.mu.Unlock()
+		if watched {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s is not watched", dir)
+		}
+	}
+	wsFile := filepath.Join(dir, "vx.toml")
+	if err := os.WriteFile(wsFile, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(wsFile)
+}
+
+func TestBreadcrumbs(t *testing.T) {
+	if got := breadcrumbs("secret", "dev/database/", 80); got != "secret › dev › database" {
+		t.Errorf("breadcrumbs() = %q", got)
+	}
+	if got := breadcrumbs("", "", 80); got != "/" {
+		t.Errorf("breadcrumbs() at base = %q, want /", got)
+	}
+	if got := breadcrumbs("secret", "dev/services/payments/stripe/", 24); got != "… › payments › stripe" {
+		t.Errorf("breadcrumbs() truncated = %q", got)
+	}
+}
+
+func TestVaultBrowserUpAndRootKeys(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.activePopup = popupVaultBrowser
+	m.vaultBrowserPath = "dev/services/api/"
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}})
+	mdl := updated.(model)
+	if mdl.vaultBrowserPath != "dev/services/" || cmd == nil {
+		t.Errorf("u: path = %q, want dev/services/ with a reload", mdl.vaultBrowserPath)
+	}
+
+	updated, cmd = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'~'}})
+	mdl = updated.(model)
+	if mdl.vaultBrowserPath != "" || cmd == nil {
+		t.Errorf("~: path = %q, want the base path with a reload", mdl.vaultBrowserPath)
+	}
+
+	_, cmd = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	if cmd != nil {
+		t.Error("g at the base path should not reload")
+	}
+}
+
+func TestSecretsChangedReportsMappedPaths(t *testing.T) {
+	b := bridge.New("", "", "", "", "")
+	m := newModel(b)
+	m.config = testConfig()
+	m.env = "dev"
+	m.secrets.SetSecrets(map[string]string{
+		"DATABASE_URL": "${env}/database/url",
+		"API_KEY":      "${env}/api/key",
+	}, "dev")
+
+	seen := m.changesSeen
+	msg := secretsChangedMsg{entries: []changes.Change{
+		{Path: "dev/database", Time: seen.Add(-time.Second)},
+		{Path: "prod/database", Time: seen.Add(time.Second)},
+		{Path: "dev/api", Time: seen.Add(2 * time.Second)},
+	}}
+
+	updated, cmd := m.Update(msg)
+	mdl := updated.(model)
+
+	if mdl.statusBar.Message != "Vault: dev/api changed" {
+		t.Errorf("status = %q, want %q", mdl.statusBar.Message, "Vault: dev/api changed")
+	}
+	if !mdl.changesSeen.Equal(seen.Add(2 * time.Second)) {
+		t.Errorf("changesSeen = %v, want the newest change", mdl.changesSeen)
+	}
+	if cmd == nil {
+		t.Error("expected the journal to be polled again")
+	}
+}
+
+func TestSecretsChangedRefreshesDetail(t *testing.T) {
+	b := bridge.New("", "", "", "", "")
+	m := newModel(b)
+	m.config = testConfig()
+	m.env = "dev"
+	m.secrets.SetSecrets(map[string]string{"API_KEY": "${env}/api/key"}, "dev")
+	m.activePopup = popupDetail
+	m.detailEnvVar = "API_KEY"
+	m.detailValue = "old"
+
+	updated, _ := m.Update(secretsChangedMsg{entries: []changes.Change{
+		{Path: "dev/api", Time: m.changesSeen.Add(time.Second)},
+	}})
+	mdl := updated.(model)
+
+	if mdl.detailValue != "" || !mdl.detailLoading {
+		t.Errorf("detail = %q (loading %v), want it re-resolving", mdl.detailValue, mdl.detailLoading)
+	}
+}
+
+func TestDetailReusesCachedValue(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.env = "dev"
+	m.focus = focusSecrets
+	m.secrets.SetSecrets(map[string]string{"API_KEY": "${env}/api/key"}, "dev")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updated, _ = updated.(model).Update(secretResolvedMsg{envVar: "API_KEY", value: "k1"})
+	mdl := updated.(model)
+	if mdl.detailCached {
+		t.Error("a value just read from Vault should show as live")
+	}
+	if ages := mdl.rowFreshness(time.Now()); ages["API_KEY"] != "just now" {
+		t.Errorf("rowFreshness() = %v, want API_KEY cached just now", ages)
+	}
+
+	// Reopening shows the cached value without resolving again.
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if mdl.detailValue != "k1" || mdl.detailLoading || !mdl.detailCached {
+		t.Fatalf("detail = %q (loading %v, cached %v), want the cached value", mdl.detailValue, mdl.detailLoading, mdl.detailCached)
+	}
+
+	// R bypasses the cache.
+	updated, cmd := mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'R'}})
+	mdl = updated.(model)
+	if !mdl.detailLoading || mdl.detailCached || cmd == nil {
+		t.Errorf("R: loading %v, cached %v; want a live resolve", mdl.detailLoading, mdl.detailCached)
+	}
+}
+
+func TestCachedValueRespectsCacheTTL(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.cacheTTLs = map[string]config.Duration{"DB_TOKEN": 0, "API_KEY": config.Duration(time.Minute)}
+	now := time.Now()
+
+	m.storeValue("DB_TOKEN", "dev/db/token", "t", now)
+	m.storeValue("API_KEY", "dev/api/key", "k", now)
+	m.storeValue("STRIPE_KEY", "dev/stripe/key", "s", now)
+
+	if _, ok := m.cachedValueFor("DB_TOKEN", "dev/db/token", now); ok {
+		t.Error("cache_ttl = 0 should never reuse a value")
+	}
+	if _, ok := m.cachedValueFor("API_KEY", "dev/api/key", now.Add(2*time.Minute)); ok {
+		t.Error("a value older than its cache_ttl was reused")
+	}
+	if _, ok := m.cachedValueFor("STRIPE_KEY", "dev/stripe/key", now.Add(4*time.Minute)); !ok {
+		t.Error("a value within the default lifetime was not reused")
+	}
+
+	m.forgetValues("dev/stripe")
+	if _, ok := m.cachedValueFor("STRIPE_KEY", "dev/stripe/key", now); ok {
+		t.Error("forgetValues() kept a value from the changed secret")
+	}
+}
+
+func TestSecretDir(t *testing.T) {
+	tests := map[string]string{
+		"dev/database/url":   "dev/database",
+		"/dev//database/url": "dev/database",
+		"key":                "",
+	}
+	for in, want := range tests {
+		if got := secretDir(in); got != want {
+			t.Errorf("secretDir(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCompareWorkspaces(t *testing.T) {
+	b := bridge.New("", "", "", "", "")
+	m := newModel(b)
+	m.config = testConfig()
+	m.env = "dev"
+	m.width, m.height = 120, 30
+	m.workspaces = components.NewWorkspaceList([]string{"web", "api"}, true)
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	mdl := updated.(model)
+	if mdl.activePopup != popupComparePicker {
+		t.Fatalf("activePopup = %v, want the compare picker", mdl.activePopup)
+	}
+	if got := mdl.compareChoices(); len(got) != 2 || got[0] != "api" || got[1] != "[root]" {
+		t.Errorf("compareChoices() = %v, want [api [root]]", got)
+	}
+
+	updated, cmd := mdl.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if mdl.activePopup != popupNone || cmd == nil {
+		t.Fatalf("enter should close the picker and load both workspaces")
+	}
+
+	updated, _ = mdl.Update(compareLoadedMsg{
+		left:         "web",
+		right:        "api",
+		leftSecrets:  map[string]string{"DATABASE_URL": "${env}/web/database/url", "SHARED_KEY": "${env}/shared/key"},
+		rightSecrets: map[string]string{"DATABASE_URL": "${env}/api/database/url", "QUEUE_URL": "${env}/api/queue"},
+	})
+	mdl = updated.(model)
+	if !mdl.comparing {
+		t.Fatal("comparing should be set once both workspaces load")
+	}
+	if n := mdl.compare.Count(components.CompareDiffers); n != 1 {
+		t.Errorf("differing keys = %d, want 1", n)
+	}
+
+	view := mdl.View()
+	if !strings.Contains(view, "web/database/url") || !strings.Contains(view, "api/database/url") {
+		t.Error("view should show both workspaces' paths side by side")
+	}
+
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(model).comparing {
+		t.Error("esc should leave compare mode")
+	}
+}
+
+func TestCompareNeedsTwoWorkspaces(t *testing.T) {
+	b := bridge.New("", "", "", "", "")
+	m := newModel(b)
+	m.config = testConfig()
+	m.workspaces = components.NewWorkspaceList([]string{"web"}, false)
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	mdl := updated.(model)
+	if mdl.activePopup != popupNone || !mdl.statusBar.IsError {
+		t.Errorf("compare with one workspace should report an error, got popup %v status %q", mdl.activePopup, mdl.statusBar.Message)
+	}
+}
+
+func TestEffectiveEnvironment(t *testing.T) {
+	b := bridge.New("", "", "", "", "")
+	m := newModel(b)
+	m.config = testConfig()
+	m.env = "production"
+	m.width, m.height = 120, 30
+	m.workspaces = components.NewWorkspaceList([]string{"web"}, false)
+
+	vars := []bridge.EffectiveVar{
+		{Name: "API_KEY", Value: "s3cret", Secret: true, Source: "web/vx.toml [secrets]", Overrides: []string{"vx.toml [defaults]"}},
+		{Name: "LOG_LEVEL", Value: "info", Source: "vx.toml [defaults.production]"},
+		{Name: "TOKEN", Source: "vx.toml [secrets]", Err: errors.New("permission denied")},
+	}
+
+	updated, _ := m.Update(effectiveLoadedMsg{workspace: "api", env: "production", vars: vars})
+	if updated.(model).effective {
+		t.Fatal("a result for another workspace should be dropped")
+	}
+
+	updated, _ = m.Update(effectiveLoadedMsg{workspace: "web", env: "production", vars: vars})
+	mdl := updated.(model)
+	if !mdl.effective {
+		t.Fatal("effective should be set once the environment loads")
+	}
+
+	view := mdl.View()
+	if strings.Contains(view, "s3cret") || !strings.Contains(view, "••••••••") {
+		t.Error("secret values should be masked by default")
+	}
+	if !strings.Contains(view, "info") || !strings.Contains(view, "web/vx.toml [secrets]") {
+		t.Error("view should show defaults and where each variable comes from")
+	}
+	if !strings.Contains(view, "overrides vx.toml [defaults]") {
+		t.Error("view should list what the selected variable overrides")
+	}
+
+	// Revealing in a protected environment asks first.
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if mdl.activePopup != popupSafety {
+		t.Fatalf("expected safety popup, got %d", mdl.activePopup)
+	}
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("production")})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if mdl.activePopup != popupNone || !strings.Contains(mdl.View(), "s3cret") {
+		t.Error("confirming should reveal the selected secret")
+	}
+
+	// Masking again needs no confirmation.
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if mdl.activePopup != popupNone || strings.Contains(mdl.View(), "s3cret") {
+		t.Error("enter should mask a revealed secret")
+	}
+
+	mdl.plain = true
+	if view := mdl.View(); !strings.Contains(view, "TOKEN = (error) from vx.toml [secrets], vx exec would fail: permission denied") {
+		t.Errorf("plain view should report the failing secret:\n%s", view)
+	}
+
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(model).effective {
+		t.Error("esc should close the effective environment")
+	}
+}
+
+func TestEffectiveEnvironmentCancel(t *testing.T) {
+	b := bridge.New("", "", "", "", "")
+	m := newModel(b)
+	m.config = testConfig()
+	m.env = "dev"
+	m.workspaces = components.NewWorkspaceList([]string{"web"}, false)
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("E")})
+	mdl := updated.(model)
+	if cmd == nil || mdl.effectiveCancel == nil {
+		t.Fatal("E should start resolving the effective environment")
+	}
+
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	mdl = updated.(model)
+	if mdl.effectiveCancel != nil || !strings.Contains(mdl.statusBar.Message, "Cancelled") {
+		t.Errorf("esc should cancel the resolution, status %q", mdl.statusBar.Message)
+	}
+	// The abandoned resolution reports nothing.
+	if msg := cmd(); msg != nil {
+		t.Errorf("cancelled resolution sent %T", msg)
+	}
+}
+
+func TestDuplicateMapping(t *testing.T) {
+	b := bridge.New("", "", "", "", "")
+	m := newModel(b)
+	m.config = testConfig()
+	m.env = "dev"
+	m.width, m.height = 120, 30
+	m.workspaces = components.NewWorkspaceList([]string{"web", "api"}, true)
+	m.secrets.SetSecrets(map[string]string{"SHARED_KEY": "${env}/shared/key"}, "dev")
+	m.focus = focusSecrets
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	mdl := updated.(model)
+	if mdl.activePopup != popupDuplicatePicker {
+		t.Fatalf("activePopup = %v, want the duplicate picker", mdl.activePopup)
+	}
+
+	// The mapping lives in the root file, so only the workspaces are offered.
+	targets := mdl.duplicateTargets()
+	if len(targets) != 2 || targets[0].Label != "web" || targets[1].Label != "api" {
+		t.Fatalf("duplicateTargets() = %+v, want web and api", targets)
+	}
+	if !strings.Contains(mdl.View(), "Duplicate SHARED_KEY To") {
+		t.Error("view should show the duplicate picker")
+	}
+
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if mdl.activePopup != popupMappingForm {
+		t.Fatalf("activePopup = %v, want the mapping form", mdl.activePopup)
+	}
+	if mdl.mappingFormIsEdit || mdl.mappingFormEnvVar != "SHARED_KEY" || mdl.mappingFormPath != "${env}/shared/key" {
+		t.Errorf("form = %q -> %q (edit %v), want a new SHARED_KEY mapping", mdl.mappingFormEnvVar, mdl.mappingFormPath, mdl.mappingFormIsEdit)
+	}
+	if got := b.WorkspaceFiles(mdl.config, mdl.rootDir)[mdl.mappingFormTarget].Label; got != "api" {
+		t.Errorf("form target = %q, want api", got)
+	}
+	if mdl.mappingFormField != 0 {
+		t.Errorf("focused field = %d, want the vault path", mdl.mappingFormField)
+	}
+}
+
+func TestPlainView(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.plain = true
+	m.config = testConfig()
+	m.env = "production"
+	m.width, m.height = 80, 24
+	m.workspaces = components.NewWorkspaceList([]string{"web", "api"}, true)
+	m.secrets.SetSecrets(map[string]string{
+		"API_KEY":      "${env}/api/key",
+		"DATABASE_URL": "${env}/database/url",
+	}, "production")
+	m.focus = focusSecrets
+	m.secrets.MoveDown()
+
+	view := m.View()
+	for _, want := range []string{
+		"Environment: production (protected)",
+		"> web",
+		"  API_KEY from production/api/key",
+		"> DATABASE_URL from production/database/url",
+		"Secrets in web (focused): 2",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("plain view missing %q:\n%s", want, view)
+		}
+	}
+	if strings.ContainsAny(view, "╭│─") || strings.Contains(view, "\x1b[") {
+		t.Errorf("plain view should have no box drawing or escape codes:\n%s", view)
+	}
+
+	m.activePopup = popupEnvPicker
+	if view := m.View(); strings.Contains(view, "Secrets in") || !strings.Contains(view, "Select Environment") {
+		t.Errorf("plain view should show an open popup on its own:\n%s", view)
+	}
+}
+
+func TestUncommittedFiles(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.rootDir = "/repo"
+	m.width, m.height = 100, 30
+
+	updated, _ := m.Update(uncommittedFilesMsg{files: []bridge.ChangedFile{
+		{FileTarget: bridge.FileTarget{Label: "[root]", Path: "/repo/vx.toml"}, State: "modified"},
+		{FileTarget: bridge.FileTarget{Label: "web", Path: "/repo/web/vx.toml"}, State: "untracked"},
+	}})
+	m = updated.(model)
+
+	if view := m.View(); !strings.Contains(view, "2 uncommitted") {
+		t.Errorf("status bar should count uncommitted files:\n%s", view)
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	m = updated.(model)
+	if m.activePopup != popupUncommitted {
+		t.Fatalf("activePopup = %v, want popupUncommitted", m.activePopup)
+	}
+	if cmd == nil {
+		t.Error("opening the list should refresh it")
+	}
+	popup := m.renderUncommittedPopup()
+	for _, want := range []string{"modified", "vx.toml", "untracked", "web/vx.toml"} {
+		if !strings.Contains(popup, want) {
+			t.Errorf("popup missing %q:\n%s", want, popup)
+		}
+	}
+
+	m.plain = true
+	m.activePopup = popupNone
+	if view := m.View(); !strings.Contains(view, "2 vx.toml file(s) with uncommitted changes") {
+		t.Errorf("plain view should mention uncommitted files:\n%s", view)
+	}
+
+	updated, _ = m.Update(uncommittedFilesMsg{})
+	if n := len(updated.(model).uncommitted); n != 0 {
+		t.Errorf("uncommitted = %d after everything was committed, want 0", n)
+	}
+}
+
+func TestPlainWindow(t *testing.T) {
+	tests := []struct {
+		n, cursor, size int
+		start, end      int
+	}{
+		{5, 0, 10, 0, 5},
+		{20, 0, 5, 0, 5},
+		{20, 10, 5, 8, 13},
+		{20, 19, 5, 15, 20},
+		{20, 3, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		start, end := plainWindow(tt.n, tt.cursor, tt.size)
+		if start != tt.start || end != tt.end {
+			t.Errorf("plainWindow(%d, %d, %d) = %d, %d; want %d, %d", tt.n, tt.cursor, tt.size, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestStartupSelection(t *testing.T) {
+	dir := t.TempDir()
+	orig := stateDir
+	stateDir = func() string { return dir }
+	t.Cleanup(func() { stateDir = orig })
+
+	rootDir := t.TempDir()
+	if err := saveState(rootDir, savedState{Workspace: "web", Environment: "dev", Filter: "shared"}); err != nil {
+		t.Fatalf("saveState() error = %v", err)
+	}
+
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.startup = startupSelection{workspace: "api", env: "staging", secret: "DATABASE_URL"}
+
+	updated, _ := m.Update(configLoadedMsg{config: testConfig(), rootDir: rootDir})
+	mdl := updated.(model)
+
+	if mdl.env != "staging" {
+		t.Errorf("env = %q, want staging over the saved dev", mdl.env)
+	}
+	if got := mdl.workspaces.Selected(); got != "api" {
+		t.Errorf("workspace = %q, want api", got)
+	}
+	if mdl.filterText != "" || mdl.focus != focusSecrets {
+		t.Errorf("filter = %q, focus = %d; want no filter and the secrets focused", mdl.filterText, mdl.focus)
+	}
+
+	updated, cmd := mdl.Update(workspaceDataLoadedMsg{
+		secrets: map[string]string{"API_KEY": "${env}/api/key", "DATABASE_URL": "${env}/api/db"},
+		source:  "api",
+	})
+	mdl = updated.(model)
+
+	if mdl.activePopup != popupDetail || mdl.detailEnvVar != "DATABASE_URL" || cmd == nil {
+		t.Errorf("popup = %d, detail = %q; want DATABASE_URL resolving", mdl.activePopup, mdl.detailEnvVar)
+	}
+	if mdl.startup.secret != "" {
+		t.Error("startup secret should be consumed by the first load")
+	}
+
+	// Later loads leave the selection alone.
+	mdl.activePopup = popupNone
+	updated, _ = mdl.Update(workspaceDataLoadedMsg{secrets: map[string]string{"DATABASE_URL": "x"}, source: "api"})
+	if updated.(model).activePopup != popupNone {
+		t.Error("a later workspace load reopened the detail popup")
+	}
+}
+
+func TestStartupSelectionProblems(t *testing.T) {
+	dir := t.TempDir()
+	orig := stateDir
+	stateDir = func() string { return dir }
+	t.Cleanup(func() { stateDir = orig })
+
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.startup = startupSelection{workspace: "nope", env: "qa", secret: "MISSING"}
+
+	updated, _ := m.Update(configLoadedMsg{config: testConfig(), rootDir: t.TempDir()})
+	mdl := updated.(model)
+
+	if mdl.env != "dev" || mdl.workspaces.Selected() != "web" {
+		t.Errorf("env = %q, workspace = %q; want the defaults", mdl.env, mdl.workspaces.Selected())
+	}
+	if !mdl.statusBar.IsError || !strings.Contains(mdl.statusBar.Message, `"qa"`) || !strings.Contains(mdl.statusBar.Message, `"nope"`) {
+		t.Errorf("status = %q, want both problems reported", mdl.statusBar.Message)
+	}
+
+	updated, _ = mdl.Update(workspaceDataLoadedMsg{secrets: map[string]string{"API_KEY": "x"}, source: "web"})
+	mdl = updated.(model)
+	if mdl.activePopup != popupNone || !strings.Contains(mdl.statusBar.Message, "MISSING is not mapped in web") {
+		t.Errorf("popup = %d, status = %q", mdl.activePopup, mdl.statusBar.Message)
+	}
+}
+
+func TestStartupSelectionProtectedEnv(t *testing.T) {
+	dir := t.TempDir()
+	orig := stateDir
+	stateDir = func() string { return dir }
+	t.Cleanup(func() { stateDir = orig })
+
+	cfg := testConfig()
+	cfg.TUI.ProtectedEnvironments = []string{"production"}
+
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.startup = startupSelection{env: "production", secret: "SHARED_KEY"}
+
+	updated, _ := m.Update(configLoadedMsg{config: cfg, rootDir: t.TempDir()})
+	updated, _ = updated.(model).Update(workspaceDataLoadedMsg{secrets: cfg.Secrets, source: "web"})
+	if got := updated.(model).activePopup; got != popupSafety {
+		t.Errorf("popup = %d, want the protected-environment prompt", got)
+	}
+}
+
+func TestEditValue(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.env = "dev"
+	m.focus = focusSecrets
+	m.secrets.SetSecrets(map[string]string{"API_KEY": "${env}/api/key"}, "dev")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updated, _ = updated.(model).Update(secretResolvedMsg{envVar: "API_KEY", value: "k1"})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	mdl := updated.(model)
+	if !mdl.detailEditing || mdl.detailEditInput != "k1" {
+		t.Fatalf("editing %v with %q, want edit mode starting from the value", mdl.detailEditing, mdl.detailEditInput)
+	}
+
+	// Keys that act on the popup are typed into the value instead.
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("cR2")})
+	updated, cmd := updated.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if mdl.detailEditInput != "kcR2" || mdl.pendingWrites != 1 || cmd == nil {
+		t.Fatalf("input %q, pendingWrites %d; want a write of kcR2 dispatched", mdl.detailEditInput, mdl.pendingWrites)
+	}
+
+	updated, _ = mdl.Update(valueSavedMsg{envVar: "API_KEY", value: "kcR2"})
+	mdl = updated.(model)
+	if mdl.detailEditing || mdl.detailValue != "kcR2" || mdl.pendingWrites != 0 {
+		t.Errorf("after save: editing %v, value %q, pendingWrites %d", mdl.detailEditing, mdl.detailValue, mdl.pendingWrites)
+	}
+	if mdl.activePopup != popupDetail {
+		t.Error("saving should leave the detail popup open")
+	}
+}
+
+func TestEditValueEscAndErrors(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.env = "production"
+	m.secrets.SetSecrets(map[string]string{"API_KEY": "${env}/api/key", "OP": "cmd://op read x"}, "production")
+	m.activePopup = popupDetail
+	m.detailEnvVar = "API_KEY"
+	m.detailValue = "k1"
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyEsc})
+	mdl := updated.(model)
+	if mdl.detailEditing || mdl.activePopup != popupDetail {
+		t.Fatal("esc should leave edit mode but keep the popup open")
+	}
+
+	// Protected environments ask for the name before writing.
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'2'}})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if mdl.activePopup != popupSafety || mdl.safetyAction != safetyWriteValue {
+		t.Fatalf("activePopup = %v, want the protected-environment prompt", mdl.activePopup)
+	}
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("production")})
+	updated, cmd := updated.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if mdl.activePopup != popupDetail || mdl.pendingWrites != 1 || cmd == nil {
+		t.Fatalf("activePopup %v, pendingWrites %d; want the write dispatched", mdl.activePopup, mdl.pendingWrites)
+	}
+
+	updated, _ = mdl.Update(valueSaveErrorMsg{err: errors.New("check-and-set parameter did not match")})
+	mdl = updated.(model)
+	if !mdl.detailEditing || mdl.pendingWrites != 0 || !mdl.statusBar.IsError {
+		t.Error("a failed write should keep the edit open and report the error")
+	}
+
+	// Command mappings cannot be written back.
+	mdl.detailEditing = false
+	mdl.detailEnvVar = "OP"
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	if updated.(model).detailEditing {
+		t.Error("a command mapping should not be editable")
+	}
+}
+
+func TestDefaultsView(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(dir+"/web", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file := dir + "/web/vx.toml"
+	content := `[defaults]
+LOG_LEVEL = "info"
+
+[defaults.production]
+LOG_LEVEL = "warn"
+`
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := bridge.New("", "", "", "", "")
+	m := newModel(b)
+	m.config = testConfig()
+	m.rootDir = dir
+	m.env = "dev"
+	m.environments = m.config.Environments.Available
+	m.width, m.height = 120, 30
+	m.workspaces = components.NewWorkspaceList([]string{"web"}, false)
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("D")})
+	if cmd == nil {
+		t.Fatal("expected the defaults to be read")
+	}
+	updated, _ = updated.(model).Update(cmd())
+	mdl := updated.(model)
+	if !mdl.defaultsView || len(mdl.defaultsTable.Rows) != 2 {
+		t.Fatalf("defaults view = %v with %d rows, want 2", mdl.defaultsView, len(mdl.defaultsTable.Rows))
+	}
+	if view := mdl.View(); !strings.Contains(view, "defaults.production") || !strings.Contains(view, "warn") {
+		t.Errorf("view should list both tables:\n%s", view)
+	}
+
+	// Add a value to [defaults]; dev is not protected.
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("PORT")})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyTab})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("8080")})
+	updated, cmd = updated.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if cmd == nil || mdl.pendingWrites != 1 {
+		t.Fatalf("expected a write, pending = %d", mdl.pendingWrites)
+	}
+	updated, _ = mdl.Update(cmd())
+	mdl = updated.(model)
+	if mdl.pendingWrites != 0 || mdl.activePopup != popupNone || mdl.statusBar.Message != "Default saved" {
+		t.Errorf("after save: pending = %d, popup = %d, status = %q", mdl.pendingWrites, mdl.activePopup, mdl.statusBar.Message)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `PORT = "8080"`) {
+		t.Errorf("file should have the new default:\n%s", data)
+	}
+
+	// Deleting from [defaults.production] asks for the protected env's name.
+	mdl.defaultsTable.Select("production", "LOG_LEVEL")
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	updated, cmd = updated.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if cmd != nil || mdl.activePopup != popupSafety || mdl.safetyEnv != "production" {
+		t.Fatalf("popup = %d in %q, want the safety prompt for production", mdl.activePopup, mdl.safetyEnv)
+	}
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("dev")})
+	updated, cmd = updated.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil || updated.(model).pendingWrites != 0 {
+		t.Error("typing another environment's name should not confirm")
+	}
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("production")})
+	updated, cmd = updated.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil || updated.(model).pendingWrites != 1 {
+		t.Fatal("typing the protected env's name should delete the default")
+	}
+	updated, _ = updated.(model).Update(cmd())
+	if data, _ := os.ReadFile(file); strings.Contains(string(data), "warn") {
+		t.Errorf("production default should be deleted:\n%s", data)
+	}
+
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(model).defaultsView {
+		t.Error("esc should close the defaults view")
+	}
+}
+
+func TestLoginPopup(t *testing.T) {
+	orig := token.DefaultDir
+	dir := t.TempDir()
+	token.DefaultDir = func() string { return dir }
+	t.Cleanup(func() { token.DefaultDir = orig })
+
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.config.Vault.AuthMethod = "approle"
+	m.env = "dev"
+	m.focus = focusSecrets
+	m.secrets.SetSecrets(map[string]string{"API_KEY": "${env}/api/key"}, "dev")
+
+	// Without a cached token, resolving asks for a login.
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	var required *authRequiredMsg
+	for _, c := range cmd().(tea.BatchMsg) {
+		if msg, ok := c().(authRequiredMsg); ok {
+			required = &msg
+		}
+	}
+	if required == nil || required.retry != authRetryDetail {
+		t.Fatalf("resolving without a token sent %v, want authRequiredMsg for the detail", required)
+	}
+
+	updated, _ = updated.(model).Update(*required)
+	mdl := updated.(model)
+	if mdl.activePopup != popupAuth || mdl.authMethod != authAppRole {
+		t.Fatalf("popup %v, method %d; want the login popup on AppRole", mdl.activePopup, mdl.authMethod)
+	}
+
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if mdl = updated.(model); mdl.authError == "" || mdl.authBusy {
+		t.Error("logging in without credentials should be refused")
+	}
+
+	for _, k := range []tea.KeyMsg{
+		{Type: tea.KeyTab},
+		{Type: tea.KeyRunes, Runes: []rune("role")},
+		{Type: tea.KeyTab},
+		{Type: tea.KeyRunes, Runes: []rune("s3cret")},
+	} {
+		updated, _ = updated.(model).Update(k)
+	}
+	mdl = updated.(model)
+	if view := mdl.renderAuthPopup(); strings.Contains(view, "s3cret") || !strings.Contains(view, "******") {
+		t.Errorf("the secret ID should be masked:\n%s", view)
+	}
+
+	updated, cmd = mdl.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if !mdl.authBusy || cmd == nil || mdl.authSecretID != "" {
+		t.Fatalf("busy %v, secret ID %q; want a login dispatched and the secret ID dropped", mdl.authBusy, mdl.authSecretID)
+	}
+
+	updated, _ = mdl.Update(authFailedMsg{err: errors.New("invalid secret ID"), login: true})
+	mdl = updated.(model)
+	if mdl.activePopup != popupAuth || mdl.authBusy || !strings.Contains(mdl.authError, "invalid secret ID") {
+		t.Errorf("a failed login should stay in the popup with its error, got %q", mdl.authError)
+	}
+
+	client, err := vault.NewClientWithToken("https://vault.example.com", "secret", "s.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, cmd = mdl.Update(authSucceededMsg{client: client})
+	mdl = updated.(model)
+	if mdl.vaultClient != client || mdl.activePopup != popupDetail || !mdl.detailLoading || cmd == nil {
+		t.Errorf("popup %v, loading %v; want the detail popup resolving again", mdl.activePopup, mdl.detailLoading)
+	}
+}
+
+func TestLoginPopupEsc(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")})
+	mdl := updated.(model)
+	if mdl.activePopup != popupAuth || mdl.authMethod != authOIDC {
+		t.Fatalf("L should open the login popup on OIDC, got %v", mdl.activePopup)
+	}
+
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if mdl = updated.(model); mdl.authMethod != authAppRole {
+		t.Error("typing on the method field should switch it")
+	}
+
+	mdl.authRetry = authRetryEffective
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	mdl = updated.(model)
+	if mdl.activePopup != popupNone || mdl.authRetry != authRetryNone {
+		t.Error("esc should close the popup and forget the operation to retry")
+	}
+
+	// A login finishing after esc keeps the client without reopening anything.
+	client, _ := vault.NewClientWithToken("https://vault.example.com", "secret", "s.test")
+	updated, _ = mdl.Update(authSucceededMsg{client: client})
+	if mdl = updated.(model); mdl.vaultClient != client || mdl.activePopup != popupNone {
+		t.Errorf("popup %v after a late login", mdl.activePopup)
+	}
+}
+
+func TestDetailHistory(t *testing.T) {
+	m := newModel(bridge.New("", "", "", "", ""))
+	m.config = testConfig()
+	m.env = "dev"
+	m.width = 100
+	m.focus = focusSecrets
+	m.secrets.SetSecrets(map[string]string{"API_KEY": "${env}/api/key"}, "dev")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updated, _ = updated.(model).Update(secretResolvedMsg{envVar: "API_KEY", value: "new"})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	if mdl := updated.(model); mdl.detailHistory || !mdl.statusBar.IsError {
+		t.Error("h without metadata should report that there is no history")
+	}
+
+	now := time.Now()
+	updated, _ = updated.(model).Update(secretMetadataMsg{envVar: "API_KEY", meta: &vault.KVMetadata{
+		CurrentVersion: 3,
+		Versions: []vault.KVVersion{
+			{Version: 3, CreatedTime: now.Add(-time.Hour)},
+			{Version: 2, CreatedTime: now.Add(-48 * time.Hour)},
+			{Version: 1, CreatedTime: now.Add(-96 * time.Hour), DeletionTime: now.Add(-72 * time.Hour), Destroyed: true},
+		},
+	}})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	mdl := updated.(model)
+	if !mdl.detailHistory || mdl.historyCursor != 0 {
+		t.Fatalf("history %v, cursor %d; want the history on the latest version", mdl.detailHistory, mdl.historyCursor)
+	}
+	if view := mdl.renderDetailPopup(); !strings.Contains(view, "destroyed") || !strings.Contains(view, "latest") {
+		t.Errorf("history should mark the latest and destroyed versions:\n%s", view)
+	}
+
+	// A destroyed version cannot be read.
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	updated, cmd := updated.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if mdl = updated.(model); cmd == nil || !mdl.detailHistory || !mdl.statusBar.IsError {
+		t.Errorf("cursor %d: picking a destroyed version should be refused", mdl.historyCursor)
+	}
+
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")})
+	updated, cmd = updated.(model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	mdl = updated.(model)
+	if cmd == nil || mdl.detailHistory || !mdl.detailLoading {
+		t.Fatal("enter on version 2 should start reading it")
+	}
+
+	updated, _ = mdl.Update(secretVersionResolvedMsg{envVar: "API_KEY", version: 2, value: "old"})
+	mdl = updated.(model)
+	if mdl.detailValue != "old" || mdl.detailVersion != 2 || mdl.activePopup != popupDetail {
+		t.Errorf("value %q of version %d, want version 2's", mdl.detailValue, mdl.detailVersion)
+	}
+	if view := mdl.renderDetailPopup(); !strings.Contains(view, "version 2 (latest is 3)") {
+		t.Errorf("the popup should say which version is shown:\n%s", view)
+	}
+	if cached, ok := mdl.cachedValueFor("API_KEY", mdl.detailPath, time.Now()); !ok || cached.value != "new" {
+		t.Error("an earlier version should not replace the cached latest value")
+	}
+
+	// Esc leaves the history without closing the popup.
+	updated, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if mdl = updated.(model); mdl.detailHistory || mdl.activePopup != popupDetail {
+		t.Error("esc should go back from the history to the value")
+	}
+}
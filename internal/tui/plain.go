@@ -0,0 +1,212 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"go.dot.industries/vx/internal/tui/components"
+)
+
+// usePlainStyles drops colors and box drawing from every style, for plain
+// mode. It changes package-level styles, so it is applied once before the
+// program starts.
+func usePlainStyles() {
+	lipgloss.SetColorProfile(termenv.Ascii)
+	styleBorder = lipgloss.NewStyle()
+	stylePopup = lipgloss.NewStyle()
+}
+
+// plainView renders the TUI as plain lines for screen readers and terminals
+// where the alternate screen gets in the way: no borders or colors, one item
+// per line with ">" marking the selection, and an open popup shown on its
+// own. The keybindings are the same as in the full view.
+func (m model) plainView() string {
+	if m.fatalError != "" {
+		return "Error: " + m.fatalError + "\n\nPress q to quit.\n"
+	}
+	if m.config == nil {
+		return "Loading configuration...\n"
+	}
+	if m.activePopup != popupNone {
+		return m.popupView() + "\n"
+	}
+
+	var b strings.Builder
+
+	b.WriteString("Environment: " + m.env)
+	if isProtectedEnv(m.config, m.env) {
+		b.WriteString(" (protected)")
+	}
+	if n := len(m.uncommitted); n > 0 {
+		fmt.Fprintf(&b, ", %d vx.toml file(s) with uncommitted changes (s to list)", n)
+	}
+	b.WriteString("\n")
+
+	// Leave room for the environment, status, and key lines, and the two
+	// list headings.
+	room := max(m.height-5, 4)
+
+	if m.defaultsView {
+		m.plainDefaults(&b, room)
+	} else if m.effective {
+		m.plainEffective(&b, room)
+	} else if m.comparing {
+		m.plainCompare(&b, room)
+	} else {
+		m.plainLists(&b, room)
+	}
+
+	switch {
+	case m.filtering:
+		b.WriteString("Filter: " + m.filterText + "_ (enter to apply, esc to stop)\n")
+	case m.statusBar.Message != "" && m.statusBar.IsError:
+		b.WriteString("Error: " + m.statusBar.Message + "\n")
+	case m.statusBar.Message != "":
+		b.WriteString("Status: " + m.statusBar.Message + "\n")
+	default:
+		b.WriteString("\n")
+	}
+
+	if m.defaultsView {
+		b.WriteString("Keys: j/k move, a add, r edit, d delete, esc close, ? help, q quit\n")
+	} else if m.effective {
+		b.WriteString("Keys: j/k move, enter reveal or mask, esc close, ? help, q quit\n")
+	} else if m.comparing {
+		b.WriteString("Keys: j/k move, esc close comparison, ? help, q quit\n")
+	} else {
+		b.WriteString("Keys: j/k move, tab switch list, enter view, / filter, e environment, ? help, q quit\n")
+	}
+
+	return b.String()
+}
+
+// plainLists writes the workspace list and the secret list, each cut to a
+// window around its cursor so the whole view fits in room lines.
+func (m model) plainLists(b *strings.Builder, room int) {
+	workspaces := m.workspaces.Names()
+	wsRoom := min(len(workspaces), max(room/3, 3))
+
+	heading := "Workspaces"
+	if m.focus == focusWorkspaces {
+		heading += " (focused)"
+	}
+	fmt.Fprintf(b, "%s: %d\n", heading, len(workspaces))
+	start, end := plainWindow(len(workspaces), m.workspaces.Cursor, wsRoom)
+	for i := start; i < end; i++ {
+		b.WriteString(plainItem(i == m.workspaces.Cursor, workspaces[i]))
+	}
+
+	heading = "Secrets in " + m.workspaces.Selected()
+	if m.focus == focusSecrets {
+		heading += " (focused)"
+	}
+	if m.filterText != "" {
+		fmt.Fprintf(b, "%s: %d of %d matching %q\n", heading, m.secrets.Len(), m.secrets.TotalLen(), m.filterText)
+	} else {
+		fmt.Fprintf(b, "%s: %d\n", heading, m.secrets.Len())
+	}
+	start, end = plainWindow(m.secrets.Len(), m.secrets.Cursor, room-wsRoom)
+	ages := m.rowFreshness(time.Now())
+	for i := start; i < end; i++ {
+		row := m.secrets.Rows[i]
+		item := row.EnvVar + " from " + row.VaultPath
+		if age := ages[row.EnvVar]; age != "" {
+			item += ", cached " + age
+		}
+		b.WriteString(plainItem(i == m.secrets.Cursor, item))
+	}
+}
+
+// plainCompare writes the workspace comparison, one key per line.
+func (m model) plainCompare(b *strings.Builder, room int) {
+	ct := m.compare
+	fmt.Fprintf(b, "Comparing %s with %s: %d differ, %d only in %s, %d only in %s\n",
+		ct.LeftName, ct.RightName,
+		ct.Count(components.CompareDiffers),
+		ct.Count(components.CompareLeftOnly), ct.LeftName,
+		ct.Count(components.CompareRightOnly), ct.RightName)
+
+	start, end := plainWindow(len(ct.Rows), ct.Cursor, room)
+	for i := start; i < end; i++ {
+		row := ct.Rows[i]
+		var line string
+		switch row.Kind {
+		case components.CompareSame:
+			line = row.EnvVar + ": same, " + row.Left
+		case components.CompareDiffers:
+			line = row.EnvVar + ": differs, " + ct.LeftName + " " + row.Left + ", " + ct.RightName + " " + row.Right
+		case components.CompareLeftOnly:
+			line = row.EnvVar + ": only in " + ct.LeftName + ", " + row.Left
+		case components.CompareRightOnly:
+			line = row.EnvVar + ": only in " + ct.RightName + ", " + row.Right
+		}
+		b.WriteString(plainItem(i == ct.Cursor, line))
+	}
+}
+
+// plainEffective writes the effective environment, one variable per line
+// with its value, source, and anything overridden.
+func (m model) plainEffective(b *strings.Builder, room int) {
+	et := m.effectiveTable
+	secrets, errors := et.Count()
+	fmt.Fprintf(b, "Effective environment of %s: %d variables, %d secrets, %d failed\n",
+		et.Title, len(et.Rows), secrets, errors)
+
+	start, end := plainWindow(len(et.Rows), et.Cursor, room)
+	for i := start; i < end; i++ {
+		row := et.Rows[i]
+		line := row.Name + " = " + row.Display() + " from " + row.Source
+		if len(row.Overrides) > 0 {
+			line += ", overrides " + strings.Join(row.Overrides, ", ")
+		}
+		switch {
+		case row.Err != "":
+			line += ", vx exec would fail: " + row.Err
+		case row.Note != "":
+			line += ", " + row.Note
+		}
+		b.WriteString(plainItem(i == et.Cursor, line))
+	}
+}
+
+// plainDefaults writes the [defaults] tables, one value per line, marking
+// the values in effect in the selected environment.
+func (m model) plainDefaults(b *strings.Builder, room int) {
+	dt := m.defaultsTable
+	fmt.Fprintf(b, "Defaults of %s: %d value(s)\n", dt.Title, len(dt.Rows))
+
+	start, end := plainWindow(len(dt.Rows), dt.Cursor, room)
+	for i := start; i < end; i++ {
+		row := dt.Rows[i]
+		line := "[" + row.Table() + "] " + row.Key + " = " + row.Value
+		if dt.InEffect(row) {
+			line += ", in effect in " + m.env
+		}
+		b.WriteString(plainItem(i == dt.Cursor, line))
+	}
+}
+
+// plainItem formats one list line, marking the selected one with ">".
+func plainItem(selected bool, text string) string {
+	if selected {
+		return "> " + text + "\n"
+	}
+	return "  " + text + "\n"
+}
+
+// plainWindow returns the range of n items to show in size lines, keeping
+// cursor in view.
+func plainWindow(n, cursor, size int) (int, int) {
+	if size <= 0 {
+		return 0, 0
+	}
+	if n <= size {
+		return 0, n
+	}
+	start := min(max(cursor-size/2, 0), n-size)
+	return start, start + size
+}
@@ -2,7 +2,14 @@ package tui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"go.dot.industries/vx/internal/tui/components"
+	"go.dot.industries/vx/internal/vault"
 )
 
 // renderHelpPopup returns the help overlay content.
@@ -14,12 +21,24 @@ func (m model) renderHelpPopup() string {
 		{"/", "Enter filter mode (type to filter secrets)"},
 		{"Enter", "View secret detail (resolves from Vault)"},
 		{"c", "Copy resolved secret value to clipboard"},
+		{"f", "Write value to a temp file and copy its path"},
+		{"R", "Resolve the shown value again, bypassing the cache"},
+		{"v", "Edit the shown value and write it to Vault"},
+		{"h", "Show the secret's versions and read an earlier one"},
 		{"a", "Add new secret mapping"},
 		{"r", "Edit selected mapping"},
 		{"d", "Delete selected mapping (with confirmation)"},
+		{"y", "Duplicate selected mapping into another vx.toml"},
+		{"o", "Open the workspace's vx.toml in $EDITOR"},
+		{"x", "Compare the workspace with another side by side"},
+		{"E", "Show the environment vx exec would inject"},
+		{"D", "Show and edit the workspace's [defaults] tables"},
+		{"s", "List vx.toml files with uncommitted git changes"},
+		{"L", "Log in to Vault with OIDC or AppRole"},
+		{"", "Protected envs ask you to type their name first"},
 		{"?", "Toggle this help"},
 		{"Esc", "Close popup / exit filter mode"},
-		{"q / Ctrl+C", "Quit"},
+		{"q / Ctrl+C", "Quit (asks first while a save is in flight)"},
 	}
 
 	var b strings.Builder
@@ -63,6 +82,57 @@ func (m model) renderEnvPickerPopup() string {
 		)
 }
 
+// renderComparePickerPopup returns the overlay for choosing the workspace to
+// compare the selected one against.
+func (m model) renderComparePickerPopup() string {
+	var b strings.Builder
+	for i, name := range m.compareChoices() {
+		prefix := "  "
+		style := styleNormal
+		if i == m.comparePickerCursor {
+			prefix = "> "
+			style = styleSelected
+		}
+		b.WriteString(style.Render(prefix+name) + "\n")
+	}
+
+	return stylePopup.
+		Width(40).
+		Render(
+			styleTitle.Render("Compare "+m.workspaces.Selected()+" With") + "\n\n" +
+				b.String() + "\n" +
+				styleMuted.Render("j/k:nav  enter:select  esc:close"),
+		)
+}
+
+// renderDuplicatePickerPopup returns the overlay for choosing the file to
+// duplicate the selected mapping into.
+func (m model) renderDuplicatePickerPopup() string {
+	title := "Duplicate Mapping To"
+	if selected := m.secrets.Selected(); selected != nil {
+		title = "Duplicate " + selected.EnvVar + " To"
+	}
+
+	var b strings.Builder
+	for i, t := range m.duplicateTargets() {
+		prefix := "  "
+		style := styleNormal
+		if i == m.duplicatePickerCursor {
+			prefix = "> "
+			style = styleSelected
+		}
+		b.WriteString(style.Render(prefix+t.Label) + "\n")
+	}
+
+	return stylePopup.
+		Width(40).
+		Render(
+			styleTitle.Render(title) + "\n\n" +
+				b.String() + "\n" +
+				styleMuted.Render("j/k:nav  enter:select  esc:close"),
+		)
+}
+
 // renderDetailPopup returns the secret detail overlay.
 func (m model) renderDetailPopup() string {
 	var content string
@@ -80,19 +150,139 @@ func (m model) renderDetailPopup() string {
 	envVar := styleKey.Render(m.detailEnvVar)
 	path := styleDim.Render(m.detailPath)
 
-	footer := styleMuted.Render("c:copy  esc:close")
+	footer := styleMuted.Render("c:copy  f:temp file  R:resolve again  v:edit  h:history  esc:close")
+	if m.detailEditing {
+		content = styleSelected.Render(m.detailEditInput + "_")
+		footer = styleMuted.Render("enter:save to Vault  esc:cancel edit")
+	}
+
+	label := "Value:"
+	if m.detailVersion != 0 && !m.detailLoading {
+		label = fmt.Sprintf("Value of version %d:", m.detailVersion)
+		if m.detailMeta != nil {
+			label = fmt.Sprintf("Value of version %d (latest is %d):", m.detailVersion, m.detailMeta.CurrentVersion)
+		}
+	}
+	if m.detailHistory {
+		label = "History:"
+		content = m.renderHistory(time.Now())
+		footer = styleMuted.Render("j/k:nav  enter:show version  h/esc:back")
+	}
+
+	fetched := ""
+	if m.detailValue != "" && !m.detailLoading {
+		fetched = "Fetched:  " + styleDim.Render(m.detailFreshness(time.Now())) + "\n"
+	}
 
 	return stylePopup.
 		Width(min(m.width-10, 70)).
 		Render(
 			styleTitle.Render("Secret Detail") + "\n\n" +
 				"Env var:  " + envVar + "\n" +
-				"Path:     " + path + "\n\n" +
-				"Value:\n" + content + "\n\n" +
+				"Path:     " + path + "\n" +
+				m.renderDetailMetadata(time.Now()) +
+				fetched + "\n" +
+				label + "\n" + content + "\n\n" +
 				footer,
 		)
 }
 
+// renderHistory lists the versions of the secret in the detail popup, newest
+// first, marking the latest and those whose value is gone.
+func (m model) renderHistory(now time.Time) string {
+	var b strings.Builder
+	for i, v := range m.detailMeta.Versions {
+		prefix := "  "
+		style := styleNormal
+		if i == m.historyCursor {
+			prefix = "> "
+			style = styleSelected
+		}
+
+		line := fmt.Sprintf("%sv%-4d", prefix, v.Version)
+		if !v.CreatedTime.IsZero() {
+			line += " " + formatTimestamp(v.CreatedTime, now)
+		}
+
+		var note string
+		switch {
+		case v.Destroyed:
+			note = styleErrorText.Render("  destroyed")
+		case v.Deleted(now):
+			note = styleWarningText.Render("  deleted")
+		case v.Version == m.detailMeta.CurrentVersion:
+			note = styleDim.Render("  latest")
+		}
+		if v.Version == m.shownVersion() {
+			note += styleDim.Render("  (shown)")
+		}
+		b.WriteString(style.Render(line) + note + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// renderDetailMetadata returns the version, timestamps, and custom metadata
+// lines of the detail popup. Metadata is informational, so failures to read
+// it are shown dimmed rather than as errors.
+func (m model) renderDetailMetadata(now time.Time) string {
+	switch {
+	case m.detailMeta != nil:
+		return formatMetadata(m.detailMeta, now)
+	case m.detailMetaErr != "":
+		return styleDim.Render("Metadata: unavailable ("+m.detailMetaErr+")") + "\n"
+	default:
+		return styleMuted.Render("Metadata: loading...") + "\n"
+	}
+}
+
+// formatMetadata renders KV metadata as aligned "Label: value" lines.
+func formatMetadata(meta *vault.KVMetadata, now time.Time) string {
+	var b strings.Builder
+
+	version := fmt.Sprintf("v%d", meta.CurrentVersion)
+	if !meta.UpdatedTime.IsZero() {
+		version += styleDim.Render(fmt.Sprintf(" (updated %s)", formatTimestamp(meta.UpdatedTime, now)))
+	}
+	b.WriteString("Version:  " + styleNormal.Render(version) + "\n")
+
+	if !meta.CreatedTime.IsZero() {
+		b.WriteString("Created:  " + styleDim.Render(formatTimestamp(meta.CreatedTime, now)) + "\n")
+	}
+
+	keys := make([]string, 0, len(meta.CustomMetadata))
+	for k := range meta.CustomMetadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString(styleDim.Render(fmt.Sprintf("%-9s ", k+":")) + styleNormal.Render(meta.CustomMetadata[k]) + "\n")
+	}
+
+	return b.String()
+}
+
+// formatTimestamp renders t as a local date-time with its age, e.g.
+// "2024-05-01 10:00, 3d ago".
+func formatTimestamp(t, now time.Time) string {
+	return fmt.Sprintf("%s, %s", t.Local().Format("2006-01-02 15:04"), formatAge(now.Sub(t)))
+}
+
+// formatAge renders a duration as a short, coarse age such as "5m ago".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 60*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	default:
+		return fmt.Sprintf("%dmo ago", int(d.Hours()/(24*30)))
+	}
+}
+
 // renderVaultBrowserPopup returns the Vault tree browser overlay.
 func (m model) renderVaultBrowserPopup() string {
 	var b strings.Builder
@@ -130,17 +320,61 @@ func (m model) renderVaultBrowserPopup() string {
 		}
 	}
 
-	title := fmt.Sprintf("Browse Vault: %s", m.vaultBrowserPath)
+	width := min(m.width-10, 55)
+	base := ""
+	if m.config != nil {
+		base = m.config.Vault.BasePath
+	}
 
 	return stylePopup.
-		Width(min(m.width-10, 55)).
+		Width(width).
 		Render(
-			styleTitle.Render(title) + "\n\n" +
+			styleTitle.Render("Browse Vault") + "\n" +
+				breadcrumbs(base, m.vaultBrowserPath, width-4) + "\n\n" +
 				b.String() + "\n" +
-				styleMuted.Render("j/k:nav  enter:open  backspace:up  esc:close"),
+				styleMuted.Render("j/k:nav  enter:open  u/bksp:up  ~/g:base  esc:close"),
 		)
 }
 
+// breadcrumbs renders the browser location as "base › dev › database", with
+// the current directory highlighted. When the trail is wider than maxWidth,
+// leading segments are replaced with "…" so the current one stays visible.
+func breadcrumbs(base, path string, maxWidth int) string {
+	if base == "" {
+		base = "/"
+	}
+	crumbs := []string{base}
+	for _, seg := range strings.Split(strings.Trim(path, "/"), "/") {
+		if seg != "" {
+			crumbs = append(crumbs, seg)
+		}
+	}
+
+	const sep = " › "
+	width := func(cs []string) int {
+		return lipgloss.Width(strings.Join(cs, sep))
+	}
+
+	trimmed := false
+	for len(crumbs) > 1 && width(crumbs)+lipgloss.Width("…"+sep) > maxWidth {
+		crumbs = crumbs[1:]
+		trimmed = true
+	}
+
+	parts := make([]string, 0, len(crumbs)+1)
+	if trimmed {
+		parts = append(parts, styleMuted.Render("…"))
+	}
+	for i, c := range crumbs {
+		if i == len(crumbs)-1 {
+			parts = append(parts, styleSelected.Render(c))
+		} else {
+			parts = append(parts, styleMuted.Render(c))
+		}
+	}
+	return strings.Join(parts, styleMuted.Render(sep))
+}
+
 // renderMappingFormPopup returns the add/edit mapping form overlay.
 func (m model) renderMappingFormPopup() string {
 	title := "New Secret Mapping"
@@ -208,3 +442,230 @@ func (m model) renderConfirmPopup() string {
 				styleMuted.Render("j/k:nav  enter:confirm  esc:cancel"),
 		)
 }
+
+// renderDefaultFormPopup returns the add/edit default form overlay.
+func (m model) renderDefaultFormPopup() string {
+	title := "New Default"
+	if m.defaultFormIsEdit {
+		title = "Edit Default"
+	}
+
+	fields := []struct {
+		label string
+		value string
+	}{
+		{"Table", "[" + components.DefaultRow{Env: m.defaultFormEnv()}.Table() + "]"},
+		{"Key", m.defaultFormKey},
+		{"Value", m.defaultFormValue},
+	}
+
+	var b strings.Builder
+	for i, f := range fields {
+		label := styleDim.Render(fmt.Sprintf("  %-8s", f.label+":"))
+		val := styleNormal.Render(f.value)
+		if i == m.defaultFormField {
+			label = styleKey.Render(fmt.Sprintf("> %-8s", f.label+":"))
+			if i == 0 {
+				val = styleSelected.Render("< " + f.value + " >")
+			} else {
+				val = styleSelected.Render(f.value + "_")
+			}
+		}
+		b.WriteString(label + " " + val + "\n")
+	}
+
+	help := "tab:next field  enter:save  esc:cancel"
+	if m.defaultFormIsEdit {
+		help = "enter:save  esc:cancel"
+	}
+	return stylePopup.
+		Width(min(m.width-10, 55)).
+		Render(
+			styleTitle.Render(title) + "\n\n" +
+				b.String() + "\n" +
+				styleMuted.Render(help),
+		)
+}
+
+// renderDefaultConfirmPopup returns the confirmation overlay for deleting
+// the selected default.
+func (m model) renderDefaultConfirmPopup() string {
+	row := m.defaultsTable.Selected()
+	if row == nil {
+		return ""
+	}
+
+	choices := []string{"Cancel", "Delete"}
+	var b strings.Builder
+	for i, c := range choices {
+		prefix := "  "
+		style := styleNormal
+		if i == m.confirmCursor {
+			prefix = "> "
+			style = styleSelected
+		}
+		b.WriteString(style.Render(prefix+c) + "\n")
+	}
+
+	return stylePopup.
+		Width(min(m.width-10, 50)).
+		Render(
+			styleTitle.Render("Confirm Delete") + "\n\n" +
+				styleNormal.Render(fmt.Sprintf("Delete %s from [%s] of %s?",
+					styleKey.Render(row.Key), row.Table(),
+					m.displayPath(m.defaultsFile))) + "\n\n" +
+				b.String() + "\n" +
+				styleMuted.Render("j/k:nav  enter:confirm  esc:cancel"),
+		)
+}
+
+// renderAuthPopup returns the login overlay: the method, then for AppRole the
+// role ID and the masked secret ID.
+func (m model) renderAuthPopup() string {
+	method := "OIDC (browser)"
+	if m.authMethod == authAppRole {
+		method = "AppRole"
+	}
+
+	fields := []struct {
+		label string
+		value string
+	}{
+		{"Method", method},
+	}
+	if m.authMethod == authAppRole {
+		fields = append(fields,
+			struct{ label, value string }{"Role ID", m.authRoleID},
+			struct{ label, value string }{"Secret ID", strings.Repeat("*", len([]rune(m.authSecretID)))},
+		)
+	}
+
+	var b strings.Builder
+	for i, f := range fields {
+		label := styleDim.Render(fmt.Sprintf("  %-11s", f.label+":"))
+		val := styleNormal.Render(f.value)
+		if i == m.authField && !m.authBusy {
+			label = styleKey.Render(fmt.Sprintf("> %-11s", f.label+":"))
+			if i == 0 {
+				val = styleSelected.Render("< " + f.value + " >")
+			} else {
+				val = styleSelected.Render(f.value + "_")
+			}
+		}
+		b.WriteString(label + " " + val + "\n")
+	}
+
+	status := styleMuted.Render("Vault needs a token for this. Log in to continue.")
+	switch {
+	case m.authBusy && m.authMethod == authOIDC:
+		status = styleMuted.Render("Waiting for the browser login (2 minutes at most)...")
+	case m.authBusy:
+		status = styleMuted.Render("Logging in...")
+	case m.authError != "":
+		status = styleErrorText.Render(m.authError)
+	}
+
+	help := "enter:log in  esc:cancel"
+	if m.authMethod == authAppRole {
+		help = "tab:next field  " + help
+	}
+	return stylePopup.
+		Width(min(m.width-10, 60)).
+		Render(
+			styleTitle.Render("Log In to Vault") + "\n\n" +
+				b.String() + "\n" +
+				status + "\n\n" +
+				styleMuted.Render(help),
+		)
+}
+
+// renderSafetyPopup returns the protected-environment confirmation overlay.
+func (m model) renderSafetyPopup() string {
+	action := "reveal a secret value"
+	switch m.safetyAction {
+	case safetySave:
+		action = "write this mapping"
+	case safetyDelete:
+		action = "delete this mapping"
+	case safetyWriteValue:
+		action = "write a secret value"
+	case safetySaveDefault:
+		action = "write this default"
+	case safetyDeleteDefault:
+		action = "delete this default"
+	}
+
+	accent := accentFor(m.config, m.safetyEnv)
+	title := styleTitle.Foreground(accent).Render("Protected Environment")
+
+	return stylePopup.
+		BorderForeground(accent).
+		Width(min(m.width-10, 55)).
+		Render(
+			title + "\n\n" +
+				styleNormal.Render(fmt.Sprintf("You are about to %s in %s.", action,
+					styleKey.Render(m.safetyEnv))) + "\n" +
+				styleNormal.Render("Type the environment name to continue:") + "\n\n" +
+				styleSelected.Foreground(accent).Render("> "+m.safetyInput+"_") + "\n\n" +
+				styleMuted.Render("enter:confirm  esc:cancel"),
+		)
+}
+
+// renderQuitPopup returns the prompt shown when quitting while mapping writes
+// are still in flight.
+func (m model) renderQuitPopup() string {
+	pending := fmt.Sprintf("%d pending write(s)", m.pendingWrites)
+
+	if m.quitWhenIdle {
+		return stylePopup.
+			Width(min(m.width-10, 50)).
+			Render(
+				styleTitle.Render("Quitting") + "\n\n" +
+					styleMuted.Render("Waiting for "+pending+" to finish...") + "\n\n" +
+					styleMuted.Render("esc:cancel  ctrl+c:quit now"),
+			)
+	}
+
+	choices := []string{"Wait for them, then quit", "Keep working"}
+	var b strings.Builder
+	for i, c := range choices {
+		prefix := "  "
+		style := styleNormal
+		if i == m.quitCursor {
+			prefix = "> "
+			style = styleSelected
+		}
+		b.WriteString(style.Render(prefix+c) + "\n")
+	}
+
+	return stylePopup.
+		Width(min(m.width-10, 50)).
+		Render(
+			styleTitle.Render("Quit vx?") + "\n\n" +
+				styleNormal.Render("There are "+pending+" to vx.toml.") + "\n" +
+				styleNormal.Render("Quitting now may interrupt them.") + "\n\n" +
+				b.String() + "\n" +
+				styleMuted.Render("j/k:nav  enter:select  esc:cancel  ctrl+c:quit now"),
+		)
+}
+
+// renderUncommittedPopup returns the overlay listing the vx.toml files with
+// changes git has not committed.
+func (m model) renderUncommittedPopup() string {
+	var b strings.Builder
+	if len(m.uncommitted) == 0 {
+		b.WriteString(styleMuted.Render("Every vx.toml is committed.") + "\n")
+	}
+	for _, f := range m.uncommitted {
+		b.WriteString(styleWarningText.Render(fmt.Sprintf("%-10s", f.State)) + " " +
+			styleNormal.Render(m.displayPath(f.Path)) + "\n")
+	}
+
+	return stylePopup.
+		Width(min(m.width-10, 60)).
+		Render(
+			styleTitle.Render("Uncommitted Changes") + "\n\n" +
+				b.String() + "\n" +
+				styleMuted.Render("Commit them with git.  esc:close"),
+		)
+}
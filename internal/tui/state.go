@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	toml "github.com/pelletier/go-toml/v2"
+
+	"go.dot.industries/vx/internal/token"
+)
+
+// savedState is what the TUI remembers about a repository between runs.
+type savedState struct {
+	Workspace   string `toml:"workspace"`
+	Environment string `toml:"environment"`
+	Filter      string `toml:"filter"`
+}
+
+// stateDir returns the directory holding per-repository TUI state
+// (~/.vx/state). It is a variable so tests can redirect it.
+var stateDir = func() string {
+	return filepath.Join(token.DefaultDir(), "state")
+}
+
+// statePath returns the state file for the repository rooted at rootDir. The
+// name is a hash of the absolute root path, so state is per checkout.
+func statePath(rootDir string) string {
+	abs, err := filepath.Abs(rootDir)
+	if err != nil {
+		abs = rootDir
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(stateDir(), hex.EncodeToString(sum[:8])+".toml")
+}
+
+// loadState reads the saved state for rootDir. A missing or unreadable file
+// yields the zero state: losing it only costs the user a few keystrokes.
+func loadState(rootDir string) savedState {
+	var s savedState
+
+	data, err := os.ReadFile(statePath(rootDir))
+	if err != nil {
+		return s
+	}
+	if err := toml.Unmarshal(data, &s); err != nil {
+		return savedState{}
+	}
+	return s
+}
+
+// saveState writes s as the saved state for rootDir.
+func saveState(rootDir string, s savedState) error {
+	path := statePath(rootDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+
+	data, err := toml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("writing state: %w", err)
+	}
+	return nil
+}
+
+// currentState captures the selection worth restoring next time.
+func (m model) currentState() savedState {
+	return savedState{
+		Workspace:   m.workspaces.Selected(),
+		Environment: m.env,
+		Filter:      m.filterText,
+	}
+}
+
+// restoreState applies saved state on top of the freshly loaded config.
+// Entries that no longer exist (a removed workspace or environment) are
+// ignored.
+func (m model) restoreState(s savedState) model {
+	if s.Environment != "" && slices.Contains(m.environments, s.Environment) {
+		m.env = s.Environment
+	}
+	if s.Workspace != "" {
+		m.workspaces.Select(s.Workspace)
+	}
+	if s.Filter != "" {
+		m.filterText = s.Filter
+		m.secrets.ApplyFilter(s.Filter)
+	}
+	return m
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultTempFileLifetime is how long a value written to a temp file is kept
+// when clipboard.file_delete_after is unset.
+const defaultTempFileLifetime = 5 * time.Minute
+
+// writeTempFile writes value to a new file only the current user can read
+// and returns its path. It is a variable so tests can redirect it.
+var writeTempFile = func(envVar, value string) (string, error) {
+	f, err := os.CreateTemp("", "vx-"+envVar+"-*")
+	if err != nil {
+		return "", err
+	}
+	// CreateTemp already uses 0600; be explicit in case that ever changes.
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if _, err := f.WriteString(value); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// tempFileLifetime returns how long a written temp file is kept.
+func (m model) tempFileLifetime() time.Duration {
+	if m.config == nil || m.config.Clipboard.FileDeleteAfter <= 0 {
+		return defaultTempFileLifetime
+	}
+	return time.Duration(m.config.Clipboard.FileDeleteAfter)
+}
+
+// handleWriteTempFile writes the resolved value to a temp file, for tools
+// that only accept a path (a kubeconfig, a PEM key), and copies the path to
+// the clipboard. The file is deleted once its lifetime elapses or the TUI
+// quits, whichever comes first.
+func (m model) handleWriteTempFile() (tea.Model, tea.Cmd) {
+	if m.activePopup != popupDetail || m.detailValue == "" {
+		return m, nil
+	}
+
+	path, err := writeTempFile(m.detailEnvVar, m.detailValue)
+	if err != nil {
+		m.statusBar.Message = "Write failed: " + err.Error()
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(3 * time.Second)
+	}
+	m.tempFiles = append(m.tempFiles, path)
+
+	lifetime := m.tempFileLifetime()
+	m.statusBar.IsError = false
+	m.statusBar.Message = fmt.Sprintf("Wrote %s (deleted in %s)", path, lifetime)
+	if err := m.clipboard.WriteAll(path); err == nil {
+		m.statusBar.Message = fmt.Sprintf("Wrote %s, path copied (deleted in %s)", path, lifetime)
+	}
+
+	return m, tea.Batch(
+		clearStatusAfter(5*time.Second),
+		tea.Tick(lifetime, func(time.Time) tea.Msg {
+			return tempFileExpiredMsg{path: path}
+		}),
+	)
+}
+
+// handleTempFileExpired deletes a temp file whose lifetime has elapsed.
+func (m model) handleTempFileExpired(msg tempFileExpiredMsg) (tea.Model, tea.Cmd) {
+	i := slices.Index(m.tempFiles, msg.path)
+	if i < 0 {
+		return m, nil
+	}
+	m.tempFiles = slices.Delete(slices.Clone(m.tempFiles), i, i+1)
+
+	if err := os.Remove(msg.path); err != nil && !os.IsNotExist(err) {
+		m.statusBar.Message = "Temp file delete failed: " + err.Error()
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(5 * time.Second)
+	}
+	m.statusBar.Message = "Deleted " + msg.path
+	m.statusBar.IsError = false
+	return m, clearStatusAfter(2 * time.Second)
+}
+
+// removeTempFiles deletes every temp file still waiting for its timer.
+func (m *model) removeTempFiles() {
+	for _, path := range m.tempFiles {
+		_ = os.Remove(path)
+	}
+	m.tempFiles = nil
+}
@@ -1,6 +1,12 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"slices"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"go.dot.industries/vx/internal/config"
+)
 
 // Colors used throughout the TUI.
 var (
@@ -68,6 +74,46 @@ var (
 			Padding(1, 2)
 )
 
+// defaultAccents gives common environment names a distinct accent so that
+// production never looks like dev at a glance. Other environments use
+// colorPrimary unless [tui.accents] says otherwise.
+var defaultAccents = map[string]lipgloss.Color{
+	"staging":    colorWarning,
+	"production": colorError,
+}
+
+// accentFor returns the accent color for env, preferring the configured
+// [tui.accents] entry over the built-in defaults.
+func accentFor(cfg *config.RootConfig, env string) lipgloss.Color {
+	if cfg != nil {
+		if c, ok := cfg.TUI.Accents[env]; ok && c != "" {
+			return lipgloss.Color(c)
+		}
+	}
+	if c, ok := defaultAccents[env]; ok {
+		return c
+	}
+	return colorPrimary
+}
+
+// isProtectedEnv reports whether env requires typed confirmation before
+// secret values are revealed or mappings are written.
+func isProtectedEnv(cfg *config.RootConfig, env string) bool {
+	if cfg == nil {
+		return slices.Contains(config.DefaultProtectedEnvironments, env)
+	}
+	return cfg.TUI.IsProtected(env)
+}
+
+// pathEnv returns what ${env} becomes in secret paths for the selected
+// environment (see config.EnvironmentConfig.Map).
+func (m model) pathEnv() string {
+	if m.config == nil {
+		return m.env
+	}
+	return m.config.Environments.PathSegment(m.env)
+}
+
 // workspaceListWidth is the fixed width of the left pane.
 const workspaceListWidth = 18
 
@@ -7,19 +7,103 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"go.dot.industries/vx/internal/config"
 	"go.dot.industries/vx/internal/tui/bridge"
+	"go.dot.industries/vx/internal/vault"
 )
 
+// runSettings holds the optional behaviour of Run.
+type runSettings struct {
+	plain         bool
+	namespace     string
+	clientOptions func(*config.RootConfig) []vault.ClientOption
+	startup       startupSelection
+}
+
+// startupSelection is what to select once the config has loaded, instead of
+// the state saved by the previous run. Empty fields keep the saved state.
+type startupSelection struct {
+	workspace string
+	env       string
+	secret    string
+}
+
+// Option configures Run.
+type Option func(*runSettings)
+
+// WithPlain renders the TUI for screen readers: inline instead of on the
+// alternate screen, without colors or box drawing, one item per line.
+func WithPlain() Option {
+	return func(s *runSettings) {
+		s.plain = true
+	}
+}
+
+// WithWorkspace selects the named workspace on startup.
+func WithWorkspace(name string) Option {
+	return func(s *runSettings) {
+		s.startup.workspace = name
+	}
+}
+
+// WithEnvironment selects env on startup.
+func WithEnvironment(env string) Option {
+	return func(s *runSettings) {
+		s.startup.env = env
+	}
+}
+
+// WithSelect selects the secret mapped to envVar on startup and opens its
+// detail popup, asking for confirmation first in a protected environment.
+func WithSelect(envVar string) Option {
+	return func(s *runSettings) {
+		s.startup.secret = envVar
+	}
+}
+
+// WithNamespace sends requests for [vault] to the given Vault namespace
+// instead of the configured one.
+func WithNamespace(ns string) Option {
+	return func(s *runSettings) {
+		s.namespace = ns
+	}
+}
+
+// WithClientOptions makes every Vault client the TUI creates use the options
+// opts returns for the config the client is for, such as request tracing
+// headers. Without it, clients use the Vault API defaults.
+func WithClientOptions(opts func(*config.RootConfig) []vault.ClientOption) Option {
+	return func(s *runSettings) {
+		s.clientOptions = opts
+	}
+}
+
 // Run starts the interactive TUI. It blocks until the user quits.
-func Run(configPath, vaultAddr, authMethod, roleID, secretID string) error {
+func Run(configPath, vaultAddr, authMethod, roleID, secretID string, opts ...Option) error {
+	var settings runSettings
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
 	b := bridge.New(configPath, vaultAddr, authMethod, roleID, secretID)
+	b.SetNamespace(settings.namespace)
+	b.SetClientOptions(settings.clientOptions)
 	m := newModel(b)
+	m.startup = settings.startup
+	m.configWatcher = newConfigWatcher()
+	defer m.configWatcher.close()
 
-	p := tea.NewProgram(
-		m,
+	programOpts := []tea.ProgramOption{
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
-	)
+	}
+	if settings.plain {
+		usePlainStyles()
+		m.plain = true
+		programOpts = nil
+	}
+
+	p := tea.NewProgram(m, programOpts...)
 
 	if _, err := p.Run(); err != nil {
 		return fmt.Errorf("running TUI: %w", err)
@@ -1,13 +1,18 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"path"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 
+	"go.dot.industries/vx/internal/changes"
+	"go.dot.industries/vx/internal/clipboard"
 	"go.dot.industries/vx/internal/config"
 	"go.dot.industries/vx/internal/tui/bridge"
 	"go.dot.industries/vx/internal/tui/components"
@@ -32,6 +37,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.fatalError = msg.err.Error()
 		return m, nil
 
+	case configReloadErrorMsg:
+		m.statusBar.Message = "Reload failed, keeping the previous config: " + msg.err.Error()
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(5 * time.Second)
+
+	case configFileChangedMsg:
+		return m.handleConfigFileChanged(msg)
+
+	case configFilesStatMsg:
+		return m.handleConfigFilesStat(msg)
+
 	// --- Workspace data ---
 	case workspaceSelectedMsg:
 		return m.handleWorkspaceSelected(msg)
@@ -40,6 +56,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleWorkspaceDataLoaded(msg)
 
 	case workspaceDataErrorMsg:
+		m.startup.secret = ""
 		m.statusBar.Message = "Error loading workspace: " + msg.err.Error()
 		m.statusBar.IsError = true
 		return m, clearStatusAfter(3 * time.Second)
@@ -50,22 +67,65 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// --- Secret resolution ---
 	case secretResolvedMsg:
+		stopResolve(&m.detailCancel)
+		if msg.envVar == m.detailEnvVar {
+			m.storeValue(msg.envVar, m.detailPath, msg.value, time.Now())
+		}
 		m.detailValue = msg.value
 		m.detailLoading = false
+		m.detailCached = false
+		m.detailVersion = pinnedVersion(m.detailPath)
 		return m, nil
 
+	case secretVersionResolvedMsg:
+		return m.handleVersionResolved(msg)
+
 	case secretResolveErrorMsg:
+		stopResolve(&m.detailCancel)
 		m.detailError = msg.err.Error()
 		m.detailLoading = false
 		return m, nil
 
+	case secretMetadataMsg:
+		if msg.envVar == m.detailEnvVar {
+			m.detailMeta = msg.meta
+		}
+		return m, nil
+
+	case secretMetadataErrorMsg:
+		if msg.envVar == m.detailEnvVar {
+			m.detailMetaErr = msg.err.Error()
+		}
+		return m, nil
+
 	// --- Auth ---
+	case authRequiredMsg:
+		switch msg.retry {
+		case authRetryDetail:
+			stopResolve(&m.detailCancel)
+		case authRetryWriteValue:
+			m, _ = m.writeFinished(true)
+		case authRetryEffective:
+			stopResolve(&m.effectiveCancel)
+			m.statusBar.Message = ""
+		}
+		return m.requireAuth(msg.retry)
+
 	case authSucceededMsg:
-		m.vaultClient = msg.client
-		return m, nil
+		return m.handleAuthSucceeded(msg)
 
 	case authFailedMsg:
+		if msg.login {
+			m.authBusy = false
+			if m.activePopup == popupAuth {
+				m.authError = msg.err.Error()
+				return m, nil
+			}
+		}
 		m.statusBar.Message = "Auth failed: " + msg.err.Error()
+		if needsLogin(msg.err) {
+			m.statusBar.Message = "Not logged in to Vault; press L to log in"
+		}
 		m.statusBar.IsError = true
 		return m, clearStatusAfter(5 * time.Second)
 
@@ -83,29 +143,54 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// --- CRUD ---
 	case mappingSavedMsg:
-		m.activePopup = popupNone
+		var quitNow bool
+		if m, quitNow = m.writeFinished(false); quitNow {
+			return m.quit()
+		}
+		if m.activePopup != popupQuit {
+			m.activePopup = popupNone
+		}
 		m.statusBar.Message = "Mapping saved"
 		m.statusBar.IsError = false
+		m.configStamps = nil
 		return m, tea.Batch(
 			loadConfigCmd(m.bridge),
 			clearStatusAfter(3*time.Second),
 		)
 
 	case mappingSaveErrorMsg:
+		m, _ = m.writeFinished(true)
+		m.statusBar.Message = "Save failed: " + msg.err.Error()
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(5 * time.Second)
+
+	case valueSavedMsg:
+		return m.handleValueSaved(msg)
+
+	case valueSaveErrorMsg:
+		m, _ = m.writeFinished(true)
 		m.statusBar.Message = "Save failed: " + msg.err.Error()
 		m.statusBar.IsError = true
 		return m, clearStatusAfter(5 * time.Second)
 
 	case mappingDeletedMsg:
-		m.activePopup = popupNone
+		var quitNow bool
+		if m, quitNow = m.writeFinished(false); quitNow {
+			return m.quit()
+		}
+		if m.activePopup != popupQuit {
+			m.activePopup = popupNone
+		}
 		m.statusBar.Message = "Mapping deleted"
 		m.statusBar.IsError = false
+		m.configStamps = nil
 		return m, tea.Batch(
 			loadConfigCmd(m.bridge),
 			clearStatusAfter(3*time.Second),
 		)
 
 	case mappingDeleteErrorMsg:
+		m, _ = m.writeFinished(true)
 		m.statusBar.Message = "Delete failed: " + msg.err.Error()
 		m.statusBar.IsError = true
 		return m, clearStatusAfter(5 * time.Second)
@@ -121,6 +206,82 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.statusBar.IsError = false
 		return m, nil
 
+	case clipboardClearMsg:
+		return m.handleClipboardClear(msg)
+
+	case tempFileExpiredMsg:
+		return m.handleTempFileExpired(msg)
+
+	case secretsChangedMsg:
+		return m.handleSecretsChanged(msg)
+
+	// --- Workspace comparison ---
+	case compareLoadedMsg:
+		m.compare = components.NewCompareTable(msg.left, msg.leftSecrets, msg.right, msg.rightSecrets)
+		m.comparing = true
+		return m, nil
+
+	case compareErrorMsg:
+		m.statusBar.Message = "Compare failed: " + msg.err.Error()
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(5 * time.Second)
+
+	// --- Effective environment ---
+	case effectiveLoadedMsg:
+		stopResolve(&m.effectiveCancel)
+		// Drop a result for a workspace or environment no longer selected.
+		if msg.workspace != m.workspaces.Selected() || msg.env != m.env {
+			return m, nil
+		}
+		m.effectiveTable = newEffectiveTable(msg.workspace, msg.env, msg.vars)
+		m.effective = true
+		m.statusBar.Message = ""
+		m.statusBar.IsError = false
+		return m, nil
+
+	case effectiveErrorMsg:
+		stopResolve(&m.effectiveCancel)
+		m.statusBar.Message = "Effective environment failed: " + msg.err.Error()
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(5 * time.Second)
+
+	// --- Defaults ---
+	case defaultsLoadedMsg:
+		return m.handleDefaultsLoaded(msg)
+
+	case defaultsErrorMsg:
+		m.statusBar.Message = "Reading defaults failed: " + msg.err.Error()
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(5 * time.Second)
+
+	case defaultSavedMsg:
+		return m.handleDefaultSaved(msg)
+
+	case defaultSaveErrorMsg:
+		m, _ = m.writeFinished(true)
+		m.statusBar.Message = "Save failed: " + msg.err.Error()
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(5 * time.Second)
+
+	case editorFinishedMsg:
+		if msg.err != nil {
+			m.statusBar.Message = "Editor failed: " + msg.err.Error()
+			m.statusBar.IsError = true
+			return m, clearStatusAfter(5 * time.Second)
+		}
+		// The file may have changed; reload so the panes reflect it.
+		m.statusBar.Message = "Reloaded " + m.displayPath(msg.path)
+		m.statusBar.IsError = false
+		m.configStamps = nil
+		return m, tea.Batch(
+			loadConfigCmd(m.bridge),
+			clearStatusAfter(3*time.Second),
+		)
+
+	case uncommittedFilesMsg:
+		m.uncommitted = msg.files
+		return m, nil
+
 	// --- Keyboard ---
 	case tea.KeyMsg:
 		return m.handleKey(msg)
@@ -129,10 +290,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// handleConfigLoaded initializes the TUI state from the loaded config.
+// handleConfigLoaded initializes the TUI state from the loaded config. On a
+// reload the current workspace, environment, filter, and secret stay
+// selected where they still exist; the first load restores the saved state
+// and applies the command line's selection.
 func (m model) handleConfigLoaded(msg configLoadedMsg) (tea.Model, tea.Cmd) {
+	reload := m.config != nil
+	current := m.currentState()
+	if row := m.secrets.Selected(); reload && row != nil {
+		m.reselect = row.EnvVar
+	}
+
 	m.config = msg.config
 	m.rootDir = msg.rootDir
+	m.configStamps = msg.stamps
+	m.configWatcher.watch(msg.rootDir, configFiles(m.bridge, msg.config, msg.rootDir))
 	m.env = msg.config.Environments.Default
 	m.environments = msg.config.Environments.Available
 
@@ -140,8 +312,33 @@ func (m model) handleConfigLoaded(msg configLoadedMsg) (tea.Model, tea.Cmd) {
 	hasRootSecrets := len(msg.config.Secrets) > 0
 	m.workspaces = components.NewWorkspaceList(wsNames, hasRootSecrets)
 
-	// Try to authenticate with cached token (non-blocking)
-	cmd := m.tryAuth()
+	var startupErr string
+	if reload {
+		m = m.restoreState(current)
+		m.workspaces.Focused = m.focus == focusWorkspaces
+	} else {
+		m = m.restoreState(loadState(m.rootDir))
+		m, startupErr = m.applyStartup()
+	}
+
+	// Try to authenticate with cached token (non-blocking), and check git
+	// for edits not yet committed
+	cmd := tea.Batch(m.tryAuth(), uncommittedFilesCmd(m.bridge, m.config, m.rootDir))
+	if startupErr != "" {
+		m.statusBar.Message = startupErr
+		m.statusBar.IsError = true
+		cmd = tea.Batch(cmd, clearStatusAfter(5*time.Second))
+	}
+
+	// The defaults view follows the file it shows
+	if m.defaultsView {
+		if file := m.selectedWorkspaceFile(); file != "" {
+			cmd = tea.Batch(cmd, loadDefaultsCmd(m.bridge, file, m.environments))
+		} else {
+			m.defaultsView = false
+			m.defaultsTable = components.DefaultsTable{}
+		}
+	}
 
 	// Load data for the first workspace
 	selected := m.workspaces.Selected()
@@ -155,6 +352,36 @@ func (m model) handleConfigLoaded(msg configLoadedMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// applyStartup applies the selection requested on the command line over the
+// restored state. A workspace or environment that does not exist is left
+// unselected and reported in the returned message.
+func (m model) applyStartup() (model, string) {
+	var problems []string
+
+	if env := m.startup.env; env != "" {
+		if slices.Contains(m.environments, env) {
+			m.env = env
+		} else {
+			problems = append(problems, fmt.Sprintf("unknown environment %q", env))
+		}
+	}
+
+	if ws := m.startup.workspace; ws != "" && !m.workspaces.Select(ws) {
+		problems = append(problems, fmt.Sprintf("unknown workspace %q", ws))
+	}
+
+	if m.startup.secret != "" {
+		// A restored filter could hide the requested secret.
+		m.filterText = ""
+		m.secrets.ApplyFilter("")
+		m.focus = focusSecrets
+		m.workspaces.Focused = false
+		m.secrets.Focused = true
+	}
+
+	return m, strings.Join(problems, "; ")
+}
+
 // handleWorkspaceSelected triggers data loading for the newly selected workspace.
 func (m model) handleWorkspaceSelected(msg workspaceSelectedMsg) (tea.Model, tea.Cmd) {
 	return m, loadWorkspaceDataCmd(m.bridge, m.config, m.rootDir, msg.name, m.env)
@@ -162,7 +389,23 @@ func (m model) handleWorkspaceSelected(msg workspaceSelectedMsg) (tea.Model, tea
 
 // handleWorkspaceDataLoaded populates the secret table with merged data.
 func (m model) handleWorkspaceDataLoaded(msg workspaceDataLoadedMsg) (tea.Model, tea.Cmd) {
-	m.secrets.SetSecrets(msg.secrets, m.env)
+	m.secrets.SetSecrets(msg.secrets, m.pathEnv())
+	m.cacheTTLs = msg.cacheTTL
+	if m.reselect != "" {
+		m.secrets.Select(m.reselect)
+		m.reselect = ""
+	}
+
+	if name := m.startup.secret; name != "" {
+		m.startup.secret = ""
+		if !m.secrets.Select(name) {
+			m.statusBar.Message = fmt.Sprintf("%s is not mapped in %s", name, msg.source)
+			m.statusBar.IsError = true
+			return m, clearStatusAfter(5 * time.Second)
+		}
+		return m.handleEnter()
+	}
+
 	return m, nil
 }
 
@@ -170,6 +413,7 @@ func (m model) handleWorkspaceDataLoaded(msg workspaceDataLoadedMsg) (tea.Model,
 func (m model) handleEnvChanged(msg envChangedMsg) (tea.Model, tea.Cmd) {
 	m.env = msg.env
 	m.activePopup = popupNone
+	m.defaultsTable.Env = m.env
 
 	selected := m.workspaces.Selected()
 	if selected != "" {
@@ -180,9 +424,10 @@ func (m model) handleEnvChanged(msg envChangedMsg) (tea.Model, tea.Cmd) {
 
 // handleKey dispatches keyboard events based on current state.
 func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	// Force quit always works
+	// Force quit works from anywhere, but asks first while a write is in
+	// flight (pressing it again at that prompt quits regardless)
 	if key.Matches(msg, keys.ForceQuit) {
-		return m, tea.Quit
+		return m.requestQuit()
 	}
 
 	// Delegate to popup handler if a popup is open
@@ -195,10 +440,22 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleFilterKey(msg)
 	}
 
+	if m.defaultsView {
+		return m.handleDefaultsKey(msg)
+	}
+
+	if m.effective {
+		return m.handleEffectiveKey(msg)
+	}
+
+	if m.comparing {
+		return m.handleCompareKey(msg)
+	}
+
 	// Main view key handling
 	switch {
 	case key.Matches(msg, keys.Quit):
-		return m, tea.Quit
+		return m.requestQuit()
 
 	case key.Matches(msg, keys.Tab):
 		if m.focus == focusWorkspaces {
@@ -252,6 +509,41 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case key.Matches(msg, keys.Delete):
 		return m.handleDelete()
+
+	case key.Matches(msg, keys.Duplicate):
+		return m.handleDuplicate()
+
+	case key.Matches(msg, keys.Open):
+		return m.handleOpen()
+
+	case key.Matches(msg, keys.Compare):
+		return m.handleCompare()
+
+	case key.Matches(msg, keys.Effective):
+		return m.handleEffective()
+
+	case key.Matches(msg, keys.Escape):
+		if m.effectiveCancel == nil {
+			return m, nil
+		}
+		stopResolve(&m.effectiveCancel)
+		m.statusBar.Message = "Cancelled resolving the effective environment"
+		m.statusBar.IsError = false
+		return m, clearStatusAfter(3 * time.Second)
+
+	case key.Matches(msg, keys.Defaults):
+		return m.handleDefaults()
+
+	case key.Matches(msg, keys.Changes):
+		// Refresh too: a commit made in another terminal clears the list.
+		m.activePopup = popupUncommitted
+		return m, uncommittedFilesCmd(m.bridge, m.config, m.rootDir)
+
+	case key.Matches(msg, keys.Login):
+		if m.config == nil {
+			return m, nil
+		}
+		return m.requireAuth(authRetryNone)
 	}
 
 	return m, nil
@@ -295,8 +587,24 @@ func (m model) handleEnter() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if m.secrets.Selected() == nil {
+		return m, nil
+	}
+
+	if isProtectedEnv(m.config, m.env) {
+		return m.requireSafetyConfirm(safetyReveal)
+	}
+
+	return m.openDetail()
+}
+
+// openDetail opens the detail popup and starts resolving the selected secret.
+// A value resolved within its cache lifetime is shown again instead; R in the
+// popup reads it live.
+func (m model) openDetail() (tea.Model, tea.Cmd) {
 	selected := m.secrets.Selected()
 	if selected == nil {
+		m.activePopup = popupNone
 		return m, nil
 	}
 
@@ -306,25 +614,144 @@ func (m model) handleEnter() (tea.Model, tea.Cmd) {
 	m.detailValue = ""
 	m.detailError = ""
 	m.detailLoading = true
+	m.detailMeta = nil
+	m.detailMetaErr = ""
+	m.detailCached = false
+	m.detailEditing = false
+	m.detailEditInput = ""
+	m.detailVersion = pinnedVersion(m.detailPath)
+	m.detailHistory = false
+
+	metaCmd := readMetadataCmd(m.bridge, m.vaultClient, m.config, selected.EnvVar, selected.RawPath, m.pathEnv())
+	if cached, ok := m.cachedValueFor(selected.EnvVar, selected.VaultPath, time.Now()); ok {
+		m.detailValue = cached.value
+		m.detailLoading = false
+		m.detailCached = true
+		m.detailFetched = cached.fetched
+		return m, metaCmd
+	}
 
-	return m, resolveSecretCmd(m.bridge, m.vaultClient, m.config, selected.EnvVar, selected.RawPath, m.env)
+	ctx := newResolveContext(&m.detailCancel)
+	return m, tea.Batch(
+		resolveSecretCmd(ctx, m.bridge, m.vaultClient, m.config, selected.EnvVar, selected.RawPath, m.pathEnv()),
+		metaCmd,
+	)
 }
 
-// handleCopy copies the resolved value to clipboard.
+// handleCopy copies the resolved value to clipboard. When clipboard.clear_after
+// is configured, clearing is scheduled for later.
 func (m model) handleCopy() (tea.Model, tea.Cmd) {
-	if m.activePopup == popupDetail && m.detailValue != "" {
-		if err := clipboard.WriteAll(m.detailValue); err != nil {
-			m.statusBar.Message = "Copy failed: " + err.Error()
-			m.statusBar.IsError = true
-		} else {
-			m.statusBar.Message = "Copied to clipboard"
-			m.statusBar.IsError = false
-		}
+	if m.activePopup != popupDetail || m.detailValue == "" {
+		return m, nil
+	}
+
+	if err := m.clipboard.WriteAll(m.detailValue); err != nil {
+		m.statusBar.Message = "Copy failed: " + err.Error()
+		m.statusBar.IsError = true
 		return m, clearStatusAfter(2 * time.Second)
 	}
+
+	m.statusBar.IsError = false
+	m.statusBar.Message = "Copied to clipboard"
+
+	clearAfter := m.clipboardClearAfter()
+	if clearAfter <= 0 {
+		return m, clearStatusAfter(2 * time.Second)
+	}
+
+	fp := clipboard.Fingerprint(m.detailValue)
+	m.clipboardPending = fp
+	m.statusBar.Message = fmt.Sprintf("Copied to clipboard (clears in %s)", clearAfter)
+	return m, tea.Batch(
+		clearStatusAfter(2*time.Second),
+		tea.Tick(clearAfter, func(time.Time) tea.Msg {
+			return clipboardClearMsg{fingerprint: fp}
+		}),
+	)
+}
+
+// clipboardClearAfter returns the configured clipboard clear delay.
+func (m model) clipboardClearAfter() time.Duration {
+	if m.config == nil {
+		return 0
+	}
+	return time.Duration(m.config.Clipboard.ClearAfter)
+}
+
+// handleClipboardClear clears the clipboard when a copy's delay elapses. Timers
+// from copies that have since been superseded are ignored.
+func (m model) handleClipboardClear(msg clipboardClearMsg) (tea.Model, tea.Cmd) {
+	if msg.fingerprint != m.clipboardPending {
+		return m, nil
+	}
+	m.clipboardPending = ""
+
+	cleared, err := clipboard.ClearIfUnchanged(m.clipboard, msg.fingerprint)
+	if err != nil {
+		m.statusBar.Message = "Clipboard clear failed: " + err.Error()
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(3 * time.Second)
+	}
+	if !cleared {
+		return m, nil
+	}
+
+	m.statusBar.Message = "Clipboard cleared"
+	m.statusBar.IsError = false
+	return m, clearStatusAfter(2 * time.Second)
+}
+
+// quit exits the TUI. A copied secret still awaiting its clear-after delay is
+// cleared immediately, since the timer dies with the program, and so are temp
+// files written from the detail popup. The selected
+// workspace, environment, and filter are saved for the next run.
+func (m model) quit() (tea.Model, tea.Cmd) {
+	if m.clipboardPending != "" {
+		_, _ = clipboard.ClearIfUnchanged(m.clipboard, m.clipboardPending)
+		m.clipboardPending = ""
+	}
+	m.removeTempFiles()
+	if m.config != nil && m.rootDir != "" {
+		// Best effort: failing to remember the selection is not worth
+		// blocking the exit for.
+		_ = saveState(m.rootDir, m.currentState())
+	}
+	return m, tea.Quit
+}
+
+// requestQuit quits, unless a mapping save or delete is still in flight. In
+// that case the quit prompt is opened so the user can wait for it or carry
+// on; requesting quit again from the prompt exits immediately.
+func (m model) requestQuit() (tea.Model, tea.Cmd) {
+	if m.pendingWrites == 0 || m.activePopup == popupQuit {
+		return m.quit()
+	}
+
+	m.activePopup = popupQuit
+	m.quitCursor = 0
+	m.quitWhenIdle = false
 	return m, nil
 }
 
+// writeFinished records that a dispatched write has completed and reports
+// whether the TUI should now exit because the user chose to quit once writes
+// finish. A failed write cancels that choice so the error stays visible.
+func (m model) writeFinished(failed bool) (model, bool) {
+	if m.pendingWrites > 0 {
+		m.pendingWrites--
+	}
+
+	if !m.quitWhenIdle {
+		return m, false
+	}
+	if failed {
+		m.quitWhenIdle = false
+		m.activePopup = popupNone
+		return m, false
+	}
+	return m, m.pendingWrites == 0
+}
+
 // handleAdd opens the mapping form for adding a new mapping.
 func (m model) handleAdd() (tea.Model, tea.Cmd) {
 	if m.vaultClient == nil {
@@ -394,6 +821,227 @@ func (m model) handleDelete() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleDuplicate opens the picker for the file to copy the selected
+// mapping into.
+func (m model) handleDuplicate() (tea.Model, tea.Cmd) {
+	if m.focus != focusSecrets {
+		return m, nil
+	}
+
+	selected := m.secrets.Selected()
+	if selected == nil {
+		return m, nil
+	}
+
+	source := m.bridge.SecretSource(m.config, m.rootDir, m.workspaces.Selected(), selected.EnvVar)
+	if source == "" {
+		m.statusBar.Message = "Cannot determine source file for this secret"
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(3 * time.Second)
+	}
+
+	m.duplicateSource = source
+	if len(m.duplicateTargets()) == 0 {
+		m.statusBar.Message = "Nowhere to duplicate to: only one vx.toml"
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(3 * time.Second)
+	}
+
+	m.activePopup = popupDuplicatePicker
+	m.duplicatePickerCursor = 0
+	return m, nil
+}
+
+// duplicateTargets returns the files the mapping being duplicated can be
+// copied into: every vx.toml except the one it comes from.
+func (m model) duplicateTargets() []bridge.FileTarget {
+	var targets []bridge.FileTarget
+	for _, t := range m.bridge.WorkspaceFiles(m.config, m.rootDir) {
+		if t.Path != m.duplicateSource {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// handleDuplicatePickerKey handles keys within the duplicate target picker.
+// Choosing a target opens the mapping form with the selected mapping filled
+// in and the vault path focused, so it can be adjusted before saving.
+func (m model) handleDuplicatePickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	targets := m.duplicateTargets()
+	switch {
+	case key.Matches(msg, keys.Up):
+		if m.duplicatePickerCursor > 0 {
+			m.duplicatePickerCursor--
+		}
+	case key.Matches(msg, keys.Down):
+		if m.duplicatePickerCursor < len(targets)-1 {
+			m.duplicatePickerCursor++
+		}
+	case msg.Type == tea.KeyEnter:
+		selected := m.secrets.Selected()
+		if selected == nil || m.duplicatePickerCursor < 0 || m.duplicatePickerCursor >= len(targets) {
+			return m, nil
+		}
+
+		m.activePopup = popupMappingForm
+		m.mappingFormEnvVar = selected.EnvVar
+		m.mappingFormPath = selected.RawPath
+		m.mappingFormTarget = m.targetIndex(targets[m.duplicatePickerCursor].Path)
+		m.mappingFormField = 0
+		m.mappingFormIsEdit = false
+		m.mappingFormOldEnvVar = ""
+	}
+	return m, nil
+}
+
+// handleOpen opens the selected workspace's vx.toml in $EDITOR, suspending
+// the TUI until the editor exits. It refuses while a mapping write is in
+// flight so the two don't overwrite each other.
+func (m model) handleOpen() (tea.Model, tea.Cmd) {
+	path := m.selectedWorkspaceFile()
+	if path == "" {
+		return m, nil
+	}
+
+	if m.pendingWrites > 0 {
+		m.statusBar.Message = "Wait for the pending save to finish"
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(3 * time.Second)
+	}
+
+	return m, openEditorCmd(path)
+}
+
+// handleCompare opens the picker for the workspace to compare the selected
+// one against.
+func (m model) handleCompare() (tea.Model, tea.Cmd) {
+	if len(m.compareChoices()) == 0 {
+		m.statusBar.Message = "Nothing to compare: only one workspace"
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(3 * time.Second)
+	}
+
+	m.activePopup = popupComparePicker
+	m.comparePickerCursor = 0
+	return m, nil
+}
+
+// compareChoices returns the workspaces the selected one can be compared
+// with.
+func (m model) compareChoices() []string {
+	selected := m.workspaces.Selected()
+	var choices []string
+	for _, name := range m.workspaces.Names() {
+		if name != selected {
+			choices = append(choices, name)
+		}
+	}
+	return choices
+}
+
+// handleComparePickerKey handles keys within the compare picker popup.
+func (m model) handleComparePickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	choices := m.compareChoices()
+	switch {
+	case key.Matches(msg, keys.Up):
+		if m.comparePickerCursor > 0 {
+			m.comparePickerCursor--
+		}
+	case key.Matches(msg, keys.Down):
+		if m.comparePickerCursor < len(choices)-1 {
+			m.comparePickerCursor++
+		}
+	case msg.Type == tea.KeyEnter:
+		if m.comparePickerCursor >= 0 && m.comparePickerCursor < len(choices) {
+			m.activePopup = popupNone
+			return m, loadCompareCmd(m.bridge, m.config, m.rootDir,
+				m.workspaces.Selected(), choices[m.comparePickerCursor], m.env)
+		}
+	}
+	return m, nil
+}
+
+// handleCompareKey handles keys while the comparison replaces the panes.
+func (m model) handleCompareKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Quit):
+		return m.requestQuit()
+	case key.Matches(msg, keys.Escape), key.Matches(msg, keys.Compare):
+		m.comparing = false
+	case key.Matches(msg, keys.Up):
+		m.compare.MoveUp()
+	case key.Matches(msg, keys.Down):
+		m.compare.MoveDown()
+	case key.Matches(msg, keys.Help):
+		m.activePopup = popupHelp
+	}
+	return m, nil
+}
+
+// handleEffective starts resolving the environment vx exec would inject for
+// the selected workspace; the view opens once it is loaded. Esc abandons it.
+func (m model) handleEffective() (tea.Model, tea.Cmd) {
+	if m.config == nil || m.workspaces.Selected() == "" {
+		return m, nil
+	}
+
+	m.statusBar.Message = "Resolving the effective environment... (esc to cancel)"
+	m.statusBar.IsError = false
+	ctx := newResolveContext(&m.effectiveCancel)
+	return m, loadEffectiveCmd(ctx, m.bridge, m.vaultClient, m.config, m.rootDir, m.workspaces.Selected(), m.env)
+}
+
+// newEffectiveTable builds the effective environment view from the bridge's
+// variables.
+func newEffectiveTable(workspace, env string, vars []bridge.EffectiveVar) components.EffectiveTable {
+	rows := make([]components.EffectiveRow, len(vars))
+	for i, v := range vars {
+		rows[i] = components.EffectiveRow{
+			Name:      v.Name,
+			Value:     v.Value,
+			Secret:    v.Secret,
+			Source:    v.Source,
+			Overrides: v.Overrides,
+			Note:      v.Note,
+			Unset:     v.Unset,
+		}
+		if v.Err != nil {
+			rows[i].Err = v.Err.Error()
+		}
+	}
+	return components.EffectiveTable{Title: workspace + " in " + env, Rows: rows}
+}
+
+// handleEffectiveKey handles keys while the effective environment replaces
+// the panes. Enter reveals or masks the selected secret; in a protected
+// environment revealing asks for confirmation first.
+func (m model) handleEffectiveKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Quit):
+		return m.requestQuit()
+	case key.Matches(msg, keys.Escape), key.Matches(msg, keys.Effective):
+		m.effective = false
+		m.effectiveTable = components.EffectiveTable{}
+	case key.Matches(msg, keys.Up):
+		m.effectiveTable.MoveUp()
+	case key.Matches(msg, keys.Down):
+		m.effectiveTable.MoveDown()
+	case key.Matches(msg, keys.Enter):
+		row := m.effectiveTable.Selected()
+		if row == nil || !row.Secret {
+			return m, nil
+		}
+		if !row.Revealed && isProtectedEnv(m.config, m.env) {
+			return m.requireSafetyConfirm(safetyRevealEffective)
+		}
+		row.Revealed = !row.Revealed
+	case key.Matches(msg, keys.Help):
+		m.activePopup = popupHelp
+	}
+	return m, nil
+}
+
 // handleFilterKey handles keyboard input while in filter mode.
 func (m model) handleFilterKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch {
@@ -423,8 +1071,23 @@ func (m model) handleFilterKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // handlePopupKey dispatches keyboard events for the currently active popup.
 func (m model) handlePopupKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.activePopup == popupDetail && m.detailEditing {
+		return m.handleValueEditKey(msg)
+	}
+	if m.activePopup == popupDetail && m.detailHistory {
+		return m.handleHistoryKey(msg)
+	}
+
 	if key.Matches(msg, keys.Escape) {
+		if m.activePopup == popupDetail {
+			stopResolve(&m.detailCancel)
+		}
+		if m.activePopup == popupAuth {
+			m.authRetry = authRetryNone
+			m.authSecretID = ""
+		}
 		m.activePopup = popupNone
+		m.quitWhenIdle = false
 		return m, nil
 	}
 
@@ -446,6 +1109,30 @@ func (m model) handlePopupKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case popupConfirm:
 		return m.handleConfirmKey(msg)
+
+	case popupSafety:
+		return m.handleSafetyKey(msg)
+
+	case popupQuit:
+		return m.handleQuitKey(msg)
+
+	case popupComparePicker:
+		return m.handleComparePickerKey(msg)
+
+	case popupDuplicatePicker:
+		return m.handleDuplicatePickerKey(msg)
+
+	case popupDefaultForm:
+		return m.handleDefaultFormKey(msg)
+
+	case popupDefaultConfirm:
+		return m.handleDefaultConfirmKey(msg)
+
+	case popupUncommitted:
+		return m, nil // Esc handled above
+
+	case popupAuth:
+		return m.handleAuthKey(msg)
 	}
 
 	return m, nil
@@ -474,8 +1161,20 @@ func (m model) handleEnvPickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // handleDetailKey handles keys within the secret detail popup.
 func (m model) handleDetailKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	if key.Matches(msg, keys.Copy) {
+	switch {
+	case key.Matches(msg, keys.Copy):
 		return m.handleCopy()
+	case key.Matches(msg, keys.TempFile):
+		return m.handleWriteTempFile()
+	case key.Matches(msg, keys.Refresh):
+		if m.detailLoading {
+			return m, nil
+		}
+		return m.refreshDetail()
+	case key.Matches(msg, keys.EditValue):
+		return m.handleEditValue()
+	case key.Matches(msg, keys.History):
+		return m.handleHistory()
 	}
 	return m, nil
 }
@@ -513,6 +1212,11 @@ func (m model) handleVaultBrowserKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	case key.Matches(msg, keys.Backspace):
 		return m.vaultBrowserGoUp()
+	case key.Matches(msg, keys.BrowseRoot):
+		if m.vaultBrowserPath == "" {
+			return m, nil
+		}
+		return m.vaultBrowserGoTo("")
 	}
 	return m, nil
 }
@@ -544,10 +1248,16 @@ func (m model) vaultBrowserGoUp() (tea.Model, tea.Cmd) {
 		newPath = ""
 	}
 
-	m.vaultBrowserPath = newPath
+	return m.vaultBrowserGoTo(newPath)
+}
+
+// vaultBrowserGoTo lists path in the Vault browser. An empty path is the
+// base of the KV mount.
+func (m model) vaultBrowserGoTo(path string) (tea.Model, tea.Cmd) {
+	m.vaultBrowserPath = path
 	m.vaultBrowserLoading = true
 	m.vaultBrowserCursor = 0
-	return m, listVaultKeysCmd(m.bridge, m.vaultClient, newPath)
+	return m, listVaultKeysCmd(m.bridge, m.vaultClient, path)
 }
 
 // handleMappingFormKey handles keys within the add/edit mapping form.
@@ -612,9 +1322,20 @@ func (m model) saveMappingForm() (tea.Model, tea.Cmd) {
 		return m, clearStatusAfter(3 * time.Second)
 	}
 
-	target := targets[m.mappingFormTarget]
+	if isProtectedEnv(m.config, m.env) {
+		return m.requireSafetyConfirm(safetySave)
+	}
+
+	m.pendingWrites++
+	return m, m.saveMappingFormCmd()
+}
+
+// saveMappingFormCmd returns the command that writes the mapping form to its
+// target file. The form must already have been validated.
+func (m model) saveMappingFormCmd() tea.Cmd {
+	target := m.bridge.WorkspaceFiles(m.config, m.rootDir)[m.mappingFormTarget]
 
-	return m, saveMappingCmd(
+	return saveMappingCmd(
 		m.bridge,
 		target.Path,
 		m.mappingFormEnvVar,
@@ -631,6 +1352,10 @@ func (m model) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.confirmCursor = 1 - m.confirmCursor
 	case msg.Type == tea.KeyEnter:
 		if m.confirmCursor == 1 { // Delete confirmed
+			if isProtectedEnv(m.config, m.env) {
+				return m.requireSafetyConfirm(safetyDelete)
+			}
+			m.pendingWrites++
 			return m, deleteMappingCmd(m.bridge, m.confirmFile, m.confirmEnvVar)
 		}
 		m.activePopup = popupNone
@@ -638,21 +1363,143 @@ func (m model) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleQuitKey handles keys within the quit prompt shown while writes are
+// in flight.
+func (m model) handleQuitKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.quitWhenIdle {
+		return m, nil // waiting; only esc or force quit do anything
+	}
+
+	switch {
+	case key.Matches(msg, keys.Up), key.Matches(msg, keys.Down):
+		m.quitCursor = 1 - m.quitCursor
+	case key.Matches(msg, keys.Quit):
+		return m.quit()
+	case msg.Type == tea.KeyEnter:
+		if m.quitCursor == 1 {
+			m.activePopup = popupNone
+			return m, nil
+		}
+		if m.pendingWrites == 0 {
+			return m.quit()
+		}
+		m.quitWhenIdle = true
+	}
+	return m, nil
+}
+
+// requireSafetyConfirm opens the protected-environment prompt. The action
+// only runs once the environment name has been typed exactly.
+func (m model) requireSafetyConfirm(action safetyAction) (tea.Model, tea.Cmd) {
+	return m.requireSafetyConfirmIn(action, m.env)
+}
+
+// requireSafetyConfirmIn is requireSafetyConfirm for an action in env, which
+// need not be the selected environment.
+func (m model) requireSafetyConfirmIn(action safetyAction, env string) (tea.Model, tea.Cmd) {
+	m.activePopup = popupSafety
+	m.safetyAction = action
+	m.safetyEnv = env
+	m.safetyInput = ""
+	return m, nil
+}
+
+// handleSafetyKey handles keys within the protected-environment prompt.
+func (m model) handleSafetyKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		if m.safetyInput != m.safetyEnv {
+			m.safetyInput = ""
+			m.statusBar.Message = fmt.Sprintf("Type %q to confirm", m.safetyEnv)
+			m.statusBar.IsError = true
+			return m, clearStatusAfter(3 * time.Second)
+		}
+		m.safetyInput = ""
+		return m.runSafetyAction()
+
+	case tea.KeyBackspace:
+		if r := []rune(m.safetyInput); len(r) > 0 {
+			m.safetyInput = string(r[:len(r)-1])
+		}
+
+	case tea.KeyRunes:
+		m.safetyInput += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// runSafetyAction performs the action that was waiting on confirmation. For
+// writes the originating popup is restored so a failure leaves it open.
+func (m model) runSafetyAction() (tea.Model, tea.Cmd) {
+	switch m.safetyAction {
+	case safetyReveal:
+		return m.openDetail()
+	case safetySave:
+		m.activePopup = popupMappingForm
+		m.pendingWrites++
+		return m, m.saveMappingFormCmd()
+	case safetyDelete:
+		m.activePopup = popupConfirm
+		m.pendingWrites++
+		return m, deleteMappingCmd(m.bridge, m.confirmFile, m.confirmEnvVar)
+	case safetyWriteValue:
+		m.activePopup = popupDetail
+		m.pendingWrites++
+		return m, m.saveValueCmd()
+	case safetyRevealEffective:
+		if row := m.effectiveTable.Selected(); row != nil {
+			row.Revealed = true
+		}
+	case safetySaveDefault:
+		m.activePopup = popupDefaultForm
+		m.pendingWrites++
+		return m, saveDefaultCmd(m.bridge, m.defaultsFile, m.defaultFormEnv(), m.defaultFormKey, m.defaultFormValue)
+	case safetyDeleteDefault:
+		m.activePopup = popupDefaultConfirm
+		if row := m.defaultsTable.Selected(); row != nil {
+			m.pendingWrites++
+			return m, deleteDefaultCmd(m.bridge, m.defaultsFile, row.Env, row.Key)
+		}
+	}
+	m.activePopup = popupNone
+	return m, nil
+}
+
 // --- Command factories ---
 
+// newResolveContext cancels the resolution *cancel belongs to, if any, and
+// returns the context of a new one, storing its cancel function in *cancel.
+func newResolveContext(cancel *context.CancelFunc) context.Context {
+	stopResolve(cancel)
+	ctx, c := context.WithCancel(context.Background())
+	*cancel = c
+	return ctx
+}
+
+// stopResolve cancels the resolution *cancel belongs to, if any.
+func stopResolve(cancel *context.CancelFunc) {
+	if *cancel != nil {
+		(*cancel)()
+		*cancel = nil
+	}
+}
+
 // resolveSecretCmd creates a command that resolves a single secret from Vault.
-func resolveSecretCmd(b *bridge.Bridge, client *vault.Client, cfg *config.RootConfig, envVar, vaultPath, env string) tea.Cmd {
+// Nothing is sent once ctx is cancelled.
+func resolveSecretCmd(ctx context.Context, b *bridge.Bridge, client *vault.Client, cfg *config.RootConfig, envVar, vaultPath, env string) tea.Cmd {
 	return func() tea.Msg {
-		if client == nil {
-			// Try to get a client from cached token
-			var err error
-			client, err = b.Authenticate(cfg)
-			if err != nil {
-				return secretResolveErrorMsg{envVar: envVar, err: err}
-			}
+		client, vaultPath, err := b.Route(client, cfg, vaultPath)
+		if needsLogin(err) {
+			return authRequiredMsg{retry: authRetryDetail}
+		}
+		if err != nil {
+			return secretResolveErrorMsg{envVar: envVar, err: err}
 		}
 
-		val, err := b.ResolveSingle(client, envVar, vaultPath, env)
+		val, err := b.ResolveSingle(ctx, client, envVar, vaultPath, env)
+		if ctx.Err() != nil {
+			return nil // abandoned with Esc
+		}
 		if err != nil {
 			return secretResolveErrorMsg{envVar: envVar, err: err}
 		}
@@ -660,6 +1507,23 @@ func resolveSecretCmd(b *bridge.Bridge, client *vault.Client, cfg *config.RootCo
 	}
 }
 
+// readMetadataCmd creates a command that fetches KV metadata for the secret
+// behind a mapping.
+func readMetadataCmd(b *bridge.Bridge, client *vault.Client, cfg *config.RootConfig, envVar, vaultPath, env string) tea.Cmd {
+	return func() tea.Msg {
+		client, vaultPath, err := b.Route(client, cfg, vaultPath)
+		if err != nil {
+			return secretMetadataErrorMsg{envVar: envVar, err: err}
+		}
+
+		meta, err := b.ReadMetadata(client, vaultPath, env)
+		if err != nil {
+			return secretMetadataErrorMsg{envVar: envVar, err: err}
+		}
+		return secretMetadataMsg{envVar: envVar, meta: meta}
+	}
+}
+
 // listVaultKeysCmd creates a command that lists Vault keys at a path.
 func listVaultKeysCmd(b *bridge.Bridge, client *vault.Client, path string) tea.Cmd {
 	return func() tea.Msg {
@@ -708,6 +1572,90 @@ func deleteMappingCmd(b *bridge.Bridge, filePath, envVar string) tea.Cmd {
 	}
 }
 
+// changesPollInterval is how often the TUI checks the daemon's change
+// journal.
+const changesPollInterval = 2 * time.Second
+
+// pollChangesCmd reads the changes journaled after since once the poll
+// interval elapses. The journal is best-effort: when it cannot be read the
+// poll reports nothing and the next one tries again.
+func pollChangesCmd(since time.Time) tea.Cmd {
+	return tea.Tick(changesPollInterval, func(time.Time) tea.Msg {
+		entries, _ := changes.Since(since)
+		return secretsChangedMsg{entries: entries}
+	})
+}
+
+// handleSecretsChanged reports changes to secrets shown in the table and
+// re-resolves the secret open in the detail popup if it changed.
+func (m model) handleSecretsChanged(msg secretsChangedMsg) (tea.Model, tea.Cmd) {
+	var changed []string
+	refreshDetail := false
+	for _, c := range msg.entries {
+		if !c.Time.After(m.changesSeen) {
+			continue
+		}
+		m.changesSeen = c.Time
+
+		for _, row := range m.secrets.AllRows {
+			if secretDir(row.VaultPath) != c.Path {
+				continue
+			}
+			if !slices.Contains(changed, c.Path) {
+				changed = append(changed, c.Path)
+				m.forgetValues(c.Path)
+			}
+			if m.activePopup == popupDetail && row.EnvVar == m.detailEnvVar {
+				refreshDetail = true
+			}
+		}
+	}
+
+	cmds := []tea.Cmd{pollChangesCmd(m.changesSeen)}
+	if len(changed) > 0 {
+		m.statusBar.Message = "Vault: " + strings.Join(changed, ", ") + " changed"
+		m.statusBar.IsError = false
+		cmds = append(cmds, clearStatusAfter(5*time.Second))
+	}
+	if refreshDetail {
+		var cmd tea.Cmd
+		m, cmd = m.refreshDetail()
+		cmds = append(cmds, cmd)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// refreshDetail resolves the secret shown in the detail popup again, live
+// from Vault.
+func (m model) refreshDetail() (model, tea.Cmd) {
+	for _, row := range m.secrets.AllRows {
+		if row.EnvVar != m.detailEnvVar {
+			continue
+		}
+		m.detailValue = ""
+		m.detailError = ""
+		m.detailLoading = true
+		m.detailCached = false
+		ctx := newResolveContext(&m.detailCancel)
+		return m, tea.Batch(
+			resolveSecretCmd(ctx, m.bridge, m.vaultClient, m.config, row.EnvVar, row.RawPath, m.pathEnv()),
+			readMetadataCmd(m.bridge, m.vaultClient, m.config, row.EnvVar, row.RawPath, m.pathEnv()),
+		)
+	}
+	return m, nil
+}
+
+// secretDir returns the KV path holding the key a mapping reads, the form
+// change events use.
+func secretDir(vaultPath string) string {
+	p := strings.Trim(path.Clean("/"+vaultPath), "/")
+	i := strings.LastIndex(p, "/")
+	if i < 0 {
+		return ""
+	}
+	return p[:i]
+}
+
 // clearStatusAfter returns a command that sends clearStatusMsg after a delay.
 func clearStatusAfter(d time.Duration) tea.Cmd {
 	return tea.Tick(d, func(time.Time) tea.Msg {
@@ -733,10 +1681,14 @@ func (m model) findTargetIndex(envVar string) int {
 	if source == "" {
 		return 0
 	}
+	return m.targetIndex(source)
+}
 
-	targets := m.bridge.WorkspaceFiles(m.config, m.rootDir)
-	for i, t := range targets {
-		if t.Path == source {
+// targetIndex returns the index of path in WorkspaceFiles(), or 0 (the root
+// file) if it isn't there.
+func (m model) targetIndex(path string) int {
+	for i, t := range m.bridge.WorkspaceFiles(m.config, m.rootDir) {
+		if t.Path == path {
 			return i
 		}
 	}
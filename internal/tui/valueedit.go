@@ -0,0 +1,149 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/resolver"
+	"go.dot.industries/vx/internal/tui/bridge"
+	"go.dot.industries/vx/internal/vault"
+)
+
+// handleEditValue switches the detail popup into edit mode, starting from
+// the value shown. Values read from a command or pinned to a version cannot
+// be written back.
+func (m model) handleEditValue() (tea.Model, tea.Cmd) {
+	if m.detailLoading {
+		return m, nil
+	}
+
+	rawPath, ok := m.detailRawPath()
+	if !ok {
+		return m, nil
+	}
+	if resolver.IsCommand(rawPath) {
+		m.statusBar.Message = m.detailEnvVar + " is read from a command, not Vault"
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(3 * time.Second)
+	}
+	if _, version := resolver.SplitVersion(rawPath); version != 0 {
+		m.statusBar.Message = fmt.Sprintf("%s is pinned to version %d; change its mapping to edit it", m.detailEnvVar, version)
+		m.statusBar.IsError = true
+		return m, clearStatusAfter(3 * time.Second)
+	}
+
+	m.detailEditing = true
+	m.detailEditInput = m.detailValue
+	return m, nil
+}
+
+// handleValueEditKey handles keys while the detail popup edits the value.
+// Esc leaves edit mode without closing the popup.
+func (m model) handleValueEditKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keys.Escape):
+		m.detailEditing = false
+		m.detailEditInput = ""
+
+	case msg.Type == tea.KeyEnter:
+		return m.saveValue()
+
+	case msg.Type == tea.KeyBackspace:
+		if r := []rune(m.detailEditInput); len(r) > 0 {
+			m.detailEditInput = string(r[:len(r)-1])
+		}
+
+	case msg.Type == tea.KeySpace:
+		m.detailEditInput += " "
+
+	case msg.Type == tea.KeyRunes:
+		m.detailEditInput += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// saveValue writes the edited value to Vault, after the protected-environment
+// prompt where one applies.
+func (m model) saveValue() (tea.Model, tea.Cmd) {
+	if m.detailEditInput == m.detailValue {
+		m.detailEditing = false
+		return m, nil
+	}
+
+	if isProtectedEnv(m.config, m.env) {
+		return m.requireSafetyConfirm(safetyWriteValue)
+	}
+
+	m.pendingWrites++
+	return m, m.saveValueCmd()
+}
+
+// saveValueCmd returns the command that writes the edited value of the
+// secret shown in the detail popup.
+func (m model) saveValueCmd() tea.Cmd {
+	rawPath, _ := m.detailRawPath()
+	return writeValueCmd(m.bridge, m.vaultClient, m.config, m.detailEnvVar, rawPath, m.pathEnv(), m.detailEditInput)
+}
+
+// detailRawPath returns the mapping, as written in vx.toml, of the secret
+// shown in the detail popup.
+func (m model) detailRawPath() (string, bool) {
+	for _, row := range m.secrets.AllRows {
+		if row.EnvVar == m.detailEnvVar {
+			return row.RawPath, true
+		}
+	}
+	return "", false
+}
+
+// handleValueSaved shows the written value in the detail popup, if it is
+// still open on the same secret, and reads the metadata again for the new
+// version.
+func (m model) handleValueSaved(msg valueSavedMsg) (tea.Model, tea.Cmd) {
+	var quitNow bool
+	if m, quitNow = m.writeFinished(false); quitNow {
+		return m.quit()
+	}
+
+	m.statusBar.Message = "Value saved to Vault"
+	m.statusBar.IsError = false
+	cmds := []tea.Cmd{clearStatusAfter(3 * time.Second)}
+
+	if msg.envVar == m.detailEnvVar {
+		now := time.Now()
+		m.storeValue(msg.envVar, m.detailPath, msg.value, now)
+		m.detailValue = msg.value
+		m.detailError = ""
+		m.detailCached = false
+		m.detailFetched = now
+		m.detailEditing = false
+		m.detailEditInput = ""
+		if rawPath, ok := m.detailRawPath(); ok {
+			cmds = append(cmds, readMetadataCmd(m.bridge, m.vaultClient, m.config, msg.envVar, rawPath, m.pathEnv()))
+		}
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// writeValueCmd creates a command that writes a secret's value to Vault.
+func writeValueCmd(b *bridge.Bridge, client *vault.Client, cfg *config.RootConfig, envVar, vaultPath, env, value string) tea.Cmd {
+	return func() tea.Msg {
+		client, vaultPath, err := b.Route(client, cfg, vaultPath)
+		if needsLogin(err) {
+			return authRequiredMsg{retry: authRetryWriteValue}
+		}
+		if err != nil {
+			return valueSaveErrorMsg{err: err}
+		}
+
+		if err := b.WriteSingle(client, vaultPath, env, value); err != nil {
+			return valueSaveErrorMsg{err: err}
+		}
+		return valueSavedMsg{envVar: envVar, value: value}
+	}
+}
@@ -0,0 +1,181 @@
+// Package upgrade migrates vx.toml files written for an older format version
+// to config.CurrentVersion. Files are edited in place with tomledit, so
+// comments and layout survive, and the previous contents are kept as a
+// backup.
+package upgrade
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/creachadair/tomledit"
+	"github.com/creachadair/tomledit/parser"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/textdiff"
+)
+
+// Migration upgrades a vx.toml from format version From to From+1.
+type Migration struct {
+	From        int
+	Description string
+	// Apply edits doc, a root vx.toml when root is true and a workspace
+	// vx.toml otherwise. Bumping the version key is done for it.
+	Apply func(doc *tomledit.Document, root bool) error
+}
+
+// migrations lists every format change in order; migrations[i].From must be
+// i. Add an entry here when raising config.CurrentVersion.
+var migrations = []Migration{
+	{
+		From:        0,
+		Description: "record the format version",
+		Apply:       func(*tomledit.Document, bool) error { return nil },
+	},
+}
+
+// File is a vx.toml that needs upgrading, with its new contents computed but
+// not yet written.
+type File struct {
+	Path    string
+	Root    bool
+	From    int
+	Applied []Migration
+	Before  []byte
+	After   []byte
+}
+
+// Backup returns where Write keeps the file's previous contents, e.g.
+// "vx.toml.v0.bak".
+func (f *File) Backup() string {
+	return fmt.Sprintf("%s.v%d.bak", f.Path, f.From)
+}
+
+// Diff returns a unified diff from f's current to its upgraded contents,
+// with name as the file name in its header.
+func (f *File) Diff(name string) string {
+	return textdiff.Unified(name, "upgraded", f.Before, f.After)
+}
+
+// Plan reads the root vx.toml at rootPath and the workspace vx.toml files
+// and returns those below config.CurrentVersion, upgraded in memory. Files
+// already current are left out.
+func Plan(rootPath string, workspacePaths []string) ([]*File, error) {
+	var files []*File
+	for i, path := range append([]string{rootPath}, workspacePaths...) {
+		f, err := planFile(path, i == 0)
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// Write saves each file's previous contents to its backup, then writes the
+// upgraded contents with the file's existing permissions.
+func Write(files []*File) error {
+	for _, f := range files {
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", f.Path, err)
+		}
+		if err := os.WriteFile(f.Backup(), f.Before, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("writing backup %s: %w", f.Backup(), err)
+		}
+		if err := os.WriteFile(f.Path, f.After, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("writing %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// planFile upgrades one file in memory, returning nil when it is current.
+func planFile(path string, root bool) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	doc, err := tomledit.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing TOML in %s: %w", path, err)
+	}
+
+	from, err := docVersion(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if from > config.CurrentVersion {
+		return nil, fmt.Errorf("%s is format version %d, but this vx only understands up to version %d; upgrade vx", path, from, config.CurrentVersion)
+	}
+	if from == config.CurrentVersion {
+		return nil, nil
+	}
+
+	f := &File{Path: path, Root: root, From: from, Before: data}
+	for _, m := range migrations[from:config.CurrentVersion] {
+		if err := m.Apply(doc, root); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, m.Description, err)
+		}
+		f.Applied = append(f.Applied, m)
+	}
+	setVersion(doc, config.CurrentVersion)
+
+	var buf bytes.Buffer
+	var fmtr tomledit.Formatter
+	if err := fmtr.Format(&buf, doc); err != nil {
+		return nil, fmt.Errorf("formatting TOML: %w", err)
+	}
+	f.After = buf.Bytes()
+	return f, nil
+}
+
+// docVersion returns the top-level version key of doc, or 0 when absent.
+func docVersion(doc *tomledit.Document) (int, error) {
+	if doc.Global == nil {
+		return 0, nil
+	}
+	for _, item := range doc.Global.Items {
+		kv, ok := item.(*parser.KeyValue)
+		if !ok || !kv.Name.Equals(parser.Key{"version"}) {
+			continue
+		}
+		v, err := strconv.Atoi(kv.Value.String())
+		if err != nil {
+			return 0, fmt.Errorf("version must be an integer, got %s", kv.Value)
+		}
+		return v, nil
+	}
+	return 0, nil
+}
+
+// setVersion sets the top-level version key of doc, adding it as the first
+// setting of the file when it is missing.
+func setVersion(doc *tomledit.Document, version int) {
+	value := parser.MustValue(strconv.Itoa(version))
+	if doc.Global == nil {
+		doc.Global = &tomledit.Section{}
+	}
+	for _, item := range doc.Global.Items {
+		if kv, ok := item.(*parser.KeyValue); ok && kv.Name.Equals(parser.Key{"version"}) {
+			kv.Value = value.WithComment(kv.Value.Trailer)
+			return
+		}
+	}
+
+	// Keep a leading header comment at the top of the file.
+	at := 0
+	if len(doc.Global.Items) > 0 {
+		if _, ok := doc.Global.Items[0].(parser.Comments); ok {
+			at = 1
+		}
+	}
+	kv := &parser.KeyValue{Name: parser.Key{"version"}, Value: value}
+	doc.Global.Items = slices.Insert(doc.Global.Items, at, parser.Item(kv))
+}
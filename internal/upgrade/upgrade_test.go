@@ -0,0 +1,93 @@
+package upgrade
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPlan(t *testing.T) {
+	dir := t.TempDir()
+	root := writeFile(t, dir, "vx.toml", "# Project secrets\n\nworkspaces = [\"api/vx.toml\"]\n\n[vault]\naddress = \"https://vault.example.com\"\n")
+	current := writeFile(t, dir, "current.toml", "version = 1\n\n[secrets]\nA = \"a\"\n")
+	ws := writeFile(t, dir, "ws.toml", "[secrets]\nA = \"a\" # shared\n")
+
+	files, err := Plan(root, []string{current, ws})
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Plan() returned %d files, want the root and ws.toml", len(files))
+	}
+
+	want := "# Project secrets\n\nversion = 1\nworkspaces = [\"api/vx.toml\"]\n\n[vault]\naddress = \"https://vault.example.com\"\n"
+	if got := string(files[0].After); got != want {
+		t.Errorf("root After =\n%s\nwant\n%s", got, want)
+	}
+	if !files[0].Root || files[0].From != 0 || len(files[0].Applied) != 1 {
+		t.Errorf("root plan = %+v", files[0])
+	}
+	if got := string(files[1].After); !strings.HasPrefix(got, "version = 1\n") || !strings.Contains(got, "# shared") {
+		t.Errorf("workspace After =\n%s", got)
+	}
+}
+
+func TestPlan_TooNew(t *testing.T) {
+	dir := t.TempDir()
+	root := writeFile(t, dir, "vx.toml", "version = 99\n")
+
+	if _, err := Plan(root, nil); err == nil || !strings.Contains(err.Error(), "upgrade vx") {
+		t.Errorf("Plan() error = %v, want a too-new error", err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	root := writeFile(t, dir, "vx.toml", "[secrets]\nA = \"a\"\n")
+
+	files, err := Plan(root, nil)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if err := Write(files); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	backup, err := os.ReadFile(filepath.Join(dir, "vx.toml.v0.bak"))
+	if err != nil || string(backup) != "[secrets]\nA = \"a\"\n" {
+		t.Errorf("backup = %q, %v; want the original contents", backup, err)
+	}
+	if data, _ := os.ReadFile(root); !strings.HasPrefix(string(data), "version = 1\n") {
+		t.Errorf("upgraded file =\n%s", data)
+	}
+
+	// Upgrading again finds nothing to do.
+	if files, err := Plan(root, nil); err != nil || len(files) != 0 {
+		t.Errorf("second Plan() = %v, %v; want nothing", files, err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	f := &File{
+		Path:   "vx.toml",
+		Before: []byte("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"),
+		After:  []byte("a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"),
+	}
+
+	want := "--- vx.toml\n+++ vx.toml (upgraded)\n" +
+		"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+		"@@ -8,3 +8,4 @@\n h\n i\n j\n+k\n"
+	if got := f.Diff("vx.toml"); got != want {
+		t.Errorf("Diff() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -1,17 +1,37 @@
 package vault
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// LoginInfo describes the token a login returned: what it may do and how
+// long it lives, as reported in the login response.
+type LoginInfo struct {
+	Accessor  string
+	Policies  []string
+	TTL       time.Duration
+	Renewable bool
+	// Orphan is set for tokens without a parent, which outlive the
+	// revocation of the token that created their credentials.
+	Orphan bool
+	// Metadata is the token's metadata, e.g. role_name for AppRole.
+	Metadata map[string]string
+}
 
 // AppRoleAuth authenticates to Vault using AppRole credentials. This is
 // intended for non-interactive environments such as CI pipelines and Docker
-// containers. On success the client's token is set to the newly obtained token.
-func AppRoleAuth(client *Client, roleID string, secretID string) error {
+// containers. On success the client's token is set to the newly obtained
+// token, which is described by the returned LoginInfo.
+func AppRoleAuth(client *Client, roleID string, secretID string) (*LoginInfo, error) {
 	if roleID == "" {
-		return fmt.Errorf("approle auth: role_id is required")
+		return nil, fmt.Errorf("approle auth: role_id is required")
 	}
 
 	if secretID == "" {
-		return fmt.Errorf("approle auth: secret_id is required")
+		return nil, fmt.Errorf("approle auth: secret_id is required")
 	}
 
 	data := map[string]interface{}{
@@ -21,14 +41,100 @@ func AppRoleAuth(client *Client, roleID string, secretID string) error {
 
 	secret, err := client.inner.Logical().Write("auth/approle/login", data)
 	if err != nil {
-		return fmt.Errorf("approle auth: %w", err)
+		return nil, fmt.Errorf("approle auth: %w", err)
 	}
 
 	if secret == nil || secret.Auth == nil {
-		return fmt.Errorf("approle auth: empty auth response")
+		return nil, fmt.Errorf("approle auth: empty auth response")
 	}
 
 	client.SetToken(secret.Auth.ClientToken)
 
+	policies := slices.Clone(secret.Auth.Policies)
+	slices.Sort(policies)
+	return &LoginInfo{
+		Accessor:  secret.Auth.Accessor,
+		Policies:  policies,
+		TTL:       time.Duration(secret.Auth.LeaseDuration) * time.Second,
+		Renewable: secret.Auth.Renewable,
+		Orphan:    secret.Auth.Orphan,
+		Metadata:  secret.Auth.Metadata,
+	}, nil
+}
+
+// SecretID is a newly generated AppRole secret-id.
+type SecretID struct {
+	SecretID string
+	Accessor string
+	TTL      time.Duration // zero means the secret-id does not expire
+}
+
+// GenerateSecretID creates a new secret-id for the given AppRole role. The
+// optional metadata is attached to the secret-id and appears in Vault's audit
+// log and in token metadata for logins made with it.
+func (c *Client) GenerateSecretID(role string, metadata map[string]string) (*SecretID, error) {
+	if role == "" {
+		return nil, fmt.Errorf("generating secret-id: role is required")
+	}
+
+	data := map[string]interface{}{}
+	if len(metadata) > 0 {
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			return nil, fmt.Errorf("generating secret-id: encoding metadata: %w", err)
+		}
+		data["metadata"] = string(encoded)
+	}
+
+	secret, err := c.inner.Logical().Write(appRoleRolePath(role, "secret-id"), data)
+	if err != nil {
+		if isPermissionDenied(err) {
+			return nil, fmt.Errorf("generating secret-id for role %q: permission denied: %w", role, err)
+		}
+		return nil, fmt.Errorf("generating secret-id for role %q: %w", role, err)
+	}
+
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("generating secret-id for role %q: empty response", role)
+	}
+
+	id := &SecretID{
+		SecretID: stringField(secret.Data, "secret_id"),
+		Accessor: stringField(secret.Data, "secret_id_accessor"),
+	}
+	if id.SecretID == "" {
+		return nil, fmt.Errorf("generating secret-id for role %q: response has no secret_id", role)
+	}
+
+	if ttl, ok := secret.Data["secret_id_ttl"].(json.Number); ok {
+		if secs, err := ttl.Int64(); err == nil {
+			id.TTL = time.Duration(secs) * time.Second
+		}
+	}
+
+	return id, nil
+}
+
+// DestroySecretID revokes a secret-id of the given role by its value.
+func (c *Client) DestroySecretID(role, secretID string) error {
+	data := map[string]interface{}{"secret_id": secretID}
+	if _, err := c.inner.Logical().Write(appRoleRolePath(role, "secret-id/destroy"), data); err != nil {
+		return fmt.Errorf("destroying secret-id for role %q: %w", role, err)
+	}
 	return nil
 }
+
+// DestroySecretIDAccessor revokes a secret-id of the given role by its
+// accessor, for when the secret-id value itself is not at hand.
+func (c *Client) DestroySecretIDAccessor(role, accessor string) error {
+	data := map[string]interface{}{"secret_id_accessor": accessor}
+	if _, err := c.inner.Logical().Write(appRoleRolePath(role, "secret-id-accessor/destroy"), data); err != nil {
+		return fmt.Errorf("destroying secret-id accessor %q for role %q: %w", accessor, role, err)
+	}
+	return nil
+}
+
+// appRoleRolePath returns the API path of an endpoint under an AppRole role.
+func appRoleRolePath(role, endpoint string) string {
+	return "auth/approle/role/" + role + "/" + endpoint
+}
@@ -1,7 +1,11 @@
 package vault
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestAppRoleAuth_EmptyRoleID(t *testing.T) {
@@ -10,7 +14,7 @@ func TestAppRoleAuth_EmptyRoleID(t *testing.T) {
 		t.Fatalf("unexpected error creating client: %v", err)
 	}
 
-	err = AppRoleAuth(client, "", "some-secret-id")
+	_, err = AppRoleAuth(client, "", "some-secret-id")
 	if err == nil {
 		t.Fatal("expected error for empty role_id, got nil")
 	}
@@ -22,7 +26,7 @@ func TestAppRoleAuth_EmptySecretID(t *testing.T) {
 		t.Fatalf("unexpected error creating client: %v", err)
 	}
 
-	err = AppRoleAuth(client, "some-role-id", "")
+	_, err = AppRoleAuth(client, "some-role-id", "")
 	if err == nil {
 		t.Fatal("expected error for empty secret_id, got nil")
 	}
@@ -35,8 +39,102 @@ func TestAppRoleAuth_NoServer(t *testing.T) {
 		t.Fatalf("unexpected error creating client: %v", err)
 	}
 
-	err = AppRoleAuth(client, "role-id", "secret-id")
+	_, err = AppRoleAuth(client, "role-id", "secret-id")
 	if err == nil {
 		t.Fatal("expected error for non-reachable server, got nil")
 	}
 }
+
+func TestAppRoleAuth_LoginInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"auth":{"client_token":"s.machine","accessor":"acc","policies":["deploy","default"],` +
+			`"metadata":{"role_name":"ci"},"orphan":true,"lease_duration":3600,"renewable":true}}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	info, err := AppRoleAuth(client, "role-id", "secret-id")
+	if err != nil {
+		t.Fatalf("AppRoleAuth() error = %v", err)
+	}
+	if client.Token() != "s.machine" {
+		t.Errorf("Token() = %q, want s.machine", client.Token())
+	}
+	if len(info.Policies) != 2 || info.Policies[0] != "default" || info.TTL != time.Hour ||
+		!info.Renewable || !info.Orphan || info.Metadata["role_name"] != "ci" {
+		t.Errorf("AppRoleAuth() = %+v", info)
+	}
+}
+
+func TestGenerateSecretID(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/auth/approle/role/ci/secret-id" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"data":{"secret_id":"new-sid","secret_id_accessor":"new-acc","secret_id_ttl":86400}}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.admin")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	id, err := client.GenerateSecretID("ci", map[string]string{"rotated_by": "vx"})
+	if err != nil {
+		t.Fatalf("GenerateSecretID() error = %v", err)
+	}
+	if id.SecretID != "new-sid" || id.Accessor != "new-acc" {
+		t.Errorf("GenerateSecretID() = %+v, want new-sid/new-acc", id)
+	}
+	if id.TTL != 24*time.Hour {
+		t.Errorf("TTL = %v, want 24h", id.TTL)
+	}
+	if body["metadata"] != `{"rotated_by":"vx"}` {
+		t.Errorf("metadata = %v, want JSON-encoded string", body["metadata"])
+	}
+
+	if _, err := client.GenerateSecretID("other", nil); err == nil {
+		t.Error("GenerateSecretID() expected permission error")
+	}
+}
+
+func TestDestroySecretID(t *testing.T) {
+	var paths []string
+	var bodies []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.admin")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	if err := client.DestroySecretID("ci", "old-sid"); err != nil {
+		t.Fatalf("DestroySecretID() error = %v", err)
+	}
+	if err := client.DestroySecretIDAccessor("ci", "old-acc"); err != nil {
+		t.Fatalf("DestroySecretIDAccessor() error = %v", err)
+	}
+
+	if paths[0] != "/v1/auth/approle/role/ci/secret-id/destroy" || bodies[0]["secret_id"] != "old-sid" {
+		t.Errorf("destroy request = %s %v", paths[0], bodies[0])
+	}
+	if paths[1] != "/v1/auth/approle/role/ci/secret-id-accessor/destroy" || bodies[1]["secret_id_accessor"] != "old-acc" {
+		t.Errorf("destroy accessor request = %s %v", paths[1], bodies[1])
+	}
+}
@@ -0,0 +1,46 @@
+package vault
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Capabilities returns the current token's capabilities on each of the given
+// KV paths, keyed by the path as passed in. Paths are relative to the
+// client's basePath mount and are checked at their data API path, so
+// "dev/database" with basePath "secret" asks about "secret/data/dev/database"
+// on KV v2 and "secret/dev/database" on KV v1.
+//
+// The token needs no access to the paths themselves; sys/capabilities-self is
+// available to every token by default.
+func (c *Client) Capabilities(kvPaths []string) (map[string][]string, error) {
+	apiPaths := make([]string, len(kvPaths))
+	for i, p := range kvPaths {
+		apiPaths[i] = c.dataPath(p)
+	}
+
+	secret, err := c.inner.Logical().Write("sys/capabilities-self", map[string]interface{}{
+		"paths": apiPaths,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checking token capabilities: %w", err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("checking token capabilities: empty response")
+	}
+
+	out := make(map[string][]string, len(kvPaths))
+	for i, p := range kvPaths {
+		out[p] = stringSliceField(secret.Data, apiPaths[i])
+	}
+	return out, nil
+}
+
+// CanRead reports whether a capability list allows reading. An explicit
+// "deny" overrides everything else.
+func CanRead(caps []string) bool {
+	if slices.Contains(caps, "deny") {
+		return false
+	}
+	return slices.Contains(caps, "read") || slices.Contains(caps, "root")
+}
@@ -0,0 +1,70 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	var body struct {
+		Paths []string `json:"paths"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/sys/capabilities-self" || r.Method != http.MethodPut && r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"data":{
+			"capabilities": ["read"],
+			"secret/data/dev/database": ["read", "list"],
+			"secret/data/prod/database": ["deny"]
+		}}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	caps, err := client.Capabilities([]string{"dev/database", "prod/database"})
+	if err != nil {
+		t.Fatalf("Capabilities() error = %v", err)
+	}
+
+	wantPaths := []string{"secret/data/dev/database", "secret/data/prod/database"}
+	if !reflect.DeepEqual(body.Paths, wantPaths) {
+		t.Errorf("request paths = %v, want %v", body.Paths, wantPaths)
+	}
+	if got := caps["dev/database"]; !reflect.DeepEqual(got, []string{"list", "read"}) {
+		t.Errorf("caps[dev/database] = %v, want [list read]", got)
+	}
+	if got := caps["prod/database"]; !reflect.DeepEqual(got, []string{"deny"}) {
+		t.Errorf("caps[prod/database] = %v, want [deny]", got)
+	}
+}
+
+func TestCanRead(t *testing.T) {
+	tests := []struct {
+		caps []string
+		want bool
+	}{
+		{[]string{"read"}, true},
+		{[]string{"create", "read", "update"}, true},
+		{[]string{"root"}, true},
+		{[]string{"list"}, false},
+		{[]string{"deny"}, false},
+		{[]string{"read", "deny"}, false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanRead(tt.caps); got != tt.want {
+			t.Errorf("CanRead(%v) = %v, want %v", tt.caps, got, tt.want)
+		}
+	}
+}
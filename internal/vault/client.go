@@ -2,22 +2,79 @@ package vault
 
 import (
 	"fmt"
+	"net/http"
+	"sync"
 	"time"
 
 	vaultapi "github.com/hashicorp/vault/api"
 )
 
 // Client wraps the official HashiCorp Vault API client with a configured
-// base path for KV v2 secret reads.
+// base path for KV secret reads.
 type Client struct {
 	inner    *vaultapi.Client
 	basePath string
+	// kvVersion is the KV engine version of basePath, 0 until KVVersion
+	// detects it.
+	kvVersion int
+	kvOnce    sync.Once
+}
+
+// ClientOption configures a Client at construction time.
+type ClientOption func(*Client)
+
+// WithHeaders adds the given HTTP headers to every request the client sends.
+// Nil or empty headers are ignored.
+func WithHeaders(h http.Header) ClientOption {
+	return func(c *Client) {
+		for name, values := range h {
+			for _, v := range values {
+				c.inner.AddHeader(name, v)
+			}
+		}
+	}
+}
+
+// WithRateLimit caps every request the client sends at rps per second,
+// allowing bursts of up to burst requests (at least 1). Requests wait for
+// their turn instead of failing. Values of rps less than or equal to zero
+// are ignored.
+func WithRateLimit(rps float64, burst int) ClientOption {
+	return func(c *Client) {
+		if rps > 0 {
+			c.inner.SetLimiter(rps, max(burst, 1))
+		}
+	}
+}
+
+// WithMaxRetries sets how often the client itself retries a request that
+// failed with a 5xx or 429 response or could not connect, overriding
+// VAULT_MAX_RETRIES and the API client's default of 2. Zero disables these
+// retries, for callers that retry on their own. Negative values are ignored.
+func WithMaxRetries(n int) ClientOption {
+	return func(c *Client) {
+		if n >= 0 {
+			c.inner.SetMaxRetries(n)
+		}
+	}
+}
+
+// WithNamespace sends every request to the given Vault Enterprise namespace
+// (the X-Vault-Namespace header), overriding VAULT_NAMESPACE. An empty
+// namespace is ignored.
+func WithNamespace(ns string) ClientOption {
+	return func(c *Client) {
+		if ns != "" {
+			c.inner.SetNamespace(ns)
+		}
+	}
 }
 
 // NewClient creates a new Vault API client pointed at the given address.
-// The basePath is the KV v2 mount point (e.g. "secret").
+// The basePath is the KV mount point (e.g. "secret"); whether it is KV v1 or
+// v2 is detected on first use unless set with WithKVVersion.
 // The client starts unauthenticated — use SetToken or an auth method to set a token.
-func NewClient(address string, basePath string) (*Client, error) {
+func NewClient(address string, basePath string, opts ...ClientOption) (*Client, error) {
 	if address == "" {
 		return nil, fmt.Errorf("vault address is required")
 	}
@@ -34,15 +91,21 @@ func NewClient(address string, basePath string) (*Client, error) {
 	// We manage tokens explicitly via ~/.vx/token or auth methods.
 	inner.ClearToken()
 
-	return &Client{
+	client := &Client{
 		inner:    inner,
 		basePath: basePath,
-	}, nil
+	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client, nil
 }
 
 // NewClientWithToken creates a new Vault API client with an existing auth token.
-func NewClientWithToken(address string, basePath string, token string) (*Client, error) {
-	client, err := NewClient(address, basePath)
+func NewClientWithToken(address string, basePath string, token string, opts ...ClientOption) (*Client, error) {
+	client, err := NewClient(address, basePath, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -81,6 +144,14 @@ func (c *Client) TokenTTL() (time.Duration, error) {
 	return ttl, nil
 }
 
+// RevokeSelf revokes the client's current token.
+func (c *Client) RevokeSelf() error {
+	if err := c.inner.Auth().Token().RevokeSelf(""); err != nil {
+		return fmt.Errorf("revoking token: %w", err)
+	}
+	return nil
+}
+
 // IsAuthenticated reports whether the client has a token that has not expired.
 // Returns false if no token is set or if the token lookup fails.
 func (c *Client) IsAuthenticated() bool {
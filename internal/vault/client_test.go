@@ -110,3 +110,54 @@ func TestIsAuthenticated_InvalidToken(t *testing.T) {
 		t.Error("IsAuthenticated() = true, want false for invalid token")
 	}
 }
+
+func TestWithNamespace(t *testing.T) {
+	client, err := NewClient("http://127.0.0.1:8200", "secret", WithNamespace("team-a"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := client.inner.Namespace(); got != "team-a" {
+		t.Errorf("Namespace() = %q, want %q", got, "team-a")
+	}
+}
+
+func TestWithMaxRetries(t *testing.T) {
+	client, err := NewClient("http://127.0.0.1:8200", "secret", WithMaxRetries(0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := client.inner.MaxRetries(); got != 0 {
+		t.Errorf("MaxRetries() = %d, want 0", got)
+	}
+
+	client, err = NewClient("http://127.0.0.1:8200", "secret", WithMaxRetries(-1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := client.inner.MaxRetries(); got == 0 {
+		t.Error("expected the default retries for a negative count")
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	client, err := NewClient("http://127.0.0.1:8200", "secret", WithRateLimit(20, 3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	limiter := client.inner.Limiter()
+	if limiter == nil {
+		t.Fatal("expected a rate limiter")
+	}
+	if limiter.Limit() != 20 || limiter.Burst() != 3 {
+		t.Errorf("limiter = %v/%d, want 20/3", limiter.Limit(), limiter.Burst())
+	}
+
+	client, err = NewClient("http://127.0.0.1:8200", "secret", WithRateLimit(0, 3))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.inner.Limiter() != nil {
+		t.Error("expected no rate limiter for a zero rate")
+	}
+}
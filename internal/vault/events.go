@@ -0,0 +1,162 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// kvEventType subscribes to every KV v2 event: writes, patches, deletes,
+// destroys, and metadata changes.
+const kvEventType = "kv-v2/*"
+
+// maxEventSize bounds a single event message, so a misbehaving server
+// cannot make the subscriber buffer without limit.
+const maxEventSize = 1 << 20
+
+// ErrEventsUnsupported is returned by WatchKV when the server has no event
+// system: Vault before 1.16, or a server where events are disabled.
+var ErrEventsUnsupported = errors.New("vault events are not supported by this server")
+
+// KVChange is a modification of a secret under the client's mount.
+type KVChange struct {
+	// Path is relative to the mount, like the paths ReadKV takes.
+	Path      string
+	Operation string
+	Version   int
+	Time      time.Time
+}
+
+// eventMessage is the part of Vault's CloudEvents JSON that WatchKV uses.
+type eventMessage struct {
+	Time time.Time `json:"time"`
+	Data struct {
+		Event struct {
+			Metadata struct {
+				Path           string `json:"path"`
+				DataPath       string `json:"data_path"`
+				Operation      string `json:"operation"`
+				CurrentVersion string `json:"current_version"`
+			} `json:"metadata"`
+		} `json:"event"`
+		PluginInfo struct {
+			MountPath string `json:"mount_path"`
+		} `json:"plugin_info"`
+	} `json:"data"`
+}
+
+// WatchKV subscribes to Vault's event stream and calls fn for every change
+// to a secret under the client's mount, until ctx is done or the connection
+// fails. It returns ErrEventsUnsupported when the server cannot stream
+// events or the mount is KV v1, and nil when ctx ends the subscription.
+//
+// The token needs "read" on sys/events/subscribe/kv-v2/* and "list" and
+// "subscribe" on the watched paths.
+func (c *Client) WatchKV(ctx context.Context, fn func(KVChange)) error {
+	if c.KVVersion() == 1 {
+		return ErrEventsUnsupported
+	}
+
+	header := c.inner.Headers()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("X-Vault-Token", c.inner.Token())
+	if ns := c.inner.Namespace(); ns != "" {
+		header.Set("X-Vault-Namespace", ns)
+	}
+
+	endpoint := strings.TrimSuffix(c.inner.Address(), "/") + "/v1/sys/events/subscribe/" + kvEventType + "?json=true"
+
+	ws, resp, err := websocket.Dial(ctx, endpoint, &websocket.DialOptions{
+		HTTPClient: c.eventsHTTPClient(),
+		HTTPHeader: header,
+	})
+	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed) {
+			return ErrEventsUnsupported
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("subscribing to vault events: %w", err)
+	}
+	defer ws.CloseNow()
+	ws.SetReadLimit(maxEventSize)
+
+	for {
+		// Read answers pings itself and returns once ctx is done.
+		_, msg, err := ws.Read(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("reading vault events: %w", err)
+		}
+
+		if change, ok := parseKVChange(msg, c.basePath); ok {
+			fn(change)
+		}
+	}
+}
+
+// eventsHTTPClient returns a copy of the API client's HTTP client, so the
+// event stream goes through the same proxy and trusts the same CAs as every
+// other request. The API client handles 307 redirects from standby nodes
+// itself and stops net/http from following them; the copy follows them, as
+// the handshake is a plain GET that can be replayed.
+func (c *Client) eventsHTTPClient() *http.Client {
+	cfg := c.inner.CloneConfig()
+	if cfg == nil || cfg.HttpClient == nil {
+		return nil
+	}
+	hc := *cfg.HttpClient
+	hc.CheckRedirect = nil
+	return &hc
+}
+
+// parseKVChange decodes an event message and reports whether it concerns a
+// secret under the mount basePath.
+func parseKVChange(msg []byte, basePath string) (KVChange, bool) {
+	var ev eventMessage
+	if err := json.Unmarshal(msg, &ev); err != nil {
+		return KVChange{}, false
+	}
+
+	mount := strings.Trim(ev.Data.PluginInfo.MountPath, "/")
+	if mount == "" || mount != strings.Trim(basePath, "/") {
+		return KVChange{}, false
+	}
+
+	meta := ev.Data.Event.Metadata
+	p := meta.Path
+	if p == "" {
+		p = meta.DataPath
+	}
+
+	// Paths look like "<mount>/data/<path>" or "<mount>/metadata/<path>";
+	// drop the mount and the API segment.
+	rest, ok := strings.CutPrefix(p, mount+"/")
+	if !ok {
+		return KVChange{}, false
+	}
+	_, rel, ok := strings.Cut(rest, "/")
+	if !ok || rel == "" {
+		return KVChange{}, false
+	}
+
+	version, _ := strconv.Atoi(meta.CurrentVersion)
+	return KVChange{
+		Path:      rel,
+		Operation: meta.Operation,
+		Version:   version,
+		Time:      ev.Time,
+	}, true
+}
@@ -0,0 +1,184 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+const testEvent = `{
+  "time": "2026-01-02T03:04:05Z",
+  "data": {
+    "event": {"metadata": {"path": "secret/data/dev/database", "operation": "data-write", "current_version": "3"}},
+    "event_type": "kv-v2/data-write",
+    "plugin_info": {"mount_path": "secret/"}
+  }
+}`
+
+const otherMountEvent = `{
+  "data": {
+    "event": {"metadata": {"path": "other/data/dev/database", "operation": "data-write"}},
+    "plugin_info": {"mount_path": "other/"}
+  }
+}`
+
+// eventServer accepts the subscription request and runs send on the
+// connection, then keeps it open until the test ends.
+func eventServer(t *testing.T, send func(context.Context, *websocket.Conn) error) *httptest.Server {
+	t.Helper()
+	done := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/sys/events/subscribe/kv-v2/*" || r.URL.Query().Get("json") != "true" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("X-Vault-Token") != "s.test" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			t.Errorf("accept: %v", err)
+			return
+		}
+		defer conn.CloseNow()
+
+		if err := send(r.Context(), conn); err != nil {
+			t.Errorf("send: %v", err)
+			return
+		}
+
+		<-done
+	}))
+	t.Cleanup(func() {
+		close(done)
+		srv.Close()
+	})
+	return srv
+}
+
+// sendEvents writes each event as a text message.
+func sendEvents(events ...string) func(context.Context, *websocket.Conn) error {
+	return func(ctx context.Context, conn *websocket.Conn) error {
+		for _, ev := range events {
+			if err := conn.Write(ctx, websocket.MessageText, []byte(ev)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// watchOne runs WatchKV against addr until the first change arrives.
+func watchOne(t *testing.T, addr string) []KVChange {
+	t.Helper()
+
+	client, err := NewClientWithToken(addr, "secret", "s.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var got []KVChange
+	err = client.WatchKV(ctx, func(c KVChange) {
+		got = append(got, c)
+		cancel()
+	})
+	if err != nil {
+		t.Fatalf("WatchKV() error = %v", err)
+	}
+	return got
+}
+
+var testChange = KVChange{
+	Path:      "dev/database",
+	Operation: "data-write",
+	Version:   3,
+	Time:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+}
+
+func TestWatchKV(t *testing.T) {
+	srv := eventServer(t, sendEvents(otherMountEvent, testEvent))
+
+	got := watchOne(t, srv.URL)
+	if len(got) != 1 || got[0] != testChange {
+		t.Errorf("changes = %+v, want [%+v]", got, testChange)
+	}
+}
+
+func TestWatchKV_Redirect(t *testing.T) {
+	// A standby node answers with a 307 to the active one.
+	active := eventServer(t, sendEvents(testEvent))
+	standby := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, active.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	defer standby.Close()
+
+	got := watchOne(t, standby.URL)
+	if len(got) != 1 || got[0] != testChange {
+		t.Errorf("changes = %+v, want [%+v]", got, testChange)
+	}
+}
+
+func TestWatchKV_Unsupported(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.WatchKV(context.Background(), func(KVChange) {})
+	if !errors.Is(err, ErrEventsUnsupported) {
+		t.Errorf("WatchKV() error = %v, want ErrEventsUnsupported", err)
+	}
+}
+
+func TestWatchKV_ServerClose(t *testing.T) {
+	srv := eventServer(t, func(_ context.Context, conn *websocket.Conn) error {
+		return conn.Close(websocket.StatusNormalClosure, "")
+	})
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.WatchKV(context.Background(), func(KVChange) {}); err == nil {
+		t.Error("WatchKV() expected error when the server closes the stream")
+	}
+}
+
+func TestParseKVChange(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want KVChange
+		ok   bool
+	}{
+		{"data write", testEvent, KVChange{Path: "dev/database", Operation: "data-write", Version: 3, Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}, true},
+		{"metadata delete", `{"data":{"event":{"metadata":{"path":"secret/metadata/dev/api","operation":"metadata-delete"}},"plugin_info":{"mount_path":"secret/"}}}`, KVChange{Path: "dev/api", Operation: "metadata-delete"}, true},
+		{"other mount", otherMountEvent, KVChange{}, false},
+		{"no path", `{"data":{"plugin_info":{"mount_path":"secret/"}}}`, KVChange{}, false},
+		{"not json", `nope`, KVChange{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseKVChange([]byte(tt.msg), "secret")
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("parseKVChange() = %+v, %v; want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
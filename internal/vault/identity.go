@@ -0,0 +1,183 @@
+package vault
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// TokenInfo describes the token the client is authenticated with, as
+// reported by auth/token/lookup-self.
+type TokenInfo struct {
+	DisplayName      string
+	EntityID         string
+	Accessor         string
+	AuthPath         string // e.g. "auth/oidc/oidc/callback"
+	Policies         []string
+	IdentityPolicies []string
+	TTL              time.Duration
+	ExpireTime       time.Time
+	Renewable        bool
+	Meta             map[string]string
+}
+
+// EntityAlias is a single identity alias attached to an entity, such as the
+// OIDC login that created it.
+type EntityAlias struct {
+	Name      string
+	MountType string
+	MountPath string
+	Metadata  map[string]string
+}
+
+// EntityInfo describes an identity entity as returned by
+// identity/entity/id/:id.
+type EntityInfo struct {
+	ID       string
+	Name     string
+	Metadata map[string]string
+	Aliases  []EntityAlias
+}
+
+// LookupSelf returns details about the client's current token.
+func (c *Client) LookupSelf() (*TokenInfo, error) {
+	secret, err := c.inner.Auth().Token().LookupSelf()
+	if err != nil {
+		return nil, fmt.Errorf("looking up token: %w", err)
+	}
+
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("looking up token: empty response")
+	}
+
+	return parseTokenInfo(secret)
+}
+
+// LookupEntity reads an identity entity by ID. Reading entities requires a
+// policy granting read on identity/entity/id/*, which many users lack; callers
+// should treat errors as "not available" rather than fatal.
+func (c *Client) LookupEntity(id string) (*EntityInfo, error) {
+	if id == "" {
+		return nil, fmt.Errorf("looking up entity: id is required")
+	}
+
+	secret, err := c.inner.Logical().Read("identity/entity/id/" + id)
+	if err != nil {
+		if isPermissionDenied(err) {
+			return nil, fmt.Errorf("looking up entity %q: permission denied: %w", id, err)
+		}
+		return nil, fmt.Errorf("looking up entity %q: %w", id, err)
+	}
+
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("looking up entity %q: not found", id)
+	}
+
+	return parseEntityInfo(secret.Data), nil
+}
+
+// parseTokenInfo extracts TokenInfo from a lookup-self response.
+func parseTokenInfo(secret *vaultapi.Secret) (*TokenInfo, error) {
+	ttl, err := secret.TokenTTL()
+	if err != nil {
+		return nil, fmt.Errorf("parsing token TTL: %w", err)
+	}
+
+	accessor, err := secret.TokenAccessor()
+	if err != nil {
+		return nil, fmt.Errorf("parsing token accessor: %w", err)
+	}
+
+	renewable, err := secret.TokenIsRenewable()
+	if err != nil {
+		return nil, fmt.Errorf("parsing token renewable flag: %w", err)
+	}
+
+	info := &TokenInfo{
+		DisplayName:      stringField(secret.Data, "display_name"),
+		EntityID:         stringField(secret.Data, "entity_id"),
+		Accessor:         accessor,
+		AuthPath:         stringField(secret.Data, "path"),
+		Policies:         stringSliceField(secret.Data, "policies"),
+		IdentityPolicies: stringSliceField(secret.Data, "identity_policies"),
+		TTL:              ttl,
+		Renewable:        renewable,
+		Meta:             stringMapField(secret.Data, "meta"),
+	}
+
+	if exp := stringField(secret.Data, "expire_time"); exp != "" {
+		if t, err := time.Parse(time.RFC3339Nano, exp); err == nil {
+			info.ExpireTime = t
+		}
+	}
+
+	return info, nil
+}
+
+// parseEntityInfo extracts EntityInfo from an identity entity response.
+func parseEntityInfo(data map[string]interface{}) *EntityInfo {
+	info := &EntityInfo{
+		ID:       stringField(data, "id"),
+		Name:     stringField(data, "name"),
+		Metadata: stringMapField(data, "metadata"),
+	}
+
+	aliases, _ := data["aliases"].([]interface{})
+	for _, raw := range aliases {
+		alias, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		info.Aliases = append(info.Aliases, EntityAlias{
+			Name:      stringField(alias, "name"),
+			MountType: stringField(alias, "mount_type"),
+			MountPath: stringField(alias, "mount_path"),
+			Metadata:  stringMapField(alias, "metadata"),
+		})
+	}
+
+	return info
+}
+
+// stringField returns data[key] if it is a string, or "".
+func stringField(data map[string]interface{}, key string) string {
+	s, _ := data[key].(string)
+	return s
+}
+
+// stringSliceField returns the string elements of data[key] in sorted order.
+func stringSliceField(data map[string]interface{}, key string) []string {
+	raw, ok := data[key].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	result := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		}
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// stringMapField returns the string values of the map at data[key].
+func stringMapField(data map[string]interface{}, key string) map[string]string {
+	raw, ok := data[key].(map[string]interface{})
+	if !ok {
+		return map[string]string{}
+	}
+
+	result := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s, ok := v.(string); ok {
+			result[k] = s
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,130 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newIdentityServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"display_name":      "oidc-jane@example.com",
+					"entity_id":         "ent-123",
+					"accessor":          "acc-456",
+					"path":              "auth/oidc/oidc/callback",
+					"policies":          []string{"default", "dev-read"},
+					"identity_policies": []string{"team-b", "team-a"},
+					"ttl":               3600,
+					"renewable":         true,
+					"expire_time":       "2030-01-02T03:04:05Z",
+					"meta": map[string]interface{}{
+						"role":  "admin",
+						"email": "jane@example.com",
+					},
+				},
+			})
+		case "/v1/identity/entity/id/ent-123":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"id":   "ent-123",
+					"name": "jane",
+					"aliases": []interface{}{
+						map[string]interface{}{
+							"name":       "jane@example.com",
+							"mount_type": "oidc",
+							"mount_path": "auth/oidc/",
+							"metadata":   map[string]interface{}{"groups": "eng"},
+						},
+					},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+		}
+	}))
+}
+
+func TestLookupSelf(t *testing.T) {
+	srv := newIdentityServer(t)
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	info, err := client.LookupSelf()
+	if err != nil {
+		t.Fatalf("LookupSelf() error = %v", err)
+	}
+
+	if info.DisplayName != "oidc-jane@example.com" {
+		t.Errorf("DisplayName = %q", info.DisplayName)
+	}
+	if info.EntityID != "ent-123" {
+		t.Errorf("EntityID = %q", info.EntityID)
+	}
+	if info.Accessor != "acc-456" {
+		t.Errorf("Accessor = %q", info.Accessor)
+	}
+	if info.TTL != time.Hour {
+		t.Errorf("TTL = %s, want 1h", info.TTL)
+	}
+	if len(info.Policies) != 2 || info.Policies[1] != "dev-read" {
+		t.Errorf("Policies = %v", info.Policies)
+	}
+	if len(info.IdentityPolicies) != 2 || info.IdentityPolicies[0] != "team-a" {
+		t.Errorf("IdentityPolicies = %v, want sorted [team-a team-b]", info.IdentityPolicies)
+	}
+	if info.Meta["email"] != "jane@example.com" {
+		t.Errorf("Meta[email] = %q", info.Meta["email"])
+	}
+	if info.ExpireTime.Year() != 2030 {
+		t.Errorf("ExpireTime = %s", info.ExpireTime)
+	}
+}
+
+func TestLookupEntity(t *testing.T) {
+	srv := newIdentityServer(t)
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	entity, err := client.LookupEntity("ent-123")
+	if err != nil {
+		t.Fatalf("LookupEntity() error = %v", err)
+	}
+
+	if entity.Name != "jane" {
+		t.Errorf("Name = %q, want jane", entity.Name)
+	}
+	if len(entity.Aliases) != 1 || entity.Aliases[0].Metadata["groups"] != "eng" {
+		t.Errorf("Aliases = %+v", entity.Aliases)
+	}
+}
+
+func TestLookupEntity_PermissionDenied(t *testing.T) {
+	srv := newIdentityServer(t)
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	if _, err := client.LookupEntity("other"); err == nil {
+		t.Fatal("expected error for forbidden entity")
+	}
+}
@@ -0,0 +1,51 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ServiceAccountTokenPath is where Kubernetes mounts a pod's service account
+// token.
+const ServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// DefaultKubernetesMount is the mount path of the Kubernetes auth method
+// when none is given.
+const DefaultKubernetesMount = "kubernetes"
+
+// KubernetesAuth authenticates to Vault with a Kubernetes service account
+// JWT, so vx can run in pods without OIDC or AppRole credentials. mount is
+// the auth method's mount path ("kubernetes" when empty). On success the
+// client's token is set to the newly obtained token.
+func KubernetesAuth(client *Client, mount, role, jwt string) error {
+	if role == "" {
+		return fmt.Errorf("kubernetes auth: role is required")
+	}
+
+	jwt = strings.TrimSpace(jwt)
+	if jwt == "" {
+		return fmt.Errorf("kubernetes auth: service account token is empty")
+	}
+
+	if mount == "" {
+		mount = DefaultKubernetesMount
+	}
+
+	data := map[string]interface{}{
+		"role": role,
+		"jwt":  jwt,
+	}
+
+	secret, err := client.inner.Logical().Write("auth/"+mount+"/login", data)
+	if err != nil {
+		return fmt.Errorf("kubernetes auth: %w", err)
+	}
+
+	if secret == nil || secret.Auth == nil {
+		return fmt.Errorf("kubernetes auth: empty auth response")
+	}
+
+	client.SetToken(secret.Auth.ClientToken)
+
+	return nil
+}
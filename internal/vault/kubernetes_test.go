@@ -0,0 +1,50 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKubernetesAuth(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/auth/k8s-prod/login" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"auth":{"client_token":"s.k8s"}}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL, "secret")
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	if err := KubernetesAuth(client, "k8s-prod", "api", "eyJhbGciOi.jwt\n"); err != nil {
+		t.Fatalf("KubernetesAuth() error = %v", err)
+	}
+	if client.Token() != "s.k8s" {
+		t.Errorf("token = %q, want %q", client.Token(), "s.k8s")
+	}
+	if body["role"] != "api" || body["jwt"] != "eyJhbGciOi.jwt" {
+		t.Errorf("login body = %v, want role and trimmed jwt", body)
+	}
+}
+
+func TestKubernetesAuth_MissingInputs(t *testing.T) {
+	client, err := NewClient("http://127.0.0.1:1", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	if err := KubernetesAuth(client, "", "", "jwt"); err == nil {
+		t.Error("expected error for empty role, got nil")
+	}
+	if err := KubernetesAuth(client, "", "api", " \n"); err == nil {
+		t.Error("expected error for empty jwt, got nil")
+	}
+}
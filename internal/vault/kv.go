@@ -1,24 +1,31 @@
 package vault
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"path"
+	"sort"
+	"strconv"
 
 	vaultapi "github.com/hashicorp/vault/api"
 )
 
-// ReadKV reads all key-value pairs at the given KV v2 path. The path is
+// ReadKV reads all key-value pairs at the given KV path. The path is
 // relative to the client's basePath mount. For example, with basePath "secret"
-// and path "dev/database", the full API path is "secret/data/dev/database".
+// and path "dev/database", the full API path is "secret/data/dev/database" on
+// KV v2 and "secret/dev/database" on KV v1.
 //
 // Returns an empty map when the path does not exist (404).
-// Returns a wrapped error on permission denied or other failures.
-func (c *Client) ReadKV(kvPath string) (map[string]string, error) {
-	fullPath := buildKV2Path(c.basePath, kvPath)
+// Returns a wrapped error on permission denied or other failures, including
+// ctx being cancelled or reaching its deadline.
+func (c *Client) ReadKV(ctx context.Context, kvPath string) (map[string]string, error) {
+	fullPath := c.dataPath(kvPath)
 
-	secret, err := c.inner.Logical().Read(fullPath)
+	secret, err := c.inner.Logical().ReadWithContext(ctx, fullPath)
 	if err != nil {
 		if isPermissionDenied(err) {
 			return nil, fmt.Errorf("reading KV path %q: permission denied: %w", kvPath, err)
@@ -30,9 +37,203 @@ func (c *Client) ReadKV(kvPath string) (map[string]string, error) {
 		return make(map[string]string), nil
 	}
 
+	if c.KVVersion() == 1 {
+		return stringValues(secret.Data), nil
+	}
+	return extractKV2Data(secret.Data, kvPath)
+}
+
+// ReadKVVersion reads the key-value pairs of one version of the KV v2 secret
+// at kvPath, relative to the client's basePath mount. A version that was
+// deleted, destroyed, or never written is an error. KV v1 keeps no versions
+// and returns ErrKVv1.
+func (c *Client) ReadKVVersion(ctx context.Context, kvPath string, version int) (map[string]string, error) {
+	if c.KVVersion() == 1 {
+		return nil, fmt.Errorf("reading version %d of %q: %w", version, kvPath, ErrKVv1)
+	}
+
+	query := map[string][]string{"version": {strconv.Itoa(version)}}
+	secret, err := c.inner.Logical().ReadWithDataWithContext(ctx, c.dataPath(kvPath), query)
+	if err != nil {
+		if isPermissionDenied(err) {
+			return nil, fmt.Errorf("reading version %d of %q: permission denied: %w", version, kvPath, err)
+		}
+		return nil, fmt.Errorf("reading version %d of %q: %w", version, kvPath, err)
+	}
+
+	// A deleted or destroyed version comes back with its metadata only.
+	if secret == nil || secret.Data == nil || secret.Data["data"] == nil {
+		return nil, fmt.Errorf("reading version %d of %q: not found, deleted, or destroyed", version, kvPath)
+	}
 	return extractKV2Data(secret.Data, kvPath)
 }
 
+// CopyKV copies the latest version of the secret at src to dst, both relative
+// to the client's basePath mount. Values are copied as-is, including
+// non-string ones. The write uses check-and-set with version 0, so it fails
+// instead of overwriting when dst already exists. KV v1 has no
+// check-and-set, so there dst is read first instead, which cannot catch a
+// secret created between the read and the write.
+func (c *Client) CopyKV(src, dst string) error {
+	data, _, err := c.ReadKVData(src)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return fmt.Errorf("reading KV path %q: not found", src)
+	}
+
+	var body map[string]interface{}
+	if c.KVVersion() == 1 {
+		existing, _, err := c.ReadKVData(dst)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return fmt.Errorf("writing KV path %q: a secret already exists there", dst)
+		}
+		body = data
+	} else {
+		body = map[string]interface{}{
+			"data":    data,
+			"options": map[string]interface{}{"cas": 0},
+		}
+	}
+	if _, err := c.inner.Logical().Write(c.dataPath(dst), body); err != nil {
+		if isPermissionDenied(err) {
+			return fmt.Errorf("writing KV path %q: permission denied: %w", dst, err)
+		}
+		return fmt.Errorf("writing KV path %q: %w", dst, err)
+	}
+
+	return nil
+}
+
+// ReadKVData returns the data of the latest version of the secret at kvPath
+// exactly as stored, including non-string values, along with that version's
+// number. A missing secret returns nil data and version 0; a secret whose
+// latest version was deleted returns nil data and the deleted version. KV v1
+// secrets have no versions and always return version 0.
+func (c *Client) ReadKVData(kvPath string) (map[string]interface{}, int, error) {
+	secret, err := c.inner.Logical().Read(c.dataPath(kvPath))
+	if err != nil {
+		if isPermissionDenied(err) {
+			return nil, 0, fmt.Errorf("reading KV path %q: permission denied: %w", kvPath, err)
+		}
+		return nil, 0, fmt.Errorf("reading KV path %q: %w", kvPath, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, 0, nil
+	}
+	if c.KVVersion() == 1 {
+		return secret.Data, 0, nil
+	}
+
+	var version int
+	if meta, ok := secret.Data["metadata"].(map[string]interface{}); ok {
+		if n, ok := meta["version"].(json.Number); ok {
+			if v, err := n.Int64(); err == nil {
+				version = int(v)
+			}
+		}
+	}
+
+	data, _ := secret.Data["data"].(map[string]interface{})
+	return data, version, nil
+}
+
+// WriteKV replaces the secret at kvPath with data, creating a new version.
+// The write uses check-and-set with cas, the version the caller last read:
+// 0 requires that the secret does not exist yet, so a concurrent change
+// makes the write fail instead of being overwritten. KV v1 has no
+// check-and-set; there cas is ignored and the secret is overwritten.
+func (c *Client) WriteKV(kvPath string, data map[string]interface{}, cas int) error {
+	body := data
+	if c.KVVersion() == 2 {
+		body = map[string]interface{}{
+			"data":    data,
+			"options": map[string]interface{}{"cas": cas},
+		}
+	}
+	if _, err := c.inner.Logical().Write(c.dataPath(kvPath), body); err != nil {
+		if isPermissionDenied(err) {
+			return fmt.Errorf("writing KV path %q: permission denied: %w", kvPath, err)
+		}
+		return fmt.Errorf("writing KV path %q: %w", kvPath, err)
+	}
+	return nil
+}
+
+// PatchKV merges values into the latest version of the secret at kvPath,
+// relative to the client's basePath mount. Keys not named in values are left
+// untouched, and Vault applies the merge server-side, so concurrent updates
+// to other keys of the same secret are not lost the way a read-modify-write
+// would lose them.
+//
+// When the secret does not exist yet it is created with check-and-set version
+// 0, so a secret created concurrently by someone else is not overwritten.
+// Patching requires the "patch" capability on the data path.
+//
+// KV v1 cannot patch, so there the secret is read, merged, and written back,
+// which loses updates other clients make in between.
+func (c *Client) PatchKV(kvPath string, values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	data := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		data[k] = v
+	}
+
+	if c.KVVersion() == 1 {
+		existing, _, err := c.ReadKVData(kvPath)
+		if err != nil {
+			return err
+		}
+		for k, v := range existing {
+			if _, ok := data[k]; !ok {
+				data[k] = v
+			}
+		}
+		return c.WriteKV(kvPath, data, 0)
+	}
+
+	fullPath := c.dataPath(kvPath)
+
+	_, err := c.inner.Logical().JSONMergePatch(context.Background(), fullPath, map[string]interface{}{"data": data})
+	if isNotFound(err) {
+		body := map[string]interface{}{
+			"data":    data,
+			"options": map[string]interface{}{"cas": 0},
+		}
+		_, err = c.inner.Logical().Write(fullPath, body)
+	}
+	if err != nil {
+		if isPermissionDenied(err) {
+			return fmt.Errorf("patching KV path %q: permission denied: %w", kvPath, err)
+		}
+		return fmt.Errorf("patching KV path %q: %w", kvPath, err)
+	}
+
+	return nil
+}
+
+// DestroyKV permanently deletes the secret at kvPath, relative to the
+// client's basePath mount, with every version and its metadata, so nothing
+// is left to undelete. This needs "delete" on {basePath}/metadata/* (on KV
+// v1, on the secret's path). Destroying a missing secret is not an error.
+func (c *Client) DestroyKV(kvPath string) error {
+	fullPath := c.listPath(kvPath)
+	if _, err := c.inner.Logical().Delete(fullPath); err != nil {
+		if isPermissionDenied(err) {
+			return fmt.Errorf("destroying KV path %q: permission denied: %w", kvPath, err)
+		}
+		return fmt.Errorf("destroying KV path %q: %w", kvPath, err)
+	}
+	return nil
+}
+
 // buildKV2Path constructs the full KV v2 API path by inserting "data" between
 // the mount point and the secret path.
 func buildKV2Path(basePath string, kvPath string) string {
@@ -52,16 +253,20 @@ func extractKV2Data(responseData map[string]interface{}, kvPath string) (map[str
 		return nil, fmt.Errorf("reading KV path %q: unexpected data format", kvPath)
 	}
 
-	result := make(map[string]string, len(dataMap))
-	for key, val := range dataMap {
+	return stringValues(dataMap), nil
+}
+
+// stringValues returns the string values of data; others are skipped.
+func stringValues(data map[string]interface{}) map[string]string {
+	result := make(map[string]string, len(data))
+	for key, val := range data {
 		str, ok := val.(string)
 		if !ok {
 			continue
 		}
 		result[key] = str
 	}
-
-	return result, nil
+	return result
 }
 
 // VaultEntry represents a key or directory in the Vault KV tree.
@@ -70,14 +275,14 @@ type VaultEntry struct {
 	IsDir bool   // trailing "/" in Vault LIST response indicates a directory
 }
 
-// ListKeys lists keys and directories at a KV v2 metadata path. This uses the
-// Vault LIST HTTP method on {basePath}/metadata/{kvPath}. Keys ending with "/"
-// are directories; others are leaf secrets.
+// ListKeys lists keys and directories at a KV path. This uses the Vault LIST
+// HTTP method on {basePath}/metadata/{kvPath} (KV v2) or {basePath}/{kvPath}
+// (KV v1). Keys ending with "/" are directories; others are leaf secrets.
 //
-// Requires the "list" capability on the metadata path. Returns an empty slice
-// when the path does not exist.
+// Requires the "list" capability on that path. Returns an empty slice when
+// the path does not exist.
 func (c *Client) ListKeys(kvPath string) ([]VaultEntry, error) {
-	fullPath := buildKV2MetadataPath(c.basePath, kvPath)
+	fullPath := c.listPath(kvPath)
 
 	secret, err := c.inner.Logical().List(fullPath)
 	if err != nil {
@@ -94,6 +299,37 @@ func (c *Client) ListKeys(kvPath string) ([]VaultEntry, error) {
 	return parseListKeys(secret.Data, kvPath)
 }
 
+// Lister lists the keys and directories at a KV path, like
+// Client.ListKeys.
+type Lister interface {
+	ListKeys(kvPath string) ([]VaultEntry, error)
+}
+
+// ListTree recursively lists every leaf secret below prefix, sorted. Each
+// directory is listed with l.ListKeys, so it needs the same capability.
+func ListTree(l Lister, prefix string) ([]string, error) {
+	entries, err := l.ListKeys(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var leaves []string
+	for _, e := range entries {
+		full := path.Join(prefix, e.Name)
+		if e.IsDir {
+			sub, err := ListTree(l, full+"/")
+			if err != nil {
+				return nil, err
+			}
+			leaves = append(leaves, sub...)
+			continue
+		}
+		leaves = append(leaves, full)
+	}
+	sort.Strings(leaves)
+	return leaves, nil
+}
+
 // buildKV2MetadataPath constructs the KV v2 metadata path for LIST operations.
 func buildKV2MetadataPath(basePath string, kvPath string) string {
 	return path.Join(basePath, "metadata", kvPath)
@@ -137,3 +373,28 @@ func isPermissionDenied(err error) bool {
 	}
 	return false
 }
+
+// IsTransient reports whether err is likely to go away when the request is
+// made again: a 5xx or 429 response, or a failure to connect to Vault other
+// than an unknown host.
+func IsTransient(err error) bool {
+	var respErr *vaultapi.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode >= http.StatusInternalServerError || respErr.StatusCode == http.StatusTooManyRequests
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isNotFound checks whether a Vault API error is a 404 not found.
+func isNotFound(err error) bool {
+	var respErr *vaultapi.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode == http.StatusNotFound
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package vault
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -122,3 +124,38 @@ func TestBuildKV2MetadataPath(t *testing.T) {
 		}
 	}
 }
+
+// fakeLister serves LIST responses keyed by path.
+type fakeLister map[string][]VaultEntry
+
+func (f fakeLister) ListKeys(kvPath string) ([]VaultEntry, error) {
+	if entries, ok := f[kvPath]; ok {
+		return entries, nil
+	}
+	return nil, fmt.Errorf("listing KV path %q: permission denied", kvPath)
+}
+
+func TestListTree(t *testing.T) {
+	l := fakeLister{
+		"dev/": {
+			{Name: "web/", IsDir: true},
+			{Name: "database"},
+			{Name: "api"},
+		},
+		"dev/web/": {{Name: "session"}},
+	}
+
+	got, err := ListTree(l, "dev/")
+	if err != nil {
+		t.Fatalf("ListTree() error = %v", err)
+	}
+	want := []string{"dev/api", "dev/database", "dev/web/session"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListTree() = %v, want %v", got, want)
+	}
+
+	l["dev/"] = append(l["dev/"], VaultEntry{Name: "locked/", IsDir: true})
+	if _, err := ListTree(l, "dev/"); err == nil {
+		t.Error("ListTree() error = nil, want the failed listing")
+	}
+}
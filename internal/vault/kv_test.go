@@ -1,7 +1,17 @@
 package vault
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	vaultapi "github.com/hashicorp/vault/api"
 )
 
 func TestBuildKV2Path(t *testing.T) {
@@ -142,8 +152,288 @@ func TestReadKV_NoServer(t *testing.T) {
 		t.Fatalf("unexpected error creating client: %v", err)
 	}
 
-	_, readErr := client.ReadKV("dev/database")
+	_, readErr := client.ReadKV(context.Background(), "dev/database")
 	if readErr == nil {
 		t.Error("expected error reading from non-existent server, got nil")
 	}
 }
+
+func TestCopyKV(t *testing.T) {
+	var written map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/secret/data/staging/db":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"data": map[string]interface{}{"url": "postgres://", "port": 5432},
+				},
+			})
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/secret/data/stage/db":
+			json.NewDecoder(r.Body).Decode(&written)
+			w.Write([]byte(`{"data":{"version":1}}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/secret/data/stage/exists":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["check-and-set parameter did not match the current version"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	if err := client.CopyKV("staging/db", "stage/db"); err != nil {
+		t.Fatalf("CopyKV() error = %v", err)
+	}
+
+	data, _ := written["data"].(map[string]interface{})
+	if data["url"] != "postgres://" || data["port"] != float64(5432) {
+		t.Errorf("written data = %v, want url and numeric port preserved", data)
+	}
+	opts, _ := written["options"].(map[string]interface{})
+	if opts["cas"] != float64(0) {
+		t.Errorf("written options = %v, want cas=0", opts)
+	}
+
+	if err := client.CopyKV("staging/db", "stage/exists"); err == nil {
+		t.Error("CopyKV() expected error when destination exists")
+	}
+	if err := client.CopyKV("staging/missing", "stage/missing"); err == nil {
+		t.Error("CopyKV() expected error when source is missing")
+	}
+}
+
+func TestPatchKV(t *testing.T) {
+	var patched, created map[string]interface{}
+	var contentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPatch && r.URL.Path == "/v1/secret/data/dev/db":
+			contentType = r.Header.Get("Content-Type")
+			json.NewDecoder(r.Body).Decode(&patched)
+			w.Write([]byte(`{"data":{"version":2}}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/v1/secret/data/dev/new":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/secret/data/dev/new":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.Write([]byte(`{"data":{"version":1}}`))
+		case r.URL.Path == "/v1/secret/data/dev/locked":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	if err := client.PatchKV("dev/db", map[string]string{"password": "new"}); err != nil {
+		t.Fatalf("PatchKV() error = %v", err)
+	}
+	if contentType != "application/merge-patch+json" {
+		t.Errorf("Content-Type = %q, want application/merge-patch+json", contentType)
+	}
+	data, _ := patched["data"].(map[string]interface{})
+	if len(data) != 1 || data["password"] != "new" {
+		t.Errorf("patched data = %v, want only the changed key", data)
+	}
+
+	if err := client.PatchKV("dev/new", map[string]string{"token": "abc"}); err != nil {
+		t.Fatalf("PatchKV() on missing secret error = %v", err)
+	}
+	opts, _ := created["options"].(map[string]interface{})
+	if opts["cas"] != float64(0) {
+		t.Errorf("create options = %v, want cas=0", opts)
+	}
+
+	err = client.PatchKV("dev/locked", map[string]string{"k": "v"})
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("PatchKV() error = %v, want permission denied", err)
+	}
+
+	if err := client.PatchKV("dev/db", nil); err != nil {
+		t.Errorf("PatchKV() with no values error = %v", err)
+	}
+}
+
+func TestReadKVData(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/data/dev/db":
+			w.Write([]byte(`{"data":{"data":{"url":"postgres://","port":5432},"metadata":{"version":4}}}`))
+		case "/v1/secret/data/dev/deleted":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"data":{"data":null,"metadata":{"version":2,"deletion_time":"2024-01-01T00:00:00Z"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	data, version, err := client.ReadKVData("dev/db")
+	if err != nil {
+		t.Fatalf("ReadKVData() error = %v", err)
+	}
+	if version != 4 {
+		t.Errorf("version = %d, want 4", version)
+	}
+	if data["url"] != "postgres://" || data["port"] != json.Number("5432") {
+		t.Errorf("data = %v, want url and numeric port as stored", data)
+	}
+
+	data, version, err = client.ReadKVData("dev/deleted")
+	if err != nil {
+		t.Fatalf("ReadKVData() on deleted secret error = %v", err)
+	}
+	if data != nil || version != 2 {
+		t.Errorf("deleted secret = %v, version %d; want nil data, version 2", data, version)
+	}
+
+	data, version, err = client.ReadKVData("dev/missing")
+	if err != nil || data != nil || version != 0 {
+		t.Errorf("missing secret = %v, %d, %v; want nil, 0, nil", data, version, err)
+	}
+}
+
+func TestWriteKV(t *testing.T) {
+	var written map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/secret/data/dev/db":
+			json.NewDecoder(r.Body).Decode(&written)
+			w.Write([]byte(`{"data":{"version":4}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["check-and-set parameter did not match the current version"]}`))
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	if err := client.WriteKV("dev/db", map[string]interface{}{"url": "postgres://"}, 3); err != nil {
+		t.Fatalf("WriteKV() error = %v", err)
+	}
+	data, _ := written["data"].(map[string]interface{})
+	if data["url"] != "postgres://" {
+		t.Errorf("written data = %v, want url", data)
+	}
+	opts, _ := written["options"].(map[string]interface{})
+	if opts["cas"] != float64(3) {
+		t.Errorf("written options = %v, want cas=3", opts)
+	}
+
+	if err := client.WriteKV("dev/other", map[string]interface{}{"k": "v"}, 0); err == nil {
+		t.Error("WriteKV() expected check-and-set error")
+	}
+}
+
+func TestDestroyKV(t *testing.T) {
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		deleted = append(deleted, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.test", WithKVVersion(2))
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+	if err := client.DestroyKV("preview-1/db"); err != nil {
+		t.Fatalf("DestroyKV() error = %v", err)
+	}
+
+	v1, err := NewClientWithToken(srv.URL, "kv", "s.test", WithKVVersion(1))
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+	if err := v1.DestroyKV("preview-1/db"); err != nil {
+		t.Fatalf("DestroyKV() on KV v1 error = %v", err)
+	}
+
+	want := []string{"/v1/secret/metadata/preview-1/db", "/v1/kv/preview-1/db"}
+	if strings.Join(deleted, " ") != strings.Join(want, " ") {
+		t.Errorf("deleted %v, want %v", deleted, want)
+	}
+}
+
+func TestReadKVVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/dev/db" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.URL.Query().Get("version") {
+		case "3":
+			w.Write([]byte(`{"data":{"data":{"password":"old"},"metadata":{"version":3}}}`))
+		case "2":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"data":{"data":null,"metadata":{"version":2,"deletion_time":"2024-01-01T00:00:00Z"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	data, err := client.ReadKVVersion(context.Background(), "dev/db", 3)
+	if err != nil {
+		t.Fatalf("ReadKVVersion() error = %v", err)
+	}
+	if data["password"] != "old" {
+		t.Errorf("data = %v, want version 3's password", data)
+	}
+
+	for _, v := range []int{2, 9} {
+		if _, err := client.ReadKVVersion(context.Background(), "dev/db", v); err == nil {
+			t.Errorf("ReadKVVersion(%d) expected an error for a deleted or missing version", v)
+		}
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	wrap := func(err error) error { return fmt.Errorf("reading KV path %q: %w", "dev/db", err) }
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{wrap(&vaultapi.ResponseError{StatusCode: http.StatusServiceUnavailable}), true},
+		{wrap(&vaultapi.ResponseError{StatusCode: http.StatusTooManyRequests}), true},
+		{wrap(&vaultapi.ResponseError{StatusCode: http.StatusForbidden}), false},
+		{wrap(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}), true},
+		{wrap(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}), false},
+		{wrap(context.DeadlineExceeded), false},
+	}
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
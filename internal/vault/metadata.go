@@ -0,0 +1,164 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// KVMetadata describes a KV v2 secret as returned by its metadata endpoint.
+type KVMetadata struct {
+	CurrentVersion int
+	// CreatedTime is when the secret was first written.
+	CreatedTime time.Time
+	// UpdatedTime is when the current version was written.
+	UpdatedTime    time.Time
+	CustomMetadata map[string]string
+	// MaxVersions is how many versions are kept; 0 means the mount's
+	// setting applies.
+	MaxVersions int
+	// DeleteVersionAfter is how long a version lives before it is deleted;
+	// 0 means versions never expire.
+	DeleteVersionAfter time.Duration
+	// Versions are the versions Vault still keeps, newest first.
+	Versions []KVVersion
+}
+
+// KVVersion describes one version of a KV v2 secret.
+type KVVersion struct {
+	Version     int
+	CreatedTime time.Time
+	// DeletionTime is when the version was deleted, or will be under
+	// delete_version_after; zero if it is not scheduled for deletion.
+	DeletionTime time.Time
+	// Destroyed is set once the version's data was permanently removed.
+	Destroyed bool
+}
+
+// Deleted reports whether the version's data can no longer be read at now,
+// because it was deleted or destroyed.
+func (v KVVersion) Deleted(now time.Time) bool {
+	return v.Destroyed || (!v.DeletionTime.IsZero() && !v.DeletionTime.After(now))
+}
+
+// MetadataSettings are the retention settings WriteMetadata changes. Nil
+// fields are left as they are.
+type MetadataSettings struct {
+	MaxVersions        *int
+	DeleteVersionAfter *time.Duration
+}
+
+// ReadMetadata reads the KV v2 metadata for the secret at kvPath, relative to
+// the client's basePath mount. This needs "read" on {basePath}/metadata/*,
+// which is often granted separately from data access.
+func (c *Client) ReadMetadata(kvPath string) (*KVMetadata, error) {
+	if c.KVVersion() == 1 {
+		return nil, fmt.Errorf("reading metadata for %q: %w", kvPath, ErrKVv1)
+	}
+
+	secret, err := c.inner.Logical().Read(buildKV2MetadataPath(c.basePath, kvPath))
+	if err != nil {
+		if isPermissionDenied(err) {
+			return nil, fmt.Errorf("reading metadata for %q: permission denied: %w", kvPath, err)
+		}
+		return nil, fmt.Errorf("reading metadata for %q: %w", kvPath, err)
+	}
+
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("reading metadata for %q: not found", kvPath)
+	}
+
+	return parseKVMetadata(secret.Data), nil
+}
+
+// WriteMetadata updates the retention settings of the secret at kvPath,
+// relative to the client's basePath mount, creating its metadata if the
+// secret does not exist yet. This needs "create" or "update" on
+// {basePath}/metadata/*.
+func (c *Client) WriteMetadata(kvPath string, settings MetadataSettings) error {
+	body := make(map[string]interface{}, 2)
+	if settings.MaxVersions != nil {
+		body["max_versions"] = *settings.MaxVersions
+	}
+	if settings.DeleteVersionAfter != nil {
+		body["delete_version_after"] = settings.DeleteVersionAfter.String()
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	if c.KVVersion() == 1 {
+		return fmt.Errorf("writing metadata for %q: %w", kvPath, ErrKVv1)
+	}
+
+	if _, err := c.inner.Logical().Write(buildKV2MetadataPath(c.basePath, kvPath), body); err != nil {
+		if isPermissionDenied(err) {
+			return fmt.Errorf("writing metadata for %q: permission denied: %w", kvPath, err)
+		}
+		return fmt.Errorf("writing metadata for %q: %w", kvPath, err)
+	}
+
+	return nil
+}
+
+// parseKVMetadata extracts KVMetadata from a metadata response. The update
+// time is taken from the current version's entry in "versions", falling back
+// to the secret-level updated_time (which also changes on metadata edits).
+func parseKVMetadata(data map[string]interface{}) *KVMetadata {
+	meta := &KVMetadata{
+		CreatedTime:    timeField(data, "created_time"),
+		UpdatedTime:    timeField(data, "updated_time"),
+		CustomMetadata: stringMapField(data, "custom_metadata"),
+	}
+
+	if n, ok := data["current_version"].(json.Number); ok {
+		if v, err := n.Int64(); err == nil {
+			meta.CurrentVersion = int(v)
+		}
+	}
+	if n, ok := data["max_versions"].(json.Number); ok {
+		if v, err := n.Int64(); err == nil {
+			meta.MaxVersions = int(v)
+		}
+	}
+	if d, err := time.ParseDuration(stringField(data, "delete_version_after")); err == nil {
+		meta.DeleteVersionAfter = d
+	}
+
+	if versions, ok := data["versions"].(map[string]interface{}); ok {
+		if current, ok := versions[strconv.Itoa(meta.CurrentVersion)].(map[string]interface{}); ok {
+			if t := timeField(current, "created_time"); !t.IsZero() {
+				meta.UpdatedTime = t
+			}
+		}
+
+		for k, raw := range versions {
+			n, err := strconv.Atoi(k)
+			v, ok := raw.(map[string]interface{})
+			if err != nil || !ok {
+				continue
+			}
+			destroyed, _ := v["destroyed"].(bool)
+			meta.Versions = append(meta.Versions, KVVersion{
+				Version:      n,
+				CreatedTime:  timeField(v, "created_time"),
+				DeletionTime: timeField(v, "deletion_time"),
+				Destroyed:    destroyed,
+			})
+		}
+		slices.SortFunc(meta.Versions, func(a, b KVVersion) int { return b.Version - a.Version })
+	}
+
+	return meta
+}
+
+// timeField parses an RFC 3339 timestamp at data[key], returning the zero
+// time when it is missing or malformed.
+func timeField(data map[string]interface{}, key string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, stringField(data, key))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
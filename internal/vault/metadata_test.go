@@ -0,0 +1,132 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadMetadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/secret/metadata/dev/database":
+			w.Write([]byte(`{"data":{
+				"current_version": 3,
+				"created_time": "2024-01-02T03:04:05.123456Z",
+				"updated_time": "2024-06-01T00:00:00Z",
+				"custom_metadata": {"owner": "team-a", "updated_by": "jane"},
+				"max_versions": 10,
+				"delete_version_after": "720h0m0s",
+				"versions": {
+					"1": {"created_time": "2024-01-02T03:04:05Z", "deletion_time": "2024-02-01T00:00:00Z", "destroyed": true},
+					"2": {"created_time": "2024-03-01T00:00:00Z", "deletion_time": "2024-04-01T00:00:00Z", "destroyed": false},
+					"3": {"created_time": "2024-05-01T10:00:00Z", "deletion_time": "", "destroyed": false}
+				}
+			}}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	meta, err := client.ReadMetadata("dev/database")
+	if err != nil {
+		t.Fatalf("ReadMetadata() error = %v", err)
+	}
+
+	if meta.CurrentVersion != 3 {
+		t.Errorf("CurrentVersion = %d, want 3", meta.CurrentVersion)
+	}
+	if want := time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC); !meta.CreatedTime.Equal(want) {
+		t.Errorf("CreatedTime = %v, want %v", meta.CreatedTime, want)
+	}
+	if want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC); !meta.UpdatedTime.Equal(want) {
+		t.Errorf("UpdatedTime = %v, want current version time %v", meta.UpdatedTime, want)
+	}
+	if meta.CustomMetadata["updated_by"] != "jane" {
+		t.Errorf("CustomMetadata = %v, want updated_by=jane", meta.CustomMetadata)
+	}
+	if meta.MaxVersions != 10 || meta.DeleteVersionAfter != 720*time.Hour {
+		t.Errorf("retention = %d versions, %v; want 10, 720h", meta.MaxVersions, meta.DeleteVersionAfter)
+	}
+
+	if len(meta.Versions) != 3 || meta.Versions[0].Version != 3 || meta.Versions[2].Version != 1 {
+		t.Fatalf("Versions = %+v, want 3, 2, 1", meta.Versions)
+	}
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	if meta.Versions[0].Deleted(now) || !meta.Versions[1].Deleted(now) || !meta.Versions[2].Destroyed {
+		t.Errorf("Versions = %+v, want 3 live, 2 deleted, 1 destroyed", meta.Versions)
+	}
+	// A deletion scheduled by delete_version_after has not happened yet.
+	if meta.Versions[1].Deleted(time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Error("version 2 should still be readable before its deletion time")
+	}
+
+	if _, err := client.ReadMetadata("prod/database"); err == nil {
+		t.Error("ReadMetadata() expected permission error")
+	}
+}
+
+func TestParseKVMetadata_NoVersions(t *testing.T) {
+	meta := parseKVMetadata(map[string]interface{}{
+		"updated_time": "2024-06-01T00:00:00Z",
+	})
+
+	if meta.CurrentVersion != 0 {
+		t.Errorf("CurrentVersion = %d, want 0", meta.CurrentVersion)
+	}
+	if want := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC); !meta.UpdatedTime.Equal(want) {
+		t.Errorf("UpdatedTime = %v, want fallback %v", meta.UpdatedTime, want)
+	}
+	if meta.CustomMetadata == nil {
+		t.Error("CustomMetadata should be non-nil")
+	}
+}
+
+func TestWriteMetadata(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/metadata/dev/database" || r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		got = nil
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	maxVersions := 10
+	if err := client.WriteMetadata("dev/database", MetadataSettings{MaxVersions: &maxVersions}); err != nil {
+		t.Fatalf("WriteMetadata() error = %v", err)
+	}
+	if len(got) != 1 || got["max_versions"] != float64(10) {
+		t.Errorf("body = %v, want only max_versions=10", got)
+	}
+
+	after := 720 * time.Hour
+	if err := client.WriteMetadata("dev/database", MetadataSettings{DeleteVersionAfter: &after}); err != nil {
+		t.Fatalf("WriteMetadata() error = %v", err)
+	}
+	if len(got) != 1 || got["delete_version_after"] != "720h0m0s" {
+		t.Errorf("body = %v, want only delete_version_after=720h0m0s", got)
+	}
+
+	if err := client.WriteMetadata("prod/database", MetadataSettings{MaxVersions: &maxVersions}); err == nil {
+		t.Error("WriteMetadata() expected permission error")
+	}
+}
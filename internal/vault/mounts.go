@@ -0,0 +1,73 @@
+package vault
+
+import (
+	"errors"
+	"path"
+)
+
+// ErrKVv1 is returned by the operations that need KV v2 versioning, such as
+// reading or writing metadata, when the mount is a KV v1 engine.
+var ErrKVv1 = errors.New("the KV v1 engine keeps no versions or metadata")
+
+// WithKVVersion sets the KV engine version of the basePath mount, 1 or 2,
+// instead of detecting it on first use. Other values are ignored.
+func WithKVVersion(v int) ClientOption {
+	return func(c *Client) {
+		if v == 1 || v == 2 {
+			c.kvVersion = v
+		}
+	}
+}
+
+// KVVersion returns the KV engine version of the client's basePath mount: 1
+// or 2. Unless set with WithKVVersion, it is detected on first use from
+// sys/internal/ui/mounts, which every token with access to the mount may
+// read. When detection fails the mount is taken to be KV v2.
+func (c *Client) KVVersion() int {
+	c.kvOnce.Do(func() {
+		if c.kvVersion == 0 {
+			c.kvVersion = c.detectKVVersion()
+		}
+	})
+	return c.kvVersion
+}
+
+// detectKVVersion asks Vault for the options of the basePath mount. A kv
+// mount without a version option is KV v1.
+func (c *Client) detectKVVersion() int {
+	secret, err := c.inner.Logical().Read(path.Join("sys/internal/ui/mounts", c.basePath))
+	if err != nil || secret == nil || secret.Data == nil {
+		return 2
+	}
+	return parseKVVersion(secret.Data)
+}
+
+// parseKVVersion reads the engine version from a mount description.
+func parseKVVersion(data map[string]interface{}) int {
+	if stringField(data, "type") != "kv" {
+		return 2
+	}
+	options, _ := data["options"].(map[string]interface{})
+	if stringField(options, "version") == "2" {
+		return 2
+	}
+	return 1
+}
+
+// dataPath returns the API path for reading and writing the secret at
+// kvPath: {basePath}/data/{kvPath} on KV v2, {basePath}/{kvPath} on KV v1.
+func (c *Client) dataPath(kvPath string) string {
+	if c.KVVersion() == 1 {
+		return path.Join(c.basePath, kvPath)
+	}
+	return buildKV2Path(c.basePath, kvPath)
+}
+
+// listPath returns the API path for listing the keys under kvPath:
+// {basePath}/metadata/{kvPath} on KV v2, {basePath}/{kvPath} on KV v1.
+func (c *Client) listPath(kvPath string) string {
+	if c.KVVersion() == 1 {
+		return path.Join(c.basePath, kvPath)
+	}
+	return buildKV2MetadataPath(c.basePath, kvPath)
+}
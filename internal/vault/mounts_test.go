@@ -0,0 +1,142 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseKVVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want int
+	}{
+		{"kv v2", map[string]interface{}{"type": "kv", "options": map[string]interface{}{"version": "2"}}, 2},
+		{"kv v1", map[string]interface{}{"type": "kv", "options": map[string]interface{}{"version": "1"}}, 1},
+		{"kv without options", map[string]interface{}{"type": "kv", "options": nil}, 1},
+		{"other engine", map[string]interface{}{"type": "generic"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseKVVersion(tt.data); got != tt.want {
+				t.Errorf("parseKVVersion() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKVVersion_Detect(t *testing.T) {
+	var lookups int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/sys/internal/ui/mounts/legacy":
+			lookups++
+			w.Write([]byte(`{"data":{"type":"kv","path":"legacy/","options":{"version":"1"}}}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "legacy", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+	if v := client.KVVersion(); v != 1 {
+		t.Errorf("KVVersion() = %d, want 1", v)
+	}
+	client.KVVersion()
+	if lookups != 1 {
+		t.Errorf("mount looked up %d times, want once", lookups)
+	}
+
+	denied, err := NewClientWithToken(srv.URL, "secret", "s.test")
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+	if v := denied.KVVersion(); v != 2 {
+		t.Errorf("KVVersion() after a failed lookup = %d, want 2", v)
+	}
+
+	set, err := NewClientWithToken(srv.URL, "legacy", "s.test", WithKVVersion(2))
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+	if v := set.KVVersion(); v != 2 || lookups != 1 {
+		t.Errorf("KVVersion() = %d with %d lookups, want the configured 2 without a lookup", v, lookups)
+	}
+}
+
+func TestKVv1(t *testing.T) {
+	stored := map[string]interface{}{"url": "postgres://", "port": 5432}
+	var written map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/kv/dev/db":
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": stored})
+		case r.Method == "LIST" && r.URL.Path == "/v1/kv/dev",
+			r.Method == http.MethodGet && r.URL.Path == "/v1/kv/dev" && r.URL.Query().Get("list") == "true":
+			w.Write([]byte(`{"data":{"keys":["db","api/"]}}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/kv/dev/db":
+			json.NewDecoder(r.Body).Decode(&written)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "kv", "s.test", WithKVVersion(1))
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	values, err := client.ReadKV(context.Background(), "dev/db")
+	if err != nil {
+		t.Fatalf("ReadKV() error = %v", err)
+	}
+	if len(values) != 1 || values["url"] != "postgres://" {
+		t.Errorf("ReadKV() = %v, want only the string value", values)
+	}
+
+	data, version, err := client.ReadKVData("dev/db")
+	if err != nil || version != 0 || data["port"] != json.Number("5432") {
+		t.Errorf("ReadKVData() = %v, %d, %v; want the stored data, version 0", data, version, err)
+	}
+
+	entries, err := client.ListKeys("dev")
+	if err != nil {
+		t.Fatalf("ListKeys() error = %v", err)
+	}
+	if len(entries) != 2 || !entries[1].IsDir {
+		t.Errorf("ListKeys() = %+v, want db and the api/ directory", entries)
+	}
+
+	if err := client.PatchKV("dev/db", map[string]string{"url": "postgres://new"}); err != nil {
+		t.Fatalf("PatchKV() error = %v", err)
+	}
+	if written["url"] != "postgres://new" || written["port"] != float64(5432) {
+		t.Errorf("patched secret = %v, want the new url and the port kept", written)
+	}
+	if _, ok := written["options"]; ok {
+		t.Errorf("patched secret = %v, want no check-and-set options on KV v1", written)
+	}
+
+	if err := client.CopyKV("dev/db", "dev/db"); err == nil {
+		t.Error("CopyKV() expected error when destination exists")
+	}
+
+	if _, err := client.ReadMetadata("dev/db"); !errors.Is(err, ErrKVv1) {
+		t.Errorf("ReadMetadata() error = %v, want ErrKVv1", err)
+	}
+	if err := client.WatchKV(context.Background(), func(KVChange) {}); !errors.Is(err, ErrEventsUnsupported) {
+		t.Errorf("WatchKV() error = %v, want ErrEventsUnsupported", err)
+	}
+}
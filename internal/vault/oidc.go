@@ -2,12 +2,23 @@ package vault
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os/exec"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
 )
 
 // oidcCallbackPort is the default port for the OIDC callback listener.
@@ -15,6 +26,19 @@ import (
 // The standard port 8250 is the same used by the vault CLI.
 const oidcCallbackPort = 8250
 
+// ErrNoCallbackPort is returned by OIDCAuth when none of the callback ports
+// could be listened on.
+var ErrNoCallbackPort = errors.New("no OIDC callback port is free")
+
+// oidcCallbackHost is the address the callback listener binds to: loopback
+// only, so no other machine can deliver a callback. The redirect URI keeps
+// "localhost", which is what allowed_redirect_uris lists.
+const oidcCallbackHost = "127.0.0.1"
+
+// errStateMismatch is returned when the callback's state is not the one of
+// the login vx started, e.g. a callback forged by another page.
+var errStateMismatch = errors.New("OIDC callback state does not match this login; it was not started by vx or belongs to an earlier attempt")
+
 // oidcCallbackResult holds the outcome of a single OIDC callback invocation.
 type oidcCallbackResult struct {
 	code  string
@@ -22,34 +46,120 @@ type oidcCallbackResult struct {
 	err   error
 }
 
+// DefaultOIDCMount is the mount path of the OIDC auth method used unless
+// WithOIDCMount says otherwise.
+const DefaultOIDCMount = "oidc"
+
+// oidcSettings holds the configurable parts of the OIDC flow.
+type oidcSettings struct {
+	mount     string
+	ports     []int
+	out       io.Writer
+	noBrowser bool
+}
+
+// OIDCOption configures OIDCAuth.
+type OIDCOption func(*oidcSettings)
+
+// WithOIDCMount logs in through the OIDC auth method mounted at mount (e.g.
+// "okta" for auth/okta) instead of the default "oidc". Empty values are
+// ignored.
+func WithOIDCMount(mount string) OIDCOption {
+	return func(s *oidcSettings) {
+		if mount != "" {
+			s.mount = mount
+		}
+	}
+}
+
+// WithCallbackPorts makes the callback listener try ports in order and use
+// the first one that is free, instead of only 8250. Each port's redirect URI
+// (http://localhost:<port>/oidc/callback) must be in the role's
+// allowed_redirect_uris. An empty list is ignored.
+func WithCallbackPorts(ports ...int) OIDCOption {
+	return func(s *oidcSettings) {
+		if len(ports) > 0 {
+			s.ports = ports
+		}
+	}
+}
+
+// WithOutput makes OIDCAuth write login instructions to w whenever the user
+// has to do more than finish the login in the browser it opened: the URL
+// when no browser could be opened, and the code Vault's device flow asks
+// for. Without it, those cases fail the login.
+func WithOutput(w io.Writer) OIDCOption {
+	return func(s *oidcSettings) {
+		s.out = w
+	}
+}
+
+// WithoutBrowser makes OIDCAuth print the login URL instead of opening a
+// browser, for machines without one. It needs WithOutput.
+func WithoutBrowser() OIDCOption {
+	return func(s *oidcSettings) {
+		s.noBrowser = true
+	}
+}
+
 // OIDCAuth performs an OIDC authentication flow against Vault. It opens a
 // browser for the user to authenticate, waits for the callback, and exchanges
 // the authorization code for a Vault token. The token is set on the client.
-func OIDCAuth(client *Client, role string) error {
-	listenAddr := fmt.Sprintf("localhost:%d", oidcCallbackPort)
-	listener, err := net.Listen("tcp", listenAddr)
+//
+// When the role completes logins in Vault itself (callback_mode "device" or
+// "direct"), the user logs in on any device, entering the code shown for the
+// device flow, and OIDCAuth polls Vault for the token instead of waiting for
+// a callback.
+//
+// The flow is bound to this process: a random client nonce is sent with the
+// auth URL request and again with the code, so Vault only issues the token
+// to the client that started the login, and the callback must carry the
+// state of the auth URL. Where the provider supports PKCE, Vault adds an
+// S256 code challenge to the auth URL; any other challenge method is
+// refused.
+//
+// Waiting for the callback or polling stops early when ctx is done.
+func OIDCAuth(ctx context.Context, client *Client, role string, opts ...OIDCOption) error {
+	settings := oidcSettings{mount: DefaultOIDCMount, ports: []int{oidcCallbackPort}}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	if settings.noBrowser && settings.out == nil {
+		return errors.New("OIDC login without a browser needs somewhere to show the login URL")
+	}
+
+	listener, port, err := listenForCallback(settings.ports)
 	if err != nil {
-		return fmt.Errorf("starting OIDC callback listener on %s (is another vault/vx process running?): %w", listenAddr, err)
+		return err
 	}
 	defer listener.Close()
 
-	redirectURI := fmt.Sprintf("http://localhost:%d/oidc/callback", oidcCallbackPort)
+	redirectURI := fmt.Sprintf("http://localhost:%d/oidc/callback", port)
 
-	authURL, clientNonce, err := requestAuthURL(client, role, redirectURI)
+	clientNonce, err := newClientNonce()
 	if err != nil {
 		return err
 	}
 
-	if err := openBrowser(authURL); err != nil {
-		return fmt.Errorf("opening browser for OIDC login: %w", err)
+	auth, err := requestAuthURL(client, settings.mount, role, redirectURI, clientNonce)
+	if err != nil {
+		return err
 	}
 
-	result, err := waitForCallback(listener)
-	if err != nil {
+	if err := settings.present(auth, port); err != nil {
 		return err
 	}
 
-	token, err := exchangeOIDCCode(client, result.code, result.state, clientNonce)
+	var token string
+	if auth.poll {
+		token, err = pollOIDC(ctx, client, settings.mount, auth, clientNonce)
+	} else {
+		var result *oidcCallbackResult
+		result, err = waitForCallback(ctx, listener, auth.state)
+		if err == nil {
+			token, err = exchangeOIDCCode(client, settings.mount, result.code, result.state, clientNonce)
+		}
+	}
 	if err != nil {
 		return err
 	}
@@ -58,55 +168,213 @@ func OIDCAuth(client *Client, role string) error {
 	return nil
 }
 
-// requestAuthURL calls Vault's auth/oidc/oidc/auth_url endpoint to get the URL
-// the user must visit to authenticate. The path is mount ("oidc") + plugin
-// route ("oidc/auth_url"), matching the official vault CLI behaviour.
-func requestAuthURL(client *Client, role string, redirectURI string) (string, string, error) {
+// present gets the user to the login URL: it opens a browser unless told
+// not to, and writes instructions to the output when no browser was opened
+// or the device flow needs its code entered.
+func (s oidcSettings) present(auth oidcAuthURL, port int) error {
+	if auth.userCode != "" && s.out == nil {
+		return errors.New("the OIDC role uses the device flow, which needs somewhere to show its code; run vx login")
+	}
+
+	opened := false
+	if !s.noBrowser {
+		err := openBrowser(auth.url)
+		if err != nil && s.out == nil {
+			return fmt.Errorf("opening browser for OIDC login: %w", err)
+		}
+		opened = err == nil
+	}
+
+	switch {
+	case auth.userCode != "" && opened:
+		fmt.Fprintf(s.out, "Enter the code %s in the browser to log in.\n", auth.userCode)
+	case auth.userCode != "":
+		fmt.Fprintf(s.out, "To log in, open this URL in a browser on any device and enter the code %s:\n\n    %s\n\n", auth.userCode, auth.url)
+	case opened:
+	case auth.poll:
+		fmt.Fprintf(s.out, "To log in, open this URL in a browser on any device:\n\n    %s\n\n", auth.url)
+	default:
+		fmt.Fprintf(s.out, "To log in, open this URL in a browser:\n\n    %s\n\n"+
+			"It redirects to http://localhost:%d/oidc/callback. On a remote machine, forward\n"+
+			"that port from the one running the browser first: ssh -L %d:localhost:%d <host>\n\n",
+			auth.url, port, port, port)
+	}
+	return nil
+}
+
+// listenForCallback listens on the first of ports that is free on the
+// loopback address and returns the listener with its port. When none is, the
+// error lists each port tried and why it failed.
+func listenForCallback(ports []int) (net.Listener, int, error) {
+	tried := make([]string, 0, len(ports))
+	for _, port := range ports {
+		listener, err := net.Listen("tcp", net.JoinHostPort(oidcCallbackHost, strconv.Itoa(port)))
+		if err == nil {
+			return listener, port, nil
+		}
+		tried = append(tried, fmt.Sprintf("%d (%v)", port, listenFailure(err)))
+	}
+	return nil, 0, fmt.Errorf("%w on %s (is another vault/vx process running?); tried %s",
+		ErrNoCallbackPort, oidcCallbackHost, strings.Join(tried, ", "))
+}
+
+// listenFailure returns the reason a listen failed without the address,
+// which the caller reports itself.
+func listenFailure(err error) error {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Err != nil {
+		return opErr.Err
+	}
+	return err
+}
+
+// newClientNonce returns 20 random bytes, hex encoded, binding an OIDC login
+// to this process.
+func newClientNonce() (string, error) {
+	var b [20]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("generating OIDC client nonce: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// oidcAuthURL is Vault's answer to an auth URL request.
+type oidcAuthURL struct {
+	url   string
+	state string
+	// poll is set when Vault completes the login itself and is polled for
+	// the token, rather than vx receiving a callback.
+	poll         bool
+	userCode     string
+	pollInterval time.Duration
+}
+
+// defaultPollInterval is how often Vault is polled for a login completed
+// elsewhere when it does not say.
+const defaultPollInterval = 5 * time.Second
+
+// requestAuthURL calls Vault's auth/<mount>/oidc/auth_url endpoint to get the
+// URL the user must visit to authenticate, with the state the callback must
+// carry or, for roles that complete logins in Vault, the state to poll with.
+// The path is mount (e.g. "oidc") + plugin route ("oidc/auth_url"), matching
+// the official vault CLI behaviour.
+func requestAuthURL(client *Client, mount string, role string, redirectURI string, clientNonce string) (oidcAuthURL, error) {
 	data := map[string]interface{}{
 		"role":         role,
 		"redirect_uri": redirectURI,
+		"client_nonce": clientNonce,
 	}
 
-	secret, err := client.inner.Logical().Write("auth/oidc/oidc/auth_url", data)
+	secret, err := client.inner.Logical().Write("auth/"+mount+"/oidc/auth_url", data)
 	if err != nil {
-		return "", "", fmt.Errorf("requesting OIDC auth URL: %w", err)
+		return oidcAuthURL{}, fmt.Errorf("requesting OIDC auth URL: %w", err)
 	}
 
 	if secret == nil || secret.Data == nil {
-		return "", "", fmt.Errorf("requesting OIDC auth URL: empty response")
+		return oidcAuthURL{}, fmt.Errorf("requesting OIDC auth URL: empty response")
 	}
 
 	authURL, ok := secret.Data["auth_url"].(string)
 	if !ok || authURL == "" {
-		return "", "", fmt.Errorf("requesting OIDC auth URL: missing auth_url in response")
+		return oidcAuthURL{}, fmt.Errorf("requesting OIDC auth URL: missing auth_url in response")
+	}
+
+	// Roles with callback_mode "device" or "direct" return the state to poll
+	// with; the URL is then the provider's, without one for vx to check.
+	if state, _ := secret.Data["state"].(string); state != "" {
+		userCode, _ := secret.Data["user_code"].(string)
+		return oidcAuthURL{
+			url:          authURL,
+			state:        state,
+			poll:         true,
+			userCode:     userCode,
+			pollInterval: pollInterval(secret.Data["poll_interval"]),
+		}, nil
+	}
+
+	state, err := checkAuthURL(authURL)
+	if err != nil {
+		return oidcAuthURL{}, fmt.Errorf("requesting OIDC auth URL: %w", err)
+	}
+
+	return oidcAuthURL{url: authURL, state: state}, nil
+}
+
+// pollInterval reads the poll_interval of an auth URL response, in seconds
+// and sent as a string or a number, falling back to defaultPollInterval when
+// it is missing, unreadable, or not positive.
+func pollInterval(v any) time.Duration {
+	if v == nil {
+		return defaultPollInterval
+	}
+	seconds, err := strconv.Atoi(fmt.Sprint(v))
+	if err != nil || seconds <= 0 {
+		return defaultPollInterval
 	}
+	return time.Duration(seconds) * time.Second
+}
+
+// checkAuthURL returns the state of an auth URL from Vault. It fails when
+// the URL has no state or nonce, or asks for a PKCE method other than S256.
+func checkAuthURL(authURL string) (string, error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid auth_url: %w", err)
+	}
+	q := u.Query()
 
-	clientNonce, _ := secret.Data["client_nonce"].(string)
+	state := q.Get("state")
+	if state == "" {
+		return "", fmt.Errorf("auth_url has no state parameter")
+	}
+	if q.Get("nonce") == "" {
+		return "", fmt.Errorf("auth_url has no nonce parameter")
+	}
+	if q.Get("code_challenge") != "" {
+		if method := q.Get("code_challenge_method"); method != "S256" {
+			return "", fmt.Errorf("auth_url uses PKCE method %q; only S256 is accepted", method)
+		}
+	}
 
-	return authURL, clientNonce, nil
+	return state, nil
 }
 
 // waitForCallback starts an HTTP server on the given listener and waits for
-// the OIDC provider to redirect back with an authorization code.
-func waitForCallback(listener net.Listener) (*oidcCallbackResult, error) {
+// the OIDC provider to redirect back with an authorization code. A callback
+// whose state is not wantState fails the login with errStateMismatch.
+func waitForCallback(ctx context.Context, listener net.Listener, wantState string) (*oidcCallbackResult, error) {
 	resultCh := make(chan oidcCallbackResult, 1)
+	send := func(result oidcCallbackResult) {
+		// Only the first callback counts; later ones must not block.
+		select {
+		case resultCh <- result:
+		default:
+		}
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/oidc/callback", func(w http.ResponseWriter, r *http.Request) {
 		code := r.URL.Query().Get("code")
 		state := r.URL.Query().Get("state")
 
+		if subtle.ConstantTimeCompare([]byte(state), []byte(wantState)) != 1 {
+			send(oidcCallbackResult{err: errStateMismatch})
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "Authentication rejected: this callback does not belong to the running vx login.")
+			return
+		}
+
 		if code == "" {
 			errMsg := r.URL.Query().Get("error_description")
 			if errMsg == "" {
 				errMsg = r.URL.Query().Get("error")
 			}
-			resultCh <- oidcCallbackResult{err: fmt.Errorf("OIDC callback error: %s", errMsg)}
+			send(oidcCallbackResult{err: fmt.Errorf("OIDC callback error: %s", errMsg)})
 			fmt.Fprint(w, "Authentication failed. You may close this tab.")
 			return
 		}
 
-		resultCh <- oidcCallbackResult{code: code, state: state}
+		send(oidcCallbackResult{code: code, state: state})
 		fmt.Fprint(w, "Authentication successful. You may close this tab.")
 	})
 
@@ -129,20 +397,77 @@ func waitForCallback(listener net.Listener) (*oidcCallbackResult, error) {
 		return &result, nil
 	case <-time.After(2 * time.Minute):
 		return nil, fmt.Errorf("OIDC authentication timed out after 2 minutes")
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for OIDC callback: %w", ctx.Err())
+	}
+}
+
+// oidcPollTimeout bounds how long OIDCAuth polls Vault for a login completed
+// elsewhere, leaving time to pick up another device.
+const oidcPollTimeout = 10 * time.Minute
+
+// pollOIDC polls Vault's auth/<mount>/oidc/poll endpoint until the user has
+// logged in, and returns the token. Vault answers authorization_pending until
+// then, and slow_down when polled too often. It stops when ctx is done.
+func pollOIDC(ctx context.Context, client *Client, mount string, auth oidcAuthURL, clientNonce string) (string, error) {
+	data := map[string]interface{}{
+		"state":        auth.state,
+		"client_nonce": clientNonce,
 	}
+	interval := auth.pollInterval
+	deadline := time.Now().Add(oidcPollTimeout)
+
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return "", fmt.Errorf("polling for OIDC login: %w", ctx.Err())
+		}
+
+		secret, err := client.inner.Logical().WriteWithContext(ctx, "auth/"+mount+"/oidc/poll", data)
+		switch {
+		case ctx.Err() != nil:
+			return "", fmt.Errorf("polling for OIDC login: %w", ctx.Err())
+		case err == nil:
+			if secret == nil || secret.Auth == nil {
+				return "", fmt.Errorf("polling for OIDC login: empty auth response")
+			}
+			return secret.Auth.ClientToken, nil
+		case isPollError(err, "slow_down"):
+			interval += defaultPollInterval
+		case !isPollError(err, "authorization_pending"):
+			return "", fmt.Errorf("polling for OIDC login: %w", err)
+		}
+
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("OIDC authentication timed out after %s", oidcPollTimeout)
+		}
+	}
+}
+
+// isPollError reports whether err is Vault answering a poll with code, such
+// as "authorization_pending".
+func isPollError(err error, code string) bool {
+	var respErr *vaultapi.ResponseError
+	if !errors.As(err, &respErr) {
+		return false
+	}
+	return slices.ContainsFunc(respErr.Errors, func(e string) bool {
+		return strings.HasSuffix(e, code)
+	})
 }
 
 // exchangeOIDCCode exchanges the authorization code and state for a Vault token.
 // The callback endpoint expects a GET (ReadWithData), not a PUT/POST, matching
 // the official vault CLI behaviour.
-func exchangeOIDCCode(client *Client, code string, state string, clientNonce string) (string, error) {
+func exchangeOIDCCode(client *Client, mount string, code string, state string, clientNonce string) (string, error) {
 	data := map[string][]string{
 		"code":         {code},
 		"state":        {state},
 		"client_nonce": {clientNonce},
 	}
 
-	secret, err := client.inner.Logical().ReadWithData("auth/oidc/oidc/callback", data)
+	secret, err := client.inner.Logical().ReadWithData("auth/"+mount+"/oidc/callback", data)
 	if err != nil {
 		return "", fmt.Errorf("exchanging OIDC code for token: %w", err)
 	}
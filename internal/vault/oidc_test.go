@@ -0,0 +1,300 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOIDCEndpointsUseMount(t *testing.T) {
+	var paths []string
+	var sentNonce string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/v1/auth/okta/oidc/auth_url":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			sentNonce = body["client_nonce"]
+			w.Write([]byte(`{"data":{"auth_url":"https://idp.example.com/authorize?state=st1&nonce=n1"}}`))
+		case "/v1/auth/okta/oidc/callback":
+			q := r.URL.Query()
+			if q.Get("code") != "c1" || q.Get("client_nonce") != sentNonce {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"auth":{"client_token":"s.okta"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":["no handler for route"]}`))
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	nonce, err := newClientNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := requestAuthURL(client, "okta", "dev", "http://localhost:8250/oidc/callback", nonce)
+	if err != nil {
+		t.Fatalf("requestAuthURL() error = %v", err)
+	}
+	if auth.url != "https://idp.example.com/authorize?state=st1&nonce=n1" || auth.state != "st1" || auth.poll || sentNonce != nonce {
+		t.Errorf("requestAuthURL() = %+v; sent nonce %q", auth, sentNonce)
+	}
+
+	tok, err := exchangeOIDCCode(client, "okta", "c1", auth.state, nonce)
+	if err != nil {
+		t.Fatalf("exchangeOIDCCode() error = %v", err)
+	}
+	if tok != "s.okta" {
+		t.Errorf("token = %q, want %q", tok, "s.okta")
+	}
+
+	if _, err := requestAuthURL(client, "oidc", "dev", "http://localhost:8250/oidc/callback", nonce); err == nil {
+		t.Error("requestAuthURL() expected error for unmounted path")
+	}
+	if len(paths) != 3 {
+		t.Errorf("requests = %v", paths)
+	}
+}
+
+func TestWithOIDCMount_IgnoresEmpty(t *testing.T) {
+	s := oidcSettings{mount: DefaultOIDCMount}
+	WithOIDCMount("")(&s)
+	if s.mount != DefaultOIDCMount {
+		t.Errorf("mount = %q, want %q", s.mount, DefaultOIDCMount)
+	}
+}
+
+func TestCheckAuthURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{"https://idp.example.com/authorize?state=s1&nonce=n1", "s1", false},
+		{"https://idp.example.com/authorize?state=s1&nonce=n1&code_challenge=abc&code_challenge_method=S256", "s1", false},
+		{"https://idp.example.com/authorize?state=s1&nonce=n1&code_challenge=abc&code_challenge_method=plain", "", true},
+		{"https://idp.example.com/authorize?state=s1&nonce=n1&code_challenge=abc", "", true},
+		{"https://idp.example.com/authorize?nonce=n1", "", true},
+		{"https://idp.example.com/authorize?state=s1", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := checkAuthURL(tt.url)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("checkAuthURL(%q) = %q, %v; want %q, error %v", tt.url, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestWaitForCallback_State(t *testing.T) {
+	callback := func(query string) (*oidcCallbackResult, int, error) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+
+		type out struct {
+			result *oidcCallbackResult
+			err    error
+		}
+		done := make(chan out, 1)
+		go func() {
+			r, err := waitForCallback(context.Background(), listener, "st1")
+			done <- out{r, err}
+		}()
+
+		resp, err := http.Get("http://" + listener.Addr().String() + "/oidc/callback?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		o := <-done
+		return o.result, resp.StatusCode, o.err
+	}
+
+	result, status, err := callback("code=c1&state=st1")
+	if err != nil || result.code != "c1" || status != http.StatusOK {
+		t.Errorf("matching state: result %+v, status %d, error %v", result, status, err)
+	}
+
+	_, status, err = callback("code=c1&state=forged")
+	if !errors.Is(err, errStateMismatch) || status != http.StatusBadRequest {
+		t.Errorf("mismatched state: status %d, error %v; want %v", status, err, errStateMismatch)
+	}
+
+	_, _, err = callback("code=c1")
+	if !errors.Is(err, errStateMismatch) {
+		t.Errorf("missing state: error %v; want %v", err, errStateMismatch)
+	}
+}
+
+func TestListenForCallback(t *testing.T) {
+	listen := func() (net.Listener, int) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ln, ln.Addr().(*net.TCPAddr).Port
+	}
+
+	busy, busyPort := listen()
+	defer busy.Close()
+	free, freePort := listen()
+	free.Close()
+
+	ln, port, err := listenForCallback([]int{busyPort, freePort})
+	if err != nil {
+		t.Fatalf("listenForCallback() error = %v", err)
+	}
+	ln.Close()
+	if port != freePort {
+		t.Errorf("port = %d, want the first free one, %d", port, freePort)
+	}
+
+	_, _, err = listenForCallback([]int{busyPort})
+	if !errors.Is(err, ErrNoCallbackPort) {
+		t.Fatalf("all ports busy: error = %v, want %v", err, ErrNoCallbackPort)
+	}
+	if !strings.Contains(err.Error(), strconv.Itoa(busyPort)) {
+		t.Errorf("error %q does not list the port tried", err)
+	}
+}
+
+func TestWithCallbackPorts_IgnoresEmpty(t *testing.T) {
+	s := oidcSettings{ports: []int{oidcCallbackPort}}
+	WithCallbackPorts()(&s)
+	if len(s.ports) != 1 || s.ports[0] != oidcCallbackPort {
+		t.Errorf("ports = %v, want [%d]", s.ports, oidcCallbackPort)
+	}
+}
+
+func TestOIDCDeviceFlow(t *testing.T) {
+	var polls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/v1/auth/oidc/oidc/auth_url":
+			w.Write([]byte(`{"data":{"auth_url":"https://idp.example.com/device","user_code":"WDJB-MJHT","state":"st1","poll_interval":"1"}}`))
+		case "/v1/auth/oidc/oidc/poll":
+			if body["state"] != "st1" || body["client_nonce"] == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":["invalid state"]}`))
+				return
+			}
+			if polls++; polls < 2 {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":["authorization_pending"]}`))
+				return
+			}
+			w.Write([]byte(`{"auth":{"client_token":"s.device"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	var out strings.Builder
+	err = OIDCAuth(context.Background(), client, "dev", WithCallbackPorts(0), WithOutput(&out), WithoutBrowser())
+	if err != nil {
+		t.Fatalf("OIDCAuth() error = %v", err)
+	}
+	if client.Token() != "s.device" || polls != 2 {
+		t.Errorf("token = %q after %d polls, want %q after 2", client.Token(), polls, "s.device")
+	}
+	if !strings.Contains(out.String(), "https://idp.example.com/device") || !strings.Contains(out.String(), "WDJB-MJHT") {
+		t.Errorf("instructions = %q, want the URL and the code", out.String())
+	}
+}
+
+func TestOIDCPoll_Denied(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"errors":["access_denied"]}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := pollOIDC(context.Background(), client, "oidc", oidcAuthURL{state: "st1", poll: true}, "n1"); err == nil {
+		t.Error("pollOIDC() succeeded after access_denied")
+	}
+}
+
+func TestOIDCPoll_Cancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"errors":["authorization_pending"]}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	auth := oidcAuthURL{state: "st1", poll: true, pollInterval: 10 * time.Millisecond}
+	if _, err := pollOIDC(ctx, client, "oidc", auth, "n1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("pollOIDC() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestPresent_WithoutBrowser(t *testing.T) {
+	var out strings.Builder
+	s := oidcSettings{out: &out, noBrowser: true}
+	auth := oidcAuthURL{url: "https://idp.example.com/authorize?state=st1&nonce=n1", state: "st1"}
+	if err := s.present(auth, 8251); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), auth.url) || !strings.Contains(out.String(), "ssh -L 8251:localhost:8251") {
+		t.Errorf("instructions = %q, want the URL and how to forward the callback port", out.String())
+	}
+
+	if err := (oidcSettings{}).present(oidcAuthURL{url: "https://idp.example.com/device", userCode: "C", poll: true}, 8250); err == nil {
+		t.Error("present() without output accepted a device code it cannot show")
+	}
+}
+
+func TestPollInterval(t *testing.T) {
+	tests := []struct {
+		in   any
+		want time.Duration
+	}{
+		{"7", 7 * time.Second},
+		{json.Number("3"), 3 * time.Second},
+		{nil, defaultPollInterval},
+		{"soon", defaultPollInterval},
+		{"0", defaultPollInterval},
+		{json.Number("-2"), defaultPollInterval},
+	}
+	for _, tt := range tests {
+		if got := pollInterval(tt.in); got != tt.want {
+			t.Errorf("pollInterval(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package vault
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// CorrelationHeader is the request header carrying the per-invocation
+// correlation ID. Vault only records it in audit logs when the header is
+// listed in sys/config/auditing/request-headers.
+const CorrelationHeader = "X-Correlation-Id"
+
+// W3C Trace Context headers, forwarded from the TRACEPARENT and TRACESTATE
+// environment variables when present.
+const (
+	traceParentHeader = "Traceparent"
+	traceStateHeader  = "Tracestate"
+)
+
+var (
+	correlationOnce sync.Once
+	correlationID   string
+)
+
+// CorrelationID returns the correlation ID for this process. It is taken from
+// VX_CORRELATION_ID when set (e.g. a CI job ID) and otherwise generated once
+// and reused for every request of the invocation.
+func CorrelationID() string {
+	correlationOnce.Do(func() {
+		correlationID = os.Getenv("VX_CORRELATION_ID")
+		if correlationID == "" {
+			correlationID = newCorrelationID()
+		}
+	})
+	return correlationID
+}
+
+// TraceHeaders returns the headers that tag requests with the given
+// correlation ID and, when the environment carries a W3C trace context, the
+// caller's traceparent and tracestate.
+func TraceHeaders(correlationID string) http.Header {
+	h := make(http.Header)
+	if correlationID != "" {
+		h.Set(CorrelationHeader, correlationID)
+	}
+
+	if tp := os.Getenv("TRACEPARENT"); tp != "" {
+		h.Set(traceParentHeader, tp)
+		if ts := os.Getenv("TRACESTATE"); ts != "" {
+			h.Set(traceStateHeader, ts)
+		}
+	}
+
+	return h
+}
+
+// newCorrelationID returns 16 random bytes, hex encoded.
+func newCorrelationID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b[:])
+}
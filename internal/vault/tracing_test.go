@@ -0,0 +1,70 @@
+package vault
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTraceHeaders(t *testing.T) {
+	t.Setenv("TRACEPARENT", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	t.Setenv("TRACESTATE", "vendor=value")
+
+	h := TraceHeaders("abc123")
+
+	if got := h.Get(CorrelationHeader); got != "abc123" {
+		t.Errorf("%s = %q, want %q", CorrelationHeader, got, "abc123")
+	}
+	if got := h.Get("traceparent"); got != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf("traceparent = %q", got)
+	}
+	if got := h.Get("tracestate"); got != "vendor=value" {
+		t.Errorf("tracestate = %q", got)
+	}
+}
+
+func TestTraceHeaders_NoTraceContext(t *testing.T) {
+	t.Setenv("TRACEPARENT", "")
+
+	h := TraceHeaders("abc123")
+
+	if got := h.Get("traceparent"); got != "" {
+		t.Errorf("traceparent = %q, want empty", got)
+	}
+	if len(h) != 1 {
+		t.Errorf("expected only the correlation header, got %v", h)
+	}
+}
+
+func TestNewCorrelationID(t *testing.T) {
+	a, b := newCorrelationID(), newCorrelationID()
+	if len(a) != 32 {
+		t.Errorf("len(id) = %d, want 32", len(a))
+	}
+	if a == b {
+		t.Error("expected distinct correlation IDs")
+	}
+}
+
+func TestWithHeaders_SentOnRequests(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(CorrelationHeader)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.test", WithHeaders(TraceHeaders("req-1")))
+	if err != nil {
+		t.Fatalf("NewClientWithToken() error = %v", err)
+	}
+
+	if _, err := client.ReadKV(context.Background(), "dev/app"); err != nil {
+		t.Fatalf("ReadKV() error = %v", err)
+	}
+
+	if got != "req-1" {
+		t.Errorf("server saw %s = %q, want %q", CorrelationHeader, got, "req-1")
+	}
+}
@@ -0,0 +1,85 @@
+package vault
+
+import (
+	"fmt"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// WrappedSecret is a single-use response-wrapping token that holds values
+// in Vault's cubbyhole until it is unwrapped or expires.
+type WrappedSecret struct {
+	Token   string
+	TTL     time.Duration
+	Expires time.Time
+}
+
+// Wrap stores values behind a new wrapping token that expires after ttl.
+// The token can be unwrapped exactly once, by anyone who holds it, without
+// any other Vault access.
+func (c *Client) Wrap(values map[string]string, ttl time.Duration) (*WrappedSecret, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("wrapping: ttl must be positive")
+	}
+
+	// The wrap TTL is a per-request setting; use a copy of the client so
+	// other requests are not wrapped.
+	inner, err := c.inner.CloneWithHeaders()
+	if err != nil {
+		return nil, fmt.Errorf("wrapping: %w", err)
+	}
+	inner.SetToken(c.inner.Token())
+	wrapTTL := fmt.Sprintf("%ds", int(ttl.Seconds()))
+	inner.SetWrappingLookupFunc(func(operation, path string) string {
+		return wrapTTL
+	})
+
+	data := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		data[k] = v
+	}
+
+	secret, err := inner.Logical().Write("sys/wrapping/wrap", data)
+	if err != nil {
+		return nil, fmt.Errorf("wrapping: %w", err)
+	}
+	if secret == nil || secret.WrapInfo == nil || secret.WrapInfo.Token == "" {
+		return nil, fmt.Errorf("wrapping: response has no wrapping token")
+	}
+
+	info := secret.WrapInfo
+	ttl = time.Duration(info.TTL) * time.Second
+	return &WrappedSecret{
+		Token:   info.Token,
+		TTL:     ttl,
+		Expires: info.CreationTime.Add(ttl),
+	}, nil
+}
+
+// Unwrap returns the values behind a wrapping token, which is then used up.
+// The client needs no token of its own: the wrapping token authorizes the
+// request.
+func (c *Client) Unwrap(wrappingToken string) (map[string]string, error) {
+	secret, err := c.inner.Logical().Unwrap(wrappingToken)
+	if err != nil {
+		return nil, fmt.Errorf("unwrapping: %w", err)
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("unwrapping: token is invalid, expired, or already used")
+	}
+	return unwrappedValues(secret), nil
+}
+
+// unwrappedValues converts the data of an unwrapped secret to strings.
+func unwrappedValues(secret *vaultapi.Secret) map[string]string {
+	values := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		if s, ok := v.(string); ok {
+			values[k] = s
+		} else {
+			values[k] = fmt.Sprint(v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,92 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWrap(t *testing.T) {
+	var body map[string]interface{}
+	var wrapTTL, token string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/sys/wrapping/wrap" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		wrapTTL = r.Header.Get("X-Vault-Wrap-TTL")
+		token = r.Header.Get("X-Vault-Token")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"wrap_info":{"token":"hvs.wrapped","ttl":900,"creation_time":"2026-01-01T10:00:00Z"}}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClientWithToken(srv.URL, "secret", "s.user")
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	wrapped, err := client.Wrap(map[string]string{"DATABASE_URL": "postgres://"}, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+
+	if wrapped.Token != "hvs.wrapped" || wrapped.TTL != 15*time.Minute {
+		t.Errorf("Wrap() = %+v", wrapped)
+	}
+	if want := time.Date(2026, 1, 1, 10, 15, 0, 0, time.UTC); !wrapped.Expires.Equal(want) {
+		t.Errorf("Expires = %v, want %v", wrapped.Expires, want)
+	}
+	if wrapTTL != "900s" || token != "s.user" {
+		t.Errorf("headers: wrap ttl %q, token %q", wrapTTL, token)
+	}
+	if body["DATABASE_URL"] != "postgres://" {
+		t.Errorf("body = %v", body)
+	}
+
+	// Only the wrap request itself is wrapped.
+	if client.inner.CurrentWrappingLookupFunc() != nil {
+		t.Error("Wrap() left the client wrapping every response")
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	var token string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/sys/wrapping/unwrap" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		token = r.Header.Get("X-Vault-Token")
+		w.Write([]byte(`{"data":{"DATABASE_URL":"postgres://"}}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(srv.URL, "secret")
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	values, err := client.Unwrap("hvs.wrapped")
+	if err != nil {
+		t.Fatalf("Unwrap() error = %v", err)
+	}
+	if values["DATABASE_URL"] != "postgres://" {
+		t.Errorf("Unwrap() = %v", values)
+	}
+	if token != "hvs.wrapped" {
+		t.Errorf("X-Vault-Token = %q, want the wrapping token", token)
+	}
+}
+
+func TestWrap_RequiresTTL(t *testing.T) {
+	client, err := NewClient("http://127.0.0.1:1", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	if _, err := client.Wrap(map[string]string{"A": "b"}, 0); err == nil {
+		t.Error("expected error for zero ttl, got nil")
+	}
+}
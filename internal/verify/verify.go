@@ -0,0 +1,145 @@
+// Package verify checks that a Vault token's read access matches what a
+// workspace needs: every mapped path must be readable, and nothing outside
+// the allowed prefixes may be.
+package verify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.dot.industries/vx/internal/resolver"
+	"go.dot.industries/vx/internal/vault"
+)
+
+// ProbeName is the final segment of the synthetic paths probed to detect
+// wildcard grants. It is never expected to exist in Vault.
+const ProbeName = "vx-verify-access-probe"
+
+// CapabilityChecker is the subset of the Vault client used for verification.
+type CapabilityChecker interface {
+	Capabilities(kvPaths []string) (map[string][]string, error)
+}
+
+// PathResult is the outcome for one required Vault path.
+type PathResult struct {
+	Path     string
+	EnvVars  []string
+	Readable bool
+}
+
+// Result is the outcome of a verification run.
+type Result struct {
+	// Required lists every path the workspace reads, sorted by path.
+	Required []PathResult
+	// Excess lists readable paths outside the allowed prefixes, sorted.
+	Excess []string
+	// Probed is the number of paths checked for excess access.
+	Probed int
+}
+
+// OK reports whether every required path is readable and no excess access
+// was found.
+func (r *Result) OK() bool {
+	if len(r.Excess) > 0 {
+		return false
+	}
+	for _, p := range r.Required {
+		if !p.Readable {
+			return false
+		}
+	}
+	return true
+}
+
+// RequiredPaths groups secret mappings by the Vault path they read, after
+// interpolating env. Mappings pinned to a version need the same access as
+// the latest, so they share its path. Env var names within each path are
+// sorted.
+func RequiredPaths(secrets map[string]string, env string) map[string][]string {
+	out := make(map[string][]string)
+	for _, mappings := range resolver.GroupByPath(secrets, env) {
+		for _, m := range mappings {
+			path, _ := resolver.SplitMapping(secrets[m.EnvVar], env)
+			out[path] = append(out[path], m.EnvVar)
+		}
+	}
+	for path := range out {
+		sort.Strings(out[path])
+	}
+	return out
+}
+
+// SyntheticProbes returns made-up paths at the mount root and under each
+// environment. A token that can read one of them holds a wildcard grant.
+func SyntheticProbes(environments []string) []string {
+	probes := []string{ProbeName}
+	for _, env := range environments {
+		probes = append(probes, env+"/"+ProbeName)
+	}
+	return probes
+}
+
+// Allowed reports whether path lies under one of the prefixes. A prefix
+// matches whole segments, so "dev/api" allows "dev/api" and "dev/api/db" but
+// not "dev/apis".
+func Allowed(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		prefix = strings.Trim(prefix, "/")
+		if prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Check asks Vault for the token's capabilities on the required paths and on
+// every probe that is neither required nor under an allowed prefix. Required
+// paths must be readable; readable probes are reported as excess access.
+func Check(c CapabilityChecker, required map[string][]string, probes []string, allowed []string) (*Result, error) {
+	var paths []string
+	for path := range required {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	seen := make(map[string]bool, len(paths)+len(probes))
+	for _, p := range paths {
+		seen[p] = true
+	}
+
+	var outside []string
+	for _, p := range probes {
+		if seen[p] || Allowed(p, allowed) {
+			continue
+		}
+		seen[p] = true
+		outside = append(outside, p)
+	}
+	sort.Strings(outside)
+
+	if len(paths) == 0 && len(outside) == 0 {
+		return nil, fmt.Errorf("nothing to verify: no mapped secrets and no paths to probe")
+	}
+
+	caps, err := c.Capabilities(append(append([]string{}, paths...), outside...))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Probed: len(outside)}
+	for _, p := range paths {
+		result.Required = append(result.Required, PathResult{
+			Path:     p,
+			EnvVars:  required[p],
+			Readable: vault.CanRead(caps[p]),
+		})
+	}
+	for _, p := range outside {
+		if vault.CanRead(caps[p]) {
+			result.Excess = append(result.Excess, p)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,124 @@
+package verify
+
+import (
+	"reflect"
+	"testing"
+)
+
+type fakeChecker struct {
+	caps    map[string][]string
+	queried []string
+}
+
+func (f *fakeChecker) Capabilities(kvPaths []string) (map[string][]string, error) {
+	f.queried = append(f.queried, kvPaths...)
+	out := make(map[string][]string, len(kvPaths))
+	for _, p := range kvPaths {
+		out[p] = f.caps[p]
+	}
+	return out, nil
+}
+
+func TestRequiredPaths(t *testing.T) {
+	got := RequiredPaths(map[string]string{
+		"DATABASE_URL":  "${env}/database/url",
+		"DATABASE_USER": "${env}/database/user",
+		"DATABASE_OLD":  "${env}/database/url@2",
+		"STRIPE_KEY":    "shared/stripe/key",
+		"BROKEN":        "nokey",
+	}, "dev")
+
+	want := map[string][]string{
+		"dev/database":  {"DATABASE_OLD", "DATABASE_URL", "DATABASE_USER"},
+		"shared/stripe": {"STRIPE_KEY"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RequiredPaths() = %v, want %v", got, want)
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	prefixes := []string{"dev/api", "shared/"}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"dev/api", true},
+		{"dev/api/db", true},
+		{"dev/apis", false},
+		{"shared/stripe", true},
+		{"prod/api", false},
+	}
+
+	for _, tt := range tests {
+		if got := Allowed(tt.path, prefixes); got != tt.want {
+			t.Errorf("Allowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	checker := &fakeChecker{caps: map[string][]string{
+		"dev/database":         {"read"},
+		"shared/stripe":        {"list"},
+		"prod/database":        {"read"},
+		"dev/cache":            {"read"},
+		"staging/" + ProbeName: {"deny"},
+		"prod/" + ProbeName:    {"read"},
+		"dev/" + ProbeName:     {"read"},
+	}}
+
+	required := map[string][]string{
+		"dev/database":  {"DATABASE_URL"},
+		"shared/stripe": {"STRIPE_KEY"},
+	}
+	probes := append([]string{"dev/database", "prod/database", "dev/cache"},
+		SyntheticProbes([]string{"dev", "staging", "prod"})...)
+
+	result, err := Check(checker, required, probes, []string{"dev"})
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	wantRequired := []PathResult{
+		{Path: "dev/database", EnvVars: []string{"DATABASE_URL"}, Readable: true},
+		{Path: "shared/stripe", EnvVars: []string{"STRIPE_KEY"}, Readable: false},
+	}
+	if !reflect.DeepEqual(result.Required, wantRequired) {
+		t.Errorf("Required = %+v, want %+v", result.Required, wantRequired)
+	}
+
+	// dev/* is allowed, so dev/cache and the dev probe are not checked.
+	wantExcess := []string{"prod/database", "prod/" + ProbeName}
+	if !reflect.DeepEqual(result.Excess, wantExcess) {
+		t.Errorf("Excess = %v, want %v", result.Excess, wantExcess)
+	}
+	if result.Probed != 4 {
+		t.Errorf("Probed = %d, want 4 (%v)", result.Probed, checker.queried)
+	}
+	if result.OK() {
+		t.Error("OK() = true, want false")
+	}
+}
+
+func TestCheck_OK(t *testing.T) {
+	checker := &fakeChecker{caps: map[string][]string{
+		"dev/database": {"read"},
+	}}
+
+	result, err := Check(checker, map[string][]string{"dev/database": {"DATABASE_URL"}},
+		SyntheticProbes([]string{"dev", "prod"}), nil)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !result.OK() {
+		t.Errorf("OK() = false, want true: %+v", result)
+	}
+}
+
+func TestCheck_NothingToVerify(t *testing.T) {
+	if _, err := Check(&fakeChecker{}, nil, nil, nil); err == nil {
+		t.Error("Check() expected error with no paths")
+	}
+}
@@ -0,0 +1,318 @@
+// Package workspaces edits the workspaces array of the root vx.toml, so
+// workspaces can be added, removed, and moved without hand-editing the file.
+// Writes go through tomlfile, keeping comments and layout intact.
+package workspaces
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/creachadair/tomledit"
+	"github.com/creachadair/tomledit/parser"
+
+	"go.dot.industries/vx/internal/config"
+	"go.dot.industries/vx/internal/tomlfile"
+)
+
+// ConfigFile is the name of a workspace's config file.
+const ConfigFile = "vx.toml"
+
+// Entry is one workspace of the root config.
+type Entry struct {
+	// Name is the workspace's directory name, which -w selects.
+	Name string
+	// Path is the vx.toml as written in the workspaces array, relative to
+	// the root, e.g. "services/api/vx.toml".
+	Path string
+}
+
+// Editor is a parsed root vx.toml whose workspaces array can be changed in
+// memory. Nothing is written until Write is called.
+type Editor struct {
+	file    string
+	rootDir string
+	doc     *tomledit.Document
+}
+
+// Open reads the root vx.toml at rootPath.
+func Open(rootPath string) (*Editor, error) {
+	doc, err := tomlfile.Read(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Editor{file: rootPath, rootDir: filepath.Dir(rootPath), doc: doc}, nil
+}
+
+// errAuto is returned by edits of a root config that discovers its
+// workspaces instead of listing them.
+var errAuto = errors.New(`the root vx.toml discovers its workspaces (workspaces = "auto"); add or remove a workspace's vx.toml instead`)
+
+// Auto reports whether the root config sets workspaces = "auto", which
+// Add, Remove, and Rename refuse to change.
+func (e *Editor) Auto() bool {
+	kv := e.array()
+	if kv == nil {
+		return false
+	}
+	s, ok := tomlfile.StringValue(kv.Value)
+	return ok && s == config.WorkspacesAuto
+}
+
+// Discovered returns the workspaces of a root config at rootDir that sets
+// workspaces = "auto" (see config.DiscoverWorkspaces).
+func Discovered(rootDir string) ([]Entry, error) {
+	paths, err := config.DiscoverWorkspaces(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, len(paths))
+	for i, p := range paths {
+		entries[i] = newEntry(p)
+	}
+	return entries, nil
+}
+
+// List returns the workspaces in the order they are configured.
+func (e *Editor) List() []Entry {
+	var entries []Entry
+	for _, item := range e.items() {
+		if v, ok := item.(parser.Value); ok {
+			if p, ok := tomlfile.StringValue(v); ok {
+				entries = append(entries, newEntry(p))
+			}
+		}
+	}
+	return entries
+}
+
+// Add appends the workspace at p, a directory or its vx.toml, either
+// absolute or relative to the root. The file must exist inside the root and
+// parse as a workspace config, and neither it nor another workspace of the
+// same name may be configured already.
+func (e *Editor) Add(p string) (Entry, error) {
+	if e.Auto() {
+		return Entry{}, errAuto
+	}
+	entry, err := e.check(p, "")
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if kv := e.array(); kv != nil {
+		arr, _ := kv.Value.X.(parser.Array)
+		kv.Value.X = append(arr, tomlfile.QuotedValue(entry.Path, ""))
+		return entry, nil
+	}
+
+	// No workspaces array yet: add one after the global keys, ahead of the
+	// first table.
+	if e.doc.Global == nil {
+		e.doc.Global = &tomledit.Section{}
+	}
+	value, err := parser.ParseValue("[" + tomlfile.Quote(entry.Path) + "]")
+	if err != nil {
+		return Entry{}, err
+	}
+	e.doc.Global.Items = append(e.doc.Global.Items, &parser.KeyValue{
+		Name:  parser.Key{"workspaces"},
+		Value: value,
+	})
+	return entry, nil
+}
+
+// Remove drops the workspace named name, or configured at that path, from
+// the array. A workspace that [services] still runs in cannot be removed.
+func (e *Editor) Remove(name string) (Entry, error) {
+	if e.Auto() {
+		return Entry{}, errAuto
+	}
+	i, entry, err := e.find(name)
+	if err != nil {
+		return Entry{}, err
+	}
+	if svc := e.servicesUsing(entry.Name); len(svc) > 0 {
+		return Entry{}, fmt.Errorf("workspace %q is used by services %s; change their workspace first",
+			entry.Name, strings.Join(svc, ", "))
+	}
+
+	kv := e.array()
+	kv.Value.X = slices.Delete(e.items(), i, i+1)
+	return entry, nil
+}
+
+// Rename points the workspace named name at newPath, e.g. after its
+// directory was moved, keeping its position and any trailing comment.
+// newPath is checked as for Add. When the directory name changes, [services]
+// entries that run in the workspace are updated to the new name; their names
+// are returned.
+func (e *Editor) Rename(name, newPath string) (old, renamed Entry, services []string, err error) {
+	if e.Auto() {
+		return Entry{}, Entry{}, nil, errAuto
+	}
+	i, old, err := e.find(name)
+	if err != nil {
+		return Entry{}, Entry{}, nil, err
+	}
+	renamed, err = e.check(newPath, old.Path)
+	if err != nil {
+		return Entry{}, Entry{}, nil, err
+	}
+	if renamed.Path == clean(old.Path) {
+		return Entry{}, Entry{}, nil, fmt.Errorf("workspace %q is already at %s", old.Name, old.Path)
+	}
+
+	arr := e.items()
+	arr[i] = tomlfile.QuotedValue(renamed.Path, arr[i].(parser.Value).Trailer)
+
+	if renamed.Name != old.Name {
+		services = e.renameServices(old.Name, renamed.Name)
+	}
+	return old, renamed, services, nil
+}
+
+// Write saves the edited root vx.toml, keeping the file's permissions.
+func (e *Editor) Write() error {
+	return tomlfile.Write(e.file, e.doc)
+}
+
+// check validates p as a workspace to configure and returns its entry.
+// except is the path of an entry being replaced, which p may duplicate.
+func (e *Editor) check(p, except string) (Entry, error) {
+	abs := p
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(e.rootDir, abs)
+	}
+	if info, err := os.Stat(abs); err == nil && info.IsDir() {
+		abs = filepath.Join(abs, ConfigFile)
+	}
+
+	rel, err := filepath.Rel(e.rootDir, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return Entry{}, fmt.Errorf("%s is outside the root directory %s", p, e.rootDir)
+	}
+	entry := newEntry(filepath.ToSlash(rel))
+	if filepath.Dir(rel) == "." {
+		return Entry{}, fmt.Errorf("%s is the root config, not a workspace", p)
+	}
+
+	if _, err := os.Stat(abs); err != nil {
+		return Entry{}, fmt.Errorf("%s does not exist", entry.Path)
+	}
+	if _, err := config.LoadWorkspaceConfig(abs); err != nil {
+		return Entry{}, err
+	}
+
+	for _, existing := range e.List() {
+		if clean(existing.Path) == clean(except) {
+			continue
+		}
+		if clean(existing.Path) == entry.Path {
+			return Entry{}, fmt.Errorf("workspace %s is already configured", entry.Path)
+		}
+		if existing.Name == entry.Name {
+			return Entry{}, fmt.Errorf("a workspace named %q is already configured at %s", entry.Name, existing.Path)
+		}
+	}
+	return entry, nil
+}
+
+// find returns the index in the array and the entry of the workspace named
+// name or configured at that path.
+func (e *Editor) find(name string) (int, Entry, error) {
+	for i, item := range e.items() {
+		v, ok := item.(parser.Value)
+		if !ok {
+			continue
+		}
+		p, ok := tomlfile.StringValue(v)
+		if !ok {
+			continue
+		}
+		entry := newEntry(p)
+		if entry.Name == name || clean(entry.Path) == clean(name) {
+			return i, entry, nil
+		}
+	}
+	return 0, Entry{}, fmt.Errorf("workspace %q is not configured", name)
+}
+
+// serviceSetting is the workspace setting of a [services.<name>] table.
+type serviceSetting struct {
+	service string
+	kv      *parser.KeyValue
+}
+
+// servicesUsing returns the names of the [services] that run in workspace.
+func (e *Editor) servicesUsing(workspace string) []string {
+	var names []string
+	for _, s := range e.serviceWorkspaces() {
+		if v, ok := tomlfile.StringValue(s.kv.Value); ok && v == workspace {
+			names = append(names, s.service)
+		}
+	}
+	return names
+}
+
+// renameServices points the [services] that run in workspace from at to
+// instead, returning their names.
+func (e *Editor) renameServices(from, to string) []string {
+	var names []string
+	for _, s := range e.serviceWorkspaces() {
+		if v, ok := tomlfile.StringValue(s.kv.Value); ok && v == from {
+			s.kv.Value = tomlfile.QuotedValue(to, s.kv.Value.Trailer)
+			names = append(names, s.service)
+		}
+	}
+	return names
+}
+
+// serviceWorkspaces returns the workspace settings of [services.<name>]
+// tables.
+func (e *Editor) serviceWorkspaces() []serviceSetting {
+	var settings []serviceSetting
+	for _, s := range e.doc.Sections {
+		if s.Heading == nil || len(s.Heading.Name) != 2 || s.Heading.Name[0] != "services" {
+			continue
+		}
+		for _, item := range s.Items {
+			if kv, ok := item.(*parser.KeyValue); ok && kv.Name.Equals(parser.Key{"workspace"}) {
+				settings = append(settings, serviceSetting{service: s.Heading.Name[1], kv: kv})
+			}
+		}
+	}
+	return settings
+}
+
+// array returns the workspaces setting, or nil if the file has none.
+func (e *Editor) array() *parser.KeyValue {
+	entry := e.doc.First("workspaces")
+	if entry == nil || !entry.IsMapping() {
+		return nil
+	}
+	return entry.KeyValue
+}
+
+// items returns the elements of the workspaces array, values and comments.
+func (e *Editor) items() parser.Array {
+	kv := e.array()
+	if kv == nil {
+		return nil
+	}
+	arr, _ := kv.Value.X.(parser.Array)
+	return arr
+}
+
+// newEntry returns the entry for a path as written in the workspaces array.
+func newEntry(p string) Entry {
+	return Entry{Name: filepath.Base(filepath.Dir(filepath.FromSlash(p))), Path: p}
+}
+
+// clean normalizes a workspace path for comparison, e.g. "./api/vx.toml"
+// to "api/vx.toml".
+func clean(p string) string {
+	return filepath.ToSlash(filepath.Clean(filepath.FromSlash(p)))
+}
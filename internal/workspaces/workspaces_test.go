@@ -0,0 +1,215 @@
+package workspaces
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const rootTOML = `# Workspaces in build order
+workspaces = ["web/vx.toml", "billing/vx.toml"] # keep short
+
+[vault]
+address = "https://vault.example.com"
+auth_method = "oidc"
+
+[environments]
+default = "dev"
+available = ["dev"]
+
+[services.invoices]
+command = "npm start"
+workspace = "billing" # runs the worker
+`
+
+// writeFixture creates a repository with the root config above and a
+// workspace vx.toml in each of dirs, returning the root vx.toml's path.
+func writeFixture(t *testing.T, root string, dirs ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, d := range dirs {
+		ws := filepath.Join(dir, d, ConfigFile)
+		if err := os.MkdirAll(filepath.Dir(ws), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(ws, []byte("[secrets]\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, ConfigFile)
+	if err := os.WriteFile(path, []byte(root), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func open(t *testing.T, path string) *Editor {
+	t.Helper()
+	ed, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return ed
+}
+
+func read(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestList(t *testing.T) {
+	ed := open(t, writeFixture(t, rootTOML))
+
+	got := ed.List()
+	if len(got) != 2 || got[0] != (Entry{"web", "web/vx.toml"}) || got[1] != (Entry{"billing", "billing/vx.toml"}) {
+		t.Errorf("List() = %+v", got)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	path := writeFixture(t, rootTOML, "web", "billing", "services/api", "other/web")
+	dir := filepath.Dir(path)
+	ed := open(t, path)
+
+	entry, err := ed.Add(filepath.Join(dir, "services", "api"))
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if entry != (Entry{"api", "services/api/vx.toml"}) {
+		t.Errorf("Add() = %+v", entry)
+	}
+
+	for _, p := range []string{
+		"web",                  // already configured
+		"./web/vx.toml",        // the same, spelled differently
+		"other/web",            // name clash
+		"missing",              // no vx.toml
+		".",                    // the root config
+		filepath.Dir(dir),      // outside the root
+		"services/api/vx.toml", // added above
+	} {
+		if _, err := ed.Add(p); err == nil {
+			t.Errorf("Add(%q) expected error", p)
+		}
+	}
+
+	if err := ed.Write(); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	got := read(t, path)
+	for _, want := range []string{
+		`"services/api/vx.toml"`,
+		"# Workspaces in build order",
+		"# keep short",
+		"# runs the worker",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("written file lacks %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestAdd_noArray(t *testing.T) {
+	path := writeFixture(t, "[vault]\naddress = \"https://vault.example.com\"\n", "api")
+	ed := open(t, path)
+
+	if _, err := ed.Add("api"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := ed.Write(); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	got := read(t, path)
+	if !strings.HasPrefix(got, `workspaces = ["api/vx.toml"]`) {
+		t.Errorf("written file =\n%s\nwant the array ahead of [vault]", got)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	path := writeFixture(t, rootTOML)
+	ed := open(t, path)
+
+	if _, err := ed.Remove("billing"); err == nil || !strings.Contains(err.Error(), "invoices") {
+		t.Errorf("Remove(billing) error = %v, want one naming the service", err)
+	}
+	if _, err := ed.Remove("api"); err == nil {
+		t.Error("Remove(api) expected error for an unknown workspace")
+	}
+
+	entry, err := ed.Remove("web/vx.toml")
+	if err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if entry.Name != "web" {
+		t.Errorf("Remove() = %+v", entry)
+	}
+	if got := ed.List(); len(got) != 1 || got[0].Name != "billing" {
+		t.Errorf("List() after Remove = %+v", got)
+	}
+}
+
+func TestRename(t *testing.T) {
+	path := writeFixture(t, rootTOML, "web", "apps/invoicing")
+	ed := open(t, path)
+
+	old, renamed, services, err := ed.Rename("billing", "apps/invoicing")
+	if err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if old.Name != "billing" || renamed != (Entry{"invoicing", "apps/invoicing/vx.toml"}) {
+		t.Errorf("Rename() = %+v, %+v", old, renamed)
+	}
+	if len(services) != 1 || services[0] != "invoices" {
+		t.Errorf("Rename() services = %v, want [invoices]", services)
+	}
+
+	if _, _, _, err := ed.Rename("invoicing", "web"); err == nil {
+		t.Error("Rename() onto another workspace expected error")
+	}
+
+	if err := ed.Write(); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	got := read(t, path)
+	for _, want := range []string{
+		`workspaces = ["web/vx.toml", "apps/invoicing/vx.toml"]`,
+		`workspace = "invoicing"  # runs the worker`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("written file lacks %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestAutoRefusesEdits(t *testing.T) {
+	root := strings.Replace(rootTOML, `workspaces = ["web/vx.toml", "billing/vx.toml"] # keep short`, `workspaces = "auto"`, 1)
+	path := writeFixture(t, root, "web", "billing")
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "go.work"), []byte("use (\n\t./web\n\t./billing\n)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ed := open(t, path)
+
+	if !ed.Auto() {
+		t.Fatal("Auto() = false, want true")
+	}
+	if _, err := ed.Add("web"); err == nil || !strings.Contains(err.Error(), "auto") {
+		t.Errorf("Add() error = %v, want the auto error", err)
+	}
+	if _, err := ed.Remove("web"); err == nil {
+		t.Error("Remove() should refuse to edit auto workspaces")
+	}
+
+	got, err := Discovered(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("Discovered() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Name != "billing" || got[1].Path != "web/vx.toml" {
+		t.Errorf("Discovered() = %v, want billing and web", got)
+	}
+}